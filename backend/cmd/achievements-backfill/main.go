@@ -0,0 +1,75 @@
+// Comando achievements-backfill: evalúa las reglas de logros contra el
+// historial de los usuarios existentes y desbloquea los que ya cumplían
+// (por ejemplo al agregar una regla nueva o al migrar desde el trigger).
+//
+// Uso:
+//
+//	go run ./cmd/achievements-backfill [-user <id>] [-skip-bonus]
+package main
+
+import (
+	"flag"
+	"log"
+	"strings"
+
+	"github.com/smartstocks/backend/internal/config"
+	"github.com/smartstocks/backend/internal/repository"
+	"github.com/smartstocks/backend/internal/services"
+	"github.com/smartstocks/backend/pkg/database"
+)
+
+func main() {
+	userID := flag.String("user", "", "Procesar solo este usuario")
+	skipBonus := flag.Bool("skip-bonus", false, "No otorgar los tokens de bonus de los logros desbloqueados")
+	flag.Parse()
+
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+
+	mysqlDB, err := database.NewMySQL(&cfg.Database)
+	if err != nil {
+		log.Fatalf("Failed to connect to MySQL: %v", err)
+	}
+	defer mysqlDB.Close()
+
+	rules, err := services.LoadAchievementRules(cfg.Achievements.RulesPath)
+	if err != nil {
+		log.Fatalf("Failed to load achievement rules: %v", err)
+	}
+
+	achievementService := services.NewAchievementService(
+		repository.NewAchievementRepository(mysqlDB.DB),
+		repository.NewTokensRepository(mysqlDB.DB),
+		rules,
+	)
+
+	if *userID != "" {
+		unlocked, err := achievementService.Backfill(*userID, !*skipBonus)
+		if err != nil {
+			log.Fatalf("Failed to backfill user %s: %v", *userID, err)
+		}
+		log.Printf("✅ User %s: %d achievements unlocked %v", *userID, len(unlocked), unlocked)
+		return
+	}
+
+	var users, failed, unlockedTotal int
+	err = achievementService.BackfillAll(!*skipBonus, func(id string, unlocked []string, err error) {
+		users++
+		if err != nil {
+			failed++
+			log.Printf("⚠️  User %s: %v", id, err)
+			return
+		}
+		unlockedTotal += len(unlocked)
+		if len(unlocked) > 0 {
+			log.Printf("🏆 User %s: %s", id, strings.Join(unlocked, ", "))
+		}
+	})
+	if err != nil {
+		log.Fatalf("Backfill interrupted after %d users: %v", users, err)
+	}
+
+	log.Printf("✅ Backfill finished: %d users, %d achievements unlocked, %d errors", users, unlockedTotal, failed)
+}
@@ -1,14 +1,18 @@
 package main
 
 import (
+	"fmt"
 	"log"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
+	"time"
 
 	"github.com/smartstocks/backend/internal/api"
 	"github.com/smartstocks/backend/internal/api/handlers"
 	"github.com/smartstocks/backend/internal/config"
+	"github.com/smartstocks/backend/internal/ranks"
 	"github.com/smartstocks/backend/internal/repository"
 	"github.com/smartstocks/backend/internal/services"
 	"github.com/smartstocks/backend/internal/websocket"
@@ -38,7 +42,10 @@ func main() {
 	defer redisClient.Close()
 
 	// Inicializar JWT Manager
-	jwtManager := jwt.NewJWTManager(cfg.JWT.Secret, cfg.JWT.ExpirationHours)
+	jwtManager, err := newJWTManager(&cfg.JWT)
+	if err != nil {
+		log.Fatalf("Failed to initialize JWT manager: %v", err)
+	}
 
 	// Inicializar WebSocket Manager
 	wsManager := websocket.NewManager()
@@ -47,14 +54,33 @@ func main() {
 	// Inicializar repositorios
 	userRepo := repository.NewUserRepository(mysqlDB.DB)
 	refreshTokenRepo := repository.NewRefreshTokenRepository(mysqlDB.DB)
+	loginAttemptRepo := repository.NewLoginAttemptRepository(mysqlDB.DB)
+	twoFactorRepo := repository.NewTwoFactorRepository(mysqlDB.DB)
+	userIdentityRepo := repository.NewUserIdentityRepository(mysqlDB.DB)
+	dataExportRepo := repository.NewDataExportRepository(mysqlDB.DB)
+	accountDeletionRepo := repository.NewAccountDeletionRepository(mysqlDB.DB)
+	classroomRepo := repository.NewClassroomRepository(mysqlDB.DB)
+	assignmentRepo := repository.NewAssignmentRepository(mysqlDB.DB)
 	schoolRepo := repository.NewSchoolRepository(mysqlDB.DB)
+	analyticsRepo := repository.NewAnalyticsRepository(mysqlDB.DB)
 	quizRepo := repository.NewQuizRepository(mysqlDB.DB)
 	forumRepo := repository.NewForumRepository(mysqlDB.DB)
 	coursesRepo := repository.NewCoursesRepository(mysqlDB.DB)
 	simulatorRepo := repository.NewSimulatorRepository(mysqlDB.DB)
 	pvpRepo := repository.NewPvPRepository(mysqlDB.DB)
 	rankingsRepo := repository.NewRankingsRepository(mysqlDB.DB)
+	seasonRepo := repository.NewSeasonRepository(mysqlDB.DB)
 	tokensRepo := repository.NewTokensRepository(mysqlDB.DB)
+	achievementRepo := repository.NewAchievementRepository(mysqlDB.DB)
+	rankTierRepo := repository.NewRankTierRepository(mysqlDB.DB)
+	dailyRewardRepo := repository.NewDailyRewardRepository(mysqlDB.DB)
+	shopRepo := repository.NewShopRepository(mysqlDB.DB)
+	tokenAdjustmentRepo := repository.NewTokenAdjustmentRepository(mysqlDB.DB)
+	tokenGiftRepo := repository.NewTokenGiftRepository(mysqlDB.DB)
+	accountLinkRepo := repository.NewAccountLinkRepository(mysqlDB.DB)
+	notificationRepo := repository.NewNotificationRepository(mysqlDB.DB)
+	consumableRepo := repository.NewConsumableRepository(mysqlDB.DB)
+	referralRepo := repository.NewReferralRepository(mysqlDB.DB)
 	tournamentsRepo := repository.NewTournamentsRepository(mysqlDB.DB)
 
 	// Inicializar servicios de IA
@@ -66,28 +92,112 @@ func main() {
 	)
 
 	// Inicializar servicios
+	emailService := services.NewEmailService(&cfg.Email)
+	loginProtectionService := services.NewLoginProtectionService(redisClient, &cfg.Login)
+	twoFactorService := services.NewTwoFactorService(twoFactorRepo, userRepo, redisClient)
+
+	// Rankings en vivo sobre sorted sets de Redis
+	leaderboardService := services.NewLeaderboardService(redisClient, rankingsRepo, seasonRepo)
+	if err := leaderboardService.Rebuild(); err != nil {
+		log.Printf("⚠️  Failed to build Redis leaderboards, serving from MySQL cache: %v", err)
+	}
+	go leaderboardService.RunPeriodicRebuild(time.Duration(cfg.Ranking.RebuildIntervalMinutes) * time.Minute)
+
+	schoolService := services.NewSchoolService(schoolRepo, userRepo, leaderboardService)
+
+	// Motor de logros
+	achievementRules, err := services.LoadAchievementRules(cfg.Achievements.RulesPath)
+	if err != nil {
+		log.Fatalf("Failed to load achievement rules: %v", err)
+	}
+	achievementService := services.NewAchievementService(achievementRepo, tokensRepo, achievementRules)
+
+	// Tabla de rangos: se sincroniza con MySQL para los procedimientos
+	rankTiers, err := ranks.Load(cfg.Ranks.TiersPath)
+	if err != nil {
+		log.Fatalf("Failed to load rank tiers: %v", err)
+	}
+	rankTierService := services.NewRankTierService(rankTierRepo, userRepo, rankTiers)
+	if err := rankTierService.SyncTiers(); err != nil {
+		log.Fatalf("Failed to sync rank tiers: %v", err)
+	}
+
+	accountLinkService := services.NewAccountLinkService(accountLinkRepo, userRepo, cfg.TokenGifts.LinkWindowDays)
+	referralService := services.NewReferralService(referralRepo, notificationRepo, accountLinkService, &cfg.Referrals)
+	go referralService.RunDeferredRewards(time.Duration(cfg.Referrals.RecheckIntervalMinutes) * time.Minute)
+
 	authService := services.NewAuthService(
 		userRepo,
 		refreshTokenRepo,
-		schoolRepo,
+		schoolService,
+		loginAttemptRepo,
+		loginProtectionService,
+		twoFactorService,
+		emailService,
+		referralService,
 		jwtManager,
 		cfg.JWT.RefreshTokenExpirationDays,
 	)
 
+	oidcService := services.NewOIDCService(&cfg.OIDC, userIdentityRepo, userRepo, authService, redisClient)
+
+	privacyService := services.NewPrivacyService(
+		dataExportRepo,
+		accountDeletionRepo,
+		userRepo,
+		emailService,
+		redisClient,
+		cfg.Privacy.DeletionCoolingOffDays,
+	)
+
+	classroomService := services.NewClassroomService(classroomRepo, userRepo)
+
+	assignmentService := services.NewAssignmentService(
+		assignmentRepo,
+		classroomService,
+		classroomRepo,
+		coursesRepo,
+		quizRepo,
+		simulatorRepo,
+	)
+
+	analyticsService := services.NewAnalyticsService(analyticsRepo, classroomService, schoolService)
+
+	tokensService := services.NewTokensService(
+		tokensRepo,
+	)
+
+	consumableService := services.NewConsumableService(consumableRepo, tokensService, &cfg.Consumables)
+
 	quizService := services.NewQuizService(
 		quizRepo,
 		userRepo,
 		openAIService,
+		assignmentService,
+		leaderboardService,
+		achievementService,
+		consumableService,
+		referralService,
 	)
 
-	forumService := services.NewForumService(forumRepo)
+	forumService := services.NewForumService(forumRepo, achievementService)
 
-	coursesService := services.NewCoursesService(coursesRepo, userRepo)
+	coursesService := services.NewCoursesService(
+		coursesRepo,
+		userRepo,
+		assignmentService,
+		leaderboardService,
+		achievementService,
+	)
 
 	simulatorService := services.NewSimulatorService(
 		simulatorRepo,
 		userRepo,
 		simulatorAIService,
+		assignmentService,
+		leaderboardService,
+		achievementService,
+		consumableService,
 	)
 
 	pvpService := services.NewPvPService(
@@ -95,46 +205,99 @@ func main() {
 		simulatorRepo,
 		userRepo,
 		simulatorAIService,
+		leaderboardService,
+		achievementService,
+		consumableService,
+	)
+
+	shopService := services.NewShopService(shopRepo, tokensRepo, tokensService)
+
+	tokenAdjustmentService := services.NewTokenAdjustmentService(tokenAdjustmentRepo, tokensRepo, userRepo)
+
+	notificationService := services.NewNotificationService(notificationRepo)
+	tokenGiftService := services.NewTokenGiftService(
+		tokenGiftRepo,
+		userRepo,
+		notificationRepo,
+		accountLinkService,
+		&cfg.TokenGifts,
 	)
 
 	rankingsService := services.NewRankingsService(
 		rankingsRepo,
 		userRepo,
+		schoolRepo,
+		leaderboardService,
+		&cfg.Schools,
+		&cfg.Ranking,
+		achievementService,
+		shopService,
+		redisClient,
 	)
 
-	tokensService := services.NewTokensService(
-		tokensRepo,
-	)
+	seasonService := services.NewSeasonService(seasonRepo, leaderboardService)
+
+	dailyRewardService := services.NewDailyRewardService(dailyRewardRepo, tokensRepo, &cfg.DailyRewards)
 
 	tournamentsService := services.NewTournamentsService(
 		tournamentsRepo,
 		userRepo,
+		rankTiers,
 	)
 
 	// Inicializar handlers
 	authHandler := handlers.NewAuthHandler(authService)
-	userHandler := handlers.NewUserHandler(userRepo, schoolRepo)
+	userHandler := handlers.NewUserHandler(userRepo, schoolRepo, schoolService)
+	twoFactorHandler := handlers.NewTwoFactorHandler(twoFactorService)
+	oidcHandler := handlers.NewOIDCHandler(oidcService)
+	privacyHandler := handlers.NewPrivacyHandler(privacyService)
+	schoolHandler := handlers.NewSchoolHandler(schoolService)
+	classroomHandler := handlers.NewClassroomHandler(classroomService)
+	assignmentHandler := handlers.NewAssignmentHandler(assignmentService)
+	analyticsHandler := handlers.NewAnalyticsHandler(analyticsService)
 	quizHandler := handlers.NewQuizHandler(quizService)
 	forumHandler := handlers.NewForumHandler(forumService)
 	coursesHandler := handlers.NewCoursesHandler(coursesService)
 	simulatorHandler := handlers.NewSimulatorHandler(simulatorService)
 	pvpHandler := handlers.NewPvPHandler(pvpService, wsManager)
-	rankingsHandler := handlers.NewRankingsHandler(rankingsService)
-	tokensHandler := handlers.NewTokensHandler(tokensService)
+	rankingsHandler := handlers.NewRankingsHandler(rankingsService, rankTierService)
+	seasonHandler := handlers.NewSeasonHandler(seasonService)
+	tokensHandler := handlers.NewTokensHandler(
+		tokensService,
+		dailyRewardService,
+		tokenAdjustmentService,
+		tokenGiftService,
+		accountLinkService,
+	)
+	shopHandler := handlers.NewShopHandler(shopService, consumableService)
+	notificationHandler := handlers.NewNotificationHandler(notificationService)
+	referralHandler := handlers.NewReferralHandler(referralService)
 	tournamentsHandler := handlers.NewTournamentsHandler(tournamentsService)
 
 	// Configurar router
 	router := api.NewRouter(
 		authHandler,
 		userHandler,
+		twoFactorHandler,
+		oidcHandler,
+		privacyHandler,
+		schoolHandler,
+		classroomHandler,
+		assignmentHandler,
+		analyticsHandler,
 		quizHandler,
 		forumHandler,
 		coursesHandler,
 		simulatorHandler,
 		pvpHandler,
 		rankingsHandler,
+		seasonHandler,
 		tokensHandler,
+		shopHandler,
+		notificationHandler,
+		referralHandler,
 		tournamentsHandler,
+		userRepo,
 		jwtManager,
 		redisClient,
 		cfg,
@@ -151,6 +314,10 @@ func main() {
 		log.Printf("📊 Environment: %s", cfg.Server.GinMode)
 		log.Printf("✅ MySQL connected to %s", cfg.Database.Host)
 		log.Printf("✅ Redis connected to %s", cfg.Redis.Host)
+		log.Printf("🔑 JWT signing with %s (kid: %s)", cfg.JWT.Algorithm, jwtManager.SigningKeyID())
+		if len(cfg.OIDC.Providers) > 0 {
+			log.Printf("🔐 OIDC providers: %s", strings.Join(oidcService.GetProviders(), ", "))
+		}
 		log.Printf("🔌 WebSocket manager running")
 		log.Printf("🏆 Rankings system enabled")
 		log.Printf("💰 Tokens system enabled")
@@ -179,3 +346,43 @@ func main() {
 	log.Println("✅ Server stopped gracefully")
 	log.Println("========================================")
 }
+
+// newJWTManager construye el manager a partir de la configuración,
+// cargando las claves desde disco cuando el algoritmo es asimétrico
+func newJWTManager(cfg *config.JWTConfig) (*jwt.JWTManager, error) {
+	var signingKey *jwt.Key
+	var err error
+
+	switch cfg.Algorithm {
+	case "", jwt.AlgHS256:
+		signingKey = jwt.NewHMACKey(cfg.KeyID, []byte(cfg.Secret))
+	case jwt.AlgRS256, jwt.AlgEdDSA:
+		if cfg.PrivateKeyFile == "" {
+			return nil, fmt.Errorf("JWT_PRIVATE_KEY_FILE is required for %s", cfg.Algorithm)
+		}
+		signingKey, err = jwt.LoadPrivateKeyFile(cfg.KeyID, cfg.Algorithm, cfg.PrivateKeyFile)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unsupported JWT algorithm: %s", cfg.Algorithm)
+	}
+
+	var verificationKeys []*jwt.Key
+
+	// Claves públicas anteriores (rotación de claves asimétricas)
+	for kid, path := range cfg.VerificationKeyFiles {
+		key, err := jwt.LoadPublicKeyFile(kid, path)
+		if err != nil {
+			return nil, err
+		}
+		verificationKeys = append(verificationKeys, key)
+	}
+
+	// Secretos HMAC anteriores (rotación de HS256 o migración a asimétrico)
+	for kid, secret := range cfg.PreviousSecrets {
+		verificationKeys = append(verificationKeys, jwt.NewHMACKey(kid, []byte(secret)))
+	}
+
+	return jwt.NewJWTManagerWithKeys(signingKey, verificationKeys, cfg.LegacyKeyID, cfg.ExpirationHours)
+}
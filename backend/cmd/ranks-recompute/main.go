@@ -0,0 +1,78 @@
+// Comando ranks-recompute: sincroniza la tabla de rangos con MySQL y
+// recalcula el rango de todos los usuarios con los umbrales actuales,
+// registrando los ascensos y descensos. Correrlo después de cambiar
+// tiers.json o RANK_TIERS_PATH.
+//
+// Uso:
+//
+//	go run ./cmd/ranks-recompute [-dry-run]
+package main
+
+import (
+	"flag"
+	"log"
+
+	"github.com/smartstocks/backend/internal/config"
+	"github.com/smartstocks/backend/internal/models"
+	"github.com/smartstocks/backend/internal/ranks"
+	"github.com/smartstocks/backend/internal/repository"
+	"github.com/smartstocks/backend/internal/services"
+	"github.com/smartstocks/backend/pkg/database"
+)
+
+func main() {
+	dryRun := flag.Bool("dry-run", false, "Mostrar los cambios sin guardarlos")
+	flag.Parse()
+
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+
+	mysqlDB, err := database.NewMySQL(&cfg.Database)
+	if err != nil {
+		log.Fatalf("Failed to connect to MySQL: %v", err)
+	}
+	defer mysqlDB.Close()
+
+	tiers, err := ranks.Load(cfg.Ranks.TiersPath)
+	if err != nil {
+		log.Fatalf("Failed to load rank tiers: %v", err)
+	}
+
+	rankTierService := services.NewRankTierService(
+		repository.NewRankTierRepository(mysqlDB.DB),
+		repository.NewUserRepository(mysqlDB.DB),
+		tiers,
+	)
+
+	if !*dryRun {
+		if err := rankTierService.SyncTiers(); err != nil {
+			log.Fatalf("Failed to sync rank tiers: %v", err)
+		}
+	}
+
+	var promotions, demotions, normalized int
+	updated, err := rankTierService.RecomputeAll(*dryRun, func(user models.UserRankTier, to ranks.Tier, change *ranks.Change) {
+		switch {
+		case change == nil:
+			normalized++
+		case change.Direction == ranks.Promotion:
+			promotions++
+			log.Printf("⬆️  User %s: %s -> %s (%d pts)", user.UserID, user.RankTier, to.Code, user.Smartpoints)
+		default:
+			demotions++
+			log.Printf("⬇️  User %s: %s -> %s (%d pts)", user.UserID, user.RankTier, to.Code, user.Smartpoints)
+		}
+	})
+	if err != nil {
+		log.Fatalf("Recompute interrupted after %d users: %v", updated, err)
+	}
+
+	mode := "Recompute"
+	if *dryRun {
+		mode = "Dry run"
+	}
+	log.Printf("✅ %s finished: %d users updated (%d promotions, %d demotions, %d normalized)",
+		mode, updated, promotions, demotions, normalized)
+}
@@ -1,4 +1,5 @@
 -- Fix: Crear stored procedure join_tournament
+-- Reemplazado por database/migrations/023_token_ledger_integrity_schema.sql (no volver a ejecutar)
 -- Ejecutar: mysql -u root -p smartstocks < database/fix_join_tournament.sql
 
 DELIMITER //
@@ -0,0 +1,28 @@
+-- Smart Stocks Database Schema - MySQL
+-- Fase 9: Seguridad de Autenticación (auditoría de logins)
+
+-- ===========================================
+-- TABLA: login_attempts (Auditoría de intentos de login)
+-- ===========================================
+CREATE TABLE login_attempts (
+    id CHAR(36) PRIMARY KEY DEFAULT (UUID()),
+    user_id CHAR(36) NULL,
+    email VARCHAR(255) NOT NULL,
+    ip_address VARCHAR(45) NOT NULL,
+    user_agent VARCHAR(500),
+    success BOOLEAN NOT NULL DEFAULT FALSE,
+    failure_reason ENUM('invalid_credentials', 'account_locked', 'ip_blocked', 'backoff') NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_login_attempts_user (user_id, created_at DESC),
+    INDEX idx_login_attempts_email (email, created_at DESC),
+    INDEX idx_login_attempts_ip (ip_address, created_at DESC),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE SET NULL
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- ===========================================
+-- EVENT: Limpiar auditoría antigua (90 días)
+-- ===========================================
+CREATE EVENT IF NOT EXISTS cleanup_login_attempts
+ON SCHEDULE EVERY 1 DAY
+DO
+    DELETE FROM login_attempts WHERE created_at < DATE_SUB(NOW(), INTERVAL 90 DAY);
@@ -0,0 +1,18 @@
+-- Smart Stocks Database Schema - MySQL
+-- Fase 11: Login con proveedores OpenID Connect
+
+-- ===========================================
+-- TABLA: user_identities (Cuentas externas vinculadas)
+-- ===========================================
+CREATE TABLE user_identities (
+    id CHAR(36) PRIMARY KEY DEFAULT (UUID()),
+    user_id CHAR(36) NOT NULL,
+    provider VARCHAR(50) NOT NULL,
+    subject VARCHAR(255) NOT NULL,
+    email VARCHAR(255),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    last_login_at TIMESTAMP NULL,
+    UNIQUE KEY unique_provider_subject (provider, subject),
+    INDEX idx_identities_user (user_id),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
@@ -0,0 +1,115 @@
+-- Smart Stocks Database Schema - MySQL
+-- Fase 12: Baja de cuenta y exportación de datos (Ley 25.326)
+
+-- ===========================================
+-- USUARIO CENTINELA: autor de contenido de cuentas eliminadas
+-- ===========================================
+-- Los posts del foro y las partidas compartidas con otros jugadores se
+-- reasignan a este usuario para no romper el historial de terceros.
+INSERT INTO users (id, username, email, password_hash, email_verified)
+VALUES ('00000000-0000-0000-0000-000000000000', 'usuario_eliminado', 'deleted@smartstocks.invalid', '!', TRUE);
+
+-- Sin stats ni tokens: no debe aparecer en rankings ni poder operar
+DELETE FROM user_stats WHERE user_id = '00000000-0000-0000-0000-000000000000';
+DELETE FROM user_tokens WHERE user_id = '00000000-0000-0000-0000-000000000000';
+
+-- ===========================================
+-- TABLA: account_deletion_requests (Solicitudes de baja)
+-- ===========================================
+-- Sin FK a users: el registro sobrevive a la baja como constancia
+-- y no guarda datos personales.
+CREATE TABLE account_deletion_requests (
+    user_id CHAR(36) PRIMARY KEY,
+    status ENUM('pending', 'cancelled', 'completed') NOT NULL DEFAULT 'pending',
+    requested_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    scheduled_for TIMESTAMP NOT NULL,
+    cancelled_at TIMESTAMP NULL,
+    completed_at TIMESTAMP NULL,
+    INDEX idx_deletion_due (status, scheduled_for)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- ===========================================
+-- STORED PROCEDURE: Eliminar cuenta
+-- ===========================================
+DELIMITER //
+CREATE PROCEDURE delete_user_account(IN p_user_id CHAR(36))
+BEGIN
+    DECLARE v_deleted_user CHAR(36) DEFAULT '00000000-0000-0000-0000-000000000000';
+    DECLARE v_email VARCHAR(255);
+
+    DECLARE EXIT HANDLER FOR SQLEXCEPTION
+    BEGIN
+        ROLLBACK;
+        RESIGNAL;
+    END;
+
+    START TRANSACTION;
+
+    -- Subconsulta en lugar de SELECT INTO: un NOT FOUND cortaría el cursor del llamador
+    SET v_email = (SELECT email FROM users WHERE id = p_user_id);
+
+    IF v_email IS NOT NULL AND p_user_id != v_deleted_user THEN
+        -- Anonimizar contenido del foro
+        UPDATE forum_posts SET user_id = v_deleted_user WHERE user_id = p_user_id;
+        UPDATE forum_replies SET user_id = v_deleted_user WHERE user_id = p_user_id;
+
+        -- Conservar partidas del rival con un oponente anónimo
+        UPDATE pvp_matches SET player1_id = v_deleted_user WHERE player1_id = p_user_id;
+        UPDATE pvp_matches SET player2_id = v_deleted_user WHERE player2_id = p_user_id;
+        UPDATE pvp_matches SET winner_id = v_deleted_user WHERE winner_id = p_user_id;
+        UPDATE tournament_matches SET player1_id = v_deleted_user WHERE player1_id = p_user_id;
+        UPDATE tournament_matches SET player2_id = v_deleted_user WHERE player2_id = p_user_id;
+        UPDATE tournament_matches SET winner_id = v_deleted_user WHERE winner_id = p_user_id;
+
+        -- login_attempts usa ON DELETE SET NULL y guarda email/IP
+        DELETE FROM login_attempts WHERE user_id = p_user_id OR email = v_email;
+
+        -- El resto de las tablas se eliminan por ON DELETE CASCADE
+        DELETE FROM users WHERE id = p_user_id;
+    END IF;
+
+    UPDATE account_deletion_requests
+    SET status = 'completed', completed_at = NOW()
+    WHERE user_id = p_user_id;
+
+    COMMIT;
+END//
+DELIMITER ;
+
+-- ===========================================
+-- STORED PROCEDURE: Procesar bajas vencidas
+-- ===========================================
+DELIMITER //
+CREATE PROCEDURE process_account_deletions()
+BEGIN
+    DECLARE done INT DEFAULT FALSE;
+    DECLARE v_user_id CHAR(36);
+
+    DECLARE deletion_cursor CURSOR FOR
+        SELECT user_id FROM account_deletion_requests
+        WHERE status = 'pending' AND scheduled_for <= NOW();
+
+    DECLARE CONTINUE HANDLER FOR NOT FOUND SET done = TRUE;
+
+    OPEN deletion_cursor;
+
+    read_loop: LOOP
+        FETCH deletion_cursor INTO v_user_id;
+        IF done THEN
+            LEAVE read_loop;
+        END IF;
+
+        CALL delete_user_account(v_user_id);
+    END LOOP;
+
+    CLOSE deletion_cursor;
+END//
+DELIMITER ;
+
+-- ===========================================
+-- EVENT: Ejecutar bajas vencidas cada hora
+-- ===========================================
+CREATE EVENT IF NOT EXISTS process_account_deletions_event
+ON SCHEDULE EVERY 1 HOUR
+DO
+    CALL process_account_deletions();
@@ -0,0 +1,44 @@
+-- Smart Stocks Database Schema - MySQL
+-- Fase 13: Aulas de docentes y seguimiento de alumnos
+
+-- ===========================================
+-- ROLES DE USUARIO
+-- ===========================================
+-- Los docentes se asignan manualmente (o desde la administración del colegio)
+ALTER TABLE users
+    ADD COLUMN role ENUM('student', 'teacher', 'admin') NOT NULL DEFAULT 'student' AFTER school_id,
+    ADD INDEX idx_users_role (role);
+
+-- ===========================================
+-- TABLA: classrooms (Aulas)
+-- ===========================================
+CREATE TABLE classrooms (
+    id CHAR(36) PRIMARY KEY DEFAULT (UUID()),
+    teacher_id CHAR(36) NOT NULL,
+    school_id CHAR(36) NULL,
+    name VARCHAR(100) NOT NULL,
+    description TEXT,
+    join_code VARCHAR(10) NOT NULL,
+    is_active BOOLEAN DEFAULT TRUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    UNIQUE KEY unique_join_code (join_code),
+    INDEX idx_classrooms_teacher (teacher_id),
+    INDEX idx_classrooms_school (school_id),
+    FOREIGN KEY (teacher_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (school_id) REFERENCES schools(id) ON DELETE SET NULL
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- ===========================================
+-- TABLA: classroom_members (Alumnos de cada aula)
+-- ===========================================
+CREATE TABLE classroom_members (
+    id CHAR(36) PRIMARY KEY DEFAULT (UUID()),
+    classroom_id CHAR(36) NOT NULL,
+    user_id CHAR(36) NOT NULL,
+    joined_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE KEY unique_classroom_member (classroom_id, user_id),
+    INDEX idx_members_user (user_id),
+    FOREIGN KEY (classroom_id) REFERENCES classrooms(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
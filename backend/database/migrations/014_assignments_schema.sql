@@ -0,0 +1,44 @@
+-- Smart Stocks Database Schema - MySQL
+-- Fase 14: Tareas asignadas por docentes
+
+-- ===========================================
+-- TABLA: assignments (Tareas de un aula)
+-- ===========================================
+-- target_id apunta a courses, lessons, quizzes o simulator_scenarios según
+-- assignment_type, por eso no tiene FK.
+CREATE TABLE assignments (
+    id CHAR(36) PRIMARY KEY DEFAULT (UUID()),
+    classroom_id CHAR(36) NOT NULL,
+    teacher_id CHAR(36) NOT NULL,
+    title VARCHAR(200) NOT NULL,
+    instructions TEXT,
+    assignment_type ENUM('course', 'lesson', 'quiz', 'scenario') NOT NULL,
+    target_id CHAR(36) NOT NULL,
+    due_at TIMESTAMP NOT NULL,
+    allow_late BOOLEAN DEFAULT TRUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    INDEX idx_assignments_classroom (classroom_id, due_at),
+    INDEX idx_assignments_target (assignment_type, target_id),
+    FOREIGN KEY (classroom_id) REFERENCES classrooms(id) ON DELETE CASCADE,
+    FOREIGN KEY (teacher_id) REFERENCES users(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- ===========================================
+-- TABLA: assignment_submissions (Entregas de alumnos)
+-- ===========================================
+-- Las entregas se registran automáticamente al completar la actividad.
+-- "pending" y "missing" se calculan: no hay fila hasta que el alumno entrega.
+CREATE TABLE assignment_submissions (
+    id CHAR(36) PRIMARY KEY DEFAULT (UUID()),
+    assignment_id CHAR(36) NOT NULL,
+    user_id CHAR(36) NOT NULL,
+    status ENUM('submitted', 'late') NOT NULL,
+    score INT NOT NULL DEFAULT 0,
+    submitted_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    UNIQUE KEY unique_assignment_user (assignment_id, user_id),
+    INDEX idx_submissions_user (user_id),
+    FOREIGN KEY (assignment_id) REFERENCES assignments(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
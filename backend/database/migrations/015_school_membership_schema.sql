@@ -0,0 +1,65 @@
+-- Smart Stocks Database Schema - MySQL
+-- Fase 15: Membresía verificada de colegios y administración de colegios
+
+-- ===========================================
+-- SCHOOLS: código de acceso y fecha de edición
+-- ===========================================
+ALTER TABLE schools
+    ADD COLUMN join_code VARCHAR(12) NULL AFTER location,
+    ADD COLUMN updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP AFTER created_at,
+    ADD UNIQUE KEY unique_school_join_code (join_code);
+
+-- ===========================================
+-- TABLA: school_email_domains (Dominios de email institucionales)
+-- ===========================================
+-- Un usuario con email verificado de uno de estos dominios se aprueba solo.
+CREATE TABLE school_email_domains (
+    domain VARCHAR(255) PRIMARY KEY,
+    school_id CHAR(36) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_school_domains_school (school_id),
+    FOREIGN KEY (school_id) REFERENCES schools(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- ===========================================
+-- TABLA: school_admins (Administradores de cada colegio)
+-- ===========================================
+CREATE TABLE school_admins (
+    school_id CHAR(36) NOT NULL,
+    user_id CHAR(36) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (school_id, user_id),
+    INDEX idx_school_admins_user (user_id),
+    FOREIGN KEY (school_id) REFERENCES schools(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- ===========================================
+-- TABLA: school_memberships (Solicitudes de membresía)
+-- ===========================================
+-- Un colegio por usuario. users.school_id solo se completa cuando la
+-- membresía está aprobada, así los rankings por colegio (que leen
+-- users.school_id) únicamente incluyen miembros verificados.
+CREATE TABLE school_memberships (
+    user_id CHAR(36) PRIMARY KEY,
+    school_id CHAR(36) NOT NULL,
+    status ENUM('pending', 'approved', 'rejected') NOT NULL DEFAULT 'pending',
+    method ENUM('request', 'join_code', 'email_domain', 'admin', 'legacy') NOT NULL,
+    requested_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    reviewed_by CHAR(36) NULL,
+    reviewed_at TIMESTAMP NULL,
+    INDEX idx_memberships_school (school_id, status),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (school_id) REFERENCES schools(id) ON DELETE CASCADE,
+    FOREIGN KEY (reviewed_by) REFERENCES users(id) ON DELETE SET NULL
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- Los colegios elegidos antes de la verificación quedan como solicitudes
+-- "legacy" pendientes: nadie cuenta para un colegio hasta que un
+-- administrador lo apruebe (o entre con el código o el dominio de email)
+INSERT INTO school_memberships (user_id, school_id, status, method, requested_at)
+SELECT id, school_id, 'pending', 'legacy', created_at
+FROM users
+WHERE school_id IS NOT NULL;
+
+UPDATE users SET school_id = NULL WHERE school_id IS NOT NULL;
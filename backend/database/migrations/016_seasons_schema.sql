@@ -0,0 +1,205 @@
+-- Smart Stocks Database Schema - MySQL
+-- Fase 16: Temporadas competitivas con reinicio parcial e historial
+
+-- ===========================================
+-- TABLA: seasons (Temporadas)
+-- ===========================================
+-- Solo una temporada puede estar activa. Al vencer se archivan las
+-- posiciones finales, se reparten los premios y los puntos de temporada
+-- se reducen al carryover_percent para la siguiente.
+CREATE TABLE seasons (
+    id CHAR(36) PRIMARY KEY DEFAULT (UUID()),
+    name VARCHAR(100) NOT NULL,
+    starts_at TIMESTAMP NOT NULL,
+    ends_at TIMESTAMP NOT NULL,
+    carryover_percent INT NOT NULL DEFAULT 50,
+    status ENUM('scheduled', 'active', 'finalized') NOT NULL DEFAULT 'scheduled',
+    activated_at TIMESTAMP NULL,
+    finalized_at TIMESTAMP NULL,
+    created_by CHAR(36) NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_seasons_status (status, starts_at),
+    INDEX idx_seasons_dates (starts_at, ends_at),
+    FOREIGN KEY (created_by) REFERENCES users(id) ON DELETE SET NULL
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- ===========================================
+-- TABLA: season_rewards (Premios en tokens por posición)
+-- ===========================================
+CREATE TABLE season_rewards (
+    id CHAR(36) PRIMARY KEY DEFAULT (UUID()),
+    season_id CHAR(36) NOT NULL,
+    position_from INT NOT NULL,
+    position_to INT NOT NULL,
+    token_reward INT NOT NULL,
+    INDEX idx_season_rewards_season (season_id),
+    FOREIGN KEY (season_id) REFERENCES seasons(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- ===========================================
+-- TABLA: season_standings (Posiciones finales archivadas)
+-- ===========================================
+CREATE TABLE season_standings (
+    season_id CHAR(36) NOT NULL,
+    user_id CHAR(36) NOT NULL,
+    final_position INT NOT NULL,
+    season_points INT NOT NULL,
+    rank_tier VARCHAR(20) NOT NULL,
+    tokens_awarded INT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (season_id, user_id),
+    INDEX idx_standings_position (season_id, final_position),
+    INDEX idx_standings_user (user_id),
+    FOREIGN KEY (season_id) REFERENCES seasons(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- ===========================================
+-- USER_STATS: puntos de la temporada en curso
+-- ===========================================
+-- smartpoints sigue siendo el total histórico; season_points es lo que
+-- ordena el ranking de la temporada.
+ALTER TABLE user_stats
+    ADD COLUMN season_points INT NOT NULL DEFAULT 0 AFTER smartpoints,
+    ADD INDEX idx_user_stats_season_points (season_points DESC);
+
+-- ===========================================
+-- TOKEN_TRANSACTIONS: premios de temporada
+-- ===========================================
+ALTER TABLE token_transactions
+    MODIFY COLUMN transaction_type ENUM(
+        'tournament_reward', 'tournament_entry',
+        'daily_bonus', 'achievement_bonus',
+        'admin_grant', 'purchase', 'refund',
+        'season_reward'
+    ) NOT NULL;
+
+-- ===========================================
+-- TRIGGER: Acumular puntos de temporada
+-- ===========================================
+-- Cada variación de smartpoints (quizzes, simulador, cursos, PvP) se
+-- replica en season_points mientras haya una temporada en curso. Así no
+-- hace falta tocar los procedimientos que otorgan puntos.
+DELIMITER //
+CREATE TRIGGER accumulate_season_points
+BEFORE UPDATE ON user_stats
+FOR EACH ROW
+BEGIN
+    IF NEW.smartpoints <> OLD.smartpoints
+       AND EXISTS (SELECT 1 FROM seasons WHERE status = 'active' AND ends_at > NOW()) THEN
+        SET NEW.season_points = GREATEST(0, OLD.season_points + NEW.smartpoints - OLD.smartpoints);
+    END IF;
+END//
+DELIMITER ;
+
+-- ===========================================
+-- STORED PROCEDURE: Cierre y apertura de temporadas
+-- ===========================================
+DELIMITER //
+CREATE PROCEDURE process_season_rollover()
+BEGIN
+    DECLARE done INT DEFAULT FALSE;
+    DECLARE v_season_id CHAR(36);
+    DECLARE v_season_name VARCHAR(100);
+    DECLARE v_carryover INT;
+    DECLARE v_user_id CHAR(36);
+    DECLARE v_position INT;
+    DECLARE v_tokens INT;
+
+    DECLARE reward_cursor CURSOR FOR
+        SELECT ss.user_id, ss.final_position, ss.tokens_awarded
+        FROM season_standings ss
+        JOIN user_tokens ut ON ut.user_id = ss.user_id
+        WHERE ss.season_id = v_season_id AND ss.tokens_awarded > 0
+        ORDER BY ss.final_position;
+
+    DECLARE CONTINUE HANDLER FOR NOT FOUND SET done = TRUE;
+
+    DECLARE EXIT HANDLER FOR SQLEXCEPTION
+    BEGIN
+        ROLLBACK;
+        RESIGNAL;
+    END;
+
+    SET v_season_id = (
+        SELECT id FROM seasons
+        WHERE status = 'active' AND ends_at <= NOW()
+        ORDER BY ends_at
+        LIMIT 1
+    );
+
+    IF v_season_id IS NOT NULL THEN
+        SELECT name, carryover_percent INTO v_season_name, v_carryover
+        FROM seasons WHERE id = v_season_id;
+
+        START TRANSACTION;
+
+        -- Archivar posiciones finales con su tier y premio
+        INSERT INTO season_standings (season_id, user_id, final_position, season_points, rank_tier, tokens_awarded)
+        SELECT
+            v_season_id,
+            ranked.user_id,
+            ranked.position,
+            ranked.season_points,
+            calculate_rank_tier(ranked.season_points),
+            COALESCE((
+                SELECT MAX(sr.token_reward) FROM season_rewards sr
+                WHERE sr.season_id = v_season_id
+                AND ranked.position BETWEEN sr.position_from AND sr.position_to
+            ), 0)
+        FROM (
+            SELECT us.user_id, us.season_points,
+                ROW_NUMBER() OVER (ORDER BY us.season_points DESC, u.created_at ASC) AS position
+            FROM user_stats us
+            JOIN users u ON u.id = us.user_id
+            WHERE us.season_points > 0
+        ) ranked;
+
+        -- Repartir premios
+        OPEN reward_cursor;
+        read_loop: LOOP
+            FETCH reward_cursor INTO v_user_id, v_position, v_tokens;
+            IF done THEN
+                LEAVE read_loop;
+            END IF;
+
+            CALL add_tokens(
+                v_user_id,
+                v_tokens,
+                'season_reward',
+                CONCAT('Season reward - ', v_season_name, ' - Position: ', v_position),
+                v_season_id
+            );
+        END LOOP;
+        CLOSE reward_cursor;
+
+        -- Reinicio parcial: se conserva un porcentaje para la próxima temporada
+        UPDATE user_stats
+        SET season_points = FLOOR(season_points * v_carryover / 100)
+        WHERE season_points > 0;
+
+        UPDATE seasons
+        SET status = 'finalized', finalized_at = NOW()
+        WHERE id = v_season_id;
+
+        COMMIT;
+    END IF;
+
+    -- Activar la próxima temporada si ya empezó
+    IF NOT EXISTS (SELECT 1 FROM seasons WHERE status = 'active') THEN
+        UPDATE seasons
+        SET status = 'active', activated_at = NOW()
+        WHERE status = 'scheduled' AND starts_at <= NOW() AND ends_at > NOW()
+        ORDER BY starts_at
+        LIMIT 1;
+    END IF;
+END//
+DELIMITER ;
+
+-- ===========================================
+-- EVENT: Revisar temporadas cada 5 minutos
+-- ===========================================
+CREATE EVENT IF NOT EXISTS process_season_rollover_event
+ON SCHEDULE EVERY 5 MINUTE
+DO
+    CALL process_season_rollover();
@@ -0,0 +1,247 @@
+-- Smart Stocks Database Schema - MySQL
+-- Fase 17: Registro de puntos para rankings diarios, semanales y mensuales
+
+-- ===========================================
+-- TABLA: points_ledger (Movimientos de smartpoints)
+-- ===========================================
+-- Una fila por cada variación de smartpoints. Los rankings por ventana
+-- suman los puntos registrados desde el inicio de la ventana; el total
+-- histórico sigue en user_stats.smartpoints.
+CREATE TABLE points_ledger (
+    id CHAR(36) PRIMARY KEY DEFAULT (UUID()),
+    user_id CHAR(36) NOT NULL,
+    source ENUM('quiz', 'simulator', 'course', 'pvp') NOT NULL,
+    points INT NOT NULL,
+    reference_id CHAR(36) NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_points_ledger_created (created_at, user_id),
+    INDEX idx_points_ledger_user (user_id, created_at),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- ===========================================
+-- BACKFILL: Historial disponible
+-- ===========================================
+-- Quizzes, simulador y cursos guardan los puntos otorgados. Las partidas
+-- PvP anteriores no los registran, así que no se pueden reconstruir.
+INSERT INTO points_ledger (id, user_id, source, points, reference_id, created_at)
+SELECT UUID(), user_id, 'quiz', points_earned, id, completed_at
+FROM quiz_attempts
+WHERE points_earned <> 0;
+
+INSERT INTO points_ledger (id, user_id, source, points, reference_id, created_at)
+SELECT UUID(), user_id, 'simulator', points_earned, id, created_at
+FROM simulator_attempts
+WHERE was_correct = TRUE AND points_earned <> 0;
+
+INSERT INTO points_ledger (id, user_id, source, points, reference_id, created_at)
+SELECT UUID(), ucp.user_id, 'course', c.points_reward, ucp.course_id, ucp.completed_at
+FROM user_course_progress ucp
+JOIN courses c ON c.id = ucp.course_id
+WHERE ucp.is_completed = TRUE AND ucp.completed_at IS NOT NULL AND c.points_reward <> 0;
+
+-- ===========================================
+-- STORED PROCEDURE: Stats después de quiz (con registro de puntos)
+-- ===========================================
+DROP PROCEDURE IF EXISTS update_user_stats_after_quiz;
+
+DELIMITER //
+CREATE PROCEDURE update_user_stats_after_quiz(
+    IN p_user_id CHAR(36),
+    IN p_attempt_id CHAR(36),
+    IN p_points_earned INT
+)
+BEGIN
+    -- Actualizar smartpoints y total de quizzes
+    UPDATE user_stats
+    SET smartpoints = smartpoints + p_points_earned,
+        total_quizzes_completed = total_quizzes_completed + 1,
+        updated_at = NOW()
+    WHERE user_id = p_user_id;
+
+    IF p_points_earned <> 0 THEN
+        INSERT INTO points_ledger (id, user_id, source, points, reference_id)
+        VALUES (UUID(), p_user_id, 'quiz', p_points_earned, p_attempt_id);
+    END IF;
+
+    -- Actualizar rango
+    CALL update_user_rank(p_user_id);
+END//
+DELIMITER ;
+
+-- ===========================================
+-- STORED PROCEDURE: Intento de simulador (con registro de puntos)
+-- ===========================================
+DROP PROCEDURE IF EXISTS record_simulator_attempt;
+
+DELIMITER //
+CREATE PROCEDURE record_simulator_attempt(
+    IN p_user_id CHAR(36),
+    IN p_scenario_id CHAR(36),
+    IN p_difficulty VARCHAR(10),
+    IN p_user_decision VARCHAR(10),
+    IN p_was_correct BOOLEAN,
+    IN p_points_earned INT,
+    IN p_time_taken INT
+)
+BEGIN
+    DECLARE v_attempt_id CHAR(36);
+    SET v_attempt_id = UUID();
+
+    -- Insertar intento
+    INSERT INTO simulator_attempts (
+        id, user_id, scenario_id, difficulty,
+        user_decision, was_correct, points_earned, time_taken_seconds
+    ) VALUES (
+        v_attempt_id, p_user_id, p_scenario_id, p_difficulty,
+        p_user_decision, p_was_correct, p_points_earned, p_time_taken
+    );
+
+    -- Registrar cooldown
+    INSERT INTO daily_simulator_cooldowns (id, user_id, difficulty, last_attempt_date)
+    VALUES (UUID(), p_user_id, p_difficulty, CURDATE())
+    ON DUPLICATE KEY UPDATE
+        attempts_count = attempts_count + 1,
+        updated_at = NOW();
+
+    -- Si fue correcto, actualizar stats del usuario
+    IF p_was_correct THEN
+        UPDATE user_stats
+        SET smartpoints = smartpoints + p_points_earned,
+            total_simulator_games = total_simulator_games + 1,
+            updated_at = NOW()
+        WHERE user_id = p_user_id;
+
+        IF p_points_earned <> 0 THEN
+            INSERT INTO points_ledger (id, user_id, source, points, reference_id)
+            VALUES (UUID(), p_user_id, 'simulator', p_points_earned, v_attempt_id);
+        END IF;
+
+        -- Actualizar rango
+        CALL update_user_rank(p_user_id);
+    ELSE
+        -- Solo incrementar contador de simulaciones
+        UPDATE user_stats
+        SET total_simulator_games = total_simulator_games + 1,
+            updated_at = NOW()
+        WHERE user_id = p_user_id;
+    END IF;
+END//
+DELIMITER ;
+
+-- ===========================================
+-- STORED PROCEDURE: Completar lección (con registro de puntos)
+-- ===========================================
+DROP PROCEDURE IF EXISTS complete_lesson;
+
+DELIMITER //
+CREATE PROCEDURE complete_lesson(
+    IN p_user_id CHAR(36),
+    IN p_lesson_id CHAR(36),
+    IN p_quiz_score INT
+)
+BEGIN
+    DECLARE v_course_id CHAR(36);
+    DECLARE v_total_lessons INT;
+    DECLARE v_completed_lessons INT;
+    DECLARE v_course_points INT;
+
+    -- Obtener course_id
+    SELECT course_id INTO v_course_id FROM lessons WHERE id = p_lesson_id;
+
+    -- Marcar leccion como completada
+    INSERT INTO user_lesson_progress (id, user_id, lesson_id, is_completed, completed_at, quiz_score)
+    VALUES (UUID(), p_user_id, p_lesson_id, TRUE, NOW(), p_quiz_score)
+    ON DUPLICATE KEY UPDATE
+        is_completed = TRUE,
+        completed_at = NOW(),
+        quiz_score = COALESCE(p_quiz_score, quiz_score);
+
+    -- Iniciar progreso del curso si no existe
+    INSERT IGNORE INTO user_course_progress (id, user_id, course_id)
+    VALUES (UUID(), p_user_id, v_course_id);
+
+    -- Contar lecciones totales y completadas
+    SELECT COUNT(*) INTO v_total_lessons
+    FROM lessons WHERE course_id = v_course_id AND is_active = TRUE;
+
+    SELECT COUNT(*) INTO v_completed_lessons
+    FROM user_lesson_progress ulp
+    JOIN lessons l ON ulp.lesson_id = l.id
+    WHERE ulp.user_id = p_user_id AND l.course_id = v_course_id AND ulp.is_completed = TRUE;
+
+    -- Si completo todas las lecciones, marcar curso como completado
+    IF v_completed_lessons = v_total_lessons THEN
+        UPDATE user_course_progress
+        SET is_completed = TRUE, completed_at = NOW()
+        WHERE user_id = p_user_id AND course_id = v_course_id;
+
+        -- Dar puntos por completar el curso
+        SELECT points_reward INTO v_course_points FROM courses WHERE id = v_course_id;
+
+        UPDATE user_stats
+        SET smartpoints = smartpoints + v_course_points,
+            updated_at = NOW()
+        WHERE user_id = p_user_id;
+
+        IF v_course_points <> 0 THEN
+            INSERT INTO points_ledger (id, user_id, source, points, reference_id)
+            VALUES (UUID(), p_user_id, 'course', v_course_points, v_course_id);
+        END IF;
+
+        CALL update_user_rank(p_user_id);
+    END IF;
+END//
+DELIMITER ;
+
+-- ===========================================
+-- STORED PROCEDURE: Stats PvP (con registro de puntos)
+-- ===========================================
+-- El perdedor no baja de 0: se registra lo que realmente perdió.
+DROP PROCEDURE IF EXISTS update_pvp_stats;
+
+DELIMITER //
+CREATE PROCEDURE update_pvp_stats(
+    IN p_match_id CHAR(36),
+    IN p_winner_id CHAR(36),
+    IN p_loser_id CHAR(36),
+    IN p_winner_points INT,
+    IN p_is_win BOOLEAN
+)
+BEGIN
+    DECLARE v_loser_points INT DEFAULT 0;
+
+    SELECT smartpoints INTO v_loser_points
+    FROM user_stats WHERE user_id = p_loser_id;
+
+    -- Actualizar stats del ganador
+    UPDATE user_stats
+    SET
+        smartpoints = smartpoints + p_winner_points,
+        total_wins = total_wins + 1,
+        win_streak = win_streak + 1,
+        rank_tier = calculate_rank_tier(smartpoints + p_winner_points),
+        updated_at = CURRENT_TIMESTAMP
+    WHERE user_id = p_winner_id;
+
+    -- Actualizar stats del perdedor
+    UPDATE user_stats
+    SET
+        smartpoints = GREATEST(0, smartpoints - 100),
+        total_losses = total_losses + 1,
+        win_streak = 0,
+        rank_tier = calculate_rank_tier(GREATEST(0, smartpoints - 100)),
+        updated_at = CURRENT_TIMESTAMP
+    WHERE user_id = p_loser_id;
+
+    IF p_winner_points <> 0 THEN
+        INSERT INTO points_ledger (id, user_id, source, points, reference_id)
+        VALUES (UUID(), p_winner_id, 'pvp', p_winner_points, p_match_id);
+    END IF;
+
+    IF LEAST(100, v_loser_points) > 0 THEN
+        INSERT INTO points_ledger (id, user_id, source, points, reference_id)
+        VALUES (UUID(), p_loser_id, 'pvp', -LEAST(100, v_loser_points), p_match_id);
+    END IF;
+END//
+DELIMITER ;
@@ -0,0 +1,94 @@
+-- Smart Stocks Database Schema - MySQL
+-- Fase 18: Historial de posiciones en los rankings
+
+-- ===========================================
+-- TABLA: leaderboard_snapshots (Foto diaria de posiciones)
+-- ===========================================
+-- Una fila por jugador y día con su posición global y en su colegio al
+-- comenzar el día. Sirve para mostrar cuántos puestos subió o bajó desde la
+-- última foto y para graficar la evolución de cada jugador.
+CREATE TABLE leaderboard_snapshots (
+    user_id CHAR(36) NOT NULL,
+    snapshot_date DATE NOT NULL,
+    global_position INT NOT NULL,
+    school_id CHAR(36) NULL,
+    school_position INT NULL,
+    smartpoints INT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, snapshot_date),
+    INDEX idx_leaderboard_snapshots_date (snapshot_date),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (school_id) REFERENCES schools(id) ON DELETE SET NULL
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- ===========================================
+-- STORED PROCEDURE: Tomar la foto del día
+-- ===========================================
+-- Mismo orden que leaderboard_cache (smartpoints y antigüedad de la cuenta).
+-- Si ya existe la foto del día no hace nada, así el EVENT puede correr cada
+-- hora y recuperar el día si el servidor estuvo caído a medianoche.
+DROP PROCEDURE IF EXISTS take_leaderboard_snapshot;
+
+DELIMITER //
+CREATE PROCEDURE take_leaderboard_snapshot()
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM leaderboard_snapshots WHERE snapshot_date = CURDATE()) THEN
+        INSERT IGNORE INTO leaderboard_snapshots (
+            user_id, snapshot_date, global_position, school_id, school_position, smartpoints
+        )
+        SELECT
+            u.id,
+            CURDATE(),
+            ROW_NUMBER() OVER (ORDER BY us.smartpoints DESC, u.created_at ASC),
+            u.school_id,
+            CASE
+                WHEN u.school_id IS NOT NULL
+                THEN ROW_NUMBER() OVER (PARTITION BY u.school_id ORDER BY us.smartpoints DESC, u.created_at ASC)
+            END,
+            us.smartpoints
+        FROM users u
+        JOIN user_stats us ON us.user_id = u.id;
+    END IF;
+END//
+DELIMITER ;
+
+-- ===========================================
+-- STORED PROCEDURE: Compactar fotos antiguas
+-- ===========================================
+-- Conserva todas las fotos de los últimos p_daily_days días, después solo
+-- la de los lunes (una por semana) y borra las de más de p_retention_days.
+DROP PROCEDURE IF EXISTS compact_leaderboard_snapshots;
+
+DELIMITER //
+CREATE PROCEDURE compact_leaderboard_snapshots(
+    IN p_daily_days INT,
+    IN p_retention_days INT
+)
+BEGIN
+    DELETE FROM leaderboard_snapshots
+    WHERE snapshot_date < DATE_SUB(CURDATE(), INTERVAL p_retention_days DAY);
+
+    DELETE FROM leaderboard_snapshots
+    WHERE snapshot_date < DATE_SUB(CURDATE(), INTERVAL p_daily_days DAY)
+      AND WEEKDAY(snapshot_date) <> 0;
+END//
+DELIMITER ;
+
+-- ===========================================
+-- EVENTS: Foto diaria y compactación
+-- ===========================================
+CREATE EVENT IF NOT EXISTS take_leaderboard_snapshot_event
+ON SCHEDULE EVERY 1 HOUR
+DO
+    CALL take_leaderboard_snapshot();
+
+-- Diarias por 90 días, semanales hasta 2 años
+CREATE EVENT IF NOT EXISTS compact_leaderboard_snapshots_event
+ON SCHEDULE EVERY 1 DAY
+DO
+    CALL compact_leaderboard_snapshots(90, 730);
+
+-- ===========================================
+-- Foto inicial
+-- ===========================================
+CALL take_leaderboard_snapshot();
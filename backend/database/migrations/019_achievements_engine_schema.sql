@@ -0,0 +1,31 @@
+-- Smart Stocks Database Schema - MySQL
+-- Fase 19: Motor de logros configurable
+
+-- ===========================================
+-- TRIGGER: Se reemplaza por el motor de logros
+-- ===========================================
+-- Las reglas ahora se definen en achievement_rules.json y las evalúa el
+-- backend ante cada evento (quiz, simulador, PvP, cursos y foro).
+DROP TRIGGER IF EXISTS check_achievements_after_stats_update;
+
+-- ===========================================
+-- TABLA: user_achievements (Tipos definidos por configuración)
+-- ===========================================
+-- El ENUM obligaba a migrar para agregar un logro nuevo
+ALTER TABLE user_achievements
+    MODIFY achievement_type VARCHAR(50) NOT NULL;
+
+-- ===========================================
+-- TABLA: user_achievement_progress (Progreso hacia cada logro)
+-- ===========================================
+-- Mejor valor alcanzado en la métrica de cada logro. Para métricas que
+-- pueden bajar (smartpoints, racha) conserva el máximo histórico; para las
+-- de menor es mejor (tiempo de respuesta) el mínimo.
+CREATE TABLE user_achievement_progress (
+    user_id CHAR(36) NOT NULL,
+    achievement_type VARCHAR(50) NOT NULL,
+    best_value INT NOT NULL,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, achievement_type),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
@@ -0,0 +1,241 @@
+-- Smart Stocks Database Schema - MySQL
+-- Fase 20: Tabla de rangos configurable
+
+-- ===========================================
+-- TABLA: rank_tiers (Umbrales de cada rango)
+-- ===========================================
+-- La tabla la define el backend (internal/ranks/tiers.json o RANK_TIERS_PATH)
+-- y la sincroniza al iniciar; los procedimientos solo la leen. Reemplaza los
+-- umbrales fijos de update_user_rank y calculate_rank_tier, que no
+-- coincidían entre sí y mezclaban nombres en inglés y en español. Los
+-- rank_tier guardados pasan a ser códigos (bronze_1 ... master, los que ya
+-- usa el frontend); el nombre visible de cada idioma lo resuelve el backend.
+CREATE TABLE rank_tiers (
+    code VARCHAR(20) PRIMARY KEY,
+    position INT NOT NULL,
+    group_code VARCHAR(20) NOT NULL,
+    group_level INT NOT NULL,
+    min_points INT NOT NULL,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    UNIQUE KEY uk_rank_tiers_position (position),
+    INDEX idx_rank_tiers_points (min_points)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+INSERT INTO rank_tiers (code, position, group_code, group_level, min_points) VALUES
+    ('bronze_1', 1, 'bronze', 1, 0),
+    ('bronze_2', 2, 'bronze', 1, 400),
+    ('bronze_3', 3, 'bronze', 1, 1600),
+    ('silver_1', 4, 'silver', 2, 3200),
+    ('silver_2', 5, 'silver', 2, 4400),
+    ('silver_3', 6, 'silver', 2, 6400),
+    ('gold_1', 7, 'gold', 3, 8400),
+    ('gold_2', 8, 'gold', 3, 10000),
+    ('gold_3', 9, 'gold', 3, 12400),
+    ('master', 10, 'master', 4, 14400);
+
+-- ===========================================
+-- TABLA: rank_tier_changes (Ascensos y descensos)
+-- ===========================================
+-- Una fila por cada cambio de rango de un jugador, para el historial y
+-- las notificaciones. La normalización de nombres anteriores no se registra.
+CREATE TABLE rank_tier_changes (
+    id CHAR(36) PRIMARY KEY DEFAULT (UUID()),
+    user_id CHAR(36) NOT NULL,
+    from_tier VARCHAR(20) NOT NULL,
+    to_tier VARCHAR(20) NOT NULL,
+    direction ENUM('promotion', 'demotion') NOT NULL,
+    smartpoints INT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_rank_tier_changes_user (user_id, created_at),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- ===========================================
+-- FUNCIÓN: Rango según puntaje (lee rank_tiers)
+-- ===========================================
+DROP FUNCTION IF EXISTS calculate_rank_tier;
+
+DELIMITER //
+CREATE FUNCTION calculate_rank_tier(points INT)
+RETURNS VARCHAR(20)
+READS SQL DATA
+BEGIN
+    RETURN COALESCE(
+        (SELECT code FROM rank_tiers WHERE min_points <= points ORDER BY position DESC LIMIT 1),
+        (SELECT code FROM rank_tiers ORDER BY position LIMIT 1)
+    );
+END//
+DELIMITER ;
+
+-- ===========================================
+-- STORED PROCEDURE: Actualizar rango (con registro de cambios)
+-- ===========================================
+DROP PROCEDURE IF EXISTS update_user_rank;
+
+DELIMITER //
+CREATE PROCEDURE update_user_rank(IN p_user_id CHAR(36))
+BEGIN
+    DECLARE v_points INT;
+    DECLARE v_old_rank VARCHAR(20);
+    DECLARE v_new_rank VARCHAR(20);
+    DECLARE v_old_position INT;
+    DECLARE v_new_position INT;
+
+    SELECT smartpoints, rank_tier INTO v_points, v_old_rank
+    FROM user_stats
+    WHERE user_id = p_user_id;
+
+    SET v_new_rank = calculate_rank_tier(v_points);
+
+    IF v_points IS NOT NULL AND NOT (v_old_rank <=> v_new_rank) THEN
+        UPDATE user_stats
+        SET rank_tier = v_new_rank
+        WHERE user_id = p_user_id;
+
+        SET v_old_position = (SELECT position FROM rank_tiers WHERE code = v_old_rank);
+        SET v_new_position = (SELECT position FROM rank_tiers WHERE code = v_new_rank);
+
+        IF v_old_position IS NOT NULL AND v_new_position IS NOT NULL THEN
+            INSERT INTO rank_tier_changes (id, user_id, from_tier, to_tier, direction, smartpoints)
+            VALUES (
+                UUID(), p_user_id, v_old_rank, v_new_rank,
+                IF(v_new_position > v_old_position, 'promotion', 'demotion'),
+                v_points
+            );
+        END IF;
+    END IF;
+END//
+DELIMITER ;
+
+-- ===========================================
+-- STORED PROCEDURE: Stats PvP (rango con la tabla común)
+-- ===========================================
+-- Antes calculaba el rango con calculate_rank_tier directamente y no
+-- quedaba registro de los ascensos por PvP.
+DROP PROCEDURE IF EXISTS update_pvp_stats;
+
+DELIMITER //
+CREATE PROCEDURE update_pvp_stats(
+    IN p_match_id CHAR(36),
+    IN p_winner_id CHAR(36),
+    IN p_loser_id CHAR(36),
+    IN p_winner_points INT,
+    IN p_is_win BOOLEAN
+)
+BEGIN
+    DECLARE v_loser_points INT DEFAULT 0;
+
+    SELECT smartpoints INTO v_loser_points
+    FROM user_stats WHERE user_id = p_loser_id;
+
+    -- Actualizar stats del ganador
+    UPDATE user_stats
+    SET
+        smartpoints = smartpoints + p_winner_points,
+        total_wins = total_wins + 1,
+        win_streak = win_streak + 1,
+        updated_at = CURRENT_TIMESTAMP
+    WHERE user_id = p_winner_id;
+
+    -- Actualizar stats del perdedor
+    UPDATE user_stats
+    SET
+        smartpoints = GREATEST(0, smartpoints - 100),
+        total_losses = total_losses + 1,
+        win_streak = 0,
+        updated_at = CURRENT_TIMESTAMP
+    WHERE user_id = p_loser_id;
+
+    IF p_winner_points <> 0 THEN
+        INSERT INTO points_ledger (id, user_id, source, points, reference_id)
+        VALUES (UUID(), p_winner_id, 'pvp', p_winner_points, p_match_id);
+    END IF;
+
+    IF LEAST(100, v_loser_points) > 0 THEN
+        INSERT INTO points_ledger (id, user_id, source, points, reference_id)
+        VALUES (UUID(), p_loser_id, 'pvp', -LEAST(100, v_loser_points), p_match_id);
+    END IF;
+
+    CALL update_user_rank(p_winner_id);
+    CALL update_user_rank(p_loser_id);
+END//
+DELIMITER ;
+
+-- ===========================================
+-- TRIGGER: Crear user_stats con el rango inicial de la tabla
+-- ===========================================
+DROP TRIGGER IF EXISTS after_user_insert;
+
+DELIMITER //
+CREATE TRIGGER after_user_insert
+AFTER INSERT ON users
+FOR EACH ROW
+BEGIN
+    INSERT INTO user_stats (user_id, smartpoints, rank_tier)
+    VALUES (NEW.id, 0, calculate_rank_tier(0));
+END//
+DELIMITER ;
+
+-- ===========================================
+-- FUNCIÓN: Verificar requisito de rango (por posición en la tabla)
+-- ===========================================
+-- Un requisito que no está en la tabla no restringe; un rango de jugador
+-- desconocido no cumple ningún requisito.
+DROP FUNCTION IF EXISTS meets_rank_requirement;
+
+DELIMITER //
+CREATE FUNCTION meets_rank_requirement(
+    user_rank VARCHAR(20),
+    required_rank VARCHAR(20)
+) RETURNS BOOLEAN
+READS SQL DATA
+BEGIN
+    RETURN COALESCE((SELECT position FROM rank_tiers WHERE code = user_rank), 0)
+        >= COALESCE((SELECT position FROM rank_tiers WHERE code = required_rank), 0);
+END//
+DELIMITER ;
+
+-- ===========================================
+-- DATOS: Pasar los nombres anteriores a códigos
+-- ===========================================
+-- "Bronze N" (update_user_rank) numeraba de abajo hacia arriba, como los
+-- códigos; los nombres en español (calculate_rank_tier, torneos) de arriba
+-- hacia abajo. Los rangos de los jugadores se recalculan con la tabla nueva.
+CREATE TABLE legacy_rank_tiers (
+    name VARCHAR(20) PRIMARY KEY,
+    code VARCHAR(20) NOT NULL
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+INSERT INTO legacy_rank_tiers (name, code) VALUES
+    ('Bronze 1', 'bronze_1'), ('Bronze 2', 'bronze_2'), ('Bronze 3', 'bronze_3'),
+    ('Bronce 3', 'bronze_1'), ('Bronce 2', 'bronze_2'), ('Bronce 1', 'bronze_3'),
+    ('Plata 3', 'silver_1'), ('Plata 2', 'silver_2'), ('Plata 1', 'silver_3'),
+    ('Oro 3', 'gold_1'), ('Oro 2', 'gold_2'), ('Oro 1', 'gold_3'),
+    ('Maestro', 'master');
+
+UPDATE tournaments t
+JOIN legacy_rank_tiers l ON l.name = t.min_rank_required
+SET t.min_rank_required = l.code;
+
+UPDATE season_standings ss
+JOIN legacy_rank_tiers l ON l.name = ss.rank_tier
+SET ss.rank_tier = l.code;
+
+DROP TABLE legacy_rank_tiers;
+
+UPDATE user_stats
+SET rank_tier = calculate_rank_tier(smartpoints), updated_at = updated_at;
+
+UPDATE pvp_queue q
+JOIN user_stats us ON us.user_id = q.user_id
+SET q.rank_tier = us.rank_tier;
+
+UPDATE leaderboard_cache lc
+JOIN user_stats us ON us.user_id = lc.user_id
+SET lc.rank_tier = us.rank_tier;
+
+ALTER TABLE user_stats
+    MODIFY rank_tier VARCHAR(20) DEFAULT 'bronze_1';
+
+ALTER TABLE tournaments
+    MODIFY min_rank_required VARCHAR(20) DEFAULT 'bronze_1';
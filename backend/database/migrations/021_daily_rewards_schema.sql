@@ -0,0 +1,63 @@
+-- Smart Stocks Database Schema - MySQL
+-- Fase 21: Recompensa diaria y rachas de actividad
+
+-- ===========================================
+-- TABLA: user_login_streaks (Racha de check-ins diarios)
+-- ===========================================
+-- last_check_in_date es la fecha local del usuario (según timezone) del
+-- último check-in. Los protectores de racha cubren días sin check-in.
+CREATE TABLE user_login_streaks (
+    user_id CHAR(36) PRIMARY KEY,
+    current_streak INT NOT NULL DEFAULT 0,
+    longest_streak INT NOT NULL DEFAULT 0,
+    freezes_available INT NOT NULL DEFAULT 0,
+    total_check_ins INT NOT NULL DEFAULT 0,
+    last_check_in_date DATE NULL,
+    last_check_in_at TIMESTAMP NULL,
+    timezone VARCHAR(64) NOT NULL,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    INDEX idx_login_streaks_last (last_check_in_date),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- ===========================================
+-- TABLA: daily_check_ins (Un check-in por usuario y día)
+-- ===========================================
+-- La clave (user_id, check_in_date) evita cobrar dos veces el mismo día.
+-- token_transactions.reference_id apunta al id del check-in.
+CREATE TABLE daily_check_ins (
+    id CHAR(36) NOT NULL,
+    user_id CHAR(36) NOT NULL,
+    check_in_date DATE NOT NULL,
+    streak INT NOT NULL,
+    reward_tokens INT NOT NULL,
+    bonus_tokens INT NOT NULL DEFAULT 0,
+    freezes_used INT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, check_in_date),
+    UNIQUE KEY uk_daily_check_ins_id (id),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- ===========================================
+-- STORED PROCEDURE: Cortar rachas vencidas
+-- ===========================================
+-- La racha se corta al hacer el próximo check-in, pero así el perfil no
+-- muestra rachas que ya se perdieron. Se deja un día de margen porque la
+-- fecha del servidor puede diferir de la fecha local del usuario.
+DROP PROCEDURE IF EXISTS expire_login_streaks;
+
+DELIMITER //
+CREATE PROCEDURE expire_login_streaks()
+BEGIN
+    UPDATE user_login_streaks
+    SET current_streak = 0
+    WHERE current_streak > 0
+      AND last_check_in_date < DATE_SUB(UTC_DATE(), INTERVAL 2 + freezes_available DAY);
+END//
+DELIMITER ;
+
+CREATE EVENT IF NOT EXISTS expire_login_streaks_event
+ON SCHEDULE EVERY 1 HOUR
+DO
+    CALL expire_login_streaks();
@@ -0,0 +1,61 @@
+-- Smart Stocks Database Schema - MySQL
+-- Fase 22: Tienda de cosméticos con tokens
+
+-- ===========================================
+-- TABLA: shop_items (Catálogo de cosméticos)
+-- ===========================================
+-- available_from / available_until delimitan la ventana de venta (NULL = sin
+-- límite). Los ítems comprados se pueden seguir usando fuera de la ventana.
+-- style_value guarda el valor del cosmético que no es una imagen (ej. el
+-- color del nombre o el id del tema del gráfico).
+CREATE TABLE shop_items (
+    id CHAR(36) PRIMARY KEY,
+    item_type ENUM('avatar_frame', 'profile_badge', 'chart_theme', 'name_color') NOT NULL,
+    code VARCHAR(50) NOT NULL UNIQUE,
+    name VARCHAR(100) NOT NULL,
+    description TEXT NULL,
+    price INT NOT NULL,
+    asset_url VARCHAR(500) NULL,
+    style_value VARCHAR(100) NULL,
+    available_from TIMESTAMP NULL,
+    available_until TIMESTAMP NULL,
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    sort_order INT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    INDEX idx_shop_items_type (item_type, is_active, sort_order),
+    CONSTRAINT chk_shop_items_price CHECK (price > 0)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- ===========================================
+-- TABLA: user_inventory (Cosméticos comprados)
+-- ===========================================
+-- Un ítem por usuario. Solo uno equipado por tipo (lo garantiza el servicio).
+-- token_transactions.reference_id de la compra apunta a shop_items.id.
+CREATE TABLE user_inventory (
+    id CHAR(36) PRIMARY KEY,
+    user_id CHAR(36) NOT NULL,
+    item_id CHAR(36) NOT NULL,
+    item_type ENUM('avatar_frame', 'profile_badge', 'chart_theme', 'name_color') NOT NULL,
+    price_paid INT NOT NULL,
+    is_equipped BOOLEAN NOT NULL DEFAULT FALSE,
+    purchased_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE KEY uk_user_inventory_item (user_id, item_id),
+    INDEX idx_user_inventory_equipped (user_id, is_equipped),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (item_id) REFERENCES shop_items(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- ===========================================
+-- DATOS INICIALES: Catálogo
+-- ===========================================
+INSERT INTO shop_items (id, item_type, code, name, description, price, asset_url, style_value, sort_order) VALUES
+(UUID(), 'avatar_frame', 'frame_bull', 'Marco Toro', 'Marco dorado para el avatar', 300, '/cosmetics/frames/bull.png', NULL, 1),
+(UUID(), 'avatar_frame', 'frame_bear', 'Marco Oso', 'Marco plateado para el avatar', 300, '/cosmetics/frames/bear.png', NULL, 2),
+(UUID(), 'avatar_frame', 'frame_candles', 'Marco Velas', 'Marco de velas japonesas', 500, '/cosmetics/frames/candles.png', NULL, 3),
+(UUID(), 'profile_badge', 'badge_early_investor', 'Inversor Temprano', 'Insignia para el perfil', 200, '/cosmetics/badges/early_investor.png', NULL, 1),
+(UUID(), 'profile_badge', 'badge_diamond_hands', 'Manos de Diamante', 'Insignia para el perfil', 400, '/cosmetics/badges/diamond_hands.png', NULL, 2),
+(UUID(), 'chart_theme', 'theme_dark_terminal', 'Terminal', 'Tema oscuro para los gráficos del simulador', 250, NULL, 'dark_terminal', 1),
+(UUID(), 'chart_theme', 'theme_neon', 'Neón', 'Tema neón para los gráficos del simulador', 350, NULL, 'neon', 2),
+(UUID(), 'name_color', 'color_emerald', 'Esmeralda', 'Color del nombre en rankings y perfil', 150, NULL, '#10B981', 1),
+(UUID(), 'name_color', 'color_gold', 'Dorado', 'Color del nombre en rankings y perfil', 250, NULL, '#F59E0B', 2);
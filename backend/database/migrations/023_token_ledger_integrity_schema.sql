@@ -0,0 +1,375 @@
+-- Smart Stocks Database Schema - MySQL
+-- Fase 23: Integridad del ledger de tokens (idempotencia, bloqueo y conciliación)
+
+-- ===========================================
+-- TOKEN_TRANSACTIONS: clave de idempotencia
+-- ===========================================
+-- Cada transacción lleva una clave única: si un reintento repite la clave la
+-- transacción no se vuelve a aplicar. Las transacciones existentes usan su id.
+ALTER TABLE token_transactions
+    ADD COLUMN idempotency_key VARCHAR(150) NULL AFTER reference_id;
+
+UPDATE token_transactions SET idempotency_key = id WHERE idempotency_key IS NULL;
+
+ALTER TABLE token_transactions
+    MODIFY COLUMN idempotency_key VARCHAR(150) NOT NULL DEFAULT (UUID()),
+    ADD UNIQUE KEY uk_token_transactions_idempotency (idempotency_key);
+
+-- ===========================================
+-- USER_TOKENS: saldos no negativos
+-- ===========================================
+-- Si la migración falla acá hay saldos negativos: conciliarlos antes.
+ALTER TABLE user_tokens
+    ADD CONSTRAINT chk_user_tokens_balance CHECK (balance >= 0),
+    ADD CONSTRAINT chk_user_tokens_totals CHECK (total_earned >= 0 AND total_spent >= 0);
+
+-- ===========================================
+-- STORED PROCEDURE: Acreditar tokens
+-- ===========================================
+-- Pensado para correr dentro de una transacción: el FOR UPDATE bloquea el
+-- saldo hasta el commit, así dos acreditaciones con la misma clave no pasan
+-- las dos el control de duplicados. p_status: 'applied' o 'duplicate'.
+DROP PROCEDURE IF EXISTS credit_tokens;
+
+DELIMITER //
+CREATE PROCEDURE credit_tokens(
+    IN p_user_id CHAR(36),
+    IN p_amount INT,
+    IN p_transaction_type VARCHAR(50),
+    IN p_description TEXT,
+    IN p_reference_id CHAR(36),
+    IN p_idempotency_key VARCHAR(150),
+    OUT p_status VARCHAR(20)
+)
+BEGIN
+    DECLARE current_balance INT;
+
+    IF p_amount <= 0 THEN
+        SIGNAL SQLSTATE '45000' SET MESSAGE_TEXT = 'Token amount must be positive';
+    END IF;
+
+    SELECT balance INTO current_balance
+    FROM user_tokens
+    WHERE user_id = p_user_id
+    FOR UPDATE;
+
+    IF current_balance IS NULL THEN
+        SIGNAL SQLSTATE '45000' SET MESSAGE_TEXT = 'User tokens not found';
+    END IF;
+
+    IF EXISTS (SELECT 1 FROM token_transactions WHERE idempotency_key = p_idempotency_key) THEN
+        SET p_status = 'duplicate';
+    ELSE
+        UPDATE user_tokens
+        SET
+            balance = current_balance + p_amount,
+            total_earned = total_earned + p_amount,
+            last_transaction_at = CURRENT_TIMESTAMP,
+            updated_at = CURRENT_TIMESTAMP
+        WHERE user_id = p_user_id;
+
+        INSERT INTO token_transactions (
+            id, user_id, transaction_type, amount, balance_after,
+            description, reference_id, idempotency_key
+        ) VALUES (
+            UUID(), p_user_id, p_transaction_type, p_amount, current_balance + p_amount,
+            p_description, p_reference_id, p_idempotency_key
+        );
+
+        SET p_status = 'applied';
+    END IF;
+END//
+DELIMITER ;
+
+-- ===========================================
+-- STORED PROCEDURE: Debitar tokens
+-- ===========================================
+-- Igual que credit_tokens. p_status: 'applied', 'duplicate' o 'insufficient'.
+DROP PROCEDURE IF EXISTS debit_tokens;
+
+DELIMITER //
+CREATE PROCEDURE debit_tokens(
+    IN p_user_id CHAR(36),
+    IN p_amount INT,
+    IN p_transaction_type VARCHAR(50),
+    IN p_description TEXT,
+    IN p_reference_id CHAR(36),
+    IN p_idempotency_key VARCHAR(150),
+    OUT p_status VARCHAR(20)
+)
+BEGIN
+    DECLARE current_balance INT;
+
+    IF p_amount <= 0 THEN
+        SIGNAL SQLSTATE '45000' SET MESSAGE_TEXT = 'Token amount must be positive';
+    END IF;
+
+    SELECT balance INTO current_balance
+    FROM user_tokens
+    WHERE user_id = p_user_id
+    FOR UPDATE;
+
+    IF EXISTS (SELECT 1 FROM token_transactions WHERE idempotency_key = p_idempotency_key) THEN
+        SET p_status = 'duplicate';
+    ELSEIF current_balance IS NULL OR current_balance < p_amount THEN
+        SET p_status = 'insufficient';
+    ELSE
+        UPDATE user_tokens
+        SET
+            balance = current_balance - p_amount,
+            total_spent = total_spent + p_amount,
+            last_transaction_at = CURRENT_TIMESTAMP,
+            updated_at = CURRENT_TIMESTAMP
+        WHERE user_id = p_user_id;
+
+        INSERT INTO token_transactions (
+            id, user_id, transaction_type, amount, balance_after,
+            description, reference_id, idempotency_key
+        ) VALUES (
+            UUID(), p_user_id, p_transaction_type, -p_amount, current_balance - p_amount,
+            p_description, p_reference_id, p_idempotency_key
+        );
+
+        SET p_status = 'applied';
+    END IF;
+END//
+DELIMITER ;
+
+-- ===========================================
+-- STORED PROCEDURES: add_tokens / subtract_tokens
+-- ===========================================
+-- Se mantienen para los procedimientos que ya los usan (temporadas, torneos).
+-- La clave se deriva del tipo, el usuario y la referencia: volver a pagar el
+-- premio de una temporada o cobrar dos veces una inscripción no tiene efecto.
+DROP PROCEDURE IF EXISTS add_tokens;
+DROP PROCEDURE IF EXISTS subtract_tokens;
+
+DELIMITER //
+CREATE PROCEDURE add_tokens(
+    IN p_user_id CHAR(36),
+    IN p_amount INT,
+    IN p_transaction_type VARCHAR(50),
+    IN p_description TEXT,
+    IN p_reference_id CHAR(36)
+)
+BEGIN
+    DECLARE v_status VARCHAR(20);
+
+    CALL credit_tokens(
+        p_user_id, p_amount, p_transaction_type, p_description, p_reference_id,
+        CONCAT(p_transaction_type, ':', p_user_id, ':', COALESCE(p_reference_id, UUID())),
+        v_status
+    );
+END//
+
+CREATE PROCEDURE subtract_tokens(
+    IN p_user_id CHAR(36),
+    IN p_amount INT,
+    IN p_transaction_type VARCHAR(50),
+    IN p_description TEXT,
+    IN p_reference_id CHAR(36),
+    OUT success BOOLEAN
+)
+BEGIN
+    DECLARE v_status VARCHAR(20);
+
+    CALL debit_tokens(
+        p_user_id, p_amount, p_transaction_type, p_description, p_reference_id,
+        CONCAT(p_transaction_type, ':', p_user_id, ':', COALESCE(p_reference_id, UUID())),
+        v_status
+    );
+
+    SET success = (v_status = 'applied');
+END//
+DELIMITER ;
+
+-- ===========================================
+-- STORED PROCEDURE: join_tournament
+-- ===========================================
+-- Cobra la inscripción con debit_tokens (saldo bloqueado y clave por torneo)
+-- en lugar de actualizar user_tokens directamente.
+DROP PROCEDURE IF EXISTS join_tournament;
+
+DELIMITER //
+CREATE PROCEDURE join_tournament(
+    IN p_tournament_id CHAR(36),
+    IN p_user_id CHAR(36),
+    OUT success BOOLEAN,
+    OUT error_message VARCHAR(255)
+)
+BEGIN
+    DECLARE entry_fee INT;
+    DECLARE max_participants INT;
+    DECLARE current_count INT;
+    DECLARE tournament_status VARCHAR(20);
+    DECLARE v_status VARCHAR(20) DEFAULT 'applied';
+
+    -- Bloquear el torneo para que dos inscripciones no superen el cupo
+    SELECT t.entry_fee, t.max_participants, t.current_participants, t.status
+    INTO entry_fee, max_participants, current_count, tournament_status
+    FROM tournaments t
+    WHERE t.id = p_tournament_id
+    FOR UPDATE;
+
+    SET success = FALSE;
+    SET error_message = NULL;
+
+    IF entry_fee IS NULL THEN
+        SET error_message = 'Tournament not found';
+    ELSEIF tournament_status != 'registration' THEN
+        SET error_message = 'Tournament is not in registration phase';
+    ELSEIF current_count >= max_participants THEN
+        SET error_message = 'Tournament is full';
+    ELSEIF EXISTS (SELECT 1 FROM tournament_participants WHERE tournament_id = p_tournament_id AND user_id = p_user_id) THEN
+        SET error_message = 'Already registered in this tournament';
+    ELSE
+        IF entry_fee > 0 THEN
+            CALL debit_tokens(
+                p_user_id,
+                entry_fee,
+                'tournament_entry',
+                'Entry fee for tournament',
+                p_tournament_id,
+                CONCAT('tournament_entry:', p_user_id, ':', p_tournament_id),
+                v_status
+            );
+        END IF;
+
+        IF v_status = 'insufficient' THEN
+            SET error_message = 'Insufficient tokens';
+        ELSE
+            INSERT INTO tournament_participants (
+                id, tournament_id, user_id, current_score, current_position
+            ) VALUES (
+                UUID(), p_tournament_id, p_user_id, 0, 0
+            );
+
+            UPDATE tournaments
+            SET current_participants = current_participants + 1
+            WHERE id = p_tournament_id;
+
+            SET success = TRUE;
+        END IF;
+    END IF;
+END//
+DELIMITER ;
+
+-- ===========================================
+-- TABLA: token_reconciliation_runs (Ejecuciones de la conciliación)
+-- ===========================================
+CREATE TABLE token_reconciliation_runs (
+    id CHAR(36) PRIMARY KEY,
+    triggered_by ENUM('job', 'admin') NOT NULL,
+    requested_by CHAR(36) NULL,
+    repair BOOLEAN NOT NULL DEFAULT FALSE,
+    accounts_checked INT NOT NULL DEFAULT 0,
+    drift_count INT NOT NULL DEFAULT 0,
+    repaired_count INT NOT NULL DEFAULT 0,
+    started_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    finished_at TIMESTAMP NULL,
+    INDEX idx_reconciliation_runs_started (started_at DESC),
+    FOREIGN KEY (requested_by) REFERENCES users(id) ON DELETE SET NULL
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- ===========================================
+-- TABLA: token_balance_drifts (Diferencias encontradas)
+-- ===========================================
+-- stored_* son los valores de user_tokens; ledger_* los recalculados desde
+-- token_transactions.
+CREATE TABLE token_balance_drifts (
+    run_id CHAR(36) NOT NULL,
+    user_id CHAR(36) NOT NULL,
+    stored_balance INT NOT NULL,
+    ledger_balance INT NOT NULL,
+    stored_earned INT NOT NULL,
+    ledger_earned INT NOT NULL,
+    stored_spent INT NOT NULL,
+    ledger_spent INT NOT NULL,
+    repaired BOOLEAN NOT NULL DEFAULT FALSE,
+    PRIMARY KEY (run_id, user_id),
+    INDEX idx_balance_drifts_user (user_id),
+    FOREIGN KEY (run_id) REFERENCES token_reconciliation_runs(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- ===========================================
+-- STORED PROCEDURE: Conciliar saldos con el ledger
+-- ===========================================
+-- Recalcula saldo, total ganado y total gastado desde token_transactions y
+-- registra las cuentas que no coinciden. Con p_repair corrige user_tokens
+-- (el ledger manda), salvo que el ledger dé un saldo negativo: esas quedan
+-- solo reportadas para revisión manual.
+DROP PROCEDURE IF EXISTS reconcile_token_balances;
+
+DELIMITER //
+CREATE PROCEDURE reconcile_token_balances(
+    IN p_run_id CHAR(36),
+    IN p_triggered_by VARCHAR(10),
+    IN p_requested_by CHAR(36),
+    IN p_repair BOOLEAN
+)
+BEGIN
+    DECLARE EXIT HANDLER FOR SQLEXCEPTION
+    BEGIN
+        ROLLBACK;
+        RESIGNAL;
+    END;
+
+    INSERT INTO token_reconciliation_runs (id, triggered_by, requested_by, repair)
+    VALUES (p_run_id, p_triggered_by, p_requested_by, p_repair);
+
+    -- INSERT ... SELECT deja bloqueadas las filas leídas hasta el commit: no
+    -- entran transacciones nuevas entre la detección y la corrección
+    START TRANSACTION;
+
+    INSERT INTO token_balance_drifts (
+        run_id, user_id, stored_balance, ledger_balance,
+        stored_earned, ledger_earned, stored_spent, ledger_spent
+    )
+    SELECT
+        p_run_id, ut.user_id, ut.balance, COALESCE(l.balance, 0),
+        ut.total_earned, COALESCE(l.earned, 0), ut.total_spent, COALESCE(l.spent, 0)
+    FROM user_tokens ut
+    LEFT JOIN (
+        SELECT user_id,
+            SUM(amount) AS balance,
+            SUM(GREATEST(amount, 0)) AS earned,
+            SUM(GREATEST(-amount, 0)) AS spent
+        FROM token_transactions
+        GROUP BY user_id
+    ) l ON l.user_id = ut.user_id
+    WHERE ut.balance <> COALESCE(l.balance, 0)
+       OR ut.total_earned <> COALESCE(l.earned, 0)
+       OR ut.total_spent <> COALESCE(l.spent, 0);
+
+    IF p_repair THEN
+        UPDATE user_tokens ut
+        JOIN token_balance_drifts d ON d.user_id = ut.user_id AND d.run_id = p_run_id
+        SET ut.balance = d.ledger_balance,
+            ut.total_earned = d.ledger_earned,
+            ut.total_spent = d.ledger_spent
+        WHERE d.ledger_balance >= 0;
+
+        UPDATE token_balance_drifts
+        SET repaired = TRUE
+        WHERE run_id = p_run_id AND ledger_balance >= 0;
+    END IF;
+
+    UPDATE token_reconciliation_runs
+    SET accounts_checked = (SELECT COUNT(*) FROM user_tokens),
+        drift_count = (SELECT COUNT(*) FROM token_balance_drifts WHERE run_id = p_run_id),
+        repaired_count = (SELECT COUNT(*) FROM token_balance_drifts WHERE run_id = p_run_id AND repaired),
+        finished_at = NOW()
+    WHERE id = p_run_id;
+
+    COMMIT;
+END//
+DELIMITER ;
+
+-- ===========================================
+-- EVENT: Conciliación diaria (solo reporta)
+-- ===========================================
+CREATE EVENT IF NOT EXISTS reconcile_token_balances_event
+ON SCHEDULE EVERY 1 DAY
+DO
+    CALL reconcile_token_balances(UUID(), 'job', NULL, FALSE);
@@ -0,0 +1,40 @@
+-- Smart Stocks Database Schema - MySQL
+-- Fase 24: Ajustes manuales de tokens (otorgar, descontar, devolver)
+
+-- ===========================================
+-- TOKEN_TRANSACTIONS: descuentos de administración
+-- ===========================================
+ALTER TABLE token_transactions
+    MODIFY COLUMN transaction_type ENUM(
+        'tournament_reward', 'tournament_entry',
+        'daily_bonus', 'achievement_bonus',
+        'admin_grant', 'purchase', 'refund',
+        'season_reward', 'admin_deduct'
+    ) NOT NULL;
+
+-- ===========================================
+-- TABLA: token_adjustments (Auditoría de ajustes manuales)
+-- ===========================================
+-- Cada ajuste apunta a la transacción que generó. Las devoluciones además
+-- apuntan a la transacción devuelta: el índice único impide devolverla dos
+-- veces. admin_id queda en NULL si se elimina la cuenta del administrador.
+CREATE TABLE token_adjustments (
+    id CHAR(36) PRIMARY KEY,
+    admin_id CHAR(36) NULL,
+    user_id CHAR(36) NOT NULL,
+    action ENUM('grant', 'deduct', 'refund') NOT NULL,
+    amount INT NOT NULL,
+    reason VARCHAR(500) NOT NULL,
+    transaction_id CHAR(36) NOT NULL,
+    refunded_transaction_id CHAR(36) NULL,
+    batch_id CHAR(36) NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE KEY uk_token_adjustments_refunded (refunded_transaction_id),
+    INDEX idx_token_adjustments_user (user_id, created_at DESC),
+    INDEX idx_token_adjustments_admin (admin_id, created_at DESC),
+    INDEX idx_token_adjustments_batch (batch_id),
+    FOREIGN KEY (admin_id) REFERENCES users(id) ON DELETE SET NULL,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (transaction_id) REFERENCES token_transactions(id) ON DELETE CASCADE,
+    CONSTRAINT chk_token_adjustments_amount CHECK (amount > 0)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
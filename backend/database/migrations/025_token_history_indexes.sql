@@ -0,0 +1,11 @@
+-- Smart Stocks Database Schema - MySQL
+-- Fase 25: Historial de tokens paginado y filtrable
+
+-- ===========================================
+-- TOKEN_TRANSACTIONS: índices del historial
+-- ===========================================
+-- El historial se pagina con cursor por (created_at, id) descendente y se
+-- filtra por tipo y rango de fechas.
+ALTER TABLE token_transactions
+    ADD INDEX idx_transactions_user_history (user_id, created_at DESC, id DESC),
+    ADD INDEX idx_transactions_user_type (user_id, transaction_type, created_at DESC);
@@ -0,0 +1,99 @@
+-- Smart Stocks Database Schema - MySQL
+-- Fase 26: Regalos de tokens entre alumnos, cuentas vinculadas y notificaciones
+
+-- ===========================================
+-- TOKEN_TRANSACTIONS: regalos enviados y recibidos
+-- ===========================================
+ALTER TABLE token_transactions
+    MODIFY COLUMN transaction_type ENUM(
+        'tournament_reward', 'tournament_entry',
+        'daily_bonus', 'achievement_bonus',
+        'admin_grant', 'purchase', 'refund',
+        'season_reward', 'admin_deduct',
+        'gift_sent', 'gift_received'
+    ) NOT NULL;
+
+-- ===========================================
+-- TABLA: token_gifts (Regalos de tokens)
+-- ===========================================
+-- Cada regalo genera un débito (gift_sent) y un crédito (gift_received) con
+-- reference_id = token_gifts.id. Si se elimina una de las cuentas el regalo
+-- queda registrado para la otra.
+CREATE TABLE token_gifts (
+    id CHAR(36) PRIMARY KEY,
+    sender_id CHAR(36) NULL,
+    recipient_id CHAR(36) NULL,
+    amount INT NOT NULL,
+    message VARCHAR(140) NULL,
+    sender_transaction_id CHAR(36) NOT NULL,
+    recipient_transaction_id CHAR(36) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_token_gifts_sender (sender_id, created_at DESC),
+    INDEX idx_token_gifts_recipient (recipient_id, created_at DESC),
+    FOREIGN KEY (sender_id) REFERENCES users(id) ON DELETE SET NULL,
+    FOREIGN KEY (recipient_id) REFERENCES users(id) ON DELETE SET NULL,
+    CONSTRAINT chk_token_gifts_amount CHECK (amount > 0)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- ===========================================
+-- TABLA: account_links (Cuentas marcadas como vinculadas)
+-- ===========================================
+-- Pares de cuentas que un administrador marcó a mano como de la misma
+-- persona. Las que comparten dispositivo en logins recientes se detectan al
+-- transferir, sin guardarse acá. Entre cuentas vinculadas no se permiten
+-- transferencias. Un vínculo descartado (status = 'dismissed') permite
+-- transferir aunque compartan dispositivo (ej. hermanos con la misma
+-- computadora). user_a_id es siempre el menor de los dos ids.
+CREATE TABLE account_links (
+    id CHAR(36) PRIMARY KEY,
+    user_a_id CHAR(36) NOT NULL,
+    user_b_id CHAR(36) NOT NULL,
+    reason ENUM('same_device', 'manual') NOT NULL,
+    status ENUM('linked', 'dismissed') NOT NULL DEFAULT 'linked',
+    notes VARCHAR(500) NULL,
+    created_by CHAR(36) NULL,
+    reviewed_by CHAR(36) NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    UNIQUE KEY uk_account_links_pair (user_a_id, user_b_id),
+    INDEX idx_account_links_user_b (user_b_id),
+    INDEX idx_account_links_status (status, created_at DESC),
+    FOREIGN KEY (user_a_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_b_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (created_by) REFERENCES users(id) ON DELETE SET NULL,
+    FOREIGN KEY (reviewed_by) REFERENCES users(id) ON DELETE SET NULL,
+    CONSTRAINT chk_account_links_order CHECK (user_a_id < user_b_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- Detección de dispositivo compartido: cada login guarda la cookie de
+-- dispositivo del navegador. La IP no sirve: en un colegio es la misma para
+-- todos los alumnos.
+ALTER TABLE login_attempts
+    ADD COLUMN device_id CHAR(36) NULL AFTER user_agent,
+    ADD INDEX idx_login_attempts_device (device_id, user_id, success, created_at);
+
+-- ===========================================
+-- TABLA: notifications (Notificaciones in-app)
+-- ===========================================
+CREATE TABLE notifications (
+    id CHAR(36) PRIMARY KEY,
+    user_id CHAR(36) NOT NULL,
+    type VARCHAR(50) NOT NULL,
+    title VARCHAR(200) NOT NULL,
+    body VARCHAR(500) NOT NULL,
+    reference_id CHAR(36) NULL,
+    read_at TIMESTAMP NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_notifications_user (user_id, created_at DESC),
+    INDEX idx_notifications_unread (user_id, read_at),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- ===========================================
+-- EVENT: Limpiar notificaciones leídas (90 días)
+-- ===========================================
+CREATE EVENT IF NOT EXISTS cleanup_read_notifications
+ON SCHEDULE EVERY 1 DAY
+DO
+    DELETE FROM notifications
+    WHERE read_at IS NOT NULL AND read_at < DATE_SUB(NOW(), INTERVAL 90 DAY);
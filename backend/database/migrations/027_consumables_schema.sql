@@ -0,0 +1,51 @@
+-- Smart Stocks Database Schema - MySQL
+-- Fase 27: Consumibles pagados con tokens (pistas, intentos extra, segunda oportunidad)
+
+-- ===========================================
+-- TABLA: consumable_purchases (Consumibles comprados)
+-- ===========================================
+-- Cada compra genera una transacción 'purchase' con reference_id igual al
+-- escenario, quiz o partida. reference_id apunta a tablas distintas según
+-- el tipo, por eso no tiene clave foránea.
+--   simulator_hint: una por escenario (reference_id = escenario)
+--   simulator_extra_attempt: reference_id = escenario; se consume al enviar
+--     la decisión
+--   quiz_extra_attempt: reference_id = quiz; se consume al enviar el quiz
+--   pvp_second_chance: una por partida (reference_id = partida)
+-- purchase_slot hace que pistas y segundas oportunidades sean únicas por
+-- referencia y que no se acumule más de un intento extra sin usar.
+CREATE TABLE consumable_purchases (
+    id CHAR(36) PRIMARY KEY,
+    user_id CHAR(36) NOT NULL,
+    type ENUM(
+        'simulator_hint', 'simulator_extra_attempt',
+        'quiz_extra_attempt', 'pvp_second_chance'
+    ) NOT NULL,
+    reference_id CHAR(36) NOT NULL,
+    price INT NOT NULL,
+    transaction_id CHAR(36) NULL,
+    consumed_at TIMESTAMP NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    purchase_slot VARCHAR(10) AS (
+        CASE
+            WHEN type IN ('simulator_hint', 'pvp_second_chance') THEN 'once'
+            WHEN consumed_at IS NULL THEN 'open'
+            ELSE NULL
+        END
+    ) STORED,
+    UNIQUE KEY uk_consumable_purchases_slot (user_id, type, reference_id, purchase_slot),
+    INDEX idx_consumable_purchases_user (user_id, created_at DESC),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (transaction_id) REFERENCES token_transactions(id) ON DELETE SET NULL,
+    CONSTRAINT chk_consumable_purchases_price CHECK (price >= 0)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- ===========================================
+-- QUIZ_ATTEMPTS: intentos extra
+-- ===========================================
+-- El índice único permitía un solo intento por quiz y día. Un intento extra
+-- se guarda con el id de su compra para que no choque con el del día.
+ALTER TABLE quiz_attempts
+    ADD COLUMN extra_attempt_id CHAR(36) NOT NULL DEFAULT '' AFTER answers,
+    DROP INDEX unique_user_quiz_date,
+    ADD UNIQUE KEY unique_user_quiz_date (user_id, quiz_id, extra_attempt_id, (DATE(completed_at)));
@@ -0,0 +1,50 @@
+-- Smart Stocks Database Schema - MySQL
+-- Fase 28: Programa de referidos con recompensa en tokens
+
+-- ===========================================
+-- TOKEN_TRANSACTIONS: recompensas por referidos
+-- ===========================================
+ALTER TABLE token_transactions
+    MODIFY COLUMN transaction_type ENUM(
+        'tournament_reward', 'tournament_entry',
+        'daily_bonus', 'achievement_bonus',
+        'admin_grant', 'purchase', 'refund',
+        'season_reward', 'admin_deduct',
+        'gift_sent', 'gift_received',
+        'referral_reward'
+    ) NOT NULL;
+
+-- ===========================================
+-- USERS: código de referido
+-- ===========================================
+-- Se genera al registrarse o la primera vez que el usuario lo consulta
+ALTER TABLE users
+    ADD COLUMN referral_code VARCHAR(12) NULL,
+    ADD UNIQUE KEY uk_users_referral_code (referral_code);
+
+-- ===========================================
+-- TABLA: referrals (Alumnos invitados)
+-- ===========================================
+-- Cada alumno puede haber sido invitado por una sola persona. La
+-- recompensa se decide cuando el invitado verifica su email y completa su
+-- primer quiz: status pasa a 'rewarded' (con la transacción del crédito) o
+-- a 'rejected' con el motivo (cuentas vinculadas o límite alcanzado).
+CREATE TABLE referrals (
+    id CHAR(36) PRIMARY KEY,
+    referrer_id CHAR(36) NOT NULL,
+    referee_id CHAR(36) NOT NULL,
+    code VARCHAR(12) NOT NULL,
+    status ENUM('pending', 'rewarded', 'rejected') NOT NULL DEFAULT 'pending',
+    reject_reason VARCHAR(50) NULL,
+    reward_tokens INT NOT NULL DEFAULT 0,
+    transaction_id CHAR(36) NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    resolved_at TIMESTAMP NULL,
+    UNIQUE KEY uk_referrals_referee (referee_id),
+    INDEX idx_referrals_referrer (referrer_id, created_at DESC),
+    INDEX idx_referrals_referrer_status (referrer_id, status, resolved_at),
+    FOREIGN KEY (referrer_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (referee_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (transaction_id) REFERENCES token_transactions(id) ON DELETE SET NULL,
+    CONSTRAINT chk_referrals_self CHECK (referrer_id <> referee_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
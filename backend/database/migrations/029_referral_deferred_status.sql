@@ -0,0 +1,14 @@
+-- Smart Stocks Database Schema - MySQL
+-- Fase 29: Referidos diferidos por el límite mensual
+
+-- ===========================================
+-- REFERRALS: estado 'deferred'
+-- ===========================================
+-- Un invitado que califica cuando el que invitó ya alcanzó el límite
+-- mensual no pierde la recompensa: queda 'deferred' (con reject_reason
+-- 'monthly_limit_reached') y se reevalúa hasta que haya cupo. El límite
+-- total sigue rechazándolo.
+ALTER TABLE referrals
+    MODIFY COLUMN status ENUM('pending', 'deferred', 'rewarded', 'rejected') NOT NULL DEFAULT 'pending';
+
+CREATE INDEX idx_referrals_status ON referrals (status, created_at);
@@ -0,0 +1,113 @@
+-- Smart Stocks Database Schema - MySQL
+-- Fase 30: Intentos extra fuera de precisión, logros y contadores
+
+-- Un intento extra repite el quiz o escenario del día con la respuesta ya
+-- vista. Suma un porcentaje de los puntos, pero no cuenta para la precisión
+-- de los rankings, las métricas de logros ni los contadores de user_stats.
+
+-- ===========================================
+-- SIMULATOR_ATTEMPTS: marca de intento extra
+-- ===========================================
+-- Igual que quiz_attempts: id de la compra usada ('' en el intento del día)
+ALTER TABLE simulator_attempts
+    ADD COLUMN extra_attempt_id CHAR(36) NOT NULL DEFAULT '' AFTER time_taken_seconds;
+
+-- ===========================================
+-- STORED PROCEDURE: Stats después de quiz
+-- ===========================================
+DROP PROCEDURE IF EXISTS update_user_stats_after_quiz;
+
+DELIMITER //
+CREATE PROCEDURE update_user_stats_after_quiz(
+    IN p_user_id CHAR(36),
+    IN p_attempt_id CHAR(36),
+    IN p_points_earned INT
+)
+BEGIN
+    DECLARE v_extra BOOLEAN DEFAULT FALSE;
+
+    SELECT extra_attempt_id <> '' INTO v_extra
+    FROM quiz_attempts WHERE id = p_attempt_id;
+
+    -- Actualizar smartpoints y total de quizzes (el intento extra no suma
+    -- al total)
+    UPDATE user_stats
+    SET smartpoints = smartpoints + p_points_earned,
+        total_quizzes_completed = total_quizzes_completed + IF(v_extra, 0, 1),
+        updated_at = NOW()
+    WHERE user_id = p_user_id;
+
+    IF p_points_earned <> 0 THEN
+        INSERT INTO points_ledger (id, user_id, source, points, reference_id)
+        VALUES (UUID(), p_user_id, 'quiz', p_points_earned, p_attempt_id);
+    END IF;
+
+    -- Actualizar rango
+    CALL update_user_rank(p_user_id);
+END//
+DELIMITER ;
+
+-- ===========================================
+-- STORED PROCEDURE: Intento de simulador
+-- ===========================================
+DROP PROCEDURE IF EXISTS record_simulator_attempt;
+
+DELIMITER //
+CREATE PROCEDURE record_simulator_attempt(
+    IN p_user_id CHAR(36),
+    IN p_scenario_id CHAR(36),
+    IN p_difficulty VARCHAR(10),
+    IN p_user_decision VARCHAR(10),
+    IN p_was_correct BOOLEAN,
+    IN p_points_earned INT,
+    IN p_time_taken INT,
+    IN p_extra_attempt_id CHAR(36)
+)
+BEGIN
+    DECLARE v_attempt_id CHAR(36);
+    DECLARE v_games INT;
+    SET v_attempt_id = UUID();
+    SET v_games = IF(p_extra_attempt_id <> '', 0, 1);
+
+    -- Insertar intento
+    INSERT INTO simulator_attempts (
+        id, user_id, scenario_id, difficulty,
+        user_decision, was_correct, points_earned, time_taken_seconds,
+        extra_attempt_id
+    ) VALUES (
+        v_attempt_id, p_user_id, p_scenario_id, p_difficulty,
+        p_user_decision, p_was_correct, p_points_earned, p_time_taken,
+        p_extra_attempt_id
+    );
+
+    -- Registrar cooldown
+    INSERT INTO daily_simulator_cooldowns (id, user_id, difficulty, last_attempt_date)
+    VALUES (UUID(), p_user_id, p_difficulty, CURDATE())
+    ON DUPLICATE KEY UPDATE
+        attempts_count = attempts_count + 1,
+        updated_at = NOW();
+
+    -- Si fue correcto, actualizar stats del usuario
+    IF p_was_correct THEN
+        UPDATE user_stats
+        SET smartpoints = smartpoints + p_points_earned,
+            total_simulator_games = total_simulator_games + v_games,
+            updated_at = NOW()
+        WHERE user_id = p_user_id;
+
+        IF p_points_earned <> 0 THEN
+            INSERT INTO points_ledger (id, user_id, source, points, reference_id)
+            VALUES (UUID(), p_user_id, 'simulator', p_points_earned, v_attempt_id);
+        END IF;
+
+        -- Actualizar rango
+        CALL update_user_rank(p_user_id);
+    ELSE
+        -- Solo incrementar contador de simulaciones
+        UPDATE user_stats
+        SET total_simulator_games = total_simulator_games + v_games,
+            updated_at = NOW()
+        WHERE user_id = p_user_id;
+    END IF;
+END//
+DELIMITER ;
@@ -23,7 +23,7 @@ INSERT INTO tournaments (
     'league',
     0,
     1000,
-    'Bronce 3',
+    'bronze_1',
     16,
     'registration',
     DATE_ADD(NOW(), INTERVAL 2 DAY),
@@ -54,7 +54,7 @@ INSERT INTO tournaments (
     'bracket',
     100,
     10000,
-    'Plata 3',
+    'silver_1',
     32,
     'registration',
     DATE_ADD(NOW(), INTERVAL 5 DAY),
@@ -87,7 +87,7 @@ INSERT INTO tournaments (
     'battle_royale',
     50,
     7500,
-    'Bronce 1',
+    'bronze_3',
     50,
     48,
     'in_progress',
@@ -120,7 +120,7 @@ INSERT INTO tournaments (
     'bracket',
     0,
     15000,
-    'Bronce 3',
+    'bronze_1',
     64,
     64,
     'completed',
@@ -154,7 +154,7 @@ INSERT INTO tournaments (
     'league',
     25,
     2500,
-    'Bronce 2',
+    'bronze_2',
     20,
     'upcoming',
     DATE_ADD(NOW(), INTERVAL 7 DAY),
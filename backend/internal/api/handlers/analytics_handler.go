@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/smartstocks/backend/internal/api/middleware"
+	"github.com/smartstocks/backend/internal/models"
+	"github.com/smartstocks/backend/internal/services"
+	"github.com/smartstocks/backend/pkg/utils"
+)
+
+type AnalyticsHandler struct {
+	analyticsService *services.AnalyticsService
+}
+
+func NewAnalyticsHandler(analyticsService *services.AnalyticsService) *AnalyticsHandler {
+	return &AnalyticsHandler{analyticsService: analyticsService}
+}
+
+// ExportClassroomAnalytics godoc
+// @Summary Export classroom analytics
+// @Description Streamed CSV or XLSX report of the classroom students: quiz history, simulator stats by difficulty, course progress and PvP record. XLSX includes every report as a sheet unless one is selected; CSV requires a report.
+// @Tags analytics
+// @Security BearerAuth
+// @Produce text/csv,application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Param id path string true "Classroom ID"
+// @Param format query string false "xlsx (default) or csv"
+// @Param report query string false "quizzes, simulator, courses or pvp"
+// @Param from query string false "Start date (YYYY-MM-DD), default 30 days ago"
+// @Param to query string false "End date inclusive (YYYY-MM-DD), default today"
+// @Success 200 {file} file
+// @Router /classrooms/{id}/analytics/export [get]
+func (h *AnalyticsHandler) ExportClassroomAnalytics(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "User not authenticated", nil)
+		return
+	}
+
+	scope, err := h.analyticsService.ClassroomScope(c.Param("id"), userID)
+	if err != nil {
+		classroomErrorResponse(c, "Failed to export classroom analytics", err)
+		return
+	}
+
+	h.export(c, scope)
+}
+
+// ExportSchoolAnalytics godoc
+// @Summary Export school analytics
+// @Description Same report as the classroom export for every approved member of the school. Requires being a school administrator.
+// @Tags analytics
+// @Security BearerAuth
+// @Produce text/csv,application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Param id path string true "School ID"
+// @Param format query string false "xlsx (default) or csv"
+// @Param report query string false "quizzes, simulator, courses or pvp"
+// @Param from query string false "Start date (YYYY-MM-DD), default 30 days ago"
+// @Param to query string false "End date inclusive (YYYY-MM-DD), default today"
+// @Success 200 {file} file
+// @Router /schools/{id}/analytics/export [get]
+func (h *AnalyticsHandler) ExportSchoolAnalytics(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "User not authenticated", nil)
+		return
+	}
+
+	scope, err := h.analyticsService.SchoolScope(c.Param("id"), userID)
+	if err != nil {
+		schoolErrorResponse(c, "Failed to export school analytics", err)
+		return
+	}
+
+	h.export(c, scope)
+}
+
+// export valida los parámetros y escribe el reporte en la respuesta
+func (h *AnalyticsHandler) export(c *gin.Context, scope models.AnalyticsScope) {
+	format := c.DefaultQuery("format", services.AnalyticsFormatXLSX)
+	report := c.Query("report")
+	reports, err := services.ExportReports(format, report)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	period, err := parseProgressRange(c)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid date range", err)
+		return
+	}
+
+	if report == "" {
+		report = "all"
+	}
+	filename := fmt.Sprintf("analytics-%s-%s-%s-%s.%s",
+		scope.Type, report,
+		period.From.Format("2006-01-02"), period.To.AddDate(0, 0, -1).Format("2006-01-02"),
+		format)
+
+	contentType := "text/csv; charset=utf-8"
+	if format == services.AnalyticsFormatXLSX {
+		contentType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	c.Header("Content-Type", contentType)
+	c.Header("Cache-Control", "no-store")
+	c.Status(http.StatusOK)
+	if err := h.analyticsService.WriteExport(c.Request.Context(), c.Writer, scope, format, reports, period); err != nil {
+		// Los headers ya se enviaron; solo queda cortar la respuesta
+		_ = c.Error(err)
+	}
+}
@@ -0,0 +1,267 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/smartstocks/backend/internal/api/middleware"
+	"github.com/smartstocks/backend/internal/models"
+	"github.com/smartstocks/backend/internal/services"
+	"github.com/smartstocks/backend/pkg/utils"
+)
+
+type AssignmentHandler struct {
+	assignmentService *services.AssignmentService
+}
+
+func NewAssignmentHandler(assignmentService *services.AssignmentService) *AssignmentHandler {
+	return &AssignmentHandler{assignmentService: assignmentService}
+}
+
+// === DOCENTE ===
+
+// CreateAssignment godoc
+// @Summary Assign a course, lesson, quiz or simulator scenario to a classroom
+// @Tags assignments
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "Classroom ID"
+// @Param request body models.CreateAssignmentRequest true "Assignment"
+// @Success 201 {object} models.Assignment
+// @Router /classrooms/{id}/assignments [post]
+func (h *AssignmentHandler) CreateAssignment(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "User not authenticated", nil)
+		return
+	}
+
+	var req models.CreateAssignmentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	assignment, err := h.assignmentService.CreateAssignment(c.Param("id"), userID, &req)
+	if err != nil {
+		assignmentErrorResponse(c, "Failed to create assignment", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, "Assignment created", assignment)
+}
+
+// GetClassroomAssignments godoc
+// @Summary List the assignments of a classroom
+// @Tags assignments
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Classroom ID"
+// @Success 200 {array} models.Assignment
+// @Router /classrooms/{id}/assignments [get]
+func (h *AssignmentHandler) GetClassroomAssignments(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "User not authenticated", nil)
+		return
+	}
+
+	assignments, err := h.assignmentService.GetClassroomAssignments(c.Param("id"), userID)
+	if err != nil {
+		assignmentErrorResponse(c, "Failed to get assignments", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Assignments retrieved", assignments)
+}
+
+// GetAssignment godoc
+// @Summary Get an assignment with each student's submission status
+// @Tags assignments
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Classroom ID"
+// @Param assignmentId path string true "Assignment ID"
+// @Success 200 {object} models.AssignmentDetailResponse
+// @Router /classrooms/{id}/assignments/{assignmentId} [get]
+func (h *AssignmentHandler) GetAssignment(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "User not authenticated", nil)
+		return
+	}
+
+	detail, err := h.assignmentService.GetAssignment(c.Param("id"), c.Param("assignmentId"), userID)
+	if err != nil {
+		assignmentErrorResponse(c, "Failed to get assignment", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Assignment retrieved", detail)
+}
+
+// UpdateAssignment godoc
+// @Summary Update an assignment
+// @Tags assignments
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "Classroom ID"
+// @Param assignmentId path string true "Assignment ID"
+// @Param request body models.UpdateAssignmentRequest true "Fields to update"
+// @Success 200 {object} models.Assignment
+// @Router /classrooms/{id}/assignments/{assignmentId} [put]
+func (h *AssignmentHandler) UpdateAssignment(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "User not authenticated", nil)
+		return
+	}
+
+	var req models.UpdateAssignmentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	assignment, err := h.assignmentService.UpdateAssignment(c.Param("id"), c.Param("assignmentId"), userID, &req)
+	if err != nil {
+		assignmentErrorResponse(c, "Failed to update assignment", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Assignment updated", assignment)
+}
+
+// DeleteAssignment godoc
+// @Summary Delete an assignment and its submissions
+// @Tags assignments
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Classroom ID"
+// @Param assignmentId path string true "Assignment ID"
+// @Success 200 {object} map[string]interface{}
+// @Router /classrooms/{id}/assignments/{assignmentId} [delete]
+func (h *AssignmentHandler) DeleteAssignment(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "User not authenticated", nil)
+		return
+	}
+
+	if err := h.assignmentService.DeleteAssignment(c.Param("id"), c.Param("assignmentId"), userID); err != nil {
+		assignmentErrorResponse(c, "Failed to delete assignment", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Assignment deleted", nil)
+}
+
+// GetGradebook godoc
+// @Summary Classroom gradebook
+// @Description One row per student and one column per assignment with the score or the late/missing/pending status
+// @Tags assignments
+// @Security BearerAuth
+// @Produce json,text/csv
+// @Param id path string true "Classroom ID"
+// @Param format query string false "json (default) or csv"
+// @Success 200 {object} models.Gradebook
+// @Router /classrooms/{id}/gradebook [get]
+func (h *AssignmentHandler) GetGradebook(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "User not authenticated", nil)
+		return
+	}
+
+	format := c.DefaultQuery("format", "json")
+	if format != "json" && format != "csv" {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid format, use json or csv", nil)
+		return
+	}
+
+	gradebook, err := h.assignmentService.GetGradebook(c.Param("id"), userID)
+	if err != nil {
+		assignmentErrorResponse(c, "Failed to get gradebook", err)
+		return
+	}
+
+	if format == "json" {
+		utils.SuccessResponse(c, http.StatusOK, "Gradebook retrieved", gradebook)
+		return
+	}
+
+	filename := fmt.Sprintf("gradebook-%s.csv", gradebook.Classroom.JoinCode)
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Status(http.StatusOK)
+	if err := h.assignmentService.WriteGradebookCSV(c.Writer, gradebook); err != nil {
+		// Los headers ya se enviaron; solo queda cortar la respuesta
+		_ = c.Error(err)
+	}
+}
+
+// === ALUMNO ===
+
+// GetMyAssignments godoc
+// @Summary List the current student's assignments
+// @Tags assignments
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {array} models.MyAssignment
+// @Router /assignments [get]
+func (h *AssignmentHandler) GetMyAssignments(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "User not authenticated", nil)
+		return
+	}
+
+	assignments, err := h.assignmentService.GetMyAssignments(userID)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to get assignments", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Assignments retrieved", assignments)
+}
+
+// GetMyAssignment godoc
+// @Summary Get an assignment with the quiz or scenario to solve
+// @Tags assignments
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Assignment ID"
+// @Success 200 {object} models.MyAssignmentDetail
+// @Router /assignments/{id} [get]
+func (h *AssignmentHandler) GetMyAssignment(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "User not authenticated", nil)
+		return
+	}
+
+	detail, err := h.assignmentService.GetMyAssignment(userID, c.Param("id"))
+	if err != nil {
+		assignmentErrorResponse(c, "Failed to get assignment", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Assignment retrieved", detail)
+}
+
+// assignmentErrorResponse traduce los errores del servicio a códigos HTTP
+func assignmentErrorResponse(c *gin.Context, message string, err error) {
+	switch {
+	case err.Error() == "assignment not found":
+		utils.ErrorResponse(c, http.StatusNotFound, "Assignment not found", err)
+	case strings.HasSuffix(err.Error(), " not found") && err.Error() != "classroom not found":
+		// La actividad a asignar no existe
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid assignment target", err)
+	default:
+		classroomErrorResponse(c, message, err)
+	}
+}
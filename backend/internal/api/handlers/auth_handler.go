@@ -1,9 +1,12 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
+	"github.com/smartstocks/backend/internal/api/middleware"
 	"github.com/smartstocks/backend/internal/models"
 	"github.com/smartstocks/backend/internal/services"
 	"github.com/smartstocks/backend/pkg/utils"
@@ -39,7 +42,7 @@ func (h *AuthHandler) Register(c *gin.Context) {
 	}
 
 	// Registrar usuario
-	response, err := h.authService.Register(&req)
+	response, err := h.authService.Register(&req, c.ClientIP(), c.Request.UserAgent(), middleware.GetDeviceID(c))
 	if err != nil {
 		utils.ErrorResponse(c, http.StatusBadRequest, "Registration failed", err)
 		return
@@ -55,6 +58,7 @@ func (h *AuthHandler) Register(c *gin.Context) {
 // @Produce json
 // @Param request body models.LoginRequest true "Login Request"
 // @Success 200 {object} models.LoginResponse
+// @Success 200 {object} models.TwoFactorChallenge
 // @Router /auth/login [post]
 func (h *AuthHandler) Login(c *gin.Context) {
 	var req models.LoginRequest
@@ -64,12 +68,53 @@ func (h *AuthHandler) Login(c *gin.Context) {
 	}
 
 	// Login
-	response, err := h.authService.Login(&req)
+	response, challenge, err := h.authService.Login(&req, c.ClientIP(), c.Request.UserAgent(), middleware.GetDeviceID(c))
 	if err != nil {
+		var blocked *services.LoginBlockedError
+		if errors.As(err, &blocked) {
+			c.Header("Retry-After", strconv.Itoa(int(blocked.RetryAfter.Seconds())))
+			utils.ErrorResponse(c, http.StatusTooManyRequests, "Login temporarily blocked", err)
+			return
+		}
 		utils.ErrorResponse(c, http.StatusUnauthorized, "Login failed", err)
 		return
 	}
 
+	if challenge != nil {
+		utils.SuccessResponse(c, http.StatusOK, "Two-factor authentication required", challenge)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Login successful", response)
+}
+
+// LoginTwoFactor godoc
+// @Summary Complete login with a TOTP or recovery code
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body models.TwoFactorLoginRequest true "Two-factor Login Request"
+// @Success 200 {object} models.LoginResponse
+// @Router /auth/login/2fa [post]
+func (h *AuthHandler) LoginTwoFactor(c *gin.Context) {
+	var req models.TwoFactorLoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	response, err := h.authService.LoginTwoFactor(&req, c.ClientIP(), c.Request.UserAgent(), middleware.GetDeviceID(c))
+	if err != nil {
+		var blocked *services.LoginBlockedError
+		if errors.As(err, &blocked) {
+			c.Header("Retry-After", strconv.Itoa(int(blocked.RetryAfter.Seconds())))
+			utils.ErrorResponse(c, http.StatusTooManyRequests, "Login temporarily blocked", err)
+			return
+		}
+		utils.ErrorResponse(c, http.StatusUnauthorized, "Two-factor verification failed", err)
+		return
+	}
+
 	utils.SuccessResponse(c, http.StatusOK, "Login successful", response)
 }
 
@@ -0,0 +1,424 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/smartstocks/backend/internal/api/middleware"
+	"github.com/smartstocks/backend/internal/models"
+	"github.com/smartstocks/backend/internal/services"
+	"github.com/smartstocks/backend/pkg/utils"
+)
+
+// defaultProgressDays período de los reportes cuando no se indica "from"
+const defaultProgressDays = 30
+
+type ClassroomHandler struct {
+	classroomService *services.ClassroomService
+}
+
+func NewClassroomHandler(classroomService *services.ClassroomService) *ClassroomHandler {
+	return &ClassroomHandler{classroomService: classroomService}
+}
+
+// === DOCENTE ===
+
+// CreateClassroom godoc
+// @Summary Create a classroom
+// @Description Teachers only. Returns the classroom with its join code.
+// @Tags classrooms
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body models.CreateClassroomRequest true "Classroom"
+// @Success 201 {object} models.Classroom
+// @Router /classrooms [post]
+func (h *ClassroomHandler) CreateClassroom(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "User not authenticated", nil)
+		return
+	}
+
+	var req models.CreateClassroomRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	classroom, err := h.classroomService.CreateClassroom(userID, &req)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to create classroom", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, "Classroom created", classroom)
+}
+
+// GetTeachingClassrooms godoc
+// @Summary List the classrooms taught by the current teacher
+// @Tags classrooms
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {array} models.Classroom
+// @Router /classrooms/teaching [get]
+func (h *ClassroomHandler) GetTeachingClassrooms(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "User not authenticated", nil)
+		return
+	}
+
+	classrooms, err := h.classroomService.GetTeacherClassrooms(userID)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to get classrooms", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Classrooms retrieved", classrooms)
+}
+
+// GetClassroom godoc
+// @Summary Get a classroom
+// @Tags classrooms
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Classroom ID"
+// @Success 200 {object} models.Classroom
+// @Router /classrooms/{id} [get]
+func (h *ClassroomHandler) GetClassroom(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "User not authenticated", nil)
+		return
+	}
+
+	classroom, err := h.classroomService.GetClassroom(c.Param("id"), userID)
+	if err != nil {
+		classroomErrorResponse(c, "Failed to get classroom", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Classroom retrieved", classroom)
+}
+
+// UpdateClassroom godoc
+// @Summary Update a classroom
+// @Tags classrooms
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "Classroom ID"
+// @Param request body models.UpdateClassroomRequest true "Changes"
+// @Success 200 {object} models.Classroom
+// @Router /classrooms/{id} [put]
+func (h *ClassroomHandler) UpdateClassroom(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "User not authenticated", nil)
+		return
+	}
+
+	var req models.UpdateClassroomRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	classroom, err := h.classroomService.UpdateClassroom(c.Param("id"), userID, &req)
+	if err != nil {
+		classroomErrorResponse(c, "Failed to update classroom", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Classroom updated", classroom)
+}
+
+// DeleteClassroom godoc
+// @Summary Delete a classroom
+// @Tags classrooms
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Classroom ID"
+// @Success 200
+// @Router /classrooms/{id} [delete]
+func (h *ClassroomHandler) DeleteClassroom(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "User not authenticated", nil)
+		return
+	}
+
+	if err := h.classroomService.DeleteClassroom(c.Param("id"), userID); err != nil {
+		classroomErrorResponse(c, "Failed to delete classroom", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Classroom deleted", nil)
+}
+
+// RegenerateJoinCode godoc
+// @Summary Regenerate the classroom join code
+// @Tags classrooms
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Classroom ID"
+// @Success 200 {object} models.Classroom
+// @Router /classrooms/{id}/join-code [post]
+func (h *ClassroomHandler) RegenerateJoinCode(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "User not authenticated", nil)
+		return
+	}
+
+	classroom, err := h.classroomService.RegenerateJoinCode(c.Param("id"), userID)
+	if err != nil {
+		classroomErrorResponse(c, "Failed to regenerate join code", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Join code regenerated", classroom)
+}
+
+// GetStudents godoc
+// @Summary List classroom students
+// @Tags classrooms
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Classroom ID"
+// @Success 200 {array} models.ClassroomMember
+// @Router /classrooms/{id}/students [get]
+func (h *ClassroomHandler) GetStudents(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "User not authenticated", nil)
+		return
+	}
+
+	members, err := h.classroomService.GetMembers(c.Param("id"), userID)
+	if err != nil {
+		classroomErrorResponse(c, "Failed to get students", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Students retrieved", members)
+}
+
+// RemoveStudent godoc
+// @Summary Remove a student from the classroom
+// @Tags classrooms
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Classroom ID"
+// @Param userId path string true "Student ID"
+// @Success 200
+// @Router /classrooms/{id}/students/{userId} [delete]
+func (h *ClassroomHandler) RemoveStudent(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "User not authenticated", nil)
+		return
+	}
+
+	if err := h.classroomService.RemoveMember(c.Param("id"), userID, c.Param("userId")); err != nil {
+		classroomErrorResponse(c, "Failed to remove student", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Student removed", nil)
+}
+
+// GetClassroomProgress godoc
+// @Summary Classroom progress dashboard
+// @Description Per-student course completion, quiz scores, simulator accuracy and PvP activity in a date range
+// @Tags classrooms
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Classroom ID"
+// @Param from query string false "Start date (YYYY-MM-DD), default 30 days ago"
+// @Param to query string false "End date inclusive (YYYY-MM-DD), default today"
+// @Success 200 {object} models.ClassroomProgressResponse
+// @Router /classrooms/{id}/progress [get]
+func (h *ClassroomHandler) GetClassroomProgress(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "User not authenticated", nil)
+		return
+	}
+
+	period, err := parseProgressRange(c)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid date range", err)
+		return
+	}
+
+	progress, err := h.classroomService.GetClassroomProgress(c.Param("id"), userID, period)
+	if err != nil {
+		classroomErrorResponse(c, "Failed to get classroom progress", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Classroom progress retrieved", progress)
+}
+
+// GetStudentProgress godoc
+// @Summary Student progress detail
+// @Tags classrooms
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Classroom ID"
+// @Param userId path string true "Student ID"
+// @Param from query string false "Start date (YYYY-MM-DD), default 30 days ago"
+// @Param to query string false "End date inclusive (YYYY-MM-DD), default today"
+// @Success 200 {object} models.StudentProgressDetail
+// @Router /classrooms/{id}/students/{userId}/progress [get]
+func (h *ClassroomHandler) GetStudentProgress(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "User not authenticated", nil)
+		return
+	}
+
+	period, err := parseProgressRange(c)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid date range", err)
+		return
+	}
+
+	progress, err := h.classroomService.GetStudentProgress(c.Param("id"), userID, c.Param("userId"), period)
+	if err != nil {
+		classroomErrorResponse(c, "Failed to get student progress", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Student progress retrieved", progress)
+}
+
+// === ALUMNO ===
+
+// GetMyClassrooms godoc
+// @Summary List the classrooms the current user joined
+// @Tags classrooms
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {array} models.Classroom
+// @Router /classrooms [get]
+func (h *ClassroomHandler) GetMyClassrooms(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "User not authenticated", nil)
+		return
+	}
+
+	classrooms, err := h.classroomService.GetStudentClassrooms(userID)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to get classrooms", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Classrooms retrieved", classrooms)
+}
+
+// JoinClassroom godoc
+// @Summary Join a classroom with its join code
+// @Tags classrooms
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body models.JoinClassroomRequest true "Join code"
+// @Success 200 {object} models.Classroom
+// @Router /classrooms/join [post]
+func (h *ClassroomHandler) JoinClassroom(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "User not authenticated", nil)
+		return
+	}
+
+	var req models.JoinClassroomRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	classroom, err := h.classroomService.JoinClassroom(userID, req.JoinCode)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Failed to join classroom", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Joined classroom", classroom)
+}
+
+// LeaveClassroom godoc
+// @Summary Leave a classroom
+// @Tags classrooms
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Classroom ID"
+// @Success 200
+// @Router /classrooms/{id}/leave [post]
+func (h *ClassroomHandler) LeaveClassroom(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "User not authenticated", nil)
+		return
+	}
+
+	if err := h.classroomService.LeaveClassroom(userID, c.Param("id")); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Failed to leave classroom", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Left classroom", nil)
+}
+
+// === HELPERS ===
+
+// classroomErrorResponse traduce errores de permisos y aulas inexistentes
+func classroomErrorResponse(c *gin.Context, message string, err error) {
+	switch {
+	case errors.Is(err, services.ErrClassroomForbidden):
+		utils.ErrorResponse(c, http.StatusForbidden, err.Error(), nil)
+	case err.Error() == "classroom not found":
+		utils.ErrorResponse(c, http.StatusNotFound, "Classroom not found", err)
+	case err.Error() == "student is not a member of this classroom":
+		utils.ErrorResponse(c, http.StatusNotFound, "Student not found", err)
+	default:
+		utils.ErrorResponse(c, http.StatusInternalServerError, message, err)
+	}
+}
+
+// parseProgressRange lee ?from=&to= (YYYY-MM-DD, "to" inclusive)
+func parseProgressRange(c *gin.Context) (models.ProgressRange, error) {
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+
+	to := today
+	if raw := c.Query("to"); raw != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", raw, now.Location())
+		if err != nil {
+			return models.ProgressRange{}, errors.New("to must be YYYY-MM-DD")
+		}
+		to = parsed
+	}
+
+	from := to.AddDate(0, 0, -defaultProgressDays)
+	if raw := c.Query("from"); raw != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", raw, now.Location())
+		if err != nil {
+			return models.ProgressRange{}, errors.New("from must be YYYY-MM-DD")
+		}
+		from = parsed
+	}
+
+	if from.After(to) {
+		return models.ProgressRange{}, errors.New("from must be before to")
+	}
+
+	// El límite superior es exclusivo: incluir todo el día "to"
+	return models.ProgressRange{From: from, To: to.AddDate(0, 0, 1)}, nil
+}
@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/smartstocks/backend/internal/api/middleware"
+	"github.com/smartstocks/backend/internal/models"
+	"github.com/smartstocks/backend/internal/services"
+	"github.com/smartstocks/backend/pkg/utils"
+)
+
+type OIDCHandler struct {
+	oidcService *services.OIDCService
+}
+
+func NewOIDCHandler(oidcService *services.OIDCService) *OIDCHandler {
+	return &OIDCHandler{oidcService: oidcService}
+}
+
+// GetProviders godoc
+// @Summary List configured OpenID Connect providers
+// @Tags auth
+// @Produce json
+// @Success 200 {array} string
+// @Router /auth/oidc/providers [get]
+func (h *OIDCHandler) GetProviders(c *gin.Context) {
+	utils.SuccessResponse(c, http.StatusOK, "Providers retrieved", h.oidcService.GetProviders())
+}
+
+// Authorize godoc
+// @Summary Start OpenID Connect login
+// @Description Returns the provider authorization URL (authorization code + PKCE) and the state to send back on callback
+// @Tags auth
+// @Produce json
+// @Param provider path string true "Provider name"
+// @Success 200 {object} models.OIDCAuthorizeResponse
+// @Router /auth/oidc/{provider}/authorize [get]
+func (h *OIDCHandler) Authorize(c *gin.Context) {
+	response, err := h.oidcService.Authorize(c.Param("provider"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Failed to start OIDC login", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Authorization URL generated", response)
+}
+
+// Callback godoc
+// @Summary Complete OpenID Connect login
+// @Description Exchanges the authorization code and returns the same response as /auth/login
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param provider path string true "Provider name"
+// @Param request body models.OIDCCallbackRequest true "Callback Request"
+// @Success 200 {object} models.LoginResponse
+// @Router /auth/oidc/{provider}/callback [post]
+func (h *OIDCHandler) Callback(c *gin.Context) {
+	var req models.OIDCCallbackRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	response, challenge, err := h.oidcService.Callback(c.Param("provider"), &req, c.ClientIP(), c.Request.UserAgent(), middleware.GetDeviceID(c))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "Login failed", err)
+		return
+	}
+
+	if challenge != nil {
+		utils.SuccessResponse(c, http.StatusOK, "Two-factor authentication required", challenge)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Login successful", response)
+}
@@ -0,0 +1,164 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/smartstocks/backend/internal/api/middleware"
+	"github.com/smartstocks/backend/internal/models"
+	"github.com/smartstocks/backend/internal/services"
+	"github.com/smartstocks/backend/pkg/utils"
+)
+
+type PrivacyHandler struct {
+	privacyService *services.PrivacyService
+}
+
+func NewPrivacyHandler(privacyService *services.PrivacyService) *PrivacyHandler {
+	return &PrivacyHandler{privacyService: privacyService}
+}
+
+// ExportData godoc
+// @Summary Export personal data
+// @Description Downloads the user's profile, stats, activity history, forum content and token ledger
+// @Tags user
+// @Security BearerAuth
+// @Produce json,application/zip
+// @Param format query string false "json (default) or zip"
+// @Success 200 {object} models.UserDataExport
+// @Router /user/export [get]
+func (h *PrivacyHandler) ExportData(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "User not authenticated", nil)
+		return
+	}
+
+	format := c.DefaultQuery("format", "json")
+	if format != "json" && format != "zip" {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid format, use json or zip", nil)
+		return
+	}
+
+	export, err := h.privacyService.ExportData(userID)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to export data", err)
+		return
+	}
+
+	filename := fmt.Sprintf("smartstocks-export-%s.%s", export.GeneratedAt.Format("20060102"), format)
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+
+	if format == "json" {
+		c.JSON(http.StatusOK, export)
+		return
+	}
+
+	c.Header("Content-Type", "application/zip")
+	c.Status(http.StatusOK)
+	if err := h.privacyService.WriteExportZip(c.Writer, export); err != nil {
+		// Los headers ya se enviaron; solo queda cortar la respuesta
+		_ = c.Error(err)
+	}
+}
+
+// GetDeletionStatus godoc
+// @Summary Get account deletion request status
+// @Tags user
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} models.AccountDeletionRequest
+// @Router /user/delete [get]
+func (h *PrivacyHandler) GetDeletionStatus(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "User not authenticated", nil)
+		return
+	}
+
+	status, err := h.privacyService.GetDeletionStatus(userID)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to get deletion status", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Deletion status retrieved", status)
+}
+
+// RequestDeletion godoc
+// @Summary Request account deletion
+// @Description Schedules the account for deletion after a cooling-off period. Forum content is anonymized and all personal data removed.
+// @Tags user
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body models.DeleteAccountRequest true "Password or emailed confirmation code"
+// @Success 202 {object} models.AccountDeletionRequest
+// @Router /user/delete [post]
+func (h *PrivacyHandler) RequestDeletion(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "User not authenticated", nil)
+		return
+	}
+
+	var req models.DeleteAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	deletion, err := h.privacyService.RequestDeletion(userID, &req)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Failed to request deletion", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusAccepted, "Account scheduled for deletion", deletion)
+}
+
+// SendDeletionConfirmation godoc
+// @Summary Email a code to confirm account deletion
+// @Description For accounts without a known password (OpenID Connect sign-ups)
+// @Tags user
+// @Security BearerAuth
+// @Produce json
+// @Success 200
+// @Router /user/delete/confirmation [post]
+func (h *PrivacyHandler) SendDeletionConfirmation(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "User not authenticated", nil)
+		return
+	}
+
+	if err := h.privacyService.SendDeletionConfirmation(userID); err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to send confirmation code", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Confirmation code sent", nil)
+}
+
+// CancelDeletion godoc
+// @Summary Cancel a pending account deletion
+// @Tags user
+// @Security BearerAuth
+// @Produce json
+// @Success 200
+// @Router /user/delete/cancel [post]
+func (h *PrivacyHandler) CancelDeletion(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "User not authenticated", nil)
+		return
+	}
+
+	if err := h.privacyService.CancelDeletion(userID); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Failed to cancel deletion", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Account deletion cancelled", nil)
+}
@@ -158,6 +158,35 @@ func (h *PvPHandler) SubmitDecision(c *gin.Context) {
 	utils.SuccessResponse(c, http.StatusOK, "Decision submitted successfully", result)
 }
 
+// UseSecondChance cobra una segunda oportunidad y reemplaza la decisión de
+// la ronda mientras el rival no haya decidido (una vez por partida)
+func (h *PvPHandler) UseSecondChance(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "User not authenticated", nil)
+		return
+	}
+
+	var req models.SubmitPvPDecisionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	idempotencyKey, ok := idempotencyKeyHeader(c)
+	if !ok {
+		return
+	}
+
+	result, err := h.pvpService.UseSecondChance(userID, &req, idempotencyKey)
+	if err != nil {
+		consumableErrorResponse(c, "Failed to use second chance", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Decision changed", result)
+}
+
 func (h *PvPHandler) GetHistory(c *gin.Context) {
 	userID, exists := middleware.GetUserID(c)
 	if !exists {
@@ -48,6 +48,44 @@ func (h *QuizHandler) GetDailyQuiz(c *gin.Context) {
 	utils.SuccessResponse(c, http.StatusOK, "Quiz retrieved successfully", response)
 }
 
+// BuyExtraAttempt godoc
+// @Summary Buy an extra quiz attempt
+// @Description Spends tokens to take the daily quiz of the difficulty again today. An extra attempt earns extra_attempt_points_percent of the points and does not count toward accuracy, achievements or assignments; purchases per day are limited to extra_attempts_per_day (see consumable prices)
+// @Tags quiz
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body models.ExtraAttemptRequest true "Difficulty"
+// @Param Idempotency-Key header string false "Client key so a retried request does not charge twice (max 100 chars)"
+// @Success 201 {object} models.QuizExtraAttemptResponse
+// @Router /quiz/extra-attempt [post]
+func (h *QuizHandler) BuyExtraAttempt(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "User not authenticated", nil)
+		return
+	}
+
+	var req models.ExtraAttemptRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	idempotencyKey, ok := idempotencyKeyHeader(c)
+	if !ok {
+		return
+	}
+
+	result, err := h.quizService.BuyExtraAttempt(userID, req.Difficulty, idempotencyKey)
+	if err != nil {
+		consumableErrorResponse(c, "Failed to buy extra attempt", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, "Extra attempt purchased", result)
+}
+
 // SubmitQuiz godoc
 // @Summary Submit quiz answers
 // @Tags quiz
@@ -6,26 +6,30 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/smartstocks/backend/internal/api/middleware"
+	"github.com/smartstocks/backend/internal/models"
 	"github.com/smartstocks/backend/internal/services"
 	"github.com/smartstocks/backend/pkg/utils"
 )
 
 type RankingsHandler struct {
 	rankingsService *services.RankingsService
+	rankTierService *services.RankTierService
 }
 
-func NewRankingsHandler(rankingsService *services.RankingsService) *RankingsHandler {
+func NewRankingsHandler(rankingsService *services.RankingsService, rankTierService *services.RankTierService) *RankingsHandler {
 	return &RankingsHandler{
 		rankingsService: rankingsService,
+		rankTierService: rankTierService,
 	}
 }
 
 // GetGlobalLeaderboard godoc
 // @Summary Get global leaderboard
-// @Description Get the global leaderboard with top players
+// @Description Get the global leaderboard with top players, by lifetime smartpoints or by points earned today, this week or this month
 // @Tags rankings
 // @Security BearerAuth
 // @Produce json
+// @Param window query string false "Window: daily, weekly, monthly or all_time (default all_time)"
 // @Param limit query int false "Limit (default 100, max 1000)"
 // @Param offset query int false "Offset (default 0)"
 // @Success 200 {object} models.LeaderboardResponse
@@ -47,7 +51,12 @@ func (h *RankingsHandler) GetGlobalLeaderboard(c *gin.Context) {
 		fmt.Sscanf(offsetParam, "%d", &offset)
 	}
 
-	leaderboard, err := h.rankingsService.GetGlobalLeaderboard(userID, limit, offset)
+	window, ok := leaderboardWindow(c)
+	if !ok {
+		return
+	}
+
+	leaderboard, err := h.rankingsService.GetGlobalLeaderboard(userID, window, limit, offset)
 	if err != nil {
 		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to get leaderboard", err)
 		return
@@ -63,6 +72,7 @@ func (h *RankingsHandler) GetGlobalLeaderboard(c *gin.Context) {
 // @Security BearerAuth
 // @Produce json
 // @Param school_id path string true "School ID"
+// @Param window query string false "Window: daily, weekly, monthly or all_time (default all_time)"
 // @Param limit query int false "Limit (default 100, max 1000)"
 // @Param offset query int false "Offset (default 0)"
 // @Success 200 {object} models.LeaderboardResponse
@@ -90,7 +100,16 @@ func (h *RankingsHandler) GetSchoolLeaderboard(c *gin.Context) {
 		fmt.Sscanf(offsetParam, "%d", &offset)
 	}
 
-	leaderboard, err := h.rankingsService.GetSchoolLeaderboard(userID, schoolID, limit, offset)
+	window, ok := leaderboardWindow(c)
+	if !ok {
+		return
+	}
+
+	leaderboard, err := h.rankingsService.GetSchoolLeaderboard(userID, schoolID, window, limit, offset)
+	if err != nil && err.Error() == "school not found" {
+		utils.ErrorResponse(c, http.StatusNotFound, "School not found", err)
+		return
+	}
 	if err != nil {
 		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to get school leaderboard", err)
 		return
@@ -99,12 +118,85 @@ func (h *RankingsHandler) GetSchoolLeaderboard(c *gin.Context) {
 	utils.SuccessResponse(c, http.StatusOK, "School leaderboard retrieved", leaderboard)
 }
 
+// GetInterSchoolLeaderboard godoc
+// @Summary Get inter-school leaderboard
+// @Description Rank schools by their top students' average smartpoints (normalized for school size), participation rate and PvP wins
+// @Tags rankings
+// @Security BearerAuth
+// @Produce json
+// @Param window query string false "Window: weekly or all_time (default all_time)"
+// @Param limit query int false "Limit (default 50, max 100)"
+// @Param offset query int false "Offset (default 0)"
+// @Success 200 {object} models.SchoolRankingResponse
+// @Router /rankings/schools [get]
+func (h *RankingsHandler) GetInterSchoolLeaderboard(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "User not authenticated", nil)
+		return
+	}
+
+	window := c.DefaultQuery("window", models.SchoolRankingWindowAllTime)
+	if window != models.SchoolRankingWindowAllTime && window != models.SchoolRankingWindowWeekly {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid window, use weekly or all_time", nil)
+		return
+	}
+
+	limit := 50
+	offset := 0
+
+	if limitParam := c.Query("limit"); limitParam != "" {
+		fmt.Sscanf(limitParam, "%d", &limit)
+	}
+	if offsetParam := c.Query("offset"); offsetParam != "" {
+		fmt.Sscanf(offsetParam, "%d", &offset)
+	}
+
+	leaderboard, err := h.rankingsService.GetInterSchoolLeaderboard(userID, window, limit, offset)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to get inter-school leaderboard", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Inter-school leaderboard retrieved", leaderboard)
+}
+
+// GetSchoolProfile godoc
+// @Summary Get school profile
+// @Description Get a school's public page with its weekly and all-time inter-school standing and top players
+// @Tags rankings
+// @Security BearerAuth
+// @Produce json
+// @Param school_id path string true "School ID"
+// @Success 200 {object} models.SchoolProfileResponse
+// @Router /rankings/schools/{school_id} [get]
+func (h *RankingsHandler) GetSchoolProfile(c *gin.Context) {
+	schoolID := c.Param("school_id")
+	if schoolID == "" {
+		utils.ErrorResponse(c, http.StatusBadRequest, "School ID is required", nil)
+		return
+	}
+
+	profile, err := h.rankingsService.GetSchoolProfile(schoolID)
+	if err != nil && err.Error() == "school not found" {
+		utils.ErrorResponse(c, http.StatusNotFound, "School not found", err)
+		return
+	}
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to get school profile", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "School profile retrieved", profile)
+}
+
 // GetMySchoolLeaderboard godoc
 // @Summary Get my school leaderboard
 // @Description Get the leaderboard for the current user's school
 // @Tags rankings
 // @Security BearerAuth
 // @Produce json
+// @Param window query string false "Window: daily, weekly, monthly or all_time (default all_time)"
 // @Param limit query int false "Limit (default 100, max 1000)"
 // @Param offset query int false "Offset (default 0)"
 // @Success 200 {object} models.LeaderboardResponse
@@ -126,7 +218,12 @@ func (h *RankingsHandler) GetMySchoolLeaderboard(c *gin.Context) {
 		fmt.Sscanf(offsetParam, "%d", &offset)
 	}
 
-	leaderboard, err := h.rankingsService.GetMySchoolLeaderboard(userID, limit, offset)
+	window, ok := leaderboardWindow(c)
+	if !ok {
+		return
+	}
+
+	leaderboard, err := h.rankingsService.GetMySchoolLeaderboard(userID, window, limit, offset)
 	if err != nil {
 		utils.ErrorResponse(c, http.StatusBadRequest, err.Error(), err)
 		return
@@ -141,6 +238,7 @@ func (h *RankingsHandler) GetMySchoolLeaderboard(c *gin.Context) {
 // @Tags rankings
 // @Security BearerAuth
 // @Produce json
+// @Param window query string false "Window: daily, weekly, monthly or all_time (default all_time)"
 // @Success 200 {object} models.UserPositionResponse
 // @Router /rankings/my-position [get]
 func (h *RankingsHandler) GetMyPosition(c *gin.Context) {
@@ -150,7 +248,12 @@ func (h *RankingsHandler) GetMyPosition(c *gin.Context) {
 		return
 	}
 
-	position, err := h.rankingsService.GetUserPosition(userID)
+	window, ok := leaderboardWindow(c)
+	if !ok {
+		return
+	}
+
+	position, err := h.rankingsService.GetUserPosition(userID, window)
 	if err != nil {
 		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to get position", err)
 		return
@@ -159,6 +262,145 @@ func (h *RankingsHandler) GetMyPosition(c *gin.Context) {
 	utils.SuccessResponse(c, http.StatusOK, "Position retrieved", position)
 }
 
+// GetMyRankHistory godoc
+// @Summary Get my rank history
+// @Description Global and school positions from the daily leaderboard snapshots, oldest first. Snapshots older than 90 days are kept weekly (Mondays)
+// @Tags rankings
+// @Security BearerAuth
+// @Produce json
+// @Param days query int false "Days of history (default 30, max 730)"
+// @Success 200 {object} models.RankHistoryResponse
+// @Router /rankings/my-position/history [get]
+func (h *RankingsHandler) GetMyRankHistory(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "User not authenticated", nil)
+		return
+	}
+
+	days := 30
+	if daysParam := c.Query("days"); daysParam != "" {
+		fmt.Sscanf(daysParam, "%d", &days)
+	}
+
+	history, err := h.rankingsService.GetRankHistory(userID, days)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to get rank history", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Rank history retrieved", history)
+}
+
+// GetRankTiers godoc
+// @Summary Get rank tiers
+// @Description Get the rank tier table with the point thresholds and localized names, plus the user's current tier and the points missing for the next one
+// @Tags rankings
+// @Security BearerAuth
+// @Produce json
+// @Param lang query string false "Language of the tier names: es or en (default Accept-Language, then es)"
+// @Success 200 {object} models.RankTiersResponse
+// @Router /rankings/tiers [get]
+func (h *RankingsHandler) GetRankTiers(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "User not authenticated", nil)
+		return
+	}
+
+	tiers, err := h.rankTierService.GetTiers(userID, rankLocale(c))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to get rank tiers", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Rank tiers retrieved", tiers)
+}
+
+// GetMyTierHistory godoc
+// @Summary Get my rank tier changes
+// @Description Get the user's latest rank tier promotions and demotions, most recent first
+// @Tags rankings
+// @Security BearerAuth
+// @Produce json
+// @Param lang query string false "Language of the tier names: es or en (default Accept-Language, then es)"
+// @Param limit query int false "Limit (default 50, max 100)"
+// @Success 200 {object} models.RankTierHistoryResponse
+// @Router /rankings/tiers/history [get]
+func (h *RankingsHandler) GetMyTierHistory(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "User not authenticated", nil)
+		return
+	}
+
+	limit := 50
+	if limitParam := c.Query("limit"); limitParam != "" {
+		fmt.Sscanf(limitParam, "%d", &limit)
+	}
+
+	history, err := h.rankTierService.GetTierHistory(userID, rankLocale(c), limit)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to get rank tier history", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Rank tier history retrieved", history)
+}
+
+// rankLocale idioma pedido para los nombres de los rangos
+func rankLocale(c *gin.Context) string {
+	if lang := c.Query("lang"); lang != "" {
+		return lang
+	}
+	return c.GetHeader("Accept-Language")
+}
+
+// GetDisciplineLeaderboard godoc
+// @Summary Get discipline leaderboard
+// @Description Leaderboard for a single discipline: quiz accuracy, simulator accuracy (optionally for one difficulty), PvP win rate or courses completed. Only players with the minimum activity are ranked; ties go to the player with more activity
+// @Tags rankings
+// @Security BearerAuth
+// @Produce json
+// @Param discipline path string true "Discipline: quiz, simulator, pvp or courses"
+// @Param difficulty query string false "Simulator difficulty: easy, medium or hard"
+// @Param limit query int false "Limit (default 100, max 1000)"
+// @Param offset query int false "Offset (default 0)"
+// @Success 200 {object} models.DisciplineLeaderboardResponse
+// @Router /rankings/disciplines/{discipline} [get]
+func (h *RankingsHandler) GetDisciplineLeaderboard(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "User not authenticated", nil)
+		return
+	}
+
+	limit := 100
+	offset := 0
+
+	if limitParam := c.Query("limit"); limitParam != "" {
+		fmt.Sscanf(limitParam, "%d", &limit)
+	}
+	if offsetParam := c.Query("offset"); offsetParam != "" {
+		fmt.Sscanf(offsetParam, "%d", &offset)
+	}
+
+	leaderboard, err := h.rankingsService.GetDisciplineLeaderboard(userID, c.Param("discipline"), c.Query("difficulty"), limit, offset)
+	if err != nil {
+		switch err.Error() {
+		case "invalid discipline":
+			utils.ErrorResponse(c, http.StatusBadRequest, "Invalid discipline, use quiz, simulator, pvp or courses", err)
+		case "invalid difficulty", "difficulty only applies to the simulator leaderboard":
+			utils.ErrorResponse(c, http.StatusBadRequest, "Invalid difficulty", err)
+		default:
+			utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to get discipline leaderboard", err)
+		}
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Discipline leaderboard retrieved", leaderboard)
+}
+
 // GetPublicProfile godoc
 // @Summary Get public profile
 // @Description Get the public profile of a user
@@ -216,7 +458,7 @@ func (h *RankingsHandler) GetMyAchievements(c *gin.Context) {
 
 // UpdateCache godoc
 // @Summary Update leaderboard cache (Admin only)
-// @Description Force update of the leaderboard cache
+// @Description Force update of the MySQL leaderboard cache and rebuild of the Redis leaderboards
 // @Tags rankings
 // @Security BearerAuth
 // @Produce json
@@ -240,3 +482,17 @@ func (h *RankingsHandler) UpdateCache(c *gin.Context) {
 
 	utils.SuccessResponse(c, http.StatusOK, "Cache updated successfully", nil)
 }
+
+// leaderboardWindow lee el parámetro window de los rankings de jugadores
+// (all_time por defecto). Si es inválido responde 400 y devuelve false.
+func leaderboardWindow(c *gin.Context) (string, bool) {
+	window := c.DefaultQuery("window", models.LeaderboardWindowAllTime)
+	switch window {
+	case models.LeaderboardWindowAllTime, models.LeaderboardWindowDaily,
+		models.LeaderboardWindowWeekly, models.LeaderboardWindowMonthly:
+		return window, true
+	}
+
+	utils.ErrorResponse(c, http.StatusBadRequest, "Invalid window, use daily, weekly, monthly or all_time", nil)
+	return "", false
+}
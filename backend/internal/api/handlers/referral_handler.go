@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/smartstocks/backend/internal/api/middleware"
+	"github.com/smartstocks/backend/internal/services"
+	"github.com/smartstocks/backend/pkg/utils"
+)
+
+type ReferralHandler struct {
+	referralService *services.ReferralService
+}
+
+func NewReferralHandler(referralService *services.ReferralService) *ReferralHandler {
+	return &ReferralHandler{referralService: referralService}
+}
+
+// GetReferrals godoc
+// @Summary My referrals
+// @Description My referral code, the users who registered with it and the tokens earned. The reward is paid when the referee verifies their email and completes a first quiz, up to the monthly and total limits
+// @Tags referrals
+// @Security BearerAuth
+// @Produce json
+// @Param limit query int false "Limit (default 50, max 100)"
+// @Success 200 {object} models.ReferralsResponse
+// @Router /referrals [get]
+func (h *ReferralHandler) GetReferrals(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "User not authenticated", nil)
+		return
+	}
+
+	limit := 50
+	if limitParam := c.Query("limit"); limitParam != "" {
+		fmt.Sscanf(limitParam, "%d", &limit)
+	}
+
+	referrals, err := h.referralService.GetReferrals(userID, limit)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to get referrals", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Referrals retrieved", referrals)
+}
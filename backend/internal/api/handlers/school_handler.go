@@ -0,0 +1,478 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/smartstocks/backend/internal/api/middleware"
+	"github.com/smartstocks/backend/internal/models"
+	"github.com/smartstocks/backend/internal/services"
+	"github.com/smartstocks/backend/pkg/utils"
+)
+
+// maxSchoolImportBytes tamaño máximo del CSV de importación
+const maxSchoolImportBytes = 2 << 20
+
+type SchoolHandler struct {
+	schoolService *services.SchoolService
+}
+
+func NewSchoolHandler(schoolService *services.SchoolService) *SchoolHandler {
+	return &SchoolHandler{schoolService: schoolService}
+}
+
+// === USUARIO ===
+
+// JoinSchool godoc
+// @Summary Request school membership
+// @Description Approved immediately with the school's join code or a verified email from one of its domains; otherwise pending until a school admin reviews it.
+// @Tags schools
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body models.JoinSchoolRequest true "School ID or join code"
+// @Success 200 {object} models.SchoolMembership
+// @Router /schools/join [post]
+func (h *SchoolHandler) JoinSchool(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "User not authenticated", nil)
+		return
+	}
+
+	var req models.JoinSchoolRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	membership, err := h.schoolService.RequestMembership(userID, &req)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Failed to join school", err)
+		return
+	}
+
+	message := "Membership request sent"
+	if membership.Status == models.MembershipStatusApproved {
+		message = "Joined school"
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, message, membership)
+}
+
+// GetMyMembership godoc
+// @Summary Get the current user's school membership
+// @Tags schools
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} models.SchoolMembership
+// @Router /schools/membership [get]
+func (h *SchoolHandler) GetMyMembership(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "User not authenticated", nil)
+		return
+	}
+
+	membership, err := h.schoolService.GetMyMembership(userID)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to get membership", err)
+		return
+	}
+	if membership == nil {
+		utils.ErrorResponse(c, http.StatusNotFound, "Not a member of any school", nil)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Membership retrieved", membership)
+}
+
+// LeaveSchool godoc
+// @Summary Leave the school or cancel the membership request
+// @Tags schools
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Router /schools/membership [delete]
+func (h *SchoolHandler) LeaveSchool(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "User not authenticated", nil)
+		return
+	}
+
+	if err := h.schoolService.LeaveSchool(userID); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Failed to leave school", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Left school", nil)
+}
+
+// === ADMINISTRADOR DEL COLEGIO ===
+
+// GetSchool godoc
+// @Summary Get a school with its join code and email domains
+// @Description School admins and global admins only.
+// @Tags schools
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "School ID"
+// @Success 200 {object} models.SchoolDetail
+// @Router /schools/{id} [get]
+func (h *SchoolHandler) GetSchool(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "User not authenticated", nil)
+		return
+	}
+
+	school, err := h.schoolService.GetSchool(c.Param("id"), userID)
+	if err != nil {
+		schoolErrorResponse(c, "Failed to get school", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "School retrieved", school)
+}
+
+// UpdateSchool godoc
+// @Summary Update a school
+// @Tags schools
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "School ID"
+// @Param request body models.UpdateSchoolRequest true "Fields to update"
+// @Success 200 {object} models.SchoolDetail
+// @Router /schools/{id} [put]
+func (h *SchoolHandler) UpdateSchool(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "User not authenticated", nil)
+		return
+	}
+
+	var req models.UpdateSchoolRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	school, err := h.schoolService.UpdateSchool(c.Param("id"), userID, &req)
+	if err != nil {
+		schoolErrorResponse(c, "Failed to update school", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "School updated", school)
+}
+
+// RegenerateJoinCode godoc
+// @Summary Generate a new school join code
+// @Tags schools
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "School ID"
+// @Success 200 {object} models.SchoolDetail
+// @Router /schools/{id}/join-code [post]
+func (h *SchoolHandler) RegenerateJoinCode(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "User not authenticated", nil)
+		return
+	}
+
+	school, err := h.schoolService.RegenerateJoinCode(c.Param("id"), userID)
+	if err != nil {
+		schoolErrorResponse(c, "Failed to regenerate join code", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Join code regenerated", school)
+}
+
+// DisableJoinCode godoc
+// @Summary Disable joining by code
+// @Tags schools
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "School ID"
+// @Success 200 {object} models.SchoolDetail
+// @Router /schools/{id}/join-code [delete]
+func (h *SchoolHandler) DisableJoinCode(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "User not authenticated", nil)
+		return
+	}
+
+	school, err := h.schoolService.DisableJoinCode(c.Param("id"), userID)
+	if err != nil {
+		schoolErrorResponse(c, "Failed to disable join code", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Join code disabled", school)
+}
+
+// GetMembers godoc
+// @Summary List school members and membership requests
+// @Tags schools
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "School ID"
+// @Param status query string false "pending, approved or rejected"
+// @Success 200 {array} models.SchoolMembership
+// @Router /schools/{id}/members [get]
+func (h *SchoolHandler) GetMembers(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "User not authenticated", nil)
+		return
+	}
+
+	status := c.Query("status")
+	switch status {
+	case "", models.MembershipStatusPending, models.MembershipStatusApproved, models.MembershipStatusRejected:
+	default:
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid status", nil)
+		return
+	}
+
+	members, err := h.schoolService.GetMembers(c.Param("id"), userID, status)
+	if err != nil {
+		schoolErrorResponse(c, "Failed to get members", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Members retrieved", members)
+}
+
+// ApproveMember godoc
+// @Summary Approve a membership request
+// @Tags schools
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "School ID"
+// @Param userId path string true "User ID"
+// @Success 200 {object} models.SchoolMembership
+// @Router /schools/{id}/members/{userId}/approve [post]
+func (h *SchoolHandler) ApproveMember(c *gin.Context) {
+	h.reviewMember(c, true)
+}
+
+// RejectMember godoc
+// @Summary Reject a membership request
+// @Tags schools
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "School ID"
+// @Param userId path string true "User ID"
+// @Success 200 {object} models.SchoolMembership
+// @Router /schools/{id}/members/{userId}/reject [post]
+func (h *SchoolHandler) RejectMember(c *gin.Context) {
+	h.reviewMember(c, false)
+}
+
+func (h *SchoolHandler) reviewMember(c *gin.Context, approve bool) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "User not authenticated", nil)
+		return
+	}
+
+	membership, err := h.schoolService.ReviewMember(c.Param("id"), userID, c.Param("userId"), approve)
+	if err != nil {
+		schoolErrorResponse(c, "Failed to review membership", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Membership "+membership.Status, membership)
+}
+
+// RemoveMember godoc
+// @Summary Remove a member from the school
+// @Tags schools
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "School ID"
+// @Param userId path string true "User ID"
+// @Success 200 {object} map[string]interface{}
+// @Router /schools/{id}/members/{userId} [delete]
+func (h *SchoolHandler) RemoveMember(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "User not authenticated", nil)
+		return
+	}
+
+	if err := h.schoolService.RemoveMember(c.Param("id"), userID, c.Param("userId")); err != nil {
+		schoolErrorResponse(c, "Failed to remove member", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Member removed", nil)
+}
+
+// === ADMINISTRADOR GLOBAL ===
+
+// CreateSchool godoc
+// @Summary Create a school
+// @Description Admins only. Returns the school with its join code.
+// @Tags schools
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body models.CreateSchoolRequest true "School"
+// @Success 201 {object} models.SchoolDetail
+// @Router /schools [post]
+func (h *SchoolHandler) CreateSchool(c *gin.Context) {
+	var req models.CreateSchoolRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	school, err := h.schoolService.CreateSchool(&req)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Failed to create school", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, "School created", school)
+}
+
+// ImportSchools godoc
+// @Summary Bulk import schools from CSV
+// @Description Admins only. Header: name,location,email_domains (domains separated by ";"). Rows with errors are reported and skipped.
+// @Tags schools
+// @Security BearerAuth
+// @Accept multipart/form-data,text/csv
+// @Produce json
+// @Param file formData file false "CSV file (multipart)"
+// @Success 200 {object} models.SchoolImportResult
+// @Router /schools/import [post]
+func (h *SchoolHandler) ImportSchools(c *gin.Context) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxSchoolImportBytes)
+
+	var reader io.Reader = c.Request.Body
+	if strings.HasPrefix(c.ContentType(), "multipart/") {
+		file, err := c.FormFile("file")
+		if err != nil {
+			utils.ErrorResponse(c, http.StatusBadRequest, "CSV file is required", err)
+			return
+		}
+		f, err := file.Open()
+		if err != nil {
+			utils.ErrorResponse(c, http.StatusBadRequest, "Invalid CSV file", err)
+			return
+		}
+		defer f.Close()
+		reader = f
+	}
+
+	result, err := h.schoolService.ImportSchools(reader)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Failed to import schools", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Schools imported", result)
+}
+
+// DeactivateSchool godoc
+// @Summary Deactivate a school
+// @Description Admins only. Hides it from the school list and its leaderboard and blocks new members.
+// @Tags schools
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "School ID"
+// @Success 200 {object} models.SchoolDetail
+// @Router /schools/{id}/deactivate [post]
+func (h *SchoolHandler) DeactivateSchool(c *gin.Context) {
+	h.setSchoolActive(c, false)
+}
+
+// ActivateSchool godoc
+// @Summary Reactivate a school
+// @Tags schools
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "School ID"
+// @Success 200 {object} models.SchoolDetail
+// @Router /schools/{id}/activate [post]
+func (h *SchoolHandler) ActivateSchool(c *gin.Context) {
+	h.setSchoolActive(c, true)
+}
+
+func (h *SchoolHandler) setSchoolActive(c *gin.Context, active bool) {
+	school, err := h.schoolService.SetSchoolActive(c.Param("id"), active)
+	if err != nil {
+		schoolErrorResponse(c, "Failed to update school", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "School updated", school)
+}
+
+// AddSchoolAdmin godoc
+// @Summary Make a user administrator of a school
+// @Tags schools
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "School ID"
+// @Param request body models.SchoolAdminRequest true "User"
+// @Success 200 {object} map[string]interface{}
+// @Router /schools/{id}/admins [post]
+func (h *SchoolHandler) AddSchoolAdmin(c *gin.Context) {
+	var req models.SchoolAdminRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	if err := h.schoolService.AddSchoolAdmin(c.Param("id"), req.UserID); err != nil {
+		schoolErrorResponse(c, "Failed to add school admin", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "School admin added", nil)
+}
+
+// RemoveSchoolAdmin godoc
+// @Summary Remove a school administrator
+// @Tags schools
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "School ID"
+// @Param userId path string true "User ID"
+// @Success 200 {object} map[string]interface{}
+// @Router /schools/{id}/admins/{userId} [delete]
+func (h *SchoolHandler) RemoveSchoolAdmin(c *gin.Context) {
+	if err := h.schoolService.RemoveSchoolAdmin(c.Param("id"), c.Param("userId")); err != nil {
+		schoolErrorResponse(c, "Failed to remove school admin", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "School admin removed", nil)
+}
+
+// schoolErrorResponse traduce los errores del servicio a códigos HTTP
+func schoolErrorResponse(c *gin.Context, message string, err error) {
+	msg := err.Error()
+	switch {
+	case errors.Is(err, services.ErrSchoolForbidden):
+		utils.ErrorResponse(c, http.StatusForbidden, msg, nil)
+	case strings.HasSuffix(msg, " not found"):
+		utils.ErrorResponse(c, http.StatusNotFound, message, err)
+	case strings.Contains(msg, "email domain"), msg == "user is not an administrator of this school":
+		utils.ErrorResponse(c, http.StatusBadRequest, message, err)
+	default:
+		utils.ErrorResponse(c, http.StatusInternalServerError, message, err)
+	}
+}
@@ -0,0 +1,220 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/smartstocks/backend/internal/api/middleware"
+	"github.com/smartstocks/backend/internal/models"
+	"github.com/smartstocks/backend/internal/services"
+	"github.com/smartstocks/backend/pkg/utils"
+)
+
+type SeasonHandler struct {
+	seasonService *services.SeasonService
+}
+
+func NewSeasonHandler(seasonService *services.SeasonService) *SeasonHandler {
+	return &SeasonHandler{seasonService: seasonService}
+}
+
+// === JUGADORES ===
+
+// GetSeasons godoc
+// @Summary List seasons
+// @Description Scheduled, active and finalized seasons with their token rewards
+// @Tags seasons
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {array} models.Season
+// @Router /seasons [get]
+func (h *SeasonHandler) GetSeasons(c *gin.Context) {
+	seasons, err := h.seasonService.GetSeasons()
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to get seasons", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Seasons retrieved", seasons)
+}
+
+// GetCurrentLeaderboard godoc
+// @Summary Current season leaderboard
+// @Description Live ranking by season points of the active season
+// @Tags seasons
+// @Security BearerAuth
+// @Produce json
+// @Param limit query int false "Limit (default 100, max 1000)"
+// @Param offset query int false "Offset (default 0)"
+// @Success 200 {object} models.SeasonLeaderboardResponse
+// @Router /seasons/current [get]
+func (h *SeasonHandler) GetCurrentLeaderboard(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "User not authenticated", nil)
+		return
+	}
+
+	limit, offset := parseSeasonPagination(c)
+	leaderboard, err := h.seasonService.GetCurrentLeaderboard(userID, limit, offset)
+	if err != nil {
+		seasonErrorResponse(c, "Failed to get season leaderboard", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Season leaderboard retrieved", leaderboard)
+}
+
+// GetSeasonLeaderboard godoc
+// @Summary Season leaderboard
+// @Description Live ranking for the active season or archived final standings for a past season
+// @Tags seasons
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Season ID"
+// @Param limit query int false "Limit (default 100, max 1000)"
+// @Param offset query int false "Offset (default 0)"
+// @Success 200 {object} models.SeasonLeaderboardResponse
+// @Router /seasons/{id}/leaderboard [get]
+func (h *SeasonHandler) GetSeasonLeaderboard(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "User not authenticated", nil)
+		return
+	}
+
+	limit, offset := parseSeasonPagination(c)
+	leaderboard, err := h.seasonService.GetSeasonLeaderboard(c.Param("id"), userID, limit, offset)
+	if err != nil {
+		seasonErrorResponse(c, "Failed to get season leaderboard", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Season leaderboard retrieved", leaderboard)
+}
+
+// GetMySeasonHistory godoc
+// @Summary My season history
+// @Description Final position, season points, tier and token reward for every past season played
+// @Tags seasons
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {array} models.SeasonHistoryEntry
+// @Router /seasons/history [get]
+func (h *SeasonHandler) GetMySeasonHistory(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "User not authenticated", nil)
+		return
+	}
+
+	history, err := h.seasonService.GetMySeasonHistory(userID)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to get season history", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Season history retrieved", history)
+}
+
+// === ADMIN ===
+
+// CreateSeason godoc
+// @Summary Schedule a season
+// @Description At rollover final standings are archived, rewards are paid in tokens and season points are reduced to carryover_percent (default 50)
+// @Tags seasons
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body models.CreateSeasonRequest true "Season"
+// @Success 201 {object} models.Season
+// @Router /seasons [post]
+func (h *SeasonHandler) CreateSeason(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "User not authenticated", nil)
+		return
+	}
+
+	var req models.CreateSeasonRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	season, err := h.seasonService.CreateSeason(userID, &req)
+	if err != nil {
+		seasonErrorResponse(c, "Failed to create season", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, "Season created", season)
+}
+
+// DeleteSeason godoc
+// @Summary Delete a scheduled season
+// @Tags seasons
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Season ID"
+// @Success 200 {object} map[string]interface{}
+// @Router /seasons/{id} [delete]
+func (h *SeasonHandler) DeleteSeason(c *gin.Context) {
+	if err := h.seasonService.DeleteSeason(c.Param("id")); err != nil {
+		seasonErrorResponse(c, "Failed to delete season", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Season deleted", nil)
+}
+
+// ProcessRollover godoc
+// @Summary Run season rollover now
+// @Description Finalizes the expired season and activates the next one without waiting for the scheduled job
+// @Tags seasons
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Router /seasons/rollover [post]
+func (h *SeasonHandler) ProcessRollover(c *gin.Context) {
+	if err := h.seasonService.ProcessRollover(); err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to process season rollover", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Season rollover processed", nil)
+}
+
+// === HELPERS ===
+
+func seasonErrorResponse(c *gin.Context, message string, err error) {
+	switch err.Error() {
+	case "season not found", "no active season":
+		utils.ErrorResponse(c, http.StatusNotFound, message, err)
+	case "season has not started yet", "only scheduled seasons can be deleted",
+		"season dates overlap with another season":
+		utils.ErrorResponse(c, http.StatusConflict, message, err)
+	default:
+		// Errores de validación de validateSeason
+		msg := err.Error()
+		if strings.HasPrefix(msg, "season ") || strings.HasPrefix(msg, "reward ") || strings.HasPrefix(msg, "invalid reward") {
+			utils.ErrorResponse(c, http.StatusBadRequest, message, err)
+			return
+		}
+		utils.ErrorResponse(c, http.StatusInternalServerError, message, err)
+	}
+}
+
+func parseSeasonPagination(c *gin.Context) (int, int) {
+	limit := 100
+	offset := 0
+	if limitParam := c.Query("limit"); limitParam != "" {
+		fmt.Sscanf(limitParam, "%d", &limit)
+	}
+	if offsetParam := c.Query("offset"); offsetParam != "" {
+		fmt.Sscanf(offsetParam, "%d", &offset)
+	}
+	return limit, offset
+}
@@ -0,0 +1,276 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/smartstocks/backend/internal/api/middleware"
+	"github.com/smartstocks/backend/internal/models"
+	"github.com/smartstocks/backend/internal/services"
+	"github.com/smartstocks/backend/pkg/utils"
+)
+
+type ShopHandler struct {
+	shopService       *services.ShopService
+	consumableService *services.ConsumableService
+}
+
+func NewShopHandler(shopService *services.ShopService, consumableService *services.ConsumableService) *ShopHandler {
+	return &ShopHandler{
+		shopService:       shopService,
+		consumableService: consumableService,
+	}
+}
+
+// GetCatalog godoc
+// @Summary Shop catalog
+// @Description Cosmetics on sale or upcoming, with availability, ownership and the current token balance
+// @Tags shop
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} models.ShopCatalogResponse
+// @Router /shop/items [get]
+func (h *ShopHandler) GetCatalog(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "User not authenticated", nil)
+		return
+	}
+
+	catalog, err := h.shopService.GetCatalog(userID)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to get shop catalog", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Shop catalog retrieved", catalog)
+}
+
+// PurchaseItem godoc
+// @Summary Buy a cosmetic
+// @Description Spends tokens and adds the item to the inventory in a single transaction
+// @Tags shop
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Item ID"
+// @Param Idempotency-Key header string false "Client key so a retried request does not buy twice (max 100 chars)"
+// @Success 201 {object} models.ShopPurchaseResponse
+// @Router /shop/items/{id}/purchase [post]
+func (h *ShopHandler) PurchaseItem(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "User not authenticated", nil)
+		return
+	}
+
+	idempotencyKey, ok := idempotencyKeyHeader(c)
+	if !ok {
+		return
+	}
+
+	purchase, err := h.shopService.Purchase(userID, c.Param("id"), idempotencyKey)
+	if err != nil {
+		shopErrorResponse(c, "Failed to purchase item", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, "Item purchased", purchase)
+}
+
+// GetInventory godoc
+// @Summary My cosmetics
+// @Tags shop
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {array} models.InventoryItem
+// @Router /shop/inventory [get]
+func (h *ShopHandler) GetInventory(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "User not authenticated", nil)
+		return
+	}
+
+	inventory, err := h.shopService.GetInventory(userID)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to get inventory", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Inventory retrieved", inventory)
+}
+
+// EquipItem godoc
+// @Summary Equip a cosmetic
+// @Description Replaces the equipped cosmetic of the same type
+// @Tags shop
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Item ID"
+// @Success 200 {object} models.InventoryItem
+// @Router /shop/inventory/{id}/equip [post]
+func (h *ShopHandler) EquipItem(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "User not authenticated", nil)
+		return
+	}
+
+	item, err := h.shopService.Equip(userID, c.Param("id"))
+	if err != nil {
+		shopErrorResponse(c, "Failed to equip item", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Item equipped", item)
+}
+
+// UnequipItem godoc
+// @Summary Unequip a cosmetic
+// @Tags shop
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Item ID"
+// @Success 200 {object} models.InventoryItem
+// @Router /shop/inventory/{id}/unequip [post]
+func (h *ShopHandler) UnequipItem(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "User not authenticated", nil)
+		return
+	}
+
+	item, err := h.shopService.Unequip(userID, c.Param("id"))
+	if err != nil {
+		shopErrorResponse(c, "Failed to unequip item", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Item unequipped", item)
+}
+
+// GetConsumables godoc
+// @Summary Consumable prices and my purchases
+// @Description Token prices of simulator hints, extra simulator/quiz attempts and PvP second chances, with the latest purchases
+// @Tags shop
+// @Security BearerAuth
+// @Produce json
+// @Param limit query int false "Purchases to return (default 20, max 100)"
+// @Success 200 {object} models.ConsumablesResponse
+// @Router /shop/consumables [get]
+func (h *ShopHandler) GetConsumables(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "User not authenticated", nil)
+		return
+	}
+
+	limit := 20
+	if limitParam := c.Query("limit"); limitParam != "" {
+		if _, err := fmt.Sscanf(limitParam, "%d", &limit); err != nil {
+			limit = 20
+		}
+	}
+
+	consumables, err := h.consumableService.GetConsumables(userID, limit)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to get consumables", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Consumables retrieved", consumables)
+}
+
+// === ADMIN ===
+
+// CreateItem godoc
+// @Summary Add a cosmetic to the catalog
+// @Tags shop
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body models.CreateShopItemRequest true "Item"
+// @Success 201 {object} models.ShopItem
+// @Router /shop/items [post]
+func (h *ShopHandler) CreateItem(c *gin.Context) {
+	var req models.CreateShopItemRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	item, err := h.shopService.CreateItem(&req)
+	if err != nil {
+		shopErrorResponse(c, "Failed to create item", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, "Item created", item)
+}
+
+// UpdateItem godoc
+// @Summary Update a catalog cosmetic
+// @Description Replaces the editable fields. Deactivated items stay in the inventories of their owners
+// @Tags shop
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "Item ID"
+// @Param request body models.UpdateShopItemRequest true "Item"
+// @Success 200 {object} models.ShopItem
+// @Router /shop/items/{id} [put]
+func (h *ShopHandler) UpdateItem(c *gin.Context) {
+	var req models.UpdateShopItemRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	item, err := h.shopService.UpdateItem(c.Param("id"), &req)
+	if err != nil {
+		shopErrorResponse(c, "Failed to update item", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Item updated", item)
+}
+
+// === HELPERS ===
+
+func shopErrorResponse(c *gin.Context, message string, err error) {
+	switch err.Error() {
+	case "item not found", "item not owned":
+		utils.ErrorResponse(c, http.StatusNotFound, message, err)
+	case "item not available", "item already owned", "item code already exists", "duplicate transaction":
+		utils.ErrorResponse(c, http.StatusConflict, message, err)
+	case "insufficient tokens":
+		utils.ErrorResponse(c, http.StatusBadRequest, message, err)
+	default:
+		if strings.HasPrefix(err.Error(), "available_until ") {
+			utils.ErrorResponse(c, http.StatusBadRequest, message, err)
+			return
+		}
+		utils.ErrorResponse(c, http.StatusInternalServerError, message, err)
+	}
+}
+
+// consumableErrorResponse errores al comprar consumibles (simulador, quiz y PvP)
+func consumableErrorResponse(c *gin.Context, message string, err error) {
+	switch err.Error() {
+	case "scenario not found", "quiz not found", "match not found", "round not found":
+		utils.ErrorResponse(c, http.StatusNotFound, message, err)
+	case "you are not part of this match":
+		utils.ErrorResponse(c, http.StatusForbidden, message, err)
+	case "hint already purchased", "extra attempt already available", "second chance already used in this match",
+		"daily attempt still available", "round already resolved", "consumable not available", "duplicate transaction":
+		utils.ErrorResponse(c, http.StatusConflict, message, err)
+	case "daily extra attempt limit reached":
+		utils.ErrorResponse(c, http.StatusTooManyRequests, message, err)
+	case "insufficient tokens", "no decision to change", "scenario is no longer active or has expired":
+		utils.ErrorResponse(c, http.StatusBadRequest, message, err)
+	default:
+		utils.ErrorResponse(c, http.StatusInternalServerError, message, err)
+	}
+}
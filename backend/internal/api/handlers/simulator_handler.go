@@ -101,6 +101,84 @@ func (h *SimulatorHandler) SubmitDecision(c *gin.Context) {
 	utils.SuccessResponse(c, http.StatusOK, "Decision submitted successfully", result)
 }
 
+// BuyHint godoc
+// @Summary Buy a simulator hint
+// @Description Spends tokens to reveal one technical-analysis clue from the visible prices of the scenario. Buying again for the same scenario returns the same clue without charging
+// @Tags simulator
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body models.SimulatorHintRequest true "Scenario"
+// @Param Idempotency-Key header string false "Client key so a retried request does not charge twice (max 100 chars)"
+// @Success 200 {object} models.SimulatorHint
+// @Failure 400 {object} utils.Response "Insufficient tokens"
+// @Router /simulator/hint [post]
+func (h *SimulatorHandler) BuyHint(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "User not authenticated", nil)
+		return
+	}
+
+	var req models.SimulatorHintRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	idempotencyKey, ok := idempotencyKeyHeader(c)
+	if !ok {
+		return
+	}
+
+	hint, err := h.simulatorService.BuyHint(userID, req.ScenarioID, idempotencyKey)
+	if err != nil {
+		consumableErrorResponse(c, "Failed to buy hint", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Hint retrieved", hint)
+}
+
+// BuyExtraAttempt godoc
+// @Summary Buy an extra simulator attempt
+// @Description Spends tokens to attempt the active scenario of the difficulty again today. A correct extra attempt earns extra_attempt_points_percent of the points and does not count toward accuracy, achievements or assignments; purchases per day are limited to extra_attempts_per_day (see consumable prices)
+// @Tags simulator
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body models.ExtraAttemptRequest true "Difficulty"
+// @Param Idempotency-Key header string false "Client key so a retried request does not charge twice (max 100 chars)"
+// @Success 201 {object} models.SimulatorExtraAttemptResponse
+// @Failure 409 {object} utils.Response "Daily attempt still available or extra attempt already bought"
+// @Router /simulator/extra-attempt [post]
+func (h *SimulatorHandler) BuyExtraAttempt(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "User not authenticated", nil)
+		return
+	}
+
+	var req models.ExtraAttemptRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	idempotencyKey, ok := idempotencyKeyHeader(c)
+	if !ok {
+		return
+	}
+
+	result, err := h.simulatorService.BuyExtraAttempt(userID, models.SimulatorDifficulty(req.Difficulty), idempotencyKey)
+	if err != nil {
+		consumableErrorResponse(c, "Failed to buy extra attempt", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, "Extra attempt purchased", result)
+}
+
 // GetHistory godoc
 // @Summary Get simulator history
 // @Description Get the user's simulator attempt history with statistics
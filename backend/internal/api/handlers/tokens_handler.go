@@ -1,22 +1,41 @@
 package handlers
 
 import (
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/smartstocks/backend/internal/api/middleware"
+	"github.com/smartstocks/backend/internal/models"
 	"github.com/smartstocks/backend/internal/services"
 	"github.com/smartstocks/backend/pkg/utils"
 )
 
 type TokensHandler struct {
-	tokensService *services.TokensService
+	tokensService      *services.TokensService
+	dailyRewardService *services.DailyRewardService
+	adjustmentService  *services.TokenAdjustmentService
+	giftService        *services.TokenGiftService
+	accountLinkService *services.AccountLinkService
 }
 
-func NewTokensHandler(tokensService *services.TokensService) *TokensHandler {
+func NewTokensHandler(
+	tokensService *services.TokensService,
+	dailyRewardService *services.DailyRewardService,
+	adjustmentService *services.TokenAdjustmentService,
+	giftService *services.TokenGiftService,
+	accountLinkService *services.AccountLinkService,
+) *TokensHandler {
 	return &TokensHandler{
-		tokensService: tokensService,
+		tokensService:      tokensService,
+		dailyRewardService: dailyRewardService,
+		adjustmentService:  adjustmentService,
+		giftService:        giftService,
+		accountLinkService: accountLinkService,
 	}
 }
 
@@ -52,12 +71,16 @@ func (h *TokensHandler) GetMyTokens(c *gin.Context) {
 
 // GetTransactionHistory godoc
 // @Summary Get transaction history
-// @Description Get full transaction history
+// @Description Transactions from newest to oldest with cursor pagination. Pass the next_cursor of the response to get the following page
 // @Tags tokens
 // @Security BearerAuth
 // @Produce json
+// @Param type query string false "Transaction types, comma separated (e.g. daily_bonus,purchase)"
+// @Param from query string false "Start date (YYYY-MM-DD)"
+// @Param to query string false "End date inclusive (YYYY-MM-DD)"
+// @Param cursor query string false "next_cursor of the previous page"
 // @Param limit query int false "Limit (default 50, max 100)"
-// @Success 200 {array} models.TokenTransaction
+// @Success 200 {object} models.TokenTransactionPage
 // @Router /tokens/transactions [get]
 func (h *TokensHandler) GetTransactionHistory(c *gin.Context) {
 	userID, exists := middleware.GetUserID(c)
@@ -66,16 +89,710 @@ func (h *TokensHandler) GetTransactionHistory(c *gin.Context) {
 		return
 	}
 
+	filter, err := parseTokenHistoryFilter(c)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid filters", err)
+		return
+	}
+
+	page, err := h.tokensService.GetTransactionHistory(userID, filter)
+	if err != nil {
+		tokenHistoryErrorResponse(c, "Failed to get transactions", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Transactions retrieved", page)
+}
+
+// GetTransactionSummary godoc
+// @Summary Weekly token summary
+// @Description Tokens earned and spent per week (Monday to Sunday). Defaults to the last 12 weeks; weeks without transactions are returned with zeros
+// @Tags tokens
+// @Security BearerAuth
+// @Produce json
+// @Param type query string false "Transaction types, comma separated"
+// @Param from query string false "Start date (YYYY-MM-DD)"
+// @Param to query string false "End date inclusive (YYYY-MM-DD), default today"
+// @Success 200 {object} models.TokenHistorySummary
+// @Router /tokens/transactions/summary [get]
+func (h *TokensHandler) GetTransactionSummary(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "User not authenticated", nil)
+		return
+	}
+
+	filter, err := parseTokenHistoryFilter(c)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid filters", err)
+		return
+	}
+
+	summary, err := h.tokensService.GetHistorySummary(userID, filter)
+	if err != nil {
+		tokenHistoryErrorResponse(c, "Failed to get summary", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Summary retrieved", summary)
+}
+
+// ExportTransactions godoc
+// @Summary Export transaction history
+// @Description Full filtered history as CSV, from newest to oldest
+// @Tags tokens
+// @Security BearerAuth
+// @Produce text/csv
+// @Param type query string false "Transaction types, comma separated"
+// @Param from query string false "Start date (YYYY-MM-DD)"
+// @Param to query string false "End date inclusive (YYYY-MM-DD)"
+// @Success 200 {file} file
+// @Router /tokens/transactions/export [get]
+func (h *TokensHandler) ExportTransactions(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "User not authenticated", nil)
+		return
+	}
+
+	filter, err := parseTokenHistoryFilter(c)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid filters", err)
+		return
+	}
+	if filter.Cursor != "" {
+		utils.ErrorResponse(c, http.StatusBadRequest, "cursor is not supported in exports", nil)
+		return
+	}
+
+	filename := fmt.Sprintf("tokens-%s.csv", time.Now().Format("2006-01-02"))
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Cache-Control", "no-store")
+	c.Status(http.StatusOK)
+	if err := h.tokensService.WriteHistoryCSV(c.Writer, userID, filter); err != nil {
+		// Los headers ya se enviaron; solo queda cortar la respuesta
+		_ = c.Error(err)
+	}
+}
+
+// GetDailyReward godoc
+// @Summary Get daily reward status
+// @Description Get the login streak, available streak freezes, whether today's reward was claimed (in the user's timezone) and the reward of the next check-in
+// @Tags tokens
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} models.DailyRewardStatus
+// @Router /tokens/daily-reward [get]
+func (h *TokensHandler) GetDailyReward(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "User not authenticated", nil)
+		return
+	}
+
+	status, err := h.dailyRewardService.GetStatus(userID)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to get daily reward", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Daily reward retrieved", status)
+}
+
+// ClaimDailyReward godoc
+// @Summary Claim daily reward
+// @Description Daily check-in: grants tokens that grow with the consecutive-day streak, plus a bonus and a streak freeze on milestones. Missed days are covered by streak freezes when there are enough. The optional timezone is saved for the following days
+// @Tags tokens
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body models.DailyCheckInRequest false "IANA timezone"
+// @Success 200 {object} models.DailyCheckInResponse
+// @Failure 409 {object} map[string]interface{}
+// @Router /tokens/daily-reward [post]
+func (h *TokensHandler) ClaimDailyReward(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "User not authenticated", nil)
+		return
+	}
+
+	var req models.DailyCheckInRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request body", err)
+			return
+		}
+	}
+
+	result, err := h.dailyRewardService.CheckIn(userID, req.Timezone)
+	if err != nil {
+		switch err.Error() {
+		case "invalid timezone":
+			utils.ErrorResponse(c, http.StatusBadRequest, err.Error(), nil)
+		case "daily reward already claimed":
+			utils.ErrorResponse(c, http.StatusConflict, err.Error(), nil)
+		default:
+			utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to claim daily reward", err)
+		}
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Daily reward claimed", result)
+}
+
+// BuyStreakFreeze godoc
+// @Summary Buy a streak freeze
+// @Description Spend tokens on a streak freeze, which covers one missed day of the login streak
+// @Tags tokens
+// @Security BearerAuth
+// @Produce json
+// @Param Idempotency-Key header string false "Client key so a retried request does not buy twice (max 100 chars)"
+// @Success 200 {object} models.StreakFreezeResponse
+// @Failure 409 {object} map[string]interface{}
+// @Router /tokens/streak-freezes [post]
+func (h *TokensHandler) BuyStreakFreeze(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "User not authenticated", nil)
+		return
+	}
+
+	idempotencyKey, ok := idempotencyKeyHeader(c)
+	if !ok {
+		return
+	}
+
+	result, err := h.dailyRewardService.BuyStreakFreeze(userID, idempotencyKey)
+	if err != nil {
+		switch err.Error() {
+		case "insufficient tokens":
+			utils.ErrorResponse(c, http.StatusBadRequest, err.Error(), nil)
+		case "streak freeze limit reached", "streak freezes are not available", "duplicate transaction":
+			utils.ErrorResponse(c, http.StatusConflict, err.Error(), nil)
+		default:
+			utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to buy streak freeze", err)
+		}
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Streak freeze purchased", result)
+}
+
+// SendGift godoc
+// @Summary Gift tokens to another user
+// @Description Debits the sender and credits the recipient in a single transaction and notifies the recipient. Subject to daily limits, a minimum account age and a block between linked accounts
+// @Tags tokens
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param Idempotency-Key header string false "Client key so a retried request does not gift twice (max 100 chars)"
+// @Param request body models.SendTokenGiftRequest true "Gift"
+// @Success 201 {object} models.TokenGift
+// @Router /tokens/gifts [post]
+func (h *TokensHandler) SendGift(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "User not authenticated", nil)
+		return
+	}
+
+	idempotencyKey, ok := idempotencyKeyHeader(c)
+	if !ok {
+		return
+	}
+
+	var req models.SendTokenGiftRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	gift, err := h.giftService.SendGift(userID, &req, idempotencyKey)
+	if err != nil {
+		tokenGiftErrorResponse(c, "Failed to send gift", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, "Gift sent", gift)
+}
+
+// GetGifts godoc
+// @Summary Gifts received or sent
+// @Tags tokens
+// @Security BearerAuth
+// @Produce json
+// @Param direction query string false "received (default) or sent"
+// @Param limit query int false "Limit (default 50, max 100)"
+// @Success 200 {array} models.TokenGift
+// @Router /tokens/gifts [get]
+func (h *TokensHandler) GetGifts(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "User not authenticated", nil)
+		return
+	}
+
+	limit := 50
+	if limitParam := c.Query("limit"); limitParam != "" {
+		fmt.Sscanf(limitParam, "%d", &limit)
+	}
+
+	gifts, err := h.giftService.GetGifts(userID, c.DefaultQuery("direction", models.TokenGiftsReceived), limit)
+	if err != nil {
+		tokenGiftErrorResponse(c, "Failed to get gifts", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Gifts retrieved", gifts)
+}
+
+// GetGiftLimits godoc
+// @Summary Gift limits
+// @Description Tokens and gifts sent in the last 24 hours, what is left and whether the account is old enough to send gifts
+// @Tags tokens
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} models.TokenGiftLimits
+// @Router /tokens/gifts/limits [get]
+func (h *TokensHandler) GetGiftLimits(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "User not authenticated", nil)
+		return
+	}
+
+	limits, err := h.giftService.GetLimits(userID)
+	if err != nil {
+		tokenGiftErrorResponse(c, "Failed to get gift limits", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Gift limits retrieved", limits)
+}
+
+// === ADMIN ===
+
+// Reconcile godoc
+// @Summary Reconcile token balances
+// @Description Recomputes every balance, total earned and total spent from the transaction log and reports the accounts that drifted. With repair the stored balances are corrected (negative ledger balances are only reported)
+// @Tags tokens
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body models.ReconcileTokensRequest false "Options"
+// @Success 200 {object} models.TokenReconciliationReport
+// @Router /tokens/admin/reconciliations [post]
+func (h *TokensHandler) Reconcile(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "User not authenticated", nil)
+		return
+	}
+
+	var req models.ReconcileTokensRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request body", err)
+			return
+		}
+	}
+
+	report, err := h.tokensService.Reconcile(userID, req.Repair)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to reconcile token balances", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Token balances reconciled", report)
+}
+
+// GetReconciliations godoc
+// @Summary List reconciliation runs
+// @Description Daily job runs (report only) and manual runs, newest first
+// @Tags tokens
+// @Security BearerAuth
+// @Produce json
+// @Param limit query int false "Limit (default 20, max 100)"
+// @Success 200 {array} models.TokenReconciliationRun
+// @Router /tokens/admin/reconciliations [get]
+func (h *TokensHandler) GetReconciliations(c *gin.Context) {
+	limit := 20
+	if limitParam := c.Query("limit"); limitParam != "" {
+		fmt.Sscanf(limitParam, "%d", &limit)
+	}
+
+	runs, err := h.tokensService.GetReconciliations(limit)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to get reconciliations", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Reconciliations retrieved", runs)
+}
+
+// GetReconciliation godoc
+// @Summary Reconciliation report
+// @Tags tokens
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Run ID"
+// @Success 200 {object} models.TokenReconciliationReport
+// @Router /tokens/admin/reconciliations/{id} [get]
+func (h *TokensHandler) GetReconciliation(c *gin.Context) {
+	report, err := h.tokensService.GetReconciliation(c.Param("id"))
+	if err != nil {
+		if err.Error() == "reconciliation not found" {
+			utils.ErrorResponse(c, http.StatusNotFound, err.Error(), nil)
+			return
+		}
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to get reconciliation", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Reconciliation retrieved", report)
+}
+
+// AdjustTokens godoc
+// @Summary Grant or deduct tokens
+// @Description Records the acting admin and the reason. Deductions cannot leave a negative balance
+// @Tags tokens
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param Idempotency-Key header string false "Client key so a retried request is not applied twice (max 100 chars)"
+// @Param request body models.TokenAdjustmentRequest true "Adjustment"
+// @Success 201 {object} models.TokenAdjustment
+// @Router /tokens/admin/adjustments [post]
+func (h *TokensHandler) AdjustTokens(c *gin.Context) {
+	adminID, exists := middleware.GetUserID(c)
+	if !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "User not authenticated", nil)
+		return
+	}
+
+	idempotencyKey, ok := idempotencyKeyHeader(c)
+	if !ok {
+		return
+	}
+
+	var req models.TokenAdjustmentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	adjustment, err := h.adjustmentService.Adjust(adminID, &req, idempotencyKey)
+	if err != nil {
+		tokenAdjustmentErrorResponse(c, "Failed to adjust tokens", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, "Tokens adjusted", adjustment)
+}
+
+// RefundTransaction godoc
+// @Summary Refund a token debit
+// @Description Credits back the full amount of a debit. Only admin deductions and tournament entries can be refunded; purchases and sent gifts cannot. The refund references the original transaction, which can only be refunded once
+// @Tags tokens
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body models.TokenRefundRequest true "Refund"
+// @Success 201 {object} models.TokenAdjustment
+// @Router /tokens/admin/refunds [post]
+func (h *TokensHandler) RefundTransaction(c *gin.Context) {
+	adminID, exists := middleware.GetUserID(c)
+	if !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "User not authenticated", nil)
+		return
+	}
+
+	var req models.TokenRefundRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	adjustment, err := h.adjustmentService.Refund(adminID, &req)
+	if err != nil {
+		tokenAdjustmentErrorResponse(c, "Failed to refund transaction", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, "Transaction refunded", adjustment)
+}
+
+// ImportAdjustments godoc
+// @Summary Bulk token adjustments from CSV
+// @Description Header: user,action,amount,reason,transaction_id. user is the user ID or email; action is grant, deduct or refund (refunds use transaction_id instead of user and amount). Rows with errors are reported and skipped. Resending a file with the same Idempotency-Key does not apply its rows again
+// @Tags tokens
+// @Security BearerAuth
+// @Accept multipart/form-data,text/csv
+// @Produce json
+// @Param Idempotency-Key header string false "Batch key (max 100 chars)"
+// @Param file formData file false "CSV file (multipart)"
+// @Success 200 {object} models.TokenAdjustmentImportResult
+// @Router /tokens/admin/adjustments/bulk [post]
+func (h *TokensHandler) ImportAdjustments(c *gin.Context) {
+	adminID, exists := middleware.GetUserID(c)
+	if !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "User not authenticated", nil)
+		return
+	}
+
+	batchKey, ok := idempotencyKeyHeader(c)
+	if !ok {
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxTokenAdjustmentBytes)
+
+	var reader io.Reader = c.Request.Body
+	if strings.HasPrefix(c.ContentType(), "multipart/") {
+		file, err := c.FormFile("file")
+		if err != nil {
+			utils.ErrorResponse(c, http.StatusBadRequest, "CSV file is required", err)
+			return
+		}
+		f, err := file.Open()
+		if err != nil {
+			utils.ErrorResponse(c, http.StatusBadRequest, "Invalid CSV file", err)
+			return
+		}
+		defer f.Close()
+		reader = f
+	}
+
+	result, err := h.adjustmentService.ImportAdjustments(adminID, reader, batchKey)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Failed to import adjustments", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Adjustments imported", result)
+}
+
+// GetAdjustments godoc
+// @Summary Token adjustments audit log
+// @Tags tokens
+// @Security BearerAuth
+// @Produce json
+// @Param user_id query string false "Only adjustments of this user"
+// @Param limit query int false "Limit (default 50, max 200)"
+// @Success 200 {array} models.TokenAdjustment
+// @Router /tokens/admin/adjustments [get]
+func (h *TokensHandler) GetAdjustments(c *gin.Context) {
 	limit := 50
 	if limitParam := c.Query("limit"); limitParam != "" {
 		fmt.Sscanf(limitParam, "%d", &limit)
 	}
 
-	transactions, err := h.tokensService.GetTransactionHistory(userID, limit)
+	adjustments, err := h.adjustmentService.GetAdjustments(c.Query("user_id"), limit)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to get adjustments", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Adjustments retrieved", adjustments)
+}
+
+// GetAccountLinks godoc
+// @Summary Linked accounts
+// @Description Account pairs detected as sharing a device or flagged by an admin. Transfers between linked accounts are blocked
+// @Tags tokens
+// @Security BearerAuth
+// @Produce json
+// @Param status query string false "linked or dismissed (default all)"
+// @Param limit query int false "Limit (default 50, max 200)"
+// @Success 200 {array} models.AccountLink
+// @Router /tokens/admin/account-links [get]
+func (h *TokensHandler) GetAccountLinks(c *gin.Context) {
+	limit := 50
+	if limitParam := c.Query("limit"); limitParam != "" {
+		fmt.Sscanf(limitParam, "%d", &limit)
+	}
+
+	links, err := h.accountLinkService.GetLinks(c.Query("status"), limit)
+	if err != nil {
+		accountLinkErrorResponse(c, "Failed to get account links", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Account links retrieved", links)
+}
+
+// FlagAccountLink godoc
+// @Summary Flag two accounts as linked
+// @Tags tokens
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body models.FlagAccountLinkRequest true "Accounts"
+// @Success 201 {object} models.AccountLink
+// @Router /tokens/admin/account-links [post]
+func (h *TokensHandler) FlagAccountLink(c *gin.Context) {
+	adminID, exists := middleware.GetUserID(c)
+	if !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "User not authenticated", nil)
+		return
+	}
+
+	var req models.FlagAccountLinkRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	link, err := h.accountLinkService.FlagLink(adminID, &req)
 	if err != nil {
-		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to get transactions", err)
+		accountLinkErrorResponse(c, "Failed to flag accounts", err)
 		return
 	}
 
-	utils.SuccessResponse(c, http.StatusOK, "Transactions retrieved", transactions)
+	utils.SuccessResponse(c, http.StatusCreated, "Accounts flagged as linked", link)
+}
+
+// UpdateAccountLink godoc
+// @Summary Confirm or dismiss a linked accounts flag
+// @Description A dismissed pair can transfer again and is not flagged again by the automatic detection
+// @Tags tokens
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "Account link ID"
+// @Param request body models.UpdateAccountLinkRequest true "Status"
+// @Success 200 {object} models.AccountLink
+// @Router /tokens/admin/account-links/{id} [put]
+func (h *TokensHandler) UpdateAccountLink(c *gin.Context) {
+	adminID, exists := middleware.GetUserID(c)
+	if !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "User not authenticated", nil)
+		return
+	}
+
+	var req models.UpdateAccountLinkRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	link, err := h.accountLinkService.UpdateLink(adminID, c.Param("id"), &req)
+	if err != nil {
+		accountLinkErrorResponse(c, "Failed to update account link", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Account link updated", link)
+}
+
+// === HELPERS ===
+
+// maxTokenAdjustmentBytes tamaño máximo del CSV de ajustes
+const maxTokenAdjustmentBytes = 2 << 20
+
+// maxIdempotencyKeyLength largo máximo del header Idempotency-Key
+const maxIdempotencyKeyLength = 100
+
+// idempotencyKeyHeader lee el header Idempotency-Key (opcional). Si no es
+// válido responde 400 y devuelve false.
+func idempotencyKeyHeader(c *gin.Context) (string, bool) {
+	key := strings.TrimSpace(c.GetHeader("Idempotency-Key"))
+	if len(key) > maxIdempotencyKeyLength {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Idempotency-Key must be at most 100 characters", nil)
+		return "", false
+	}
+	return key, true
+}
+
+func tokenAdjustmentErrorResponse(c *gin.Context, message string, err error) {
+	switch err.Error() {
+	case "user not found", "transaction not found":
+		utils.ErrorResponse(c, http.StatusNotFound, message, err)
+	case "transaction already refunded", "duplicate transaction":
+		utils.ErrorResponse(c, http.StatusConflict, message, err)
+	case "insufficient tokens", "only debits can be refunded", "transaction type cannot be refunded":
+		utils.ErrorResponse(c, http.StatusBadRequest, message, err)
+	default:
+		utils.ErrorResponse(c, http.StatusInternalServerError, message, err)
+	}
+}
+
+// parseTokenHistoryFilter lee ?type=&from=&to=&cursor=&limit= (fechas
+// YYYY-MM-DD, "to" inclusive)
+func parseTokenHistoryFilter(c *gin.Context) (models.TokenHistoryFilter, error) {
+	filter := models.TokenHistoryFilter{Cursor: c.Query("cursor")}
+
+	for _, t := range strings.Split(c.Query("type"), ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			filter.Types = append(filter.Types, t)
+		}
+	}
+
+	if raw := c.Query("from"); raw != "" {
+		from, err := time.ParseInLocation("2006-01-02", raw, time.Local)
+		if err != nil {
+			return filter, errors.New("from must be YYYY-MM-DD")
+		}
+		filter.From = &from
+	}
+	if raw := c.Query("to"); raw != "" {
+		to, err := time.ParseInLocation("2006-01-02", raw, time.Local)
+		if err != nil {
+			return filter, errors.New("to must be YYYY-MM-DD")
+		}
+		// El límite superior es exclusivo: incluir todo el día "to"
+		to = to.AddDate(0, 0, 1)
+		filter.To = &to
+	}
+	if filter.From != nil && filter.To != nil && !filter.From.Before(*filter.To) {
+		return filter, errors.New("from must be before to")
+	}
+
+	if limitParam := c.Query("limit"); limitParam != "" {
+		fmt.Sscanf(limitParam, "%d", &filter.Limit)
+	}
+
+	return filter, nil
+}
+
+func tokenHistoryErrorResponse(c *gin.Context, message string, err error) {
+	switch {
+	case err.Error() == "invalid cursor", err.Error() == "from must be before to",
+		strings.HasPrefix(err.Error(), "invalid transaction type"),
+		strings.HasPrefix(err.Error(), "date range exceeds"):
+		utils.ErrorResponse(c, http.StatusBadRequest, message, err)
+	default:
+		utils.ErrorResponse(c, http.StatusInternalServerError, message, err)
+	}
+}
+
+func tokenGiftErrorResponse(c *gin.Context, message string, err error) {
+	switch err.Error() {
+	case "user not found", "recipient not found":
+		utils.ErrorResponse(c, http.StatusNotFound, message, err)
+	case "account too new to send gifts", "recipient account too new to receive gifts",
+		"transfers between linked accounts are not allowed":
+		utils.ErrorResponse(c, http.StatusForbidden, message, err)
+	case "daily gift limit reached", "daily gift token limit exceeded":
+		utils.ErrorResponse(c, http.StatusTooManyRequests, message, err)
+	case "duplicate transaction":
+		utils.ErrorResponse(c, http.StatusConflict, message, err)
+	case "insufficient tokens", "cannot gift tokens to yourself", "direction must be sent or received":
+		utils.ErrorResponse(c, http.StatusBadRequest, message, err)
+	default:
+		utils.ErrorResponse(c, http.StatusInternalServerError, message, err)
+	}
+}
+
+func accountLinkErrorResponse(c *gin.Context, message string, err error) {
+	switch err.Error() {
+	case "user not found", "account link not found":
+		utils.ErrorResponse(c, http.StatusNotFound, message, err)
+	case "an account cannot be linked to itself", "invalid status":
+		utils.ErrorResponse(c, http.StatusBadRequest, message, err)
+	default:
+		utils.ErrorResponse(c, http.StatusInternalServerError, message, err)
+	}
 }
@@ -7,18 +7,25 @@ import (
 	"github.com/smartstocks/backend/internal/api/middleware"
 	"github.com/smartstocks/backend/internal/models"
 	"github.com/smartstocks/backend/internal/repository"
+	"github.com/smartstocks/backend/internal/services"
 	"github.com/smartstocks/backend/pkg/utils"
 )
 
 type UserHandler struct {
-	userRepo   *repository.UserRepository
-	schoolRepo *repository.SchoolRepository
+	userRepo      *repository.UserRepository
+	schoolRepo    *repository.SchoolRepository
+	schoolService *services.SchoolService
 }
 
-func NewUserHandler(userRepo *repository.UserRepository, schoolRepo *repository.SchoolRepository) *UserHandler {
+func NewUserHandler(
+	userRepo *repository.UserRepository,
+	schoolRepo *repository.SchoolRepository,
+	schoolService *services.SchoolService,
+) *UserHandler {
 	return &UserHandler{
-		userRepo:   userRepo,
-		schoolRepo: schoolRepo,
+		userRepo:      userRepo,
+		schoolRepo:    schoolRepo,
+		schoolService: schoolService,
 	}
 }
 
@@ -70,6 +77,8 @@ func (h *UserHandler) GetUserStats(c *gin.Context) {
 
 // UpdateProfile godoc
 // @Summary Update user profile
+// @Description school_id no longer sets the school directly: it requests membership,
+// @Description which is approved by join code, institutional email domain or a school admin.
 // @Tags user
 // @Security BearerAuth
 // @Accept json
@@ -90,8 +99,27 @@ func (h *UserHandler) UpdateProfile(c *gin.Context) {
 		return
 	}
 
-	if err := h.userRepo.UpdateProfile(userID, &req); err != nil {
-		utils.ErrorResponse(c, http.StatusBadRequest, "Profile update failed", err)
+	// El colegio pasa por la verificación de membresía
+	var membership *models.SchoolMembership
+	if req.SchoolID != nil {
+		var err error
+		membership, err = h.schoolService.RequestMembership(userID, &models.JoinSchoolRequest{SchoolID: req.SchoolID})
+		if err != nil {
+			utils.ErrorResponse(c, http.StatusBadRequest, "Profile update failed", err)
+			return
+		}
+		req.SchoolID = nil
+	}
+
+	if req.Username != nil || req.ProfilePictureURL != nil || membership == nil {
+		if err := h.userRepo.UpdateProfile(userID, &req); err != nil {
+			utils.ErrorResponse(c, http.StatusBadRequest, "Profile update failed", err)
+			return
+		}
+	}
+
+	if membership != nil {
+		utils.SuccessResponse(c, http.StatusOK, "Profile updated successfully", gin.H{"school_membership": membership})
 		return
 	}
 
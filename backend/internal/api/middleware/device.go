@@ -0,0 +1,44 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// deviceCookie cookie de larga duración que identifica al navegador. Sirve
+// para detectar cuentas de la misma persona sin depender de la IP, que en
+// un colegio comparten todos los alumnos.
+const deviceCookie = "ss_device"
+
+// deviceCookieMaxAge dos años
+const deviceCookieMaxAge = 2 * 365 * 24 * 60 * 60
+
+// DeviceMiddleware lee el id de dispositivo de la cookie o, si no hay uno
+// válido, genera uno nuevo y lo guarda. secure la limita a HTTPS (en
+// producción el frontend está en otro dominio y necesita SameSite=None).
+func DeviceMiddleware(secure bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		deviceID, err := c.Cookie(deviceCookie)
+		if err != nil || uuid.Validate(deviceID) != nil {
+			deviceID = uuid.New().String()
+		}
+
+		// Se renueva en cada pedido para que no venza mientras se use
+		sameSite := http.SameSiteLaxMode
+		if secure {
+			sameSite = http.SameSiteNoneMode
+		}
+		c.SetSameSite(sameSite)
+		c.SetCookie(deviceCookie, deviceID, deviceCookieMaxAge, "/", "", secure, true)
+
+		c.Set("device_id", deviceID)
+		c.Next()
+	}
+}
+
+// GetDeviceID obtiene el id de dispositivo del contexto
+func GetDeviceID(c *gin.Context) string {
+	return c.GetString("device_id")
+}
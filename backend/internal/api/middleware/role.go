@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/smartstocks/backend/internal/repository"
+	"github.com/smartstocks/backend/pkg/utils"
+)
+
+// RequireRole permite el acceso solo a usuarios con alguno de los roles indicados.
+// El rol se lee de la base en cada request para que los cambios apliquen sin
+// esperar a que expire el JWT. Debe usarse después de AuthMiddleware.
+func RequireRole(userRepo *repository.UserRepository, roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, exists := GetUserID(c)
+		if !exists {
+			utils.ErrorResponse(c, http.StatusUnauthorized, "User not authenticated", nil)
+			c.Abort()
+			return
+		}
+
+		role, err := userRepo.GetUserRole(userID)
+		if err != nil {
+			utils.ErrorResponse(c, http.StatusUnauthorized, "User not found", err)
+			c.Abort()
+			return
+		}
+
+		for _, allowed := range roles {
+			if role == allowed {
+				c.Set("role", role)
+				c.Next()
+				return
+			}
+		}
+
+		utils.ErrorResponse(c, http.StatusForbidden, "Insufficient permissions", nil)
+		c.Abort()
+	}
+}
@@ -7,57 +7,95 @@ import (
 	"github.com/smartstocks/backend/internal/api/handlers"
 	"github.com/smartstocks/backend/internal/api/middleware"
 	"github.com/smartstocks/backend/internal/config"
+	"github.com/smartstocks/backend/internal/models"
+	"github.com/smartstocks/backend/internal/repository"
 	"github.com/smartstocks/backend/pkg/database"
 	"github.com/smartstocks/backend/pkg/jwt"
 )
 
 type Router struct {
-	engine             *gin.Engine
-	authHandler        *handlers.AuthHandler
-	userHandler        *handlers.UserHandler
-	quizHandler        *handlers.QuizHandler
-	forumHandler       *handlers.ForumHandler
-	coursesHandler     *handlers.CoursesHandler
-	simulatorHandler   *handlers.SimulatorHandler
-	pvpHandler         *handlers.PvPHandler
-	rankingsHandler    *handlers.RankingsHandler
-	tokensHandler      *handlers.TokensHandler
-	tournamentsHandler *handlers.TournamentsHandler
-	jwtManager         *jwt.JWTManager
-	redis              *database.RedisClient
-	config             *config.Config
+	engine              *gin.Engine
+	authHandler         *handlers.AuthHandler
+	userHandler         *handlers.UserHandler
+	twoFactorHandler    *handlers.TwoFactorHandler
+	oidcHandler         *handlers.OIDCHandler
+	privacyHandler      *handlers.PrivacyHandler
+	schoolHandler       *handlers.SchoolHandler
+	classroomHandler    *handlers.ClassroomHandler
+	assignmentHandler   *handlers.AssignmentHandler
+	analyticsHandler    *handlers.AnalyticsHandler
+	quizHandler         *handlers.QuizHandler
+	forumHandler        *handlers.ForumHandler
+	coursesHandler      *handlers.CoursesHandler
+	simulatorHandler    *handlers.SimulatorHandler
+	pvpHandler          *handlers.PvPHandler
+	rankingsHandler     *handlers.RankingsHandler
+	seasonHandler       *handlers.SeasonHandler
+	tokensHandler       *handlers.TokensHandler
+	shopHandler         *handlers.ShopHandler
+	notificationHandler *handlers.NotificationHandler
+	referralHandler     *handlers.ReferralHandler
+	tournamentsHandler  *handlers.TournamentsHandler
+	userRepo            *repository.UserRepository
+	jwtManager          *jwt.JWTManager
+	redis               *database.RedisClient
+	config              *config.Config
 }
 
 func NewRouter(
 	authHandler *handlers.AuthHandler,
 	userHandler *handlers.UserHandler,
+	twoFactorHandler *handlers.TwoFactorHandler,
+	oidcHandler *handlers.OIDCHandler,
+	privacyHandler *handlers.PrivacyHandler,
+	schoolHandler *handlers.SchoolHandler,
+	classroomHandler *handlers.ClassroomHandler,
+	assignmentHandler *handlers.AssignmentHandler,
+	analyticsHandler *handlers.AnalyticsHandler,
 	quizHandler *handlers.QuizHandler,
 	forumHandler *handlers.ForumHandler,
 	coursesHandler *handlers.CoursesHandler,
 	simulatorHandler *handlers.SimulatorHandler,
 	pvpHandler *handlers.PvPHandler,
 	rankingsHandler *handlers.RankingsHandler,
+	seasonHandler *handlers.SeasonHandler,
 	tokensHandler *handlers.TokensHandler,
+	shopHandler *handlers.ShopHandler,
+	notificationHandler *handlers.NotificationHandler,
+	referralHandler *handlers.ReferralHandler,
 	tournamentsHandler *handlers.TournamentsHandler,
+	userRepo *repository.UserRepository,
 	jwtManager *jwt.JWTManager,
 	redis *database.RedisClient,
 	cfg *config.Config,
 ) *Router {
 	return &Router{
-		engine:             gin.Default(),
-		authHandler:        authHandler,
-		userHandler:        userHandler,
-		quizHandler:        quizHandler,
-		forumHandler:       forumHandler,
-		coursesHandler:     coursesHandler,
-		simulatorHandler:   simulatorHandler,
-		pvpHandler:         pvpHandler,
-		rankingsHandler:    rankingsHandler,
-		tokensHandler:      tokensHandler,
-		tournamentsHandler: tournamentsHandler,
-		jwtManager:         jwtManager,
-		redis:              redis,
-		config:             cfg,
+		engine:              gin.Default(),
+		authHandler:         authHandler,
+		userHandler:         userHandler,
+		twoFactorHandler:    twoFactorHandler,
+		oidcHandler:         oidcHandler,
+		privacyHandler:      privacyHandler,
+		schoolHandler:       schoolHandler,
+		classroomHandler:    classroomHandler,
+		assignmentHandler:   assignmentHandler,
+		analyticsHandler:    analyticsHandler,
+		quizHandler:         quizHandler,
+		forumHandler:        forumHandler,
+		coursesHandler:      coursesHandler,
+		simulatorHandler:    simulatorHandler,
+		pvpHandler:          pvpHandler,
+		rankingsHandler:     rankingsHandler,
+		seasonHandler:       seasonHandler,
+		tokensHandler:       tokensHandler,
+		shopHandler:         shopHandler,
+		notificationHandler: notificationHandler,
+		referralHandler:     referralHandler,
+		tournamentsHandler:  tournamentsHandler,
+		userRepo:            userRepo,
+		jwtManager:          jwtManager,
+		redis:               redis,
+		config:              cfg,
 	}
 }
 
@@ -75,22 +113,67 @@ func (r *Router) Setup() *gin.Engine {
 		})
 	})
 
+	// JWKS (pública) para que otros servicios verifiquen los access tokens
+	r.engine.GET("/.well-known/jwks.json", func(c *gin.Context) {
+		c.JSON(http.StatusOK, r.jwtManager.JWKS())
+	})
+
 	// API v1
 	v1 := r.engine.Group("/api/v1")
 	{
-		// Auth routes (públicas)
-		auth := v1.Group("/auth")
+		// Auth routes (públicas). La cookie de dispositivo se asigna acá, donde
+		// se registran los logins
+		auth := v1.Group("/auth", middleware.DeviceMiddleware(r.config.Server.GinMode == "release"))
 		{
 			auth.POST("/register", r.authHandler.Register)
 			auth.POST("/login", r.authHandler.Login)
+			auth.POST("/login/2fa", r.authHandler.LoginTwoFactor)
 			auth.POST("/refresh", r.authHandler.RefreshToken)
 			auth.POST("/verify-email", r.authHandler.VerifyEmail)
 			auth.POST("/logout", r.authHandler.Logout)
+
+			// OpenID Connect
+			auth.GET("/oidc/providers", r.oidcHandler.GetProviders)
+			auth.GET("/oidc/:provider/authorize", r.oidcHandler.Authorize)
+			auth.POST("/oidc/:provider/callback", r.oidcHandler.Callback)
 		}
 
 		// Schools route (pública)
 		v1.GET("/schools", r.userHandler.GetSchools)
 
+		// School membership y administración (protegidas)
+		schools := v1.Group("/schools")
+		schools.Use(middleware.AuthMiddleware(r.jwtManager))
+		{
+			// Usuarios
+			schools.POST("/join", r.schoolHandler.JoinSchool)
+			schools.GET("/membership", r.schoolHandler.GetMyMembership)
+			schools.DELETE("/membership", r.schoolHandler.LeaveSchool)
+
+			// Administradores del colegio (verificado en el servicio)
+			schools.GET("/:id", r.schoolHandler.GetSchool)
+			schools.PUT("/:id", r.schoolHandler.UpdateSchool)
+			schools.POST("/:id/join-code", r.schoolHandler.RegenerateJoinCode)
+			schools.DELETE("/:id/join-code", r.schoolHandler.DisableJoinCode)
+			schools.GET("/:id/members", r.schoolHandler.GetMembers)
+			schools.POST("/:id/members/:userId/approve", r.schoolHandler.ApproveMember)
+			schools.POST("/:id/members/:userId/reject", r.schoolHandler.RejectMember)
+			schools.DELETE("/:id/members/:userId", r.schoolHandler.RemoveMember)
+			schools.GET("/:id/analytics/export", r.analyticsHandler.ExportSchoolAnalytics)
+
+			// Administradores globales
+			admin := schools.Group("")
+			admin.Use(middleware.RequireRole(r.userRepo, models.RoleAdmin))
+			{
+				admin.POST("", r.schoolHandler.CreateSchool)
+				admin.POST("/import", r.schoolHandler.ImportSchools)
+				admin.POST("/:id/activate", r.schoolHandler.ActivateSchool)
+				admin.POST("/:id/deactivate", r.schoolHandler.DeactivateSchool)
+				admin.POST("/:id/admins", r.schoolHandler.AddSchoolAdmin)
+				admin.DELETE("/:id/admins/:userId", r.schoolHandler.RemoveSchoolAdmin)
+			}
+		}
+
 		// User routes (protegidas)
 		user := v1.Group("/user")
 		user.Use(middleware.AuthMiddleware(r.jwtManager))
@@ -98,6 +181,20 @@ func (r *Router) Setup() *gin.Engine {
 			user.GET("/profile", r.userHandler.GetProfile)
 			user.PUT("/profile", r.userHandler.UpdateProfile)
 			user.GET("/stats", r.userHandler.GetUserStats)
+
+			// Two-factor (TOTP)
+			user.GET("/2fa", r.twoFactorHandler.GetStatus)
+			user.POST("/2fa/enroll", r.twoFactorHandler.Enroll)
+			user.POST("/2fa/confirm", r.twoFactorHandler.Confirm)
+			user.POST("/2fa/disable", r.twoFactorHandler.Disable)
+			user.POST("/2fa/recovery-codes", r.twoFactorHandler.RegenerateRecoveryCodes)
+
+			// Privacidad (exportación y baja)
+			user.GET("/export", r.privacyHandler.ExportData)
+			user.GET("/delete", r.privacyHandler.GetDeletionStatus)
+			user.POST("/delete", r.privacyHandler.RequestDeletion)
+			user.POST("/delete/confirmation", r.privacyHandler.SendDeletionConfirmation)
+			user.POST("/delete/cancel", r.privacyHandler.CancelDeletion)
 		}
 
 		// Quiz routes (protegidas)
@@ -106,6 +203,7 @@ func (r *Router) Setup() *gin.Engine {
 		{
 			quiz.GET("/:difficulty", r.quizHandler.GetDailyQuiz)
 			quiz.POST("/submit", r.quizHandler.SubmitQuiz)
+			quiz.POST("/extra-attempt", r.quizHandler.BuyExtraAttempt)
 			quiz.GET("/history", r.quizHandler.GetQuizHistory)
 		}
 
@@ -146,6 +244,8 @@ func (r *Router) Setup() *gin.Engine {
 		{
 			simulator.GET("/:difficulty", r.simulatorHandler.GetScenario)
 			simulator.POST("/submit", r.simulatorHandler.SubmitDecision)
+			simulator.POST("/hint", r.simulatorHandler.BuyHint)
+			simulator.POST("/extra-attempt", r.simulatorHandler.BuyExtraAttempt)
 			simulator.GET("/history", r.simulatorHandler.GetHistory)
 			simulator.GET("/cooldown/:difficulty", r.simulatorHandler.GetCooldownStatus)
 			simulator.GET("/stats", r.simulatorHandler.GetStats)
@@ -164,10 +264,56 @@ func (r *Router) Setup() *gin.Engine {
 				pvpRest.POST("/queue/join", r.pvpHandler.JoinQueue)
 				pvpRest.POST("/queue/leave", r.pvpHandler.LeaveQueue)
 				pvpRest.POST("/submit", r.pvpHandler.SubmitDecision)
+				pvpRest.POST("/second-chance", r.pvpHandler.UseSecondChance)
 				pvpRest.GET("/history", r.pvpHandler.GetHistory)
 			}
 		}
 
+		// Classroom routes (protegidas)
+		classrooms := v1.Group("/classrooms")
+		classrooms.Use(middleware.AuthMiddleware(r.jwtManager))
+		{
+			// Alumnos
+			classrooms.GET("", r.classroomHandler.GetMyClassrooms)
+			classrooms.POST("/join", r.classroomHandler.JoinClassroom)
+			classrooms.POST("/:id/leave", r.classroomHandler.LeaveClassroom)
+
+			// Docentes
+			teacher := classrooms.Group("")
+			teacher.Use(middleware.RequireRole(r.userRepo, models.RoleTeacher, models.RoleAdmin))
+			{
+				teacher.POST("", r.classroomHandler.CreateClassroom)
+				teacher.GET("/teaching", r.classroomHandler.GetTeachingClassrooms)
+				teacher.GET("/:id", r.classroomHandler.GetClassroom)
+				teacher.PUT("/:id", r.classroomHandler.UpdateClassroom)
+				teacher.DELETE("/:id", r.classroomHandler.DeleteClassroom)
+				teacher.POST("/:id/join-code", r.classroomHandler.RegenerateJoinCode)
+				teacher.GET("/:id/students", r.classroomHandler.GetStudents)
+				teacher.DELETE("/:id/students/:userId", r.classroomHandler.RemoveStudent)
+				teacher.GET("/:id/students/:userId/progress", r.classroomHandler.GetStudentProgress)
+				teacher.GET("/:id/progress", r.classroomHandler.GetClassroomProgress)
+
+				// Tareas
+				teacher.POST("/:id/assignments", r.assignmentHandler.CreateAssignment)
+				teacher.GET("/:id/assignments", r.assignmentHandler.GetClassroomAssignments)
+				teacher.GET("/:id/assignments/:assignmentId", r.assignmentHandler.GetAssignment)
+				teacher.PUT("/:id/assignments/:assignmentId", r.assignmentHandler.UpdateAssignment)
+				teacher.DELETE("/:id/assignments/:assignmentId", r.assignmentHandler.DeleteAssignment)
+				teacher.GET("/:id/gradebook", r.assignmentHandler.GetGradebook)
+
+				// Reportes
+				teacher.GET("/:id/analytics/export", r.analyticsHandler.ExportClassroomAnalytics)
+			}
+		}
+
+		// Assignment routes (protegidas) - tareas del alumno
+		assignments := v1.Group("/assignments")
+		assignments.Use(middleware.AuthMiddleware(r.jwtManager))
+		{
+			assignments.GET("", r.assignmentHandler.GetMyAssignments)
+			assignments.GET("/:id", r.assignmentHandler.GetMyAssignment)
+		}
+
 		// Rankings routes (protegidas)
 		rankings := v1.Group("/rankings")
 		rankings.Use(middleware.AuthMiddleware(r.jwtManager))
@@ -175,7 +321,13 @@ func (r *Router) Setup() *gin.Engine {
 			rankings.GET("/global", r.rankingsHandler.GetGlobalLeaderboard)
 			rankings.GET("/school/:school_id", r.rankingsHandler.GetSchoolLeaderboard)
 			rankings.GET("/my-school", r.rankingsHandler.GetMySchoolLeaderboard)
+			rankings.GET("/schools", r.rankingsHandler.GetInterSchoolLeaderboard)
+			rankings.GET("/schools/:school_id", r.rankingsHandler.GetSchoolProfile)
 			rankings.GET("/my-position", r.rankingsHandler.GetMyPosition)
+			rankings.GET("/my-position/history", r.rankingsHandler.GetMyRankHistory)
+			rankings.GET("/tiers", r.rankingsHandler.GetRankTiers)
+			rankings.GET("/tiers/history", r.rankingsHandler.GetMyTierHistory)
+			rankings.GET("/disciplines/:discipline", r.rankingsHandler.GetDisciplineLeaderboard)
 			rankings.GET("/profile/:user_id", r.rankingsHandler.GetPublicProfile)
 			rankings.GET("/achievements", r.rankingsHandler.GetMyAchievements)
 
@@ -183,12 +335,89 @@ func (r *Router) Setup() *gin.Engine {
 			rankings.POST("/admin/update-cache", r.rankingsHandler.UpdateCache)
 		}
 
+		// Season routes (protegidas)
+		seasons := v1.Group("/seasons")
+		seasons.Use(middleware.AuthMiddleware(r.jwtManager))
+		{
+			seasons.GET("", r.seasonHandler.GetSeasons)
+			seasons.GET("/current", r.seasonHandler.GetCurrentLeaderboard)
+			seasons.GET("/history", r.seasonHandler.GetMySeasonHistory)
+			seasons.GET("/:id/leaderboard", r.seasonHandler.GetSeasonLeaderboard)
+
+			// Administración
+			admin := seasons.Group("")
+			admin.Use(middleware.RequireRole(r.userRepo, models.RoleAdmin))
+			{
+				admin.POST("", r.seasonHandler.CreateSeason)
+				admin.POST("/rollover", r.seasonHandler.ProcessRollover)
+				admin.DELETE("/:id", r.seasonHandler.DeleteSeason)
+			}
+		}
+
 		// Tokens routes (protegidas)
 		tokens := v1.Group("/tokens")
 		tokens.Use(middleware.AuthMiddleware(r.jwtManager))
 		{
 			tokens.GET("/balance", r.tokensHandler.GetMyTokens)
 			tokens.GET("/transactions", r.tokensHandler.GetTransactionHistory)
+			tokens.GET("/transactions/summary", r.tokensHandler.GetTransactionSummary)
+			tokens.GET("/transactions/export", r.tokensHandler.ExportTransactions)
+			tokens.GET("/daily-reward", r.tokensHandler.GetDailyReward)
+			tokens.POST("/daily-reward", r.tokensHandler.ClaimDailyReward)
+			tokens.POST("/streak-freezes", r.tokensHandler.BuyStreakFreeze)
+			tokens.POST("/gifts", r.tokensHandler.SendGift)
+			tokens.GET("/gifts", r.tokensHandler.GetGifts)
+			tokens.GET("/gifts/limits", r.tokensHandler.GetGiftLimits)
+
+			admin := tokens.Group("/admin")
+			admin.Use(middleware.RequireRole(r.userRepo, models.RoleAdmin))
+			{
+				admin.POST("/reconciliations", r.tokensHandler.Reconcile)
+				admin.GET("/reconciliations", r.tokensHandler.GetReconciliations)
+				admin.GET("/reconciliations/:id", r.tokensHandler.GetReconciliation)
+				admin.POST("/adjustments", r.tokensHandler.AdjustTokens)
+				admin.POST("/adjustments/bulk", r.tokensHandler.ImportAdjustments)
+				admin.GET("/adjustments", r.tokensHandler.GetAdjustments)
+				admin.POST("/refunds", r.tokensHandler.RefundTransaction)
+				admin.GET("/account-links", r.tokensHandler.GetAccountLinks)
+				admin.POST("/account-links", r.tokensHandler.FlagAccountLink)
+				admin.PUT("/account-links/:id", r.tokensHandler.UpdateAccountLink)
+			}
+		}
+
+		// Notifications routes (protegidas)
+		notifications := v1.Group("/notifications")
+		notifications.Use(middleware.AuthMiddleware(r.jwtManager))
+		{
+			notifications.GET("", r.notificationHandler.GetNotifications)
+			notifications.POST("/read-all", r.notificationHandler.MarkAllRead)
+			notifications.POST("/:id/read", r.notificationHandler.MarkRead)
+		}
+
+		// Referrals routes (protegidas)
+		referrals := v1.Group("/referrals")
+		referrals.Use(middleware.AuthMiddleware(r.jwtManager))
+		{
+			referrals.GET("", r.referralHandler.GetReferrals)
+		}
+
+		// Shop routes (protegidas)
+		shop := v1.Group("/shop")
+		shop.Use(middleware.AuthMiddleware(r.jwtManager))
+		{
+			shop.GET("/items", r.shopHandler.GetCatalog)
+			shop.GET("/consumables", r.shopHandler.GetConsumables)
+			shop.POST("/items/:id/purchase", r.shopHandler.PurchaseItem)
+			shop.GET("/inventory", r.shopHandler.GetInventory)
+			shop.POST("/inventory/:id/equip", r.shopHandler.EquipItem)
+			shop.POST("/inventory/:id/unequip", r.shopHandler.UnequipItem)
+
+			admin := shop.Group("")
+			admin.Use(middleware.RequireRole(r.userRepo, models.RoleAdmin))
+			{
+				admin.POST("/items", r.shopHandler.CreateItem)
+				admin.PUT("/items/:id", r.shopHandler.UpdateItem)
+			}
 		}
 
 		// Tournaments routes (protegidas)
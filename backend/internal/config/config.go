@@ -1,22 +1,38 @@
 package config
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
 
 	"github.com/joho/godotenv"
 )
 
+// DefaultJWTSecret es el secreto de desarrollo; no se permite en modo release
+const DefaultJWTSecret = "change-this-secret"
+
 type Config struct {
-	Server   ServerConfig
-	Database DatabaseConfig
-	Redis    RedisConfig
-	JWT      JWTConfig
-	Email    EmailConfig
-	AWS      AWSConfig
-	CORS     CORSConfig
-	OpenAI   OpenAIConfig
+	Server       ServerConfig
+	Database     DatabaseConfig
+	Redis        RedisConfig
+	JWT          JWTConfig
+	Login        LoginProtectionConfig
+	Privacy      PrivacyConfig
+	Schools      SchoolRankingConfig
+	Ranking      LeaderboardConfig
+	Achievements AchievementsConfig
+	Ranks        RanksConfig
+	DailyRewards DailyRewardsConfig
+	TokenGifts   TokenGiftsConfig
+	Consumables  ConsumablesConfig
+	Referrals    ReferralsConfig
+	Email        EmailConfig
+	AWS          AWSConfig
+	CORS         CORSConfig
+	OpenAI       OpenAIConfig
+	OIDC         OIDCConfig
 }
 
 type ServerConfig struct {
@@ -41,10 +57,117 @@ type RedisConfig struct {
 
 type JWTConfig struct {
 	Secret                     string
+	Algorithm                  string            // HS256, RS256 o EdDSA
+	KeyID                      string            // kid de la clave activa de firma
+	PrivateKeyFile             string            // PEM de la clave privada (RS256/EdDSA)
+	VerificationKeyFiles       map[string]string // kid -> PEM de claves públicas anteriores
+	PreviousSecrets            map[string]string // kid -> secretos HS256 anteriores
+	LegacyKeyID                string            // kid que verifica tokens sin "kid" (vacío: KeyID)
 	ExpirationHours            int
 	RefreshTokenExpirationDays int
 }
 
+// LoginProtectionConfig umbrales anti fuerza bruta. MaxAccountFailures bloquea
+// la cuenta desde una IP; MaxIPFailures solo activa backoff en la IP, porque
+// en los colegios muchos alumnos comparten la misma IP pública.
+type LoginProtectionConfig struct {
+	MaxAccountFailures   int
+	MaxIPFailures        int
+	FailureWindowMinutes int
+	LockoutMinutes       int
+	BackoffBaseSeconds   int
+	BackoffMaxSeconds    int
+}
+
+// PrivacyConfig parámetros de la baja de cuentas
+type PrivacyConfig struct {
+	DeletionCoolingOffDays int
+}
+
+// SchoolRankingConfig parámetros del ranking entre colegios: cuántos alumnos
+// promedian por colegio y cuántos miembros necesita un colegio para clasificar
+type SchoolRankingConfig struct {
+	RankingTopN       int
+	RankingMinMembers int
+}
+
+// LeaderboardConfig parámetros de los rankings de jugadores.
+// RebuildIntervalMinutes: cada cuántos minutos se reconstruyen los rankings en
+// Redis desde MySQL para corregir desvíos (cuentas eliminadas, cambios
+// manuales); 0 desactiva la reconstrucción periódica, siempre se reconstruyen
+// al iniciar. Los Min* son la actividad mínima para figurar en los rankings
+// por disciplina, así un único intento con suerte no encabeza la tabla.
+type LeaderboardConfig struct {
+	RebuildIntervalMinutes int
+	MinQuizAttempts        int
+	MinSimulatorAttempts   int
+	MinPvPMatches          int
+	MinCompletedCourses    int
+}
+
+// AchievementsConfig archivo JSON con las reglas de logros. Vacío usa las
+// reglas incluidas en el binario.
+type AchievementsConfig struct {
+	RulesPath string
+}
+
+// RanksConfig archivo JSON con la tabla de rangos. Vacío usa la tabla
+// incluida en el binario.
+type RanksConfig struct {
+	TiersPath string
+}
+
+// DailyRewardsConfig recompensa del check-in diario. La recompensa sube
+// StepTokens por día de racha hasta MaxTokens; cada MilestoneDays días se
+// suma MilestoneBonus y se gana un protector de racha (hasta MaxFreezes).
+type DailyRewardsConfig struct {
+	BaseTokens      int
+	StepTokens      int
+	MaxTokens       int
+	MilestoneDays   int
+	MilestoneBonus  int
+	MaxFreezes      int
+	FreezePrice     int
+	DefaultTimezone string
+}
+
+// TokenGiftsConfig límites de los regalos de tokens entre alumnos. Los
+// límites diarios son por remitente en las últimas 24 horas. Emisor y
+// receptor necesitan MinAccountAgeDays de antigüedad. Dos cuentas con logins
+// exitosos desde el mismo dispositivo (cookie) en los últimos LinkWindowDays
+// días se consideran vinculadas y no pueden transferirse.
+type TokenGiftsConfig struct {
+	MinAccountAgeDays int
+	DailyTokenLimit   int
+	DailyGiftLimit    int
+	LinkWindowDays    int
+}
+
+// ConsumablesConfig precios en tokens de las ayudas del simulador, el quiz y
+// el PvP. Un intento extra se puede rendir aunque ya se haya usado el intento
+// del día y otorga ExtraAttemptPointsPercent de los puntos normales. Se
+// pueden comprar hasta ExtraAttemptsPerDay de cada tipo en 24 horas.
+type ConsumablesConfig struct {
+	SimulatorHintPrice         int
+	SimulatorExtraAttemptPrice int
+	QuizExtraAttemptPrice      int
+	PvPSecondChancePrice       int
+	ExtraAttemptPointsPercent  int
+	ExtraAttemptsPerDay        int
+}
+
+// ReferralsConfig recompensa por invitar alumnos. El que invita recibe
+// RewardTokens cuando el invitado verifica su email y completa su primer
+// quiz, hasta MonthlyRewardLimit recompensas por mes calendario y
+// TotalRewardLimit en total. Los que califican con el límite mensual
+// alcanzado quedan diferidos y se reevalúan cada RecheckIntervalMinutes.
+type ReferralsConfig struct {
+	RewardTokens           int
+	MonthlyRewardLimit     int
+	TotalRewardLimit       int
+	RecheckIntervalMinutes int
+}
+
 type EmailConfig struct {
 	SMTPHost     string
 	SMTPPort     string
@@ -64,6 +187,20 @@ type CORSConfig struct {
 	AllowedOrigins string
 }
 
+// OIDCConfig proveedores OpenID Connect habilitados (Google Workspace, Microsoft, etc.)
+type OIDCConfig struct {
+	Providers map[string]OIDCProviderConfig
+}
+
+type OIDCProviderConfig struct {
+	Name         string
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+}
+
 type OpenAIConfig struct {
 	APIKey string
 	APIURL string
@@ -78,6 +215,41 @@ func Load() (*Config, error) {
 	redisDB, _ := strconv.Atoi(getEnv("REDIS_DB", "0"))
 	jwtExp, _ := strconv.Atoi(getEnv("JWT_EXPIRATION_HOURS", "24"))
 	refreshExp, _ := strconv.Atoi(getEnv("REFRESH_TOKEN_EXPIRATION_DAYS", "30"))
+	loginMaxAccount, _ := strconv.Atoi(getEnv("LOGIN_MAX_ACCOUNT_FAILURES", "5"))
+	loginMaxIP, _ := strconv.Atoi(getEnv("LOGIN_MAX_IP_FAILURES", "100"))
+	loginWindow, _ := strconv.Atoi(getEnv("LOGIN_FAILURE_WINDOW_MINUTES", "15"))
+	loginLockout, _ := strconv.Atoi(getEnv("LOGIN_LOCKOUT_MINUTES", "15"))
+	loginBackoffBase, _ := strconv.Atoi(getEnv("LOGIN_BACKOFF_BASE_SECONDS", "1"))
+	loginBackoffMax, _ := strconv.Atoi(getEnv("LOGIN_BACKOFF_MAX_SECONDS", "60"))
+	deletionCoolingOff, _ := strconv.Atoi(getEnv("ACCOUNT_DELETION_COOLING_OFF_DAYS", "14"))
+	schoolTopN, _ := strconv.Atoi(getEnv("SCHOOL_RANKING_TOP_N", "10"))
+	schoolMinMembers, _ := strconv.Atoi(getEnv("SCHOOL_RANKING_MIN_MEMBERS", "3"))
+	leaderboardRebuild, _ := strconv.Atoi(getEnv("LEADERBOARD_REBUILD_INTERVAL_MINUTES", "60"))
+	minQuizAttempts, _ := strconv.Atoi(getEnv("RANKING_MIN_QUIZ_ATTEMPTS", "5"))
+	minSimulatorAttempts, _ := strconv.Atoi(getEnv("RANKING_MIN_SIMULATOR_ATTEMPTS", "10"))
+	minPvPMatches, _ := strconv.Atoi(getEnv("RANKING_MIN_PVP_MATCHES", "10"))
+	minCompletedCourses, _ := strconv.Atoi(getEnv("RANKING_MIN_COMPLETED_COURSES", "1"))
+	dailyRewardBase, _ := strconv.Atoi(getEnv("DAILY_REWARD_BASE_TOKENS", "10"))
+	dailyRewardStep, _ := strconv.Atoi(getEnv("DAILY_REWARD_STEP_TOKENS", "5"))
+	dailyRewardMax, _ := strconv.Atoi(getEnv("DAILY_REWARD_MAX_TOKENS", "50"))
+	dailyRewardMilestoneDays, _ := strconv.Atoi(getEnv("DAILY_REWARD_MILESTONE_DAYS", "7"))
+	dailyRewardMilestoneBonus, _ := strconv.Atoi(getEnv("DAILY_REWARD_MILESTONE_BONUS", "50"))
+	streakMaxFreezes, _ := strconv.Atoi(getEnv("STREAK_MAX_FREEZES", "2"))
+	streakFreezePrice, _ := strconv.Atoi(getEnv("STREAK_FREEZE_PRICE", "100"))
+	giftMinAccountAge, _ := strconv.Atoi(getEnv("TOKEN_GIFT_MIN_ACCOUNT_AGE_DAYS", "7"))
+	giftDailyTokens, _ := strconv.Atoi(getEnv("TOKEN_GIFT_DAILY_TOKEN_LIMIT", "200"))
+	giftDailyCount, _ := strconv.Atoi(getEnv("TOKEN_GIFT_DAILY_COUNT_LIMIT", "5"))
+	giftLinkWindow, _ := strconv.Atoi(getEnv("TOKEN_GIFT_LINK_WINDOW_DAYS", "30"))
+	hintPrice, _ := strconv.Atoi(getEnv("SIMULATOR_HINT_PRICE", "10"))
+	simulatorExtraPrice, _ := strconv.Atoi(getEnv("SIMULATOR_EXTRA_ATTEMPT_PRICE", "30"))
+	quizExtraPrice, _ := strconv.Atoi(getEnv("QUIZ_EXTRA_ATTEMPT_PRICE", "30"))
+	secondChancePrice, _ := strconv.Atoi(getEnv("PVP_SECOND_CHANCE_PRICE", "20"))
+	extraPointsPercent, _ := strconv.Atoi(getEnv("EXTRA_ATTEMPT_POINTS_PERCENT", "50"))
+	extraAttemptsPerDay, _ := strconv.Atoi(getEnv("EXTRA_ATTEMPTS_PER_DAY", "3"))
+	referralReward, _ := strconv.Atoi(getEnv("REFERRAL_REWARD_TOKENS", "50"))
+	referralMonthly, _ := strconv.Atoi(getEnv("REFERRAL_MONTHLY_LIMIT", "5"))
+	referralTotal, _ := strconv.Atoi(getEnv("REFERRAL_TOTAL_LIMIT", "50"))
+	referralRecheck, _ := strconv.Atoi(getEnv("REFERRAL_RECHECK_INTERVAL_MINUTES", "60"))
 
 	config := &Config{
 		Server: ServerConfig{
@@ -98,10 +270,74 @@ func Load() (*Config, error) {
 			DB:       redisDB,
 		},
 		JWT: JWTConfig{
-			Secret:                     getEnv("JWT_SECRET", "change-this-secret"),
+			Secret:                     getEnv("JWT_SECRET", DefaultJWTSecret),
+			Algorithm:                  getEnv("JWT_ALGORITHM", "HS256"),
+			KeyID:                      getEnv("JWT_KEY_ID", "default"),
+			PrivateKeyFile:             getEnv("JWT_PRIVATE_KEY_FILE", ""),
+			VerificationKeyFiles:       parseKeyValueList(getEnv("JWT_VERIFICATION_KEY_FILES", "")),
+			PreviousSecrets:            parseKeyValueList(getEnv("JWT_PREVIOUS_SECRETS", "")),
+			LegacyKeyID:                getEnv("JWT_LEGACY_KEY_ID", ""),
 			ExpirationHours:            jwtExp,
 			RefreshTokenExpirationDays: refreshExp,
 		},
+		Login: LoginProtectionConfig{
+			MaxAccountFailures:   loginMaxAccount,
+			MaxIPFailures:        loginMaxIP,
+			FailureWindowMinutes: loginWindow,
+			LockoutMinutes:       loginLockout,
+			BackoffBaseSeconds:   loginBackoffBase,
+			BackoffMaxSeconds:    loginBackoffMax,
+		},
+		Privacy: PrivacyConfig{
+			DeletionCoolingOffDays: deletionCoolingOff,
+		},
+		Schools: SchoolRankingConfig{
+			RankingTopN:       schoolTopN,
+			RankingMinMembers: schoolMinMembers,
+		},
+		Ranking: LeaderboardConfig{
+			RebuildIntervalMinutes: leaderboardRebuild,
+			MinQuizAttempts:        minQuizAttempts,
+			MinSimulatorAttempts:   minSimulatorAttempts,
+			MinPvPMatches:          minPvPMatches,
+			MinCompletedCourses:    minCompletedCourses,
+		},
+		Achievements: AchievementsConfig{
+			RulesPath: getEnv("ACHIEVEMENT_RULES_PATH", ""),
+		},
+		Ranks: RanksConfig{
+			TiersPath: getEnv("RANK_TIERS_PATH", ""),
+		},
+		DailyRewards: DailyRewardsConfig{
+			BaseTokens:      dailyRewardBase,
+			StepTokens:      dailyRewardStep,
+			MaxTokens:       dailyRewardMax,
+			MilestoneDays:   dailyRewardMilestoneDays,
+			MilestoneBonus:  dailyRewardMilestoneBonus,
+			MaxFreezes:      streakMaxFreezes,
+			FreezePrice:     streakFreezePrice,
+			DefaultTimezone: getEnv("DAILY_REWARD_DEFAULT_TIMEZONE", "America/Argentina/Buenos_Aires"),
+		},
+		TokenGifts: TokenGiftsConfig{
+			MinAccountAgeDays: giftMinAccountAge,
+			DailyTokenLimit:   giftDailyTokens,
+			DailyGiftLimit:    giftDailyCount,
+			LinkWindowDays:    giftLinkWindow,
+		},
+		Consumables: ConsumablesConfig{
+			SimulatorHintPrice:         hintPrice,
+			SimulatorExtraAttemptPrice: simulatorExtraPrice,
+			QuizExtraAttemptPrice:      quizExtraPrice,
+			PvPSecondChancePrice:       secondChancePrice,
+			ExtraAttemptPointsPercent:  extraPointsPercent,
+			ExtraAttemptsPerDay:        extraAttemptsPerDay,
+		},
+		Referrals: ReferralsConfig{
+			RewardTokens:           referralReward,
+			MonthlyRewardLimit:     referralMonthly,
+			TotalRewardLimit:       referralTotal,
+			RecheckIntervalMinutes: referralRecheck,
+		},
 		Email: EmailConfig{
 			SMTPHost:     getEnv("SMTP_HOST", "smtp.gmail.com"),
 			SMTPPort:     getEnv("SMTP_PORT", "587"),
@@ -123,11 +359,35 @@ func Load() (*Config, error) {
 			APIURL: getEnv("OPENAI_API_URL", "https://api.openai.com/v1/chat/completions"),
 			Model:  getEnv("OPENAI_MODEL", "gpt-4"),
 		},
+		OIDC: loadOIDCConfig(),
+	}
+
+	if err := config.Validate(); err != nil {
+		return nil, err
 	}
 
 	return config, nil
 }
 
+// Validate rechaza configuraciones inseguras para producción
+func (c *Config) Validate() error {
+	if c.Server.GinMode != "release" {
+		return nil
+	}
+
+	if (c.JWT.Algorithm == "" || c.JWT.Algorithm == "HS256") && c.JWT.Secret == DefaultJWTSecret {
+		return errors.New("JWT_SECRET must be set when GIN_MODE=release")
+	}
+
+	for kid, secret := range c.JWT.PreviousSecrets {
+		if secret == DefaultJWTSecret {
+			return fmt.Errorf("JWT_PREVIOUS_SECRETS entry %q uses the default secret", kid)
+		}
+	}
+
+	return nil
+}
+
 func getEnv(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
 		return value
@@ -135,6 +395,51 @@ func getEnv(key, defaultValue string) string {
 	return defaultValue
 }
 
+// loadOIDCConfig lee OIDC_PROVIDERS="google,microsoft" y por cada proveedor
+// OIDC_<NOMBRE>_ISSUER, _CLIENT_ID, _CLIENT_SECRET, _REDIRECT_URL y _SCOPES
+func loadOIDCConfig() OIDCConfig {
+	cfg := OIDCConfig{Providers: make(map[string]OIDCProviderConfig)}
+
+	for _, name := range strings.Split(getEnv("OIDC_PROVIDERS", ""), ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+
+		prefix := "OIDC_" + strings.ToUpper(name) + "_"
+		provider := OIDCProviderConfig{
+			Name:         name,
+			Issuer:       getEnv(prefix+"ISSUER", ""),
+			ClientID:     getEnv(prefix+"CLIENT_ID", ""),
+			ClientSecret: getEnv(prefix+"CLIENT_SECRET", ""),
+			RedirectURL:  getEnv(prefix+"REDIRECT_URL", ""),
+			Scopes:       strings.Fields(getEnv(prefix+"SCOPES", "openid email profile")),
+		}
+
+		if provider.Issuer == "" || provider.ClientID == "" || provider.RedirectURL == "" {
+			fmt.Printf("Warning: OIDC provider %s is missing issuer, client id or redirect url - skipped\n", name)
+			continue
+		}
+
+		cfg.Providers[name] = provider
+	}
+
+	return cfg
+}
+
+// parseKeyValueList convierte "a=1,b=2" en un mapa
+func parseKeyValueList(value string) map[string]string {
+	result := make(map[string]string)
+	for _, pair := range strings.Split(value, ",") {
+		key, val, found := strings.Cut(strings.TrimSpace(pair), "=")
+		if !found || key == "" {
+			continue
+		}
+		result[strings.TrimSpace(key)] = strings.TrimSpace(val)
+	}
+	return result
+}
+
 func (c *DatabaseConfig) GetDSN() string {
 	return fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?charset=utf8mb4&parseTime=True&loc=Local",
 		c.User,
@@ -0,0 +1,56 @@
+package models
+
+// Eventos de dominio que disparan la evaluación de logros
+const (
+	AchievementEventQuizCompleted     = "quiz_completed"
+	AchievementEventSimulatorAttempt  = "simulator_attempt"
+	AchievementEventPvPMatchFinished  = "pvp_match_finished"
+	AchievementEventLessonCompleted   = "lesson_completed"
+	AchievementEventForumPostCreated  = "forum_post_created"
+	AchievementEventForumReplyCreated = "forum_reply_created"
+)
+
+// Métricas sobre las que se definen las condiciones de los logros. Se
+// calculan desde el historial del usuario, así el mismo cálculo sirve para
+// los eventos y para el backfill.
+const (
+	AchievementMetricSmartpoints            = "smartpoints"
+	AchievementMetricRankLevel              = "rank_level" // grupo del rango en la tabla (por defecto 1 Bronce ... 4 Maestro)
+	AchievementMetricPvPWins                = "pvp_wins"
+	AchievementMetricWinStreak              = "win_streak"
+	AchievementMetricBestComeback           = "best_comeback" // mayor desventaja remontada en una partida ganada
+	AchievementMetricQuizzesCompleted       = "quizzes_completed"
+	AchievementMetricBestQuizScore          = "best_quiz_score" // % de aciertos
+	AchievementMetricSimulatorGames         = "simulator_games"
+	AchievementMetricSimulatorCorrect       = "simulator_correct"
+	AchievementMetricFastestCorrectDecision = "fastest_correct_decision" // segundos
+	AchievementMetricLessonsCompleted       = "lessons_completed"
+	AchievementMetricCoursesCompleted       = "courses_completed"
+	AchievementMetricForumPosts             = "forum_posts"
+	AchievementMetricForumReplies           = "forum_replies"
+)
+
+// Operadores de las condiciones
+const (
+	AchievementOperatorGTE = "gte" // la métrica debe llegar al umbral
+	AchievementOperatorLTE = "lte" // la métrica debe bajar del umbral (ej. tiempos)
+)
+
+// AchievementRule define un logro: se evalúa ante los eventos indicados y se
+// desbloquea cuando la métrica cumple la condición contra el umbral
+type AchievementRule struct {
+	Type        string   `json:"type"`
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Events      []string `json:"events"`
+	Metric      string   `json:"metric"`
+	Operator    string   `json:"operator"`
+	Threshold   int      `json:"threshold"`
+	TokenBonus  int      `json:"token_bonus"`
+	IconURL     string   `json:"icon_url"`
+}
+
+// AchievementRuleSet contenido del archivo de reglas
+type AchievementRuleSet struct {
+	Achievements []AchievementRule `json:"achievements"`
+}
@@ -0,0 +1,81 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+)
+
+// Alcances de los reportes de analíticas
+const (
+	AnalyticsScopeClassroom = "classroom"
+	AnalyticsScopeSchool    = "school"
+)
+
+// Reportes exportables (una hoja por reporte en XLSX)
+const (
+	AnalyticsReportQuizzes   = "quizzes"
+	AnalyticsReportSimulator = "simulator"
+	AnalyticsReportCourses   = "courses"
+	AnalyticsReportPvP       = "pvp"
+)
+
+// AnalyticsReports orden de las hojas del libro XLSX
+var AnalyticsReports = []string{
+	AnalyticsReportQuizzes,
+	AnalyticsReportSimulator,
+	AnalyticsReportCourses,
+	AnalyticsReportPvP,
+}
+
+// AnalyticsScope aula o colegio cuyos alumnos se exportan
+type AnalyticsScope struct {
+	Type string
+	ID   string
+	Name string
+}
+
+// QuizHistoryRow un intento de quiz de un alumno
+type QuizHistoryRow struct {
+	UserID           string
+	Username         string
+	QuizID           string
+	QuizTitle        string
+	Difficulty       string
+	Score            int
+	CorrectAnswers   int
+	TotalQuestions   int
+	PointsEarned     int
+	TimeTakenSeconds sql.NullInt64
+	CompletedAt      time.Time
+}
+
+// SimulatorStatsRow estadísticas del simulador de un alumno en el período
+type SimulatorStatsRow struct {
+	UserID   string
+	Username string
+	Stats    SimulatorStats
+}
+
+// CourseProgressRow avance de un alumno en un curso
+type CourseProgressRow struct {
+	UserID           string
+	Username         string
+	CourseID         string
+	CourseTitle      string
+	TotalLessons     int
+	CompletedLessons int
+	IsCompleted      bool
+	StartedAt        time.Time
+	CompletedAt      sql.NullTime
+}
+
+// PvPRecordRow historial PvP de un alumno en el período
+type PvPRecordRow struct {
+	UserID   string
+	Username string
+	Matches  int
+	Wins     int
+	Losses   int
+	Draws    int
+	WinRate  float64
+}
@@ -0,0 +1,121 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+)
+
+// Tipos de tarea
+const (
+	AssignmentTypeCourse   = "course"
+	AssignmentTypeLesson   = "lesson"
+	AssignmentTypeQuiz     = "quiz"
+	AssignmentTypeScenario = "scenario"
+)
+
+// Estados de la entrega de un alumno
+const (
+	SubmissionStatusPending   = "pending"
+	SubmissionStatusSubmitted = "submitted"
+	SubmissionStatusLate      = "late"
+	SubmissionStatusMissing   = "missing"
+)
+
+// Assignment tarea asignada a un aula
+type Assignment struct {
+	ID             string    `json:"id"`
+	ClassroomID    string    `json:"classroom_id"`
+	TeacherID      string    `json:"teacher_id"`
+	Title          string    `json:"title"`
+	Instructions   string    `json:"instructions"`
+	AssignmentType string    `json:"assignment_type"`
+	TargetID       string    `json:"target_id"`
+	DueAt          time.Time `json:"due_at"`
+	AllowLate      bool      `json:"allow_late"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+	// Campos calculados
+	ClassroomName  string `json:"classroom_name,omitempty"`
+	SubmittedCount int    `json:"submitted_count"`
+	StudentCount   int    `json:"student_count"`
+}
+
+// AssignmentSubmission entrega de un alumno
+type AssignmentSubmission struct {
+	AssignmentID string    `json:"assignment_id"`
+	UserID       string    `json:"user_id"`
+	Status       string    `json:"status"`
+	Score        int       `json:"score"`
+	SubmittedAt  time.Time `json:"submitted_at"`
+}
+
+// CreateAssignmentRequest datos para asignar una tarea
+type CreateAssignmentRequest struct {
+	Title          string    `json:"title" binding:"required,min=3,max=200"`
+	Instructions   string    `json:"instructions" binding:"max=2000"`
+	AssignmentType string    `json:"assignment_type" binding:"required,oneof=course lesson quiz scenario"`
+	TargetID       string    `json:"target_id" binding:"required"`
+	DueAt          time.Time `json:"due_at" binding:"required"`
+	AllowLate      *bool     `json:"allow_late,omitempty"`
+}
+
+// UpdateAssignmentRequest datos editables de una tarea
+type UpdateAssignmentRequest struct {
+	Title        *string    `json:"title,omitempty" binding:"omitempty,min=3,max=200"`
+	Instructions *string    `json:"instructions,omitempty" binding:"omitempty,max=2000"`
+	DueAt        *time.Time `json:"due_at,omitempty"`
+	AllowLate    *bool      `json:"allow_late,omitempty"`
+}
+
+// StudentAssignmentStatus estado de una tarea para un alumno
+type StudentAssignmentStatus struct {
+	UserID      string       `json:"user_id"`
+	Username    string       `json:"username"`
+	Status      string       `json:"status"`
+	Score       *int         `json:"score,omitempty"`
+	SubmittedAt sql.NullTime `json:"submitted_at,omitempty"`
+}
+
+// AssignmentDetailResponse tarea con el estado de cada alumno (vista docente)
+type AssignmentDetailResponse struct {
+	Assignment *Assignment               `json:"assignment"`
+	Students   []StudentAssignmentStatus `json:"students"`
+}
+
+// MyAssignment tarea vista por el alumno
+type MyAssignment struct {
+	Assignment
+	Status      string       `json:"status"`
+	Score       *int         `json:"score,omitempty"`
+	SubmittedAt sql.NullTime `json:"submitted_at,omitempty"`
+}
+
+// GradebookEntry celda del libro de calificaciones
+type GradebookEntry struct {
+	AssignmentID string `json:"assignment_id"`
+	Status       string `json:"status"`
+	Score        *int   `json:"score,omitempty"`
+}
+
+// GradebookRow calificaciones de un alumno
+type GradebookRow struct {
+	UserID   string           `json:"user_id"`
+	Username string           `json:"username"`
+	Entries  []GradebookEntry `json:"entries"`
+	Average  float64          `json:"average"`
+}
+
+// Gradebook libro de calificaciones del aula
+type Gradebook struct {
+	Classroom   *Classroom     `json:"classroom"`
+	Assignments []Assignment   `json:"assignments"`
+	Rows        []GradebookRow `json:"rows"`
+}
+
+// MyAssignmentDetail tarea del alumno con la actividad a resolver. El quiz y el
+// escenario asignados se exponen aunque ya no sean los del día.
+type MyAssignmentDetail struct {
+	Assignment *MyAssignment              `json:"assignment"`
+	Quiz       *QuizResponse              `json:"quiz,omitempty"`
+	Scenario   *SimulatorScenarioResponse `json:"scenario,omitempty"`
+}
@@ -0,0 +1,141 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+)
+
+// Classroom aula creada por un docente
+type Classroom struct {
+	ID          string         `json:"id"`
+	TeacherID   string         `json:"teacher_id"`
+	SchoolID    sql.NullString `json:"school_id,omitempty"`
+	Name        string         `json:"name"`
+	Description string         `json:"description"`
+	JoinCode    string         `json:"join_code,omitempty"`
+	IsActive    bool           `json:"is_active"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	// Campos calculados
+	TeacherUsername string `json:"teacher_username,omitempty"`
+	StudentCount    int    `json:"student_count"`
+}
+
+// ClassroomMember alumno de un aula
+type ClassroomMember struct {
+	UserID            string         `json:"user_id"`
+	Username          string         `json:"username"`
+	ProfilePictureURL sql.NullString `json:"profile_picture_url,omitempty"`
+	JoinedAt          time.Time      `json:"joined_at"`
+	LastLogin         sql.NullTime   `json:"last_login,omitempty"`
+}
+
+// CreateClassroomRequest datos para crear un aula
+type CreateClassroomRequest struct {
+	Name        string `json:"name" binding:"required,min=3,max=100"`
+	Description string `json:"description" binding:"max=1000"`
+}
+
+// UpdateClassroomRequest datos editables de un aula
+type UpdateClassroomRequest struct {
+	Name        *string `json:"name,omitempty" binding:"omitempty,min=3,max=100"`
+	Description *string `json:"description,omitempty" binding:"omitempty,max=1000"`
+	IsActive    *bool   `json:"is_active,omitempty"`
+}
+
+// JoinClassroomRequest código que el docente comparte con sus alumnos
+type JoinClassroomRequest struct {
+	JoinCode string `json:"join_code" binding:"required"`
+}
+
+// ProgressRange período de actividad a considerar en los reportes
+type ProgressRange struct {
+	From time.Time `json:"from"`
+	To   time.Time `json:"to"`
+}
+
+// StudentProgress actividad de un alumno en el período
+type StudentProgress struct {
+	UserID            string       `json:"user_id"`
+	Username          string       `json:"username"`
+	LastLogin         sql.NullTime `json:"last_login,omitempty"`
+	LessonsCompleted  int          `json:"lessons_completed"`
+	CoursesCompleted  int          `json:"courses_completed"`
+	QuizAttempts      int          `json:"quiz_attempts"`
+	AvgQuizScore      float64      `json:"avg_quiz_score"`
+	SimulatorAttempts int          `json:"simulator_attempts"`
+	SimulatorCorrect  int          `json:"simulator_correct"`
+	SimulatorAccuracy float64      `json:"simulator_accuracy"`
+	PvPMatches        int          `json:"pvp_matches"`
+	PvPWins           int          `json:"pvp_wins"`
+	SmartpointsEarned int          `json:"smartpoints_earned"`
+	LastActivityAt    sql.NullTime `json:"last_activity_at,omitempty"`
+}
+
+// ClassroomProgressSummary totales del aula en el período
+type ClassroomProgressSummary struct {
+	StudentCount      int     `json:"student_count"`
+	ActiveStudents    int     `json:"active_students"`
+	LessonsCompleted  int     `json:"lessons_completed"`
+	CoursesCompleted  int     `json:"courses_completed"`
+	QuizAttempts      int     `json:"quiz_attempts"`
+	AvgQuizScore      float64 `json:"avg_quiz_score"`
+	SimulatorAttempts int     `json:"simulator_attempts"`
+	SimulatorAccuracy float64 `json:"simulator_accuracy"`
+	PvPMatches        int     `json:"pvp_matches"`
+}
+
+// ClassroomProgressResponse dashboard del aula
+type ClassroomProgressResponse struct {
+	Classroom *Classroom               `json:"classroom"`
+	Range     ProgressRange            `json:"range"`
+	Summary   ClassroomProgressSummary `json:"summary"`
+	Students  []StudentProgress        `json:"students"`
+}
+
+// StudentCourseCompletion avance de un alumno en un curso
+type StudentCourseCompletion struct {
+	CourseID         string       `json:"course_id"`
+	CourseTitle      string       `json:"course_title"`
+	TotalLessons     int          `json:"total_lessons"`
+	CompletedLessons int          `json:"completed_lessons"`
+	IsCompleted      bool         `json:"is_completed"`
+	CompletedAt      sql.NullTime `json:"completed_at,omitempty"`
+}
+
+// StudentLessonActivity lección completada en el período
+type StudentLessonActivity struct {
+	LessonID    string        `json:"lesson_id"`
+	LessonTitle string        `json:"lesson_title"`
+	CourseTitle string        `json:"course_title"`
+	QuizScore   sql.NullInt64 `json:"quiz_score,omitempty"`
+	CompletedAt time.Time     `json:"completed_at"`
+}
+
+// StudentQuizActivity quiz diario resuelto en el período
+type StudentQuizActivity struct {
+	QuizID         string    `json:"quiz_id"`
+	Difficulty     string    `json:"difficulty"`
+	Score          int       `json:"score"`
+	CorrectAnswers int       `json:"correct_answers"`
+	TotalQuestions int       `json:"total_questions"`
+	CompletedAt    time.Time `json:"completed_at"`
+}
+
+// SimulatorAccuracy aciertos del simulador por dificultad
+type SimulatorAccuracy struct {
+	Difficulty string  `json:"difficulty"`
+	Attempts   int     `json:"attempts"`
+	Correct    int     `json:"correct"`
+	Accuracy   float64 `json:"accuracy"`
+}
+
+// StudentProgressDetail vista detallada de un alumno para el docente
+type StudentProgressDetail struct {
+	Range     ProgressRange             `json:"range"`
+	Summary   StudentProgress           `json:"summary"`
+	Courses   []StudentCourseCompletion `json:"courses"`
+	Lessons   []StudentLessonActivity   `json:"lessons"`
+	Quizzes   []StudentQuizActivity     `json:"quizzes"`
+	Simulator []SimulatorAccuracy       `json:"simulator"`
+}
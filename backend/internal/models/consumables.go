@@ -0,0 +1,81 @@
+package models
+
+import "time"
+
+// Tipos de consumibles que se compran con tokens
+const (
+	ConsumableSimulatorHint         = "simulator_hint"
+	ConsumableSimulatorExtraAttempt = "simulator_extra_attempt"
+	ConsumableQuizExtraAttempt      = "quiz_extra_attempt"
+	ConsumablePvPSecondChance       = "pvp_second_chance"
+)
+
+// ConsumablePurchase compra de un consumible. ReferenceID es el escenario,
+// quiz o partida sobre el que se usa.
+type ConsumablePurchase struct {
+	ID            string     `json:"id"`
+	UserID        string     `json:"user_id"`
+	Type          string     `json:"type"`
+	ReferenceID   string     `json:"reference_id"`
+	Price         int        `json:"price"`
+	TransactionID *string    `json:"transaction_id,omitempty"`
+	ConsumedAt    *time.Time `json:"consumed_at,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+// ConsumablePrices precios vigentes de los consumibles
+type ConsumablePrices struct {
+	SimulatorHint             int `json:"simulator_hint"`
+	SimulatorExtraAttempt     int `json:"simulator_extra_attempt"`
+	QuizExtraAttempt          int `json:"quiz_extra_attempt"`
+	PvPSecondChance           int `json:"pvp_second_chance"`
+	ExtraAttemptPointsPercent int `json:"extra_attempt_points_percent"`
+	ExtraAttemptsPerDay       int `json:"extra_attempts_per_day"` // por tipo, en 24 horas
+}
+
+// ConsumablesResponse precios y últimas compras del usuario
+type ConsumablesResponse struct {
+	Prices    ConsumablePrices     `json:"prices"`
+	Purchases []ConsumablePurchase `json:"purchases"`
+}
+
+// SimulatorHintRequest comprar una pista para un escenario
+type SimulatorHintRequest struct {
+	ScenarioID string `json:"scenario_id" binding:"required"`
+}
+
+// SimulatorHint pista de análisis técnico sobre los precios visibles del
+// escenario. Nunca revela la decisión correcta.
+type SimulatorHint struct {
+	ScenarioID  string `json:"scenario_id"`
+	Indicator   string `json:"indicator"`
+	Clue        string `json:"clue"`
+	TokensSpent int    `json:"tokens_spent"`
+}
+
+// ExtraAttemptRequest comprar un intento extra del simulador o del quiz
+type ExtraAttemptRequest struct {
+	Difficulty string `json:"difficulty" binding:"required,oneof=easy medium hard"`
+}
+
+// SimulatorExtraAttemptResponse escenario habilitado por el intento extra
+type SimulatorExtraAttemptResponse struct {
+	Purchase      ConsumablePurchase        `json:"purchase"`
+	Scenario      SimulatorScenarioResponse `json:"scenario"`
+	PointsPercent int                       `json:"points_percent"`
+}
+
+// QuizExtraAttemptResponse quiz habilitado por el intento extra
+type QuizExtraAttemptResponse struct {
+	Purchase      ConsumablePurchase `json:"purchase"`
+	Quiz          QuizResponse       `json:"quiz"`
+	PointsPercent int                `json:"points_percent"`
+}
+
+// PvPSecondChanceResponse decisión reemplazada con la segunda oportunidad
+type PvPSecondChanceResponse struct {
+	Purchase    ConsumablePurchase `json:"purchase"`
+	MatchID     string             `json:"match_id"`
+	RoundNumber int                `json:"round_number"`
+	Decision    SimulatorDecision  `json:"decision"`
+}
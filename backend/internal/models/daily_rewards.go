@@ -0,0 +1,69 @@
+package models
+
+import "time"
+
+// LoginStreak racha de check-ins diarios del usuario. LastCheckInDate es la
+// fecha local (según Timezone) del último check-in.
+type LoginStreak struct {
+	UserID           string
+	CurrentStreak    int
+	LongestStreak    int
+	FreezesAvailable int
+	TotalCheckIns    int
+	LastCheckInDate  *time.Time
+	LastCheckInAt    *time.Time
+	Timezone         string
+}
+
+// DailyCheckIn check-in de un día con la recompensa otorgada
+type DailyCheckIn struct {
+	ID           string    `json:"id"`
+	UserID       string    `json:"-"`
+	CheckInDate  time.Time `json:"check_in_date"`
+	Streak       int       `json:"streak"`
+	RewardTokens int       `json:"reward_tokens"`
+	BonusTokens  int       `json:"bonus_tokens"`
+	FreezesUsed  int       `json:"freezes_used"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// DailyCheckInRequest zona horaria IANA del usuario (ej.
+// "America/Argentina/Buenos_Aires"). Opcional: se guarda para los próximos días.
+type DailyCheckInRequest struct {
+	Timezone string `json:"timezone"`
+}
+
+// DailyCheckInResponse resultado del check-in
+type DailyCheckInResponse struct {
+	CheckInDate      string `json:"check_in_date"`
+	Streak           int    `json:"streak"`
+	LongestStreak    int    `json:"longest_streak"`
+	RewardTokens     int    `json:"reward_tokens"`
+	BonusTokens      int    `json:"bonus_tokens"`
+	StreakReset      bool   `json:"streak_reset"`
+	FreezesUsed      int    `json:"freezes_used"`
+	FreezeEarned     bool   `json:"freeze_earned"`
+	FreezesAvailable int    `json:"freezes_available"`
+}
+
+// DailyRewardStatus estado de la racha y recompensa del próximo check-in
+type DailyRewardStatus struct {
+	Timezone         string         `json:"timezone"`
+	Today            string         `json:"today"`
+	ClaimedToday     bool           `json:"claimed_today"`
+	CurrentStreak    int            `json:"current_streak"`
+	LongestStreak    int            `json:"longest_streak"`
+	TotalCheckIns    int            `json:"total_check_ins"`
+	FreezesAvailable int            `json:"freezes_available"`
+	MaxFreezes       int            `json:"max_freezes"`
+	FreezePrice      int            `json:"freeze_price"`
+	NextRewardTokens int            `json:"next_reward_tokens"`
+	NextBonusTokens  int            `json:"next_bonus_tokens"`
+	RecentCheckIns   []DailyCheckIn `json:"recent_check_ins"`
+}
+
+// StreakFreezeResponse resultado de comprar un protector de racha
+type StreakFreezeResponse struct {
+	FreezesAvailable int `json:"freezes_available"`
+	TokensSpent      int `json:"tokens_spent"`
+}
@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+// Tipos de notificación
+const (
+	NotificationTokenGift      = "token_gift"
+	NotificationReferralReward = "referral_reward"
+)
+
+// Notification notificación in-app de un usuario
+type Notification struct {
+	ID          string     `json:"id"`
+	UserID      string     `json:"-"`
+	Type        string     `json:"type"`
+	Title       string     `json:"title"`
+	Body        string     `json:"body"`
+	ReferenceID *string    `json:"reference_id,omitempty"`
+	ReadAt      *time.Time `json:"read_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// NotificationsResponse notificaciones del usuario y cuántas no leyó
+type NotificationsResponse struct {
+	Unread        int            `json:"unread"`
+	Notifications []Notification `json:"notifications"`
+}
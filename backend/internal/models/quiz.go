@@ -44,6 +44,7 @@ type QuizAttempt struct {
 	PointsEarned     int       `json:"points_earned"`
 	TimeTakenSeconds int       `json:"time_taken_seconds"`
 	Answers          string    `json:"answers"` // JSON string
+	ExtraAttemptID   string    `json:"extra_attempt_id,omitempty"`
 	StartedAt        time.Time `json:"started_at"`
 	CompletedAt      time.Time `json:"completed_at"`
 }
@@ -85,6 +86,7 @@ type SubmitQuizResponse struct {
 	PointsEarned   int              `json:"points_earned"`
 	NewTotalPoints int              `json:"new_total_points"`
 	NewRank        string           `json:"new_rank"`
+	ExtraAttempt   bool             `json:"extra_attempt,omitempty"` // Intento extra pagado (puntos reducidos)
 	Results        []QuestionResult `json:"results"`
 }
 
@@ -0,0 +1,48 @@
+package models
+
+import "time"
+
+// RankTierInfo rango de la tabla con su nombre en el idioma pedido
+type RankTierInfo struct {
+	Code      string `json:"code"`
+	Name      string `json:"name"`
+	Group     string `json:"group"`
+	Level     int    `json:"level"` // 1 = el rango más bajo
+	MinPoints int    `json:"min_points"`
+	MaxPoints *int   `json:"max_points,omitempty"` // nil en el rango más alto
+}
+
+// RankTiersResponse tabla de rangos y el rango actual del usuario
+type RankTiersResponse struct {
+	Locale           string         `json:"locale"`
+	Tiers            []RankTierInfo `json:"tiers"`
+	CurrentTier      *RankTierInfo  `json:"current_tier,omitempty"`
+	Smartpoints      int            `json:"smartpoints"`
+	PointsToNextTier *int           `json:"points_to_next_tier,omitempty"`
+}
+
+// RankTierChange ascenso o descenso de rango de un jugador
+type RankTierChange struct {
+	ID           string    `json:"id"`
+	UserID       string    `json:"-"`
+	FromTier     string    `json:"from_tier"`
+	FromTierName string    `json:"from_tier_name"`
+	ToTier       string    `json:"to_tier"`
+	ToTierName   string    `json:"to_tier_name"`
+	Direction    string    `json:"direction"` // promotion o demotion
+	Smartpoints  int       `json:"smartpoints"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// RankTierHistoryResponse ascensos y descensos del usuario, más recientes primero
+type RankTierHistoryResponse struct {
+	Locale  string           `json:"locale"`
+	Changes []RankTierChange `json:"changes"`
+}
+
+// UserRankTier rango guardado de un usuario, para recalcularlo con la tabla
+type UserRankTier struct {
+	UserID      string
+	Smartpoints int
+	RankTier    string
+}
@@ -4,6 +4,16 @@ import (
 	"time"
 )
 
+// Ventanas de los rankings de jugadores: all_time ordena por smartpoints
+// totales, el resto por los puntos sumados desde el inicio del día, la semana
+// (lunes) o el mes
+const (
+	LeaderboardWindowAllTime = "all_time"
+	LeaderboardWindowDaily   = "daily"
+	LeaderboardWindowWeekly  = "weekly"
+	LeaderboardWindowMonthly = "monthly"
+)
+
 // LeaderboardEntry representa una entrada en el ranking
 type LeaderboardEntry struct {
 	RankPosition      int     `json:"rank_position"`
@@ -17,12 +27,19 @@ type LeaderboardEntry struct {
 	ProfilePictureURL *string `json:"profile_picture_url,omitempty"`
 	SchoolName        *string `json:"school_name,omitempty"`
 	SchoolID          *string `json:"school_id,omitempty"`
+	WindowPoints      int     `json:"window_points,omitempty"`     // puntos dentro de la ventana
+	PreviousPosition  *int    `json:"previous_position,omitempty"` // posición en la última foto diaria
+	RankDelta         *int    `json:"rank_delta,omitempty"`        // puestos subidos desde la foto (negativo si bajó)
 	IsCurrentUser     bool    `json:"is_current_user"`
+
+	Cosmetics *EquippedCosmetics `json:"cosmetics,omitempty"` // cosméticos equipados de la tienda
 }
 
 // LeaderboardResponse representa la respuesta del ranking
 type LeaderboardResponse struct {
 	Type         string             `json:"type"` // "global" o "school"
+	Window       string             `json:"window"`
+	Since        *time.Time         `json:"since,omitempty"` // inicio de la ventana
 	TopPlayers   []LeaderboardEntry `json:"top_players"`
 	UserPosition *LeaderboardEntry  `json:"user_position,omitempty"`
 	TotalPlayers int                `json:"total_players"`
@@ -31,9 +48,79 @@ type LeaderboardResponse struct {
 
 // UserPositionResponse representa la posición del usuario
 type UserPositionResponse struct {
-	GlobalPosition int `json:"global_position"`
-	SchoolPosition int `json:"school_position,omitempty"`
-	TotalPlayers   int `json:"total_players"`
+	Window         string `json:"window"`
+	GlobalPosition int    `json:"global_position"`
+	SchoolPosition int    `json:"school_position,omitempty"`
+	TotalPlayers   int    `json:"total_players"`
+}
+
+// LeaderboardSnapshot posiciones de un jugador en la foto diaria de los
+// rankings (tomada al comenzar el día)
+type LeaderboardSnapshot struct {
+	UserID         string    `json:"-"`
+	SnapshotDate   time.Time `json:"snapshot_date"`
+	GlobalPosition int       `json:"global_position"`
+	SchoolID       *string   `json:"school_id,omitempty"`
+	SchoolPosition *int      `json:"school_position,omitempty"`
+	Smartpoints    int       `json:"smartpoints"`
+}
+
+// RankHistoryResponse evolución de las posiciones del usuario. Las fotos de
+// más de 90 días se compactan a una por semana.
+type RankHistoryResponse struct {
+	Days      int                   `json:"days"`
+	Snapshots []LeaderboardSnapshot `json:"snapshots"`
+}
+
+// Disciplinas con ranking propio
+const (
+	DisciplineQuiz      = "quiz"
+	DisciplineSimulator = "simulator"
+	DisciplinePvP       = "pvp"
+	DisciplineCourses   = "courses"
+)
+
+// Métricas de los rankings por disciplina
+const (
+	DisciplineMetricAccuracy         = "accuracy"          // % de respuestas o decisiones correctas
+	DisciplineMetricWinRate          = "win_rate"          // % de partidas PvP ganadas
+	DisciplineMetricCoursesCompleted = "courses_completed" // cursos terminados
+)
+
+// DisciplineLeaderboardEntry posición de un jugador en el ranking de una
+// disciplina. Activity son los intentos, partidas o lecciones completadas que
+// respaldan el puntaje y desempatan.
+type DisciplineLeaderboardEntry struct {
+	RankPosition      int     `json:"rank_position"`
+	UserID            string  `json:"user_id"`
+	Username          string  `json:"username"`
+	ProfilePictureURL *string `json:"profile_picture_url,omitempty"`
+	SchoolName        *string `json:"school_name,omitempty"`
+	Score             float64 `json:"score"`
+	Activity          int     `json:"activity"`
+	IsCurrentUser     bool    `json:"is_current_user"`
+}
+
+// DisciplineLeaderboardResponse ranking de una disciplina. Solo figuran los
+// jugadores con al menos MinActivity de actividad.
+type DisciplineLeaderboardResponse struct {
+	Discipline   string                       `json:"discipline"`
+	Difficulty   string                       `json:"difficulty,omitempty"`
+	Metric       string                       `json:"metric"`
+	MinActivity  int                          `json:"min_activity"`
+	TopPlayers   []DisciplineLeaderboardEntry `json:"top_players"`
+	UserPosition *DisciplineLeaderboardEntry  `json:"user_position,omitempty"`
+	TotalPlayers int                          `json:"total_players"`
+	GeneratedAt  time.Time                    `json:"generated_at"`
+}
+
+// LeaderboardScore puntajes con los que un usuario figura en los rankings en
+// Redis. SchoolID vacío si no tiene membresía aprobada.
+type LeaderboardScore struct {
+	UserID       string
+	SchoolID     string
+	Smartpoints  int
+	SeasonPoints int
 }
 
 // Achievement representa un logro
@@ -55,6 +142,8 @@ type UserProfilePublic struct {
 	Achievements []Achievement `json:"achievements"`
 	GlobalRank   int           `json:"global_rank"`
 	SchoolRank   int           `json:"school_rank,omitempty"`
+
+	Cosmetics *EquippedCosmetics `json:"cosmetics,omitempty"`
 }
 
 // SchoolLeaderboardResponse representa el ranking de un colegio específico
@@ -80,9 +169,11 @@ type AchievementProgress struct {
 	AchievementType string  `json:"achievement_type"`
 	Name            string  `json:"name"`
 	Description     string  `json:"description"`
+	IconURL         *string `json:"icon_url,omitempty"`
 	Current         int     `json:"current"`
 	Required        int     `json:"required"`
 	Progress        float64 `json:"progress"` // Porcentaje (0-100)
+	TokenBonus      int     `json:"token_bonus"`
 	IsUnlocked      bool    `json:"is_unlocked"`
 }
 
@@ -92,3 +183,59 @@ type AllAchievementsResponse struct {
 	Locked     []AchievementProgress `json:"locked"`
 	TotalCount int                   `json:"total_count"`
 }
+
+// Ventanas del ranking entre colegios
+const (
+	SchoolRankingWindowWeekly  = "weekly"
+	SchoolRankingWindowAllTime = "all_time"
+)
+
+// SchoolMemberPerformance rendimiento de un miembro aprobado dentro de una ventana
+type SchoolMemberPerformance struct {
+	SchoolID   string
+	SchoolName string
+	Location   string
+	UserID     string
+	Points     int
+	PvPWins    int
+	Active     bool
+}
+
+// SchoolRankingEntry representa un colegio en el ranking entre colegios
+type SchoolRankingEntry struct {
+	RankPosition       int     `json:"rank_position,omitempty"` // 0 si no clasifica
+	Ranked             bool    `json:"ranked"`
+	SchoolID           string  `json:"school_id"`
+	SchoolName         string  `json:"school_name"`
+	Location           string  `json:"location"`
+	Score              float64 `json:"score"` // 0-100
+	TopAverage         float64 `json:"top_average"`
+	AdjustedTopAverage float64 `json:"adjusted_top_average"` // Normalizado por tamaño
+	MemberCount        int     `json:"member_count"`
+	ActiveMembers      int     `json:"active_members"`
+	ParticipationRate  float64 `json:"participation_rate"` // 0-1
+	PvPWins            int     `json:"pvp_wins"`
+	PvPWinsPerMember   float64 `json:"pvp_wins_per_member"`
+	IsCurrentSchool    bool    `json:"is_current_school"`
+}
+
+// SchoolRankingResponse representa el ranking entre colegios
+type SchoolRankingResponse struct {
+	Window       string               `json:"window"`
+	Since        *time.Time           `json:"since,omitempty"` // Inicio de la ventana semanal
+	TopN         int                  `json:"top_n"`
+	MinMembers   int                  `json:"min_members"`
+	Schools      []SchoolRankingEntry `json:"schools"`
+	MySchool     *SchoolRankingEntry  `json:"my_school,omitempty"`
+	TotalSchools int                  `json:"total_schools"`
+	GeneratedAt  time.Time            `json:"generated_at"`
+}
+
+// SchoolProfileResponse representa la página pública de un colegio
+type SchoolProfileResponse struct {
+	School             School              `json:"school"`
+	AllTime            *SchoolRankingEntry `json:"all_time"`
+	Weekly             *SchoolRankingEntry `json:"weekly"`
+	TotalRankedSchools int                 `json:"total_ranked_schools"`
+	TopPlayers         []LeaderboardEntry  `json:"top_players"`
+}
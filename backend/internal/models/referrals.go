@@ -0,0 +1,60 @@
+package models
+
+import "time"
+
+// Estados de un referido
+const (
+	ReferralPending  = "pending"
+	ReferralDeferred = "deferred" // calificó con el límite mensual alcanzado
+	ReferralRewarded = "rewarded"
+	ReferralRejected = "rejected"
+)
+
+// Motivos por los que un referido no recibe recompensa (o, con el límite
+// mensual, la recibe más adelante)
+const (
+	ReferralRejectLinkedAccounts = "linked_accounts"
+	ReferralRejectMonthlyLimit   = "monthly_limit_reached"
+	ReferralRejectTotalLimit     = "total_limit_reached"
+)
+
+// Referral alumno invitado con el código de otro usuario
+type Referral struct {
+	ID              string     `json:"id"`
+	ReferrerID      string     `json:"-"`
+	RefereeID       string     `json:"referee_id"`
+	RefereeUsername string     `json:"referee_username"`
+	Code            string     `json:"code"`
+	Status          string     `json:"status"`
+	RejectReason    *string    `json:"reject_reason,omitempty"`
+	RewardTokens    int        `json:"reward_tokens"`
+	TransactionID   *string    `json:"transaction_id,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
+	ResolvedAt      *time.Time `json:"resolved_at,omitempty"`
+}
+
+// ReferralLimits recompensa y límites vigentes del programa
+type ReferralLimits struct {
+	RewardTokens       int `json:"reward_tokens"`
+	MonthlyRewardLimit int `json:"monthly_reward_limit"`
+	TotalRewardLimit   int `json:"total_reward_limit"`
+	RewardedThisMonth  int `json:"rewarded_this_month"`
+}
+
+// ReferralSummary totales de los referidos de un usuario
+type ReferralSummary struct {
+	Total        int `json:"total"`
+	Pending      int `json:"pending"`
+	Deferred     int `json:"deferred"`
+	Rewarded     int `json:"rewarded"`
+	Rejected     int `json:"rejected"`
+	TokensEarned int `json:"tokens_earned"`
+}
+
+// ReferralsResponse código del usuario, sus referidos y lo que ganó
+type ReferralsResponse struct {
+	Code      string          `json:"code"`
+	Limits    ReferralLimits  `json:"limits"`
+	Summary   ReferralSummary `json:"summary"`
+	Referrals []Referral      `json:"referrals"`
+}
@@ -0,0 +1,87 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+)
+
+// Estados de la membresía a un colegio
+const (
+	MembershipStatusPending  = "pending"
+	MembershipStatusApproved = "approved"
+	MembershipStatusRejected = "rejected"
+)
+
+// Formas de verificar la membresía
+const (
+	MembershipMethodRequest     = "request"
+	MembershipMethodJoinCode    = "join_code"
+	MembershipMethodEmailDomain = "email_domain"
+	MembershipMethodAdmin       = "admin"
+	MembershipMethodLegacy      = "legacy"
+)
+
+// SchoolDetail colegio con los datos que solo ven sus administradores
+type SchoolDetail struct {
+	School
+	JoinCode     string    `json:"join_code,omitempty"`
+	EmailDomains []string  `json:"email_domains"`
+	UpdatedAt    time.Time `json:"updated_at"`
+	// Campos calculados
+	MemberCount  int `json:"member_count"`
+	PendingCount int `json:"pending_count"`
+}
+
+// SchoolMembership membresía (o solicitud) de un usuario a un colegio
+type SchoolMembership struct {
+	UserID      string         `json:"user_id"`
+	SchoolID    string         `json:"school_id"`
+	Status      string         `json:"status"`
+	Method      string         `json:"method"`
+	RequestedAt time.Time      `json:"requested_at"`
+	ReviewedBy  sql.NullString `json:"reviewed_by,omitempty"`
+	ReviewedAt  sql.NullTime   `json:"reviewed_at,omitempty"`
+	// Campos calculados
+	SchoolName    string `json:"school_name,omitempty"`
+	Username      string `json:"username,omitempty"`
+	Email         string `json:"email,omitempty"`
+	EmailVerified bool   `json:"email_verified,omitempty"`
+}
+
+// CreateSchoolRequest datos para dar de alta un colegio
+type CreateSchoolRequest struct {
+	Name         string   `json:"name" binding:"required,min=3,max=255"`
+	Location     string   `json:"location" binding:"max=255"`
+	EmailDomains []string `json:"email_domains,omitempty"`
+}
+
+// UpdateSchoolRequest datos editables de un colegio
+type UpdateSchoolRequest struct {
+	Name         *string   `json:"name,omitempty" binding:"omitempty,min=3,max=255"`
+	Location     *string   `json:"location,omitempty" binding:"omitempty,max=255"`
+	EmailDomains *[]string `json:"email_domains,omitempty"`
+}
+
+// JoinSchoolRequest solicitud de membresía: por código de acceso o por colegio
+type JoinSchoolRequest struct {
+	SchoolID *string `json:"school_id,omitempty"`
+	JoinCode *string `json:"join_code,omitempty"`
+}
+
+// SchoolAdminRequest usuario a designar como administrador del colegio
+type SchoolAdminRequest struct {
+	UserID string `json:"user_id" binding:"required"`
+}
+
+// SchoolImportError fila del CSV que no se pudo importar
+type SchoolImportError struct {
+	Line  int    `json:"line"`
+	Name  string `json:"name,omitempty"`
+	Error string `json:"error"`
+}
+
+// SchoolImportResult resultado de la importación masiva
+type SchoolImportResult struct {
+	Created []SchoolDetail      `json:"created"`
+	Errors  []SchoolImportError `json:"errors"`
+}
@@ -0,0 +1,79 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+)
+
+// Estados de una temporada
+const (
+	SeasonStatusScheduled = "scheduled"
+	SeasonStatusActive    = "active"
+	SeasonStatusFinalized = "finalized"
+)
+
+// Season temporada competitiva
+type Season struct {
+	ID               string         `json:"id"`
+	Name             string         `json:"name"`
+	StartsAt         time.Time      `json:"starts_at"`
+	EndsAt           time.Time      `json:"ends_at"`
+	CarryoverPercent int            `json:"carryover_percent"`
+	Status           string         `json:"status"`
+	ActivatedAt      sql.NullTime   `json:"activated_at,omitempty"`
+	FinalizedAt      sql.NullTime   `json:"finalized_at,omitempty"`
+	CreatedBy        sql.NullString `json:"-"`
+	CreatedAt        time.Time      `json:"created_at"`
+	Rewards          []SeasonReward `json:"rewards"`
+}
+
+// SeasonReward premio en tokens para un rango de posiciones finales
+type SeasonReward struct {
+	PositionFrom int `json:"position_from" binding:"required,min=1"`
+	PositionTo   int `json:"position_to" binding:"required,min=1"`
+	TokenReward  int `json:"token_reward" binding:"required,min=1"`
+}
+
+// CreateSeasonRequest alta de una temporada
+type CreateSeasonRequest struct {
+	Name             string         `json:"name" binding:"required,min=3,max=100"`
+	StartsAt         time.Time      `json:"starts_at" binding:"required"`
+	EndsAt           time.Time      `json:"ends_at" binding:"required"`
+	CarryoverPercent *int           `json:"carryover_percent" binding:"omitempty,min=0,max=100"`
+	Rewards          []SeasonReward `json:"rewards" binding:"dive"`
+}
+
+// SeasonStanding posición de un jugador en una temporada (en vivo o archivada)
+type SeasonStanding struct {
+	RankPosition      int     `json:"rank_position"`
+	UserID            string  `json:"user_id"`
+	Username          string  `json:"username"`
+	ProfilePictureURL *string `json:"profile_picture_url,omitempty"`
+	SeasonPoints      int     `json:"season_points"`
+	RankTier          string  `json:"rank_tier"`
+	TokensAwarded     int     `json:"tokens_awarded"`
+	IsCurrentUser     bool    `json:"is_current_user"`
+}
+
+// SeasonLeaderboardResponse ranking de una temporada. Live indica que la
+// temporada está en curso y las posiciones todavía pueden cambiar.
+type SeasonLeaderboardResponse struct {
+	Season       *Season          `json:"season"`
+	Live         bool             `json:"live"`
+	Standings    []SeasonStanding `json:"standings"`
+	UserPosition *SeasonStanding  `json:"user_position,omitempty"`
+	TotalPlayers int              `json:"total_players"`
+}
+
+// SeasonHistoryEntry resultado final de un usuario en una temporada pasada
+type SeasonHistoryEntry struct {
+	SeasonID      string    `json:"season_id"`
+	SeasonName    string    `json:"season_name"`
+	StartsAt      time.Time `json:"starts_at"`
+	EndsAt        time.Time `json:"ends_at"`
+	FinalPosition int       `json:"final_position"`
+	SeasonPoints  int       `json:"season_points"`
+	RankTier      string    `json:"rank_tier"`
+	TokensAwarded int       `json:"tokens_awarded"`
+	TotalPlayers  int       `json:"total_players"`
+}
@@ -0,0 +1,117 @@
+package models
+
+import "time"
+
+// Tipos de cosméticos de la tienda
+const (
+	ShopItemAvatarFrame  = "avatar_frame"
+	ShopItemProfileBadge = "profile_badge"
+	ShopItemChartTheme   = "chart_theme"
+	ShopItemNameColor    = "name_color"
+)
+
+// ShopItem cosmético del catálogo. Available indica si se puede comprar
+// ahora (activo y dentro de la ventana de venta).
+type ShopItem struct {
+	ID             string     `json:"id"`
+	ItemType       string     `json:"item_type"`
+	Code           string     `json:"code"`
+	Name           string     `json:"name"`
+	Description    *string    `json:"description,omitempty"`
+	Price          int        `json:"price"`
+	AssetURL       *string    `json:"asset_url,omitempty"`
+	StyleValue     *string    `json:"style_value,omitempty"`
+	AvailableFrom  *time.Time `json:"available_from,omitempty"`
+	AvailableUntil *time.Time `json:"available_until,omitempty"`
+	IsActive       bool       `json:"is_active"`
+	SortOrder      int        `json:"sort_order"`
+	CreatedAt      time.Time  `json:"created_at"`
+	Available      bool       `json:"available"`
+	Owned          bool       `json:"owned"`
+}
+
+// ShopCatalogResponse catálogo con el saldo del usuario
+type ShopCatalogResponse struct {
+	Balance int        `json:"balance"`
+	Items   []ShopItem `json:"items"`
+}
+
+// InventoryItem cosmético comprado por el usuario
+type InventoryItem struct {
+	ID          string    `json:"id"`
+	UserID      string    `json:"-"`
+	ItemID      string    `json:"item_id"`
+	ItemType    string    `json:"item_type"`
+	Code        string    `json:"code"`
+	Name        string    `json:"name"`
+	AssetURL    *string   `json:"asset_url,omitempty"`
+	StyleValue  *string   `json:"style_value,omitempty"`
+	PricePaid   int       `json:"price_paid"`
+	IsEquipped  bool      `json:"is_equipped"`
+	PurchasedAt time.Time `json:"purchased_at"`
+}
+
+// ShopPurchaseResponse resultado de una compra
+type ShopPurchaseResponse struct {
+	Item        InventoryItem `json:"item"`
+	TokensSpent int           `json:"tokens_spent"`
+}
+
+// EquippedCosmetic cosmético equipado, con lo necesario para mostrarlo
+type EquippedCosmetic struct {
+	ItemID     string  `json:"item_id"`
+	Code       string  `json:"code"`
+	Name       string  `json:"name"`
+	AssetURL   *string `json:"asset_url,omitempty"`
+	StyleValue *string `json:"style_value,omitempty"`
+}
+
+// EquippedCosmetics cosméticos equipados de un usuario (uno por tipo)
+type EquippedCosmetics struct {
+	AvatarFrame  *EquippedCosmetic `json:"avatar_frame,omitempty"`
+	ProfileBadge *EquippedCosmetic `json:"profile_badge,omitempty"`
+	ChartTheme   *EquippedCosmetic `json:"chart_theme,omitempty"`
+	NameColor    *EquippedCosmetic `json:"name_color,omitempty"`
+}
+
+// Set asigna el cosmético al casillero de su tipo (ignora tipos desconocidos)
+func (e *EquippedCosmetics) Set(itemType string, cosmetic *EquippedCosmetic) {
+	switch itemType {
+	case ShopItemAvatarFrame:
+		e.AvatarFrame = cosmetic
+	case ShopItemProfileBadge:
+		e.ProfileBadge = cosmetic
+	case ShopItemChartTheme:
+		e.ChartTheme = cosmetic
+	case ShopItemNameColor:
+		e.NameColor = cosmetic
+	}
+}
+
+// CreateShopItemRequest alta de un cosmético (admin)
+type CreateShopItemRequest struct {
+	ItemType       string     `json:"item_type" binding:"required,oneof=avatar_frame profile_badge chart_theme name_color"`
+	Code           string     `json:"code" binding:"required,min=3,max=50"`
+	Name           string     `json:"name" binding:"required,min=2,max=100"`
+	Description    *string    `json:"description"`
+	Price          int        `json:"price" binding:"required,min=1"`
+	AssetURL       *string    `json:"asset_url" binding:"omitempty,max=500"`
+	StyleValue     *string    `json:"style_value" binding:"omitempty,max=100"`
+	AvailableFrom  *time.Time `json:"available_from"`
+	AvailableUntil *time.Time `json:"available_until"`
+	SortOrder      int        `json:"sort_order"`
+}
+
+// UpdateShopItemRequest reemplaza los datos editables de un cosmético
+// (admin). El tipo y el código no cambian; sin fechas no hay ventana de venta.
+type UpdateShopItemRequest struct {
+	Name           string     `json:"name" binding:"required,min=2,max=100"`
+	Description    *string    `json:"description"`
+	Price          int        `json:"price" binding:"required,min=1"`
+	AssetURL       *string    `json:"asset_url" binding:"omitempty,max=500"`
+	StyleValue     *string    `json:"style_value" binding:"omitempty,max=100"`
+	AvailableFrom  *time.Time `json:"available_from"`
+	AvailableUntil *time.Time `json:"available_until"`
+	IsActive       *bool      `json:"is_active"`
+	SortOrder      int        `json:"sort_order"`
+}
@@ -55,6 +55,7 @@ type SimulatorAttempt struct {
 	WasCorrect       bool                `json:"was_correct"`
 	PointsEarned     int                 `json:"points_earned"`
 	TimeTakenSeconds sql.NullInt64       `json:"time_taken_seconds,omitempty"`
+	ExtraAttemptID   string              `json:"extra_attempt_id,omitempty"` // Compra usada ("" en el intento del día)
 	CreatedAt        time.Time           `json:"created_at"`
 }
 
@@ -102,6 +103,7 @@ type SubmitSimulatorDecisionResponse struct {
 	FullChartData   ChartData         `json:"full_chart_data"` // Gráfico completo con el futuro
 	NewTotalPoints  int               `json:"new_total_points"`
 	NewRankTier     string            `json:"new_rank_tier"`
+	ExtraAttempt    bool              `json:"extra_attempt,omitempty"` // Intento extra pagado (puntos reducidos)
 }
 
 // SimulatorHistoryResponse representa el historial de intentos
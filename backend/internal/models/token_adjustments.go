@@ -0,0 +1,56 @@
+package models
+
+import "time"
+
+// Acciones de un ajuste manual de tokens
+const (
+	TokenAdjustmentGrant  = "grant"
+	TokenAdjustmentDeduct = "deduct"
+	TokenAdjustmentRefund = "refund"
+)
+
+// TokenAdjustment ajuste manual de tokens hecho por un administrador
+type TokenAdjustment struct {
+	ID                    string    `json:"id"`
+	AdminID               *string   `json:"admin_id,omitempty"`
+	AdminUsername         *string   `json:"admin_username,omitempty"`
+	UserID                string    `json:"user_id"`
+	Username              string    `json:"username"`
+	Action                string    `json:"action"`
+	Amount                int       `json:"amount"`
+	Reason                string    `json:"reason"`
+	TransactionID         string    `json:"transaction_id"`
+	RefundedTransactionID *string   `json:"refunded_transaction_id,omitempty"`
+	BatchID               *string   `json:"batch_id,omitempty"`
+	BalanceAfter          int       `json:"balance_after"`
+	CreatedAt             time.Time `json:"created_at"`
+}
+
+// TokenAdjustmentRequest otorgar o descontar tokens a un usuario
+type TokenAdjustmentRequest struct {
+	UserID string `json:"user_id" binding:"required"`
+	Action string `json:"action" binding:"required,oneof=grant deduct"`
+	Amount int    `json:"amount" binding:"required,min=1,max=100000"`
+	Reason string `json:"reason" binding:"required,min=5,max=500"`
+}
+
+// TokenRefundRequest devolver un gasto (un descuento manual o una
+// inscripción a torneo)
+type TokenRefundRequest struct {
+	TransactionID string `json:"transaction_id" binding:"required"`
+	Reason        string `json:"reason" binding:"required,min=5,max=500"`
+}
+
+// TokenAdjustmentImportError fila del CSV que no se pudo aplicar
+type TokenAdjustmentImportError struct {
+	Line  int    `json:"line"`
+	User  string `json:"user,omitempty"`
+	Error string `json:"error"`
+}
+
+// TokenAdjustmentImportResult resultado de los ajustes masivos
+type TokenAdjustmentImportResult struct {
+	BatchID string                       `json:"batch_id"`
+	Applied []TokenAdjustment            `json:"applied"`
+	Errors  []TokenAdjustmentImportError `json:"errors"`
+}
@@ -0,0 +1,84 @@
+package models
+
+import "time"
+
+// Direcciones del historial de regalos
+const (
+	TokenGiftsSent     = "sent"
+	TokenGiftsReceived = "received"
+)
+
+// TokenGift regalo de tokens entre dos usuarios. SenderID y RecipientID
+// quedan en nil si la cuenta se eliminó.
+type TokenGift struct {
+	ID                     string    `json:"id"`
+	SenderID               *string   `json:"sender_id,omitempty"`
+	SenderUsername         *string   `json:"sender_username,omitempty"`
+	RecipientID            *string   `json:"recipient_id,omitempty"`
+	RecipientUsername      *string   `json:"recipient_username,omitempty"`
+	Amount                 int       `json:"amount"`
+	Message                *string   `json:"message,omitempty"`
+	SenderTransactionID    string    `json:"-"`
+	RecipientTransactionID string    `json:"-"`
+	BalanceAfter           *int      `json:"balance_after,omitempty"` // saldo del remitente, solo al enviar
+	CreatedAt              time.Time `json:"created_at"`
+}
+
+// SendTokenGiftRequest regalar tokens a otro usuario
+type SendTokenGiftRequest struct {
+	RecipientUsername string `json:"recipient_username" binding:"required"`
+	Amount            int    `json:"amount" binding:"required,min=1"`
+	Message           string `json:"message" binding:"max=140"`
+}
+
+// TokenGiftLimits límites de regalos del usuario en las últimas 24 horas
+type TokenGiftLimits struct {
+	DailyTokenLimit   int  `json:"daily_token_limit"`
+	DailyGiftLimit    int  `json:"daily_gift_limit"`
+	TokensSent        int  `json:"tokens_sent"`
+	GiftsSent         int  `json:"gifts_sent"`
+	RemainingTokens   int  `json:"remaining_tokens"`
+	RemainingGifts    int  `json:"remaining_gifts"`
+	MinAccountAgeDays int  `json:"min_account_age_days"`
+	Eligible          bool `json:"eligible"` // la cuenta tiene la antigüedad mínima
+}
+
+// === CUENTAS VINCULADAS ===
+
+// Motivos y estados de un vínculo entre cuentas
+const (
+	AccountLinkSameDevice = "same_device"
+	AccountLinkManual     = "manual"
+
+	AccountLinkLinked    = "linked"
+	AccountLinkDismissed = "dismissed"
+)
+
+// AccountLink par de cuentas que parecen de la misma persona
+type AccountLink struct {
+	ID            string    `json:"id"`
+	UserAID       string    `json:"user_a_id"`
+	UserAUsername string    `json:"user_a_username"`
+	UserBID       string    `json:"user_b_id"`
+	UserBUsername string    `json:"user_b_username"`
+	Reason        string    `json:"reason"`
+	Status        string    `json:"status"`
+	Notes         *string   `json:"notes,omitempty"`
+	CreatedBy     *string   `json:"created_by,omitempty"`
+	ReviewedBy    *string   `json:"reviewed_by,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// FlagAccountLinkRequest marcar a mano dos cuentas como vinculadas
+type FlagAccountLinkRequest struct {
+	UserID       string `json:"user_id" binding:"required"`
+	LinkedUserID string `json:"linked_user_id" binding:"required"`
+	Notes        string `json:"notes" binding:"max=500"`
+}
+
+// UpdateAccountLinkRequest confirmar o descartar un vínculo
+type UpdateAccountLinkRequest struct {
+	Status string `json:"status" binding:"required,oneof=linked dismissed"`
+	Notes  string `json:"notes" binding:"max=500"`
+}
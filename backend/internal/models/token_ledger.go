@@ -0,0 +1,49 @@
+package models
+
+import "time"
+
+// Quién disparó una conciliación de saldos
+const (
+	ReconciliationTriggeredByJob   = "job"
+	ReconciliationTriggeredByAdmin = "admin"
+)
+
+// TokenReconciliationRun ejecución de la conciliación de saldos contra el ledger
+type TokenReconciliationRun struct {
+	ID              string     `json:"id"`
+	TriggeredBy     string     `json:"triggered_by"`
+	RequestedBy     *string    `json:"requested_by,omitempty"`
+	Repair          bool       `json:"repair"`
+	AccountsChecked int        `json:"accounts_checked"`
+	DriftCount      int        `json:"drift_count"`
+	RepairedCount   int        `json:"repaired_count"`
+	StartedAt       time.Time  `json:"started_at"`
+	FinishedAt      *time.Time `json:"finished_at,omitempty"` // nil si la ejecución falló
+}
+
+// TokenBalanceDrift cuenta cuyo saldo guardado no coincide con el recalculado
+// desde token_transactions
+type TokenBalanceDrift struct {
+	UserID        string `json:"user_id"`
+	Username      string `json:"username"`
+	StoredBalance int    `json:"stored_balance"`
+	LedgerBalance int    `json:"ledger_balance"`
+	StoredEarned  int    `json:"stored_earned"`
+	LedgerEarned  int    `json:"ledger_earned"`
+	StoredSpent   int    `json:"stored_spent"`
+	LedgerSpent   int    `json:"ledger_spent"`
+	BalanceDelta  int    `json:"balance_delta"` // stored - ledger
+	Repaired      bool   `json:"repaired"`
+}
+
+// TokenReconciliationReport ejecución con las diferencias encontradas
+type TokenReconciliationReport struct {
+	Run    TokenReconciliationRun `json:"run"`
+	Drifts []TokenBalanceDrift    `json:"drifts"`
+}
+
+// ReconcileTokensRequest ejecución manual de la conciliación. Con repair se
+// corrigen los saldos (el ledger manda).
+type ReconcileTokensRequest struct {
+	Repair bool `json:"repair"`
+}
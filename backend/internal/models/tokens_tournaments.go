@@ -38,6 +38,57 @@ type TokensResponse struct {
 	RecentTransactions []TokenTransaction `json:"recent_transactions"`
 }
 
+// TokenTransactionTypes tipos de transacción del ledger de tokens
+var TokenTransactionTypes = []string{
+	"tournament_reward", "tournament_entry",
+	"daily_bonus", "achievement_bonus",
+	"admin_grant", "admin_deduct", "purchase", "refund",
+	"season_reward", "gift_sent", "gift_received",
+	"referral_reward",
+}
+
+// TokenHistoryFilter filtros del historial de transacciones. From es
+// inclusivo y To exclusivo; nil significa sin límite.
+type TokenHistoryFilter struct {
+	Types  []string
+	From   *time.Time
+	To     *time.Time
+	Cursor string
+	Limit  int
+}
+
+// TokenHistoryCursor posición en el historial (orden created_at, id descendente)
+type TokenHistoryCursor struct {
+	CreatedAt time.Time
+	ID        string
+}
+
+// TokenTransactionPage página del historial de transacciones. NextCursor es
+// nil en la última página.
+type TokenTransactionPage struct {
+	Transactions []TokenTransaction `json:"transactions"`
+	NextCursor   *string            `json:"next_cursor,omitempty"`
+}
+
+// TokenWeeklySummary tokens ganados y gastados en una semana (lunes a domingo)
+type TokenWeeklySummary struct {
+	WeekStart    string `json:"week_start"`
+	Earned       int    `json:"earned"`
+	Spent        int    `json:"spent"`
+	Net          int    `json:"net"`
+	Transactions int    `json:"transactions"`
+}
+
+// TokenHistorySummary resumen semanal del período
+type TokenHistorySummary struct {
+	From   string               `json:"from"`
+	To     string               `json:"to"`
+	Earned int                  `json:"earned"`
+	Spent  int                  `json:"spent"`
+	Net    int                  `json:"net"`
+	Weeks  []TokenWeeklySummary `json:"weeks"`
+}
+
 // === TOURNAMENTS ===
 
 // TournamentType tipos de torneo
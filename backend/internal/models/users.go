@@ -12,6 +12,7 @@ type User struct {
 	PasswordHash      string         `json:"-"`
 	ProfilePictureURL sql.NullString `json:"profile_picture_url,omitempty"`
 	SchoolID          sql.NullString `json:"school_id,omitempty"`
+	Role              string         `json:"role"`
 	CreatedAt         time.Time      `json:"created_at"`
 	UpdatedAt         time.Time      `json:"updated_at"`
 	LastLogin         sql.NullTime   `json:"last_login,omitempty"`
@@ -21,15 +22,25 @@ type User struct {
 	ResetTokenExpires sql.NullTime   `json:"-"`
 }
 
+// Roles de usuario
+const (
+	RoleStudent = "student"
+	RoleTeacher = "teacher"
+	RoleAdmin   = "admin"
+)
+
 type UserStats struct {
 	UserID                string    `json:"user_id"`
 	Smartpoints           int       `json:"smartpoints"`
+	SeasonPoints          int       `json:"season_points"`
 	RankTier              string    `json:"rank_tier"`
 	TotalQuizzesCompleted int       `json:"total_quizzes_completed"`
 	TotalSimulatorGames   int       `json:"total_simulator_games"`
 	WinStreak             int       `json:"win_streak"`
 	TotalWins             int       `json:"total_wins"`
 	TotalLosses           int       `json:"total_losses"`
+	LoginStreak           int       `json:"login_streak"` // días seguidos con check-in diario
+	LongestLoginStreak    int       `json:"longest_login_streak"`
 	UpdatedAt             time.Time `json:"updated_at"`
 }
 
@@ -49,12 +60,26 @@ type RefreshToken struct {
 	CreatedAt time.Time `json:"created_at"`
 }
 
+// LoginAttempt registro de auditoría de un intento de login
+type LoginAttempt struct {
+	ID            string         `json:"id"`
+	UserID        sql.NullString `json:"user_id,omitempty"`
+	Email         string         `json:"email"`
+	IPAddress     string         `json:"ip_address"`
+	UserAgent     string         `json:"user_agent"`
+	DeviceID      sql.NullString `json:"device_id,omitempty"` // cookie de dispositivo
+	Success       bool           `json:"success"`
+	FailureReason sql.NullString `json:"failure_reason,omitempty"`
+	CreatedAt     time.Time      `json:"created_at"`
+}
+
 type RegisterRequest struct {
 	Username          string  `json:"username" binding:"required,min=3,max=50"`
 	Email             string  `json:"email" binding:"required,email"`
 	Password          string  `json:"password" binding:"required,min=8"`
 	SchoolID          *string `json:"school_id,omitempty"`
 	ProfilePictureURL *string `json:"profile_picture_url,omitempty"`
+	ReferralCode      *string `json:"referral_code,omitempty" binding:"omitempty,max=12"`
 }
 
 type LoginRequest struct {
@@ -99,5 +124,112 @@ type PasswordResetConfirmRequest struct {
 type UpdateProfileRequest struct {
 	Username          *string `json:"username,omitempty" binding:"omitempty,min=3,max=50"`
 	ProfilePictureURL *string `json:"profile_picture_url,omitempty"`
-	SchoolID          *string `json:"school_id,omitempty"`
+	SchoolID          *string `json:"school_id,omitempty"` // Solicita membresía; no asigna el colegio directamente
+}
+
+// === TWO-FACTOR (TOTP) ===
+
+// UserTwoFactor configuración TOTP del usuario
+type UserTwoFactor struct {
+	UserID       string       `json:"user_id"`
+	Secret       string       `json:"-"`
+	Enabled      bool         `json:"enabled"`
+	LastUsedStep int64        `json:"-"`
+	ConfirmedAt  sql.NullTime `json:"confirmed_at,omitempty"`
+	CreatedAt    time.Time    `json:"created_at"`
+}
+
+// TwoFactorEnrollResponse datos para configurar la app autenticadora
+type TwoFactorEnrollResponse struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"`
+}
+
+// TwoFactorCodeRequest código TOTP de 6 dígitos
+type TwoFactorCodeRequest struct {
+	Code string `json:"code" binding:"required,len=6,numeric"`
+}
+
+// TwoFactorConfirmResponse códigos de respaldo (se muestran una sola vez)
+type TwoFactorConfirmResponse struct {
+	Enabled       bool     `json:"enabled"`
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// TwoFactorStatusResponse estado del 2FA del usuario
+type TwoFactorStatusResponse struct {
+	Enabled                bool `json:"enabled"`
+	RemainingRecoveryCodes int  `json:"remaining_recovery_codes"`
+}
+
+// TwoFactorChallenge se devuelve en el login en lugar de LoginResponse
+type TwoFactorChallenge struct {
+	TwoFactorRequired bool   `json:"two_factor_required"`
+	ChallengeToken    string `json:"challenge_token"`
+	ExpiresIn         int    `json:"expires_in"`
+}
+
+// TwoFactorLoginRequest segundo paso del login
+type TwoFactorLoginRequest struct {
+	ChallengeToken string `json:"challenge_token" binding:"required"`
+	Code           string `json:"code,omitempty"`
+	RecoveryCode   string `json:"recovery_code,omitempty"`
+}
+
+// === OPENID CONNECT ===
+
+// UserIdentity cuenta externa (proveedor OIDC) vinculada a un usuario
+type UserIdentity struct {
+	ID          string       `json:"id"`
+	UserID      string       `json:"user_id"`
+	Provider    string       `json:"provider"`
+	Subject     string       `json:"-"`
+	Email       string       `json:"email"`
+	CreatedAt   time.Time    `json:"created_at"`
+	LastLoginAt sql.NullTime `json:"last_login_at,omitempty"`
+}
+
+// OIDCAuthorizeResponse URL a la que el frontend debe redirigir
+type OIDCAuthorizeResponse struct {
+	Provider         string `json:"provider"`
+	AuthorizationURL string `json:"authorization_url"`
+	State            string `json:"state"`
+}
+
+// OIDCCallbackRequest parámetros recibidos en el redirect del proveedor
+type OIDCCallbackRequest struct {
+	Code  string `json:"code" binding:"required"`
+	State string `json:"state" binding:"required"`
+}
+
+// === PRIVACIDAD (EXPORTACIÓN Y BAJA) ===
+
+// DataExportSection filas de una tabla incluidas en la exportación
+type DataExportSection struct {
+	Name string                   `json:"name"`
+	Rows []map[string]interface{} `json:"rows"`
+}
+
+// UserDataExport copia de los datos personales del usuario
+type UserDataExport struct {
+	UserID      string              `json:"user_id"`
+	GeneratedAt time.Time           `json:"generated_at"`
+	Sections    []DataExportSection `json:"sections"`
+}
+
+// AccountDeletionRequest solicitud de baja con período de arrepentimiento
+type AccountDeletionRequest struct {
+	UserID       string       `json:"user_id"`
+	Status       string       `json:"status"`
+	RequestedAt  time.Time    `json:"requested_at"`
+	ScheduledFor time.Time    `json:"scheduled_for"`
+	CancelledAt  sql.NullTime `json:"cancelled_at,omitempty"`
+	CompletedAt  sql.NullTime `json:"completed_at,omitempty"`
+}
+
+// DeleteAccountRequest confirmación de la baja con la contraseña actual o,
+// para cuentas sin contraseña conocida (OIDC), con el código enviado por email
+type DeleteAccountRequest struct {
+	Password         string `json:"password,omitempty"`
+	ConfirmationCode string `json:"confirmation_code,omitempty"`
 }
@@ -0,0 +1,256 @@
+package ranks
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Tabla por defecto, se usa si no se configura RANK_TIERS_PATH
+//
+//go:embed tiers.json
+var defaultTiers []byte
+
+// DefaultLocale idioma de los nombres cuando no se pide otro o no existe
+const DefaultLocale = "es"
+
+// MaxCodeLength largo máximo del código (columnas rank_tier VARCHAR(20))
+const MaxCodeLength = 20
+
+// Nombres que guardaban las versiones anteriores de los procedimientos. Los
+// "Bronze N" de update_user_rank numeraban de abajo hacia arriba, como los
+// códigos actuales; los nombres en español de calculate_rank_tier y de los
+// torneos, de arriba hacia abajo (Bronce 3 era el más bajo).
+var legacyCodes = map[string]string{
+	"Bronze 1": "bronze_1",
+	"Bronze 2": "bronze_2",
+	"Bronze 3": "bronze_3",
+	"Bronce 3": "bronze_1",
+	"Bronce 2": "bronze_2",
+	"Bronce 1": "bronze_3",
+	"Plata 3":  "silver_1",
+	"Plata 2":  "silver_2",
+	"Plata 1":  "silver_3",
+	"Oro 3":    "gold_1",
+	"Oro 2":    "gold_2",
+	"Oro 1":    "gold_3",
+	"Maestro":  "master",
+}
+
+// Definition un escalón de la tabla de rangos
+type Definition struct {
+	Code      string            `json:"code"`
+	Group     string            `json:"group"` // bronze, silver, gold, master
+	MinPoints int               `json:"min_points"`
+	Names     map[string]string `json:"names"` // nombre visible por idioma
+}
+
+// Tier rango de un jugador. Level es la posición en la tabla (1 = el más bajo)
+// y solo es comparable entre rangos de la misma tabla; 0 si el código no existe.
+type Tier struct {
+	Code  string
+	Level int
+}
+
+// Known indica si el rango existe en la tabla
+func (t Tier) Known() bool {
+	return t.Level > 0
+}
+
+// Compare devuelve -1, 0 o 1 si t es menor, igual o mayor que other
+func (t Tier) Compare(other Tier) int {
+	switch {
+	case t.Level < other.Level:
+		return -1
+	case t.Level > other.Level:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// AtLeast indica si t es igual o superior a other
+func (t Tier) AtLeast(other Tier) bool {
+	return t.Compare(other) >= 0
+}
+
+// Direction sentido de un cambio de rango
+type Direction string
+
+const (
+	Promotion Direction = "promotion"
+	Demotion  Direction = "demotion"
+)
+
+// Change ascenso o descenso entre dos rangos
+type Change struct {
+	From      Tier
+	To        Tier
+	Direction Direction
+}
+
+// DetectChange devuelve el ascenso o descenso de from a to. ok es false si no
+// cambió el rango o si alguno no existe en la tabla (ej. un nombre anterior
+// que se normaliza, que no es un ascenso real).
+func DetectChange(from, to Tier) (change Change, ok bool) {
+	if !from.Known() || !to.Known() || from.Level == to.Level {
+		return Change{}, false
+	}
+
+	change = Change{From: from, To: to, Direction: Promotion}
+	if to.Level < from.Level {
+		change.Direction = Demotion
+	}
+	return change, true
+}
+
+// Table tabla ordenada de rangos, de menor a mayor puntaje
+type Table struct {
+	definitions []Definition
+	levels      map[string]int
+	groupLevels map[string]int
+}
+
+// Load lee la tabla del archivo indicado (la embebida si path está vacío) y
+// la valida
+func Load(path string) (*Table, error) {
+	data := defaultTiers
+	if path != "" {
+		var err error
+		data, err = os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("error reading rank tiers: %w", err)
+		}
+	}
+
+	var file struct {
+		Tiers []Definition `json:"tiers"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("invalid rank tiers: %w", err)
+	}
+
+	return NewTable(file.Tiers)
+}
+
+// NewTable valida las definiciones y arma la tabla. El primer rango debe
+// empezar en 0 puntos y los umbrales deben ser estrictamente crecientes.
+func NewTable(definitions []Definition) (*Table, error) {
+	if len(definitions) == 0 {
+		return nil, errors.New("at least one rank tier is required")
+	}
+
+	t := &Table{
+		definitions: make([]Definition, len(definitions)),
+		levels:      make(map[string]int, len(definitions)),
+		groupLevels: make(map[string]int),
+	}
+	copy(t.definitions, definitions)
+
+	for i, def := range definitions {
+		if def.Code == "" || len(def.Code) > MaxCodeLength {
+			return nil, fmt.Errorf("rank tier %d: code is required (max %d characters)", i+1, MaxCodeLength)
+		}
+		if _, exists := t.levels[def.Code]; exists {
+			return nil, fmt.Errorf("rank tier %q is defined twice", def.Code)
+		}
+		if def.Group == "" || len(def.Group) > MaxCodeLength {
+			return nil, fmt.Errorf("rank tier %q: group is required (max %d characters)", def.Code, MaxCodeLength)
+		}
+		if def.Names[DefaultLocale] == "" {
+			return nil, fmt.Errorf("rank tier %q: %s name is required", def.Code, DefaultLocale)
+		}
+		if i == 0 && def.MinPoints != 0 {
+			return nil, fmt.Errorf("rank tier %q: the lowest tier must start at 0 points", def.Code)
+		}
+		if i > 0 && def.MinPoints <= definitions[i-1].MinPoints {
+			return nil, fmt.Errorf("rank tier %q: min_points must be greater than %q", def.Code, definitions[i-1].Code)
+		}
+
+		t.levels[def.Code] = i + 1
+		if _, exists := t.groupLevels[def.Group]; !exists {
+			t.groupLevels[def.Group] = len(t.groupLevels) + 1
+		} else if definitions[i-1].Group != def.Group {
+			return nil, fmt.Errorf("rank tier %q: tiers of group %q must be contiguous", def.Code, def.Group)
+		}
+	}
+
+	return t, nil
+}
+
+// Definitions copia de las definiciones, de menor a mayor
+func (t *Table) Definitions() []Definition {
+	definitions := make([]Definition, len(t.definitions))
+	copy(definitions, t.definitions)
+	return definitions
+}
+
+// Lowest rango inicial
+func (t *Table) Lowest() Tier {
+	return t.tier(0)
+}
+
+// ForPoints rango que corresponde al puntaje
+func (t *Table) ForPoints(points int) Tier {
+	i := 0
+	for i+1 < len(t.definitions) && points >= t.definitions[i+1].MinPoints {
+		i++
+	}
+	return t.tier(i)
+}
+
+// Lookup busca el rango por código. También acepta los nombres que se
+// guardaban antes de la tabla configurable ("Bronze 1", "Plata 3", ...).
+func (t *Table) Lookup(value string) (Tier, bool) {
+	value = strings.TrimSpace(value)
+	if level, ok := t.levels[value]; ok {
+		return t.tier(level - 1), true
+	}
+	if code, ok := legacyCodes[value]; ok {
+		if level, ok := t.levels[code]; ok {
+			return t.tier(level - 1), true
+		}
+	}
+	return Tier{Code: value}, false
+}
+
+// Definition definición del rango (vacía si no existe)
+func (t *Table) Definition(tier Tier) Definition {
+	if !tier.Known() || tier.Level > len(t.definitions) {
+		return Definition{}
+	}
+	return t.definitions[tier.Level-1]
+}
+
+// NextMinPoints puntaje del rango siguiente (false si es el más alto)
+func (t *Table) NextMinPoints(tier Tier) (int, bool) {
+	if !tier.Known() || tier.Level >= len(t.definitions) {
+		return 0, false
+	}
+	return t.definitions[tier.Level].MinPoints, true
+}
+
+// GroupLevel posición del grupo del rango (1 = el grupo más bajo)
+func (t *Table) GroupLevel(tier Tier) int {
+	return t.groupLevels[t.Definition(tier).Group]
+}
+
+// Name nombre visible del rango en el idioma pedido, con el idioma por
+// defecto como respaldo. Los rangos desconocidos muestran su código.
+func (t *Table) Name(tier Tier, locale string) string {
+	def := t.Definition(tier)
+	if name := def.Names[locale]; name != "" {
+		return name
+	}
+	if name := def.Names[DefaultLocale]; name != "" {
+		return name
+	}
+	return tier.Code
+}
+
+func (t *Table) tier(i int) Tier {
+	return Tier{Code: t.definitions[i].Code, Level: i + 1}
+}
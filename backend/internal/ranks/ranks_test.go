@@ -0,0 +1,137 @@
+package ranks
+
+import "testing"
+
+func TestDefaultTable(t *testing.T) {
+	table, err := Load("")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	tests := []struct {
+		points int
+		want   string
+	}{
+		{0, "bronze_1"},
+		{399, "bronze_1"},
+		{400, "bronze_2"},
+		{3199, "bronze_3"},
+		{3200, "silver_1"},
+		{8400, "gold_1"},
+		{14399, "gold_3"},
+		{14400, "master"},
+		{1000000, "master"},
+	}
+	for _, tt := range tests {
+		if got := table.ForPoints(tt.points); got.Code != tt.want {
+			t.Errorf("ForPoints(%d) = %s, want %s", tt.points, got.Code, tt.want)
+		}
+	}
+
+	if level := table.GroupLevel(table.ForPoints(5000)); level != 2 {
+		t.Errorf("GroupLevel(silver) = %d, want 2", level)
+	}
+	if next, ok := table.NextMinPoints(table.Lowest()); !ok || next != 400 {
+		t.Errorf("NextMinPoints(lowest) = %d, %v, want 400, true", next, ok)
+	}
+	if _, ok := table.NextMinPoints(table.ForPoints(20000)); ok {
+		t.Error("NextMinPoints(master) should not exist")
+	}
+}
+
+func TestLookupAcceptsLegacyNames(t *testing.T) {
+	table, err := Load("")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	tests := []struct {
+		value string
+		want  string
+		ok    bool
+	}{
+		{"silver_2", "silver_2", true},
+		{"Bronze 1", "bronze_1", true},
+		{"Bronce 1", "bronze_3", true},
+		{" Plata 3 ", "silver_1", true},
+		{"Maestro", "master", true},
+		{"Diamante", "Diamante", false},
+	}
+	for _, tt := range tests {
+		got, ok := table.Lookup(tt.value)
+		if got.Code != tt.want || ok != tt.ok {
+			t.Errorf("Lookup(%q) = %s, %v, want %s, %v", tt.value, got.Code, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestTierComparison(t *testing.T) {
+	table, err := Load("")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	bronze := table.ForPoints(0)
+	gold := table.ForPoints(9000)
+	if !gold.AtLeast(bronze) || bronze.AtLeast(gold) || !gold.AtLeast(gold) {
+		t.Error("AtLeast does not follow the table order")
+	}
+	if bronze.Compare(gold) != -1 || gold.Compare(bronze) != 1 || gold.Compare(gold) != 0 {
+		t.Error("Compare does not follow the table order")
+	}
+
+	change, ok := DetectChange(bronze, gold)
+	if !ok || change.Direction != Promotion {
+		t.Errorf("DetectChange(bronze, gold) = %+v, %v, want promotion", change, ok)
+	}
+	change, ok = DetectChange(gold, bronze)
+	if !ok || change.Direction != Demotion {
+		t.Errorf("DetectChange(gold, bronze) = %+v, %v, want demotion", change, ok)
+	}
+	if _, ok := DetectChange(gold, gold); ok {
+		t.Error("DetectChange should ignore unchanged tiers")
+	}
+	if _, ok := DetectChange(Tier{Code: "Diamante"}, gold); ok {
+		t.Error("DetectChange should ignore unknown tiers")
+	}
+}
+
+func TestName(t *testing.T) {
+	table, err := Load("")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	master := table.ForPoints(20000)
+	if got := table.Name(master, "en"); got != "Master" {
+		t.Errorf("Name(master, en) = %q", got)
+	}
+	if got := table.Name(master, "pt"); got != "Maestro" {
+		t.Errorf("Name(master, pt) = %q, want default locale", got)
+	}
+	if got := table.Name(Tier{Code: "Diamante"}, "es"); got != "Diamante" {
+		t.Errorf("Name(unknown) = %q, want code", got)
+	}
+}
+
+func TestNewTableRejectsInvalid(t *testing.T) {
+	es := map[string]string{"es": "x"}
+	tests := map[string][]Definition{
+		"empty":          nil,
+		"missing code":   {{Group: "a", MinPoints: 0, Names: es}},
+		"not from zero":  {{Code: "a", Group: "a", MinPoints: 10, Names: es}},
+		"missing name":   {{Code: "a", Group: "a", MinPoints: 0}},
+		"duplicate code": {{Code: "a", Group: "a", MinPoints: 0, Names: es}, {Code: "a", Group: "a", MinPoints: 10, Names: es}},
+		"not increasing": {{Code: "a", Group: "a", MinPoints: 0, Names: es}, {Code: "b", Group: "a", MinPoints: 0, Names: es}},
+		"split group": {
+			{Code: "a", Group: "a", MinPoints: 0, Names: es},
+			{Code: "b", Group: "b", MinPoints: 10, Names: es},
+			{Code: "c", Group: "a", MinPoints: 20, Names: es},
+		},
+	}
+	for name, definitions := range tests {
+		if _, err := NewTable(definitions); err == nil {
+			t.Errorf("%s: NewTable() should fail", name)
+		}
+	}
+}
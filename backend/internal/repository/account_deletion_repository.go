@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/smartstocks/backend/internal/models"
+)
+
+type AccountDeletionRepository struct {
+	db *sql.DB
+}
+
+func NewAccountDeletionRepository(db *sql.DB) *AccountDeletionRepository {
+	return &AccountDeletionRepository{db: db}
+}
+
+// GetByUserID obtiene la última solicitud de baja (nil si no existe)
+func (r *AccountDeletionRepository) GetByUserID(userID string) (*models.AccountDeletionRequest, error) {
+	req := &models.AccountDeletionRequest{}
+	query := `
+		SELECT user_id, status, requested_at, scheduled_for, cancelled_at, completed_at
+		FROM account_deletion_requests WHERE user_id = ?
+	`
+
+	err := r.db.QueryRow(query, userID).Scan(
+		&req.UserID,
+		&req.Status,
+		&req.RequestedAt,
+		&req.ScheduledFor,
+		&req.CancelledAt,
+		&req.CompletedAt,
+	)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+
+	return req, err
+}
+
+// Schedule registra (o reabre) una solicitud de baja para la fecha indicada
+func (r *AccountDeletionRepository) Schedule(userID string, scheduledFor time.Time) error {
+	query := `
+		INSERT INTO account_deletion_requests (user_id, status, requested_at, scheduled_for)
+		VALUES (?, 'pending', NOW(), ?)
+		ON DUPLICATE KEY UPDATE
+			status = 'pending',
+			requested_at = NOW(),
+			scheduled_for = VALUES(scheduled_for),
+			cancelled_at = NULL,
+			completed_at = NULL
+	`
+	_, err := r.db.Exec(query, userID, scheduledFor)
+	return err
+}
+
+// Cancel cancela una solicitud pendiente. Devuelve false si no había ninguna.
+func (r *AccountDeletionRepository) Cancel(userID string) (bool, error) {
+	query := `
+		UPDATE account_deletion_requests
+		SET status = 'cancelled', cancelled_at = NOW()
+		WHERE user_id = ? AND status = 'pending'
+	`
+	result, err := r.db.Exec(query, userID)
+	if err != nil {
+		return false, err
+	}
+
+	rows, err := result.RowsAffected()
+	return rows > 0, err
+}
@@ -0,0 +1,172 @@
+package repository
+
+import (
+	"database/sql"
+
+	"github.com/smartstocks/backend/internal/models"
+)
+
+type AccountLinkRepository struct {
+	db *sql.DB
+}
+
+func NewAccountLinkRepository(db *sql.DB) *AccountLinkRepository {
+	return &AccountLinkRepository{db: db}
+}
+
+// accountLinkPair ordena el par como se guarda (user_a_id < user_b_id)
+func accountLinkPair(userID, otherID string) (string, string) {
+	if userID < otherID {
+		return userID, otherID
+	}
+	return otherID, userID
+}
+
+const accountLinkColumns = `
+	l.id, l.user_a_id, ua.username, l.user_b_id, ub.username, l.reason, l.status,
+	l.notes, l.created_by, l.reviewed_by, l.created_at, l.updated_at`
+
+const accountLinkFrom = `
+	FROM account_links l
+	JOIN users ua ON ua.id = l.user_a_id
+	JOIN users ub ON ub.id = l.user_b_id`
+
+// GetLink obtiene el vínculo entre dos cuentas (nil si no hay)
+func (r *AccountLinkRepository) GetLink(userID, otherID string) (*models.AccountLink, error) {
+	userA, userB := accountLinkPair(userID, otherID)
+	link, err := scanAccountLink(r.db.QueryRow(`
+		SELECT `+accountLinkColumns+accountLinkFrom+`
+		WHERE l.user_a_id = ? AND l.user_b_id = ?
+	`, userA, userB))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return link, err
+}
+
+// GetLinkByID obtiene un vínculo (nil si no existe)
+func (r *AccountLinkRepository) GetLinkByID(linkID string) (*models.AccountLink, error) {
+	link, err := scanAccountLink(r.db.QueryRow(`
+		SELECT `+accountLinkColumns+accountLinkFrom+`
+		WHERE l.id = ?
+	`, linkID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return link, err
+}
+
+// HasSharedDevice indica si las dos cuentas tuvieron logins exitosos desde
+// el mismo dispositivo (la cookie de dispositivo) en los últimos windowDays
+// días. La IP no alcanza: en un colegio todos los alumnos salen por la misma.
+func (r *AccountLinkRepository) HasSharedDevice(userID, otherID string, windowDays int) (bool, error) {
+	var shared bool
+	err := r.db.QueryRow(`
+		SELECT EXISTS(
+			SELECT 1
+			FROM login_attempts a
+			JOIN login_attempts b ON b.device_id = a.device_id
+			WHERE a.user_id = ? AND a.success = TRUE AND a.device_id IS NOT NULL
+			  AND a.created_at >= DATE_SUB(NOW(), INTERVAL ? DAY)
+			  AND b.user_id = ? AND b.success = TRUE
+			  AND b.created_at >= DATE_SUB(NOW(), INTERVAL ? DAY)
+		)
+	`, userID, windowDays, otherID, windowDays).Scan(&shared)
+	return shared, err
+}
+
+// CreateLink marca dos cuentas como vinculadas. Devuelve false si el par ya
+// tenía un vínculo (confirmado o descartado), que no se modifica.
+func (r *AccountLinkRepository) CreateLink(link *models.AccountLink) (bool, error) {
+	link.UserAID, link.UserBID = accountLinkPair(link.UserAID, link.UserBID)
+
+	result, err := r.db.Exec(`
+		INSERT IGNORE INTO account_links (id, user_a_id, user_b_id, reason, status, notes, created_by)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`, link.ID, link.UserAID, link.UserBID, link.Reason, link.Status, link.Notes, link.CreatedBy)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
+
+// UpdateStatus confirma o descarta un vínculo
+func (r *AccountLinkRepository) UpdateStatus(linkID, status string, notes *string, reviewerID string) error {
+	_, err := r.db.Exec(`
+		UPDATE account_links
+		SET status = ?, notes = COALESCE(?, notes), reviewed_by = ?
+		WHERE id = ?
+	`, status, notes, reviewerID, linkID)
+	return err
+}
+
+// GetLinks lista los vínculos, opcionalmente filtrados por estado
+func (r *AccountLinkRepository) GetLinks(status string, limit int) ([]models.AccountLink, error) {
+	if limit <= 0 || limit > 200 {
+		limit = 50
+	}
+
+	query := `SELECT ` + accountLinkColumns + accountLinkFrom
+	args := []interface{}{}
+	if status != "" {
+		query += ` WHERE l.status = ?`
+		args = append(args, status)
+	}
+	query += ` ORDER BY l.created_at DESC LIMIT ?`
+	args = append(args, limit)
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	links := []models.AccountLink{}
+	for rows.Next() {
+		link, err := scanAccountLink(rows)
+		if err != nil {
+			return nil, err
+		}
+		links = append(links, *link)
+	}
+
+	return links, rows.Err()
+}
+
+func scanAccountLink(row rowScanner) (*models.AccountLink, error) {
+	var link models.AccountLink
+	var notes, createdBy, reviewedBy sql.NullString
+
+	if err := row.Scan(
+		&link.ID,
+		&link.UserAID,
+		&link.UserAUsername,
+		&link.UserBID,
+		&link.UserBUsername,
+		&link.Reason,
+		&link.Status,
+		&notes,
+		&createdBy,
+		&reviewedBy,
+		&link.CreatedAt,
+		&link.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+
+	if notes.Valid {
+		link.Notes = &notes.String
+	}
+	if createdBy.Valid {
+		link.CreatedBy = &createdBy.String
+	}
+	if reviewedBy.Valid {
+		link.ReviewedBy = &reviewedBy.String
+	}
+	return &link, nil
+}
@@ -0,0 +1,238 @@
+package repository
+
+import (
+	"database/sql"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/smartstocks/backend/internal/models"
+)
+
+type AchievementRepository struct {
+	db *sql.DB
+}
+
+func NewAchievementRepository(db *sql.DB) *AchievementRepository {
+	return &AchievementRepository{db: db}
+}
+
+// GetAchievementMetrics calcula las métricas de logros del usuario desde su
+// historial (nil si el usuario no tiene stats). Las métricas sin datos (ej.
+// ninguna decisión correcta con tiempo registrado) no figuran en el mapa.
+// Los intentos extra no cuentan.
+func (r *AchievementRepository) GetAchievementMetrics(userID string) (map[string]int, error) {
+	query := `
+		SELECT
+			us.smartpoints,
+			COALESCE((SELECT rt.group_level FROM rank_tiers rt WHERE rt.code = us.rank_tier), 1),
+			us.total_wins,
+			us.win_streak,
+			us.total_quizzes_completed,
+			us.total_simulator_games,
+			(SELECT COUNT(*) FROM simulator_attempts sa
+			 WHERE sa.user_id = us.user_id AND sa.was_correct = TRUE AND sa.extra_attempt_id = ''),
+			(SELECT MAX(qa.score) FROM quiz_attempts qa
+			 WHERE qa.user_id = us.user_id AND qa.extra_attempt_id = ''),
+			(SELECT MIN(sa.time_taken_seconds) FROM simulator_attempts sa
+			 WHERE sa.user_id = us.user_id AND sa.was_correct = TRUE AND sa.time_taken_seconds IS NOT NULL
+			   AND sa.extra_attempt_id = ''),
+			(SELECT COUNT(*) FROM user_lesson_progress ulp
+			 WHERE ulp.user_id = us.user_id AND ulp.is_completed = TRUE),
+			(SELECT COUNT(*) FROM user_course_progress ucp
+			 WHERE ucp.user_id = us.user_id AND ucp.is_completed = TRUE),
+			(SELECT COUNT(*) FROM forum_posts fp WHERE fp.user_id = us.user_id),
+			(SELECT COUNT(*) FROM forum_replies fr WHERE fr.user_id = us.user_id),
+			(SELECT MAX(d.deficit) FROM (
+				SELECT SUM(CASE
+						WHEN m.winner_id = m.player1_id THEN pr.player2_points - pr.player1_points
+						ELSE pr.player1_points - pr.player2_points
+					END) OVER (PARTITION BY m.id ORDER BY pr.round_number) AS deficit
+				FROM pvp_matches m
+				JOIN pvp_rounds pr ON pr.match_id = m.id
+				WHERE m.winner_id = ? AND m.status = 'completed'
+			) d)
+		FROM user_stats us
+		WHERE us.user_id = ?
+	`
+
+	var smartpoints, rankLevel, wins, streak, quizzes, simulatorGames, simulatorCorrect int
+	var lessons, courses, posts, replies int
+	var bestQuizScore, fastestDecision, bestComeback sql.NullInt64
+	err := r.db.QueryRow(query, userID, userID).Scan(
+		&smartpoints,
+		&rankLevel,
+		&wins,
+		&streak,
+		&quizzes,
+		&simulatorGames,
+		&simulatorCorrect,
+		&bestQuizScore,
+		&fastestDecision,
+		&lessons,
+		&courses,
+		&posts,
+		&replies,
+		&bestComeback,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	metrics := map[string]int{
+		models.AchievementMetricSmartpoints:      smartpoints,
+		models.AchievementMetricRankLevel:        rankLevel,
+		models.AchievementMetricPvPWins:          wins,
+		models.AchievementMetricWinStreak:        streak,
+		models.AchievementMetricQuizzesCompleted: quizzes,
+		models.AchievementMetricSimulatorGames:   simulatorGames,
+		models.AchievementMetricSimulatorCorrect: simulatorCorrect,
+		models.AchievementMetricLessonsCompleted: lessons,
+		models.AchievementMetricCoursesCompleted: courses,
+		models.AchievementMetricForumPosts:       posts,
+		models.AchievementMetricForumReplies:     replies,
+	}
+	if bestQuizScore.Valid {
+		metrics[models.AchievementMetricBestQuizScore] = int(bestQuizScore.Int64)
+	}
+	if fastestDecision.Valid {
+		metrics[models.AchievementMetricFastestCorrectDecision] = int(fastestDecision.Int64)
+	}
+	if bestComeback.Valid {
+		metrics[models.AchievementMetricBestComeback] = int(bestComeback.Int64)
+	}
+
+	return metrics, nil
+}
+
+// GetUnlockedAchievementTypes obtiene los tipos de logro que el usuario ya desbloqueó
+func (r *AchievementRepository) GetUnlockedAchievementTypes(userID string) (map[string]bool, error) {
+	rows, err := r.db.Query(`SELECT achievement_type FROM user_achievements WHERE user_id = ?`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	unlocked := make(map[string]bool)
+	for rows.Next() {
+		var achievementType string
+		if err := rows.Scan(&achievementType); err != nil {
+			return nil, err
+		}
+		unlocked[achievementType] = true
+	}
+
+	return unlocked, rows.Err()
+}
+
+// GetAchievementProgress obtiene el mejor valor registrado por logro
+func (r *AchievementRepository) GetAchievementProgress(userID string) (map[string]int, error) {
+	rows, err := r.db.Query(`
+		SELECT achievement_type, best_value
+		FROM user_achievement_progress
+		WHERE user_id = ?
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	progress := make(map[string]int)
+	for rows.Next() {
+		var achievementType string
+		var best int
+		if err := rows.Scan(&achievementType, &best); err != nil {
+			return nil, err
+		}
+		progress[achievementType] = best
+	}
+
+	return progress, rows.Err()
+}
+
+// SaveAchievementProgress guarda el mejor valor de cada logro indicado
+func (r *AchievementRepository) SaveAchievementProgress(userID string, progress map[string]int) error {
+	if len(progress) == 0 {
+		return nil
+	}
+
+	args := make([]interface{}, 0, len(progress)*3)
+	for achievementType, best := range progress {
+		args = append(args, userID, achievementType, best)
+	}
+
+	query := `
+		INSERT INTO user_achievement_progress (user_id, achievement_type, best_value)
+		VALUES (?, ?, ?)` + strings.Repeat(", (?, ?, ?)", len(progress)-1) + `
+		ON DUPLICATE KEY UPDATE best_value = VALUES(best_value)
+	`
+	_, err := r.db.Exec(query, args...)
+	return err
+}
+
+// UnlockAchievement registra el logro si el usuario todavía no lo tenía y
+// ejecuta apply (la recompensa) en la misma transacción: si apply falla el
+// logro tampoco queda desbloqueado y se reintenta en la próxima evaluación.
+// Devuelve el id del logro y false si ya estaba desbloqueado.
+func (r *AchievementRepository) UnlockAchievement(userID string, rule *models.AchievementRule, apply func(tx *sql.Tx, achievementID string) error) (string, bool, error) {
+	id := uuid.New().String()
+
+	var iconURL sql.NullString
+	if rule.IconURL != "" {
+		iconURL = sql.NullString{String: rule.IconURL, Valid: true}
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return "", false, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`
+		INSERT IGNORE INTO user_achievements (
+			id, user_id, achievement_type, achievement_name, achievement_description, icon_url
+		) VALUES (?, ?, ?, ?, ?, ?)
+	`, id, userID, rule.Type, rule.Name, rule.Description, iconURL)
+	if err != nil {
+		return "", false, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return "", false, err
+	}
+	if affected == 0 {
+		return id, false, nil
+	}
+
+	if apply != nil {
+		if err := apply(tx, id); err != nil {
+			return "", false, err
+		}
+	}
+
+	return id, true, tx.Commit()
+}
+
+// GetUserIDsAfter obtiene hasta limit ids de usuario mayores a afterID, en
+// orden, para recorrer todos los usuarios por páginas
+func (r *AchievementRepository) GetUserIDsAfter(afterID string, limit int) ([]string, error) {
+	rows, err := r.db.Query(`SELECT id FROM users WHERE id > ? ORDER BY id LIMIT ?`, afterID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
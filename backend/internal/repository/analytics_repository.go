@@ -0,0 +1,221 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/smartstocks/backend/internal/models"
+)
+
+// AnalyticsRepository consultas de los reportes exportables. Las filas se
+// entregan de a una a un callback para poder escribirlas en la respuesta
+// sin cargar el reporte completo en memoria.
+type AnalyticsRepository struct {
+	db *sql.DB
+}
+
+func NewAnalyticsRepository(db *sql.DB) *AnalyticsRepository {
+	return &AnalyticsRepository{db: db}
+}
+
+// StreamQuizHistory intentos de quiz de los alumnos del alcance en el período
+func (r *AnalyticsRepository) StreamQuizHistory(ctx context.Context, scope models.AnalyticsScope, period models.ProgressRange, fn func(*models.QuizHistoryRow) error) error {
+	filter, scopeArgs, err := scopeFilter(scope)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		SELECT u.id, u.username, qa.quiz_id, q.title, qa.difficulty, qa.score,
+			   qa.correct_answers, qa.total_questions, qa.points_earned,
+			   qa.time_taken_seconds, qa.completed_at
+		FROM quiz_attempts qa
+		JOIN users u ON qa.user_id = u.id
+		JOIN quizzes q ON qa.quiz_id = q.id
+		WHERE qa.completed_at >= ? AND qa.completed_at < ? AND ` + filter + `
+		ORDER BY u.username, qa.completed_at
+	`
+	args := append([]interface{}{period.From, period.To}, scopeArgs...)
+
+	return r.stream(ctx, query, args, func(rows *sql.Rows) error {
+		var row models.QuizHistoryRow
+		if err := rows.Scan(
+			&row.UserID, &row.Username, &row.QuizID, &row.QuizTitle, &row.Difficulty, &row.Score,
+			&row.CorrectAnswers, &row.TotalQuestions, &row.PointsEarned,
+			&row.TimeTakenSeconds, &row.CompletedAt,
+		); err != nil {
+			return err
+		}
+		return fn(&row)
+	})
+}
+
+// StreamSimulatorStats estadísticas del simulador por alumno en el período.
+// Incluye a los alumnos sin intentos para que el reporte muestre a todos.
+func (r *AnalyticsRepository) StreamSimulatorStats(ctx context.Context, scope models.AnalyticsScope, period models.ProgressRange, fn func(*models.SimulatorStatsRow) error) error {
+	filter, scopeArgs, err := scopeFilter(scope)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		SELECT u.id, u.username, sa.difficulty, COUNT(sa.id),
+			   COALESCE(SUM(sa.was_correct), 0), COALESCE(SUM(sa.points_earned), 0)
+		FROM users u
+		LEFT JOIN simulator_attempts sa ON sa.user_id = u.id
+			AND sa.created_at >= ? AND sa.created_at < ?
+		WHERE ` + filter + `
+		GROUP BY u.id, u.username, sa.difficulty
+		ORDER BY u.username, u.id
+	`
+	args := append([]interface{}{period.From, period.To}, scopeArgs...)
+
+	// Las filas llegan agrupadas por alumno: se acumulan hasta que cambia
+	var current *models.SimulatorStatsRow
+	emit := func() error {
+		if current == nil {
+			return nil
+		}
+		stats := &current.Stats
+		if stats.TotalAttempts > 0 {
+			stats.AccuracyRate = float64(stats.CorrectAttempts) / float64(stats.TotalAttempts) * 100
+		}
+		return fn(current)
+	}
+
+	err = r.stream(ctx, query, args, func(rows *sql.Rows) error {
+		var userID, username string
+		var difficulty sql.NullString
+		var d models.SimulatorDifficultyStats
+		if err := rows.Scan(&userID, &username, &difficulty, &d.Attempts, &d.Correct, &d.PointsEarned); err != nil {
+			return err
+		}
+
+		if current == nil || current.UserID != userID {
+			if err := emit(); err != nil {
+				return err
+			}
+			current = &models.SimulatorStatsRow{
+				UserID:   userID,
+				Username: username,
+				Stats:    models.SimulatorStats{ByDifficulty: map[string]models.SimulatorDifficultyStats{}},
+			}
+		}
+
+		if !difficulty.Valid {
+			return nil
+		}
+		if d.Attempts > 0 {
+			d.AccuracyRate = float64(d.Correct) / float64(d.Attempts) * 100
+		}
+		current.Stats.ByDifficulty[difficulty.String] = d
+		current.Stats.TotalAttempts += d.Attempts
+		current.Stats.CorrectAttempts += d.Correct
+		current.Stats.TotalPoints += d.PointsEarned
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return emit()
+}
+
+// StreamCourseProgress avance actual de los alumnos en los cursos que empezaron
+func (r *AnalyticsRepository) StreamCourseProgress(ctx context.Context, scope models.AnalyticsScope, fn func(*models.CourseProgressRow) error) error {
+	filter, scopeArgs, err := scopeFilter(scope)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		SELECT u.id, u.username, c.id, c.title,
+			   (SELECT COUNT(*) FROM lessons l WHERE l.course_id = c.id AND l.is_active = TRUE),
+			   (SELECT COUNT(*) FROM user_lesson_progress ulp
+			    JOIN lessons l ON ulp.lesson_id = l.id
+			    WHERE l.course_id = c.id AND ulp.user_id = u.id AND ulp.is_completed = TRUE),
+			   ucp.is_completed, ucp.started_at, ucp.completed_at
+		FROM user_course_progress ucp
+		JOIN users u ON ucp.user_id = u.id
+		JOIN courses c ON ucp.course_id = c.id
+		WHERE ` + filter + `
+		ORDER BY u.username, c.order_index
+	`
+
+	return r.stream(ctx, query, scopeArgs, func(rows *sql.Rows) error {
+		var row models.CourseProgressRow
+		if err := rows.Scan(
+			&row.UserID, &row.Username, &row.CourseID, &row.CourseTitle,
+			&row.TotalLessons, &row.CompletedLessons,
+			&row.IsCompleted, &row.StartedAt, &row.CompletedAt,
+		); err != nil {
+			return err
+		}
+		return fn(&row)
+	})
+}
+
+// StreamPvPRecords partidas PvP terminadas de cada alumno en el período
+func (r *AnalyticsRepository) StreamPvPRecords(ctx context.Context, scope models.AnalyticsScope, period models.ProgressRange, fn func(*models.PvPRecordRow) error) error {
+	filter, scopeArgs, err := scopeFilter(scope)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		SELECT u.id, u.username, COUNT(pm.id),
+			   COALESCE(SUM(pm.winner_id = u.id), 0),
+			   COALESCE(SUM(pm.id IS NOT NULL AND pm.winner_id IS NULL), 0)
+		FROM users u
+		LEFT JOIN pvp_matches pm ON (pm.player1_id = u.id OR pm.player2_id = u.id)
+			AND pm.status = 'completed'
+			AND pm.completed_at >= ? AND pm.completed_at < ?
+		WHERE ` + filter + `
+		GROUP BY u.id, u.username
+		ORDER BY u.username
+	`
+	args := append([]interface{}{period.From, period.To}, scopeArgs...)
+
+	return r.stream(ctx, query, args, func(rows *sql.Rows) error {
+		var row models.PvPRecordRow
+		if err := rows.Scan(&row.UserID, &row.Username, &row.Matches, &row.Wins, &row.Draws); err != nil {
+			return err
+		}
+		row.Losses = row.Matches - row.Wins - row.Draws
+		row.WinRate = percentage(row.Wins, row.Matches)
+		return fn(&row)
+	})
+}
+
+// === HELPERS ===
+
+// stream ejecuta la consulta y llama a scan por cada fila. Se cancela si
+// el cliente corta la descarga.
+func (r *AnalyticsRepository) stream(ctx context.Context, query string, args []interface{}, scan func(*sql.Rows) error) error {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		if err := scan(rows); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+// scopeFilter condición sobre u.id que limita el reporte a los alumnos del alcance
+func scopeFilter(scope models.AnalyticsScope) (string, []interface{}, error) {
+	switch scope.Type {
+	case models.AnalyticsScopeClassroom:
+		return "u.id IN (SELECT user_id FROM classroom_members WHERE classroom_id = ?)", []interface{}{scope.ID}, nil
+	case models.AnalyticsScopeSchool:
+		return "u.school_id = ?", []interface{}{scope.ID}, nil
+	default:
+		return "", nil, fmt.Errorf("invalid analytics scope: %q", scope.Type)
+	}
+}
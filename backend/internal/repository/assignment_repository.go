@@ -0,0 +1,310 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/smartstocks/backend/internal/models"
+)
+
+type AssignmentRepository struct {
+	db *sql.DB
+}
+
+func NewAssignmentRepository(db *sql.DB) *AssignmentRepository {
+	return &AssignmentRepository{db: db}
+}
+
+// assignmentColumns columnas comunes de las consultas de tareas (alias a = assignments, c = classrooms)
+const assignmentColumns = `
+	a.id, a.classroom_id, a.teacher_id, a.title, COALESCE(a.instructions, ''), a.assignment_type,
+	a.target_id, a.due_at, a.allow_late, a.created_at, a.updated_at, c.name,
+	(SELECT COUNT(*) FROM assignment_submissions s WHERE s.assignment_id = a.id),
+	(SELECT COUNT(*) FROM classroom_members cm WHERE cm.classroom_id = a.classroom_id)
+`
+
+// === TAREAS ===
+
+// CreateAssignment crea una tarea
+func (r *AssignmentRepository) CreateAssignment(assignment *models.Assignment) error {
+	assignment.ID = uuid.New().String()
+	assignment.CreatedAt = time.Now()
+	assignment.UpdatedAt = time.Now()
+
+	query := `
+		INSERT INTO assignments (id, classroom_id, teacher_id, title, instructions,
+								 assignment_type, target_id, due_at, allow_late)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+
+	_, err := r.db.Exec(query,
+		assignment.ID,
+		assignment.ClassroomID,
+		assignment.TeacherID,
+		assignment.Title,
+		assignment.Instructions,
+		assignment.AssignmentType,
+		assignment.TargetID,
+		assignment.DueAt,
+		assignment.AllowLate,
+	)
+
+	return err
+}
+
+// GetAssignmentByID obtiene una tarea
+func (r *AssignmentRepository) GetAssignmentByID(assignmentID string) (*models.Assignment, error) {
+	query := `
+		SELECT ` + assignmentColumns + `
+		FROM assignments a
+		JOIN classrooms c ON a.classroom_id = c.id
+		WHERE a.id = ?
+	`
+
+	assignment, err := scanAssignment(r.db.QueryRow(query, assignmentID))
+	if err == sql.ErrNoRows {
+		return nil, errors.New("assignment not found")
+	}
+
+	return assignment, err
+}
+
+// GetClassroomAssignments lista las tareas de un aula ordenadas por vencimiento
+func (r *AssignmentRepository) GetClassroomAssignments(classroomID string) ([]models.Assignment, error) {
+	query := `
+		SELECT ` + assignmentColumns + `
+		FROM assignments a
+		JOIN classrooms c ON a.classroom_id = c.id
+		WHERE a.classroom_id = ?
+		ORDER BY a.due_at, a.created_at
+	`
+
+	rows, err := r.db.Query(query, classroomID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	assignments := []models.Assignment{}
+	for rows.Next() {
+		assignment, err := scanAssignment(rows)
+		if err != nil {
+			return nil, err
+		}
+		assignments = append(assignments, *assignment)
+	}
+
+	return assignments, rows.Err()
+}
+
+// UpdateAssignment actualiza los datos editables de una tarea
+func (r *AssignmentRepository) UpdateAssignment(assignmentID string, req *models.UpdateAssignmentRequest) error {
+	query := `
+		UPDATE assignments
+		SET title = COALESCE(?, title),
+			instructions = COALESCE(?, instructions),
+			due_at = COALESCE(?, due_at),
+			allow_late = COALESCE(?, allow_late)
+		WHERE id = ?
+	`
+	_, err := r.db.Exec(query, req.Title, req.Instructions, req.DueAt, req.AllowLate, assignmentID)
+	return err
+}
+
+// DeleteAssignment elimina una tarea y sus entregas
+func (r *AssignmentRepository) DeleteAssignment(assignmentID string) error {
+	_, err := r.db.Exec(`DELETE FROM assignments WHERE id = ?`, assignmentID)
+	return err
+}
+
+// === ALUMNO ===
+
+// GetStudentAssignments lista las tareas de las aulas activas del alumno con su entrega
+func (r *AssignmentRepository) GetStudentAssignments(userID string) ([]models.MyAssignment, error) {
+	return r.queryMyAssignments(``, userID)
+}
+
+// GetStudentAssignment obtiene una tarea del alumno (nil si no pertenece a sus aulas)
+func (r *AssignmentRepository) GetStudentAssignment(userID, assignmentID string) (*models.MyAssignment, error) {
+	assignments, err := r.queryMyAssignments(`AND a.id = ?`, userID, assignmentID)
+	if err != nil || len(assignments) == 0 {
+		return nil, err
+	}
+	return &assignments[0], nil
+}
+
+// GetOpenAssignments tareas abiertas del alumno para una actividad: en aulas activas
+// y sin vencer, o vencidas pero que aceptan entregas tardías
+func (r *AssignmentRepository) GetOpenAssignments(userID, assignmentType, targetID string) ([]models.Assignment, error) {
+	query := `
+		SELECT ` + assignmentColumns + `
+		FROM classroom_members m
+		JOIN classrooms c ON m.classroom_id = c.id
+		JOIN assignments a ON a.classroom_id = c.id
+		WHERE m.user_id = ? AND c.is_active = TRUE
+		  AND a.assignment_type = ? AND a.target_id = ?
+		  AND (a.due_at >= NOW() OR a.allow_late = TRUE)
+	`
+
+	rows, err := r.db.Query(query, userID, assignmentType, targetID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	assignments := []models.Assignment{}
+	for rows.Next() {
+		assignment, err := scanAssignment(rows)
+		if err != nil {
+			return nil, err
+		}
+		assignments = append(assignments, *assignment)
+	}
+
+	return assignments, rows.Err()
+}
+
+// HasPendingAssignment indica si el alumno tiene una tarea abierta y aún sin
+// entregar para la actividad
+func (r *AssignmentRepository) HasPendingAssignment(userID, assignmentType, targetID string) (bool, error) {
+	var exists bool
+	query := `
+		SELECT EXISTS(
+			SELECT 1
+			FROM classroom_members m
+			JOIN classrooms c ON m.classroom_id = c.id
+			JOIN assignments a ON a.classroom_id = c.id
+			LEFT JOIN assignment_submissions sub ON sub.assignment_id = a.id AND sub.user_id = m.user_id
+			WHERE m.user_id = ? AND c.is_active = TRUE
+			  AND a.assignment_type = ? AND a.target_id = ?
+			  AND (a.due_at >= NOW() OR a.allow_late = TRUE)
+			  AND sub.id IS NULL
+		)
+	`
+	err := r.db.QueryRow(query, userID, assignmentType, targetID).Scan(&exists)
+	return exists, err
+}
+
+// === ENTREGAS ===
+
+// CreateSubmission registra la entrega de un alumno. Solo cuenta la primera:
+// si ya había entregado, los intentos posteriores no cambian la nota.
+func (r *AssignmentRepository) CreateSubmission(submission *models.AssignmentSubmission) error {
+	query := `
+		INSERT IGNORE INTO assignment_submissions (id, assignment_id, user_id, status, score, submitted_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`
+
+	_, err := r.db.Exec(query,
+		uuid.New().String(),
+		submission.AssignmentID,
+		submission.UserID,
+		submission.Status,
+		submission.Score,
+		submission.SubmittedAt,
+	)
+
+	return err
+}
+
+// GetClassroomSubmissions obtiene todas las entregas de las tareas de un aula
+func (r *AssignmentRepository) GetClassroomSubmissions(classroomID string) ([]models.AssignmentSubmission, error) {
+	query := `
+		SELECT s.assignment_id, s.user_id, s.status, s.score, s.submitted_at
+		FROM assignment_submissions s
+		JOIN assignments a ON s.assignment_id = a.id
+		WHERE a.classroom_id = ?
+	`
+
+	return r.querySubmissions(query, classroomID)
+}
+
+// GetAssignmentSubmissions obtiene las entregas de una tarea
+func (r *AssignmentRepository) GetAssignmentSubmissions(assignmentID string) ([]models.AssignmentSubmission, error) {
+	query := `
+		SELECT assignment_id, user_id, status, score, submitted_at
+		FROM assignment_submissions
+		WHERE assignment_id = ?
+	`
+
+	return r.querySubmissions(query, assignmentID)
+}
+
+// === HELPERS ===
+
+// queryMyAssignments lista las tareas del alumno con su entrega. filter agrega
+// condiciones al WHERE.
+func (r *AssignmentRepository) queryMyAssignments(filter string, args ...interface{}) ([]models.MyAssignment, error) {
+	query := `
+		SELECT ` + assignmentColumns + `, sub.status, sub.score, sub.submitted_at
+		FROM classroom_members m
+		JOIN classrooms c ON m.classroom_id = c.id
+		JOIN assignments a ON a.classroom_id = c.id
+		LEFT JOIN assignment_submissions sub ON sub.assignment_id = a.id AND sub.user_id = m.user_id
+		WHERE m.user_id = ? AND c.is_active = TRUE ` + filter + `
+		ORDER BY a.due_at
+	`
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	assignments := []models.MyAssignment{}
+	for rows.Next() {
+		var a models.MyAssignment
+		var status sql.NullString
+		var score sql.NullInt64
+		if err := rows.Scan(
+			&a.ID, &a.ClassroomID, &a.TeacherID, &a.Title, &a.Instructions, &a.AssignmentType,
+			&a.TargetID, &a.DueAt, &a.AllowLate, &a.CreatedAt, &a.UpdatedAt, &a.ClassroomName,
+			&a.SubmittedCount, &a.StudentCount,
+			&status, &score, &a.SubmittedAt,
+		); err != nil {
+			return nil, err
+		}
+		a.Status = status.String
+		if score.Valid {
+			v := int(score.Int64)
+			a.Score = &v
+		}
+		assignments = append(assignments, a)
+	}
+
+	return assignments, rows.Err()
+}
+
+func (r *AssignmentRepository) querySubmissions(query string, args ...interface{}) ([]models.AssignmentSubmission, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	submissions := []models.AssignmentSubmission{}
+	for rows.Next() {
+		var s models.AssignmentSubmission
+		if err := rows.Scan(&s.AssignmentID, &s.UserID, &s.Status, &s.Score, &s.SubmittedAt); err != nil {
+			return nil, err
+		}
+		submissions = append(submissions, s)
+	}
+
+	return submissions, rows.Err()
+}
+
+func scanAssignment(row rowScanner) (*models.Assignment, error) {
+	var a models.Assignment
+	err := row.Scan(
+		&a.ID, &a.ClassroomID, &a.TeacherID, &a.Title, &a.Instructions, &a.AssignmentType,
+		&a.TargetID, &a.DueAt, &a.AllowLate, &a.CreatedAt, &a.UpdatedAt, &a.ClassroomName,
+		&a.SubmittedCount, &a.StudentCount,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
@@ -0,0 +1,528 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/smartstocks/backend/internal/models"
+)
+
+type ClassroomRepository struct {
+	db *sql.DB
+}
+
+func NewClassroomRepository(db *sql.DB) *ClassroomRepository {
+	return &ClassroomRepository{db: db}
+}
+
+// === AULAS ===
+
+// CreateClassroom crea un aula
+func (r *ClassroomRepository) CreateClassroom(classroom *models.Classroom) error {
+	classroom.ID = uuid.New().String()
+	classroom.IsActive = true
+	classroom.CreatedAt = time.Now()
+	classroom.UpdatedAt = time.Now()
+
+	query := `
+		INSERT INTO classrooms (id, teacher_id, school_id, name, description, join_code)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`
+
+	_, err := r.db.Exec(query,
+		classroom.ID,
+		classroom.TeacherID,
+		classroom.SchoolID,
+		classroom.Name,
+		classroom.Description,
+		classroom.JoinCode,
+	)
+
+	return err
+}
+
+// GetClassroomByID obtiene un aula con su cantidad de alumnos
+func (r *ClassroomRepository) GetClassroomByID(classroomID string) (*models.Classroom, error) {
+	query := `
+		SELECT c.id, c.teacher_id, c.school_id, c.name, COALESCE(c.description, ''), c.join_code,
+			   c.is_active, c.created_at, c.updated_at, u.username,
+			   (SELECT COUNT(*) FROM classroom_members cm WHERE cm.classroom_id = c.id)
+		FROM classrooms c
+		JOIN users u ON c.teacher_id = u.id
+		WHERE c.id = ?
+	`
+
+	classroom, err := scanClassroom(r.db.QueryRow(query, classroomID))
+	if err == sql.ErrNoRows {
+		return nil, errors.New("classroom not found")
+	}
+
+	return classroom, err
+}
+
+// GetClassroomByJoinCode busca un aula por su código (nil si no existe)
+func (r *ClassroomRepository) GetClassroomByJoinCode(joinCode string) (*models.Classroom, error) {
+	query := `
+		SELECT c.id, c.teacher_id, c.school_id, c.name, COALESCE(c.description, ''), c.join_code,
+			   c.is_active, c.created_at, c.updated_at, u.username,
+			   (SELECT COUNT(*) FROM classroom_members cm WHERE cm.classroom_id = c.id)
+		FROM classrooms c
+		JOIN users u ON c.teacher_id = u.id
+		WHERE c.join_code = ?
+	`
+
+	classroom, err := scanClassroom(r.db.QueryRow(query, joinCode))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+
+	return classroom, err
+}
+
+// GetTeacherClassrooms lista las aulas de un docente
+func (r *ClassroomRepository) GetTeacherClassrooms(teacherID string) ([]models.Classroom, error) {
+	query := `
+		SELECT c.id, c.teacher_id, c.school_id, c.name, COALESCE(c.description, ''), c.join_code,
+			   c.is_active, c.created_at, c.updated_at, u.username,
+			   (SELECT COUNT(*) FROM classroom_members cm WHERE cm.classroom_id = c.id)
+		FROM classrooms c
+		JOIN users u ON c.teacher_id = u.id
+		WHERE c.teacher_id = ?
+		ORDER BY c.is_active DESC, c.created_at DESC
+	`
+
+	return r.queryClassrooms(query, teacherID)
+}
+
+// GetStudentClassrooms lista las aulas a las que se unió un alumno
+func (r *ClassroomRepository) GetStudentClassrooms(userID string) ([]models.Classroom, error) {
+	query := `
+		SELECT c.id, c.teacher_id, c.school_id, c.name, COALESCE(c.description, ''), c.join_code,
+			   c.is_active, c.created_at, c.updated_at, u.username,
+			   (SELECT COUNT(*) FROM classroom_members cm2 WHERE cm2.classroom_id = c.id)
+		FROM classroom_members cm
+		JOIN classrooms c ON cm.classroom_id = c.id
+		JOIN users u ON c.teacher_id = u.id
+		WHERE cm.user_id = ?
+		ORDER BY cm.joined_at DESC
+	`
+
+	classrooms, err := r.queryClassrooms(query, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// El código de acceso solo lo ve el docente
+	for i := range classrooms {
+		classrooms[i].JoinCode = ""
+	}
+
+	return classrooms, nil
+}
+
+// UpdateClassroom actualiza los datos editables de un aula
+func (r *ClassroomRepository) UpdateClassroom(classroomID string, req *models.UpdateClassroomRequest) error {
+	query := `
+		UPDATE classrooms
+		SET name = COALESCE(?, name),
+			description = COALESCE(?, description),
+			is_active = COALESCE(?, is_active)
+		WHERE id = ?
+	`
+	_, err := r.db.Exec(query, req.Name, req.Description, req.IsActive, classroomID)
+	return err
+}
+
+// UpdateJoinCode reemplaza el código de acceso de un aula
+func (r *ClassroomRepository) UpdateJoinCode(classroomID, joinCode string) error {
+	_, err := r.db.Exec(`UPDATE classrooms SET join_code = ? WHERE id = ?`, joinCode, classroomID)
+	return err
+}
+
+// DeleteClassroom elimina un aula y sus membresías
+func (r *ClassroomRepository) DeleteClassroom(classroomID string) error {
+	_, err := r.db.Exec(`DELETE FROM classrooms WHERE id = ?`, classroomID)
+	return err
+}
+
+// === ALUMNOS ===
+
+// AddMember agrega un alumno al aula. Devuelve false si ya era miembro.
+func (r *ClassroomRepository) AddMember(classroomID, userID string) (bool, error) {
+	query := `
+		INSERT IGNORE INTO classroom_members (id, classroom_id, user_id)
+		VALUES (?, ?, ?)
+	`
+	result, err := r.db.Exec(query, uuid.New().String(), classroomID, userID)
+	if err != nil {
+		return false, err
+	}
+
+	rows, err := result.RowsAffected()
+	return rows > 0, err
+}
+
+// RemoveMember quita un alumno del aula. Devuelve false si no era miembro.
+func (r *ClassroomRepository) RemoveMember(classroomID, userID string) (bool, error) {
+	result, err := r.db.Exec(`DELETE FROM classroom_members WHERE classroom_id = ? AND user_id = ?`, classroomID, userID)
+	if err != nil {
+		return false, err
+	}
+
+	rows, err := result.RowsAffected()
+	return rows > 0, err
+}
+
+// IsMember verifica si un alumno pertenece al aula
+func (r *ClassroomRepository) IsMember(classroomID, userID string) (bool, error) {
+	var exists bool
+	query := `SELECT EXISTS(SELECT 1 FROM classroom_members WHERE classroom_id = ? AND user_id = ?)`
+	err := r.db.QueryRow(query, classroomID, userID).Scan(&exists)
+	return exists, err
+}
+
+// GetMembers lista los alumnos del aula
+func (r *ClassroomRepository) GetMembers(classroomID string) ([]models.ClassroomMember, error) {
+	query := `
+		SELECT u.id, u.username, u.profile_picture_url, cm.joined_at, u.last_login
+		FROM classroom_members cm
+		JOIN users u ON cm.user_id = u.id
+		WHERE cm.classroom_id = ?
+		ORDER BY u.username
+	`
+
+	rows, err := r.db.Query(query, classroomID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	members := []models.ClassroomMember{}
+	for rows.Next() {
+		var m models.ClassroomMember
+		if err := rows.Scan(&m.UserID, &m.Username, &m.ProfilePictureURL, &m.JoinedAt, &m.LastLogin); err != nil {
+			return nil, err
+		}
+		members = append(members, m)
+	}
+
+	return members, rows.Err()
+}
+
+// === PROGRESO ===
+
+// studentProgressQuery agrega la actividad de los alumnos del aula en [from, to).
+// Cada subconsulta se limita a los miembros del aula para no recorrer toda la tabla.
+const studentProgressQuery = `
+	SELECT u.id, u.username, u.last_login,
+		   COALESCE(lp.completed, 0), lp.last_at,
+		   COALESCE(cp.completed, 0),
+		   COALESCE(qa.attempts, 0), COALESCE(qa.avg_score, 0), COALESCE(qa.points, 0), qa.last_at,
+		   COALESCE(sa.attempts, 0), COALESCE(sa.correct, 0), COALESCE(sa.points, 0), sa.last_at,
+		   COALESCE(pm.matches, 0), COALESCE(pm.wins, 0), pm.last_at
+	FROM classroom_members cm
+	JOIN users u ON cm.user_id = u.id
+	LEFT JOIN (
+		SELECT user_id, COUNT(*) AS completed, MAX(completed_at) AS last_at
+		FROM user_lesson_progress
+		WHERE is_completed = TRUE AND completed_at >= ? AND completed_at < ?
+		  AND user_id IN (SELECT user_id FROM classroom_members WHERE classroom_id = ?)
+		GROUP BY user_id
+	) lp ON lp.user_id = u.id
+	LEFT JOIN (
+		SELECT user_id, COUNT(*) AS completed
+		FROM user_course_progress
+		WHERE is_completed = TRUE AND completed_at >= ? AND completed_at < ?
+		  AND user_id IN (SELECT user_id FROM classroom_members WHERE classroom_id = ?)
+		GROUP BY user_id
+	) cp ON cp.user_id = u.id
+	LEFT JOIN (
+		SELECT user_id, COUNT(*) AS attempts, AVG(score) AS avg_score,
+			   SUM(points_earned) AS points, MAX(completed_at) AS last_at
+		FROM quiz_attempts
+		WHERE completed_at >= ? AND completed_at < ?
+		  AND user_id IN (SELECT user_id FROM classroom_members WHERE classroom_id = ?)
+		GROUP BY user_id
+	) qa ON qa.user_id = u.id
+	LEFT JOIN (
+		SELECT user_id, COUNT(*) AS attempts, SUM(was_correct) AS correct,
+			   SUM(points_earned) AS points, MAX(created_at) AS last_at
+		FROM simulator_attempts
+		WHERE created_at >= ? AND created_at < ?
+		  AND user_id IN (SELECT user_id FROM classroom_members WHERE classroom_id = ?)
+		GROUP BY user_id
+	) sa ON sa.user_id = u.id
+	LEFT JOIN (
+		SELECT p.user_id, COUNT(*) AS matches, SUM(p.won) AS wins, MAX(p.completed_at) AS last_at
+		FROM (
+			SELECT player1_id AS user_id, winner_id = player1_id AS won, completed_at
+			FROM pvp_matches WHERE status = 'completed'
+			UNION ALL
+			SELECT player2_id AS user_id, winner_id = player2_id AS won, completed_at
+			FROM pvp_matches WHERE status = 'completed'
+		) p
+		WHERE p.completed_at >= ? AND p.completed_at < ?
+		  AND p.user_id IN (SELECT user_id FROM classroom_members WHERE classroom_id = ?)
+		GROUP BY p.user_id
+	) pm ON pm.user_id = u.id
+	WHERE cm.classroom_id = ?
+`
+
+// GetStudentsProgress obtiene la actividad de todos los alumnos del aula
+func (r *ClassroomRepository) GetStudentsProgress(classroomID string, period models.ProgressRange) ([]models.StudentProgress, error) {
+	query := studentProgressQuery + ` ORDER BY u.username`
+	return r.queryStudentProgress(query, progressArgs(classroomID, period)...)
+}
+
+// GetStudentProgress obtiene la actividad de un alumno del aula (nil si no es miembro)
+func (r *ClassroomRepository) GetStudentProgress(classroomID, userID string, period models.ProgressRange) (*models.StudentProgress, error) {
+	query := studentProgressQuery + ` AND cm.user_id = ?`
+	args := append(progressArgs(classroomID, period), userID)
+
+	students, err := r.queryStudentProgress(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	if len(students) == 0 {
+		return nil, nil
+	}
+
+	return &students[0], nil
+}
+
+// GetStudentCourses avance del alumno en los cursos con actividad en el
+// período: empezados, terminados o con alguna lección completada en él
+func (r *ClassroomRepository) GetStudentCourses(userID string, period models.ProgressRange) ([]models.StudentCourseCompletion, error) {
+	query := `
+		SELECT c.id, c.title,
+			   (SELECT COUNT(*) FROM lessons l WHERE l.course_id = c.id AND l.is_active = TRUE),
+			   (SELECT COUNT(*) FROM user_lesson_progress ulp
+			    JOIN lessons l ON ulp.lesson_id = l.id
+			    WHERE l.course_id = c.id AND ulp.user_id = ucp.user_id AND ulp.is_completed = TRUE),
+			   ucp.is_completed, ucp.completed_at
+		FROM user_course_progress ucp
+		JOIN courses c ON ucp.course_id = c.id
+		WHERE ucp.user_id = ?
+		  AND (
+			(ucp.started_at >= ? AND ucp.started_at < ?)
+			OR (ucp.completed_at >= ? AND ucp.completed_at < ?)
+			OR EXISTS (
+				SELECT 1 FROM user_lesson_progress ulp
+				JOIN lessons l ON ulp.lesson_id = l.id
+				WHERE l.course_id = c.id AND ulp.user_id = ucp.user_id AND ulp.is_completed = TRUE
+				  AND ulp.completed_at >= ? AND ulp.completed_at < ?
+			)
+		  )
+		ORDER BY c.order_index
+	`
+
+	rows, err := r.db.Query(query, userID,
+		period.From, period.To,
+		period.From, period.To,
+		period.From, period.To,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	courses := []models.StudentCourseCompletion{}
+	for rows.Next() {
+		var c models.StudentCourseCompletion
+		if err := rows.Scan(&c.CourseID, &c.CourseTitle, &c.TotalLessons, &c.CompletedLessons, &c.IsCompleted, &c.CompletedAt); err != nil {
+			return nil, err
+		}
+		courses = append(courses, c)
+	}
+
+	return courses, rows.Err()
+}
+
+// GetStudentLessons lecciones completadas por el alumno en el período
+func (r *ClassroomRepository) GetStudentLessons(userID string, period models.ProgressRange) ([]models.StudentLessonActivity, error) {
+	query := `
+		SELECT l.id, l.title, c.title, ulp.quiz_score, ulp.completed_at
+		FROM user_lesson_progress ulp
+		JOIN lessons l ON ulp.lesson_id = l.id
+		JOIN courses c ON l.course_id = c.id
+		WHERE ulp.user_id = ? AND ulp.is_completed = TRUE
+		  AND ulp.completed_at >= ? AND ulp.completed_at < ?
+		ORDER BY ulp.completed_at DESC
+	`
+
+	rows, err := r.db.Query(query, userID, period.From, period.To)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	lessons := []models.StudentLessonActivity{}
+	for rows.Next() {
+		var l models.StudentLessonActivity
+		if err := rows.Scan(&l.LessonID, &l.LessonTitle, &l.CourseTitle, &l.QuizScore, &l.CompletedAt); err != nil {
+			return nil, err
+		}
+		lessons = append(lessons, l)
+	}
+
+	return lessons, rows.Err()
+}
+
+// GetStudentQuizzes quizzes resueltos por el alumno en el período
+func (r *ClassroomRepository) GetStudentQuizzes(userID string, period models.ProgressRange) ([]models.StudentQuizActivity, error) {
+	query := `
+		SELECT quiz_id, difficulty, score, correct_answers, total_questions, completed_at
+		FROM quiz_attempts
+		WHERE user_id = ? AND completed_at >= ? AND completed_at < ?
+		ORDER BY completed_at DESC
+	`
+
+	rows, err := r.db.Query(query, userID, period.From, period.To)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	quizzes := []models.StudentQuizActivity{}
+	for rows.Next() {
+		var q models.StudentQuizActivity
+		if err := rows.Scan(&q.QuizID, &q.Difficulty, &q.Score, &q.CorrectAnswers, &q.TotalQuestions, &q.CompletedAt); err != nil {
+			return nil, err
+		}
+		quizzes = append(quizzes, q)
+	}
+
+	return quizzes, rows.Err()
+}
+
+// GetStudentSimulatorAccuracy aciertos del simulador por dificultad en el período
+func (r *ClassroomRepository) GetStudentSimulatorAccuracy(userID string, period models.ProgressRange) ([]models.SimulatorAccuracy, error) {
+	query := `
+		SELECT difficulty, COUNT(*), COALESCE(SUM(was_correct), 0)
+		FROM simulator_attempts
+		WHERE user_id = ? AND created_at >= ? AND created_at < ?
+		GROUP BY difficulty
+		ORDER BY FIELD(difficulty, 'easy', 'medium', 'hard')
+	`
+
+	rows, err := r.db.Query(query, userID, period.From, period.To)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	accuracy := []models.SimulatorAccuracy{}
+	for rows.Next() {
+		var a models.SimulatorAccuracy
+		if err := rows.Scan(&a.Difficulty, &a.Attempts, &a.Correct); err != nil {
+			return nil, err
+		}
+		a.Accuracy = percentage(a.Correct, a.Attempts)
+		accuracy = append(accuracy, a)
+	}
+
+	return accuracy, rows.Err()
+}
+
+// === HELPERS ===
+
+func (r *ClassroomRepository) queryClassrooms(query string, args ...interface{}) ([]models.Classroom, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	classrooms := []models.Classroom{}
+	for rows.Next() {
+		classroom, err := scanClassroom(rows)
+		if err != nil {
+			return nil, err
+		}
+		classrooms = append(classrooms, *classroom)
+	}
+
+	return classrooms, rows.Err()
+}
+
+func (r *ClassroomRepository) queryStudentProgress(query string, args ...interface{}) ([]models.StudentProgress, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	students := []models.StudentProgress{}
+	for rows.Next() {
+		var s models.StudentProgress
+		var lessonAt, quizAt, simulatorAt, pvpAt sql.NullTime
+		var quizPoints, simulatorPoints int
+
+		err := rows.Scan(
+			&s.UserID, &s.Username, &s.LastLogin,
+			&s.LessonsCompleted, &lessonAt,
+			&s.CoursesCompleted,
+			&s.QuizAttempts, &s.AvgQuizScore, &quizPoints, &quizAt,
+			&s.SimulatorAttempts, &s.SimulatorCorrect, &simulatorPoints, &simulatorAt,
+			&s.PvPMatches, &s.PvPWins, &pvpAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		s.SimulatorAccuracy = percentage(s.SimulatorCorrect, s.SimulatorAttempts)
+		s.SmartpointsEarned = quizPoints + simulatorPoints
+		for _, t := range []sql.NullTime{lessonAt, quizAt, simulatorAt, pvpAt} {
+			if t.Valid && (!s.LastActivityAt.Valid || t.Time.After(s.LastActivityAt.Time)) {
+				s.LastActivityAt = t
+			}
+		}
+
+		students = append(students, s)
+	}
+
+	return students, rows.Err()
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanClassroom(row rowScanner) (*models.Classroom, error) {
+	c := &models.Classroom{}
+	err := row.Scan(
+		&c.ID,
+		&c.TeacherID,
+		&c.SchoolID,
+		&c.Name,
+		&c.Description,
+		&c.JoinCode,
+		&c.IsActive,
+		&c.CreatedAt,
+		&c.UpdatedAt,
+		&c.TeacherUsername,
+		&c.StudentCount,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// progressArgs parámetros de studentProgressQuery en orden
+func progressArgs(classroomID string, period models.ProgressRange) []interface{} {
+	args := make([]interface{}, 0, 16)
+	for i := 0; i < 5; i++ {
+		args = append(args, period.From, period.To, classroomID)
+	}
+	return append(args, classroomID)
+}
+
+func percentage(part, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(part) * 100 / float64(total)
+}
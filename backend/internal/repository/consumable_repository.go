@@ -0,0 +1,203 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/smartstocks/backend/internal/models"
+)
+
+type ConsumableRepository struct {
+	db *sql.DB
+}
+
+func NewConsumableRepository(db *sql.DB) *ConsumableRepository {
+	return &ConsumableRepository{db: db}
+}
+
+// CreatePurchaseTx registra la compra dentro de la transacción del cobro.
+// idempotencyKey es la clave de la transacción de tokens, para guardar su
+// id. Devuelve false si ya hay una compra que ocupa el mismo lugar (la
+// pista o la segunda oportunidad ya compradas, o un intento extra sin usar).
+func (r *ConsumableRepository) CreatePurchaseTx(tx *sql.Tx, p *models.ConsumablePurchase, idempotencyKey string) (bool, error) {
+	var transactionID string
+	err := tx.QueryRow(`
+		SELECT id FROM token_transactions WHERE idempotency_key = ?
+	`, idempotencyKey).Scan(&transactionID)
+	if err != nil {
+		return false, err
+	}
+
+	result, err := tx.Exec(`
+		INSERT IGNORE INTO consumable_purchases (
+			id, user_id, type, reference_id, price, transaction_id, consumed_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?)
+	`, p.ID, p.UserID, p.Type, p.ReferenceID, p.Price, transactionID, p.ConsumedAt)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	if affected == 0 {
+		return false, nil
+	}
+
+	p.TransactionID = &transactionID
+	p.CreatedAt = time.Now()
+	return true, nil
+}
+
+// CountRecentPurchasesTx compras de un tipo hechas por el usuario en las
+// últimas 24 horas, dentro de la transacción del cobro
+func (r *ConsumableRepository) CountRecentPurchasesTx(tx *sql.Tx, userID, purchaseType string) (int, error) {
+	var count int
+	err := tx.QueryRow(`
+		SELECT COUNT(*) FROM consumable_purchases
+		WHERE user_id = ? AND type = ? AND created_at >= DATE_SUB(NOW(), INTERVAL 1 DAY)
+	`, userID, purchaseType).Scan(&count)
+	return count, err
+}
+
+// GetPurchase obtiene la última compra de un tipo para la referencia
+func (r *ConsumableRepository) GetPurchase(userID, purchaseType, referenceID string) (*models.ConsumablePurchase, error) {
+	row := r.db.QueryRow(`
+		SELECT id, user_id, type, reference_id, price, transaction_id, consumed_at, created_at
+		FROM consumable_purchases
+		WHERE user_id = ? AND type = ? AND reference_id = ?
+		ORDER BY created_at DESC
+		LIMIT 1
+	`, userID, purchaseType, referenceID)
+
+	p, err := scanConsumablePurchase(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return p, err
+}
+
+// GetPurchaseByKey obtiene la compra cuyo cobro usó la clave de idempotencia
+// (nil si no existe)
+func (r *ConsumableRepository) GetPurchaseByKey(idempotencyKey string) (*models.ConsumablePurchase, error) {
+	row := r.db.QueryRow(`
+		SELECT p.id, p.user_id, p.type, p.reference_id, p.price, p.transaction_id, p.consumed_at, p.created_at
+		FROM consumable_purchases p
+		JOIN token_transactions t ON t.id = p.transaction_id
+		WHERE t.idempotency_key = ?
+	`, idempotencyKey)
+
+	p, err := scanConsumablePurchase(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return p, err
+}
+
+// HasUnused verifica si hay una compra sin usar para la referencia
+func (r *ConsumableRepository) HasUnused(userID, purchaseType, referenceID string) (bool, error) {
+	var exists bool
+	err := r.db.QueryRow(`
+		SELECT EXISTS(
+			SELECT 1 FROM consumable_purchases
+			WHERE user_id = ? AND type = ? AND reference_id = ? AND consumed_at IS NULL
+		)
+	`, userID, purchaseType, referenceID).Scan(&exists)
+	return exists, err
+}
+
+// Consume marca como usada la compra sin usar de la referencia. Devuelve el
+// id de la compra o "" si no había ninguna.
+func (r *ConsumableRepository) Consume(userID, purchaseType, referenceID string) (string, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback()
+
+	var purchaseID string
+	err = tx.QueryRow(`
+		SELECT id FROM consumable_purchases
+		WHERE user_id = ? AND type = ? AND reference_id = ? AND consumed_at IS NULL
+		FOR UPDATE
+	`, userID, purchaseType, referenceID).Scan(&purchaseID)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	_, err = tx.Exec(`
+		UPDATE consumable_purchases SET consumed_at = NOW() WHERE id = ?
+	`, purchaseID)
+	if err != nil {
+		return "", err
+	}
+
+	return purchaseID, tx.Commit()
+}
+
+// Release devuelve al usuario una compra consumida cuyo uso falló
+func (r *ConsumableRepository) Release(purchaseID string) error {
+	_, err := r.db.Exec(`
+		UPDATE consumable_purchases SET consumed_at = NULL WHERE id = ?
+	`, purchaseID)
+	return err
+}
+
+// GetPurchases obtiene las últimas compras del usuario
+func (r *ConsumableRepository) GetPurchases(userID string, limit int) ([]models.ConsumablePurchase, error) {
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+
+	rows, err := r.db.Query(`
+		SELECT id, user_id, type, reference_id, price, transaction_id, consumed_at, created_at
+		FROM consumable_purchases
+		WHERE user_id = ?
+		ORDER BY created_at DESC
+		LIMIT ?
+	`, userID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	purchases := []models.ConsumablePurchase{}
+	for rows.Next() {
+		p, err := scanConsumablePurchase(rows)
+		if err != nil {
+			return nil, err
+		}
+		purchases = append(purchases, *p)
+	}
+
+	return purchases, rows.Err()
+}
+
+func scanConsumablePurchase(row rowScanner) (*models.ConsumablePurchase, error) {
+	var p models.ConsumablePurchase
+	var transactionID sql.NullString
+	var consumedAt sql.NullTime
+	if err := row.Scan(
+		&p.ID,
+		&p.UserID,
+		&p.Type,
+		&p.ReferenceID,
+		&p.Price,
+		&transactionID,
+		&consumedAt,
+		&p.CreatedAt,
+	); err != nil {
+		return nil, err
+	}
+	if transactionID.Valid {
+		p.TransactionID = &transactionID.String
+	}
+	if consumedAt.Valid {
+		p.ConsumedAt = &consumedAt.Time
+	}
+	return &p, nil
+}
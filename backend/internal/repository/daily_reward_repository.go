@@ -0,0 +1,174 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/smartstocks/backend/internal/models"
+)
+
+type DailyRewardRepository struct {
+	db *sql.DB
+}
+
+func NewDailyRewardRepository(db *sql.DB) *DailyRewardRepository {
+	return &DailyRewardRepository{db: db}
+}
+
+// GetLoginStreak obtiene la racha del usuario (nil si nunca hizo check-in)
+func (r *DailyRewardRepository) GetLoginStreak(userID string) (*models.LoginStreak, error) {
+	streak := &models.LoginStreak{}
+	var lastDate, lastAt sql.NullTime
+
+	err := r.db.QueryRow(`
+		SELECT user_id, current_streak, longest_streak, freezes_available, total_check_ins,
+			   last_check_in_date, last_check_in_at, timezone
+		FROM user_login_streaks
+		WHERE user_id = ?
+	`, userID).Scan(
+		&streak.UserID,
+		&streak.CurrentStreak,
+		&streak.LongestStreak,
+		&streak.FreezesAvailable,
+		&streak.TotalCheckIns,
+		&lastDate,
+		&lastAt,
+		&streak.Timezone,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if lastDate.Valid {
+		// DATE sin zona: se conserva solo el día
+		date := time.Date(lastDate.Time.Year(), lastDate.Time.Month(), lastDate.Time.Day(), 0, 0, 0, 0, time.UTC)
+		streak.LastCheckInDate = &date
+	}
+	if lastAt.Valid {
+		streak.LastCheckInAt = &lastAt.Time
+	}
+
+	return streak, nil
+}
+
+// SaveCheckIn registra el check-in del día y la racha resultante, y ejecuta
+// apply (la acreditación de tokens) en la misma transacción. Devuelve false
+// si el usuario ya tenía un check-in en esa fecha.
+func (r *DailyRewardRepository) SaveCheckIn(streak *models.LoginStreak, checkIn *models.DailyCheckIn, apply func(tx *sql.Tx) error) (bool, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	checkInDate := checkIn.CheckInDate.Format("2006-01-02")
+
+	result, err := tx.Exec(`
+		INSERT IGNORE INTO daily_check_ins (
+			id, user_id, check_in_date, streak, reward_tokens, bonus_tokens, freezes_used
+		) VALUES (?, ?, ?, ?, ?, ?, ?)
+	`, checkIn.ID, checkIn.UserID, checkInDate, checkIn.Streak,
+		checkIn.RewardTokens, checkIn.BonusTokens, checkIn.FreezesUsed)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	if affected == 0 {
+		return false, nil
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO user_login_streaks (
+			user_id, current_streak, longest_streak, freezes_available, total_check_ins,
+			last_check_in_date, last_check_in_at, timezone
+		) VALUES (?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP, ?)
+		ON DUPLICATE KEY UPDATE
+			current_streak = VALUES(current_streak),
+			longest_streak = VALUES(longest_streak),
+			freezes_available = VALUES(freezes_available),
+			total_check_ins = VALUES(total_check_ins),
+			last_check_in_date = VALUES(last_check_in_date),
+			last_check_in_at = VALUES(last_check_in_at),
+			timezone = VALUES(timezone)
+	`, streak.UserID, streak.CurrentStreak, streak.LongestStreak, streak.FreezesAvailable,
+		streak.TotalCheckIns, checkInDate, streak.Timezone)
+	if err != nil {
+		return false, err
+	}
+
+	if apply != nil {
+		if err := apply(tx); err != nil {
+			return false, err
+		}
+	}
+
+	return true, tx.Commit()
+}
+
+// AddStreakFreezeTx suma un protector de racha, dentro de la transacción de
+// la compra, si el usuario tiene menos de maxFreezes. Devuelve false si ya
+// estaba en el máximo.
+func (r *DailyRewardRepository) AddStreakFreezeTx(tx *sql.Tx, userID, timezone string, maxFreezes int) (bool, error) {
+	_, err := tx.Exec(`
+		INSERT IGNORE INTO user_login_streaks (user_id, timezone) VALUES (?, ?)
+	`, userID, timezone)
+	if err != nil {
+		return false, err
+	}
+
+	result, err := tx.Exec(`
+		UPDATE user_login_streaks
+		SET freezes_available = freezes_available + 1
+		WHERE user_id = ? AND freezes_available < ?
+	`, userID, maxFreezes)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
+
+// GetRecentCheckIns obtiene los últimos check-ins del usuario
+func (r *DailyRewardRepository) GetRecentCheckIns(userID string, limit int) ([]models.DailyCheckIn, error) {
+	rows, err := r.db.Query(`
+		SELECT id, user_id, check_in_date, streak, reward_tokens, bonus_tokens, freezes_used, created_at
+		FROM daily_check_ins
+		WHERE user_id = ?
+		ORDER BY check_in_date DESC
+		LIMIT ?
+	`, userID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	checkIns := []models.DailyCheckIn{}
+	for rows.Next() {
+		var checkIn models.DailyCheckIn
+		if err := rows.Scan(
+			&checkIn.ID,
+			&checkIn.UserID,
+			&checkIn.CheckInDate,
+			&checkIn.Streak,
+			&checkIn.RewardTokens,
+			&checkIn.BonusTokens,
+			&checkIn.FreezesUsed,
+			&checkIn.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		checkIns = append(checkIns, checkIn)
+	}
+
+	return checkIns, rows.Err()
+}
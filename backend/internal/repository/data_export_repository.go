@@ -0,0 +1,170 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/smartstocks/backend/internal/models"
+)
+
+// exportSections consultas de la exportación de datos personales. Cada una
+// recibe el user_id como único parámetro. Nunca incluir hashes, secretos ni tokens.
+var exportSections = []struct {
+	name  string
+	query string
+}{
+	{"profile", `
+		SELECT u.id, u.username, u.email, u.profile_picture_url, s.name AS school, u.role,
+			   u.created_at, u.updated_at, u.last_login, u.email_verified
+		FROM users u LEFT JOIN schools s ON u.school_id = s.id
+		WHERE u.id = ?`},
+	{"stats", `SELECT * FROM user_stats WHERE user_id = ?`},
+	{"points_ledger", `SELECT source, points, reference_id, created_at FROM points_ledger WHERE user_id = ? ORDER BY created_at`},
+	{"leaderboard_snapshots", `SELECT snapshot_date, global_position, school_id, school_position, smartpoints FROM leaderboard_snapshots WHERE user_id = ? ORDER BY snapshot_date`},
+	{"rank_tier_changes", `SELECT from_tier, to_tier, direction, smartpoints, created_at FROM rank_tier_changes WHERE user_id = ? ORDER BY created_at`},
+	{"achievements", `SELECT * FROM user_achievements WHERE user_id = ? ORDER BY unlocked_at`},
+	{"achievement_progress", `SELECT achievement_type, best_value, updated_at FROM user_achievement_progress WHERE user_id = ? ORDER BY achievement_type`},
+	{"token_balance", `SELECT * FROM user_tokens WHERE user_id = ?`},
+	{"login_streak", `SELECT current_streak, longest_streak, freezes_available, total_check_ins, last_check_in_date, timezone FROM user_login_streaks WHERE user_id = ?`},
+	{"daily_check_ins", `SELECT check_in_date, streak, reward_tokens, bonus_tokens, freezes_used, created_at FROM daily_check_ins WHERE user_id = ? ORDER BY check_in_date`},
+	{"token_transactions", `SELECT * FROM token_transactions WHERE user_id = ? ORDER BY created_at`},
+	{"shop_inventory", `
+		SELECT si.code, si.item_type, si.name, ui.price_paid, ui.is_equipped, ui.purchased_at
+		FROM user_inventory ui JOIN shop_items si ON si.id = ui.item_id
+		WHERE ui.user_id = ? ORDER BY ui.purchased_at`},
+	{"token_gifts", `
+		SELECT g.amount, g.message, s.username AS sender, r.username AS recipient, g.created_at
+		FROM token_gifts g
+		LEFT JOIN users s ON s.id = g.sender_id
+		LEFT JOIN users r ON r.id = g.recipient_id
+		WHERE g.sender_id = ? OR g.recipient_id = ?
+		ORDER BY g.created_at`},
+	{"notifications", `SELECT type, title, body, read_at, created_at FROM notifications WHERE user_id = ? ORDER BY created_at`},
+	{"referrals", `
+		SELECT u.username AS referee, f.code, f.status, f.reject_reason, f.reward_tokens, f.created_at, f.resolved_at
+		FROM referrals f JOIN users u ON u.id = f.referee_id
+		WHERE f.referrer_id = ? ORDER BY f.created_at`},
+	{"consumable_purchases", `
+		SELECT type, reference_id, price, consumed_at, created_at
+		FROM consumable_purchases WHERE user_id = ? ORDER BY created_at`},
+	{"quiz_attempts", `SELECT * FROM quiz_attempts WHERE user_id = ? ORDER BY completed_at`},
+	{"simulator_attempts", `SELECT * FROM simulator_attempts WHERE user_id = ? ORDER BY created_at`},
+	{"pvp_matches", `
+		SELECT * FROM pvp_matches
+		WHERE player1_id = ? OR player2_id = ?
+		ORDER BY created_at`},
+	{"season_standings", `
+		SELECT s.name AS season, s.starts_at, s.ends_at, ss.final_position,
+			   ss.season_points, ss.rank_tier, ss.tokens_awarded
+		FROM season_standings ss JOIN seasons s ON ss.season_id = s.id
+		WHERE ss.user_id = ? ORDER BY s.starts_at`},
+	{"tournament_participations", `SELECT * FROM tournament_participants WHERE user_id = ? ORDER BY joined_at`},
+	{"course_progress", `SELECT * FROM user_course_progress WHERE user_id = ? ORDER BY started_at`},
+	{"lesson_progress", `SELECT * FROM user_lesson_progress WHERE user_id = ? ORDER BY started_at`},
+	{"school_membership", `
+		SELECT s.name AS school, m.status, m.method, m.requested_at, m.reviewed_at
+		FROM school_memberships m JOIN schools s ON m.school_id = s.id
+		WHERE m.user_id = ?`},
+	{"classroom_memberships", `
+		SELECT c.name AS classroom, cm.joined_at
+		FROM classroom_members cm JOIN classrooms c ON cm.classroom_id = c.id
+		WHERE cm.user_id = ?`},
+	{"classrooms_taught", `
+		SELECT id, name, description, is_active, created_at
+		FROM classrooms WHERE teacher_id = ?`},
+	{"assignment_submissions", `
+		SELECT a.title AS assignment, a.assignment_type, a.due_at, s.status, s.score, s.submitted_at
+		FROM assignment_submissions s JOIN assignments a ON s.assignment_id = a.id
+		WHERE s.user_id = ? ORDER BY s.submitted_at`},
+	{"forum_posts", `SELECT * FROM forum_posts WHERE user_id = ? ORDER BY created_at`},
+	{"forum_replies", `SELECT * FROM forum_replies WHERE user_id = ? ORDER BY created_at`},
+	{"forum_reactions", `SELECT * FROM forum_reactions WHERE user_id = ? ORDER BY created_at`},
+	{"linked_accounts", `
+		SELECT provider, email, created_at, last_login_at
+		FROM user_identities WHERE user_id = ?`},
+	{"two_factor", `
+		SELECT enabled, confirmed_at, created_at
+		FROM user_two_factor WHERE user_id = ?`},
+	{"login_history", `
+		SELECT ip_address, user_agent, success, failure_reason, created_at
+		FROM login_attempts WHERE user_id = ? ORDER BY created_at`},
+}
+
+type DataExportRepository struct {
+	db *sql.DB
+}
+
+func NewDataExportRepository(db *sql.DB) *DataExportRepository {
+	return &DataExportRepository{db: db}
+}
+
+// GetUserData reúne todos los datos personales del usuario
+func (r *DataExportRepository) GetUserData(userID string) (*models.UserDataExport, error) {
+	export := &models.UserDataExport{
+		UserID:      userID,
+		GeneratedAt: time.Now(),
+		Sections:    make([]models.DataExportSection, 0, len(exportSections)),
+	}
+
+	for _, section := range exportSections {
+		// Algunas consultas usan el user_id más de una vez
+		args := make([]interface{}, strings.Count(section.query, "?"))
+		for i := range args {
+			args[i] = userID
+		}
+
+		rows, err := r.queryRows(section.query, args...)
+		if err != nil {
+			return nil, fmt.Errorf("error exporting %s: %w", section.name, err)
+		}
+
+		export.Sections = append(export.Sections, models.DataExportSection{
+			Name: section.name,
+			Rows: rows,
+		})
+	}
+
+	return export, nil
+}
+
+// queryRows ejecuta una consulta y devuelve cada fila como columna -> valor
+func (r *DataExportRepository) queryRows(query string, args ...interface{}) ([]map[string]interface{}, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+
+	result := []map[string]interface{}{}
+	for rows.Next() {
+		values := make([]interface{}, len(columns))
+		pointers := make([]interface{}, len(columns))
+		for i := range values {
+			pointers[i] = &values[i]
+		}
+
+		if err := rows.Scan(pointers...); err != nil {
+			return nil, err
+		}
+
+		row := make(map[string]interface{}, len(columns))
+		for i, column := range columns {
+			// El driver devuelve texto y JSON como []byte
+			if b, ok := values[i].([]byte); ok {
+				row[column] = string(b)
+			} else {
+				row[column] = values[i]
+			}
+		}
+		result = append(result, row)
+	}
+
+	return result, rows.Err()
+}
@@ -0,0 +1,41 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/smartstocks/backend/internal/models"
+)
+
+type LoginAttemptRepository struct {
+	db *sql.DB
+}
+
+func NewLoginAttemptRepository(db *sql.DB) *LoginAttemptRepository {
+	return &LoginAttemptRepository{db: db}
+}
+
+// RecordAttempt guarda un intento de login para auditoría
+func (r *LoginAttemptRepository) RecordAttempt(attempt *models.LoginAttempt) error {
+	attempt.ID = uuid.New().String()
+	attempt.CreatedAt = time.Now()
+
+	query := `
+		INSERT INTO login_attempts (id, user_id, email, ip_address, user_agent, device_id, success, failure_reason)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`
+
+	_, err := r.db.Exec(query,
+		attempt.ID,
+		attempt.UserID,
+		attempt.Email,
+		attempt.IPAddress,
+		attempt.UserAgent,
+		attempt.DeviceID,
+		attempt.Success,
+		attempt.FailureReason,
+	)
+
+	return err
+}
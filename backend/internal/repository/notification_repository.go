@@ -0,0 +1,130 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/smartstocks/backend/internal/models"
+)
+
+type NotificationRepository struct {
+	db *sql.DB
+}
+
+func NewNotificationRepository(db *sql.DB) *NotificationRepository {
+	return &NotificationRepository{db: db}
+}
+
+// CreateTx guarda una notificación dentro de la transacción del evento que
+// la origina, así no queda un aviso de algo que no ocurrió
+func (r *NotificationRepository) CreateTx(tx *sql.Tx, notification *models.Notification) error {
+	notification.ID = uuid.New().String()
+	notification.CreatedAt = time.Now()
+
+	_, err := tx.Exec(`
+		INSERT INTO notifications (id, user_id, type, title, body, reference_id)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, notification.ID, notification.UserID, notification.Type,
+		notification.Title, notification.Body, notification.ReferenceID)
+	return err
+}
+
+// GetNotifications obtiene las últimas notificaciones del usuario
+func (r *NotificationRepository) GetNotifications(userID string, unreadOnly bool, limit int) ([]models.Notification, error) {
+	if limit <= 0 || limit > 100 {
+		limit = 50
+	}
+
+	query := `
+		SELECT id, user_id, type, title, body, reference_id, read_at, created_at
+		FROM notifications
+		WHERE user_id = ?
+	`
+	if unreadOnly {
+		query += ` AND read_at IS NULL`
+	}
+	query += ` ORDER BY created_at DESC LIMIT ?`
+
+	rows, err := r.db.Query(query, userID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	notifications := []models.Notification{}
+	for rows.Next() {
+		var n models.Notification
+		var referenceID sql.NullString
+		var readAt sql.NullTime
+		if err := rows.Scan(
+			&n.ID,
+			&n.UserID,
+			&n.Type,
+			&n.Title,
+			&n.Body,
+			&referenceID,
+			&readAt,
+			&n.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		if referenceID.Valid {
+			n.ReferenceID = &referenceID.String
+		}
+		if readAt.Valid {
+			n.ReadAt = &readAt.Time
+		}
+		notifications = append(notifications, n)
+	}
+
+	return notifications, rows.Err()
+}
+
+// CountUnread cuenta las notificaciones sin leer
+func (r *NotificationRepository) CountUnread(userID string) (int, error) {
+	var count int
+	err := r.db.QueryRow(`
+		SELECT COUNT(*) FROM notifications WHERE user_id = ? AND read_at IS NULL
+	`, userID).Scan(&count)
+	return count, err
+}
+
+// MarkRead marca una notificación del usuario como leída. Devuelve false si
+// no existe o es de otro usuario.
+func (r *NotificationRepository) MarkRead(userID, notificationID string) (bool, error) {
+	result, err := r.db.Exec(`
+		UPDATE notifications SET read_at = CURRENT_TIMESTAMP
+		WHERE id = ? AND user_id = ? AND read_at IS NULL
+	`, notificationID, userID)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	if affected > 0 {
+		return true, nil
+	}
+
+	// Ya estaba leída
+	var exists bool
+	err = r.db.QueryRow(`
+		SELECT EXISTS(SELECT 1 FROM notifications WHERE id = ? AND user_id = ?)
+	`, notificationID, userID).Scan(&exists)
+	return exists, err
+}
+
+// MarkAllRead marca como leídas todas las notificaciones del usuario
+func (r *NotificationRepository) MarkAllRead(userID string) (int64, error) {
+	result, err := r.db.Exec(`
+		UPDATE notifications SET read_at = CURRENT_TIMESTAMP
+		WHERE user_id = ? AND read_at IS NULL
+	`, userID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -312,23 +312,70 @@ func (r *PvPRepository) SubmitRoundDecision(matchID string, roundNumber int, pla
 		return err
 	}
 
+	// La decisión es definitiva: solo se cambia con una segunda oportunidad
 	var query string
 	if playerID == match.Player1ID {
 		query = `
 			UPDATE pvp_rounds
 			SET player1_decision = ?, player1_time_seconds = ?
 			WHERE match_id = ? AND round_number = ?
+			AND player1_decision IS NULL AND completed_at IS NULL
 		`
 	} else {
 		query = `
 			UPDATE pvp_rounds
 			SET player2_decision = ?, player2_time_seconds = ?
 			WHERE match_id = ? AND round_number = ?
+			AND player2_decision IS NULL AND completed_at IS NULL
 		`
 	}
 
-	_, err = r.db.Exec(query, decision, timeElapsed, matchID, roundNumber)
-	return err
+	result, err := r.db.Exec(query, decision, timeElapsed, matchID, roundNumber)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return errors.New("decision already submitted")
+	}
+	return nil
+}
+
+// ChangeRoundDecisionTx reemplaza la decisión ya enviada por el jugador
+// mientras el rival todavía no decidió. Devuelve false si la ronda ya no
+// admite el cambio.
+func (r *PvPRepository) ChangeRoundDecisionTx(tx *sql.Tx, matchID string, roundNumber int, isPlayer1 bool, decision models.SimulatorDecision, timeElapsed float64) (bool, error) {
+	query := `
+		UPDATE pvp_rounds
+		SET player1_decision = ?, player1_time_seconds = ?
+		WHERE match_id = ? AND round_number = ?
+		AND player1_decision IS NOT NULL AND player2_decision IS NULL
+		AND completed_at IS NULL
+	`
+	if !isPlayer1 {
+		query = `
+			UPDATE pvp_rounds
+			SET player2_decision = ?, player2_time_seconds = ?
+			WHERE match_id = ? AND round_number = ?
+			AND player2_decision IS NOT NULL AND player1_decision IS NULL
+			AND completed_at IS NULL
+		`
+	}
+
+	result, err := tx.Exec(query, decision, timeElapsed, matchID, roundNumber)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
 }
 
 // CompleteRound marca una ronda como completada y calcula puntos
@@ -550,8 +597,8 @@ func (r *PvPRepository) GetUserPvPStats(userID string) (*models.PvPStats, error)
 }
 
 // UpdatePvPStats actualiza las estadísticas después de una partida
-func (r *PvPRepository) UpdatePvPStats(winnerID, loserID string, winnerPoints int) error {
-	query := `CALL update_pvp_stats(?, ?, ?, ?)`
-	_, err := r.db.Exec(query, winnerID, loserID, winnerPoints, true)
+func (r *PvPRepository) UpdatePvPStats(matchID, winnerID, loserID string, winnerPoints int) error {
+	query := `CALL update_pvp_stats(?, ?, ?, ?, ?)`
+	_, err := r.db.Exec(query, matchID, winnerID, loserID, winnerPoints, true)
 	return err
 }
@@ -102,6 +102,36 @@ func (r *QuizRepository) GetActiveQuizByDifficulty(difficulty string) (*models.Q
 	return quiz, err
 }
 
+// GetQuizByID obtiene un quiz aunque ya no esté activo (nil si no existe)
+func (r *QuizRepository) GetQuizByID(quizID string) (*models.Quiz, error) {
+	quiz := &models.Quiz{}
+	query := `
+		SELECT id, difficulty, title, description, points_reward, total_questions,
+			   time_limit_minutes, is_active, created_at, expires_at
+		FROM quizzes
+		WHERE id = ?
+	`
+
+	err := r.db.QueryRow(query, quizID).Scan(
+		&quiz.ID,
+		&quiz.Difficulty,
+		&quiz.Title,
+		&quiz.Description,
+		&quiz.PointsReward,
+		&quiz.TotalQuestions,
+		&quiz.TimeLimitMinutes,
+		&quiz.IsActive,
+		&quiz.CreatedAt,
+		&quiz.ExpiresAt,
+	)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+
+	return quiz, err
+}
+
 func (r *QuizRepository) GetQuestionsByQuizID(quizID string) ([]models.QuizQuestion, error) {
 	query := `
 		SELECT id, quiz_id, question_text, option_a, option_b, option_c, option_d,
@@ -151,8 +181,8 @@ func (r *QuizRepository) CreateAttempt(attempt *models.QuizAttempt) error {
 	query := `
 		INSERT INTO quiz_attempts (id, user_id, quiz_id, difficulty, score, 
 								  total_questions, correct_answers, points_earned,
-								  time_taken_seconds, answers)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+								  time_taken_seconds, answers, extra_attempt_id)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 
 	_, err := r.db.Exec(query,
@@ -166,6 +196,7 @@ func (r *QuizRepository) CreateAttempt(attempt *models.QuizAttempt) error {
 		attempt.PointsEarned,
 		attempt.TimeTakenSeconds,
 		attempt.Answers,
+		attempt.ExtraAttemptID,
 	)
 
 	return err
@@ -198,9 +229,9 @@ func (r *QuizRepository) SetCooldown(userID, difficulty string) error {
 	return err
 }
 
-func (r *QuizRepository) UpdateUserStatsAfterQuiz(userID string, pointsEarned int) error {
-	query := `CALL update_user_stats_after_quiz(?, ?)`
-	_, err := r.db.Exec(query, userID, pointsEarned)
+func (r *QuizRepository) UpdateUserStatsAfterQuiz(userID, attemptID string, pointsEarned int) error {
+	query := `CALL update_user_stats_after_quiz(?, ?, ?)`
+	_, err := r.db.Exec(query, userID, attemptID, pointsEarned)
 	return err
 }
 
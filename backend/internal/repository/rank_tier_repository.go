@@ -0,0 +1,150 @@
+package repository
+
+import (
+	"database/sql"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/smartstocks/backend/internal/models"
+	"github.com/smartstocks/backend/internal/ranks"
+)
+
+type RankTierRepository struct {
+	db *sql.DB
+}
+
+func NewRankTierRepository(db *sql.DB) *RankTierRepository {
+	return &RankTierRepository{db: db}
+}
+
+// SyncTiers reemplaza rank_tiers por la tabla configurada. Los procedimientos
+// de MySQL (calculate_rank_tier, update_user_rank, meets_rank_requirement)
+// leen de ahí los umbrales.
+func (r *RankTierRepository) SyncTiers(table *ranks.Table) error {
+	definitions := table.Definitions()
+
+	args := make([]interface{}, 0, len(definitions)*5)
+	for _, def := range definitions {
+		tier, _ := table.Lookup(def.Code)
+		args = append(args, def.Code, tier.Level, def.Group, table.GroupLevel(tier), def.MinPoints)
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM rank_tiers`); err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO rank_tiers (code, position, group_code, group_level, min_points)
+		VALUES (?, ?, ?, ?, ?)` + strings.Repeat(", (?, ?, ?, ?, ?)", len(definitions)-1)
+	if _, err := tx.Exec(query, args...); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// GetUserRankTiersAfter obtiene hasta limit rangos guardados de usuarios con
+// id mayor a afterID, en orden, para recorrer todos los usuarios por páginas
+func (r *RankTierRepository) GetUserRankTiersAfter(afterID string, limit int) ([]models.UserRankTier, error) {
+	rows, err := r.db.Query(`
+		SELECT user_id, smartpoints, COALESCE(rank_tier, '')
+		FROM user_stats
+		WHERE user_id > ?
+		ORDER BY user_id
+		LIMIT ?
+	`, afterID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var users []models.UserRankTier
+	for rows.Next() {
+		var u models.UserRankTier
+		if err := rows.Scan(&u.UserID, &u.Smartpoints, &u.RankTier); err != nil {
+			return nil, err
+		}
+		users = append(users, u)
+	}
+
+	return users, rows.Err()
+}
+
+// UpdateUserRankTier cambia el rango del usuario si todavía es fromTier y
+// registra el ascenso o descenso (si change no es nil). Devuelve false si el
+// rango cambió mientras tanto.
+func (r *RankTierRepository) UpdateUserRankTier(user models.UserRankTier, toTier string, change *ranks.Change) (bool, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`
+		UPDATE user_stats
+		SET rank_tier = ?, updated_at = updated_at
+		WHERE user_id = ? AND rank_tier = ?
+	`, toTier, user.UserID, user.RankTier)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	if affected == 0 {
+		return false, nil
+	}
+
+	if change != nil {
+		_, err = tx.Exec(`
+			INSERT INTO rank_tier_changes (id, user_id, from_tier, to_tier, direction, smartpoints)
+			VALUES (?, ?, ?, ?, ?, ?)
+		`, uuid.New().String(), user.UserID, change.From.Code, change.To.Code, string(change.Direction), user.Smartpoints)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	return true, tx.Commit()
+}
+
+// GetRankTierChanges obtiene los últimos cambios de rango del usuario
+func (r *RankTierRepository) GetRankTierChanges(userID string, limit int) ([]models.RankTierChange, error) {
+	rows, err := r.db.Query(`
+		SELECT id, user_id, from_tier, to_tier, direction, smartpoints, created_at
+		FROM rank_tier_changes
+		WHERE user_id = ?
+		ORDER BY created_at DESC
+		LIMIT ?
+	`, userID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	changes := []models.RankTierChange{}
+	for rows.Next() {
+		var change models.RankTierChange
+		if err := rows.Scan(
+			&change.ID,
+			&change.UserID,
+			&change.FromTier,
+			&change.ToTier,
+			&change.Direction,
+			&change.Smartpoints,
+			&change.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		changes = append(changes, change)
+	}
+
+	return changes, rows.Err()
+}
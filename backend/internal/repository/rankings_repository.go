@@ -1,8 +1,10 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/smartstocks/backend/internal/models"
@@ -215,6 +217,565 @@ func (r *RankingsRepository) UpdateLeaderboardCache() error {
 	return err
 }
 
+// === HISTORIAL DE POSICIONES ===
+
+// GetLatestSnapshots obtiene la última foto diaria de los usuarios indicados,
+// indexada por user_id (los que no figuran en la foto no están en el mapa)
+func (r *RankingsRepository) GetLatestSnapshots(userIDs []string) (map[string]models.LeaderboardSnapshot, error) {
+	snapshots := make(map[string]models.LeaderboardSnapshot, len(userIDs))
+	if len(userIDs) == 0 {
+		return snapshots, nil
+	}
+
+	args := make([]interface{}, len(userIDs))
+	for i, id := range userIDs {
+		args[i] = id
+	}
+
+	query := `
+		SELECT user_id, snapshot_date, global_position, school_id, school_position, smartpoints
+		FROM leaderboard_snapshots
+		WHERE snapshot_date = (SELECT MAX(snapshot_date) FROM leaderboard_snapshots)
+		  AND user_id IN (?` + strings.Repeat(", ?", len(userIDs)-1) + `)
+	`
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		snapshot, err := scanLeaderboardSnapshot(rows)
+		if err != nil {
+			return nil, err
+		}
+		snapshots[snapshot.UserID] = *snapshot
+	}
+
+	return snapshots, rows.Err()
+}
+
+// GetUserSnapshots obtiene las fotos del usuario desde la fecha indicada,
+// de la más antigua a la más reciente
+func (r *RankingsRepository) GetUserSnapshots(userID string, since time.Time) ([]models.LeaderboardSnapshot, error) {
+	query := `
+		SELECT user_id, snapshot_date, global_position, school_id, school_position, smartpoints
+		FROM leaderboard_snapshots
+		WHERE user_id = ? AND snapshot_date >= ?
+		ORDER BY snapshot_date ASC
+	`
+
+	rows, err := r.db.Query(query, userID, since.Format("2006-01-02"))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	snapshots := []models.LeaderboardSnapshot{}
+	for rows.Next() {
+		snapshot, err := scanLeaderboardSnapshot(rows)
+		if err != nil {
+			return nil, err
+		}
+		snapshots = append(snapshots, *snapshot)
+	}
+
+	return snapshots, rows.Err()
+}
+
+func scanLeaderboardSnapshot(row rowScanner) (*models.LeaderboardSnapshot, error) {
+	var snapshot models.LeaderboardSnapshot
+	var schoolID sql.NullString
+	var schoolPosition sql.NullInt64
+	if err := row.Scan(
+		&snapshot.UserID,
+		&snapshot.SnapshotDate,
+		&snapshot.GlobalPosition,
+		&schoolID,
+		&schoolPosition,
+		&snapshot.Smartpoints,
+	); err != nil {
+		return nil, err
+	}
+
+	if schoolID.Valid {
+		snapshot.SchoolID = &schoolID.String
+	}
+	if schoolPosition.Valid {
+		position := int(schoolPosition.Int64)
+		snapshot.SchoolPosition = &position
+	}
+
+	return &snapshot, nil
+}
+
+// === RANKINGS POR VENTANA ===
+
+// windowRanking ranking por puntos registrados en points_ledger desde since.
+// Solo figuran quienes sumaron puntos en la ventana. Con schoolID se limita a
+// los miembros del colegio.
+func windowRanking(since time.Time, schoolID string) (string, []interface{}) {
+	filter := ""
+	args := []interface{}{since}
+	if schoolID != "" {
+		filter = " AND u.school_id = ?"
+		args = append(args, schoolID)
+	}
+
+	return `
+		SELECT ranked.position, u.id, u.username, us.smartpoints, us.rank_tier,
+			   us.total_wins, us.total_losses,
+			   CASE
+				   WHEN (us.total_wins + us.total_losses) > 0
+				   THEN ROUND((us.total_wins * 100.0) / (us.total_wins + us.total_losses), 2)
+				   ELSE 0.00
+			   END,
+			   u.profile_picture_url, s.name, s.id, ranked.window_points
+		FROM (
+			SELECT pl.user_id, SUM(pl.points) AS window_points,
+				   ROW_NUMBER() OVER (ORDER BY SUM(pl.points) DESC, MIN(u.created_at) ASC) AS position
+			FROM points_ledger pl
+			JOIN users u ON u.id = pl.user_id
+			WHERE pl.created_at >= ?` + filter + `
+			GROUP BY pl.user_id
+			HAVING SUM(pl.points) > 0
+		) ranked
+		JOIN users u ON u.id = ranked.user_id
+		JOIN user_stats us ON us.user_id = u.id
+		LEFT JOIN schools s ON s.id = u.school_id
+	`, args
+}
+
+// GetWindowLeaderboard obtiene una página del ranking de la ventana que empieza en since
+func (r *RankingsRepository) GetWindowLeaderboard(since time.Time, schoolID string, limit, offset int) ([]models.LeaderboardEntry, error) {
+	if limit <= 0 || limit > 1000 {
+		limit = 100
+	}
+
+	query, args := windowRanking(since, schoolID)
+	return r.queryWindowRanking(query+` ORDER BY ranked.position LIMIT ? OFFSET ?`, append(args, limit, offset)...)
+}
+
+// GetWindowPosition obtiene la entrada del usuario en el ranking de la ventana
+// (nil si no sumó puntos)
+func (r *RankingsRepository) GetWindowPosition(since time.Time, schoolID, userID string) (*models.LeaderboardEntry, error) {
+	query, args := windowRanking(since, schoolID)
+	entries, err := r.queryWindowRanking(query+` WHERE ranked.user_id = ?`, append(args, userID)...)
+	if err != nil || len(entries) == 0 {
+		return nil, err
+	}
+	return &entries[0], nil
+}
+
+// CountWindowPlayers cuenta los jugadores que sumaron puntos desde since
+func (r *RankingsRepository) CountWindowPlayers(since time.Time, schoolID string) (int, error) {
+	filter := ""
+	args := []interface{}{since}
+	if schoolID != "" {
+		filter = " AND u.school_id = ?"
+		args = append(args, schoolID)
+	}
+
+	var count int
+	err := r.db.QueryRow(`
+		SELECT COUNT(*) FROM (
+			SELECT pl.user_id
+			FROM points_ledger pl
+			JOIN users u ON u.id = pl.user_id
+			WHERE pl.created_at >= ?`+filter+`
+			GROUP BY pl.user_id
+			HAVING SUM(pl.points) > 0
+		) players
+	`, args...).Scan(&count)
+	return count, err
+}
+
+func (r *RankingsRepository) queryWindowRanking(query string, args ...interface{}) ([]models.LeaderboardEntry, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []models.LeaderboardEntry{}
+	for rows.Next() {
+		var entry models.LeaderboardEntry
+		var profilePic, schoolName, schoolID sql.NullString
+		if err := rows.Scan(
+			&entry.RankPosition,
+			&entry.UserID,
+			&entry.Username,
+			&entry.Smartpoints,
+			&entry.RankTier,
+			&entry.TotalWins,
+			&entry.TotalLosses,
+			&entry.WinRate,
+			&profilePic,
+			&schoolName,
+			&schoolID,
+			&entry.WindowPoints,
+		); err != nil {
+			return nil, err
+		}
+
+		if profilePic.Valid {
+			entry.ProfilePictureURL = &profilePic.String
+		}
+		if schoolName.Valid {
+			entry.SchoolName = &schoolName.String
+		}
+		if schoolID.Valid {
+			entry.SchoolID = &schoolID.String
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries, rows.Err()
+}
+
+// === RANKINGS POR DISCIPLINA ===
+
+// disciplineStats consulta que devuelve (user_id, score, activity) de cada
+// jugador con al menos minActivity en la disciplina. Los intentos extra
+// repiten un quiz o escenario ya corregido y no cuentan para la precisión.
+func disciplineStats(discipline, difficulty string, minActivity int) (string, []interface{}, error) {
+	switch discipline {
+	case models.DisciplineQuiz:
+		return `
+			SELECT qa.user_id,
+				   SUM(qa.correct_answers) * 100.0 / NULLIF(SUM(qa.total_questions), 0) AS score,
+				   COUNT(*) AS activity
+			FROM quiz_attempts qa
+			WHERE qa.extra_attempt_id = ''
+			GROUP BY qa.user_id
+			HAVING COUNT(*) >= ?
+		`, []interface{}{minActivity}, nil
+
+	case models.DisciplineSimulator:
+		filter := "WHERE sa.extra_attempt_id = ''"
+		var args []interface{}
+		if difficulty != "" {
+			filter += " AND sa.difficulty = ?"
+			args = append(args, difficulty)
+		}
+		return `
+			SELECT sa.user_id,
+				   SUM(CASE WHEN sa.was_correct THEN 1 ELSE 0 END) * 100.0 / COUNT(*) AS score,
+				   COUNT(*) AS activity
+			FROM simulator_attempts sa
+			` + filter + `
+			GROUP BY sa.user_id
+			HAVING COUNT(*) >= ?
+		`, append(args, minActivity), nil
+
+	case models.DisciplinePvP:
+		return `
+			SELECT us.user_id,
+				   us.total_wins * 100.0 / (us.total_wins + us.total_losses) AS score,
+				   us.total_wins + us.total_losses AS activity
+			FROM user_stats us
+			WHERE us.total_wins + us.total_losses >= GREATEST(?, 1)
+		`, []interface{}{minActivity}, nil
+
+	case models.DisciplineCourses:
+		return `
+			SELECT ucp.user_id,
+				   COUNT(*) AS score,
+				   (SELECT COUNT(*) FROM user_lesson_progress ulp
+					WHERE ulp.user_id = ucp.user_id AND ulp.is_completed = TRUE) AS activity
+			FROM user_course_progress ucp
+			WHERE ucp.is_completed = TRUE
+			GROUP BY ucp.user_id
+			HAVING COUNT(*) >= ?
+		`, []interface{}{minActivity}, nil
+	}
+
+	return "", nil, fmt.Errorf("invalid discipline: %q", discipline)
+}
+
+// disciplineRanking posiciones de la disciplina: mayor puntaje, a igual
+// puntaje más actividad y luego la cuenta más antigua
+func disciplineRanking(discipline, difficulty string, minActivity int) (string, []interface{}, error) {
+	stats, args, err := disciplineStats(discipline, difficulty, minActivity)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return `
+		SELECT ranked.position, u.id, u.username, u.profile_picture_url, s.name,
+			   ROUND(ranked.score, 2), ranked.activity
+		FROM (
+			SELECT st.user_id, st.score, st.activity,
+				   ROW_NUMBER() OVER (ORDER BY st.score DESC, st.activity DESC, u.created_at ASC) AS position
+			FROM (` + stats + `) st
+			JOIN users u ON u.id = st.user_id
+		) ranked
+		JOIN users u ON u.id = ranked.user_id
+		LEFT JOIN schools s ON s.id = u.school_id
+	`, args, nil
+}
+
+// GetDisciplineLeaderboard obtiene una página del ranking de la disciplina
+func (r *RankingsRepository) GetDisciplineLeaderboard(discipline, difficulty string, minActivity, limit, offset int) ([]models.DisciplineLeaderboardEntry, error) {
+	query, args, err := disciplineRanking(discipline, difficulty, minActivity)
+	if err != nil {
+		return nil, err
+	}
+	return r.queryDisciplineRanking(query+` ORDER BY ranked.position LIMIT ? OFFSET ?`, append(args, limit, offset)...)
+}
+
+// GetDisciplinePosition obtiene la entrada del usuario en el ranking de la
+// disciplina (nil si no alcanza la actividad mínima)
+func (r *RankingsRepository) GetDisciplinePosition(discipline, difficulty string, minActivity int, userID string) (*models.DisciplineLeaderboardEntry, error) {
+	query, args, err := disciplineRanking(discipline, difficulty, minActivity)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := r.queryDisciplineRanking(query+` WHERE ranked.user_id = ?`, append(args, userID)...)
+	if err != nil || len(entries) == 0 {
+		return nil, err
+	}
+	return &entries[0], nil
+}
+
+// CountDisciplinePlayers cuenta los jugadores que alcanzan la actividad mínima
+func (r *RankingsRepository) CountDisciplinePlayers(discipline, difficulty string, minActivity int) (int, error) {
+	stats, args, err := disciplineStats(discipline, difficulty, minActivity)
+	if err != nil {
+		return 0, err
+	}
+
+	var count int
+	err = r.db.QueryRow(`SELECT COUNT(*) FROM (`+stats+`) st JOIN users u ON u.id = st.user_id`, args...).Scan(&count)
+	return count, err
+}
+
+func (r *RankingsRepository) queryDisciplineRanking(query string, args ...interface{}) ([]models.DisciplineLeaderboardEntry, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []models.DisciplineLeaderboardEntry{}
+	for rows.Next() {
+		var entry models.DisciplineLeaderboardEntry
+		var profilePic, schoolName sql.NullString
+		if err := rows.Scan(
+			&entry.RankPosition,
+			&entry.UserID,
+			&entry.Username,
+			&profilePic,
+			&schoolName,
+			&entry.Score,
+			&entry.Activity,
+		); err != nil {
+			return nil, err
+		}
+
+		if profilePic.Valid {
+			entry.ProfilePictureURL = &profilePic.String
+		}
+		if schoolName.Valid {
+			entry.SchoolName = &schoolName.String
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries, rows.Err()
+}
+
+// === RANKINGS EN VIVO (REDIS) ===
+
+const leaderboardScoreQuery = `
+	SELECT u.id, COALESCE(u.school_id, ''), us.smartpoints, us.season_points
+	FROM users u
+	JOIN user_stats us ON us.user_id = u.id
+`
+
+// GetLeaderboardScore obtiene los puntajes actuales del usuario (nil si no existe)
+func (r *RankingsRepository) GetLeaderboardScore(userID string) (*models.LeaderboardScore, error) {
+	var score models.LeaderboardScore
+	err := r.db.QueryRow(leaderboardScoreQuery+` WHERE u.id = ?`, userID).Scan(
+		&score.UserID, &score.SchoolID, &score.Smartpoints, &score.SeasonPoints,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &score, nil
+}
+
+// StreamLeaderboardScores recorre los puntajes de todos los usuarios para
+// reconstruir los rankings
+func (r *RankingsRepository) StreamLeaderboardScores(ctx context.Context, fn func(models.LeaderboardScore) error) error {
+	rows, err := r.db.QueryContext(ctx, leaderboardScoreQuery)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var score models.LeaderboardScore
+		if err := rows.Scan(&score.UserID, &score.SchoolID, &score.Smartpoints, &score.SeasonPoints); err != nil {
+			return err
+		}
+		if err := fn(score); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+// GetLeaderboardEntries obtiene los datos de ranking de los usuarios indicados,
+// indexados por user_id. La posición la completa quien llama.
+func (r *RankingsRepository) GetLeaderboardEntries(userIDs []string) (map[string]models.LeaderboardEntry, error) {
+	entries := make(map[string]models.LeaderboardEntry, len(userIDs))
+	if len(userIDs) == 0 {
+		return entries, nil
+	}
+
+	args := make([]interface{}, len(userIDs))
+	for i, id := range userIDs {
+		args[i] = id
+	}
+
+	query := `
+		SELECT u.id, u.username, us.smartpoints, us.rank_tier,
+			   us.total_wins, us.total_losses,
+			   CASE
+				   WHEN (us.total_wins + us.total_losses) > 0
+				   THEN ROUND((us.total_wins * 100.0) / (us.total_wins + us.total_losses), 2)
+				   ELSE 0.00
+			   END,
+			   u.profile_picture_url, s.name, s.id
+		FROM users u
+		JOIN user_stats us ON us.user_id = u.id
+		LEFT JOIN schools s ON s.id = u.school_id
+		WHERE u.id IN (?` + strings.Repeat(", ?", len(userIDs)-1) + `)
+	`
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var entry models.LeaderboardEntry
+		var profilePic, schoolName, schoolID sql.NullString
+		if err := rows.Scan(
+			&entry.UserID,
+			&entry.Username,
+			&entry.Smartpoints,
+			&entry.RankTier,
+			&entry.TotalWins,
+			&entry.TotalLosses,
+			&entry.WinRate,
+			&profilePic,
+			&schoolName,
+			&schoolID,
+		); err != nil {
+			return nil, err
+		}
+
+		if profilePic.Valid {
+			entry.ProfilePictureURL = &profilePic.String
+		}
+		if schoolName.Valid {
+			entry.SchoolName = &schoolName.String
+		}
+		if schoolID.Valid {
+			entry.SchoolID = &schoolID.String
+		}
+
+		entries[entry.UserID] = entry
+	}
+
+	return entries, rows.Err()
+}
+
+// GetSchoolMemberPerformance obtiene el rendimiento de los miembros aprobados
+// de los colegios activos. Con allTime se usan los totales acumulados de
+// user_stats; si no, solo lo sumado desde since en points_ledger. Un miembro
+// es activo si registró actividad desde activeSince. Las métricas se agregan
+// una vez por tabla y se unen por usuario.
+func (r *RankingsRepository) GetSchoolMemberPerformance(allTime bool, since, activeSince time.Time) ([]models.SchoolMemberPerformance, error) {
+	pointsExpr := "us.smartpoints"
+	winsExpr := "us.total_wins"
+	windowJoins := ""
+	var args []interface{}
+	if !allTime {
+		pointsExpr = "COALESCE(wp.points, 0)"
+		winsExpr = "COALESCE(ww.wins, 0)"
+		windowJoins = `
+		LEFT JOIN (
+			SELECT user_id, SUM(points) AS points
+			FROM points_ledger
+			WHERE created_at >= ?
+			GROUP BY user_id
+		) wp ON wp.user_id = u.id
+		LEFT JOIN (
+			SELECT winner_id AS user_id, COUNT(*) AS wins
+			FROM pvp_matches
+			WHERE status = 'completed' AND completed_at >= ?
+			GROUP BY winner_id
+		) ww ON ww.user_id = u.id`
+		args = append(args, since, since)
+	}
+	args = append(args, activeSince, activeSince, activeSince, activeSince, activeSince)
+
+	query := `
+		SELECT s.id, s.name, COALESCE(s.location, ''), u.id,
+			` + pointsExpr + `,
+			` + winsExpr + `,
+			act.user_id IS NOT NULL AS is_active
+		FROM schools s
+		INNER JOIN users u ON u.school_id = s.id
+		INNER JOIN user_stats us ON us.user_id = u.id` + windowJoins + `
+		LEFT JOIN (
+			SELECT user_id FROM quiz_attempts WHERE completed_at >= ?
+			UNION SELECT user_id FROM simulator_attempts WHERE created_at >= ?
+			UNION SELECT user_id FROM user_lesson_progress WHERE completed_at >= ?
+			UNION SELECT player1_id FROM pvp_matches WHERE status = 'completed' AND completed_at >= ?
+			UNION SELECT player2_id FROM pvp_matches WHERE status = 'completed' AND completed_at >= ?
+		) act ON act.user_id = u.id
+		WHERE s.is_active = TRUE
+		ORDER BY s.id
+	`
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var members []models.SchoolMemberPerformance
+	for rows.Next() {
+		var m models.SchoolMemberPerformance
+		if err := rows.Scan(
+			&m.SchoolID,
+			&m.SchoolName,
+			&m.Location,
+			&m.UserID,
+			&m.Points,
+			&m.PvPWins,
+			&m.Active,
+		); err != nil {
+			return nil, err
+		}
+		members = append(members, m)
+	}
+
+	return members, rows.Err()
+}
+
 // === ACHIEVEMENTS ===
 
 // GetUserAchievements obtiene los logros de un usuario
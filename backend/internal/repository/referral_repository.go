@@ -0,0 +1,340 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/smartstocks/backend/internal/models"
+)
+
+type ReferralRepository struct {
+	db *sql.DB
+}
+
+func NewReferralRepository(db *sql.DB) *ReferralRepository {
+	return &ReferralRepository{db: db}
+}
+
+// GetCode obtiene el código de referido del usuario ("" si todavía no tiene)
+func (r *ReferralRepository) GetCode(userID string) (string, error) {
+	var code sql.NullString
+	err := r.db.QueryRow(`SELECT referral_code FROM users WHERE id = ?`, userID).Scan(&code)
+	if err != nil {
+		return "", err
+	}
+	return code.String, nil
+}
+
+// SetCode asigna el código si el usuario no tiene uno. Devuelve false si el
+// código ya lo usa otro usuario o si el usuario ya tenía código.
+func (r *ReferralRepository) SetCode(userID, code string) (bool, error) {
+	result, err := r.db.Exec(`
+		UPDATE IGNORE users SET referral_code = ? WHERE id = ? AND referral_code IS NULL
+	`, code, userID)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
+
+// GetUserIDByCode obtiene el dueño del código ("" si no existe)
+func (r *ReferralRepository) GetUserIDByCode(code string) (string, error) {
+	var userID string
+	err := r.db.QueryRow(`SELECT id FROM users WHERE referral_code = ?`, code).Scan(&userID)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return userID, err
+}
+
+// CreateReferral registra que el invitado se registró con el código
+func (r *ReferralRepository) CreateReferral(referral *models.Referral) error {
+	referral.Status = models.ReferralPending
+	referral.CreatedAt = time.Now()
+
+	_, err := r.db.Exec(`
+		INSERT INTO referrals (id, referrer_id, referee_id, code, status)
+		VALUES (?, ?, ?, ?, ?)
+	`, referral.ID, referral.ReferrerID, referral.RefereeID, referral.Code, referral.Status)
+	return err
+}
+
+// GetPendingByReferee obtiene el referido pendiente o diferido del invitado
+func (r *ReferralRepository) GetPendingByReferee(refereeID string) (*models.Referral, error) {
+	row := r.db.QueryRow(`
+		SELECT `+referralColumns+`
+		FROM referrals f
+		JOIN users u ON u.id = f.referee_id
+		WHERE f.referee_id = ? AND f.status IN ('pending', 'deferred')
+	`, refereeID)
+
+	referral, err := scanReferral(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return referral, err
+}
+
+// IsQualified verifica si el invitado verificó su email y completó un quiz
+func (r *ReferralRepository) IsQualified(refereeID string) (bool, error) {
+	var qualified bool
+	err := r.db.QueryRow(`
+		SELECT u.email_verified AND EXISTS(SELECT 1 FROM quiz_attempts qa WHERE qa.user_id = u.id)
+		FROM users u
+		WHERE u.id = ?
+	`, refereeID).Scan(&qualified)
+	return qualified, err
+}
+
+// GetDeferredRefereeIDs obtiene los invitados con la recompensa diferida,
+// los más antiguos primero
+func (r *ReferralRepository) GetDeferredRefereeIDs(limit int) ([]string, error) {
+	rows, err := r.db.Query(`
+		SELECT referee_id FROM referrals
+		WHERE status = 'deferred'
+		ORDER BY created_at
+		LIMIT ?
+	`, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	refereeIDs := []string{}
+	for rows.Next() {
+		var refereeID string
+		if err := rows.Scan(&refereeID); err != nil {
+			return nil, err
+		}
+		refereeIDs = append(refereeIDs, refereeID)
+	}
+
+	return refereeIDs, rows.Err()
+}
+
+// Reject descarta la recompensa del referido pendiente o diferido
+func (r *ReferralRepository) Reject(referral *models.Referral, reason string) error {
+	_, err := r.db.Exec(`
+		UPDATE referrals SET status = 'rejected', reject_reason = ?, resolved_at = NOW()
+		WHERE id = ? AND status IN ('pending', 'deferred')
+	`, reason, referral.ID)
+	if err != nil {
+		return err
+	}
+
+	referral.Status = models.ReferralRejected
+	referral.RejectReason = &reason
+	return nil
+}
+
+// RewardReferral acredita la recompensa al que invitó y ejecuta apply (ej.
+// la notificación) en la misma transacción. Si el que invitó ya alcanzó el
+// límite total, el referido queda rechazado; si alcanzó el mensual, queda
+// diferido hasta que el mes siguiente se vuelva a evaluar. Si otro pedido
+// ya resolvió el referido, no hace nada. El resultado queda en
+// referral.Status.
+func (r *ReferralRepository) RewardReferral(referral *models.Referral, rewardTokens, monthlyLimit, totalLimit int, apply func(tx *sql.Tx) error) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Bloquear al que invitó serializa las recompensas de sus referidos, así
+	// dos invitados que califican a la vez no superan el límite
+	var lockedID string
+	if err := tx.QueryRow(`SELECT id FROM users WHERE id = ? FOR UPDATE`, referral.ReferrerID).Scan(&lockedID); err != nil {
+		return err
+	}
+
+	var status string
+	err = tx.QueryRow(`SELECT status FROM referrals WHERE id = ? FOR UPDATE`, referral.ID).Scan(&status)
+	if err != nil {
+		return err
+	}
+	if status != models.ReferralPending && status != models.ReferralDeferred {
+		referral.Status = status
+		return nil
+	}
+
+	var rewardedMonth, rewardedTotal int
+	err = tx.QueryRow(`
+		SELECT
+			COALESCE(SUM(resolved_at >= DATE_FORMAT(NOW(), '%Y-%m-01')), 0),
+			COUNT(*)
+		FROM referrals
+		WHERE referrer_id = ? AND status = 'rewarded'
+	`, referral.ReferrerID).Scan(&rewardedMonth, &rewardedTotal)
+	if err != nil {
+		return err
+	}
+
+	if rewardedTotal >= totalLimit {
+		reason := models.ReferralRejectTotalLimit
+		_, err = tx.Exec(`
+			UPDATE referrals SET status = 'rejected', reject_reason = ?, resolved_at = NOW()
+			WHERE id = ?
+		`, reason, referral.ID)
+		if err != nil {
+			return err
+		}
+		referral.Status = models.ReferralRejected
+		referral.RejectReason = &reason
+		return tx.Commit()
+	}
+
+	if rewardedMonth >= monthlyLimit {
+		reason := models.ReferralRejectMonthlyLimit
+		_, err = tx.Exec(`
+			UPDATE referrals SET status = 'deferred', reject_reason = ?
+			WHERE id = ?
+		`, reason, referral.ID)
+		if err != nil {
+			return err
+		}
+		referral.Status = models.ReferralDeferred
+		referral.RejectReason = &reason
+		return tx.Commit()
+	}
+
+	key := "referral_reward:" + referral.ID
+	if _, err := callTokenProcedure(tx, "credit_tokens", referral.ReferrerID, rewardTokens,
+		"referral_reward", "Recompensa por invitar a "+referral.RefereeUsername, &referral.ID, key); err != nil {
+		return err
+	}
+
+	var transactionID string
+	err = tx.QueryRow(`SELECT id FROM token_transactions WHERE idempotency_key = ?`, key).Scan(&transactionID)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`
+		UPDATE referrals
+		SET status = 'rewarded', reject_reason = NULL, reward_tokens = ?, transaction_id = ?, resolved_at = NOW()
+		WHERE id = ?
+	`, rewardTokens, transactionID, referral.ID)
+	if err != nil {
+		return err
+	}
+
+	if apply != nil {
+		if err := apply(tx); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	referral.Status = models.ReferralRewarded
+	referral.RejectReason = nil
+	referral.RewardTokens = rewardTokens
+	referral.TransactionID = &transactionID
+	referral.ResolvedAt = &now
+	return nil
+}
+
+// GetReferrals obtiene los últimos referidos del usuario
+func (r *ReferralRepository) GetReferrals(referrerID string, limit int) ([]models.Referral, error) {
+	if limit <= 0 || limit > 100 {
+		limit = 50
+	}
+
+	rows, err := r.db.Query(`
+		SELECT `+referralColumns+`
+		FROM referrals f
+		JOIN users u ON u.id = f.referee_id
+		WHERE f.referrer_id = ?
+		ORDER BY f.created_at DESC
+		LIMIT ?
+	`, referrerID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	referrals := []models.Referral{}
+	for rows.Next() {
+		referral, err := scanReferral(rows)
+		if err != nil {
+			return nil, err
+		}
+		referrals = append(referrals, *referral)
+	}
+
+	return referrals, rows.Err()
+}
+
+// GetSummary obtiene los totales de referidos del usuario y cuántos se
+// recompensaron en el mes
+func (r *ReferralRepository) GetSummary(referrerID string) (*models.ReferralSummary, int, error) {
+	var summary models.ReferralSummary
+	var rewardedMonth int
+	err := r.db.QueryRow(`
+		SELECT
+			COUNT(*),
+			COALESCE(SUM(status = 'pending'), 0),
+			COALESCE(SUM(status = 'deferred'), 0),
+			COALESCE(SUM(status = 'rewarded'), 0),
+			COALESCE(SUM(status = 'rejected'), 0),
+			COALESCE(SUM(reward_tokens), 0),
+			COALESCE(SUM(status = 'rewarded' AND resolved_at >= DATE_FORMAT(NOW(), '%Y-%m-01')), 0)
+		FROM referrals
+		WHERE referrer_id = ?
+	`, referrerID).Scan(
+		&summary.Total,
+		&summary.Pending,
+		&summary.Deferred,
+		&summary.Rewarded,
+		&summary.Rejected,
+		&summary.TokensEarned,
+		&rewardedMonth,
+	)
+	if err != nil {
+		return nil, 0, err
+	}
+	return &summary, rewardedMonth, nil
+}
+
+const referralColumns = `
+	f.id, f.referrer_id, f.referee_id, u.username, f.code, f.status, f.reject_reason,
+	f.reward_tokens, f.transaction_id, f.created_at, f.resolved_at`
+
+func scanReferral(row rowScanner) (*models.Referral, error) {
+	var referral models.Referral
+	var rejectReason, transactionID sql.NullString
+	var resolvedAt sql.NullTime
+	if err := row.Scan(
+		&referral.ID,
+		&referral.ReferrerID,
+		&referral.RefereeID,
+		&referral.RefereeUsername,
+		&referral.Code,
+		&referral.Status,
+		&rejectReason,
+		&referral.RewardTokens,
+		&transactionID,
+		&referral.CreatedAt,
+		&resolvedAt,
+	); err != nil {
+		return nil, err
+	}
+	if rejectReason.Valid {
+		referral.RejectReason = &rejectReason.String
+	}
+	if transactionID.Valid {
+		referral.TransactionID = &transactionID.String
+	}
+	if resolvedAt.Valid {
+		referral.ResolvedAt = &resolvedAt.Time
+	}
+	return &referral, nil
+}
@@ -2,6 +2,7 @@ package repository
 
 import (
 	"database/sql"
+	"time"
 
 	"github.com/smartstocks/backend/internal/models"
 )
@@ -69,3 +70,331 @@ func (r *SchoolRepository) GetSchoolByID(schoolID string) (*models.School, error
 
 	return school, err
 }
+
+// === ADMINISTRACIÓN ===
+
+// CreateSchool da de alta un colegio con sus dominios de email
+func (r *SchoolRepository) CreateSchool(school *models.SchoolDetail) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// El id lo genera la base (DEFAULT UUID()), así que se lee después del insert
+	_, err = tx.Exec(`
+		INSERT INTO schools (name, location, join_code) VALUES (?, ?, ?)
+	`, school.Name, school.Location, school.JoinCode)
+	if err != nil {
+		return err
+	}
+
+	err = tx.QueryRow(`SELECT id, is_active, created_at, updated_at FROM schools WHERE join_code = ?`, school.JoinCode).
+		Scan(&school.ID, &school.IsActive, &school.CreatedAt, &school.UpdatedAt)
+	if err != nil {
+		return err
+	}
+
+	if err := insertEmailDomains(tx, school.ID, school.EmailDomains); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// GetSchoolDetail obtiene un colegio con su código, dominios y cantidad de miembros (nil si no existe)
+func (r *SchoolRepository) GetSchoolDetail(schoolID string) (*models.SchoolDetail, error) {
+	query := `
+		SELECT s.id, s.name, COALESCE(s.location, ''), s.is_active, s.created_at, s.updated_at,
+			   COALESCE(s.join_code, ''),
+			   (SELECT COUNT(*) FROM school_memberships m WHERE m.school_id = s.id AND m.status = 'approved'),
+			   (SELECT COUNT(*) FROM school_memberships m WHERE m.school_id = s.id AND m.status = 'pending')
+		FROM schools s
+		WHERE s.id = ?
+	`
+
+	var school models.SchoolDetail
+	err := r.db.QueryRow(query, schoolID).Scan(
+		&school.ID, &school.Name, &school.Location, &school.IsActive, &school.CreatedAt, &school.UpdatedAt,
+		&school.JoinCode, &school.MemberCount, &school.PendingCount,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	school.EmailDomains, err = r.GetEmailDomains(schoolID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &school, nil
+}
+
+// GetSchoolByJoinCode busca un colegio por su código de acceso (nil si no existe)
+func (r *SchoolRepository) GetSchoolByJoinCode(joinCode string) (*models.School, error) {
+	school := &models.School{}
+	query := `
+		SELECT id, name, COALESCE(location, ''), is_active, created_at
+		FROM schools WHERE join_code = ?
+	`
+
+	err := r.db.QueryRow(query, joinCode).Scan(
+		&school.ID,
+		&school.Name,
+		&school.Location,
+		&school.IsActive,
+		&school.CreatedAt,
+	)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+
+	return school, err
+}
+
+// UpdateSchool actualiza nombre y ubicación
+func (r *SchoolRepository) UpdateSchool(schoolID string, req *models.UpdateSchoolRequest) error {
+	query := `
+		UPDATE schools
+		SET name = COALESCE(?, name),
+			location = COALESCE(?, location)
+		WHERE id = ?
+	`
+	_, err := r.db.Exec(query, req.Name, req.Location, schoolID)
+	return err
+}
+
+// SetSchoolActive activa o desactiva un colegio
+func (r *SchoolRepository) SetSchoolActive(schoolID string, active bool) error {
+	_, err := r.db.Exec(`UPDATE schools SET is_active = ? WHERE id = ?`, active, schoolID)
+	return err
+}
+
+// UpdateJoinCode reemplaza el código de acceso (NULL lo deshabilita)
+func (r *SchoolRepository) UpdateJoinCode(schoolID string, joinCode sql.NullString) error {
+	_, err := r.db.Exec(`UPDATE schools SET join_code = ? WHERE id = ?`, joinCode, schoolID)
+	return err
+}
+
+// === DOMINIOS DE EMAIL ===
+
+// GetEmailDomains lista los dominios institucionales del colegio
+func (r *SchoolRepository) GetEmailDomains(schoolID string) ([]string, error) {
+	rows, err := r.db.Query(`SELECT domain FROM school_email_domains WHERE school_id = ? ORDER BY domain`, schoolID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	domains := []string{}
+	for rows.Next() {
+		var domain string
+		if err := rows.Scan(&domain); err != nil {
+			return nil, err
+		}
+		domains = append(domains, domain)
+	}
+
+	return domains, rows.Err()
+}
+
+// ReplaceEmailDomains reemplaza los dominios del colegio
+func (r *SchoolRepository) ReplaceEmailDomains(schoolID string, domains []string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM school_email_domains WHERE school_id = ?`, schoolID); err != nil {
+		return err
+	}
+	if err := insertEmailDomains(tx, schoolID, domains); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// GetSchoolIDByEmailDomain devuelve el colegio dueño del dominio ("" si ninguno)
+func (r *SchoolRepository) GetSchoolIDByEmailDomain(domain string) (string, error) {
+	var schoolID string
+	err := r.db.QueryRow(`SELECT school_id FROM school_email_domains WHERE domain = ?`, domain).Scan(&schoolID)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return schoolID, err
+}
+
+// === ADMINISTRADORES ===
+
+// IsSchoolAdmin verifica si el usuario administra el colegio
+func (r *SchoolRepository) IsSchoolAdmin(schoolID, userID string) (bool, error) {
+	var exists bool
+	query := `SELECT EXISTS(SELECT 1 FROM school_admins WHERE school_id = ? AND user_id = ?)`
+	err := r.db.QueryRow(query, schoolID, userID).Scan(&exists)
+	return exists, err
+}
+
+// AddSchoolAdmin designa un administrador del colegio
+func (r *SchoolRepository) AddSchoolAdmin(schoolID, userID string) error {
+	_, err := r.db.Exec(`INSERT IGNORE INTO school_admins (school_id, user_id) VALUES (?, ?)`, schoolID, userID)
+	return err
+}
+
+// RemoveSchoolAdmin quita un administrador. Devuelve false si no lo era.
+func (r *SchoolRepository) RemoveSchoolAdmin(schoolID, userID string) (bool, error) {
+	result, err := r.db.Exec(`DELETE FROM school_admins WHERE school_id = ? AND user_id = ?`, schoolID, userID)
+	if err != nil {
+		return false, err
+	}
+
+	rows, err := result.RowsAffected()
+	return rows > 0, err
+}
+
+// === MEMBRESÍAS ===
+
+// GetMembership obtiene la membresía del usuario (nil si no tiene)
+func (r *SchoolRepository) GetMembership(userID string) (*models.SchoolMembership, error) {
+	query := `
+		SELECT m.user_id, m.school_id, m.status, m.method, m.requested_at, m.reviewed_by, m.reviewed_at,
+			   s.name, u.username, u.email, u.email_verified
+		FROM school_memberships m
+		JOIN schools s ON m.school_id = s.id
+		JOIN users u ON m.user_id = u.id
+		WHERE m.user_id = ?
+	`
+
+	membership, err := scanMembership(r.db.QueryRow(query, userID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+
+	return membership, err
+}
+
+// GetSchoolMemberships lista las membresías del colegio, opcionalmente filtradas por estado
+func (r *SchoolRepository) GetSchoolMemberships(schoolID, status string) ([]models.SchoolMembership, error) {
+	query := `
+		SELECT m.user_id, m.school_id, m.status, m.method, m.requested_at, m.reviewed_by, m.reviewed_at,
+			   s.name, u.username, u.email, u.email_verified
+		FROM school_memberships m
+		JOIN schools s ON m.school_id = s.id
+		JOIN users u ON m.user_id = u.id
+		WHERE m.school_id = ? AND (? = '' OR m.status = ?)
+		ORDER BY m.status = 'pending' DESC, m.requested_at DESC
+	`
+
+	rows, err := r.db.Query(query, schoolID, status, status)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	memberships := []models.SchoolMembership{}
+	for rows.Next() {
+		membership, err := scanMembership(rows)
+		if err != nil {
+			return nil, err
+		}
+		memberships = append(memberships, *membership)
+	}
+
+	return memberships, rows.Err()
+}
+
+// SaveMembership guarda la membresía y sincroniza users.school_id: solo se
+// completa cuando la membresía está aprobada
+func (r *SchoolRepository) SaveMembership(membership *models.SchoolMembership) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`
+		INSERT INTO school_memberships (user_id, school_id, status, method, requested_at, reviewed_by, reviewed_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE
+			school_id = VALUES(school_id),
+			status = VALUES(status),
+			method = VALUES(method),
+			requested_at = VALUES(requested_at),
+			reviewed_by = VALUES(reviewed_by),
+			reviewed_at = VALUES(reviewed_at)
+	`,
+		membership.UserID,
+		membership.SchoolID,
+		membership.Status,
+		membership.Method,
+		membership.RequestedAt,
+		membership.ReviewedBy,
+		membership.ReviewedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	schoolID := sql.NullString{}
+	if membership.Status == models.MembershipStatusApproved {
+		schoolID = sql.NullString{String: membership.SchoolID, Valid: true}
+	}
+	if _, err := tx.Exec(`UPDATE users SET school_id = ?, updated_at = ? WHERE id = ?`, schoolID, time.Now(), membership.UserID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// DeleteMembership elimina la membresía y desvincula al usuario del colegio.
+// Devuelve false si no tenía membresía.
+func (r *SchoolRepository) DeleteMembership(userID string) (bool, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`DELETE FROM school_memberships WHERE user_id = ?`, userID)
+	if err != nil {
+		return false, err
+	}
+	if _, err := tx.Exec(`UPDATE users SET school_id = NULL, updated_at = ? WHERE id = ?`, time.Now(), userID); err != nil {
+		return false, err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return rows > 0, tx.Commit()
+}
+
+// === HELPERS ===
+
+func insertEmailDomains(tx *sql.Tx, schoolID string, domains []string) error {
+	for _, domain := range domains {
+		if _, err := tx.Exec(`INSERT INTO school_email_domains (domain, school_id) VALUES (?, ?)`, domain, schoolID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func scanMembership(row rowScanner) (*models.SchoolMembership, error) {
+	var m models.SchoolMembership
+	err := row.Scan(
+		&m.UserID, &m.SchoolID, &m.Status, &m.Method, &m.RequestedAt, &m.ReviewedBy, &m.ReviewedAt,
+		&m.SchoolName, &m.Username, &m.Email, &m.EmailVerified,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
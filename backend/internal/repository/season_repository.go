@@ -0,0 +1,363 @@
+package repository
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/smartstocks/backend/internal/models"
+)
+
+type SeasonRepository struct {
+	db *sql.DB
+}
+
+func NewSeasonRepository(db *sql.DB) *SeasonRepository {
+	return &SeasonRepository{db: db}
+}
+
+const seasonColumns = `
+	id, name, starts_at, ends_at, carryover_percent, status,
+	activated_at, finalized_at, created_by, created_at
+`
+
+// === TEMPORADAS ===
+
+// CreateSeason crea una temporada programada con sus premios
+func (r *SeasonRepository) CreateSeason(season *models.Season) error {
+	season.ID = uuid.New().String()
+	season.Status = models.SeasonStatusScheduled
+	season.CreatedAt = time.Now()
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`
+		INSERT INTO seasons (id, name, starts_at, ends_at, carryover_percent, status, created_by)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`, season.ID, season.Name, season.StartsAt, season.EndsAt, season.CarryoverPercent, season.Status, season.CreatedBy)
+	if err != nil {
+		return err
+	}
+
+	for _, reward := range season.Rewards {
+		_, err = tx.Exec(`
+			INSERT INTO season_rewards (id, season_id, position_from, position_to, token_reward)
+			VALUES (?, ?, ?, ?, ?)
+		`, uuid.New().String(), season.ID, reward.PositionFrom, reward.PositionTo, reward.TokenReward)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// GetSeasonByID obtiene una temporada con sus premios (nil si no existe)
+func (r *SeasonRepository) GetSeasonByID(seasonID string) (*models.Season, error) {
+	season, err := scanSeason(r.db.QueryRow(`SELECT `+seasonColumns+` FROM seasons WHERE id = ?`, seasonID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := r.loadRewards([]*models.Season{season}); err != nil {
+		return nil, err
+	}
+	return season, nil
+}
+
+// GetActiveSeason obtiene la temporada en curso (nil si no hay)
+func (r *SeasonRepository) GetActiveSeason() (*models.Season, error) {
+	season, err := scanSeason(r.db.QueryRow(`SELECT `+seasonColumns+` FROM seasons WHERE status = ?`, models.SeasonStatusActive))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := r.loadRewards([]*models.Season{season}); err != nil {
+		return nil, err
+	}
+	return season, nil
+}
+
+// GetSeasons lista todas las temporadas, las más recientes primero
+func (r *SeasonRepository) GetSeasons() ([]models.Season, error) {
+	rows, err := r.db.Query(`SELECT ` + seasonColumns + ` FROM seasons ORDER BY starts_at DESC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	seasons := []models.Season{}
+	for rows.Next() {
+		season, err := scanSeason(rows)
+		if err != nil {
+			return nil, err
+		}
+		seasons = append(seasons, *season)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	ptrs := make([]*models.Season, len(seasons))
+	for i := range seasons {
+		ptrs[i] = &seasons[i]
+	}
+	if err := r.loadRewards(ptrs); err != nil {
+		return nil, err
+	}
+	return seasons, nil
+}
+
+// HasOverlappingSeason indica si alguna temporada se superpone con [startsAt, endsAt)
+func (r *SeasonRepository) HasOverlappingSeason(startsAt, endsAt time.Time) (bool, error) {
+	var exists bool
+	err := r.db.QueryRow(`
+		SELECT EXISTS(SELECT 1 FROM seasons WHERE starts_at < ? AND ends_at > ?)
+	`, endsAt, startsAt).Scan(&exists)
+	return exists, err
+}
+
+// DeleteScheduledSeason elimina una temporada que todavía no empezó
+func (r *SeasonRepository) DeleteScheduledSeason(seasonID string) (bool, error) {
+	result, err := r.db.Exec(`DELETE FROM seasons WHERE id = ? AND status = ?`, seasonID, models.SeasonStatusScheduled)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	return affected > 0, err
+}
+
+// ProcessRollover cierra la temporada vencida y activa la siguiente
+func (r *SeasonRepository) ProcessRollover() error {
+	_, err := r.db.Exec(`CALL process_season_rollover()`)
+	return err
+}
+
+// === POSICIONES EN VIVO ===
+
+// liveStandingsQuery posiciones de la temporada en curso según user_stats
+const liveStandingsQuery = `
+	SELECT ranked.position, ranked.user_id, ranked.username, ranked.profile_picture_url,
+		   ranked.season_points, calculate_rank_tier(ranked.season_points)
+	FROM (
+		SELECT u.id AS user_id, u.username, u.profile_picture_url, us.season_points,
+			   ROW_NUMBER() OVER (ORDER BY us.season_points DESC, u.created_at ASC) AS position
+		FROM user_stats us
+		JOIN users u ON u.id = us.user_id
+		WHERE us.season_points > 0
+	) ranked
+`
+
+// GetLiveStandings ranking de la temporada en curso
+func (r *SeasonRepository) GetLiveStandings(limit, offset int) ([]models.SeasonStanding, error) {
+	return r.queryStandings(liveStandingsQuery+` ORDER BY ranked.position LIMIT ? OFFSET ?`, false, limit, offset)
+}
+
+// GetLiveStanding posición del usuario en la temporada en curso (nil si no sumó puntos)
+func (r *SeasonRepository) GetLiveStanding(userID string) (*models.SeasonStanding, error) {
+	standings, err := r.queryStandings(liveStandingsQuery+` WHERE ranked.user_id = ?`, false, userID)
+	if err != nil || len(standings) == 0 {
+		return nil, err
+	}
+	return &standings[0], nil
+}
+
+// CountLivePlayers jugadores con puntos en la temporada en curso
+func (r *SeasonRepository) CountLivePlayers() (int, error) {
+	var count int
+	err := r.db.QueryRow(`SELECT COUNT(*) FROM user_stats WHERE season_points > 0`).Scan(&count)
+	return count, err
+}
+
+// GetLiveStandingsByUserIDs datos de temporada de los usuarios indicados,
+// indexados por user_id. La posición la completa quien llama (ranking en Redis).
+func (r *SeasonRepository) GetLiveStandingsByUserIDs(userIDs []string) (map[string]models.SeasonStanding, error) {
+	byID := make(map[string]models.SeasonStanding, len(userIDs))
+	if len(userIDs) == 0 {
+		return byID, nil
+	}
+
+	args := make([]interface{}, len(userIDs))
+	for i, id := range userIDs {
+		args[i] = id
+	}
+
+	standings, err := r.queryStandings(`
+		SELECT 0, u.id, u.username, u.profile_picture_url,
+			   us.season_points, calculate_rank_tier(us.season_points)
+		FROM user_stats us
+		JOIN users u ON u.id = us.user_id
+		WHERE u.id IN (?`+strings.Repeat(", ?", len(userIDs)-1)+`)
+	`, false, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, s := range standings {
+		byID[s.UserID] = s
+	}
+	return byID, nil
+}
+
+// === POSICIONES ARCHIVADAS ===
+
+const archivedStandingsQuery = `
+	SELECT ss.final_position, ss.user_id, u.username, u.profile_picture_url,
+		   ss.season_points, ss.rank_tier, ss.tokens_awarded
+	FROM season_standings ss
+	JOIN users u ON u.id = ss.user_id
+	WHERE ss.season_id = ?
+`
+
+// GetArchivedStandings posiciones finales de una temporada cerrada
+func (r *SeasonRepository) GetArchivedStandings(seasonID string, limit, offset int) ([]models.SeasonStanding, error) {
+	return r.queryStandings(archivedStandingsQuery+` ORDER BY ss.final_position LIMIT ? OFFSET ?`, true, seasonID, limit, offset)
+}
+
+// GetArchivedStanding posición final del usuario en una temporada (nil si no participó)
+func (r *SeasonRepository) GetArchivedStanding(seasonID, userID string) (*models.SeasonStanding, error) {
+	standings, err := r.queryStandings(archivedStandingsQuery+` AND ss.user_id = ?`, true, seasonID, userID)
+	if err != nil || len(standings) == 0 {
+		return nil, err
+	}
+	return &standings[0], nil
+}
+
+// CountArchivedPlayers participantes de una temporada cerrada
+func (r *SeasonRepository) CountArchivedPlayers(seasonID string) (int, error) {
+	var count int
+	err := r.db.QueryRow(`SELECT COUNT(*) FROM season_standings WHERE season_id = ?`, seasonID).Scan(&count)
+	return count, err
+}
+
+// GetUserSeasonHistory resultados finales del usuario en cada temporada jugada
+func (r *SeasonRepository) GetUserSeasonHistory(userID string) ([]models.SeasonHistoryEntry, error) {
+	rows, err := r.db.Query(`
+		SELECT s.id, s.name, s.starts_at, s.ends_at,
+			   ss.final_position, ss.season_points, ss.rank_tier, ss.tokens_awarded,
+			   (SELECT COUNT(*) FROM season_standings t WHERE t.season_id = s.id)
+		FROM season_standings ss
+		JOIN seasons s ON s.id = ss.season_id
+		WHERE ss.user_id = ?
+		ORDER BY s.starts_at DESC
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	history := []models.SeasonHistoryEntry{}
+	for rows.Next() {
+		var h models.SeasonHistoryEntry
+		if err := rows.Scan(
+			&h.SeasonID, &h.SeasonName, &h.StartsAt, &h.EndsAt,
+			&h.FinalPosition, &h.SeasonPoints, &h.RankTier, &h.TokensAwarded,
+			&h.TotalPlayers,
+		); err != nil {
+			return nil, err
+		}
+		history = append(history, h)
+	}
+
+	return history, rows.Err()
+}
+
+// === HELPERS ===
+
+// loadRewards completa los premios de las temporadas con una sola consulta
+func (r *SeasonRepository) loadRewards(seasons []*models.Season) error {
+	if len(seasons) == 0 {
+		return nil
+	}
+
+	byID := make(map[string]*models.Season, len(seasons))
+	for _, s := range seasons {
+		s.Rewards = []models.SeasonReward{}
+		byID[s.ID] = s
+	}
+
+	query := `SELECT season_id, position_from, position_to, token_reward FROM season_rewards`
+	var args []interface{}
+	if len(seasons) == 1 {
+		query += ` WHERE season_id = ?`
+		args = append(args, seasons[0].ID)
+	}
+	query += ` ORDER BY position_from`
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var seasonID string
+		var reward models.SeasonReward
+		if err := rows.Scan(&seasonID, &reward.PositionFrom, &reward.PositionTo, &reward.TokenReward); err != nil {
+			return err
+		}
+		if s, ok := byID[seasonID]; ok {
+			s.Rewards = append(s.Rewards, reward)
+		}
+	}
+
+	return rows.Err()
+}
+
+func (r *SeasonRepository) queryStandings(query string, archived bool, args ...interface{}) ([]models.SeasonStanding, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	standings := []models.SeasonStanding{}
+	for rows.Next() {
+		var s models.SeasonStanding
+		var profilePic sql.NullString
+		dest := []interface{}{&s.RankPosition, &s.UserID, &s.Username, &profilePic, &s.SeasonPoints, &s.RankTier}
+		if archived {
+			dest = append(dest, &s.TokensAwarded)
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+		if profilePic.Valid {
+			s.ProfilePictureURL = &profilePic.String
+		}
+		standings = append(standings, s)
+	}
+
+	return standings, rows.Err()
+}
+
+func scanSeason(row rowScanner) (*models.Season, error) {
+	s := &models.Season{}
+	err := row.Scan(
+		&s.ID,
+		&s.Name,
+		&s.StartsAt,
+		&s.EndsAt,
+		&s.CarryoverPercent,
+		&s.Status,
+		&s.ActivatedAt,
+		&s.FinalizedAt,
+		&s.CreatedBy,
+		&s.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
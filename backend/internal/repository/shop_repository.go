@@ -0,0 +1,339 @@
+package repository
+
+import (
+	"database/sql"
+	"strings"
+
+	"github.com/smartstocks/backend/internal/models"
+)
+
+type ShopRepository struct {
+	db *sql.DB
+}
+
+func NewShopRepository(db *sql.DB) *ShopRepository {
+	return &ShopRepository{db: db}
+}
+
+const shopItemColumns = `
+	id, item_type, code, name, description, price, asset_url, style_value,
+	available_from, available_until, is_active, sort_order, created_at`
+
+func scanShopItem(scanner interface{ Scan(...interface{}) error }) (*models.ShopItem, error) {
+	item := &models.ShopItem{}
+	var description, assetURL, styleValue sql.NullString
+	var from, until sql.NullTime
+
+	if err := scanner.Scan(
+		&item.ID,
+		&item.ItemType,
+		&item.Code,
+		&item.Name,
+		&description,
+		&item.Price,
+		&assetURL,
+		&styleValue,
+		&from,
+		&until,
+		&item.IsActive,
+		&item.SortOrder,
+		&item.CreatedAt,
+	); err != nil {
+		return nil, err
+	}
+
+	if description.Valid {
+		item.Description = &description.String
+	}
+	if assetURL.Valid {
+		item.AssetURL = &assetURL.String
+	}
+	if styleValue.Valid {
+		item.StyleValue = &styleValue.String
+	}
+	if from.Valid {
+		item.AvailableFrom = &from.Time
+	}
+	if until.Valid {
+		item.AvailableUntil = &until.Time
+	}
+
+	return item, nil
+}
+
+// GetCatalog obtiene los ítems activos cuya ventana de venta no terminó
+// (incluye los que todavía no empezaron)
+func (r *ShopRepository) GetCatalog() ([]models.ShopItem, error) {
+	rows, err := r.db.Query(`
+		SELECT` + shopItemColumns + `
+		FROM shop_items
+		WHERE is_active = TRUE
+		  AND (available_until IS NULL OR available_until > NOW())
+		ORDER BY item_type, sort_order, name
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []models.ShopItem{}
+	for rows.Next() {
+		item, err := scanShopItem(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, *item)
+	}
+
+	return items, rows.Err()
+}
+
+// GetItemByID obtiene un ítem del catálogo (nil si no existe)
+func (r *ShopRepository) GetItemByID(itemID string) (*models.ShopItem, error) {
+	item, err := scanShopItem(r.db.QueryRow(`
+		SELECT`+shopItemColumns+`
+		FROM shop_items
+		WHERE id = ?
+	`, itemID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return item, err
+}
+
+// CodeExists verifica si ya hay un ítem con ese código
+func (r *ShopRepository) CodeExists(code string) (bool, error) {
+	var exists bool
+	err := r.db.QueryRow(`SELECT EXISTS(SELECT 1 FROM shop_items WHERE code = ?)`, code).Scan(&exists)
+	return exists, err
+}
+
+// CreateItem da de alta un ítem en el catálogo
+func (r *ShopRepository) CreateItem(item *models.ShopItem) error {
+	_, err := r.db.Exec(`
+		INSERT INTO shop_items (
+			id, item_type, code, name, description, price, asset_url, style_value,
+			available_from, available_until, is_active, sort_order
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, item.ID, item.ItemType, item.Code, item.Name, item.Description, item.Price,
+		item.AssetURL, item.StyleValue, item.AvailableFrom, item.AvailableUntil,
+		item.IsActive, item.SortOrder)
+	return err
+}
+
+// UpdateItem actualiza los datos editables de un ítem
+func (r *ShopRepository) UpdateItem(item *models.ShopItem) error {
+	_, err := r.db.Exec(`
+		UPDATE shop_items
+		SET name = ?, description = ?, price = ?, asset_url = ?, style_value = ?,
+			available_from = ?, available_until = ?, is_active = ?, sort_order = ?
+		WHERE id = ?
+	`, item.Name, item.Description, item.Price, item.AssetURL, item.StyleValue,
+		item.AvailableFrom, item.AvailableUntil, item.IsActive, item.SortOrder, item.ID)
+	return err
+}
+
+// GetOwnedItemIDs obtiene los ids de los ítems que tiene el usuario
+func (r *ShopRepository) GetOwnedItemIDs(userID string) (map[string]bool, error) {
+	rows, err := r.db.Query(`SELECT item_id FROM user_inventory WHERE user_id = ?`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	owned := make(map[string]bool)
+	for rows.Next() {
+		var itemID string
+		if err := rows.Scan(&itemID); err != nil {
+			return nil, err
+		}
+		owned[itemID] = true
+	}
+
+	return owned, rows.Err()
+}
+
+// AddInventoryItemTx agrega el ítem al inventario dentro de la transacción de
+// la compra. Devuelve false si el usuario ya lo tenía.
+func (r *ShopRepository) AddInventoryItemTx(tx *sql.Tx, item *models.InventoryItem) (bool, error) {
+	result, err := tx.Exec(`
+		INSERT IGNORE INTO user_inventory (id, user_id, item_id, item_type, price_paid)
+		VALUES (?, ?, ?, ?, ?)
+	`, item.ID, item.UserID, item.ItemID, item.ItemType, item.PricePaid)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
+
+const inventoryColumns = `
+	ui.id, ui.user_id, ui.item_id, ui.item_type, si.code, si.name, si.asset_url,
+	si.style_value, ui.price_paid, ui.is_equipped, ui.purchased_at`
+
+func scanInventoryItem(scanner interface{ Scan(...interface{}) error }) (*models.InventoryItem, error) {
+	item := &models.InventoryItem{}
+	var assetURL, styleValue sql.NullString
+
+	if err := scanner.Scan(
+		&item.ID,
+		&item.UserID,
+		&item.ItemID,
+		&item.ItemType,
+		&item.Code,
+		&item.Name,
+		&assetURL,
+		&styleValue,
+		&item.PricePaid,
+		&item.IsEquipped,
+		&item.PurchasedAt,
+	); err != nil {
+		return nil, err
+	}
+
+	if assetURL.Valid {
+		item.AssetURL = &assetURL.String
+	}
+	if styleValue.Valid {
+		item.StyleValue = &styleValue.String
+	}
+
+	return item, nil
+}
+
+// GetInventory obtiene los cosméticos del usuario
+func (r *ShopRepository) GetInventory(userID string) ([]models.InventoryItem, error) {
+	rows, err := r.db.Query(`
+		SELECT`+inventoryColumns+`
+		FROM user_inventory ui
+		JOIN shop_items si ON si.id = ui.item_id
+		WHERE ui.user_id = ?
+		ORDER BY ui.item_type, ui.purchased_at DESC
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []models.InventoryItem{}
+	for rows.Next() {
+		item, err := scanInventoryItem(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, *item)
+	}
+
+	return items, rows.Err()
+}
+
+// GetInventoryItem obtiene un ítem del inventario del usuario (nil si no lo tiene)
+func (r *ShopRepository) GetInventoryItem(userID, itemID string) (*models.InventoryItem, error) {
+	item, err := scanInventoryItem(r.db.QueryRow(`
+		SELECT`+inventoryColumns+`
+		FROM user_inventory ui
+		JOIN shop_items si ON si.id = ui.item_id
+		WHERE ui.user_id = ? AND ui.item_id = ?
+	`, userID, itemID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return item, err
+}
+
+// SetEquipped equipa o desequipa un ítem del usuario. Al equipar se
+// desequipa el que hubiera del mismo tipo. Devuelve false si no lo tiene.
+func (r *ShopRepository) SetEquipped(userID, itemID string, equipped bool) (bool, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	var itemType string
+	err = tx.QueryRow(`
+		SELECT item_type FROM user_inventory
+		WHERE user_id = ? AND item_id = ?
+		FOR UPDATE
+	`, userID, itemID).Scan(&itemType)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	if equipped {
+		_, err = tx.Exec(`
+			UPDATE user_inventory SET is_equipped = FALSE
+			WHERE user_id = ? AND item_type = ? AND item_id <> ?
+		`, userID, itemType, itemID)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	_, err = tx.Exec(`
+		UPDATE user_inventory SET is_equipped = ?
+		WHERE user_id = ? AND item_id = ?
+	`, equipped, userID, itemID)
+	if err != nil {
+		return false, err
+	}
+
+	return true, tx.Commit()
+}
+
+// GetEquippedCosmetics obtiene los cosméticos equipados de varios usuarios.
+// Los usuarios sin cosméticos equipados no aparecen en el mapa.
+func (r *ShopRepository) GetEquippedCosmetics(userIDs []string) (map[string]*models.EquippedCosmetics, error) {
+	cosmetics := make(map[string]*models.EquippedCosmetics, len(userIDs))
+	if len(userIDs) == 0 {
+		return cosmetics, nil
+	}
+
+	args := make([]interface{}, len(userIDs))
+	for i, id := range userIDs {
+		args[i] = id
+	}
+
+	rows, err := r.db.Query(`
+		SELECT ui.user_id, ui.item_type, si.id, si.code, si.name, si.asset_url, si.style_value
+		FROM user_inventory ui
+		JOIN shop_items si ON si.id = ui.item_id
+		WHERE ui.is_equipped = TRUE
+		  AND ui.user_id IN (?`+strings.Repeat(", ?", len(userIDs)-1)+`)
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var userID, itemType string
+		var cosmetic models.EquippedCosmetic
+		var assetURL, styleValue sql.NullString
+		if err := rows.Scan(&userID, &itemType, &cosmetic.ItemID, &cosmetic.Code, &cosmetic.Name, &assetURL, &styleValue); err != nil {
+			return nil, err
+		}
+		if assetURL.Valid {
+			cosmetic.AssetURL = &assetURL.String
+		}
+		if styleValue.Valid {
+			cosmetic.StyleValue = &styleValue.String
+		}
+
+		equipped, ok := cosmetics[userID]
+		if !ok {
+			equipped = &models.EquippedCosmetics{}
+			cosmetics[userID] = equipped
+		}
+		equipped.Set(itemType, &cosmetic)
+	}
+
+	return cosmetics, rows.Err()
+}
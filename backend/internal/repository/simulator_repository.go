@@ -227,7 +227,7 @@ func (r *SimulatorRepository) RecordAttempt(attempt *models.SimulatorAttempt) er
 	}
 
 	query := `
-		CALL record_simulator_attempt(?, ?, ?, ?, ?, ?, ?)
+		CALL record_simulator_attempt(?, ?, ?, ?, ?, ?, ?, ?)
 	`
 
 	_, err := r.db.Exec(query,
@@ -238,6 +238,7 @@ func (r *SimulatorRepository) RecordAttempt(attempt *models.SimulatorAttempt) er
 		attempt.WasCorrect,
 		attempt.PointsEarned,
 		timeTaken,
+		attempt.ExtraAttemptID,
 	)
 
 	return err
@@ -0,0 +1,166 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+
+	"github.com/smartstocks/backend/internal/models"
+)
+
+type TokenAdjustmentRepository struct {
+	db *sql.DB
+}
+
+func NewTokenAdjustmentRepository(db *sql.DB) *TokenAdjustmentRepository {
+	return &TokenAdjustmentRepository{db: db}
+}
+
+// ApplyAdjustment registra la transacción del ajuste y su auditoría en una
+// misma transacción. Completa TransactionID y BalanceAfter. Devuelve
+// ErrDuplicateTransaction si la clave ya se usó e "insufficient tokens" si un
+// descuento dejaría el saldo negativo.
+func (r *TokenAdjustmentRepository) ApplyAdjustment(adj *models.TokenAdjustment, transactionType, description string, referenceID *string, idempotencyKey string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	procedure := "credit_tokens"
+	if adj.Action == models.TokenAdjustmentDeduct {
+		procedure = "debit_tokens"
+	}
+
+	status, err := callTokenProcedure(tx, procedure, adj.UserID, adj.Amount, transactionType, description, referenceID, idempotencyKey)
+	if err != nil {
+		return err
+	}
+	switch status {
+	case tokenTxDuplicate:
+		return ErrDuplicateTransaction
+	case tokenTxInsufficient:
+		return errors.New("insufficient tokens")
+	}
+
+	err = tx.QueryRow(`
+		SELECT id, balance_after FROM token_transactions WHERE idempotency_key = ?
+	`, idempotencyKey).Scan(&adj.TransactionID, &adj.BalanceAfter)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO token_adjustments (
+			id, admin_id, user_id, action, amount, reason,
+			transaction_id, refunded_transaction_id, batch_id
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, adj.ID, adj.AdminID, adj.UserID, adj.Action, adj.Amount, adj.Reason,
+		adj.TransactionID, adj.RefundedTransactionID, adj.BatchID)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// IsRefunded verifica si la transacción ya fue devuelta
+func (r *TokenAdjustmentRepository) IsRefunded(transactionID string) (bool, error) {
+	var exists bool
+	err := r.db.QueryRow(`
+		SELECT EXISTS(SELECT 1 FROM token_adjustments WHERE refunded_transaction_id = ?)
+	`, transactionID).Scan(&exists)
+	return exists, err
+}
+
+// tokenAdjustmentSelect ajustes con los usernames y el saldo resultante
+const tokenAdjustmentSelect = `
+	SELECT a.id, a.admin_id, admin.username, a.user_id, u.username, a.action, a.amount,
+		   a.reason, a.transaction_id, a.refunded_transaction_id, a.batch_id,
+		   t.balance_after, a.created_at
+	FROM token_adjustments a
+	JOIN users u ON u.id = a.user_id
+	JOIN token_transactions t ON t.id = a.transaction_id
+	LEFT JOIN users admin ON admin.id = a.admin_id
+`
+
+// GetAdjustmentByKey obtiene el ajuste cuya transacción usó la clave de
+// idempotencia (nil si no existe)
+func (r *TokenAdjustmentRepository) GetAdjustmentByKey(idempotencyKey string) (*models.TokenAdjustment, error) {
+	adj, err := scanTokenAdjustment(r.db.QueryRow(tokenAdjustmentSelect+`
+		WHERE t.idempotency_key = ?
+	`, idempotencyKey))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return adj, err
+}
+
+// GetAdjustments obtiene los últimos ajustes, de un usuario o de todos si
+// userID está vacío
+func (r *TokenAdjustmentRepository) GetAdjustments(userID string, limit int) ([]models.TokenAdjustment, error) {
+	if limit <= 0 || limit > 200 {
+		limit = 50
+	}
+
+	query := tokenAdjustmentSelect
+	args := []interface{}{}
+	if userID != "" {
+		query += ` WHERE a.user_id = ?`
+		args = append(args, userID)
+	}
+	query += ` ORDER BY a.created_at DESC LIMIT ?`
+	args = append(args, limit)
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	adjustments := []models.TokenAdjustment{}
+	for rows.Next() {
+		adj, err := scanTokenAdjustment(rows)
+		if err != nil {
+			return nil, err
+		}
+		adjustments = append(adjustments, *adj)
+	}
+
+	return adjustments, rows.Err()
+}
+
+func scanTokenAdjustment(row rowScanner) (*models.TokenAdjustment, error) {
+	var adj models.TokenAdjustment
+	var adminID, adminUsername, refunded, batchID sql.NullString
+	if err := row.Scan(
+		&adj.ID,
+		&adminID,
+		&adminUsername,
+		&adj.UserID,
+		&adj.Username,
+		&adj.Action,
+		&adj.Amount,
+		&adj.Reason,
+		&adj.TransactionID,
+		&refunded,
+		&batchID,
+		&adj.BalanceAfter,
+		&adj.CreatedAt,
+	); err != nil {
+		return nil, err
+	}
+
+	if adminID.Valid {
+		adj.AdminID = &adminID.String
+	}
+	if adminUsername.Valid {
+		adj.AdminUsername = &adminUsername.String
+	}
+	if refunded.Valid {
+		adj.RefundedTransactionID = &refunded.String
+	}
+	if batchID.Valid {
+		adj.BatchID = &batchID.String
+	}
+	return &adj, nil
+}
@@ -0,0 +1,222 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+
+	"github.com/smartstocks/backend/internal/models"
+)
+
+type TokenGiftRepository struct {
+	db *sql.DB
+}
+
+func NewTokenGiftRepository(db *sql.DB) *TokenGiftRepository {
+	return &TokenGiftRepository{db: db}
+}
+
+// dailyGiftUsageQuery regalos enviados por el usuario en las últimas 24 horas
+const dailyGiftUsageQuery = `
+	SELECT COUNT(*), COALESCE(SUM(amount), 0)
+	FROM token_gifts
+	WHERE sender_id = ? AND created_at >= DATE_SUB(NOW(), INTERVAL 1 DAY)
+`
+
+// CreateGift debita al remitente, acredita al destinatario y registra el
+// regalo en una misma transacción. Los límites diarios se verifican con el
+// saldo del remitente bloqueado, así dos envíos simultáneos no los superan.
+// apply corre en la misma transacción (ej. la notificación al destinatario).
+// Completa SenderTransactionID, RecipientTransactionID y BalanceAfter.
+// Devuelve ErrDuplicateTransaction si la clave ya se usó.
+func (r *TokenGiftRepository) CreateGift(gift *models.TokenGift, idempotencyKey string, dailyTokenLimit, dailyGiftLimit int, apply func(tx *sql.Tx) error) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Bloquear los dos saldos siempre en el mismo orden evita deadlocks
+	// cuando dos usuarios se regalan tokens a la vez
+	rows, err := tx.Query(`
+		SELECT user_id FROM user_tokens WHERE user_id IN (?, ?) ORDER BY user_id FOR UPDATE
+	`, *gift.SenderID, *gift.RecipientID)
+	if err != nil {
+		return err
+	}
+	rows.Close()
+
+	status, err := callTokenProcedure(tx, "debit_tokens", *gift.SenderID, gift.Amount,
+		"gift_sent", "Regalo enviado", &gift.ID, idempotencyKey)
+	if err != nil {
+		return err
+	}
+	switch status {
+	case tokenTxDuplicate:
+		return ErrDuplicateTransaction
+	case tokenTxInsufficient:
+		return errors.New("insufficient tokens")
+	}
+
+	var giftsSent, tokensSent int
+	if err := tx.QueryRow(dailyGiftUsageQuery, *gift.SenderID).Scan(&giftsSent, &tokensSent); err != nil {
+		return err
+	}
+	if giftsSent+1 > dailyGiftLimit {
+		return errors.New("daily gift limit reached")
+	}
+	if tokensSent+gift.Amount > dailyTokenLimit {
+		return errors.New("daily gift token limit exceeded")
+	}
+
+	recipientKey := "gift_received:" + gift.ID
+	status, err = callTokenProcedure(tx, "credit_tokens", *gift.RecipientID, gift.Amount,
+		"gift_received", "Regalo recibido", &gift.ID, recipientKey)
+	if err != nil {
+		return err
+	}
+	if status == tokenTxDuplicate {
+		return ErrDuplicateTransaction
+	}
+
+	var balanceAfter int
+	err = tx.QueryRow(`
+		SELECT id, balance_after FROM token_transactions WHERE idempotency_key = ?
+	`, idempotencyKey).Scan(&gift.SenderTransactionID, &balanceAfter)
+	if err != nil {
+		return err
+	}
+	gift.BalanceAfter = &balanceAfter
+
+	err = tx.QueryRow(`
+		SELECT id FROM token_transactions WHERE idempotency_key = ?
+	`, recipientKey).Scan(&gift.RecipientTransactionID)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO token_gifts (
+			id, sender_id, recipient_id, amount, message,
+			sender_transaction_id, recipient_transaction_id
+		) VALUES (?, ?, ?, ?, ?, ?, ?)
+	`, gift.ID, gift.SenderID, gift.RecipientID, gift.Amount, gift.Message,
+		gift.SenderTransactionID, gift.RecipientTransactionID)
+	if err != nil {
+		return err
+	}
+
+	if apply != nil {
+		if err := apply(tx); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// GetDailyUsage cantidad de regalos y tokens enviados en las últimas 24 horas
+func (r *TokenGiftRepository) GetDailyUsage(senderID string) (int, int, error) {
+	var giftsSent, tokensSent int
+	err := r.db.QueryRow(dailyGiftUsageQuery, senderID).Scan(&giftsSent, &tokensSent)
+	return giftsSent, tokensSent, err
+}
+
+// tokenGiftColumns columnas de token_gifts g con los usernames de s y rc
+const tokenGiftColumns = `
+	g.id, g.sender_id, s.username, g.recipient_id, rc.username,
+	g.amount, g.message, g.created_at
+`
+
+// GetGiftByKey obtiene el regalo cuyo débito usó la clave de idempotencia
+// (nil si no existe), para devolver el original cuando se reintenta el envío
+func (r *TokenGiftRepository) GetGiftByKey(idempotencyKey string) (*models.TokenGift, error) {
+	var transactionID string
+	var balanceAfter int
+	gift, err := scanTokenGift(r.db.QueryRow(`
+		SELECT `+tokenGiftColumns+`, t.id, t.balance_after
+		FROM token_gifts g
+		JOIN token_transactions t ON t.id = g.sender_transaction_id
+		LEFT JOIN users s ON s.id = g.sender_id
+		LEFT JOIN users rc ON rc.id = g.recipient_id
+		WHERE t.idempotency_key = ?
+	`, idempotencyKey), &transactionID, &balanceAfter)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	gift.SenderTransactionID = transactionID
+	gift.BalanceAfter = &balanceAfter
+	return gift, nil
+}
+
+// GetGifts obtiene los últimos regalos enviados o recibidos por el usuario
+func (r *TokenGiftRepository) GetGifts(userID, direction string, limit int) ([]models.TokenGift, error) {
+	if limit <= 0 || limit > 100 {
+		limit = 50
+	}
+
+	column := "g.sender_id"
+	if direction == models.TokenGiftsReceived {
+		column = "g.recipient_id"
+	}
+
+	rows, err := r.db.Query(`
+		SELECT `+tokenGiftColumns+`
+		FROM token_gifts g
+		LEFT JOIN users s ON s.id = g.sender_id
+		LEFT JOIN users rc ON rc.id = g.recipient_id
+		WHERE `+column+` = ?
+		ORDER BY g.created_at DESC
+		LIMIT ?
+	`, userID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	gifts := []models.TokenGift{}
+	for rows.Next() {
+		gift, err := scanTokenGift(rows)
+		if err != nil {
+			return nil, err
+		}
+		gifts = append(gifts, *gift)
+	}
+
+	return gifts, rows.Err()
+}
+
+// scanTokenGift lee las columnas de tokenGiftColumns y, en extra, las
+// columnas que la consulta agregue al final
+func scanTokenGift(row rowScanner, extra ...interface{}) (*models.TokenGift, error) {
+	var gift models.TokenGift
+	var senderID, senderUsername, recipientID, recipientUsername, message sql.NullString
+	dest := append([]interface{}{
+		&gift.ID,
+		&senderID,
+		&senderUsername,
+		&recipientID,
+		&recipientUsername,
+		&gift.Amount,
+		&message,
+		&gift.CreatedAt,
+	}, extra...)
+	if err := row.Scan(dest...); err != nil {
+		return nil, err
+	}
+
+	if senderID.Valid {
+		gift.SenderID = &senderID.String
+		gift.SenderUsername = &senderUsername.String
+	}
+	if recipientID.Valid {
+		gift.RecipientID = &recipientID.String
+		gift.RecipientUsername = &recipientUsername.String
+	}
+	if message.Valid {
+		gift.Message = &message.String
+	}
+	return &gift, nil
+}
@@ -2,7 +2,9 @@ package repository
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/smartstocks/backend/internal/models"
 )
@@ -43,34 +45,117 @@ func (r *TokensRepository) GetUserTokens(userID string) (*models.UserTokens, err
 	return tokens, err
 }
 
-// AddTokens añade tokens al usuario
-func (r *TokensRepository) AddTokens(userID string, amount int, transactionType, description string, referenceID *string) error {
-	var refID sql.NullString
-	if referenceID != nil {
-		refID = sql.NullString{String: *referenceID, Valid: true}
+// Resultado de credit_tokens / debit_tokens
+const (
+	tokenTxApplied      = "applied"
+	tokenTxDuplicate    = "duplicate"
+	tokenTxInsufficient = "insufficient"
+)
+
+// ErrDuplicateTransaction la clave de idempotencia ya se usó: la operación
+// original ya está aplicada y quien llama debe devolver su resultado
+var ErrDuplicateTransaction = errors.New("duplicate transaction")
+
+// AddTokens añade tokens al usuario. idempotencyKey identifica la operación:
+// si ya se aplicó una transacción con esa clave no se vuelve a acreditar y se
+// devuelve false.
+func (r *TokensRepository) AddTokens(userID string, amount int, transactionType, description string, referenceID *string, idempotencyKey string) (bool, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return false, err
 	}
+	defer tx.Rollback()
 
-	query := `CALL add_tokens(?, ?, ?, ?, ?)`
-	_, err := r.db.Exec(query, userID, amount, transactionType, description, refID)
-	return err
+	applied, err := r.AddTokensTx(tx, userID, amount, transactionType, description, referenceID, idempotencyKey)
+	if err != nil {
+		return false, err
+	}
+
+	return applied, tx.Commit()
 }
 
-// SubtractTokens resta tokens al usuario
-func (r *TokensRepository) SubtractTokens(userID string, amount int, transactionType, description string, referenceID *string) (bool, error) {
+// AddTokensTx acredita tokens dentro de una transacción existente
+func (r *TokensRepository) AddTokensTx(tx *sql.Tx, userID string, amount int, transactionType, description string, referenceID *string, idempotencyKey string) (bool, error) {
+	status, err := callTokenProcedure(tx, "credit_tokens", userID, amount, transactionType, description, referenceID, idempotencyKey)
+	if err != nil {
+		return false, err
+	}
+	return status == tokenTxApplied, nil
+}
+
+// SubtractTokens resta tokens al usuario. Devuelve false si el saldo no alcanza.
+func (r *TokensRepository) SubtractTokens(userID string, amount int, transactionType, description string, referenceID *string, idempotencyKey string) (bool, error) {
+	return r.SubtractTokensWith(userID, amount, transactionType, description, referenceID, idempotencyKey, nil)
+}
+
+// SubtractTokensWith resta tokens y ejecuta apply en la misma transacción: si
+// apply falla no se cobra nada. debit_tokens bloquea el saldo hasta el commit
+// para que dos compras simultáneas no gasten los mismos tokens. Devuelve false
+// si el saldo no alcanza y ErrDuplicateTransaction si la clave ya se usó
+// (apply no se vuelve a ejecutar).
+func (r *TokensRepository) SubtractTokensWith(userID string, amount int, transactionType, description string, referenceID *string, idempotencyKey string, apply func(tx *sql.Tx) error) (bool, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	status, err := callTokenProcedure(tx, "debit_tokens", userID, amount, transactionType, description, referenceID, idempotencyKey)
+	if err != nil {
+		return false, err
+	}
+	switch status {
+	case tokenTxInsufficient:
+		return false, nil
+	case tokenTxDuplicate:
+		return false, ErrDuplicateTransaction
+	}
+
+	if apply != nil {
+		if err := apply(tx); err != nil {
+			return false, err
+		}
+	}
+
+	return true, tx.Commit()
+}
+
+// callTokenProcedure llama a credit_tokens o debit_tokens. @status es una
+// variable de sesión: se lee en la misma conexión de la transacción.
+func callTokenProcedure(tx *sql.Tx, procedure, userID string, amount int, transactionType, description string, referenceID *string, idempotencyKey string) (string, error) {
+	if idempotencyKey == "" {
+		return "", fmt.Errorf("idempotency key required")
+	}
+
 	var refID sql.NullString
 	if referenceID != nil {
 		refID = sql.NullString{String: *referenceID, Valid: true}
 	}
 
-	var success bool
-	query := `CALL subtract_tokens(?, ?, ?, ?, ?, @success)`
-	_, err := r.db.Exec(query, userID, amount, transactionType, description, refID)
+	_, err := tx.Exec(`CALL `+procedure+`(?, ?, ?, ?, ?, ?, @status)`,
+		userID, amount, transactionType, description, refID, idempotencyKey)
 	if err != nil {
-		return false, err
+		return "", err
 	}
 
-	err = r.db.QueryRow(`SELECT @success`).Scan(&success)
-	return success, err
+	var status string
+	err = tx.QueryRow(`SELECT @status`).Scan(&status)
+	return status, err
+}
+
+// GetTransactionByKey obtiene la transacción aplicada con la clave de
+// idempotencia (nil si no existe)
+func (r *TokensRepository) GetTransactionByKey(idempotencyKey string) (*models.TokenTransaction, error) {
+	tx, err := scanTokenTransaction(r.db.QueryRow(`
+		SELECT id, user_id, transaction_type, amount, balance_after,
+			   description, reference_id, created_at
+		FROM token_transactions
+		WHERE idempotency_key = ?
+	`, idempotencyKey))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return tx, err
 }
 
 // GetTransactionHistory obtiene el historial de transacciones
@@ -96,31 +181,142 @@ func (r *TokensRepository) GetTransactionHistory(userID string, limit int) ([]mo
 
 	var transactions []models.TokenTransaction
 	for rows.Next() {
-		var tx models.TokenTransaction
-		var refID sql.NullString
-
-		err := rows.Scan(
-			&tx.ID,
-			&tx.UserID,
-			&tx.TransactionType,
-			&tx.Amount,
-			&tx.BalanceAfter,
-			&tx.Description,
-			&refID,
-			&tx.CreatedAt,
-		)
+		tx, err := scanTokenTransaction(rows)
+		if err != nil {
+			return nil, err
+		}
+		transactions = append(transactions, *tx)
+	}
+
+	return transactions, nil
+}
+
+// GetTransaction obtiene una transacción (nil si no existe)
+func (r *TokensRepository) GetTransaction(transactionID string) (*models.TokenTransaction, error) {
+	tx, err := scanTokenTransaction(r.db.QueryRow(`
+		SELECT id, user_id, transaction_type, amount, balance_after,
+			   description, reference_id, created_at
+		FROM token_transactions
+		WHERE id = ?
+	`, transactionID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return tx, err
+}
+
+// GetTransactions obtiene una página del historial filtrado, de la más
+// reciente a la más antigua, a partir del cursor (nil desde el principio)
+func (r *TokensRepository) GetTransactions(userID string, filter models.TokenHistoryFilter, cursor *models.TokenHistoryCursor, limit int) ([]models.TokenTransaction, error) {
+	where, args := tokenHistoryWhere(userID, filter)
+	if cursor != nil {
+		where += ` AND (created_at < ? OR (created_at = ? AND id < ?))`
+		args = append(args, cursor.CreatedAt, cursor.CreatedAt, cursor.ID)
+	}
+	args = append(args, limit)
+
+	rows, err := r.db.Query(`
+		SELECT id, user_id, transaction_type, amount, balance_after,
+			   description, reference_id, created_at
+		FROM token_transactions
+		WHERE `+where+`
+		ORDER BY created_at DESC, id DESC
+		LIMIT ?
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	transactions := []models.TokenTransaction{}
+	for rows.Next() {
+		tx, err := scanTokenTransaction(rows)
 		if err != nil {
 			return nil, err
 		}
+		transactions = append(transactions, *tx)
+	}
+
+	return transactions, rows.Err()
+}
+
+// GetWeeklySummary suma lo ganado y gastado por semana (desde el lunes).
+// Solo devuelve las semanas con movimientos.
+func (r *TokensRepository) GetWeeklySummary(userID string, filter models.TokenHistoryFilter) ([]models.TokenWeeklySummary, error) {
+	where, args := tokenHistoryWhere(userID, filter)
+
+	rows, err := r.db.Query(`
+		SELECT DATE_FORMAT(DATE_SUB(DATE(created_at), INTERVAL WEEKDAY(created_at) DAY), '%Y-%m-%d') AS week_start,
+			   COALESCE(SUM(CASE WHEN amount > 0 THEN amount ELSE 0 END), 0),
+			   COALESCE(SUM(CASE WHEN amount < 0 THEN -amount ELSE 0 END), 0),
+			   COUNT(*)
+		FROM token_transactions
+		WHERE `+where+`
+		GROUP BY week_start
+		ORDER BY week_start ASC
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	weeks := []models.TokenWeeklySummary{}
+	for rows.Next() {
+		var week models.TokenWeeklySummary
+		if err := rows.Scan(&week.WeekStart, &week.Earned, &week.Spent, &week.Transactions); err != nil {
+			return nil, err
+		}
+		week.Net = week.Earned - week.Spent
+		weeks = append(weeks, week)
+	}
+
+	return weeks, rows.Err()
+}
 
-		if refID.Valid {
-			tx.ReferenceID = &refID.String
+// tokenHistoryWhere arma la condición de los filtros del historial
+func tokenHistoryWhere(userID string, filter models.TokenHistoryFilter) (string, []interface{}) {
+	where := `user_id = ?`
+	args := []interface{}{userID}
+
+	if len(filter.Types) > 0 {
+		where += ` AND transaction_type IN (?` + strings.Repeat(", ?", len(filter.Types)-1) + `)`
+		for _, t := range filter.Types {
+			args = append(args, t)
 		}
+	}
+	if filter.From != nil {
+		where += ` AND created_at >= ?`
+		args = append(args, *filter.From)
+	}
+	if filter.To != nil {
+		where += ` AND created_at < ?`
+		args = append(args, *filter.To)
+	}
 
-		transactions = append(transactions, tx)
+	return where, args
+}
+
+func scanTokenTransaction(row rowScanner) (*models.TokenTransaction, error) {
+	var tx models.TokenTransaction
+	var refID sql.NullString
+
+	if err := row.Scan(
+		&tx.ID,
+		&tx.UserID,
+		&tx.TransactionType,
+		&tx.Amount,
+		&tx.BalanceAfter,
+		&tx.Description,
+		&refID,
+		&tx.CreatedAt,
+	); err != nil {
+		return nil, err
 	}
 
-	return transactions, nil
+	if refID.Valid {
+		tx.ReferenceID = &refID.String
+	}
+	return &tx, nil
 }
 
 // HasSufficientTokens verifica si el usuario tiene suficientes tokens
@@ -133,3 +329,124 @@ func (r *TokensRepository) HasSufficientTokens(userID string, amount int) (bool,
 	}
 	return balance >= amount, nil
 }
+
+// RunReconciliation recalcula los saldos desde token_transactions y registra
+// las diferencias en la ejecución runID. Con repair corrige user_tokens.
+func (r *TokensRepository) RunReconciliation(runID, triggeredBy string, requestedBy *string, repair bool) error {
+	_, err := r.db.Exec(`CALL reconcile_token_balances(?, ?, ?, ?)`, runID, triggeredBy, requestedBy, repair)
+	return err
+}
+
+const reconciliationRunColumns = `
+	id, triggered_by, requested_by, repair, accounts_checked, drift_count,
+	repaired_count, started_at, finished_at`
+
+func scanReconciliationRun(scanner interface{ Scan(...interface{}) error }) (*models.TokenReconciliationRun, error) {
+	run := &models.TokenReconciliationRun{}
+	var requestedBy sql.NullString
+	var finishedAt sql.NullTime
+
+	if err := scanner.Scan(
+		&run.ID,
+		&run.TriggeredBy,
+		&requestedBy,
+		&run.Repair,
+		&run.AccountsChecked,
+		&run.DriftCount,
+		&run.RepairedCount,
+		&run.StartedAt,
+		&finishedAt,
+	); err != nil {
+		return nil, err
+	}
+
+	if requestedBy.Valid {
+		run.RequestedBy = &requestedBy.String
+	}
+	if finishedAt.Valid {
+		run.FinishedAt = &finishedAt.Time
+	}
+
+	return run, nil
+}
+
+// GetReconciliationRun obtiene una ejecución (nil si no existe)
+func (r *TokensRepository) GetReconciliationRun(runID string) (*models.TokenReconciliationRun, error) {
+	run, err := scanReconciliationRun(r.db.QueryRow(`
+		SELECT`+reconciliationRunColumns+`
+		FROM token_reconciliation_runs
+		WHERE id = ?
+	`, runID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return run, err
+}
+
+// GetReconciliationRuns obtiene las últimas ejecuciones
+func (r *TokensRepository) GetReconciliationRuns(limit int) ([]models.TokenReconciliationRun, error) {
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+
+	rows, err := r.db.Query(`
+		SELECT`+reconciliationRunColumns+`
+		FROM token_reconciliation_runs
+		ORDER BY started_at DESC
+		LIMIT ?
+	`, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	runs := []models.TokenReconciliationRun{}
+	for rows.Next() {
+		run, err := scanReconciliationRun(rows)
+		if err != nil {
+			return nil, err
+		}
+		runs = append(runs, *run)
+	}
+
+	return runs, rows.Err()
+}
+
+// GetBalanceDrifts obtiene las diferencias encontradas en una ejecución, las
+// más grandes primero
+func (r *TokensRepository) GetBalanceDrifts(runID string) ([]models.TokenBalanceDrift, error) {
+	rows, err := r.db.Query(`
+		SELECT d.user_id, u.username, d.stored_balance, d.ledger_balance,
+			   d.stored_earned, d.ledger_earned, d.stored_spent, d.ledger_spent, d.repaired
+		FROM token_balance_drifts d
+		JOIN users u ON u.id = d.user_id
+		WHERE d.run_id = ?
+		ORDER BY ABS(d.stored_balance - d.ledger_balance) DESC, u.username
+	`, runID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	drifts := []models.TokenBalanceDrift{}
+	for rows.Next() {
+		var drift models.TokenBalanceDrift
+		if err := rows.Scan(
+			&drift.UserID,
+			&drift.Username,
+			&drift.StoredBalance,
+			&drift.LedgerBalance,
+			&drift.StoredEarned,
+			&drift.LedgerEarned,
+			&drift.StoredSpent,
+			&drift.LedgerSpent,
+			&drift.Repaired,
+		); err != nil {
+			return nil, err
+		}
+		drift.BalanceDelta = drift.StoredBalance - drift.LedgerBalance
+		drifts = append(drifts, drift)
+	}
+
+	return drifts, rows.Err()
+}
@@ -127,17 +127,29 @@ func (r *TournamentsRepository) JoinTournament(tournamentID, userID string) (boo
 	var success bool
 	var errorMessage sql.NullString
 
+	// En una transacción: el cobro bloquea el saldo hasta el commit y las
+	// variables de sesión se leen en la misma conexión
+	tx, err := r.db.Begin()
+	if err != nil {
+		return false, "", err
+	}
+	defer tx.Rollback()
+
 	query := `CALL join_tournament(?, ?, @success, @error_message)`
-	_, err := r.db.Exec(query, tournamentID, userID)
+	_, err = tx.Exec(query, tournamentID, userID)
 	if err != nil {
 		return false, "", err
 	}
 
-	err = r.db.QueryRow(`SELECT @success, @error_message`).Scan(&success, &errorMessage)
+	err = tx.QueryRow(`SELECT @success, @error_message`).Scan(&success, &errorMessage)
 	if err != nil {
 		return false, "", err
 	}
 
+	if err := tx.Commit(); err != nil {
+		return false, "", err
+	}
+
 	if errorMessage.Valid {
 		return success, errorMessage.String, nil
 	}
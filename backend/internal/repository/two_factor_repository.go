@@ -0,0 +1,139 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/smartstocks/backend/internal/models"
+)
+
+type TwoFactorRepository struct {
+	db *sql.DB
+}
+
+func NewTwoFactorRepository(db *sql.DB) *TwoFactorRepository {
+	return &TwoFactorRepository{db: db}
+}
+
+// GetByUserID obtiene la configuración TOTP del usuario (nil si no existe)
+func (r *TwoFactorRepository) GetByUserID(userID string) (*models.UserTwoFactor, error) {
+	tf := &models.UserTwoFactor{}
+	query := `
+		SELECT user_id, secret, enabled, last_used_step, confirmed_at, created_at
+		FROM user_two_factor WHERE user_id = ?
+	`
+
+	err := r.db.QueryRow(query, userID).Scan(
+		&tf.UserID,
+		&tf.Secret,
+		&tf.Enabled,
+		&tf.LastUsedStep,
+		&tf.ConfirmedAt,
+		&tf.CreatedAt,
+	)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+
+	return tf, err
+}
+
+// SavePendingSecret guarda un secreto nuevo sin habilitar (reemplaza uno pendiente)
+func (r *TwoFactorRepository) SavePendingSecret(userID, secret string) error {
+	query := `
+		INSERT INTO user_two_factor (user_id, secret, enabled, last_used_step)
+		VALUES (?, ?, FALSE, 0)
+		ON DUPLICATE KEY UPDATE secret = VALUES(secret), last_used_step = 0
+	`
+	_, err := r.db.Exec(query, userID, secret)
+	return err
+}
+
+// Enable habilita el 2FA y reemplaza los códigos de respaldo en una transacción
+func (r *TwoFactorRepository) Enable(userID string, step int64, recoveryCodeHashes []string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`
+		UPDATE user_two_factor
+		SET enabled = TRUE, last_used_step = ?, confirmed_at = ?
+		WHERE user_id = ?
+	`, step, time.Now(), userID)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(`DELETE FROM user_recovery_codes WHERE user_id = ?`, userID); err != nil {
+		return err
+	}
+
+	for _, hash := range recoveryCodeHashes {
+		_, err := tx.Exec(`
+			INSERT INTO user_recovery_codes (id, user_id, code_hash) VALUES (?, ?, ?)
+		`, uuid.New().String(), userID, hash)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// Disable elimina la configuración TOTP y los códigos de respaldo
+func (r *TwoFactorRepository) Disable(userID string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM user_recovery_codes WHERE user_id = ?`, userID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM user_two_factor WHERE user_id = ?`, userID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// MarkStepUsed guarda el último step aceptado. Devuelve false si ya se usó
+// ese step o uno posterior (evita reusar un mismo código).
+func (r *TwoFactorRepository) MarkStepUsed(userID string, step int64) (bool, error) {
+	query := `UPDATE user_two_factor SET last_used_step = ? WHERE user_id = ? AND last_used_step < ?`
+	result, err := r.db.Exec(query, step, userID, step)
+	if err != nil {
+		return false, err
+	}
+
+	rows, err := result.RowsAffected()
+	return rows > 0, err
+}
+
+// UseRecoveryCode consume un código de respaldo no usado
+func (r *TwoFactorRepository) UseRecoveryCode(userID, codeHash string) (bool, error) {
+	query := `
+		UPDATE user_recovery_codes SET used_at = ?
+		WHERE user_id = ? AND code_hash = ? AND used_at IS NULL
+	`
+	result, err := r.db.Exec(query, time.Now(), userID, codeHash)
+	if err != nil {
+		return false, err
+	}
+
+	rows, err := result.RowsAffected()
+	return rows > 0, err
+}
+
+// CountRemainingRecoveryCodes cuenta los códigos de respaldo sin usar
+func (r *TwoFactorRepository) CountRemainingRecoveryCodes(userID string) (int, error) {
+	var count int
+	query := `SELECT COUNT(*) FROM user_recovery_codes WHERE user_id = ? AND used_at IS NULL`
+	err := r.db.QueryRow(query, userID).Scan(&count)
+	return count, err
+}
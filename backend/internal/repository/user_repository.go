@@ -19,6 +19,7 @@ func NewUserRepository(db *sql.DB) *UserRepository {
 
 func (r *UserRepository) CreateUser(user *models.User) error {
 	user.ID = uuid.New().String()
+	user.Role = models.RoleStudent
 	user.CreatedAt = time.Now()
 	user.UpdatedAt = time.Now()
 
@@ -45,7 +46,7 @@ func (r *UserRepository) CreateUser(user *models.User) error {
 func (r *UserRepository) GetUserByEmail(email string) (*models.User, error) {
 	user := &models.User{}
 	query := `
-		SELECT id, username, email, password_hash, profile_picture_url, school_id,
+		SELECT id, username, email, password_hash, profile_picture_url, school_id, role,
 			   created_at, updated_at, last_login, email_verified, verification_token
 		FROM users WHERE email = ?
 	`
@@ -57,6 +58,7 @@ func (r *UserRepository) GetUserByEmail(email string) (*models.User, error) {
 		&user.PasswordHash,
 		&user.ProfilePictureURL,
 		&user.SchoolID,
+		&user.Role,
 		&user.CreatedAt,
 		&user.UpdatedAt,
 		&user.LastLogin,
@@ -74,7 +76,7 @@ func (r *UserRepository) GetUserByEmail(email string) (*models.User, error) {
 func (r *UserRepository) GetUserByID(userID string) (*models.User, error) {
 	user := &models.User{}
 	query := `
-		SELECT id, username, email, password_hash, profile_picture_url, school_id,
+		SELECT id, username, email, password_hash, profile_picture_url, school_id, role,
 			   created_at, updated_at, last_login, email_verified
 		FROM users WHERE id = ?
 	`
@@ -86,6 +88,7 @@ func (r *UserRepository) GetUserByID(userID string) (*models.User, error) {
 		&user.PasswordHash,
 		&user.ProfilePictureURL,
 		&user.SchoolID,
+		&user.Role,
 		&user.CreatedAt,
 		&user.UpdatedAt,
 		&user.LastLogin,
@@ -111,29 +114,90 @@ func (r *UserRepository) GetUserByUsername(username string) (*models.User, error
 	return user, err
 }
 
+// GetUserRole obtiene el rol del usuario
+func (r *UserRepository) GetUserRole(userID string) (string, error) {
+	var role string
+	err := r.db.QueryRow(`SELECT role FROM users WHERE id = ?`, userID).Scan(&role)
+	if err == sql.ErrNoRows {
+		return "", errors.New("user not found")
+	}
+	return role, err
+}
+
 func (r *UserRepository) UpdateLastLogin(userID string) error {
 	query := `UPDATE users SET last_login = ? WHERE id = ?`
 	_, err := r.db.Exec(query, time.Now(), userID)
 	return err
 }
 
-func (r *UserRepository) VerifyEmail(token string) error {
-	query := `UPDATE users SET email_verified = TRUE, verification_token = NULL WHERE verification_token = ?`
-	result, err := r.db.Exec(query, token)
+func (r *UserRepository) VerifyEmail(token string) (string, error) {
+	var userID string
+	err := r.db.QueryRow(`SELECT id FROM users WHERE verification_token = ?`, token).Scan(&userID)
+	if err == sql.ErrNoRows {
+		return "", errors.New("invalid verification token")
+	}
 	if err != nil {
-		return err
+		return "", err
+	}
+
+	query := `UPDATE users SET email_verified = TRUE, verification_token = NULL WHERE id = ? AND verification_token = ?`
+	result, err := r.db.Exec(query, userID, token)
+	if err != nil {
+		return "", err
 	}
 
 	rows, err := result.RowsAffected()
+	if err != nil {
+		return "", err
+	}
+
+	if rows == 0 {
+		return "", errors.New("invalid verification token")
+	}
+
+	return userID, nil
+}
+
+// ClaimUnverifiedAccount marca el email como verificado cuando un proveedor
+// OIDC prueba su titularidad. Quien registró la cuenta sin verificarla pudo
+// no ser el dueño del email, así que se reemplaza la contraseña y se borran
+// sesiones y 2FA que haya configurado.
+func (r *UserRepository) ClaimUnverifiedAccount(userID, newPasswordHash string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`
+		UPDATE users
+		SET email_verified = TRUE, verification_token = NULL, password_hash = ?,
+		    reset_token = NULL, reset_token_expires = NULL, updated_at = ?
+		WHERE id = ? AND email_verified = FALSE
+	`, newPasswordHash, time.Now(), userID)
 	if err != nil {
 		return err
 	}
 
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
 	if rows == 0 {
-		return errors.New("invalid verification token")
+		return errors.New("account is already verified")
 	}
 
-	return nil
+	for _, query := range []string{
+		`DELETE FROM refresh_tokens WHERE user_id = ?`,
+		`DELETE FROM user_recovery_codes WHERE user_id = ?`,
+		`DELETE FROM user_two_factor WHERE user_id = ?`,
+	} {
+		if _, err := tx.Exec(query, userID); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
 }
 
 func (r *UserRepository) UpdateProfile(userID string, req *models.UpdateProfileRequest) error {
@@ -149,11 +213,6 @@ func (r *UserRepository) UpdateProfile(userID string, req *models.UpdateProfileR
 		updates = append(updates, "profile_picture_url = ?")
 		args = append(args, *req.ProfilePictureURL)
 	}
-	if req.SchoolID != nil {
-		updates = append(updates, "school_id = ?")
-		args = append(args, *req.SchoolID)
-	}
-
 	if len(updates) == 0 {
 		return errors.New("no fields to update")
 	}
@@ -201,20 +260,26 @@ func (r *UserRepository) ResetPassword(token, newPasswordHash string) error {
 func (r *UserRepository) GetUserStats(userID string) (*models.UserStats, error) {
 	stats := &models.UserStats{}
 	query := `
-		SELECT user_id, smartpoints, rank_tier, total_quizzes_completed,
-			   total_simulator_games, win_streak, total_wins, total_losses, updated_at
-		FROM user_stats WHERE user_id = ?
+		SELECT us.user_id, us.smartpoints, us.season_points, us.rank_tier, us.total_quizzes_completed,
+			   us.total_simulator_games, us.win_streak, us.total_wins, us.total_losses,
+			   COALESCE(ls.current_streak, 0), COALESCE(ls.longest_streak, 0), us.updated_at
+		FROM user_stats us
+		LEFT JOIN user_login_streaks ls ON ls.user_id = us.user_id
+		WHERE us.user_id = ?
 	`
 
 	err := r.db.QueryRow(query, userID).Scan(
 		&stats.UserID,
 		&stats.Smartpoints,
+		&stats.SeasonPoints,
 		&stats.RankTier,
 		&stats.TotalQuizzesCompleted,
 		&stats.TotalSimulatorGames,
 		&stats.WinStreak,
 		&stats.TotalWins,
 		&stats.TotalLosses,
+		&stats.LoginStreak,
+		&stats.LongestLoginStreak,
 		&stats.UpdatedAt,
 	)
 
@@ -0,0 +1,126 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/smartstocks/backend/internal/models"
+	"github.com/smartstocks/backend/internal/repository"
+)
+
+// AccountLinkService detecta y administra cuentas que parecen de la misma
+// persona, para bloquear transferencias entre ellas
+type AccountLinkService struct {
+	linkRepo   *repository.AccountLinkRepository
+	userRepo   *repository.UserRepository
+	windowDays int
+}
+
+func NewAccountLinkService(
+	linkRepo *repository.AccountLinkRepository,
+	userRepo *repository.UserRepository,
+	windowDays int,
+) *AccountLinkService {
+	return &AccountLinkService{
+		linkRepo:   linkRepo,
+		userRepo:   userRepo,
+		windowDays: windowDays,
+	}
+}
+
+// AreLinked indica si las dos cuentas están vinculadas: por un vínculo
+// registrado o, si no hay, por compartir dispositivo. La consulta no guarda
+// nada. Un vínculo descartado por un administrador prevalece.
+func (s *AccountLinkService) AreLinked(userID, otherID string) (bool, error) {
+	link, err := s.linkRepo.GetLink(userID, otherID)
+	if err != nil {
+		return false, fmt.Errorf("error getting account link: %w", err)
+	}
+	if link != nil {
+		return link.Status == models.AccountLinkLinked, nil
+	}
+
+	if s.windowDays <= 0 {
+		return false, nil
+	}
+	shared, err := s.linkRepo.HasSharedDevice(userID, otherID, s.windowDays)
+	if err != nil {
+		return false, fmt.Errorf("error checking shared device: %w", err)
+	}
+	return shared, nil
+}
+
+// === ADMIN ===
+
+// FlagLink marca a mano dos cuentas como vinculadas. Si el par ya tenía un
+// vínculo lo vuelve a confirmar.
+func (s *AccountLinkService) FlagLink(adminID string, req *models.FlagAccountLinkRequest) (*models.AccountLink, error) {
+	if req.UserID == req.LinkedUserID {
+		return nil, errors.New("an account cannot be linked to itself")
+	}
+	for _, id := range []string{req.UserID, req.LinkedUserID} {
+		if _, err := s.userRepo.GetUserByID(id); err != nil {
+			return nil, errors.New("user not found")
+		}
+	}
+
+	notes := optionalNotes(req.Notes)
+	created, err := s.linkRepo.CreateLink(&models.AccountLink{
+		ID:        uuid.New().String(),
+		UserAID:   req.UserID,
+		UserBID:   req.LinkedUserID,
+		Reason:    models.AccountLinkManual,
+		Status:    models.AccountLinkLinked,
+		Notes:     notes,
+		CreatedBy: &adminID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error flagging accounts: %w", err)
+	}
+
+	link, err := s.linkRepo.GetLink(req.UserID, req.LinkedUserID)
+	if err != nil {
+		return nil, err
+	}
+	if !created {
+		if err := s.linkRepo.UpdateStatus(link.ID, models.AccountLinkLinked, notes, adminID); err != nil {
+			return nil, fmt.Errorf("error updating account link: %w", err)
+		}
+		return s.linkRepo.GetLinkByID(link.ID)
+	}
+	return link, nil
+}
+
+// UpdateLink confirma o descarta un vínculo
+func (s *AccountLinkService) UpdateLink(adminID, linkID string, req *models.UpdateAccountLinkRequest) (*models.AccountLink, error) {
+	link, err := s.linkRepo.GetLinkByID(linkID)
+	if err != nil {
+		return nil, err
+	}
+	if link == nil {
+		return nil, errors.New("account link not found")
+	}
+
+	if err := s.linkRepo.UpdateStatus(linkID, req.Status, optionalNotes(req.Notes), adminID); err != nil {
+		return nil, fmt.Errorf("error updating account link: %w", err)
+	}
+	return s.linkRepo.GetLinkByID(linkID)
+}
+
+// GetLinks lista los vínculos (status vacío trae todos)
+func (s *AccountLinkService) GetLinks(status string, limit int) ([]models.AccountLink, error) {
+	if status != "" && status != models.AccountLinkLinked && status != models.AccountLinkDismissed {
+		return nil, errors.New("invalid status")
+	}
+	return s.linkRepo.GetLinks(status, limit)
+}
+
+func optionalNotes(notes string) *string {
+	notes = strings.TrimSpace(notes)
+	if notes == "" {
+		return nil
+	}
+	return &notes
+}
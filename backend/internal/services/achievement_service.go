@@ -0,0 +1,349 @@
+package services
+
+import (
+	"database/sql"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/smartstocks/backend/internal/models"
+	"github.com/smartstocks/backend/internal/repository"
+)
+
+// Reglas por defecto, se usan si no se configura ACHIEVEMENT_RULES_PATH
+//
+//go:embed achievement_rules.json
+var defaultAchievementRules []byte
+
+// Eventos y métricas que entiende el motor de logros
+var (
+	achievementEvents = map[string]bool{
+		models.AchievementEventQuizCompleted:     true,
+		models.AchievementEventSimulatorAttempt:  true,
+		models.AchievementEventPvPMatchFinished:  true,
+		models.AchievementEventLessonCompleted:   true,
+		models.AchievementEventForumPostCreated:  true,
+		models.AchievementEventForumReplyCreated: true,
+	}
+	achievementMetrics = map[string]bool{
+		models.AchievementMetricSmartpoints:            true,
+		models.AchievementMetricRankLevel:              true,
+		models.AchievementMetricPvPWins:                true,
+		models.AchievementMetricWinStreak:              true,
+		models.AchievementMetricBestComeback:           true,
+		models.AchievementMetricQuizzesCompleted:       true,
+		models.AchievementMetricBestQuizScore:          true,
+		models.AchievementMetricSimulatorGames:         true,
+		models.AchievementMetricSimulatorCorrect:       true,
+		models.AchievementMetricFastestCorrectDecision: true,
+		models.AchievementMetricLessonsCompleted:       true,
+		models.AchievementMetricCoursesCompleted:       true,
+		models.AchievementMetricForumPosts:             true,
+		models.AchievementMetricForumReplies:           true,
+	}
+)
+
+type AchievementService struct {
+	achievementRepo *repository.AchievementRepository
+	tokensRepo      *repository.TokensRepository
+	rules           []models.AchievementRule
+	rulesByEvent    map[string][]models.AchievementRule
+}
+
+func NewAchievementService(
+	achievementRepo *repository.AchievementRepository,
+	tokensRepo *repository.TokensRepository,
+	rules []models.AchievementRule,
+) *AchievementService {
+	rulesByEvent := make(map[string][]models.AchievementRule)
+	for _, rule := range rules {
+		for _, event := range rule.Events {
+			rulesByEvent[event] = append(rulesByEvent[event], rule)
+		}
+	}
+
+	return &AchievementService{
+		achievementRepo: achievementRepo,
+		tokensRepo:      tokensRepo,
+		rules:           rules,
+		rulesByEvent:    rulesByEvent,
+	}
+}
+
+// LoadAchievementRules lee las reglas del archivo indicado (las embebidas si
+// path está vacío) y las valida
+func LoadAchievementRules(path string) ([]models.AchievementRule, error) {
+	data := defaultAchievementRules
+	if path != "" {
+		var err error
+		data, err = os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("error reading achievement rules: %w", err)
+		}
+	}
+
+	return parseAchievementRules(data)
+}
+
+func parseAchievementRules(data []byte) ([]models.AchievementRule, error) {
+	var ruleSet models.AchievementRuleSet
+	if err := json.Unmarshal(data, &ruleSet); err != nil {
+		return nil, fmt.Errorf("invalid achievement rules: %w", err)
+	}
+
+	seen := make(map[string]bool)
+	for _, rule := range ruleSet.Achievements {
+		if err := validateAchievementRule(rule); err != nil {
+			return nil, err
+		}
+		if seen[rule.Type] {
+			return nil, fmt.Errorf("achievement %q is defined twice", rule.Type)
+		}
+		seen[rule.Type] = true
+	}
+
+	return ruleSet.Achievements, nil
+}
+
+func validateAchievementRule(rule models.AchievementRule) error {
+	if rule.Type == "" || len(rule.Type) > 50 {
+		return errors.New("achievement type is required (max 50 characters)")
+	}
+	if rule.Name == "" {
+		return fmt.Errorf("achievement %q: name is required", rule.Type)
+	}
+	if len(rule.Events) == 0 {
+		return fmt.Errorf("achievement %q: at least one event is required", rule.Type)
+	}
+	for _, event := range rule.Events {
+		if !achievementEvents[event] {
+			return fmt.Errorf("achievement %q: unknown event %q", rule.Type, event)
+		}
+	}
+	if !achievementMetrics[rule.Metric] {
+		return fmt.Errorf("achievement %q: unknown metric %q", rule.Type, rule.Metric)
+	}
+	if rule.Operator != models.AchievementOperatorGTE && rule.Operator != models.AchievementOperatorLTE {
+		return fmt.Errorf("achievement %q: operator must be gte or lte", rule.Type)
+	}
+	if rule.Threshold <= 0 {
+		return fmt.Errorf("achievement %q: threshold must be positive", rule.Type)
+	}
+	if rule.TokenBonus < 0 {
+		return fmt.Errorf("achievement %q: token bonus cannot be negative", rule.Type)
+	}
+	return nil
+}
+
+// Publish evalúa los logros asociados al evento. Los errores se registran y
+// no afectan la operación que originó el evento.
+func (s *AchievementService) Publish(userID, event string) {
+	if s == nil {
+		return
+	}
+
+	rules := s.rulesByEvent[event]
+	if len(rules) == 0 {
+		return
+	}
+
+	if _, err := s.evaluate(userID, rules, true); err != nil {
+		fmt.Printf("⚠️ Error evaluating achievements of user %s after %s: %v\n", userID, event, err)
+	}
+}
+
+// Backfill evalúa todas las reglas contra el historial del usuario y
+// desbloquea los logros que ya cumplía. Devuelve los tipos desbloqueados.
+func (s *AchievementService) Backfill(userID string, grantBonus bool) ([]string, error) {
+	return s.evaluate(userID, s.rules, grantBonus)
+}
+
+// BackfillAll recorre todos los usuarios. onUser se llama después de cada uno.
+func (s *AchievementService) BackfillAll(grantBonus bool, onUser func(userID string, unlocked []string, err error)) error {
+	const batchSize = 500
+
+	afterID := ""
+	for {
+		userIDs, err := s.achievementRepo.GetUserIDsAfter(afterID, batchSize)
+		if err != nil {
+			return err
+		}
+		for _, userID := range userIDs {
+			unlocked, err := s.Backfill(userID, grantBonus)
+			onUser(userID, unlocked, err)
+		}
+		if len(userIDs) < batchSize {
+			return nil
+		}
+		afterID = userIDs[len(userIDs)-1]
+	}
+}
+
+// GetLockedProgress progreso hacia los logros que el usuario todavía no desbloqueó
+func (s *AchievementService) GetLockedProgress(userID string) ([]models.AchievementProgress, error) {
+	unlocked, err := s.achievementRepo.GetUnlockedAchievementTypes(userID)
+	if err != nil {
+		return nil, err
+	}
+	metrics, err := s.achievementRepo.GetAchievementMetrics(userID)
+	if err != nil {
+		return nil, err
+	}
+	stored, err := s.achievementRepo.GetAchievementProgress(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	locked := []models.AchievementProgress{}
+	for _, rule := range s.rules {
+		if unlocked[rule.Type] {
+			continue
+		}
+
+		best, ok, _ := evaluateAchievementRule(rule, metrics, stored)
+		progress := models.AchievementProgress{
+			AchievementType: rule.Type,
+			Name:            rule.Name,
+			Description:     rule.Description,
+			Current:         best,
+			Required:        rule.Threshold,
+			Progress:        achievementProgressPercent(rule, best, ok),
+			TokenBonus:      rule.TokenBonus,
+		}
+		if rule.IconURL != "" {
+			iconURL := rule.IconURL
+			progress.IconURL = &iconURL
+		}
+		locked = append(locked, progress)
+	}
+
+	return locked, nil
+}
+
+// evaluate actualiza el progreso de las reglas y desbloquea las que se cumplen
+func (s *AchievementService) evaluate(userID string, rules []models.AchievementRule, grantBonus bool) ([]string, error) {
+	unlocked, err := s.achievementRepo.GetUnlockedAchievementTypes(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	pending := make([]models.AchievementRule, 0, len(rules))
+	for _, rule := range rules {
+		if !unlocked[rule.Type] {
+			pending = append(pending, rule)
+		}
+	}
+	if len(pending) == 0 {
+		return nil, nil
+	}
+
+	metrics, err := s.achievementRepo.GetAchievementMetrics(userID)
+	if err != nil {
+		return nil, err
+	}
+	if metrics == nil {
+		return nil, nil
+	}
+	stored, err := s.achievementRepo.GetAchievementProgress(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	progress := make(map[string]int)
+	var newlyUnlocked []string
+	for i := range pending {
+		rule := &pending[i]
+		best, ok, met := evaluateAchievementRule(*rule, metrics, stored)
+		if !ok {
+			continue
+		}
+		if previous, exists := stored[rule.Type]; !exists || previous != best {
+			progress[rule.Type] = best
+		}
+		if !met {
+			continue
+		}
+
+		// El bonus se acredita en la misma transacción que el desbloqueo
+		var credit func(tx *sql.Tx, achievementID string) error
+		if grantBonus && rule.TokenBonus > 0 {
+			description := fmt.Sprintf("Logro desbloqueado: %s", rule.Name)
+			key := "achievement_bonus:" + userID + ":" + rule.Type
+			credit = func(tx *sql.Tx, achievementID string) error {
+				_, err := s.tokensRepo.AddTokensTx(tx, userID, rule.TokenBonus, "achievement_bonus", description, &achievementID, key)
+				return err
+			}
+		}
+
+		_, granted, err := s.achievementRepo.UnlockAchievement(userID, rule, credit)
+		if err != nil {
+			return newlyUnlocked, fmt.Errorf("error unlocking %s: %w", rule.Type, err)
+		}
+		if !granted {
+			continue
+		}
+		newlyUnlocked = append(newlyUnlocked, rule.Type)
+	}
+
+	if err := s.achievementRepo.SaveAchievementProgress(userID, progress); err != nil {
+		return newlyUnlocked, fmt.Errorf("error saving achievement progress: %w", err)
+	}
+
+	return newlyUnlocked, nil
+}
+
+// evaluateAchievementRule combina la métrica actual con el mejor valor
+// guardado y verifica la condición. ok es false si todavía no hay datos para
+// la métrica (ej. ninguna decisión correcta con tiempo registrado).
+func evaluateAchievementRule(rule models.AchievementRule, metrics, stored map[string]int) (best int, ok, met bool) {
+	current, hasCurrent := metrics[rule.Metric]
+	previous, hasPrevious := stored[rule.Type]
+
+	switch {
+	case hasCurrent && hasPrevious:
+		best = current
+		previousIsBetter := previous > current
+		if rule.Operator == models.AchievementOperatorLTE {
+			previousIsBetter = previous < current
+		}
+		if previousIsBetter {
+			best = previous
+		}
+	case hasCurrent:
+		best = current
+	case hasPrevious:
+		best = previous
+	default:
+		return 0, false, false
+	}
+
+	if rule.Operator == models.AchievementOperatorLTE {
+		return best, true, best <= rule.Threshold
+	}
+	return best, true, best >= rule.Threshold
+}
+
+// achievementProgressPercent porcentaje de avance (0-100). Las condiciones de
+// "a lo sumo" (ej. tiempos) no tienen avance parcial.
+func achievementProgressPercent(rule models.AchievementRule, best int, ok bool) float64 {
+	if !ok {
+		return 0
+	}
+	if rule.Operator == models.AchievementOperatorLTE {
+		if best <= rule.Threshold {
+			return 100
+		}
+		return 0
+	}
+
+	if best <= 0 {
+		return 0
+	}
+	progress := float64(best) / float64(rule.Threshold) * 100
+	if progress > 100 {
+		progress = 100
+	}
+	return progress
+}
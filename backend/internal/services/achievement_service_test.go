@@ -0,0 +1,92 @@
+package services
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/smartstocks/backend/internal/models"
+)
+
+func TestDefaultAchievementRules(t *testing.T) {
+	rules, err := LoadAchievementRules("")
+	if err != nil {
+		t.Fatalf("embedded rules: %v", err)
+	}
+
+	// Los logros del ENUM original que el trigger nunca otorgaba
+	types := make(map[string]bool)
+	for _, rule := range rules {
+		types[rule.Type] = true
+	}
+	for _, want := range []string{"perfect_quiz", "speed_demon", "comeback_king", "simulator_expert"} {
+		if !types[want] {
+			t.Errorf("missing rule %s", want)
+		}
+	}
+}
+
+func TestParseAchievementRulesRejectsInvalid(t *testing.T) {
+	cases := map[string]string{
+		"unknown metric": `{"achievements": [{"type": "a", "name": "A", "events": ["quiz_completed"], "metric": "height", "operator": "gte", "threshold": 1}]}`,
+		"unknown event":  `{"achievements": [{"type": "a", "name": "A", "events": ["login"], "metric": "smartpoints", "operator": "gte", "threshold": 1}]}`,
+		"operator":       `{"achievements": [{"type": "a", "name": "A", "events": ["quiz_completed"], "metric": "smartpoints", "operator": "eq", "threshold": 1}]}`,
+		"threshold":      `{"achievements": [{"type": "a", "name": "A", "events": ["quiz_completed"], "metric": "smartpoints", "operator": "gte", "threshold": 0}]}`,
+		"duplicate": `{"achievements": [
+			{"type": "a", "name": "A", "events": ["quiz_completed"], "metric": "smartpoints", "operator": "gte", "threshold": 1},
+			{"type": "a", "name": "B", "events": ["quiz_completed"], "metric": "smartpoints", "operator": "gte", "threshold": 2}]}`,
+		"type too long": `{"achievements": [{"type": "` + strings.Repeat("x", 51) + `", "name": "A", "events": ["quiz_completed"], "metric": "smartpoints", "operator": "gte", "threshold": 1}]}`,
+	}
+	for name, data := range cases {
+		if _, err := parseAchievementRules([]byte(data)); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestEvaluateAchievementRule(t *testing.T) {
+	streak := models.AchievementRule{Type: "win_streak_5", Metric: models.AchievementMetricWinStreak, Operator: models.AchievementOperatorGTE, Threshold: 5}
+	speed := models.AchievementRule{Type: "speed_demon", Metric: models.AchievementMetricFastestCorrectDecision, Operator: models.AchievementOperatorLTE, Threshold: 10}
+
+	// La racha actual se cortó pero el mejor valor guardado se conserva
+	best, ok, met := evaluateAchievementRule(streak, map[string]int{models.AchievementMetricWinStreak: 1}, map[string]int{"win_streak_5": 4})
+	if best != 4 || !ok || met {
+		t.Errorf("streak: got best %d ok %v met %v, want 4 true false", best, ok, met)
+	}
+	_, _, met = evaluateAchievementRule(streak, map[string]int{models.AchievementMetricWinStreak: 5}, nil)
+	if !met {
+		t.Error("streak of 5 should unlock")
+	}
+
+	// Menor es mejor
+	best, _, met = evaluateAchievementRule(speed, map[string]int{models.AchievementMetricFastestCorrectDecision: 14}, map[string]int{"speed_demon": 12})
+	if best != 12 || met {
+		t.Errorf("speed: got best %d met %v, want 12 false", best, met)
+	}
+	_, _, met = evaluateAchievementRule(speed, map[string]int{models.AchievementMetricFastestCorrectDecision: 8}, nil)
+	if !met {
+		t.Error("8 seconds should unlock")
+	}
+
+	// Sin datos no se cumple ni registra progreso
+	if _, ok, met := evaluateAchievementRule(speed, map[string]int{}, nil); ok || met {
+		t.Errorf("no data: got ok %v met %v", ok, met)
+	}
+}
+
+func TestAchievementProgressPercent(t *testing.T) {
+	points := models.AchievementRule{Operator: models.AchievementOperatorGTE, Threshold: 1000}
+	if got := achievementProgressPercent(points, 250, true); got != 25 {
+		t.Errorf("250/1000 = %v, want 25", got)
+	}
+	if got := achievementProgressPercent(points, 4000, true); got != 100 {
+		t.Errorf("4000/1000 = %v, want 100", got)
+	}
+
+	speed := models.AchievementRule{Operator: models.AchievementOperatorLTE, Threshold: 10}
+	if got := achievementProgressPercent(speed, 12, true); got != 0 {
+		t.Errorf("12s = %v, want 0", got)
+	}
+	if got := achievementProgressPercent(speed, 0, false); got != 0 {
+		t.Errorf("no data = %v, want 0", got)
+	}
+}
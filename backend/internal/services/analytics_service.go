@@ -0,0 +1,307 @@
+package services
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/smartstocks/backend/internal/models"
+	"github.com/smartstocks/backend/internal/repository"
+	"github.com/smartstocks/backend/pkg/xlsx"
+)
+
+// Formatos de exportación
+const (
+	AnalyticsFormatCSV  = "csv"
+	AnalyticsFormatXLSX = "xlsx"
+)
+
+// Cada cuántas filas se envía lo escrito al cliente
+const analyticsFlushRows = 500
+
+// Dificultades en el orden de las columnas del reporte del simulador
+var simulatorDifficulties = []string{"easy", "medium", "hard"}
+
+type AnalyticsService struct {
+	analyticsRepo    *repository.AnalyticsRepository
+	classroomService *ClassroomService
+	schoolService    *SchoolService
+}
+
+func NewAnalyticsService(
+	analyticsRepo *repository.AnalyticsRepository,
+	classroomService *ClassroomService,
+	schoolService *SchoolService,
+) *AnalyticsService {
+	return &AnalyticsService{
+		analyticsRepo:    analyticsRepo,
+		classroomService: classroomService,
+		schoolService:    schoolService,
+	}
+}
+
+// ClassroomScope alcance de un aula del docente
+func (s *AnalyticsService) ClassroomScope(classroomID, teacherID string) (models.AnalyticsScope, error) {
+	classroom, err := s.classroomService.GetClassroom(classroomID, teacherID)
+	if err != nil {
+		return models.AnalyticsScope{}, err
+	}
+	return models.AnalyticsScope{Type: models.AnalyticsScopeClassroom, ID: classroom.ID, Name: classroom.Name}, nil
+}
+
+// SchoolScope alcance de un colegio administrado por el usuario
+func (s *AnalyticsService) SchoolScope(schoolID, adminID string) (models.AnalyticsScope, error) {
+	school, err := s.schoolService.getManagedSchool(schoolID, adminID)
+	if err != nil {
+		return models.AnalyticsScope{}, err
+	}
+	return models.AnalyticsScope{Type: models.AnalyticsScopeSchool, ID: school.ID, Name: school.Name}, nil
+}
+
+// ExportReports valida el formato y los reportes pedidos. En CSV se exporta
+// un único reporte; en XLSX, si no se indica ninguno, van todos en hojas
+// separadas.
+func ExportReports(format, report string) ([]string, error) {
+	if format != AnalyticsFormatCSV && format != AnalyticsFormatXLSX {
+		return nil, errors.New("invalid format, use csv or xlsx")
+	}
+
+	if report == "" {
+		if format == AnalyticsFormatCSV {
+			return nil, errors.New("report is required for csv exports")
+		}
+		return models.AnalyticsReports, nil
+	}
+
+	for _, known := range models.AnalyticsReports {
+		if report == known {
+			return []string{report}, nil
+		}
+	}
+	return nil, fmt.Errorf("invalid report, use one of: %s", strings.Join(models.AnalyticsReports, ", "))
+}
+
+// WriteExport escribe los reportes en w a medida que se leen de la base
+func (s *AnalyticsService) WriteExport(ctx context.Context, w io.Writer, scope models.AnalyticsScope, format string, reports []string, period models.ProgressRange) error {
+	var out reportWriter
+	if format == AnalyticsFormatXLSX {
+		out = &xlsxReportWriter{w: xlsx.NewWriter(w)}
+	} else {
+		out = &csvReportWriter{w: csv.NewWriter(w)}
+	}
+
+	for _, report := range reports {
+		if err := s.writeReport(ctx, out, scope, report, period); err != nil {
+			return fmt.Errorf("error exporting %s: %w", report, err)
+		}
+	}
+
+	return out.Close()
+}
+
+func (s *AnalyticsService) writeReport(ctx context.Context, out reportWriter, scope models.AnalyticsScope, report string, period models.ProgressRange) error {
+	switch report {
+	case models.AnalyticsReportQuizzes:
+		if err := out.Begin(report, quizHistoryHeader); err != nil {
+			return err
+		}
+		return s.analyticsRepo.StreamQuizHistory(ctx, scope, period, func(row *models.QuizHistoryRow) error {
+			return out.Row(quizHistoryValues(row)...)
+		})
+
+	case models.AnalyticsReportSimulator:
+		if err := out.Begin(report, simulatorStatsHeader()); err != nil {
+			return err
+		}
+		return s.analyticsRepo.StreamSimulatorStats(ctx, scope, period, func(row *models.SimulatorStatsRow) error {
+			return out.Row(simulatorStatsValues(row)...)
+		})
+
+	case models.AnalyticsReportCourses:
+		if err := out.Begin(report, courseProgressHeader); err != nil {
+			return err
+		}
+		return s.analyticsRepo.StreamCourseProgress(ctx, scope, func(row *models.CourseProgressRow) error {
+			return out.Row(courseProgressValues(row)...)
+		})
+
+	case models.AnalyticsReportPvP:
+		if err := out.Begin(report, pvpRecordHeader); err != nil {
+			return err
+		}
+		return s.analyticsRepo.StreamPvPRecords(ctx, scope, period, func(row *models.PvPRecordRow) error {
+			return out.Row(pvpRecordValues(row)...)
+		})
+	}
+
+	return fmt.Errorf("unknown report %q", report)
+}
+
+// === COLUMNAS ===
+
+var quizHistoryHeader = []string{
+	"user_id", "username", "quiz_id", "quiz_title", "difficulty", "score",
+	"correct_answers", "total_questions", "points_earned", "time_taken_seconds", "completed_at",
+}
+
+func quizHistoryValues(row *models.QuizHistoryRow) []interface{} {
+	var timeTaken interface{}
+	if row.TimeTakenSeconds.Valid {
+		timeTaken = row.TimeTakenSeconds.Int64
+	}
+	return []interface{}{
+		row.UserID, row.Username, row.QuizID, row.QuizTitle, row.Difficulty, row.Score,
+		row.CorrectAnswers, row.TotalQuestions, row.PointsEarned, timeTaken, row.CompletedAt,
+	}
+}
+
+func simulatorStatsHeader() []string {
+	header := []string{"user_id", "username", "total_attempts", "correct_attempts", "accuracy_rate", "total_points"}
+	for _, d := range simulatorDifficulties {
+		header = append(header, d+"_attempts", d+"_correct", d+"_accuracy_rate", d+"_points")
+	}
+	return header
+}
+
+func simulatorStatsValues(row *models.SimulatorStatsRow) []interface{} {
+	values := []interface{}{
+		row.UserID, row.Username, row.Stats.TotalAttempts, row.Stats.CorrectAttempts,
+		roundTo(row.Stats.AccuracyRate, 2), row.Stats.TotalPoints,
+	}
+	for _, d := range simulatorDifficulties {
+		stats := row.Stats.ByDifficulty[d]
+		values = append(values, stats.Attempts, stats.Correct, roundTo(stats.AccuracyRate, 2), stats.PointsEarned)
+	}
+	return values
+}
+
+var courseProgressHeader = []string{
+	"user_id", "username", "course_id", "course_title", "completed_lessons",
+	"total_lessons", "progress_percent", "is_completed", "started_at", "completed_at",
+}
+
+func courseProgressValues(row *models.CourseProgressRow) []interface{} {
+	progress := 0.0
+	if row.TotalLessons > 0 {
+		progress = roundTo(float64(row.CompletedLessons)*100/float64(row.TotalLessons), 2)
+	}
+	var completedAt interface{}
+	if row.CompletedAt.Valid {
+		completedAt = row.CompletedAt.Time
+	}
+	return []interface{}{
+		row.UserID, row.Username, row.CourseID, row.CourseTitle, row.CompletedLessons,
+		row.TotalLessons, progress, row.IsCompleted, row.StartedAt, completedAt,
+	}
+}
+
+var pvpRecordHeader = []string{"user_id", "username", "matches", "wins", "losses", "draws", "win_rate"}
+
+func pvpRecordValues(row *models.PvPRecordRow) []interface{} {
+	return []interface{}{
+		row.UserID, row.Username, row.Matches, row.Wins, row.Losses, row.Draws, roundTo(row.WinRate, 2),
+	}
+}
+
+// === ESCRITORES ===
+
+// reportWriter destino de los reportes: un CSV o un libro XLSX con una hoja por reporte
+type reportWriter interface {
+	Begin(report string, header []string) error
+	Row(values ...interface{}) error
+	Close() error
+}
+
+type csvReportWriter struct {
+	w    *csv.Writer
+	rows int
+}
+
+func (c *csvReportWriter) Begin(_ string, header []string) error {
+	return c.w.Write(header)
+}
+
+func (c *csvReportWriter) Row(values ...interface{}) error {
+	record := make([]string, len(values))
+	for i, v := range values {
+		record[i] = csvCell(v)
+	}
+	if err := c.w.Write(record); err != nil {
+		return err
+	}
+
+	c.rows++
+	if c.rows%analyticsFlushRows == 0 {
+		c.w.Flush()
+		return c.w.Error()
+	}
+	return nil
+}
+
+func (c *csvReportWriter) Close() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+type xlsxReportWriter struct {
+	w    *xlsx.Writer
+	rows int
+}
+
+func (x *xlsxReportWriter) Begin(report string, header []string) error {
+	if err := x.w.NewSheet(report); err != nil {
+		return err
+	}
+	values := make([]interface{}, len(header))
+	for i, h := range header {
+		values[i] = h
+	}
+	return x.w.WriteRow(values...)
+}
+
+func (x *xlsxReportWriter) Row(values ...interface{}) error {
+	if err := x.w.WriteRow(values...); err != nil {
+		return err
+	}
+
+	x.rows++
+	if x.rows%analyticsFlushRows == 0 {
+		return x.w.Flush()
+	}
+	return nil
+}
+
+func (x *xlsxReportWriter) Close() error {
+	return x.w.Close()
+}
+
+// csvCell formatea un valor para CSV. Los textos que empiezan como fórmula
+// se escapan para que la planilla no los ejecute al abrir el archivo.
+func csvCell(v interface{}) string {
+	switch val := v.(type) {
+	case nil:
+		return ""
+	case string:
+		if val != "" && strings.ContainsRune("=+-@\t\r", rune(val[0])) {
+			return "'" + val
+		}
+		return val
+	case int:
+		return strconv.Itoa(val)
+	case int64:
+		return strconv.FormatInt(val, 10)
+	case float64:
+		return strconv.FormatFloat(val, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(val)
+	case time.Time:
+		return val.Format(xlsx.TimeLayout)
+	default:
+		return fmt.Sprint(val)
+	}
+}
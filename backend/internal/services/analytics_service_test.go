@@ -0,0 +1,151 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/smartstocks/backend/internal/models"
+	"github.com/smartstocks/backend/pkg/xlsx"
+)
+
+func TestExportReports(t *testing.T) {
+	reports, err := ExportReports(AnalyticsFormatXLSX, "")
+	if err != nil || len(reports) != len(models.AnalyticsReports) {
+		t.Errorf("xlsx without report = %v, %v", reports, err)
+	}
+
+	reports, err = ExportReports(AnalyticsFormatCSV, models.AnalyticsReportPvP)
+	if err != nil || len(reports) != 1 || reports[0] != models.AnalyticsReportPvP {
+		t.Errorf("csv pvp = %v, %v", reports, err)
+	}
+
+	for _, tt := range []struct{ format, report string }{
+		{AnalyticsFormatCSV, ""},
+		{AnalyticsFormatCSV, "grades"},
+		{"pdf", models.AnalyticsReportQuizzes},
+	} {
+		if _, err := ExportReports(tt.format, tt.report); err == nil {
+			t.Errorf("ExportReports(%q, %q) should fail", tt.format, tt.report)
+		}
+	}
+}
+
+func TestCSVCell(t *testing.T) {
+	tests := []struct {
+		in   interface{}
+		want string
+	}{
+		{nil, ""},
+		{"ana", "ana"},
+		{"=HYPERLINK(\"x\")", "'=HYPERLINK(\"x\")"},
+		{"-5", "'-5"},
+		{42, "42"},
+		{int64(7), "7"},
+		{66.67, "66.67"},
+		{true, "true"},
+		{time.Date(2026, 3, 2, 10, 30, 0, 0, time.UTC), "2026-03-02 10:30:00"},
+	}
+	for _, tt := range tests {
+		if got := csvCell(tt.in); got != tt.want {
+			t.Errorf("csvCell(%v) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestReportColumns(t *testing.T) {
+	quiz := quizHistoryValues(&models.QuizHistoryRow{UserID: "u1", Username: "ana", CompletedAt: time.Now()})
+	if len(quiz) != len(quizHistoryHeader) {
+		t.Errorf("quiz columns = %d, header = %d", len(quiz), len(quizHistoryHeader))
+	}
+	if quiz[9] != nil {
+		t.Errorf("missing time taken should be empty, got %v", quiz[9])
+	}
+
+	course := courseProgressValues(&models.CourseProgressRow{TotalLessons: 3, CompletedLessons: 2})
+	if len(course) != len(courseProgressHeader) || course[6] != 66.67 || course[9] != nil {
+		t.Errorf("course values = %v", course)
+	}
+
+	pvp := pvpRecordValues(&models.PvPRecordRow{Matches: 3, Wins: 1, WinRate: 33.3333})
+	if len(pvp) != len(pvpRecordHeader) || pvp[6] != 33.33 {
+		t.Errorf("pvp values = %v", pvp)
+	}
+
+	row := &models.SimulatorStatsRow{
+		UserID:   "u1",
+		Username: "ana",
+		Stats: models.SimulatorStats{
+			TotalAttempts:   3,
+			CorrectAttempts: 2,
+			AccuracyRate:    66.666,
+			TotalPoints:     40,
+			ByDifficulty: map[string]models.SimulatorDifficultyStats{
+				"hard": {Attempts: 3, Correct: 2, AccuracyRate: 66.666, PointsEarned: 40},
+			},
+		},
+	}
+	simulator := simulatorStatsValues(row)
+	header := simulatorStatsHeader()
+	if len(simulator) != len(header) {
+		t.Fatalf("simulator columns = %d, header = %d", len(simulator), len(header))
+	}
+	// Sin intentos en easy/medium: ceros; hard al final
+	if simulator[6] != 0 || header[14] != "hard_attempts" || simulator[14] != 3 || simulator[16] != 66.67 {
+		t.Errorf("simulator values = %v", simulator)
+	}
+}
+
+func TestCSVReportWriter(t *testing.T) {
+	var buf bytes.Buffer
+	out := &csvReportWriter{w: csv.NewWriter(&buf)}
+
+	if err := out.Begin(models.AnalyticsReportPvP, pvpRecordHeader); err != nil {
+		t.Fatal(err)
+	}
+	if err := out.Row(pvpRecordValues(&models.PvPRecordRow{UserID: "u1", Username: "ana", Matches: 2, Wins: 1, Losses: 1, WinRate: 50})...); err != nil {
+		t.Fatal(err)
+	}
+	if err := out.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "user_id,username,matches,wins,losses,draws,win_rate\nu1,ana,2,1,1,0,50\n"
+	if buf.String() != want {
+		t.Errorf("csv = %q", buf.String())
+	}
+}
+
+func TestXLSXReportWriter(t *testing.T) {
+	var buf bytes.Buffer
+	out := &xlsxReportWriter{w: xlsx.NewWriter(&buf)}
+
+	for _, report := range []string{models.AnalyticsReportCourses, models.AnalyticsReportPvP} {
+		if err := out.Begin(report, pvpRecordHeader); err != nil {
+			t.Fatal(err)
+		}
+		if err := out.Row(pvpRecordValues(&models.PvPRecordRow{Username: "ana"})...); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := out.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sheets := 0
+	for _, f := range zr.File {
+		if strings.HasPrefix(f.Name, "xl/worksheets/") {
+			sheets++
+		}
+	}
+	if sheets != 2 {
+		t.Errorf("sheets = %d, want one per report", sheets)
+	}
+}
@@ -0,0 +1,484 @@
+package services
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/smartstocks/backend/internal/models"
+	"github.com/smartstocks/backend/internal/repository"
+)
+
+type AssignmentService struct {
+	assignmentRepo   *repository.AssignmentRepository
+	classroomService *ClassroomService
+	classroomRepo    *repository.ClassroomRepository
+	coursesRepo      *repository.CoursesRepository
+	quizRepo         *repository.QuizRepository
+	simulatorRepo    *repository.SimulatorRepository
+}
+
+func NewAssignmentService(
+	assignmentRepo *repository.AssignmentRepository,
+	classroomService *ClassroomService,
+	classroomRepo *repository.ClassroomRepository,
+	coursesRepo *repository.CoursesRepository,
+	quizRepo *repository.QuizRepository,
+	simulatorRepo *repository.SimulatorRepository,
+) *AssignmentService {
+	return &AssignmentService{
+		assignmentRepo:   assignmentRepo,
+		classroomService: classroomService,
+		classroomRepo:    classroomRepo,
+		coursesRepo:      coursesRepo,
+		quizRepo:         quizRepo,
+		simulatorRepo:    simulatorRepo,
+	}
+}
+
+// === DOCENTE ===
+
+// CreateAssignment asigna una actividad al aula
+func (s *AssignmentService) CreateAssignment(classroomID, teacherID string, req *models.CreateAssignmentRequest) (*models.Assignment, error) {
+	classroom, err := s.classroomService.GetClassroom(classroomID, teacherID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.validateTarget(req.AssignmentType, req.TargetID, teacherID); err != nil {
+		return nil, err
+	}
+
+	allowLate := true
+	if req.AllowLate != nil {
+		allowLate = *req.AllowLate
+	}
+
+	assignment := &models.Assignment{
+		ClassroomID:    classroomID,
+		TeacherID:      teacherID,
+		Title:          strings.TrimSpace(req.Title),
+		Instructions:   strings.TrimSpace(req.Instructions),
+		AssignmentType: req.AssignmentType,
+		TargetID:       req.TargetID,
+		DueAt:          req.DueAt,
+		AllowLate:      allowLate,
+		ClassroomName:  classroom.Name,
+		StudentCount:   classroom.StudentCount,
+	}
+
+	if err := s.assignmentRepo.CreateAssignment(assignment); err != nil {
+		return nil, fmt.Errorf("error creating assignment: %w", err)
+	}
+
+	return assignment, nil
+}
+
+// GetClassroomAssignments lista las tareas del aula
+func (s *AssignmentService) GetClassroomAssignments(classroomID, teacherID string) ([]models.Assignment, error) {
+	if _, err := s.classroomService.GetClassroom(classroomID, teacherID); err != nil {
+		return nil, err
+	}
+	return s.assignmentRepo.GetClassroomAssignments(classroomID)
+}
+
+// GetAssignment obtiene una tarea con el estado de entrega de cada alumno
+func (s *AssignmentService) GetAssignment(classroomID, assignmentID, teacherID string) (*models.AssignmentDetailResponse, error) {
+	assignment, err := s.getClassroomAssignment(classroomID, assignmentID, teacherID)
+	if err != nil {
+		return nil, err
+	}
+
+	members, err := s.classroomRepo.GetMembers(classroomID)
+	if err != nil {
+		return nil, err
+	}
+
+	submissions, err := s.assignmentRepo.GetAssignmentSubmissions(assignmentID)
+	if err != nil {
+		return nil, err
+	}
+
+	byUser := make(map[string]*models.AssignmentSubmission, len(submissions))
+	for i := range submissions {
+		byUser[submissions[i].UserID] = &submissions[i]
+	}
+
+	now := time.Now()
+	students := make([]models.StudentAssignmentStatus, 0, len(members))
+	for _, m := range members {
+		st := models.StudentAssignmentStatus{
+			UserID:   m.UserID,
+			Username: m.Username,
+		}
+		sub := byUser[m.UserID]
+		st.Status = submissionStatus(assignment, sub, now)
+		if sub != nil {
+			score := sub.Score
+			st.Score = &score
+			st.SubmittedAt.Time, st.SubmittedAt.Valid = sub.SubmittedAt, true
+		}
+		students = append(students, st)
+	}
+
+	return &models.AssignmentDetailResponse{
+		Assignment: assignment,
+		Students:   students,
+	}, nil
+}
+
+// UpdateAssignment edita título, consigna, vencimiento o entregas tardías
+func (s *AssignmentService) UpdateAssignment(classroomID, assignmentID, teacherID string, req *models.UpdateAssignmentRequest) (*models.Assignment, error) {
+	if _, err := s.getClassroomAssignment(classroomID, assignmentID, teacherID); err != nil {
+		return nil, err
+	}
+
+	if err := s.assignmentRepo.UpdateAssignment(assignmentID, req); err != nil {
+		return nil, fmt.Errorf("error updating assignment: %w", err)
+	}
+
+	return s.assignmentRepo.GetAssignmentByID(assignmentID)
+}
+
+// DeleteAssignment elimina la tarea y sus entregas
+func (s *AssignmentService) DeleteAssignment(classroomID, assignmentID, teacherID string) error {
+	if _, err := s.getClassroomAssignment(classroomID, assignmentID, teacherID); err != nil {
+		return err
+	}
+	return s.assignmentRepo.DeleteAssignment(assignmentID)
+}
+
+// GetGradebook arma el libro de calificaciones del aula
+func (s *AssignmentService) GetGradebook(classroomID, teacherID string) (*models.Gradebook, error) {
+	classroom, err := s.classroomService.GetClassroom(classroomID, teacherID)
+	if err != nil {
+		return nil, err
+	}
+
+	members, err := s.classroomRepo.GetMembers(classroomID)
+	if err != nil {
+		return nil, err
+	}
+
+	assignments, err := s.assignmentRepo.GetClassroomAssignments(classroomID)
+	if err != nil {
+		return nil, err
+	}
+
+	submissions, err := s.assignmentRepo.GetClassroomSubmissions(classroomID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.Gradebook{
+		Classroom:   classroom,
+		Assignments: assignments,
+		Rows:        buildGradebook(members, assignments, submissions, time.Now()),
+	}, nil
+}
+
+// WriteGradebookCSV escribe el libro de calificaciones como CSV: una fila por
+// alumno y una columna por tarea con el puntaje o el estado
+func (s *AssignmentService) WriteGradebookCSV(w io.Writer, gradebook *models.Gradebook) error {
+	writer := csv.NewWriter(w)
+
+	header := []string{"username"}
+	for _, a := range gradebook.Assignments {
+		header = append(header, csvCell(fmt.Sprintf("%s (%s)", a.Title, a.DueAt.Format("2006-01-02"))))
+	}
+	header = append(header, "average")
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+
+	for _, row := range gradebook.Rows {
+		record := []string{csvCell(row.Username)}
+		for _, entry := range row.Entries {
+			record = append(record, gradebookCell(entry))
+		}
+		record = append(record, strconv.FormatFloat(row.Average, 'f', 1, 64))
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// === ALUMNO ===
+
+// GetMyAssignments lista las tareas del alumno con su estado
+func (s *AssignmentService) GetMyAssignments(userID string) ([]models.MyAssignment, error) {
+	assignments, err := s.assignmentRepo.GetStudentAssignments(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	for i := range assignments {
+		assignments[i].Status = myAssignmentStatus(&assignments[i], now)
+	}
+
+	return assignments, nil
+}
+
+// GetMyAssignment obtiene una tarea del alumno junto con el quiz o escenario a resolver
+func (s *AssignmentService) GetMyAssignment(userID, assignmentID string) (*models.MyAssignmentDetail, error) {
+	assignment, err := s.assignmentRepo.GetStudentAssignment(userID, assignmentID)
+	if err != nil {
+		return nil, err
+	}
+	if assignment == nil {
+		return nil, errors.New("assignment not found")
+	}
+	assignment.Status = myAssignmentStatus(assignment, time.Now())
+
+	detail := &models.MyAssignmentDetail{Assignment: assignment}
+
+	switch assignment.AssignmentType {
+	case models.AssignmentTypeQuiz:
+		quiz, err := s.quizRepo.GetQuizByID(assignment.TargetID)
+		if err != nil {
+			return nil, err
+		}
+		if quiz != nil {
+			questions, err := s.quizRepo.GetQuestionsByQuizID(quiz.ID)
+			if err != nil {
+				return nil, err
+			}
+			detail.Quiz = &models.QuizResponse{
+				Quiz:       quiz,
+				Questions:  questions,
+				CanAttempt: assignment.Status == models.SubmissionStatusPending || (assignment.Status == models.SubmissionStatusMissing && assignment.AllowLate),
+			}
+		}
+	case models.AssignmentTypeScenario:
+		scenario, err := s.simulatorRepo.GetScenarioByID(assignment.TargetID)
+		if err != nil {
+			return nil, err
+		}
+		// Sin la decisión correcta ni el gráfico completo
+		detail.Scenario = &models.SimulatorScenarioResponse{
+			ScenarioID:  scenario.ID,
+			Difficulty:  scenario.Difficulty,
+			NewsContent: scenario.NewsContent,
+			ChartData: models.ChartData{
+				Labels:    scenario.ChartData.Labels,
+				Prices:    scenario.ChartData.Prices,
+				Ticker:    scenario.ChartData.Ticker,
+				AssetName: scenario.ChartData.AssetName,
+			},
+			ExpiresAt: scenario.ExpiresAt,
+		}
+	}
+
+	return detail, nil
+}
+
+// HasPendingAssignment indica si el alumno tiene la actividad asignada y aún no la
+// entregó. Permite resolver un quiz o escenario asignado fuera del día o del cooldown.
+func (s *AssignmentService) HasPendingAssignment(userID, assignmentType, targetID string) bool {
+	pending, err := s.assignmentRepo.HasPendingAssignment(userID, assignmentType, targetID)
+	if err != nil {
+		fmt.Printf("⚠️ Error checking pending assignments for %s: %v\n", userID, err)
+		return false
+	}
+	return pending
+}
+
+// === REGISTRO DE ENTREGAS ===
+// Los servicios de cursos, quiz y simulador informan la actividad completada.
+// Los errores solo se registran: la entrega no debe romper la actividad en sí.
+
+// RecordLessonCompleted registra la lección y, si terminó el curso, también el curso
+func (s *AssignmentService) RecordLessonCompleted(userID, lessonID, courseID string, courseCompleted bool, quizScore *int, quizTotal int) {
+	score := 100
+	if quizScore != nil && quizTotal > 0 {
+		score = *quizScore * 100 / quizTotal
+	}
+	s.recordSubmission(userID, models.AssignmentTypeLesson, lessonID, score)
+
+	if courseCompleted {
+		s.recordSubmission(userID, models.AssignmentTypeCourse, courseID, 100)
+	}
+}
+
+// RecordQuizSubmitted registra un quiz resuelto con su puntaje (0-100)
+func (s *AssignmentService) RecordQuizSubmitted(userID, quizID string, score int) {
+	s.recordSubmission(userID, models.AssignmentTypeQuiz, quizID, score)
+}
+
+// RecordSimulatorDecision registra la decisión sobre un escenario
+func (s *AssignmentService) RecordSimulatorDecision(userID, scenarioID string, wasCorrect bool) {
+	score := 0
+	if wasCorrect {
+		score = 100
+	}
+	s.recordSubmission(userID, models.AssignmentTypeScenario, scenarioID, score)
+}
+
+// === HELPERS ===
+
+func (s *AssignmentService) recordSubmission(userID, assignmentType, targetID string, score int) {
+	assignments, err := s.assignmentRepo.GetOpenAssignments(userID, assignmentType, targetID)
+	if err != nil {
+		fmt.Printf("⚠️ Error getting open assignments for %s: %v\n", userID, err)
+		return
+	}
+
+	now := time.Now()
+	for _, a := range assignments {
+		status := models.SubmissionStatusSubmitted
+		if now.After(a.DueAt) {
+			status = models.SubmissionStatusLate
+		}
+
+		submission := &models.AssignmentSubmission{
+			AssignmentID: a.ID,
+			UserID:       userID,
+			Status:       status,
+			Score:        score,
+			SubmittedAt:  now,
+		}
+		if err := s.assignmentRepo.CreateSubmission(submission); err != nil {
+			fmt.Printf("⚠️ Error recording submission for assignment %s: %v\n", a.ID, err)
+		}
+	}
+}
+
+// getClassroomAssignment obtiene la tarea verificando el aula y su docente
+func (s *AssignmentService) getClassroomAssignment(classroomID, assignmentID, teacherID string) (*models.Assignment, error) {
+	if _, err := s.classroomService.GetClassroom(classroomID, teacherID); err != nil {
+		return nil, err
+	}
+
+	assignment, err := s.assignmentRepo.GetAssignmentByID(assignmentID)
+	if err != nil {
+		return nil, err
+	}
+	if assignment.ClassroomID != classroomID {
+		return nil, errors.New("assignment not found")
+	}
+
+	return assignment, nil
+}
+
+// validateTarget verifica que exista la actividad a asignar
+func (s *AssignmentService) validateTarget(assignmentType, targetID, teacherID string) error {
+	var found bool
+
+	switch assignmentType {
+	case models.AssignmentTypeCourse:
+		course, err := s.coursesRepo.GetCourseByID(targetID, teacherID)
+		if err != nil {
+			return err
+		}
+		found = course != nil
+	case models.AssignmentTypeLesson:
+		lesson, err := s.coursesRepo.GetLessonByID(targetID, teacherID)
+		if err != nil {
+			return err
+		}
+		found = lesson != nil
+	case models.AssignmentTypeQuiz:
+		quiz, err := s.quizRepo.GetQuizByID(targetID)
+		if err != nil {
+			return err
+		}
+		found = quiz != nil
+	case models.AssignmentTypeScenario:
+		// GetScenarioByID no distingue "no existe" de otros errores
+		_, err := s.simulatorRepo.GetScenarioByID(targetID)
+		found = err == nil
+	default:
+		return errors.New("invalid assignment type")
+	}
+
+	if !found {
+		return fmt.Errorf("%s not found", assignmentType)
+	}
+	return nil
+}
+
+// submissionStatus calcula el estado de la entrega: submitted/late si entregó,
+// missing si venció sin entrega y pending si todavía está en plazo
+func submissionStatus(assignment *models.Assignment, submission *models.AssignmentSubmission, now time.Time) string {
+	if submission != nil {
+		return submission.Status
+	}
+	if now.After(assignment.DueAt) {
+		return models.SubmissionStatusMissing
+	}
+	return models.SubmissionStatusPending
+}
+
+// myAssignmentStatus estado de una tarea del alumno según su entrega
+func myAssignmentStatus(a *models.MyAssignment, now time.Time) string {
+	if a.Status != "" {
+		return a.Status
+	}
+	return submissionStatus(&a.Assignment, nil, now)
+}
+
+// buildGradebook arma una fila por alumno con una celda por tarea. Las tareas
+// faltantes cuentan como 0 en el promedio; las pendientes no cuentan.
+func buildGradebook(members []models.ClassroomMember, assignments []models.Assignment, submissions []models.AssignmentSubmission, now time.Time) []models.GradebookRow {
+	type key struct{ assignmentID, userID string }
+	byKey := make(map[key]*models.AssignmentSubmission, len(submissions))
+	for i := range submissions {
+		byKey[key{submissions[i].AssignmentID, submissions[i].UserID}] = &submissions[i]
+	}
+
+	rows := make([]models.GradebookRow, 0, len(members))
+	for _, m := range members {
+		row := models.GradebookRow{
+			UserID:   m.UserID,
+			Username: m.Username,
+			Entries:  make([]models.GradebookEntry, 0, len(assignments)),
+		}
+
+		var total, graded int
+		for i := range assignments {
+			sub := byKey[key{assignments[i].ID, m.UserID}]
+			entry := models.GradebookEntry{
+				AssignmentID: assignments[i].ID,
+				Status:       submissionStatus(&assignments[i], sub, now),
+			}
+
+			switch {
+			case sub != nil:
+				score := sub.Score
+				entry.Score = &score
+				total += score
+				graded++
+			case entry.Status == models.SubmissionStatusMissing:
+				graded++
+			}
+
+			row.Entries = append(row.Entries, entry)
+		}
+
+		if graded > 0 {
+			row.Average = float64(total) / float64(graded)
+		}
+		rows = append(rows, row)
+	}
+
+	return rows
+}
+
+// gradebookCell valor de una celda del CSV
+func gradebookCell(entry models.GradebookEntry) string {
+	if entry.Score == nil {
+		return entry.Status
+	}
+	if entry.Status == models.SubmissionStatusLate {
+		return strconv.Itoa(*entry.Score) + " (late)"
+	}
+	return strconv.Itoa(*entry.Score)
+}
@@ -0,0 +1,99 @@
+package services
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/smartstocks/backend/internal/models"
+)
+
+func TestSubmissionStatus(t *testing.T) {
+	now := time.Now()
+	past := &models.Assignment{DueAt: now.Add(-time.Hour)}
+	future := &models.Assignment{DueAt: now.Add(time.Hour)}
+	late := &models.AssignmentSubmission{Status: models.SubmissionStatusLate}
+
+	tests := []struct {
+		name       string
+		assignment *models.Assignment
+		submission *models.AssignmentSubmission
+		want       string
+	}{
+		{"pending", future, nil, models.SubmissionStatusPending},
+		{"missing", past, nil, models.SubmissionStatusMissing},
+		{"late", past, late, models.SubmissionStatusLate},
+	}
+
+	for _, tt := range tests {
+		if got := submissionStatus(tt.assignment, tt.submission, now); got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestBuildGradebook(t *testing.T) {
+	now := time.Now()
+	members := []models.ClassroomMember{
+		{UserID: "u1", Username: "ana"},
+		{UserID: "u2", Username: "beto"},
+	}
+	assignments := []models.Assignment{
+		{ID: "a1", Title: "Curso", DueAt: now.Add(-48 * time.Hour)},
+		{ID: "a2", Title: "Quiz", DueAt: now.Add(-time.Hour)},
+		{ID: "a3", Title: "Escenario", DueAt: now.Add(24 * time.Hour)},
+	}
+	submissions := []models.AssignmentSubmission{
+		{AssignmentID: "a1", UserID: "u1", Status: models.SubmissionStatusSubmitted, Score: 100},
+		{AssignmentID: "a2", UserID: "u1", Status: models.SubmissionStatusLate, Score: 60},
+		{AssignmentID: "a1", UserID: "u2", Status: models.SubmissionStatusSubmitted, Score: 80},
+	}
+
+	rows := buildGradebook(members, assignments, submissions, now)
+	if len(rows) != 2 {
+		t.Fatalf("len(rows) = %d, want 2", len(rows))
+	}
+
+	// Pendiente no cuenta: (100 + 60) / 2
+	if rows[0].Average != 80 {
+		t.Errorf("ana average = %v, want 80", rows[0].Average)
+	}
+	// Faltante cuenta como 0: (80 + 0) / 2
+	if rows[1].Average != 40 {
+		t.Errorf("beto average = %v, want 40", rows[1].Average)
+	}
+	if got := rows[1].Entries[1].Status; got != models.SubmissionStatusMissing {
+		t.Errorf("beto a2 status = %q, want missing", got)
+	}
+	if got := rows[1].Entries[2].Status; got != models.SubmissionStatusPending {
+		t.Errorf("beto a3 status = %q, want pending", got)
+	}
+
+	var buf bytes.Buffer
+	gradebook := &models.Gradebook{Assignments: assignments, Rows: rows}
+	if err := (&AssignmentService{}).WriteGradebookCSV(&buf, gradebook); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("csv lines = %d, want 3", len(lines))
+	}
+	if lines[1] != "ana,100,60 (late),pending,80.0" {
+		t.Errorf("csv row = %q", lines[1])
+	}
+
+	// Títulos y usernames no pueden inyectar fórmulas en la planilla
+	buf.Reset()
+	gradebook = &models.Gradebook{
+		Assignments: []models.Assignment{{ID: "a1", Title: "=HYPERLINK(\"x\")", DueAt: now}},
+		Rows:        []models.GradebookRow{{Username: "@beto", Entries: []models.GradebookEntry{{Status: models.SubmissionStatusPending}}}},
+	}
+	if err := (&AssignmentService{}).WriteGradebookCSV(&buf, gradebook); err != nil {
+		t.Fatal(err)
+	}
+	lines = strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if !strings.HasPrefix(lines[0], "username,\"'=HYPERLINK") || !strings.HasPrefix(lines[1], "'@beto,") {
+		t.Errorf("csv not escaped: %q", lines)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/smartstocks/backend/internal/models"
 
@@ -16,7 +17,12 @@ import (
 type AuthService struct {
 	userRepo         *repository.UserRepository
 	refreshTokenRepo *repository.RefreshTokenRepository
-	schoolRepo       *repository.SchoolRepository
+	schoolService    *SchoolService
+	loginAttemptRepo *repository.LoginAttemptRepository
+	loginProtection  *LoginProtectionService
+	twoFactorService *TwoFactorService
+	emailService     *EmailService
+	referrals        *ReferralService
 	jwtManager       *jwt.JWTManager
 	refreshTokenDays int
 }
@@ -24,21 +30,32 @@ type AuthService struct {
 func NewAuthService(
 	userRepo *repository.UserRepository,
 	refreshTokenRepo *repository.RefreshTokenRepository,
-	schoolRepo *repository.SchoolRepository,
+	schoolService *SchoolService,
+	loginAttemptRepo *repository.LoginAttemptRepository,
+	loginProtection *LoginProtectionService,
+	twoFactorService *TwoFactorService,
+	emailService *EmailService,
+	referrals *ReferralService,
 	jwtManager *jwt.JWTManager,
 	refreshTokenDays int,
 ) *AuthService {
 	return &AuthService{
 		userRepo:         userRepo,
 		refreshTokenRepo: refreshTokenRepo,
-		schoolRepo:       schoolRepo,
+		schoolService:    schoolService,
+		loginAttemptRepo: loginAttemptRepo,
+		loginProtection:  loginProtection,
+		twoFactorService: twoFactorService,
+		emailService:     emailService,
+		referrals:        referrals,
 		jwtManager:       jwtManager,
 		refreshTokenDays: refreshTokenDays,
 	}
 }
 
-// Register registra un nuevo usuario
-func (s *AuthService) Register(req *models.RegisterRequest) (*models.LoginResponse, error) {
+// Register registra un nuevo usuario. clientIP, userAgent y deviceID quedan
+// como el primer login exitoso de la cuenta.
+func (s *AuthService) Register(req *models.RegisterRequest, clientIP, userAgent, deviceID string) (*models.LoginResponse, error) {
 	// Validar si el email ya existe
 	existingUser, err := s.userRepo.GetUserByEmail(req.Email)
 	if err == nil && existingUser != nil {
@@ -53,9 +70,17 @@ func (s *AuthService) Register(req *models.RegisterRequest) (*models.LoginRespon
 
 	// Validar school_id si se proporciona
 	if req.SchoolID != nil {
-		school, err := s.schoolRepo.GetSchoolByID(*req.SchoolID)
-		if err != nil || school == nil {
-			return nil, errors.New("invalid school_id")
+		if err := s.schoolService.ValidateSchoolID(*req.SchoolID); err != nil {
+			return nil, err
+		}
+	}
+
+	// Validar el código de referido si se proporciona
+	var referrerID, referralCode string
+	if req.ReferralCode != nil && strings.TrimSpace(*req.ReferralCode) != "" {
+		referrerID, referralCode, err = s.referrals.ResolveCode(*req.ReferralCode)
+		if err != nil {
+			return nil, err
 		}
 	}
 
@@ -84,87 +109,156 @@ func (s *AuthService) Register(req *models.RegisterRequest) (*models.LoginRespon
 		user.ProfilePictureURL = sql.NullString{String: *req.ProfilePictureURL, Valid: true}
 	}
 
+	if err := s.userRepo.CreateUser(user); err != nil {
+		return nil, fmt.Errorf("error creating user: %w", err)
+	}
+
+	// El registro ya deja la sesión iniciada: se audita como login para que
+	// la detección de cuentas vinculadas (ej. invitarse a uno mismo) vea el
+	// dispositivo con el que se creó la cuenta
+	s.recordLoginAttempt(&models.LoginAttempt{
+		UserID:    sql.NullString{String: user.ID, Valid: true},
+		Email:     user.Email,
+		IPAddress: clientIP,
+		UserAgent: userAgent,
+		DeviceID:  sql.NullString{String: deviceID, Valid: deviceID != ""},
+		Success:   true,
+	})
+
+	// El colegio elegido queda como solicitud pendiente hasta que se verifique
 	if req.SchoolID != nil {
-		user.SchoolID = sql.NullString{String: *req.SchoolID, Valid: true}
+		if _, err := s.schoolService.RequestMembership(user.ID, &models.JoinSchoolRequest{SchoolID: req.SchoolID}); err != nil {
+			fmt.Printf("⚠️ Error requesting school membership for %s: %v\n", user.ID, err)
+		}
 	}
 
-	if err := s.userRepo.CreateUser(user); err != nil {
-		return nil, fmt.Errorf("error creating user: %w", err)
+	// La recompensa del que invitó se decide cuando el alumno verifica su
+	// email y completa su primer quiz
+	if referrerID != "" {
+		s.referrals.Attach(referrerID, referralCode, user.ID)
+	}
+	if _, err := s.referrals.EnsureCode(user.ID); err != nil {
+		fmt.Printf("⚠️ Error generating referral code for %s: %v\n", user.ID, err)
 	}
 
 	// TODO: Enviar email de verificación con el token
 	// s.emailService.SendVerificationEmail(user.Email, verificationToken)
 
-	// Generar tokens
-	accessToken, err := s.jwtManager.GenerateToken(user.ID, user.Email, user.Username)
-	if err != nil {
-		return nil, fmt.Errorf("error generating access token: %w", err)
-	}
+	return s.issueLoginResponse(user)
+}
 
-	refreshToken := s.jwtManager.GenerateRefreshToken()
-	refreshTokenModel := &models.RefreshToken{
-		UserID:    user.ID,
-		Token:     refreshToken,
-		ExpiresAt: s.jwtManager.GetRefreshTokenExpiration(s.refreshTokenDays),
+// Login autentica un usuario. Si tiene 2FA habilitado devuelve un challenge
+// en lugar de LoginResponse. deviceID es el de la cookie de dispositivo.
+func (s *AuthService) Login(req *models.LoginRequest, clientIP, userAgent, deviceID string) (*models.LoginResponse, *models.TwoFactorChallenge, error) {
+	attempt := &models.LoginAttempt{
+		Email:     req.Email,
+		IPAddress: clientIP,
+		UserAgent: userAgent,
+		DeviceID:  sql.NullString{String: deviceID, Valid: deviceID != ""},
 	}
 
-	if err := s.refreshTokenRepo.CreateRefreshToken(refreshTokenModel); err != nil {
-		return nil, fmt.Errorf("error creating refresh token: %w", err)
+	// Verificar bloqueos y backoff antes de tocar la contraseña
+	if err := s.loginProtection.Check(req.Email, clientIP); err != nil {
+		var blocked *LoginBlockedError
+		if errors.As(err, &blocked) {
+			attempt.FailureReason = sql.NullString{String: blocked.Reason, Valid: true}
+		}
+		s.recordLoginAttempt(attempt)
+		return nil, nil, err
 	}
 
-	// Obtener stats del usuario
-	stats, _ := s.userRepo.GetUserStats(user.ID)
-
-	return &models.LoginResponse{
-		AccessToken:  accessToken,
-		RefreshToken: refreshToken,
-		User:         s.userToUserInfo(user),
-		Stats:        stats,
-	}, nil
-}
-
-// Login autentica un usuario
-func (s *AuthService) Login(req *models.LoginRequest) (*models.LoginResponse, error) {
 	// Buscar usuario
 	user, err := s.userRepo.GetUserByEmail(req.Email)
 	if err != nil {
-		return nil, errors.New("invalid credentials")
+		s.handleFailedLogin(attempt, nil)
+		return nil, nil, errors.New("invalid credentials")
 	}
+	attempt.UserID = sql.NullString{String: user.ID, Valid: true}
 
 	// Verificar contraseña
 	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.Password)); err != nil {
-		return nil, errors.New("invalid credentials")
+		s.handleFailedLogin(attempt, user)
+		return nil, nil, errors.New("invalid credentials")
+	}
+
+	response, challenge, err := s.completeLogin(user)
+	if err == nil && challenge == nil {
+		// Con 2FA el login recién es exitoso tras el segundo factor
+		s.registerSuccessfulLogin(attempt)
+	}
+
+	return response, challenge, err
+}
+
+// completeLogin emite los tokens de un usuario ya autenticado o, si tiene
+// 2FA, un challenge para el segundo paso
+func (s *AuthService) completeLogin(user *models.User) (*models.LoginResponse, *models.TwoFactorChallenge, error) {
+	twoFactorEnabled, err := s.twoFactorService.IsEnabled(user.ID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error checking two-factor: %w", err)
+	}
+	if twoFactorEnabled {
+		challenge, err := s.twoFactorService.CreateChallenge(user.ID)
+		if err != nil {
+			return nil, nil, err
+		}
+		return nil, challenge, nil
 	}
 
 	// Actualizar último login
 	_ = s.userRepo.UpdateLastLogin(user.ID)
 
-	// Generar tokens
-	accessToken, err := s.jwtManager.GenerateToken(user.ID, user.Email, user.Username)
+	response, err := s.issueLoginResponse(user)
+	return response, nil, err
+}
+
+// LoginTwoFactor completa el login de un usuario con 2FA. Los códigos
+// incorrectos suman al mismo contador de bloqueo que las contraseñas.
+func (s *AuthService) LoginTwoFactor(req *models.TwoFactorLoginRequest, clientIP, userAgent, deviceID string) (*models.LoginResponse, error) {
+	if req.Code == "" && req.RecoveryCode == "" {
+		return nil, errors.New("code or recovery_code is required")
+	}
+
+	userID, err := s.twoFactorService.ChallengeUserID(req.ChallengeToken)
 	if err != nil {
-		return nil, fmt.Errorf("error generating access token: %w", err)
+		return nil, err
 	}
 
-	refreshToken := s.jwtManager.GenerateRefreshToken()
-	refreshTokenModel := &models.RefreshToken{
-		UserID:    user.ID,
-		Token:     refreshToken,
-		ExpiresAt: s.jwtManager.GetRefreshTokenExpiration(s.refreshTokenDays),
+	user, err := s.userRepo.GetUserByID(userID)
+	if err != nil {
+		return nil, errors.New("user not found")
 	}
 
-	if err := s.refreshTokenRepo.CreateRefreshToken(refreshTokenModel); err != nil {
-		return nil, fmt.Errorf("error creating refresh token: %w", err)
+	attempt := &models.LoginAttempt{
+		UserID:    sql.NullString{String: user.ID, Valid: true},
+		Email:     user.Email,
+		IPAddress: clientIP,
+		UserAgent: userAgent,
+		DeviceID:  sql.NullString{String: deviceID, Valid: deviceID != ""},
 	}
 
-	// Obtener stats
-	stats, _ := s.userRepo.GetUserStats(user.ID)
+	if err := s.loginProtection.Check(user.Email, clientIP); err != nil {
+		var blocked *LoginBlockedError
+		if errors.As(err, &blocked) {
+			attempt.FailureReason = sql.NullString{String: blocked.Reason, Valid: true}
+		}
+		s.recordLoginAttempt(attempt)
+		return nil, err
+	}
 
-	return &models.LoginResponse{
-		AccessToken:  accessToken,
-		RefreshToken: refreshToken,
-		User:         s.userToUserInfo(user),
-		Stats:        stats,
-	}, nil
+	if _, err := s.twoFactorService.VerifyChallenge(req); err != nil {
+		if errors.Is(err, ErrInvalidTwoFactorCode) {
+			s.handleFailedLogin(attempt, user)
+		}
+		return nil, err
+	}
+
+	s.registerSuccessfulLogin(attempt)
+
+	// Actualizar último login
+	_ = s.userRepo.UpdateLastLogin(user.ID)
+
+	return s.issueLoginResponse(user)
 }
 
 // RefreshToken genera un nuevo access token usando un refresh token
@@ -200,7 +294,18 @@ func (s *AuthService) RefreshToken(refreshTokenStr string) (*models.LoginRespons
 
 // VerifyEmail verifica el email de un usuario
 func (s *AuthService) VerifyEmail(token string) error {
-	return s.userRepo.VerifyEmail(token)
+	userID, err := s.userRepo.VerifyEmail(token)
+	if err != nil {
+		return err
+	}
+	s.onEmailVerified(userID)
+	return nil
+}
+
+// onEmailVerified corre lo que depende de un email verificado (la
+// recompensa por referido)
+func (s *AuthService) onEmailVerified(userID string) {
+	s.referrals.Evaluate(userID)
 }
 
 // Logout invalida el refresh token
@@ -208,6 +313,65 @@ func (s *AuthService) Logout(refreshToken string) error {
 	return s.refreshTokenRepo.DeleteRefreshToken(refreshToken)
 }
 
+// handleFailedLogin registra el fallo y notifica por email si la cuenta quedó bloqueada
+func (s *AuthService) handleFailedLogin(attempt *models.LoginAttempt, user *models.User) {
+	attempt.FailureReason = sql.NullString{String: "invalid_credentials", Valid: true}
+	s.recordLoginAttempt(attempt)
+
+	locked := s.loginProtection.RegisterFailure(attempt.Email, attempt.IPAddress)
+	if locked && user != nil {
+		go func() {
+			if err := s.emailService.SendLockoutNotification(user.Email, user.Username, s.loginProtection.LockoutMinutes()); err != nil {
+				fmt.Printf("Warning: could not send lockout email to %s: %v\n", user.Email, err)
+			}
+		}()
+	}
+}
+
+// registerSuccessfulLogin limpia los contadores de fallos y audita el éxito
+func (s *AuthService) registerSuccessfulLogin(attempt *models.LoginAttempt) {
+	s.loginProtection.RegisterSuccess(attempt.Email, attempt.IPAddress)
+	attempt.Success = true
+	attempt.FailureReason = sql.NullString{}
+	s.recordLoginAttempt(attempt)
+}
+
+// recordLoginAttempt guarda la auditoría sin interrumpir el login si falla
+func (s *AuthService) recordLoginAttempt(attempt *models.LoginAttempt) {
+	if err := s.loginAttemptRepo.RecordAttempt(attempt); err != nil {
+		fmt.Printf("Warning: could not record login attempt: %v\n", err)
+	}
+}
+
+// issueLoginResponse genera access y refresh token para el usuario
+func (s *AuthService) issueLoginResponse(user *models.User) (*models.LoginResponse, error) {
+	accessToken, err := s.jwtManager.GenerateToken(user.ID, user.Email, user.Username)
+	if err != nil {
+		return nil, fmt.Errorf("error generating access token: %w", err)
+	}
+
+	refreshToken := s.jwtManager.GenerateRefreshToken()
+	refreshTokenModel := &models.RefreshToken{
+		UserID:    user.ID,
+		Token:     refreshToken,
+		ExpiresAt: s.jwtManager.GetRefreshTokenExpiration(s.refreshTokenDays),
+	}
+
+	if err := s.refreshTokenRepo.CreateRefreshToken(refreshTokenModel); err != nil {
+		return nil, fmt.Errorf("error creating refresh token: %w", err)
+	}
+
+	// Obtener stats
+	stats, _ := s.userRepo.GetUserStats(user.ID)
+
+	return &models.LoginResponse{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		User:         s.userToUserInfo(user),
+		Stats:        stats,
+	}, nil
+}
+
 // Helper: convierte User a UserInfo
 func (s *AuthService) userToUserInfo(user *models.User) *models.UserInfo {
 	userInfo := &models.UserInfo{
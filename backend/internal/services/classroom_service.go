@@ -0,0 +1,338 @@
+package services
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/smartstocks/backend/internal/models"
+	"github.com/smartstocks/backend/internal/repository"
+)
+
+const (
+	joinCodeLength   = 6
+	joinCodeAlphabet = "ABCDEFGHJKMNPQRSTUVWXYZ23456789"
+	joinCodeRetries  = 5
+)
+
+// ErrClassroomForbidden el usuario no es el docente del aula
+var ErrClassroomForbidden = errors.New("unauthorized: you are not the teacher of this classroom")
+
+type ClassroomService struct {
+	classroomRepo *repository.ClassroomRepository
+	userRepo      *repository.UserRepository
+}
+
+func NewClassroomService(
+	classroomRepo *repository.ClassroomRepository,
+	userRepo *repository.UserRepository,
+) *ClassroomService {
+	return &ClassroomService{
+		classroomRepo: classroomRepo,
+		userRepo:      userRepo,
+	}
+}
+
+// === DOCENTE ===
+
+// CreateClassroom crea un aula del colegio del docente con un código de acceso único
+func (s *ClassroomService) CreateClassroom(teacherID string, req *models.CreateClassroomRequest) (*models.Classroom, error) {
+	teacher, err := s.userRepo.GetUserByID(teacherID)
+	if err != nil {
+		return nil, err
+	}
+
+	classroom := &models.Classroom{
+		TeacherID:       teacherID,
+		SchoolID:        teacher.SchoolID,
+		Name:            strings.TrimSpace(req.Name),
+		Description:     strings.TrimSpace(req.Description),
+		TeacherUsername: teacher.Username,
+	}
+
+	// Reintentar ante la (improbable) colisión del código
+	for i := 0; i < joinCodeRetries; i++ {
+		classroom.JoinCode, err = generateJoinCode()
+		if err != nil {
+			return nil, err
+		}
+
+		existing, err := s.classroomRepo.GetClassroomByJoinCode(classroom.JoinCode)
+		if err != nil {
+			return nil, err
+		}
+		if existing == nil {
+			if err := s.classroomRepo.CreateClassroom(classroom); err != nil {
+				return nil, fmt.Errorf("error creating classroom: %w", err)
+			}
+			return classroom, nil
+		}
+	}
+
+	return nil, errors.New("could not generate a unique join code")
+}
+
+// GetTeacherClassrooms lista las aulas del docente
+func (s *ClassroomService) GetTeacherClassrooms(teacherID string) ([]models.Classroom, error) {
+	return s.classroomRepo.GetTeacherClassrooms(teacherID)
+}
+
+// GetClassroom obtiene un aula del docente
+func (s *ClassroomService) GetClassroom(classroomID, teacherID string) (*models.Classroom, error) {
+	return s.getOwnedClassroom(classroomID, teacherID)
+}
+
+// UpdateClassroom edita nombre, descripción o estado del aula
+func (s *ClassroomService) UpdateClassroom(classroomID, teacherID string, req *models.UpdateClassroomRequest) (*models.Classroom, error) {
+	if _, err := s.getOwnedClassroom(classroomID, teacherID); err != nil {
+		return nil, err
+	}
+
+	if err := s.classroomRepo.UpdateClassroom(classroomID, req); err != nil {
+		return nil, fmt.Errorf("error updating classroom: %w", err)
+	}
+
+	return s.classroomRepo.GetClassroomByID(classroomID)
+}
+
+// DeleteClassroom elimina el aula
+func (s *ClassroomService) DeleteClassroom(classroomID, teacherID string) error {
+	if _, err := s.getOwnedClassroom(classroomID, teacherID); err != nil {
+		return err
+	}
+	return s.classroomRepo.DeleteClassroom(classroomID)
+}
+
+// RegenerateJoinCode invalida el código actual (ej. si se filtró) y genera otro
+func (s *ClassroomService) RegenerateJoinCode(classroomID, teacherID string) (*models.Classroom, error) {
+	classroom, err := s.getOwnedClassroom(classroomID, teacherID)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := 0; i < joinCodeRetries; i++ {
+		code, err := generateJoinCode()
+		if err != nil {
+			return nil, err
+		}
+
+		existing, err := s.classroomRepo.GetClassroomByJoinCode(code)
+		if err != nil {
+			return nil, err
+		}
+		if existing == nil {
+			if err := s.classroomRepo.UpdateJoinCode(classroomID, code); err != nil {
+				return nil, fmt.Errorf("error updating join code: %w", err)
+			}
+			classroom.JoinCode = code
+			return classroom, nil
+		}
+	}
+
+	return nil, errors.New("could not generate a unique join code")
+}
+
+// GetMembers lista los alumnos del aula
+func (s *ClassroomService) GetMembers(classroomID, teacherID string) ([]models.ClassroomMember, error) {
+	if _, err := s.getOwnedClassroom(classroomID, teacherID); err != nil {
+		return nil, err
+	}
+	return s.classroomRepo.GetMembers(classroomID)
+}
+
+// RemoveMember quita un alumno del aula
+func (s *ClassroomService) RemoveMember(classroomID, teacherID, studentID string) error {
+	if _, err := s.getOwnedClassroom(classroomID, teacherID); err != nil {
+		return err
+	}
+
+	removed, err := s.classroomRepo.RemoveMember(classroomID, studentID)
+	if err != nil {
+		return err
+	}
+	if !removed {
+		return errors.New("student is not a member of this classroom")
+	}
+
+	return nil
+}
+
+// GetClassroomProgress dashboard del aula: actividad de cada alumno y totales
+func (s *ClassroomService) GetClassroomProgress(classroomID, teacherID string, period models.ProgressRange) (*models.ClassroomProgressResponse, error) {
+	classroom, err := s.getOwnedClassroom(classroomID, teacherID)
+	if err != nil {
+		return nil, err
+	}
+
+	students, err := s.classroomRepo.GetStudentsProgress(classroomID, period)
+	if err != nil {
+		return nil, fmt.Errorf("error getting progress: %w", err)
+	}
+
+	return &models.ClassroomProgressResponse{
+		Classroom: classroom,
+		Range:     period,
+		Summary:   summarizeProgress(students),
+		Students:  students,
+	}, nil
+}
+
+// GetStudentProgress vista detallada de un alumno del aula
+func (s *ClassroomService) GetStudentProgress(classroomID, teacherID, studentID string, period models.ProgressRange) (*models.StudentProgressDetail, error) {
+	if _, err := s.getOwnedClassroom(classroomID, teacherID); err != nil {
+		return nil, err
+	}
+
+	summary, err := s.classroomRepo.GetStudentProgress(classroomID, studentID, period)
+	if err != nil {
+		return nil, fmt.Errorf("error getting progress: %w", err)
+	}
+	if summary == nil {
+		return nil, errors.New("student is not a member of this classroom")
+	}
+
+	courses, err := s.classroomRepo.GetStudentCourses(studentID, period)
+	if err != nil {
+		return nil, err
+	}
+
+	lessons, err := s.classroomRepo.GetStudentLessons(studentID, period)
+	if err != nil {
+		return nil, err
+	}
+
+	quizzes, err := s.classroomRepo.GetStudentQuizzes(studentID, period)
+	if err != nil {
+		return nil, err
+	}
+
+	simulator, err := s.classroomRepo.GetStudentSimulatorAccuracy(studentID, period)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.StudentProgressDetail{
+		Range:     period,
+		Summary:   *summary,
+		Courses:   courses,
+		Lessons:   lessons,
+		Quizzes:   quizzes,
+		Simulator: simulator,
+	}, nil
+}
+
+// === ALUMNO ===
+
+// JoinClassroom une al alumno al aula del código
+func (s *ClassroomService) JoinClassroom(userID, joinCode string) (*models.Classroom, error) {
+	code := strings.ToUpper(strings.TrimSpace(joinCode))
+
+	classroom, err := s.classroomRepo.GetClassroomByJoinCode(code)
+	if err != nil {
+		return nil, err
+	}
+	if classroom == nil || !classroom.IsActive {
+		return nil, errors.New("invalid join code")
+	}
+	if classroom.TeacherID == userID {
+		return nil, errors.New("you are the teacher of this classroom")
+	}
+
+	joined, err := s.classroomRepo.AddMember(classroom.ID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("error joining classroom: %w", err)
+	}
+	if !joined {
+		return nil, errors.New("already a member of this classroom")
+	}
+
+	classroom.JoinCode = ""
+	classroom.StudentCount++
+	return classroom, nil
+}
+
+// LeaveClassroom saca al alumno del aula
+func (s *ClassroomService) LeaveClassroom(userID, classroomID string) error {
+	removed, err := s.classroomRepo.RemoveMember(classroomID, userID)
+	if err != nil {
+		return err
+	}
+	if !removed {
+		return errors.New("not a member of this classroom")
+	}
+	return nil
+}
+
+// GetStudentClassrooms lista las aulas del alumno
+func (s *ClassroomService) GetStudentClassrooms(userID string) ([]models.Classroom, error) {
+	return s.classroomRepo.GetStudentClassrooms(userID)
+}
+
+// === HELPERS ===
+
+// getOwnedClassroom obtiene el aula verificando que pertenezca al docente (o sea admin)
+func (s *ClassroomService) getOwnedClassroom(classroomID, teacherID string) (*models.Classroom, error) {
+	classroom, err := s.classroomRepo.GetClassroomByID(classroomID)
+	if err != nil {
+		return nil, err
+	}
+
+	if classroom.TeacherID != teacherID {
+		role, err := s.userRepo.GetUserRole(teacherID)
+		if err != nil {
+			return nil, err
+		}
+		if role != models.RoleAdmin {
+			return nil, ErrClassroomForbidden
+		}
+	}
+
+	return classroom, nil
+}
+
+// summarizeProgress calcula los totales del aula a partir de cada alumno
+func summarizeProgress(students []models.StudentProgress) models.ClassroomProgressSummary {
+	summary := models.ClassroomProgressSummary{StudentCount: len(students)}
+
+	var quizScoreSum float64
+	var simulatorCorrect int
+	for _, st := range students {
+		if st.LastActivityAt.Valid {
+			summary.ActiveStudents++
+		}
+		summary.LessonsCompleted += st.LessonsCompleted
+		summary.CoursesCompleted += st.CoursesCompleted
+		summary.QuizAttempts += st.QuizAttempts
+		summary.SimulatorAttempts += st.SimulatorAttempts
+		summary.PvPMatches += st.PvPMatches
+
+		// Promedio ponderado por intentos, no por alumno
+		quizScoreSum += st.AvgQuizScore * float64(st.QuizAttempts)
+		simulatorCorrect += st.SimulatorCorrect
+	}
+
+	if summary.QuizAttempts > 0 {
+		summary.AvgQuizScore = quizScoreSum / float64(summary.QuizAttempts)
+	}
+	if summary.SimulatorAttempts > 0 {
+		summary.SimulatorAccuracy = float64(simulatorCorrect) * 100 / float64(summary.SimulatorAttempts)
+	}
+
+	return summary
+}
+
+// generateJoinCode genera un código corto sin caracteres ambiguos (0/O, 1/I/L)
+func generateJoinCode() (string, error) {
+	raw := make([]byte, joinCodeLength)
+	if _, err := rand.Read(raw); err != nil {
+		return "", fmt.Errorf("error generating join code: %w", err)
+	}
+
+	code := make([]byte, joinCodeLength)
+	for i, b := range raw {
+		code[i] = joinCodeAlphabet[int(b)%len(joinCodeAlphabet)]
+	}
+
+	return string(code), nil
+}
@@ -0,0 +1,65 @@
+package services
+
+import (
+	"database/sql"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/smartstocks/backend/internal/models"
+)
+
+func TestSummarizeProgress(t *testing.T) {
+	students := []models.StudentProgress{
+		{
+			QuizAttempts:      3,
+			AvgQuizScore:      90,
+			SimulatorAttempts: 4,
+			SimulatorCorrect:  3,
+			LessonsCompleted:  2,
+			LastActivityAt:    sql.NullTime{Time: time.Now(), Valid: true},
+		},
+		{
+			QuizAttempts:      1,
+			AvgQuizScore:      50,
+			SimulatorAttempts: 0,
+			LastActivityAt:    sql.NullTime{Time: time.Now(), Valid: true},
+		},
+		{}, // alumno sin actividad en el período
+	}
+
+	summary := summarizeProgress(students)
+
+	if summary.StudentCount != 3 || summary.ActiveStudents != 2 {
+		t.Errorf("unexpected counts: %+v", summary)
+	}
+	if summary.QuizAttempts != 4 || summary.LessonsCompleted != 2 {
+		t.Errorf("unexpected totals: %+v", summary)
+	}
+	// Ponderado por intentos: (3*90 + 1*50) / 4 = 80
+	if summary.AvgQuizScore != 80 {
+		t.Errorf("AvgQuizScore = %v, want 80", summary.AvgQuizScore)
+	}
+	if summary.SimulatorAccuracy != 75 {
+		t.Errorf("SimulatorAccuracy = %v, want 75", summary.SimulatorAccuracy)
+	}
+
+	if empty := summarizeProgress(nil); empty.AvgQuizScore != 0 || empty.SimulatorAccuracy != 0 {
+		t.Errorf("empty classroom should have zero averages: %+v", empty)
+	}
+}
+
+func TestGenerateJoinCode(t *testing.T) {
+	code, err := generateJoinCode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(code) != joinCodeLength {
+		t.Fatalf("len(code) = %d, want %d", len(code), joinCodeLength)
+	}
+	for _, ch := range code {
+		if !strings.ContainsRune(joinCodeAlphabet, ch) {
+			t.Errorf("unexpected character %q in %s", ch, code)
+		}
+	}
+}
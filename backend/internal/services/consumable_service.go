@@ -0,0 +1,303 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/smartstocks/backend/internal/config"
+	"github.com/smartstocks/backend/internal/models"
+	"github.com/smartstocks/backend/internal/repository"
+)
+
+// ConsumableService cobra y registra los consumibles (pistas, intentos
+// extra y segundas oportunidades). Cada servicio de juego decide cuándo se
+// usan; este servicio solo maneja precios, cobro y consumo.
+type ConsumableService struct {
+	consumableRepo *repository.ConsumableRepository
+	tokensService  *TokensService
+	cfg            *config.ConsumablesConfig
+}
+
+func NewConsumableService(
+	consumableRepo *repository.ConsumableRepository,
+	tokensService *TokensService,
+	cfg *config.ConsumablesConfig,
+) *ConsumableService {
+	return &ConsumableService{
+		consumableRepo: consumableRepo,
+		tokensService:  tokensService,
+		cfg:            cfg,
+	}
+}
+
+// Prices precios vigentes de los consumibles
+func (s *ConsumableService) Prices() models.ConsumablePrices {
+	return models.ConsumablePrices{
+		SimulatorHint:             s.cfg.SimulatorHintPrice,
+		SimulatorExtraAttempt:     s.cfg.SimulatorExtraAttemptPrice,
+		QuizExtraAttempt:          s.cfg.QuizExtraAttemptPrice,
+		PvPSecondChance:           s.cfg.PvPSecondChancePrice,
+		ExtraAttemptPointsPercent: s.cfg.ExtraAttemptPointsPercent,
+		ExtraAttemptsPerDay:       s.cfg.ExtraAttemptsPerDay,
+	}
+}
+
+// GetConsumables obtiene los precios y las últimas compras del usuario
+func (s *ConsumableService) GetConsumables(userID string, limit int) (*models.ConsumablesResponse, error) {
+	purchases, err := s.consumableRepo.GetPurchases(userID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("error getting purchases: %w", err)
+	}
+
+	return &models.ConsumablesResponse{
+		Prices:    s.Prices(),
+		Purchases: purchases,
+	}, nil
+}
+
+func (s *ConsumableService) price(purchaseType string) int {
+	switch purchaseType {
+	case models.ConsumableSimulatorHint:
+		return s.cfg.SimulatorHintPrice
+	case models.ConsumableSimulatorExtraAttempt:
+		return s.cfg.SimulatorExtraAttemptPrice
+	case models.ConsumableQuizExtraAttempt:
+		return s.cfg.QuizExtraAttemptPrice
+	case models.ConsumablePvPSecondChance:
+		return s.cfg.PvPSecondChancePrice
+	}
+	return 0
+}
+
+// Purchase cobra el consumible como una transacción 'purchase' que referencia
+// al escenario, quiz o partida, y registra la compra. apply (opcional) corre
+// en la misma transacción: si falla, el cobro se revierte. consumed indica
+// que el consumible se usa en el momento (pistas y segundas oportunidades).
+func (s *ConsumableService) Purchase(userID, purchaseType, referenceID, description, idempotencyKey string, consumed bool, apply func(tx *sql.Tx) error) (*models.ConsumablePurchase, error) {
+	price := s.price(purchaseType)
+	if price <= 0 {
+		return nil, errors.New("consumable not available")
+	}
+
+	purchase := &models.ConsumablePurchase{
+		ID:          uuid.New().String(),
+		UserID:      userID,
+		Type:        purchaseType,
+		ReferenceID: referenceID,
+		Price:       price,
+	}
+	if consumed {
+		now := time.Now()
+		purchase.ConsumedAt = &now
+	}
+
+	// Un reintento con la misma clave devuelve la compra original sin cobrar
+	previous, err := s.GetPurchaseByKey(userID, purchaseType, idempotencyKey)
+	if err != nil {
+		return nil, err
+	}
+	if previous != nil {
+		return previous, nil
+	}
+
+	// El índice único de la compra cubre dos pedidos simultáneos: en ese caso
+	// se revierte el cobro. El límite diario de intentos extra se cuenta con
+	// el saldo bloqueado por el cobro, así dos compras a la vez no lo superan.
+	taken := consumableTakenError(purchaseType)
+	limited := purchaseType == models.ConsumableSimulatorExtraAttempt || purchaseType == models.ConsumableQuizExtraAttempt
+	key := tokenIdempotencyKey(purchaseType, userID, idempotencyKey, purchase.ID)
+	err = s.tokensService.SpendTokensWith(userID, price, "purchase", description, &referenceID, key, func(tx *sql.Tx) error {
+		if limited {
+			purchases, err := s.consumableRepo.CountRecentPurchasesTx(tx, userID, purchaseType)
+			if err != nil {
+				return err
+			}
+			if extraAttemptLimitReached(purchases, s.cfg.ExtraAttemptsPerDay) {
+				return errors.New("daily extra attempt limit reached")
+			}
+		}
+
+		added, err := s.consumableRepo.CreatePurchaseTx(tx, purchase, key)
+		if err != nil {
+			return err
+		}
+		if !added {
+			return taken
+		}
+		if apply != nil {
+			return apply(tx)
+		}
+		return nil
+	})
+	if errors.Is(err, repository.ErrDuplicateTransaction) {
+		// Otro pedido con la misma clave se aplicó mientras tanto
+		if previous, lookupErr := s.GetPurchaseByKey(userID, purchaseType, idempotencyKey); lookupErr == nil && previous != nil {
+			return previous, nil
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return purchase, nil
+}
+
+// GetPurchaseByKey obtiene la compra hecha con la clave enviada por el
+// cliente (nil si no hay clave o no existe)
+func (s *ConsumableService) GetPurchaseByKey(userID, purchaseType, idempotencyKey string) (*models.ConsumablePurchase, error) {
+	if idempotencyKey == "" {
+		return nil, nil
+	}
+	purchase, err := s.consumableRepo.GetPurchaseByKey(tokenIdempotencyKey(purchaseType, userID, idempotencyKey, ""))
+	if err != nil {
+		return nil, fmt.Errorf("error getting purchase: %w", err)
+	}
+	return purchase, nil
+}
+
+// consumableTakenError error cuando el consumible ya fue comprado para la
+// referencia
+func consumableTakenError(purchaseType string) error {
+	switch purchaseType {
+	case models.ConsumableSimulatorHint:
+		return errors.New("hint already purchased")
+	case models.ConsumablePvPSecondChance:
+		return errors.New("second chance already used in this match")
+	}
+	return errors.New("extra attempt already available")
+}
+
+// GetPurchase obtiene la última compra del tipo para la referencia
+func (s *ConsumableService) GetPurchase(userID, purchaseType, referenceID string) (*models.ConsumablePurchase, error) {
+	return s.consumableRepo.GetPurchase(userID, purchaseType, referenceID)
+}
+
+// HasUnused verifica si el usuario tiene un intento extra sin usar
+func (s *ConsumableService) HasUnused(userID, purchaseType, referenceID string) bool {
+	unused, err := s.consumableRepo.HasUnused(userID, purchaseType, referenceID)
+	if err != nil {
+		fmt.Printf("⚠️ Error checking %s for user %s: %v\n", purchaseType, userID, err)
+		return false
+	}
+	return unused
+}
+
+// Consume usa el intento extra sin usar de la referencia. Devuelve el id de
+// la compra o "" si no había ninguno.
+func (s *ConsumableService) Consume(userID, purchaseType, referenceID string) (string, error) {
+	return s.consumableRepo.Consume(userID, purchaseType, referenceID)
+}
+
+// Release devuelve un intento extra cuyo uso falló
+func (s *ConsumableService) Release(purchaseID string) {
+	if err := s.consumableRepo.Release(purchaseID); err != nil {
+		fmt.Printf("⚠️ Error releasing consumable %s: %v\n", purchaseID, err)
+	}
+}
+
+// ExtraAttemptPoints puntos que otorga un intento extra
+func (s *ConsumableService) ExtraAttemptPoints(points int) int {
+	return extraAttemptPoints(points, s.cfg.ExtraAttemptPointsPercent)
+}
+
+// extraAttemptPoints aplica el porcentaje (entre 0 y 100) a los puntos
+func extraAttemptPoints(points, percent int) int {
+	if percent < 0 {
+		percent = 0
+	}
+	if percent > 100 {
+		percent = 100
+	}
+	return points * percent / 100
+}
+
+// extraAttemptLimitReached indica si ya se compraron los intentos extra
+// permitidos en 24 horas. Un límite de 0 o menos no limita.
+func extraAttemptLimitReached(purchases, limit int) bool {
+	return limit > 0 && purchases >= limit
+}
+
+// technicalHint arma una pista de análisis técnico con los precios visibles
+// del escenario. La pista sale siempre del mismo indicador para un mismo
+// seed (el id del escenario), así todos los que la compran ven la misma.
+func technicalHint(prices []float64, seed string) (string, string) {
+	type hint struct{ indicator, clue string }
+	hints := []hint{}
+
+	n := len(prices)
+	if n >= 2 && prices[0] != 0 {
+		change := (prices[n-1] - prices[0]) / prices[0] * 100
+		direction := "subió"
+		if change < 0 {
+			direction = "bajó"
+		}
+		hints = append(hints, hint{"trend", fmt.Sprintf(
+			"En el período visible el precio %s %.2f%%.", direction, math.Abs(change))})
+
+		low, high := prices[0], prices[0]
+		for _, p := range prices {
+			low = math.Min(low, p)
+			high = math.Max(high, p)
+		}
+		if high > low {
+			position := (prices[n-1] - low) / (high - low) * 100
+			hints = append(hints, hint{"support_resistance", fmt.Sprintf(
+				"El soporte está en %.2f y la resistencia en %.2f. El último precio está al %.0f%% del rango.",
+				low, high, position)})
+		}
+
+		var moves float64
+		for i := 1; i < n; i++ {
+			if prices[i-1] != 0 {
+				moves += math.Abs(prices[i]-prices[i-1]) / prices[i-1] * 100
+			}
+		}
+		hints = append(hints, hint{"volatility", fmt.Sprintf(
+			"La variación promedio entre períodos es de %.2f%%.", moves/float64(n-1))})
+	}
+
+	if n >= 4 {
+		ups := 0
+		for i := n - 3; i < n; i++ {
+			if prices[i] > prices[i-1] {
+				ups++
+			}
+		}
+		hints = append(hints, hint{"momentum", fmt.Sprintf(
+			"En los últimos 3 períodos el precio subió en %d y no subió en %d.", ups, 3-ups)})
+	}
+
+	if n >= 6 {
+		short := averagePrice(prices[n-5:])
+		long := averagePrice(prices)
+		position := "por encima de"
+		if short < long {
+			position = "por debajo de"
+		}
+		hints = append(hints, hint{"moving_average", fmt.Sprintf(
+			"La media móvil de 5 períodos (%.2f) está %s la media de todo el período (%.2f).",
+			short, position, long)})
+	}
+
+	if len(hints) == 0 {
+		return "none", "No hay suficientes precios para calcular una pista."
+	}
+
+	h := fnv.New32a()
+	h.Write([]byte(seed))
+	chosen := hints[h.Sum32()%uint32(len(hints))]
+	return chosen.indicator, chosen.clue
+}
+
+func averagePrice(values []float64) float64 {
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	return sum / float64(len(values))
+}
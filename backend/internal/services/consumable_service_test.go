@@ -0,0 +1,88 @@
+package services
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestExtraAttemptPoints(t *testing.T) {
+	cases := []struct {
+		points, percent, want int
+	}{
+		{100, 50, 50},
+		{25, 50, 12},
+		{500, 0, 0},
+		{500, 100, 500},
+		{500, 150, 500},
+		{500, -10, 0},
+	}
+	for _, tc := range cases {
+		if got := extraAttemptPoints(tc.points, tc.percent); got != tc.want {
+			t.Errorf("extraAttemptPoints(%d, %d) = %d, want %d", tc.points, tc.percent, got, tc.want)
+		}
+	}
+}
+
+func TestExtraAttemptLimitReached(t *testing.T) {
+	cases := []struct {
+		purchases, limit int
+		want             bool
+	}{
+		{0, 3, false},
+		{2, 3, false},
+		{3, 3, true},
+		{5, 3, true},
+		{10, 0, false},
+		{10, -1, false},
+	}
+	for _, tc := range cases {
+		if got := extraAttemptLimitReached(tc.purchases, tc.limit); got != tc.want {
+			t.Errorf("extraAttemptLimitReached(%d, %d) = %v, want %v", tc.purchases, tc.limit, got, tc.want)
+		}
+	}
+}
+
+func TestTechnicalHint(t *testing.T) {
+	prices := []float64{100, 102, 101, 104, 106, 105, 108, 110}
+
+	indicator, clue := technicalHint(prices, "scenario-1")
+	if indicator == "" || clue == "" {
+		t.Fatalf("hint = %q, %q", indicator, clue)
+	}
+
+	// El mismo escenario siempre da la misma pista
+	again, againClue := technicalHint(prices, "scenario-1")
+	if again != indicator || againClue != clue {
+		t.Errorf("hint not deterministic: %q then %q", clue, againClue)
+	}
+
+	// Con suficientes precios se usan todos los indicadores
+	seen := map[string]bool{}
+	for i := 0; i < 200; i++ {
+		ind, _ := technicalHint(prices, strings.Repeat("x", i))
+		seen[ind] = true
+	}
+	for _, want := range []string{"trend", "support_resistance", "volatility", "momentum", "moving_average"} {
+		if !seen[want] {
+			t.Errorf("indicator %q never chosen", want)
+		}
+	}
+}
+
+func TestTechnicalHintFewPrices(t *testing.T) {
+	if indicator, _ := technicalHint([]float64{100}, "scenario-1"); indicator != "none" {
+		t.Errorf("single price indicator = %q, want none", indicator)
+	}
+
+	// Con 2 precios no hay momentum ni media móvil
+	for i := 0; i < 50; i++ {
+		indicator, _ := technicalHint([]float64{100, 90}, strings.Repeat("y", i))
+		if indicator == "momentum" || indicator == "moving_average" {
+			t.Errorf("indicator %q needs more prices", indicator)
+		}
+	}
+
+	if _, clue := technicalHint([]float64{100, 90}, ""); !strings.Contains(clue, "bajó") && !strings.Contains(clue, "soporte") && !strings.Contains(clue, "variación") {
+		t.Errorf("unexpected clue %q", clue)
+	}
+}
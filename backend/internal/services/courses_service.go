@@ -8,14 +8,26 @@ import (
 )
 
 type CoursesService struct {
-	coursesRepo *repository.CoursesRepository
-	userRepo    *repository.UserRepository
+	coursesRepo       *repository.CoursesRepository
+	userRepo          *repository.UserRepository
+	assignmentService *AssignmentService
+	leaderboards      *LeaderboardService
+	achievements      *AchievementService
 }
 
-func NewCoursesService(coursesRepo *repository.CoursesRepository, userRepo *repository.UserRepository) *CoursesService {
+func NewCoursesService(
+	coursesRepo *repository.CoursesRepository,
+	userRepo *repository.UserRepository,
+	assignmentService *AssignmentService,
+	leaderboards *LeaderboardService,
+	achievements *AchievementService,
+) *CoursesService {
 	return &CoursesService{
-		coursesRepo: coursesRepo,
-		userRepo:    userRepo,
+		coursesRepo:       coursesRepo,
+		userRepo:          userRepo,
+		assignmentService: assignmentService,
+		leaderboards:      leaderboards,
+		achievements:      achievements,
 	}
 }
 
@@ -148,6 +160,8 @@ func (s *CoursesService) CompleteLesson(userID, lessonID string, req *models.Com
 	if err != nil {
 		return nil, err
 	}
+	s.leaderboards.SyncUser(userID)
+	s.achievements.Publish(userID, models.AchievementEventLessonCompleted)
 
 	// Obtener progreso actualizado
 	totalLessons, completedLessons, courseCompleted, err := s.coursesRepo.GetCourseProgress(userID, lesson.CourseID)
@@ -155,6 +169,9 @@ func (s *CoursesService) CompleteLesson(userID, lessonID string, req *models.Com
 		return nil, err
 	}
 
+	// Registrar la entrega de tareas asignadas (lección o curso)
+	s.assignmentService.RecordLessonCompleted(userID, lessonID, lesson.CourseID, courseCompleted, quizScore, quizTotal)
+
 	// Obtener puntos actualizados
 	userStats, err := s.userRepo.GetUserStats(userID)
 	if err != nil {
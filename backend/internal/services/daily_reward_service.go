@@ -0,0 +1,316 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/smartstocks/backend/internal/config"
+	"github.com/smartstocks/backend/internal/models"
+	"github.com/smartstocks/backend/internal/repository"
+)
+
+type DailyRewardService struct {
+	dailyRewardRepo *repository.DailyRewardRepository
+	tokensRepo      *repository.TokensRepository
+	cfg             *config.DailyRewardsConfig
+	defaultLocation *time.Location
+}
+
+func NewDailyRewardService(
+	dailyRewardRepo *repository.DailyRewardRepository,
+	tokensRepo *repository.TokensRepository,
+	cfg *config.DailyRewardsConfig,
+) *DailyRewardService {
+	location, err := loadTimezone(cfg.DefaultTimezone)
+	if err != nil {
+		fmt.Printf("⚠️ Invalid DAILY_REWARD_DEFAULT_TIMEZONE %q, using UTC\n", cfg.DefaultTimezone)
+		location = time.UTC
+	}
+
+	return &DailyRewardService{
+		dailyRewardRepo: dailyRewardRepo,
+		tokensRepo:      tokensRepo,
+		cfg:             cfg,
+		defaultLocation: location,
+	}
+}
+
+// GetStatus obtiene la racha del usuario y la recompensa del próximo check-in
+func (s *DailyRewardService) GetStatus(userID string) (*models.DailyRewardStatus, error) {
+	streak, err := s.dailyRewardRepo.GetLoginStreak(userID)
+	if err != nil {
+		return nil, err
+	}
+	if streak == nil {
+		streak = &models.LoginStreak{UserID: userID, Timezone: s.defaultLocation.String()}
+	}
+
+	location := s.location(streak.Timezone)
+	today := localDate(time.Now(), location)
+
+	status := &models.DailyRewardStatus{
+		Timezone:         location.String(),
+		Today:            today.Format("2006-01-02"),
+		CurrentStreak:    streak.CurrentStreak,
+		LongestStreak:    streak.LongestStreak,
+		TotalCheckIns:    streak.TotalCheckIns,
+		FreezesAvailable: streak.FreezesAvailable,
+		MaxFreezes:       s.cfg.MaxFreezes,
+		FreezePrice:      s.cfg.FreezePrice,
+	}
+
+	step, ok := advanceLoginStreak(streak.LastCheckInDate, streak.CurrentStreak, streak.FreezesAvailable, today)
+	if !ok {
+		status.ClaimedToday = true
+		step, _ = advanceLoginStreak(streak.LastCheckInDate, streak.CurrentStreak, streak.FreezesAvailable, today.AddDate(0, 0, 1))
+	} else if step.Reset {
+		// La racha ya se perdió aunque todavía no se haya registrado
+		status.CurrentStreak = 0
+	}
+	status.NextRewardTokens, status.NextBonusTokens = dailyRewardTokens(s.cfg, step.Streak)
+
+	status.RecentCheckIns, err = s.dailyRewardRepo.GetRecentCheckIns(userID, 30)
+	if err != nil {
+		return nil, err
+	}
+
+	return status, nil
+}
+
+// CheckIn registra el check-in del día (en la zona horaria del usuario) y
+// otorga los tokens de la racha. timezone es opcional y queda guardada.
+func (s *DailyRewardService) CheckIn(userID, timezone string) (*models.DailyCheckInResponse, error) {
+	streak, err := s.dailyRewardRepo.GetLoginStreak(userID)
+	if err != nil {
+		return nil, err
+	}
+	if streak == nil {
+		streak = &models.LoginStreak{UserID: userID, Timezone: s.defaultLocation.String()}
+	}
+
+	stored := s.location(streak.Timezone)
+	requested := stored
+	if timezone != "" {
+		requested, err = loadTimezone(timezone)
+		if err != nil {
+			return nil, errors.New("invalid timezone")
+		}
+	}
+
+	today := checkInDate(time.Now(), stored, requested)
+	step, ok := advanceLoginStreak(streak.LastCheckInDate, streak.CurrentStreak, streak.FreezesAvailable, today)
+	if !ok {
+		return nil, errors.New("daily reward already claimed")
+	}
+
+	reward, bonus := dailyRewardTokens(s.cfg, step.Streak)
+	freezes := streak.FreezesAvailable - step.FreezesUsed
+	freezeEarned := isStreakMilestone(s.cfg, step.Streak) && freezes < s.cfg.MaxFreezes
+	if freezeEarned {
+		freezes++
+	}
+
+	updated := &models.LoginStreak{
+		UserID:           userID,
+		CurrentStreak:    step.Streak,
+		LongestStreak:    streak.LongestStreak,
+		FreezesAvailable: freezes,
+		TotalCheckIns:    streak.TotalCheckIns + 1,
+		Timezone:         requested.String(),
+	}
+	if step.Streak > updated.LongestStreak {
+		updated.LongestStreak = step.Streak
+	}
+
+	checkIn := &models.DailyCheckIn{
+		ID:           uuid.New().String(),
+		UserID:       userID,
+		CheckInDate:  today,
+		Streak:       step.Streak,
+		RewardTokens: reward,
+		BonusTokens:  bonus,
+		FreezesUsed:  step.FreezesUsed,
+	}
+
+	// El check-in y la recompensa se confirman juntos: si falla la
+	// acreditación no queda registrado y se puede reintentar
+	description := fmt.Sprintf("Recompensa diaria (racha de %d días)", step.Streak)
+	saved, err := s.dailyRewardRepo.SaveCheckIn(updated, checkIn, func(tx *sql.Tx) error {
+		_, err := s.tokensRepo.AddTokensTx(tx, userID, reward+bonus, "daily_bonus", description, &checkIn.ID, "daily_bonus:"+checkIn.ID)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error saving check-in: %w", err)
+	}
+	if !saved {
+		return nil, errors.New("daily reward already claimed")
+	}
+
+	return &models.DailyCheckInResponse{
+		CheckInDate:      today.Format("2006-01-02"),
+		Streak:           step.Streak,
+		LongestStreak:    updated.LongestStreak,
+		RewardTokens:     reward,
+		BonusTokens:      bonus,
+		StreakReset:      step.Reset,
+		FreezesUsed:      step.FreezesUsed,
+		FreezeEarned:     freezeEarned,
+		FreezesAvailable: freezes,
+	}, nil
+}
+
+// BuyStreakFreeze compra un protector de racha con tokens. El cobro y el
+// protector van en la misma transacción. idempotencyKey es la clave enviada
+// por el cliente (opcional) para que un reintento no compre dos veces.
+func (s *DailyRewardService) BuyStreakFreeze(userID, idempotencyKey string) (*models.StreakFreezeResponse, error) {
+	if s.cfg.FreezePrice <= 0 || s.cfg.MaxFreezes <= 0 {
+		return nil, errors.New("streak freezes are not available")
+	}
+
+	// Un reintento con la misma clave devuelve el resultado de la compra
+	// original aunque ya se haya alcanzado el máximo
+	key := tokenIdempotencyKey("streak_freeze", userID, idempotencyKey, uuid.New().String())
+	if idempotencyKey != "" {
+		previous, err := s.tokensRepo.GetTransactionByKey(key)
+		if err != nil {
+			return nil, err
+		}
+		if previous != nil {
+			return s.streakFreezeResponse(userID, -previous.Amount)
+		}
+	}
+
+	streak, err := s.dailyRewardRepo.GetLoginStreak(userID)
+	if err != nil {
+		return nil, err
+	}
+	timezone := s.defaultLocation.String()
+	if streak != nil {
+		timezone = streak.Timezone
+		if streak.FreezesAvailable >= s.cfg.MaxFreezes {
+			return nil, errors.New("streak freeze limit reached")
+		}
+	}
+
+	limitReached := errors.New("streak freeze limit reached")
+	success, err := s.tokensRepo.SubtractTokensWith(userID, s.cfg.FreezePrice, "purchase", "Protector de racha", nil, key, func(tx *sql.Tx) error {
+		// Otra compra simultánea pudo alcanzar el máximo: no se cobra
+		added, err := s.dailyRewardRepo.AddStreakFreezeTx(tx, userID, timezone, s.cfg.MaxFreezes)
+		if err != nil {
+			return err
+		}
+		if !added {
+			return limitReached
+		}
+		return nil
+	})
+	switch {
+	case errors.Is(err, repository.ErrDuplicateTransaction):
+		// Otro pedido con la misma clave se aplicó mientras tanto
+	case err != nil:
+		return nil, err
+	case !success:
+		return nil, errors.New("insufficient tokens")
+	}
+
+	return s.streakFreezeResponse(userID, s.cfg.FreezePrice)
+}
+
+// streakFreezeResponse protectores disponibles después de una compra
+func (s *DailyRewardService) streakFreezeResponse(userID string, tokensSpent int) (*models.StreakFreezeResponse, error) {
+	streak, err := s.dailyRewardRepo.GetLoginStreak(userID)
+	if err != nil || streak == nil {
+		return nil, fmt.Errorf("error getting streak: %w", err)
+	}
+
+	return &models.StreakFreezeResponse{
+		FreezesAvailable: streak.FreezesAvailable,
+		TokensSpent:      tokensSpent,
+	}, nil
+}
+
+// location zona horaria guardada del usuario (la por defecto si no es válida)
+func (s *DailyRewardService) location(name string) *time.Location {
+	location, err := loadTimezone(name)
+	if err != nil {
+		return s.defaultLocation
+	}
+	return location
+}
+
+// loadTimezone carga una zona IANA. "Local" y vacío no se aceptan porque
+// dependen del servidor.
+func loadTimezone(name string) (*time.Location, error) {
+	if name == "" || name == "Local" {
+		return nil, errors.New("invalid timezone")
+	}
+	return time.LoadLocation(name)
+}
+
+// localDate fecha (sin hora, en UTC) del instante en la zona indicada
+func localDate(now time.Time, location *time.Location) time.Time {
+	local := now.In(location)
+	return time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// checkInDate fecha del check-in. Si el usuario cambia de zona horaria se
+// toma la fecha más temprana de las dos, para que el cambio no permita
+// cobrar dos veces el mismo día.
+func checkInDate(now time.Time, stored, requested *time.Location) time.Time {
+	date := localDate(now, stored)
+	if other := localDate(now, requested); other.Before(date) {
+		return other
+	}
+	return date
+}
+
+// loginStreakStep racha resultante de un check-in
+type loginStreakStep struct {
+	Streak      int
+	FreezesUsed int
+	Reset       bool // se perdió la racha anterior
+}
+
+// advanceLoginStreak calcula la racha al hacer check-in en today. Los días
+// sin check-in se cubren con protectores si alcanzan para todos; si no, la
+// racha vuelve a 1. ok es false si ya hubo check-in en today (o después).
+func advanceLoginStreak(lastDate *time.Time, streak, freezes int, today time.Time) (loginStreakStep, bool) {
+	if lastDate == nil {
+		return loginStreakStep{Streak: 1}, true
+	}
+
+	gap := int(today.Sub(*lastDate).Hours() / 24)
+	if gap <= 0 {
+		return loginStreakStep{}, false
+	}
+
+	missed := gap - 1
+	if missed <= freezes {
+		return loginStreakStep{Streak: streak + 1, FreezesUsed: missed}, true
+	}
+	return loginStreakStep{Streak: 1, Reset: streak > 0}, true
+}
+
+// dailyRewardTokens recompensa del día de racha indicado: crece StepTokens
+// por día hasta MaxTokens, más el bonus de los hitos
+func dailyRewardTokens(cfg *config.DailyRewardsConfig, streak int) (reward, bonus int) {
+	if streak < 1 {
+		streak = 1
+	}
+
+	reward = cfg.BaseTokens + cfg.StepTokens*(streak-1)
+	if cfg.MaxTokens > 0 && reward > cfg.MaxTokens {
+		reward = cfg.MaxTokens
+	}
+	if isStreakMilestone(cfg, streak) {
+		bonus = cfg.MilestoneBonus
+	}
+	return reward, bonus
+}
+
+func isStreakMilestone(cfg *config.DailyRewardsConfig, streak int) bool {
+	return cfg.MilestoneDays > 0 && streak > 0 && streak%cfg.MilestoneDays == 0
+}
@@ -0,0 +1,86 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/smartstocks/backend/internal/config"
+)
+
+func calendarDate(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func TestAdvanceLoginStreak(t *testing.T) {
+	today := calendarDate(2026, 3, 10)
+	yesterday := calendarDate(2026, 3, 9)
+	threeDaysAgo := calendarDate(2026, 3, 7)
+
+	tests := []struct {
+		name    string
+		last    *time.Time
+		streak  int
+		freezes int
+		want    loginStreakStep
+		wantOK  bool
+	}{
+		{"first check-in", nil, 0, 0, loginStreakStep{Streak: 1}, true},
+		{"already claimed", &today, 4, 0, loginStreakStep{}, false},
+		{"consecutive day", &yesterday, 4, 0, loginStreakStep{Streak: 5}, true},
+		{"gap covered by freezes", &threeDaysAgo, 4, 2, loginStreakStep{Streak: 5, FreezesUsed: 2}, true},
+		{"gap not covered", &threeDaysAgo, 4, 1, loginStreakStep{Streak: 1, Reset: true}, true},
+	}
+	for _, tt := range tests {
+		got, ok := advanceLoginStreak(tt.last, tt.streak, tt.freezes, today)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("%s: advanceLoginStreak() = %+v, %v, want %+v, %v", tt.name, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestDailyRewardTokens(t *testing.T) {
+	cfg := &config.DailyRewardsConfig{BaseTokens: 10, StepTokens: 5, MaxTokens: 30, MilestoneDays: 7, MilestoneBonus: 50}
+
+	tests := []struct {
+		streak     int
+		wantReward int
+		wantBonus  int
+	}{
+		{1, 10, 0},
+		{3, 20, 0},
+		{5, 30, 0},
+		{6, 30, 0},
+		{7, 30, 50},
+		{14, 30, 50},
+	}
+	for _, tt := range tests {
+		reward, bonus := dailyRewardTokens(cfg, tt.streak)
+		if reward != tt.wantReward || bonus != tt.wantBonus {
+			t.Errorf("dailyRewardTokens(%d) = %d, %d, want %d, %d", tt.streak, reward, bonus, tt.wantReward, tt.wantBonus)
+		}
+	}
+}
+
+func TestCheckInDate(t *testing.T) {
+	buenosAires, err := time.LoadLocation("America/Argentina/Buenos_Aires")
+	if err != nil {
+		t.Skipf("timezone data not available: %v", err)
+	}
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	if err != nil {
+		t.Skipf("timezone data not available: %v", err)
+	}
+
+	// 23:00 en Buenos Aires es el día siguiente en Tokio
+	now := time.Date(2026, 3, 10, 23, 0, 0, 0, buenosAires)
+
+	if got := checkInDate(now, buenosAires, buenosAires); !got.Equal(calendarDate(2026, 3, 10)) {
+		t.Errorf("same timezone = %s, want 2026-03-10", got.Format("2006-01-02"))
+	}
+	if got := checkInDate(now, buenosAires, tokyo); !got.Equal(calendarDate(2026, 3, 10)) {
+		t.Errorf("switching to a later timezone = %s, want the earlier date", got.Format("2006-01-02"))
+	}
+	if got := checkInDate(now, tokyo, tokyo); !got.Equal(calendarDate(2026, 3, 11)) {
+		t.Errorf("stored Tokyo timezone = %s, want 2026-03-11", got.Format("2006-01-02"))
+	}
+}
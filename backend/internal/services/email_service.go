@@ -0,0 +1,92 @@
+package services
+
+import (
+	"fmt"
+	"net/smtp"
+	"strings"
+	"time"
+
+	"github.com/smartstocks/backend/internal/config"
+)
+
+type EmailService struct {
+	cfg *config.EmailConfig
+}
+
+func NewEmailService(cfg *config.EmailConfig) *EmailService {
+	return &EmailService{cfg: cfg}
+}
+
+// IsConfigured indica si hay credenciales SMTP configuradas
+func (s *EmailService) IsConfigured() bool {
+	return s.cfg.SMTPUsername != "" && s.cfg.SMTPFrom != ""
+}
+
+// Send envía un email de texto plano
+func (s *EmailService) Send(to, subject, body string) error {
+	if !s.IsConfigured() {
+		fmt.Printf("⚠️  SMTP no configurado - email a %s no enviado: %s\n", to, subject)
+		return nil
+	}
+
+	headers := []string{
+		"From: " + s.cfg.SMTPFrom,
+		"To: " + to,
+		"Subject: " + subject,
+		"MIME-Version: 1.0",
+		"Content-Type: text/plain; charset=UTF-8",
+	}
+	message := strings.Join(headers, "\r\n") + "\r\n\r\n" + body
+
+	auth := smtp.PlainAuth("", s.cfg.SMTPUsername, s.cfg.SMTPPassword, s.cfg.SMTPHost)
+	addr := fmt.Sprintf("%s:%s", s.cfg.SMTPHost, s.cfg.SMTPPort)
+
+	if err := smtp.SendMail(addr, auth, s.cfg.SMTPFrom, []string{to}, []byte(message)); err != nil {
+		return fmt.Errorf("error sending email: %w", err)
+	}
+
+	return nil
+}
+
+// SendLockoutNotification avisa al usuario que su cuenta fue bloqueada temporalmente
+func (s *EmailService) SendLockoutNotification(to, username string, minutes int) error {
+	subject := "Smart Stocks - Cuenta bloqueada temporalmente"
+	body := fmt.Sprintf(
+		"Hola %s,\n\n"+
+			"Detectamos varios intentos fallidos de inicio de sesión en tu cuenta, "+
+			"por lo que la bloqueamos durante %d minutos.\n\n"+
+			"Si fuiste vos, esperá y volvé a intentar. Si no reconocés estos intentos, "+
+			"te recomendamos cambiar tu contraseña.\n\n"+
+			"El equipo de Smart Stocks",
+		username, minutes,
+	)
+	return s.Send(to, subject, body)
+}
+
+// SendDeletionScheduledNotification confirma la solicitud de baja y cómo cancelarla
+func (s *EmailService) SendDeletionScheduledNotification(to, username string, scheduledFor time.Time) error {
+	subject := "Smart Stocks - Solicitud de baja de cuenta"
+	body := fmt.Sprintf(
+		"Hola %s,\n\n"+
+			"Recibimos tu solicitud para eliminar tu cuenta. Tus datos se borrarán "+
+			"definitivamente el %s.\n\n"+
+			"Hasta esa fecha podés cancelar la baja desde tu perfil. Si no fuiste vos, "+
+			"cancelala y cambiá tu contraseña.\n\n"+
+			"El equipo de Smart Stocks",
+		username, scheduledFor.Format("02/01/2006 15:04"),
+	)
+	return s.Send(to, subject, body)
+}
+
+// SendDeletionConfirmationCode envía el código que confirma la baja sin contraseña
+func (s *EmailService) SendDeletionConfirmationCode(to, username, code string, validFor time.Duration) error {
+	subject := "Smart Stocks - Confirmá la baja de tu cuenta"
+	body := fmt.Sprintf(
+		"Hola %s,\n\n"+
+			"Tu código para confirmar la baja de la cuenta es: %s\n\n"+
+			"Vence en %d minutos. Si no lo pediste, ignorá este email.\n\n"+
+			"El equipo de Smart Stocks",
+		username, code, int(validFor.Minutes()),
+	)
+	return s.Send(to, subject, body)
+}
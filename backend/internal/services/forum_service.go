@@ -10,12 +10,14 @@ import (
 )
 
 type ForumService struct {
-	forumRepo *repository.ForumRepository
+	forumRepo    *repository.ForumRepository
+	achievements *AchievementService
 }
 
-func NewForumService(forumRepo *repository.ForumRepository) *ForumService {
+func NewForumService(forumRepo *repository.ForumRepository, achievements *AchievementService) *ForumService {
 	return &ForumService{
-		forumRepo: forumRepo,
+		forumRepo:    forumRepo,
+		achievements: achievements,
 	}
 }
 
@@ -31,6 +33,7 @@ func (s *ForumService) CreatePost(userID string, req *models.CreatePostRequest)
 	if err := s.forumRepo.CreatePost(post); err != nil {
 		return nil, fmt.Errorf("error creating post: %w", err)
 	}
+	s.achievements.Publish(userID, models.AchievementEventForumPostCreated)
 
 	// Obtener el post completo con username
 	createdPost, err := s.forumRepo.GetPostByID(post.ID, userID)
@@ -149,6 +152,7 @@ func (s *ForumService) CreateReply(userID string, req *models.CreateReplyRequest
 	if err := s.forumRepo.CreateReply(reply); err != nil {
 		return nil, fmt.Errorf("error creating reply: %w", err)
 	}
+	s.achievements.Publish(userID, models.AchievementEventForumReplyCreated)
 
 	return reply, nil
 }
@@ -0,0 +1,469 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/smartstocks/backend/internal/models"
+	"github.com/smartstocks/backend/internal/repository"
+	"github.com/smartstocks/backend/pkg/database"
+)
+
+// Claves de los rankings en Redis. Cada sorted set guarda user_id con su
+// puntaje; a igual puntaje Redis ordena por user_id (MySQL ordena por
+// antigüedad de la cuenta).
+const (
+	leaderboardGlobalKey       = "leaderboard:global"
+	leaderboardSchoolPrefix    = "leaderboard:school:"
+	leaderboardSeasonPrefix    = "leaderboard:season:"
+	leaderboardUserSchoolsKey  = "leaderboard:user_schools"  // hash user_id -> school_id
+	leaderboardReadyKey        = "leaderboard:ready"         // existe tras reconstruir global y colegios
+	leaderboardActiveSeasonKey = "leaderboard:active_season" // temporada cuyo set está construido
+
+	leaderboardTimeout        = 3 * time.Second
+	leaderboardRebuildTimeout = 2 * time.Minute
+
+	leaderboardDefaultLimit = 100
+	leaderboardMaxLimit     = 1000
+)
+
+// ErrLeaderboardUnavailable los rankings en Redis todavía no se construyeron
+// (o Redis se vació): quien llama usa las tablas de MySQL
+var ErrLeaderboardUnavailable = errors.New("live leaderboard unavailable")
+
+// LeaderboardService mantiene los rankings global, por colegio y de la
+// temporada en curso en sorted sets de Redis. Se actualizan en el momento en
+// que cambian los smartpoints y se reconstruyen desde MySQL al iniciar.
+type LeaderboardService struct {
+	redis        *database.RedisClient
+	rankingsRepo *repository.RankingsRepository
+	seasonRepo   *repository.SeasonRepository
+
+	// Evita reconstrucciones simultáneas en esta instancia
+	rebuildMu sync.Mutex
+}
+
+func NewLeaderboardService(
+	redis *database.RedisClient,
+	rankingsRepo *repository.RankingsRepository,
+	seasonRepo *repository.SeasonRepository,
+) *LeaderboardService {
+	return &LeaderboardService{
+		redis:        redis,
+		rankingsRepo: rankingsRepo,
+		seasonRepo:   seasonRepo,
+	}
+}
+
+func schoolLeaderboardKey(schoolID string) string {
+	return leaderboardSchoolPrefix + schoolID
+}
+
+func seasonLeaderboardKey(seasonID string) string {
+	return leaderboardSeasonPrefix + seasonID
+}
+
+// === ACTUALIZACIÓN ===
+
+// SyncUser copia los puntajes actuales del usuario a todos sus rankings. Se
+// llama después de cada cambio de smartpoints o de colegio; si falla solo se
+// registra, la próxima reconstrucción corrige la diferencia.
+func (s *LeaderboardService) SyncUser(userID string) {
+	if s == nil {
+		return
+	}
+	if err := s.syncUser(userID); err != nil {
+		fmt.Printf("⚠️ Error syncing leaderboards for user %s: %v\n", userID, err)
+	}
+}
+
+// SyncUsers sincroniza varios usuarios (por ejemplo, los dos jugadores de una partida PvP)
+func (s *LeaderboardService) SyncUsers(userIDs ...string) {
+	for _, userID := range userIDs {
+		if userID != "" {
+			s.SyncUser(userID)
+		}
+	}
+}
+
+func (s *LeaderboardService) syncUser(userID string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), leaderboardTimeout)
+	defer cancel()
+
+	score, err := s.rankingsRepo.GetLeaderboardScore(userID)
+	if err != nil {
+		return err
+	}
+
+	previousSchool, err := s.redis.HGet(ctx, leaderboardUserSchoolsKey, userID)
+	if err != nil && !database.IsNil(err) {
+		return err
+	}
+
+	seasonID, err := s.redis.Get(ctx, leaderboardActiveSeasonKey)
+	if err != nil && !database.IsNil(err) {
+		return err
+	}
+
+	// Cuenta eliminada: sale de todos los rankings
+	if score == nil {
+		if err := s.redis.ZRem(ctx, leaderboardGlobalKey, userID); err != nil {
+			return err
+		}
+		if previousSchool != "" {
+			if err := s.redis.ZRem(ctx, schoolLeaderboardKey(previousSchool), userID); err != nil {
+				return err
+			}
+		}
+		if seasonID != "" {
+			if err := s.redis.ZRem(ctx, seasonLeaderboardKey(seasonID), userID); err != nil {
+				return err
+			}
+		}
+		return s.redis.HDel(ctx, leaderboardUserSchoolsKey, userID)
+	}
+
+	if err := s.redis.ZAdd(ctx, leaderboardGlobalKey, float64(score.Smartpoints), userID); err != nil {
+		return err
+	}
+
+	// Cambio de colegio: sale del ranking anterior
+	if previousSchool != "" && previousSchool != score.SchoolID {
+		if err := s.redis.ZRem(ctx, schoolLeaderboardKey(previousSchool), userID); err != nil {
+			return err
+		}
+	}
+	if score.SchoolID != "" {
+		if err := s.redis.ZAdd(ctx, schoolLeaderboardKey(score.SchoolID), float64(score.Smartpoints), userID); err != nil {
+			return err
+		}
+		if err := s.redis.HSet(ctx, leaderboardUserSchoolsKey, userID, score.SchoolID); err != nil {
+			return err
+		}
+	} else if previousSchool != "" {
+		if err := s.redis.HDel(ctx, leaderboardUserSchoolsKey, userID); err != nil {
+			return err
+		}
+	}
+
+	// El set de temporada solo se actualiza si está construido; si la
+	// temporada cambió se reconstruye completo en la próxima lectura
+	if seasonID == "" {
+		return nil
+	}
+	if score.SeasonPoints > 0 {
+		return s.redis.ZAdd(ctx, seasonLeaderboardKey(seasonID), float64(score.SeasonPoints), userID)
+	}
+	return s.redis.ZRem(ctx, seasonLeaderboardKey(seasonID), userID)
+}
+
+// === RECONSTRUCCIÓN ===
+
+// Rebuild reconstruye todos los rankings desde MySQL
+func (s *LeaderboardService) Rebuild() error {
+	s.rebuildMu.Lock()
+	defer s.rebuildMu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), leaderboardRebuildTimeout)
+	defer cancel()
+
+	season, err := s.seasonRepo.GetActiveSeason()
+	if err != nil {
+		return fmt.Errorf("error getting active season: %w", err)
+	}
+
+	global := make(map[string]float64)
+	schools := make(map[string]map[string]float64)
+	userSchools := make(map[string]string)
+	seasonScores := make(map[string]float64)
+
+	err = s.rankingsRepo.StreamLeaderboardScores(ctx, func(score models.LeaderboardScore) error {
+		global[score.UserID] = float64(score.Smartpoints)
+		if score.SchoolID != "" {
+			if schools[score.SchoolID] == nil {
+				schools[score.SchoolID] = make(map[string]float64)
+			}
+			schools[score.SchoolID][score.UserID] = float64(score.Smartpoints)
+			userSchools[score.UserID] = score.SchoolID
+		}
+		if score.SeasonPoints > 0 {
+			seasonScores[score.UserID] = float64(score.SeasonPoints)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("error reading leaderboard scores: %w", err)
+	}
+
+	if err := s.redis.ReplaceSortedSet(ctx, leaderboardGlobalKey, global); err != nil {
+		return err
+	}
+	for schoolID, members := range schools {
+		if err := s.redis.ReplaceSortedSet(ctx, schoolLeaderboardKey(schoolID), members); err != nil {
+			return err
+		}
+	}
+	if err := s.redis.ReplaceHash(ctx, leaderboardUserSchoolsKey, userSchools); err != nil {
+		return err
+	}
+
+	// Colegios que se quedaron sin miembros
+	keep := make(map[string]bool, len(schools))
+	for schoolID := range schools {
+		keep[schoolLeaderboardKey(schoolID)] = true
+	}
+	if err := s.deleteStaleKeys(ctx, leaderboardSchoolPrefix+"*", keep); err != nil {
+		return err
+	}
+
+	seasonID := ""
+	if season != nil {
+		seasonID = season.ID
+	}
+	if err := s.storeSeason(ctx, seasonID, seasonScores); err != nil {
+		return err
+	}
+
+	return s.redis.Set(ctx, leaderboardReadyKey, time.Now().Format(time.RFC3339), 0)
+}
+
+// RunPeriodicRebuild reconstruye los rankings cada interval para corregir
+// desvíos (cuentas eliminadas por el EVENT de MySQL, cambios manuales).
+// Bloquea; se ejecuta en una goroutine.
+func (s *LeaderboardService) RunPeriodicRebuild(interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if err := s.Rebuild(); err != nil {
+			fmt.Printf("⚠️ Error rebuilding leaderboards: %v\n", err)
+		}
+	}
+}
+
+// ensureSeason reconstruye el set de la temporada si el construido es de otra
+// (la temporada cambia en el EVENT de MySQL, sin pasar por la API)
+func (s *LeaderboardService) ensureSeason(ctx context.Context, seasonID string) error {
+	current, err := s.redis.Get(ctx, leaderboardActiveSeasonKey)
+	if err != nil && !database.IsNil(err) {
+		return err
+	}
+	if current == seasonID {
+		return nil
+	}
+
+	s.rebuildMu.Lock()
+	defer s.rebuildMu.Unlock()
+
+	// Otra lectura pudo haberlo construido mientras se esperaba el lock
+	if current, err := s.redis.Get(ctx, leaderboardActiveSeasonKey); err == nil && current == seasonID {
+		return nil
+	}
+
+	scores := make(map[string]float64)
+	err = s.rankingsRepo.StreamLeaderboardScores(ctx, func(score models.LeaderboardScore) error {
+		if score.SeasonPoints > 0 {
+			scores[score.UserID] = float64(score.SeasonPoints)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("error reading season scores: %w", err)
+	}
+
+	return s.storeSeason(ctx, seasonID, scores)
+}
+
+// storeSeason guarda el set de la temporada en curso y borra los de temporadas
+// anteriores (sus posiciones finales quedan archivadas en MySQL)
+func (s *LeaderboardService) storeSeason(ctx context.Context, seasonID string, scores map[string]float64) error {
+	keep := map[string]bool{}
+	if seasonID != "" {
+		if err := s.redis.ReplaceSortedSet(ctx, seasonLeaderboardKey(seasonID), scores); err != nil {
+			return err
+		}
+		keep[seasonLeaderboardKey(seasonID)] = true
+	}
+	if err := s.deleteStaleKeys(ctx, leaderboardSeasonPrefix+"*", keep); err != nil {
+		return err
+	}
+
+	if seasonID == "" {
+		return s.redis.Delete(ctx, leaderboardActiveSeasonKey)
+	}
+	return s.redis.Set(ctx, leaderboardActiveSeasonKey, seasonID, 0)
+}
+
+func (s *LeaderboardService) deleteStaleKeys(ctx context.Context, pattern string, keep map[string]bool) error {
+	keys, err := s.redis.ScanKeys(ctx, pattern)
+	if err != nil {
+		return err
+	}
+
+	var stale []string
+	for _, key := range keys {
+		if !keep[key] {
+			stale = append(stale, key)
+		}
+	}
+	if len(stale) == 0 {
+		return nil
+	}
+	return s.redis.Delete(ctx, stale...)
+}
+
+// === LECTURA ===
+
+// GlobalPage user_ids de la página del ranking global y total de jugadores
+func (s *LeaderboardService) GlobalPage(limit, offset int) ([]string, int, error) {
+	return s.page("", leaderboardGlobalKey, limit, offset)
+}
+
+// SchoolPage user_ids de la página del ranking del colegio y total de miembros
+func (s *LeaderboardService) SchoolPage(schoolID string, limit, offset int) ([]string, int, error) {
+	return s.page("", schoolLeaderboardKey(schoolID), limit, offset)
+}
+
+// SeasonPage user_ids de la página del ranking de la temporada en curso y
+// total de jugadores con puntos de temporada
+func (s *LeaderboardService) SeasonPage(seasonID string, limit, offset int) ([]string, int, error) {
+	return s.page(seasonID, seasonLeaderboardKey(seasonID), limit, offset)
+}
+
+// GlobalPosition posición del usuario en el ranking global (0 si no figura)
+func (s *LeaderboardService) GlobalPosition(userID string) (int, error) {
+	return s.position("", leaderboardGlobalKey, userID)
+}
+
+// SchoolPosition posición del usuario en el ranking del colegio (0 si no figura)
+func (s *LeaderboardService) SchoolPosition(schoolID, userID string) (int, error) {
+	return s.position("", schoolLeaderboardKey(schoolID), userID)
+}
+
+// SeasonPosition posición del usuario en la temporada en curso (0 si no sumó puntos)
+func (s *LeaderboardService) SeasonPosition(seasonID, userID string) (int, error) {
+	return s.position(seasonID, seasonLeaderboardKey(seasonID), userID)
+}
+
+// Positions posiciones global y en su colegio del usuario, y el total de
+// jugadores del ranking global. Cada posición es un ZREVRANK: O(log n).
+func (s *LeaderboardService) Positions(userID string) (global, school, total int, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), leaderboardTimeout)
+	defer cancel()
+
+	if err = s.ready(ctx, ""); err != nil {
+		return 0, 0, 0, err
+	}
+
+	if global, err = s.rank(ctx, leaderboardGlobalKey, userID); err != nil {
+		return 0, 0, 0, err
+	}
+
+	schoolID, err := s.redis.HGet(ctx, leaderboardUserSchoolsKey, userID)
+	if err != nil && !database.IsNil(err) {
+		return 0, 0, 0, err
+	}
+	if schoolID != "" {
+		if school, err = s.rank(ctx, schoolLeaderboardKey(schoolID), userID); err != nil {
+			return 0, 0, 0, err
+		}
+	}
+
+	count, err := s.redis.ZCard(ctx, leaderboardGlobalKey)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+
+	return global, school, int(count), nil
+}
+
+// ready verifica que los sets estén construidos. Con seasonID además
+// construye el de esa temporada si hace falta.
+func (s *LeaderboardService) ready(ctx context.Context, seasonID string) error {
+	if seasonID != "" {
+		return s.ensureSeason(ctx, seasonID)
+	}
+
+	exists, err := s.redis.Exists(ctx, leaderboardReadyKey)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return ErrLeaderboardUnavailable
+	}
+	return nil
+}
+
+func (s *LeaderboardService) page(seasonID, key string, limit, offset int) ([]string, int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), leaderboardTimeout)
+	defer cancel()
+
+	if err := s.ready(ctx, seasonID); err != nil {
+		return nil, 0, err
+	}
+
+	limit, offset = leaderboardPage(limit, offset)
+	userIDs, err := s.redis.ZRevRange(ctx, key, int64(offset), int64(offset+limit-1))
+	if err != nil {
+		return nil, 0, err
+	}
+
+	total, err := s.redis.ZCard(ctx, key)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return userIDs, int(total), nil
+}
+
+func (s *LeaderboardService) position(seasonID, key, userID string) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), leaderboardTimeout)
+	defer cancel()
+
+	if err := s.ready(ctx, seasonID); err != nil {
+		return 0, err
+	}
+	return s.rank(ctx, key, userID)
+}
+
+// rank posición base 1 del usuario en el set (0 si no figura)
+func (s *LeaderboardService) rank(ctx context.Context, key, userID string) (int, error) {
+	rank, err := s.redis.ZRevRank(ctx, key, userID)
+	if database.IsNil(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return int(rank) + 1, nil
+}
+
+// leaderboardPage normaliza la paginación igual que las consultas a leaderboard_cache
+func leaderboardPage(limit, offset int) (int, int) {
+	if limit <= 0 || limit > leaderboardMaxLimit {
+		limit = leaderboardDefaultLimit
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	return limit, offset
+}
+
+// outsidePage indica si la posición (base 1) está fuera de la página que
+// empieza en offset y tiene size entradas
+func outsidePage(position, offset, size int) bool {
+	return position > 0 && (position <= offset || position > offset+size)
+}
+
+// isLeaderboardUnavailable indica si el error es que Redis no está listo (se
+// usa MySQL sin avisar) en vez de una falla que conviene registrar
+func isLeaderboardUnavailable(err error) bool {
+	return errors.Is(err, ErrLeaderboardUnavailable)
+}
@@ -0,0 +1,40 @@
+package services
+
+import "testing"
+
+func TestLeaderboardPage(t *testing.T) {
+	tests := []struct {
+		limit, offset         int
+		wantLimit, wantOffset int
+	}{
+		{50, 20, 50, 20},
+		{0, 0, leaderboardDefaultLimit, 0},
+		{leaderboardMaxLimit + 1, -5, leaderboardDefaultLimit, 0},
+		{leaderboardMaxLimit, 0, leaderboardMaxLimit, 0},
+	}
+
+	for _, tt := range tests {
+		limit, offset := leaderboardPage(tt.limit, tt.offset)
+		if limit != tt.wantLimit || offset != tt.wantOffset {
+			t.Errorf("leaderboardPage(%d, %d) = %d, %d; want %d, %d",
+				tt.limit, tt.offset, limit, offset, tt.wantLimit, tt.wantOffset)
+		}
+	}
+}
+
+func TestOutsidePage(t *testing.T) {
+	// Página con las posiciones 11 a 20
+	tests := map[int]bool{
+		0:  false, // no figura en el ranking
+		10: true,
+		11: false,
+		20: false,
+		21: true,
+	}
+
+	for position, want := range tests {
+		if got := outsidePage(position, 10, 10); got != want {
+			t.Errorf("outsidePage(%d, 10, 10) = %v; want %v", position, got, want)
+		}
+	}
+}
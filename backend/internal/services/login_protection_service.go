@@ -0,0 +1,175 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/smartstocks/backend/internal/config"
+	"github.com/smartstocks/backend/pkg/database"
+)
+
+// Motivos de rechazo de un login por protección anti fuerza bruta
+const (
+	LoginBlockAccountLocked = "account_locked"
+	LoginBlockBackoff       = "backoff"
+)
+
+// LoginBlockedError se devuelve cuando un login se rechaza sin verificar la contraseña
+type LoginBlockedError struct {
+	Reason     string
+	RetryAfter time.Duration
+}
+
+func (e *LoginBlockedError) Error() string {
+	return fmt.Sprintf("too many failed login attempts, retry in %d seconds", int(e.RetryAfter.Seconds()))
+}
+
+// LoginProtectionService lleva contadores de intentos fallidos en Redis. El
+// bloqueo duro es por (IP, cuenta); por cuenta y por IP solo hay backoff, para
+// que una clase detrás de la IP pública del colegio no quede bloqueada entera.
+type LoginProtectionService struct {
+	redis *database.RedisClient
+	cfg   *config.LoginProtectionConfig
+}
+
+func NewLoginProtectionService(redis *database.RedisClient, cfg *config.LoginProtectionConfig) *LoginProtectionService {
+	return &LoginProtectionService{
+		redis: redis,
+		cfg:   cfg,
+	}
+}
+
+// loginFailureDecision qué aplicar tras un intento fallido
+type loginFailureDecision struct {
+	LockPair       bool
+	AccountBackoff time.Duration
+	IPBackoff      time.Duration
+}
+
+// Check verifica si el par (IP, cuenta) está bloqueado o la cuenta o la IP en backoff
+func (s *LoginProtectionService) Check(email, ip string) error {
+	ctx := context.Background()
+	email = normalizeEmail(email)
+
+	checks := []struct {
+		key    string
+		reason string
+	}{
+		{pairLockKey(ip, email), LoginBlockAccountLocked},
+		{accountBackoffKey(email), LoginBlockBackoff},
+		{ipBackoffKey(ip), LoginBlockBackoff},
+	}
+
+	for _, check := range checks {
+		ttl, err := s.redis.TTL(ctx, check.key)
+		if err != nil {
+			// Si Redis falla, permitir el intento (igual que RateLimitMiddleware)
+			continue
+		}
+		if ttl > 0 {
+			return &LoginBlockedError{Reason: check.reason, RetryAfter: ttl}
+		}
+	}
+
+	return nil
+}
+
+// RegisterFailure suma un intento fallido y aplica backoff o bloqueo.
+// Devuelve true si este intento bloqueó la cuenta desde esta IP.
+func (s *LoginProtectionService) RegisterFailure(email, ip string) bool {
+	ctx := context.Background()
+	email = normalizeEmail(email)
+	window := time.Duration(s.cfg.FailureWindowMinutes) * time.Minute
+
+	// Si Redis falla el contador queda en 0 y no se aplica nada por esa vía
+	ipCount, _ := s.incrementWithWindow(ctx, ipFailKey(ip), window)
+	pairCount, _ := s.incrementWithWindow(ctx, pairFailKey(ip, email), window)
+	accountCount, _ := s.incrementWithWindow(ctx, accountFailKey(email), window)
+
+	decision := s.decideFailure(pairCount, accountCount, ipCount)
+
+	if decision.AccountBackoff > 0 {
+		_ = s.redis.Set(ctx, accountBackoffKey(email), "1", decision.AccountBackoff)
+	}
+	if decision.IPBackoff > 0 {
+		_ = s.redis.Set(ctx, ipBackoffKey(ip), "1", decision.IPBackoff)
+	}
+	if decision.LockPair {
+		lockout := time.Duration(s.cfg.LockoutMinutes) * time.Minute
+		_ = s.redis.Set(ctx, pairLockKey(ip, email), "1", lockout)
+		_ = s.redis.Delete(ctx, pairFailKey(ip, email))
+	}
+
+	return decision.LockPair
+}
+
+// RegisterSuccess limpia los contadores de la cuenta tras un login correcto
+func (s *LoginProtectionService) RegisterSuccess(email, ip string) {
+	email = normalizeEmail(email)
+	_ = s.redis.Delete(context.Background(), accountFailKey(email), accountBackoffKey(email), pairFailKey(ip, email))
+}
+
+// LockoutMinutes duración del bloqueo temporal de la cuenta desde una IP
+func (s *LoginProtectionService) LockoutMinutes() int {
+	return s.cfg.LockoutMinutes
+}
+
+// decideFailure aplica los umbrales a los contadores de la ventana actual.
+// La cuenta siempre entra en backoff exponencial; la IP solo a partir de
+// MaxIPFailures y el bloqueo duro solo por par (IP, cuenta).
+func (s *LoginProtectionService) decideFailure(pairCount, accountCount, ipCount int64) loginFailureDecision {
+	var decision loginFailureDecision
+
+	if accountCount > 0 {
+		decision.AccountBackoff = s.backoffFor(accountCount)
+	}
+	if ipCount >= int64(s.cfg.MaxIPFailures) {
+		decision.IPBackoff = s.backoffFor(ipCount - int64(s.cfg.MaxIPFailures) + 1)
+	}
+	if pairCount >= int64(s.cfg.MaxAccountFailures) {
+		decision.LockPair = true
+	}
+
+	return decision
+}
+
+func (s *LoginProtectionService) backoffFor(failures int64) time.Duration {
+	base := time.Duration(s.cfg.BackoffBaseSeconds) * time.Second
+	max := time.Duration(s.cfg.BackoffMaxSeconds) * time.Second
+
+	backoff := base
+	for i := int64(1); i < failures && backoff < max; i++ {
+		backoff *= 2
+	}
+	if backoff > max {
+		backoff = max
+	}
+
+	return backoff
+}
+
+func (s *LoginProtectionService) incrementWithWindow(ctx context.Context, key string, window time.Duration) (int64, error) {
+	count, err := s.redis.Increment(ctx, key)
+	if err != nil {
+		return 0, err
+	}
+	if count == 1 {
+		_ = s.redis.SetExpire(ctx, key, window)
+	}
+	return count, nil
+}
+
+// === HELPERS ===
+
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+func accountFailKey(email string) string    { return "login:fail:acct:" + email }
+func accountBackoffKey(email string) string { return "login:backoff:acct:" + email }
+func ipFailKey(ip string) string            { return "login:fail:ip:" + ip }
+func ipBackoffKey(ip string) string         { return "login:backoff:ip:" + ip }
+func pairFailKey(ip, email string) string   { return "login:fail:pair:" + ip + ":" + email }
+func pairLockKey(ip, email string) string   { return "login:lock:pair:" + ip + ":" + email }
@@ -0,0 +1,66 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/smartstocks/backend/internal/config"
+)
+
+func newTestLoginProtection() *LoginProtectionService {
+	return NewLoginProtectionService(nil, &config.LoginProtectionConfig{
+		MaxAccountFailures:   5,
+		MaxIPFailures:        100,
+		FailureWindowMinutes: 15,
+		LockoutMinutes:       15,
+		BackoffBaseSeconds:   1,
+		BackoffMaxSeconds:    30,
+	})
+}
+
+func TestLoginBackoffFor(t *testing.T) {
+	s := newTestLoginProtection()
+
+	tests := []struct {
+		failures int64
+		want     time.Duration
+	}{
+		{1, 1 * time.Second},
+		{2, 2 * time.Second},
+		{3, 4 * time.Second},
+		{5, 16 * time.Second},
+		{6, 30 * time.Second},
+		{50, 30 * time.Second},
+	}
+	for _, tt := range tests {
+		if got := s.backoffFor(tt.failures); got != tt.want {
+			t.Errorf("backoffFor(%d) = %v, want %v", tt.failures, got, tt.want)
+		}
+	}
+}
+
+func TestLoginDecideFailure(t *testing.T) {
+	s := newTestLoginProtection()
+
+	tests := []struct {
+		name    string
+		pair    int64
+		account int64
+		ip      int64
+		want    loginFailureDecision
+	}{
+		{"first failure", 1, 1, 1, loginFailureDecision{AccountBackoff: time.Second}},
+		{"pair below threshold", 4, 4, 4, loginFailureDecision{AccountBackoff: 8 * time.Second}},
+		{"pair reaches threshold", 5, 5, 5, loginFailureDecision{LockPair: true, AccountBackoff: 16 * time.Second}},
+		{"account failing from many ips", 1, 20, 1, loginFailureDecision{AccountBackoff: 30 * time.Second}},
+		{"shared school ip below threshold", 1, 1, 99, loginFailureDecision{AccountBackoff: time.Second}},
+		{"shared school ip only backs off", 1, 1, 100, loginFailureDecision{AccountBackoff: time.Second, IPBackoff: time.Second}},
+		{"shared school ip keeps growing", 2, 2, 103, loginFailureDecision{AccountBackoff: 2 * time.Second, IPBackoff: 8 * time.Second}},
+		{"redis unavailable", 0, 0, 0, loginFailureDecision{}},
+	}
+	for _, tt := range tests {
+		if got := s.decideFailure(tt.pair, tt.account, tt.ip); got != tt.want {
+			t.Errorf("%s: decideFailure(%d, %d, %d) = %+v, want %+v", tt.name, tt.pair, tt.account, tt.ip, got, tt.want)
+		}
+	}
+}
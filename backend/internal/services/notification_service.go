@@ -0,0 +1,52 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/smartstocks/backend/internal/models"
+	"github.com/smartstocks/backend/internal/repository"
+)
+
+type NotificationService struct {
+	notificationRepo *repository.NotificationRepository
+}
+
+func NewNotificationService(notificationRepo *repository.NotificationRepository) *NotificationService {
+	return &NotificationService{notificationRepo: notificationRepo}
+}
+
+// GetNotifications obtiene las notificaciones del usuario y cuántas no leyó
+func (s *NotificationService) GetNotifications(userID string, unreadOnly bool, limit int) (*models.NotificationsResponse, error) {
+	notifications, err := s.notificationRepo.GetNotifications(userID, unreadOnly, limit)
+	if err != nil {
+		return nil, fmt.Errorf("error getting notifications: %w", err)
+	}
+
+	unread, err := s.notificationRepo.CountUnread(userID)
+	if err != nil {
+		return nil, fmt.Errorf("error counting notifications: %w", err)
+	}
+
+	return &models.NotificationsResponse{
+		Unread:        unread,
+		Notifications: notifications,
+	}, nil
+}
+
+// MarkRead marca una notificación como leída
+func (s *NotificationService) MarkRead(userID, notificationID string) error {
+	found, err := s.notificationRepo.MarkRead(userID, notificationID)
+	if err != nil {
+		return fmt.Errorf("error marking notification: %w", err)
+	}
+	if !found {
+		return errors.New("notification not found")
+	}
+	return nil
+}
+
+// MarkAllRead marca como leídas todas las notificaciones del usuario
+func (s *NotificationService) MarkAllRead(userID string) (int64, error) {
+	return s.notificationRepo.MarkAllRead(userID)
+}
@@ -0,0 +1,290 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/smartstocks/backend/internal/config"
+	"github.com/smartstocks/backend/internal/models"
+	"github.com/smartstocks/backend/internal/repository"
+	"github.com/smartstocks/backend/pkg/database"
+	"github.com/smartstocks/backend/pkg/oidc"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const oidcStateTTL = 10 * time.Minute
+
+var usernameInvalidChars = regexp.MustCompile(`[^a-zA-Z0-9_]`)
+
+// oidcPendingLogin datos guardados en Redis entre authorize y callback
+type oidcPendingLogin struct {
+	Provider     string `json:"provider"`
+	CodeVerifier string `json:"code_verifier"`
+	Nonce        string `json:"nonce"`
+}
+
+type OIDCService struct {
+	clients      map[string]*oidc.Client
+	identityRepo *repository.UserIdentityRepository
+	userRepo     *repository.UserRepository
+	authService  *AuthService
+	redis        *database.RedisClient
+}
+
+func NewOIDCService(
+	cfg *config.OIDCConfig,
+	identityRepo *repository.UserIdentityRepository,
+	userRepo *repository.UserRepository,
+	authService *AuthService,
+	redis *database.RedisClient,
+) *OIDCService {
+	clients := make(map[string]*oidc.Client)
+	for name, providerCfg := range cfg.Providers {
+		clients[name] = oidc.NewClient(oidc.ProviderOptions{
+			Name:         providerCfg.Name,
+			Issuer:       providerCfg.Issuer,
+			ClientID:     providerCfg.ClientID,
+			ClientSecret: providerCfg.ClientSecret,
+			RedirectURL:  providerCfg.RedirectURL,
+			Scopes:       providerCfg.Scopes,
+		})
+	}
+
+	return &OIDCService{
+		clients:      clients,
+		identityRepo: identityRepo,
+		userRepo:     userRepo,
+		authService:  authService,
+		redis:        redis,
+	}
+}
+
+// GetProviders lista los proveedores configurados
+func (s *OIDCService) GetProviders() []string {
+	providers := make([]string, 0, len(s.clients))
+	for name := range s.clients {
+		providers = append(providers, name)
+	}
+	sort.Strings(providers)
+	return providers
+}
+
+// Authorize inicia el flujo authorization code + PKCE
+func (s *OIDCService) Authorize(provider string) (*models.OIDCAuthorizeResponse, error) {
+	client, ok := s.clients[provider]
+	if !ok {
+		return nil, fmt.Errorf("unknown OIDC provider: %s", provider)
+	}
+
+	ctx := context.Background()
+	state := uuid.New().String()
+	pending := oidcPendingLogin{
+		Provider:     provider,
+		CodeVerifier: oidc.GenerateVerifier(),
+		Nonce:        uuid.New().String(),
+	}
+
+	authURL, err := client.AuthCodeURL(ctx, state, pending.Nonce, pending.CodeVerifier)
+	if err != nil {
+		return nil, err
+	}
+
+	data, _ := json.Marshal(pending)
+	if err := s.redis.Set(ctx, oidcStateKey(state), string(data), oidcStateTTL); err != nil {
+		return nil, fmt.Errorf("error saving OIDC state: %w", err)
+	}
+
+	return &models.OIDCAuthorizeResponse{
+		Provider:         provider,
+		AuthorizationURL: authURL,
+		State:            state,
+	}, nil
+}
+
+// Callback canjea el código, vincula o crea el usuario y completa el login
+// igual que AuthService.Login (incluyendo el challenge de 2FA y la auditoría
+// del login)
+func (s *OIDCService) Callback(provider string, req *models.OIDCCallbackRequest, clientIP, userAgent, deviceID string) (*models.LoginResponse, *models.TwoFactorChallenge, error) {
+	ctx := context.Background()
+
+	// El state es de un solo uso
+	raw, err := s.redis.Get(ctx, oidcStateKey(req.State))
+	if err != nil || raw == "" {
+		return nil, nil, errors.New("invalid or expired state")
+	}
+	_ = s.redis.Delete(ctx, oidcStateKey(req.State))
+
+	var pending oidcPendingLogin
+	if err := json.Unmarshal([]byte(raw), &pending); err != nil || pending.Provider != provider {
+		return nil, nil, errors.New("invalid or expired state")
+	}
+
+	client, ok := s.clients[provider]
+	if !ok {
+		return nil, nil, fmt.Errorf("unknown OIDC provider: %s", provider)
+	}
+
+	identity, err := client.Exchange(ctx, req.Code, pending.CodeVerifier, pending.Nonce)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	user, err := s.resolveUser(identity)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	response, challenge, err := s.authService.completeLogin(user)
+	if err == nil && challenge == nil {
+		// Con 2FA el login recién es exitoso tras el segundo factor
+		s.authService.registerSuccessfulLogin(&models.LoginAttempt{
+			UserID:    sql.NullString{String: user.ID, Valid: true},
+			Email:     user.Email,
+			IPAddress: clientIP,
+			UserAgent: userAgent,
+			DeviceID:  sql.NullString{String: deviceID, Valid: deviceID != ""},
+		})
+	}
+
+	return response, challenge, err
+}
+
+// resolveUser busca la identidad vinculada; si no existe vincula por email
+// verificado o crea un usuario nuevo
+func (s *OIDCService) resolveUser(identity *oidc.Identity) (*models.User, error) {
+	linked, err := s.identityRepo.GetByProviderSubject(identity.Provider, identity.Subject)
+	if err != nil {
+		return nil, fmt.Errorf("error getting identity: %w", err)
+	}
+	if linked != nil {
+		_ = s.identityRepo.TouchLogin(linked.ID)
+		return s.userRepo.GetUserByID(linked.UserID)
+	}
+
+	// Sin email verificado no se puede vincular ni crear (evita tomar cuentas ajenas)
+	if identity.Email == "" || !identity.EmailVerified {
+		return nil, errors.New("the provider did not return a verified email")
+	}
+
+	// GetUserByEmail devuelve un usuario nil solo cuando no existe
+	user, err := s.userRepo.GetUserByEmail(identity.Email)
+	if user == nil {
+		user, err = s.createUser(identity)
+		if err != nil {
+			return nil, err
+		}
+	} else if err != nil {
+		return nil, fmt.Errorf("error getting user: %w", err)
+	} else if !user.EmailVerified {
+		// El proveedor acaba de probar la titularidad del email; lo que haya
+		// dejado quien registró la cuenta sin verificarla deja de valer
+		if err := s.claimUnverifiedAccount(user); err != nil {
+			return nil, err
+		}
+	}
+
+	err = s.identityRepo.CreateIdentity(&models.UserIdentity{
+		UserID:   user.ID,
+		Provider: identity.Provider,
+		Subject:  identity.Subject,
+		Email:    identity.Email,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error linking identity: %w", err)
+	}
+
+	return user, nil
+}
+
+// claimUnverifiedAccount entrega una cuenta con email sin verificar al dueño
+// probado del email: contraseña nueva aleatoria y sesiones y 2FA revocados
+func (s *OIDCService) claimUnverifiedAccount(user *models.User) error {
+	hashedPassword, err := randomPasswordHash()
+	if err != nil {
+		return err
+	}
+
+	if err := s.userRepo.ClaimUnverifiedAccount(user.ID, hashedPassword); err != nil {
+		return fmt.Errorf("error claiming account: %w", err)
+	}
+
+	user.PasswordHash = hashedPassword
+	user.EmailVerified = true
+	s.authService.onEmailVerified(user.ID)
+
+	return nil
+}
+
+// createUser crea un usuario con email verificado y contraseña inutilizable
+func (s *OIDCService) createUser(identity *oidc.Identity) (*models.User, error) {
+	username, err := s.availableUsername(identity)
+	if err != nil {
+		return nil, err
+	}
+
+	hashedPassword, err := randomPasswordHash()
+	if err != nil {
+		return nil, err
+	}
+
+	user := &models.User{
+		Username:      username,
+		Email:         identity.Email,
+		PasswordHash:  hashedPassword,
+		EmailVerified: true,
+	}
+
+	if err := s.userRepo.CreateUser(user); err != nil {
+		return nil, fmt.Errorf("error creating user: %w", err)
+	}
+
+	return user, nil
+}
+
+// availableUsername deriva un username válido del email y agrega un sufijo si está tomado
+func (s *OIDCService) availableUsername(identity *oidc.Identity) (string, error) {
+	base := strings.SplitN(identity.Email, "@", 2)[0]
+	base = usernameInvalidChars.ReplaceAllString(base, "_")
+	if len(base) > 40 {
+		base = base[:40]
+	}
+	for len(base) < 3 {
+		base += "_"
+	}
+
+	candidate := base
+	for i := 0; i < 10; i++ {
+		existing, err := s.userRepo.GetUserByUsername(candidate)
+		if err != nil {
+			return "", err
+		}
+		if existing == nil {
+			return candidate, nil
+		}
+		candidate = fmt.Sprintf("%s_%s", base, uuid.New().String()[:6])
+	}
+
+	return "", errors.New("could not generate a unique username")
+}
+
+func oidcStateKey(state string) string {
+	return "oidc:state:" + state
+}
+
+// randomPasswordHash contraseña aleatoria: solo se puede entrar por OIDC
+// hasta que el usuario la resetee
+func randomPasswordHash() (string, error) {
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(uuid.New().String()), bcrypt.DefaultCost)
+	if err != nil {
+		return "", fmt.Errorf("error hashing password: %w", err)
+	}
+	return string(hashedPassword), nil
+}
@@ -0,0 +1,180 @@
+package services
+
+import (
+	"archive/zip"
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/smartstocks/backend/internal/models"
+	"github.com/smartstocks/backend/internal/repository"
+	"github.com/smartstocks/backend/pkg/database"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const deletionConfirmationTTL = 30 * time.Minute
+
+// PrivacyService atiende los pedidos de acceso y supresión de datos (Ley 25.326)
+type PrivacyService struct {
+	exportRepo     *repository.DataExportRepository
+	deletionRepo   *repository.AccountDeletionRepository
+	userRepo       *repository.UserRepository
+	emailService   *EmailService
+	redis          *database.RedisClient
+	coolingOffDays int
+}
+
+func NewPrivacyService(
+	exportRepo *repository.DataExportRepository,
+	deletionRepo *repository.AccountDeletionRepository,
+	userRepo *repository.UserRepository,
+	emailService *EmailService,
+	redis *database.RedisClient,
+	coolingOffDays int,
+) *PrivacyService {
+	return &PrivacyService{
+		exportRepo:     exportRepo,
+		deletionRepo:   deletionRepo,
+		userRepo:       userRepo,
+		emailService:   emailService,
+		redis:          redis,
+		coolingOffDays: coolingOffDays,
+	}
+}
+
+// ExportData reúne los datos personales del usuario
+func (s *PrivacyService) ExportData(userID string) (*models.UserDataExport, error) {
+	return s.exportRepo.GetUserData(userID)
+}
+
+// WriteExportZip escribe la exportación como ZIP con un archivo JSON por sección
+func (s *PrivacyService) WriteExportZip(w io.Writer, export *models.UserDataExport) error {
+	zw := zip.NewWriter(w)
+
+	manifest := map[string]interface{}{
+		"user_id":      export.UserID,
+		"generated_at": export.GeneratedAt,
+	}
+	if err := writeZipJSON(zw, "export.json", manifest); err != nil {
+		return err
+	}
+
+	for _, section := range export.Sections {
+		if err := writeZipJSON(zw, section.Name+".json", section.Rows); err != nil {
+			return err
+		}
+	}
+
+	return zw.Close()
+}
+
+// SendDeletionConfirmation envía por email un código de un solo uso para
+// confirmar la baja; es la vía para usuarios OIDC, que no conocen su contraseña
+func (s *PrivacyService) SendDeletionConfirmation(userID string) error {
+	user, err := s.userRepo.GetUserByID(userID)
+	if err != nil {
+		return err
+	}
+
+	code := uuid.New().String()
+	if err := s.redis.Set(context.Background(), deletionConfirmationKey(userID), code, deletionConfirmationTTL); err != nil {
+		return fmt.Errorf("error saving confirmation code: %w", err)
+	}
+
+	return s.emailService.SendDeletionConfirmationCode(user.Email, user.Username, code, deletionConfirmationTTL)
+}
+
+// RequestDeletion programa la baja tras el período de arrepentimiento. Se
+// confirma con la contraseña o con el código enviado por email.
+func (s *PrivacyService) RequestDeletion(userID string, req *models.DeleteAccountRequest) (*models.AccountDeletionRequest, error) {
+	user, err := s.userRepo.GetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case req.ConfirmationCode != "":
+		if !s.consumeDeletionConfirmation(userID, req.ConfirmationCode) {
+			return nil, errors.New("invalid or expired confirmation code")
+		}
+	case req.Password != "":
+		if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.Password)); err != nil {
+			return nil, errors.New("invalid password")
+		}
+	default:
+		return nil, errors.New("password or confirmation_code is required")
+	}
+
+	existing, err := s.deletionRepo.GetByUserID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("error getting deletion request: %w", err)
+	}
+	if existing != nil && existing.Status == "pending" {
+		return existing, nil
+	}
+
+	scheduledFor := time.Now().AddDate(0, 0, s.coolingOffDays)
+	if err := s.deletionRepo.Schedule(userID, scheduledFor); err != nil {
+		return nil, fmt.Errorf("error scheduling deletion: %w", err)
+	}
+
+	go func() {
+		_ = s.emailService.SendDeletionScheduledNotification(user.Email, user.Username, scheduledFor)
+	}()
+
+	return s.deletionRepo.GetByUserID(userID)
+}
+
+// CancelDeletion cancela una baja pendiente
+func (s *PrivacyService) CancelDeletion(userID string) error {
+	cancelled, err := s.deletionRepo.Cancel(userID)
+	if err != nil {
+		return fmt.Errorf("error cancelling deletion: %w", err)
+	}
+	if !cancelled {
+		return errors.New("no pending deletion request")
+	}
+	return nil
+}
+
+// GetDeletionStatus obtiene la solicitud de baja del usuario (nil si no hay)
+func (s *PrivacyService) GetDeletionStatus(userID string) (*models.AccountDeletionRequest, error) {
+	return s.deletionRepo.GetByUserID(userID)
+}
+
+// consumeDeletionConfirmation valida y borra el código (un solo uso)
+func (s *PrivacyService) consumeDeletionConfirmation(userID, code string) bool {
+	ctx := context.Background()
+	key := deletionConfirmationKey(userID)
+
+	stored, err := s.redis.Get(ctx, key)
+	if err != nil || stored == "" {
+		return false
+	}
+	if subtle.ConstantTimeCompare([]byte(stored), []byte(code)) != 1 {
+		return false
+	}
+
+	_ = s.redis.Delete(ctx, key)
+	return true
+}
+
+func deletionConfirmationKey(userID string) string {
+	return "privacy:delete:confirm:" + userID
+}
+
+func writeZipJSON(zw *zip.Writer, name string, v interface{}) error {
+	f, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+
+	encoder := json.NewEncoder(f)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
@@ -0,0 +1,78 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/smartstocks/backend/internal/models"
+)
+
+func TestWriteExportZip(t *testing.T) {
+	export := &models.UserDataExport{
+		UserID:      "user-1",
+		GeneratedAt: time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC),
+		Sections: []models.DataExportSection{
+			{Name: "profile", Rows: []map[string]interface{}{{"username": "ana", "email": "ana@example.com"}}},
+			{Name: "forum_posts", Rows: []map[string]interface{}{}},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := (&PrivacyService{}).WriteExportZip(&buf, export); err != nil {
+		t.Fatalf("WriteExportZip() error = %v", err)
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("output is not a valid zip: %v", err)
+	}
+
+	files := map[string]*zip.File{}
+	var names []string
+	for _, f := range zr.File {
+		files[f.Name] = f
+		names = append(names, f.Name)
+	}
+	want := []string{"export.json", "profile.json", "forum_posts.json"}
+	if len(names) != len(want) {
+		t.Fatalf("zip entries = %v, want %v", names, want)
+	}
+	for i := range want {
+		if names[i] != want[i] {
+			t.Fatalf("zip entries = %v, want %v", names, want)
+		}
+	}
+
+	var manifest map[string]string
+	readZipJSON(t, files["export.json"], &manifest)
+	if manifest["user_id"] != "user-1" || manifest["generated_at"] != "2026-03-10T12:00:00Z" {
+		t.Errorf("manifest = %v", manifest)
+	}
+
+	var profile []map[string]string
+	readZipJSON(t, files["profile.json"], &profile)
+	if len(profile) != 1 || profile[0]["username"] != "ana" || profile[0]["email"] != "ana@example.com" {
+		t.Errorf("profile.json = %v", profile)
+	}
+
+	var posts []map[string]string
+	readZipJSON(t, files["forum_posts.json"], &posts)
+	if posts == nil || len(posts) != 0 {
+		t.Errorf("forum_posts.json = %v, want empty array", posts)
+	}
+}
+
+func readZipJSON(t *testing.T, f *zip.File, v interface{}) {
+	t.Helper()
+	rc, err := f.Open()
+	if err != nil {
+		t.Fatalf("open %s: %v", f.Name, err)
+	}
+	defer rc.Close()
+	if err := json.NewDecoder(rc).Decode(v); err != nil {
+		t.Fatalf("decode %s: %v", f.Name, err)
+	}
+}
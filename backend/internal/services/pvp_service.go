@@ -1,6 +1,7 @@
 package services
 
 import (
+	"database/sql"
 	"errors"
 	"fmt"
 
@@ -13,6 +14,9 @@ type PvPService struct {
 	simulatorRepo *repository.SimulatorRepository
 	userRepo      *repository.UserRepository
 	aiService     *SimulatorAIService
+	leaderboards  *LeaderboardService
+	achievements  *AchievementService
+	consumables   *ConsumableService
 }
 
 func NewPvPService(
@@ -20,12 +24,18 @@ func NewPvPService(
 	simulatorRepo *repository.SimulatorRepository,
 	userRepo *repository.UserRepository,
 	aiService *SimulatorAIService,
+	leaderboards *LeaderboardService,
+	achievements *AchievementService,
+	consumables *ConsumableService,
 ) *PvPService {
 	return &PvPService{
 		pvpRepo:       pvpRepo,
 		simulatorRepo: simulatorRepo,
 		userRepo:      userRepo,
 		aiService:     aiService,
+		leaderboards:  leaderboards,
+		achievements:  achievements,
+		consumables:   consumables,
 	}
 }
 
@@ -265,6 +275,78 @@ func (s *PvPService) SubmitDecision(userID string, req *models.SubmitPvPDecision
 	return nil, errors.New("waiting for opponent decision")
 }
 
+// UseSecondChance compra una segunda oportunidad: reemplaza la decisión ya
+// enviada en la ronda mientras el rival todavía no decidió. Se puede usar
+// una vez por partida.
+func (s *PvPService) UseSecondChance(userID string, req *models.SubmitPvPDecisionRequest, idempotencyKey string) (*models.PvPSecondChanceResponse, error) {
+	match, err := s.pvpRepo.GetMatchByID(req.MatchID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Verificar que el usuario es parte de la partida
+	if userID != match.Player1ID && userID != match.Player2ID {
+		return nil, errors.New("you are not part of this match")
+	}
+	isPlayer1 := userID == match.Player1ID
+
+	// Un reintento con la misma clave devuelve la compra original aunque la
+	// ronda ya se haya resuelto
+	previous, err := s.consumables.GetPurchaseByKey(userID, models.ConsumablePvPSecondChance, idempotencyKey)
+	if err != nil {
+		return nil, err
+	}
+	if previous != nil && previous.ReferenceID == req.MatchID {
+		return &models.PvPSecondChanceResponse{
+			Purchase:    *previous,
+			MatchID:     req.MatchID,
+			RoundNumber: req.RoundNumber,
+			Decision:    req.Decision,
+		}, nil
+	}
+
+	round, err := s.pvpRepo.GetRound(req.MatchID, req.RoundNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	yourDecision, opponentDecision := round.Player1Decision, round.Player2Decision
+	if !isPlayer1 {
+		yourDecision, opponentDecision = round.Player2Decision, round.Player1Decision
+	}
+	if !yourDecision.Valid {
+		return nil, errors.New("no decision to change")
+	}
+	if round.CompletedAt.Valid || opponentDecision.Valid {
+		return nil, errors.New("round already resolved")
+	}
+
+	// El rival puede decidir mientras tanto: el cambio solo se aplica si la
+	// ronda sigue abierta, y si no se revierte el cobro
+	roundResolved := errors.New("round already resolved")
+	description := fmt.Sprintf("Segunda oportunidad PvP (ronda %d)", req.RoundNumber)
+	purchase, err := s.consumables.Purchase(userID, models.ConsumablePvPSecondChance, req.MatchID, description, idempotencyKey, true, func(tx *sql.Tx) error {
+		changed, err := s.pvpRepo.ChangeRoundDecisionTx(tx, req.MatchID, req.RoundNumber, isPlayer1, req.Decision, req.TimeElapsed)
+		if err != nil {
+			return err
+		}
+		if !changed {
+			return roundResolved
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.PvPSecondChanceResponse{
+		Purchase:    *purchase,
+		MatchID:     req.MatchID,
+		RoundNumber: req.RoundNumber,
+		Decision:    req.Decision,
+	}, nil
+}
+
 // GetMatchResult obtiene el resultado final de una partida
 func (s *PvPService) GetMatchResult(userID, matchID string) (*models.MatchResultResponse, error) {
 	// Obtener partida
@@ -322,12 +404,14 @@ func (s *PvPService) GetMatchResult(userID, matchID string) (*models.MatchResult
 		streakBonus = pointsGained - 200 // Base es 200
 
 		// Actualizar stats
-		_ = s.pvpRepo.UpdatePvPStats(winnerID, loserID, pointsGained)
+		_ = s.pvpRepo.UpdatePvPStats(matchID, winnerID, loserID, pointsGained)
+		s.leaderboards.SyncUsers(winnerID, loserID)
 	} else if winner == "opponent" {
 		pointsGained = -100 // Pierde 100 puntos
 
 		// Actualizar stats
-		_ = s.pvpRepo.UpdatePvPStats(winnerID, loserID, 200) // El ganador recibe base
+		_ = s.pvpRepo.UpdatePvPStats(matchID, winnerID, loserID, 200) // El ganador recibe base
+		s.leaderboards.SyncUsers(winnerID, loserID)
 	} else {
 		pointsGained = 0 // Empate, no gana ni pierde
 	}
@@ -339,6 +423,12 @@ func (s *PvPService) GetMatchResult(userID, matchID string) (*models.MatchResult
 		_ = s.pvpRepo.CompleteMatch(matchID, "") // Sin ganador
 	}
 
+	// Logros con la partida ya cerrada (la remontada se calcula sobre el ganador)
+	if winner != "tie" {
+		s.achievements.Publish(winnerID, models.AchievementEventPvPMatchFinished)
+		s.achievements.Publish(loserID, models.AchievementEventPvPMatchFinished)
+	}
+
 	// Obtener stats actualizados
 	newStats, _ := s.userRepo.GetUserStats(userID)
 
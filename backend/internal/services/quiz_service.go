@@ -12,20 +12,35 @@ import (
 )
 
 type QuizService struct {
-	quizRepo      *repository.QuizRepository
-	userRepo      *repository.UserRepository
-	openAIService *OpenAIService
+	quizRepo          *repository.QuizRepository
+	userRepo          *repository.UserRepository
+	openAIService     *OpenAIService
+	assignmentService *AssignmentService
+	leaderboards      *LeaderboardService
+	achievements      *AchievementService
+	consumables       *ConsumableService
+	referrals         *ReferralService
 }
 
 func NewQuizService(
 	quizRepo *repository.QuizRepository,
 	userRepo *repository.UserRepository,
 	openAIService *OpenAIService,
+	assignmentService *AssignmentService,
+	leaderboards *LeaderboardService,
+	achievements *AchievementService,
+	consumables *ConsumableService,
+	referrals *ReferralService,
 ) *QuizService {
 	return &QuizService{
-		quizRepo:      quizRepo,
-		userRepo:      userRepo,
-		openAIService: openAIService,
+		quizRepo:          quizRepo,
+		userRepo:          userRepo,
+		openAIService:     openAIService,
+		assignmentService: assignmentService,
+		leaderboards:      leaderboards,
+		achievements:      achievements,
+		consumables:       consumables,
+		referrals:         referrals,
 	}
 }
 
@@ -66,6 +81,11 @@ func (s *QuizService) GetDailyQuiz(userID, difficulty string) (*models.QuizRespo
 
 	fmt.Printf("   Total preguntas obtenidas: %d\n", len(questions))
 
+	// Un intento extra comprado habilita el quiz aunque ya se haya rendido hoy
+	if !canAttempt && s.consumables.HasUnused(userID, models.ConsumableQuizExtraAttempt, quiz.ID) {
+		canAttempt = true
+	}
+
 	response := &models.QuizResponse{
 		Quiz:       quiz,
 		Questions:  questions,
@@ -170,8 +190,20 @@ func (s *QuizService) SubmitQuiz(userID string, req *models.SubmitQuizRequest) (
 		return nil, fmt.Errorf("error checking cooldown: %w", err)
 	}
 
-	if !canAttempt {
-		return nil, errors.New("you have already completed this quiz today")
+	// Un quiz asignado y sin entregar se puede resolver con el intento del día
+	// usado, pero esa entrega cuenta solo para la tarea y no suma puntos
+	assignedReplay := !canAttempt && s.assignmentService.HasPendingAssignment(userID, models.AssignmentTypeQuiz, req.QuizID)
+
+	// Con el intento del día usado, se consume un intento extra comprado
+	extraAttemptID := ""
+	if !canAttempt && !assignedReplay {
+		extraAttemptID, err = s.consumables.Consume(userID, models.ConsumableQuizExtraAttempt, req.QuizID)
+		if err != nil {
+			return nil, fmt.Errorf("error using extra attempt: %w", err)
+		}
+		if extraAttemptID == "" {
+			return nil, errors.New("you have already completed this quiz today")
+		}
 	}
 
 	// Validar respuestas
@@ -210,7 +242,14 @@ func (s *QuizService) SubmitQuiz(userID string, req *models.SubmitQuizRequest) (
 		"medium": 1000,
 		"hard":   2000,
 	}
+	// Un intento extra repite el quiz ya corregido: suma un porcentaje de los
+	// puntos y no cuenta para precisión, logros ni tareas
 	pointsEarned := pointsReward[difficulty]
+	if assignedReplay {
+		pointsEarned = 0
+	} else if extraAttemptID != "" {
+		pointsEarned = s.consumables.ExtraAttemptPoints(pointsEarned)
+	}
 
 	// Guardar intento
 	answersJSON, _ := json.Marshal(req.Answers)
@@ -224,9 +263,13 @@ func (s *QuizService) SubmitQuiz(userID string, req *models.SubmitQuizRequest) (
 		PointsEarned:     pointsEarned,
 		TimeTakenSeconds: req.TimeTakenSeconds,
 		Answers:          string(answersJSON),
+		ExtraAttemptID:   extraAttemptID,
 	}
 
 	if err := s.quizRepo.CreateAttempt(attempt); err != nil {
+		if extraAttemptID != "" {
+			s.consumables.Release(extraAttemptID)
+		}
 		return nil, fmt.Errorf("error saving attempt: %w", err)
 	}
 
@@ -236,9 +279,19 @@ func (s *QuizService) SubmitQuiz(userID string, req *models.SubmitQuizRequest) (
 	}
 
 	// Actualizar stats del usuario
-	if err := s.quizRepo.UpdateUserStatsAfterQuiz(userID, pointsEarned); err != nil {
+	if err := s.quizRepo.UpdateUserStatsAfterQuiz(userID, attempt.ID, pointsEarned); err != nil {
 		return nil, fmt.Errorf("error updating user stats: %w", err)
 	}
+	s.leaderboards.SyncUser(userID)
+	s.achievements.Publish(userID, models.AchievementEventQuizCompleted)
+
+	// El primer quiz completado puede habilitar la recompensa del que lo invitó
+	s.referrals.Evaluate(userID)
+
+	// Registrar la entrega si el quiz estaba asignado
+	if extraAttemptID == "" {
+		s.assignmentService.RecordQuizSubmitted(userID, req.QuizID, score)
+	}
 
 	// Obtener stats actualizados
 	userStats, err := s.userRepo.GetUserStats(userID)
@@ -254,10 +307,42 @@ func (s *QuizService) SubmitQuiz(userID string, req *models.SubmitQuizRequest) (
 		PointsEarned:   pointsEarned,
 		NewTotalPoints: userStats.Smartpoints,
 		NewRank:        userStats.RankTier,
+		ExtraAttempt:   extraAttemptID != "",
 		Results:        results,
 	}, nil
 }
 
+// BuyExtraAttempt compra un intento extra para el quiz diario de la
+// dificultad. Solo se puede comprar con el intento del día ya usado.
+func (s *QuizService) BuyExtraAttempt(userID, difficulty, idempotencyKey string) (*models.QuizExtraAttemptResponse, error) {
+	canAttempt, err := s.quizRepo.CheckCooldown(userID, difficulty)
+	if err != nil {
+		return nil, fmt.Errorf("error checking cooldown: %w", err)
+	}
+	if canAttempt {
+		return nil, errors.New("daily attempt still available")
+	}
+
+	quiz, err := s.GetDailyQuiz(userID, difficulty)
+	if err != nil {
+		return nil, err
+	}
+
+	description := fmt.Sprintf("Intento extra del quiz (%s)", difficulty)
+	purchase, err := s.consumables.Purchase(userID, models.ConsumableQuizExtraAttempt, quiz.Quiz.ID, description, idempotencyKey, false, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	quiz.CanAttempt = true
+	quiz.Cooldown = nil
+	return &models.QuizExtraAttemptResponse{
+		Purchase:      *purchase,
+		Quiz:          *quiz,
+		PointsPercent: s.consumables.Prices().ExtraAttemptPointsPercent,
+	}, nil
+}
+
 // GetQuizHistory obtiene el historial de quizzes del usuario
 func (s *QuizService) GetQuizHistory(userID string) (*models.QuizHistoryResponse, error) {
 	attempts, err := s.quizRepo.GetUserAttempts(userID, 50)
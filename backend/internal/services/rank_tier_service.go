@@ -0,0 +1,175 @@
+package services
+
+import (
+	"strings"
+
+	"github.com/smartstocks/backend/internal/models"
+	"github.com/smartstocks/backend/internal/ranks"
+	"github.com/smartstocks/backend/internal/repository"
+)
+
+type RankTierService struct {
+	rankTierRepo *repository.RankTierRepository
+	userRepo     *repository.UserRepository
+	tiers        *ranks.Table
+}
+
+func NewRankTierService(
+	rankTierRepo *repository.RankTierRepository,
+	userRepo *repository.UserRepository,
+	tiers *ranks.Table,
+) *RankTierService {
+	return &RankTierService{
+		rankTierRepo: rankTierRepo,
+		userRepo:     userRepo,
+		tiers:        tiers,
+	}
+}
+
+// SyncTiers copia la tabla configurada a MySQL para que los procedimientos
+// calculen los rangos con los mismos umbrales. Si los umbrales cambiaron, los
+// rangos ya guardados se corrigen con el comando ranks-recompute.
+func (s *RankTierService) SyncTiers() error {
+	return s.rankTierRepo.SyncTiers(s.tiers)
+}
+
+// GetTiers obtiene la tabla de rangos en el idioma pedido y el rango actual del usuario
+func (s *RankTierService) GetTiers(userID, locale string) (*models.RankTiersResponse, error) {
+	locale = rankLocale(locale)
+
+	stats, err := s.userRepo.GetUserStats(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &models.RankTiersResponse{
+		Locale:      locale,
+		Tiers:       rankTierInfos(s.tiers, locale),
+		Smartpoints: stats.Smartpoints,
+	}
+
+	current, ok := s.tiers.Lookup(stats.RankTier)
+	if !ok {
+		current = s.tiers.ForPoints(stats.Smartpoints)
+	}
+	currentInfo := response.Tiers[current.Level-1]
+	response.CurrentTier = &currentInfo
+
+	if next, ok := s.tiers.NextMinPoints(current); ok && next > stats.Smartpoints {
+		missing := next - stats.Smartpoints
+		response.PointsToNextTier = &missing
+	}
+
+	return response, nil
+}
+
+// GetTierHistory obtiene los últimos ascensos y descensos del usuario
+func (s *RankTierService) GetTierHistory(userID, locale string, limit int) (*models.RankTierHistoryResponse, error) {
+	locale = rankLocale(locale)
+	if limit <= 0 || limit > 100 {
+		limit = 50
+	}
+
+	changes, err := s.rankTierRepo.GetRankTierChanges(userID, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range changes {
+		from, _ := s.tiers.Lookup(changes[i].FromTier)
+		to, _ := s.tiers.Lookup(changes[i].ToTier)
+		changes[i].FromTierName = s.tiers.Name(from, locale)
+		changes[i].ToTierName = s.tiers.Name(to, locale)
+	}
+
+	return &models.RankTierHistoryResponse{
+		Locale:  locale,
+		Changes: changes,
+	}, nil
+}
+
+// RecomputeAll recalcula el rango de todos los usuarios con la tabla actual
+// y registra los ascensos y descensos. Con dryRun solo informa los cambios.
+// onUpdate se llama por cada usuario cuyo rango cambia; change es nil cuando
+// solo se normaliza un nombre anterior. Devuelve la cantidad de usuarios
+// actualizados.
+func (s *RankTierService) RecomputeAll(dryRun bool, onUpdate func(user models.UserRankTier, to ranks.Tier, change *ranks.Change)) (int, error) {
+	const batchSize = 500
+
+	updated := 0
+	afterID := ""
+	for {
+		users, err := s.rankTierRepo.GetUserRankTiersAfter(afterID, batchSize)
+		if err != nil {
+			return updated, err
+		}
+
+		for _, user := range users {
+			to := s.tiers.ForPoints(user.Smartpoints)
+			if user.RankTier == to.Code {
+				continue
+			}
+
+			var change *ranks.Change
+			from, _ := s.tiers.Lookup(user.RankTier)
+			if c, ok := ranks.DetectChange(from, to); ok {
+				change = &c
+			}
+
+			if !dryRun {
+				ok, err := s.rankTierRepo.UpdateUserRankTier(user, to.Code, change)
+				if err != nil {
+					return updated, err
+				}
+				if !ok {
+					continue
+				}
+			}
+			updated++
+			onUpdate(user, to, change)
+		}
+
+		if len(users) < batchSize {
+			return updated, nil
+		}
+		afterID = users[len(users)-1].UserID
+	}
+}
+
+// rankTierInfos arma la tabla de rangos con los nombres del idioma pedido
+func rankTierInfos(tiers *ranks.Table, locale string) []models.RankTierInfo {
+	definitions := tiers.Definitions()
+	infos := make([]models.RankTierInfo, 0, len(definitions))
+	for _, def := range definitions {
+		tier, _ := tiers.Lookup(def.Code)
+		info := models.RankTierInfo{
+			Code:      def.Code,
+			Name:      tiers.Name(tier, locale),
+			Group:     def.Group,
+			Level:     tier.Level,
+			MinPoints: def.MinPoints,
+		}
+		if next, ok := tiers.NextMinPoints(tier); ok {
+			maxPoints := next - 1
+			info.MaxPoints = &maxPoints
+		}
+		infos = append(infos, info)
+	}
+	return infos
+}
+
+// rankLocale idioma de los nombres a partir del parámetro lang o del header
+// Accept-Language ("en-US,en;q=0.9" -> "en"). Vacío usa el idioma por defecto.
+func rankLocale(value string) string {
+	value = strings.TrimSpace(strings.ToLower(value))
+	if i := strings.IndexAny(value, ",;"); i >= 0 {
+		value = value[:i]
+	}
+	if i := strings.IndexAny(value, "-_"); i >= 0 {
+		value = value[:i]
+	}
+	if value == "" || value == "*" {
+		return ranks.DefaultLocale
+	}
+	return value
+}
@@ -0,0 +1,44 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/smartstocks/backend/internal/ranks"
+)
+
+func TestRankLocale(t *testing.T) {
+	tests := map[string]string{
+		"":                     "es",
+		"*":                    "es",
+		"en":                   "en",
+		"EN-us":                "en",
+		"pt_BR":                "pt",
+		"en-US,en;q=0.9,es;q=": "en",
+	}
+	for value, want := range tests {
+		if got := rankLocale(value); got != want {
+			t.Errorf("rankLocale(%q) = %q, want %q", value, got, want)
+		}
+	}
+}
+
+func TestRankTierInfos(t *testing.T) {
+	tiers, err := ranks.Load("")
+	if err != nil {
+		t.Fatalf("ranks.Load() error = %v", err)
+	}
+
+	infos := rankTierInfos(tiers, "en")
+	if len(infos) != 10 {
+		t.Fatalf("len(infos) = %d, want 10", len(infos))
+	}
+
+	first := infos[0]
+	if first.Code != "bronze_1" || first.Name != "Bronze I" || first.Level != 1 || first.MaxPoints == nil || *first.MaxPoints != 399 {
+		t.Errorf("infos[0] = %+v", first)
+	}
+	last := infos[len(infos)-1]
+	if last.Code != "master" || last.MaxPoints != nil {
+		t.Errorf("infos[last] = %+v, want master without max", last)
+	}
+}
@@ -1,29 +1,74 @@
 package services
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"math"
+	"sort"
+	"time"
 
+	"github.com/smartstocks/backend/internal/config"
 	"github.com/smartstocks/backend/internal/models"
 	"github.com/smartstocks/backend/internal/repository"
+	"github.com/smartstocks/backend/pkg/database"
 )
 
 type RankingsService struct {
 	rankingsRepo *repository.RankingsRepository
 	userRepo     *repository.UserRepository
+	schoolRepo   *repository.SchoolRepository
+	leaderboards *LeaderboardService
+	schoolCfg    *config.SchoolRankingConfig
+	rankingCfg   *config.LeaderboardConfig
+	achievements *AchievementService
+	shop         *ShopService
+	redis        *database.RedisClient
 }
 
 func NewRankingsService(
 	rankingsRepo *repository.RankingsRepository,
 	userRepo *repository.UserRepository,
+	schoolRepo *repository.SchoolRepository,
+	leaderboards *LeaderboardService,
+	schoolCfg *config.SchoolRankingConfig,
+	rankingCfg *config.LeaderboardConfig,
+	achievements *AchievementService,
+	shop *ShopService,
+	redis *database.RedisClient,
 ) *RankingsService {
 	return &RankingsService{
 		rankingsRepo: rankingsRepo,
 		userRepo:     userRepo,
+		schoolRepo:   schoolRepo,
+		leaderboards: leaderboards,
+		schoolCfg:    schoolCfg,
+		rankingCfg:   rankingCfg,
+		achievements: achievements,
+		shop:         shop,
+		redis:        redis,
 	}
 }
 
-// GetGlobalLeaderboard obtiene el ranking global
-func (s *RankingsService) GetGlobalLeaderboard(userID string, limit, offset int) (*models.LeaderboardResponse, error) {
+// GetGlobalLeaderboard obtiene el ranking global. El histórico se sirve desde
+// Redis (si no está disponible, desde leaderboard_cache); las ventanas diaria,
+// semanal y mensual desde points_ledger.
+func (s *RankingsService) GetGlobalLeaderboard(userID, window string, limit, offset int) (*models.LeaderboardResponse, error) {
+	if window != models.LeaderboardWindowAllTime {
+		return s.windowLeaderboard("global", "", userID, window, limit, offset)
+	}
+
+	live, err := s.liveLeaderboard("global", "", userID, limit, offset)
+	if err == nil {
+		s.addRankDeltas(live, "")
+		s.addCosmetics(live)
+		return live, nil
+	}
+	if !isLeaderboardUnavailable(err) {
+		fmt.Printf("⚠️ Error reading live global leaderboard, using cache: %v\n", err)
+	}
+
 	// Obtener top players
 	topPlayers, err := s.rankingsRepo.GetGlobalLeaderboard(limit, offset)
 	if err != nil {
@@ -69,17 +114,44 @@ func (s *RankingsService) GetGlobalLeaderboard(userID string, limit, offset int)
 
 	response := &models.LeaderboardResponse{
 		Type:         "global",
+		Window:       models.LeaderboardWindowAllTime,
 		TopPlayers:   topPlayers,
 		UserPosition: userPosition,
 		TotalPlayers: totalPlayers,
 		LastUpdated:  lastUpdated,
 	}
+	s.addRankDeltas(response, "")
+	s.addCosmetics(response)
 
 	return response, nil
 }
 
-// GetSchoolLeaderboard obtiene el ranking de un colegio
-func (s *RankingsService) GetSchoolLeaderboard(userID, schoolID string, limit, offset int) (*models.LeaderboardResponse, error) {
+// GetSchoolLeaderboard obtiene el ranking de un colegio. Solo incluye miembros
+// con membresía aprobada (users.school_id se completa al aprobarla).
+func (s *RankingsService) GetSchoolLeaderboard(userID, schoolID, window string, limit, offset int) (*models.LeaderboardResponse, error) {
+	// Los colegios desactivados no tienen ranking
+	school, err := s.schoolRepo.GetSchoolByID(schoolID)
+	if err != nil {
+		return nil, fmt.Errorf("error getting school: %w", err)
+	}
+	if school == nil || !school.IsActive {
+		return nil, errors.New("school not found")
+	}
+
+	if window != models.LeaderboardWindowAllTime {
+		return s.windowLeaderboard("school", schoolID, userID, window, limit, offset)
+	}
+
+	live, err := s.liveLeaderboard("school", schoolID, userID, limit, offset)
+	if err == nil {
+		s.addRankDeltas(live, schoolID)
+		s.addCosmetics(live)
+		return live, nil
+	}
+	if !isLeaderboardUnavailable(err) {
+		fmt.Printf("⚠️ Error reading live leaderboard of school %s, using cache: %v\n", schoolID, err)
+	}
+
 	// Obtener top players del colegio
 	topPlayers, err := s.rankingsRepo.GetSchoolLeaderboard(schoolID, limit, offset)
 	if err != nil {
@@ -125,17 +197,225 @@ func (s *RankingsService) GetSchoolLeaderboard(userID, schoolID string, limit, o
 
 	response := &models.LeaderboardResponse{
 		Type:         "school",
+		Window:       models.LeaderboardWindowAllTime,
 		TopPlayers:   topPlayers,
 		UserPosition: userPosition,
 		TotalPlayers: totalPlayers,
 		LastUpdated:  lastUpdated,
 	}
+	s.addRankDeltas(response, schoolID)
+	s.addCosmetics(response)
+
+	return response, nil
+}
+
+// liveLeaderboard arma el ranking desde Redis: posiciones con ZREVRANGE y
+// ZREVRANK, datos de cada jugador desde MySQL. Con schoolID vacío es el global.
+func (s *RankingsService) liveLeaderboard(leaderboardType, schoolID, userID string, limit, offset int) (*models.LeaderboardResponse, error) {
+	if s.leaderboards == nil {
+		return nil, ErrLeaderboardUnavailable
+	}
+
+	var userIDs []string
+	var total, userPos int
+	var err error
+	if schoolID == "" {
+		userIDs, total, err = s.leaderboards.GlobalPage(limit, offset)
+		if err == nil {
+			userPos, err = s.leaderboards.GlobalPosition(userID)
+		}
+	} else {
+		userIDs, total, err = s.leaderboards.SchoolPage(schoolID, limit, offset)
+		if err == nil {
+			userPos, err = s.leaderboards.SchoolPosition(schoolID, userID)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	_, offset = leaderboardPage(limit, offset)
+	lookup := userIDs
+	if outsidePage(userPos, offset, len(userIDs)) {
+		lookup = append(append([]string{}, userIDs...), userID)
+	}
+	details, err := s.rankingsRepo.GetLeaderboardEntries(lookup)
+	if err != nil {
+		return nil, fmt.Errorf("error getting leaderboard entries: %w", err)
+	}
+
+	topPlayers := make([]models.LeaderboardEntry, 0, len(userIDs))
+	for i, id := range userIDs {
+		entry, ok := details[id]
+		if !ok {
+			// Cuenta eliminada después de la última reconstrucción
+			s.leaderboards.SyncUser(id)
+			continue
+		}
+		entry.RankPosition = offset + i + 1
+		entry.IsCurrentUser = id == userID
+		topPlayers = append(topPlayers, entry)
+	}
+
+	var userPosition *models.LeaderboardEntry
+	if entry, ok := details[userID]; ok && outsidePage(userPos, offset, len(userIDs)) {
+		entry.RankPosition = userPos
+		entry.IsCurrentUser = true
+		userPosition = &entry
+	}
+
+	return &models.LeaderboardResponse{
+		Type:         leaderboardType,
+		Window:       models.LeaderboardWindowAllTime,
+		TopPlayers:   topPlayers,
+		UserPosition: userPosition,
+		TotalPlayers: total,
+		LastUpdated:  time.Now(),
+	}, nil
+}
+
+// addRankDeltas completa cuántos puestos subió o bajó cada jugador desde la
+// última foto diaria. Si falla se devuelve el ranking sin variaciones.
+func (s *RankingsService) addRankDeltas(response *models.LeaderboardResponse, schoolID string) {
+	entries, userIDs := leaderboardEntries(response)
+	snapshots, err := s.rankingsRepo.GetLatestSnapshots(userIDs)
+	if err != nil {
+		fmt.Printf("⚠️ Error getting leaderboard snapshots: %v\n", err)
+		return
+	}
+
+	for _, entry := range entries {
+		if snapshot, ok := snapshots[entry.UserID]; ok {
+			applyRankDelta(entry, snapshot, schoolID)
+		}
+	}
+}
+
+// addCosmetics completa los cosméticos equipados de cada jugador. Si falla se
+// devuelve el ranking sin ellos.
+func (s *RankingsService) addCosmetics(response *models.LeaderboardResponse) {
+	if s.shop == nil {
+		return
+	}
+
+	entries, userIDs := leaderboardEntries(response)
+	cosmetics, err := s.shop.EquippedCosmetics(userIDs)
+	if err != nil {
+		fmt.Printf("⚠️ Error getting equipped cosmetics: %v\n", err)
+		return
+	}
+
+	for _, entry := range entries {
+		entry.Cosmetics = cosmetics[entry.UserID]
+	}
+}
+
+// leaderboardEntries entradas de la respuesta (la página y la posición del
+// usuario) con sus user ids
+func leaderboardEntries(response *models.LeaderboardResponse) ([]*models.LeaderboardEntry, []string) {
+	entries := make([]*models.LeaderboardEntry, 0, len(response.TopPlayers)+1)
+	for i := range response.TopPlayers {
+		entries = append(entries, &response.TopPlayers[i])
+	}
+	if response.UserPosition != nil {
+		entries = append(entries, response.UserPosition)
+	}
+
+	userIDs := make([]string, len(entries))
+	for i, entry := range entries {
+		userIDs[i] = entry.UserID
+	}
+	return entries, userIDs
+}
+
+// applyRankDelta compara la posición actual con la de la foto: la global en
+// el ranking global, la del colegio en el de un colegio (solo si en la foto
+// ya era miembro de ese colegio)
+func applyRankDelta(entry *models.LeaderboardEntry, snapshot models.LeaderboardSnapshot, schoolID string) {
+	previous := snapshot.GlobalPosition
+	if schoolID != "" {
+		if snapshot.SchoolID == nil || *snapshot.SchoolID != schoolID || snapshot.SchoolPosition == nil {
+			return
+		}
+		previous = *snapshot.SchoolPosition
+	}
+
+	delta := previous - entry.RankPosition
+	entry.PreviousPosition = &previous
+	entry.RankDelta = &delta
+}
+
+// windowLeaderboard arma el ranking por los puntos sumados en la ventana
+// (points_ledger). Con schoolID vacío es el global.
+func (s *RankingsService) windowLeaderboard(leaderboardType, schoolID, userID, window string, limit, offset int) (*models.LeaderboardResponse, error) {
+	since, err := leaderboardWindowStart(window, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	topPlayers, err := s.rankingsRepo.GetWindowLeaderboard(since, schoolID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("error getting %s leaderboard: %w", window, err)
+	}
+
+	// Marcar al usuario actual
+	userInTop := false
+	for i := range topPlayers {
+		if topPlayers[i].UserID == userID {
+			topPlayers[i].IsCurrentUser = true
+			userInTop = true
+		}
+	}
+
+	// Posición del usuario si no está en la página
+	var userPosition *models.LeaderboardEntry
+	if !userInTop {
+		entry, err := s.rankingsRepo.GetWindowPosition(since, schoolID, userID)
+		if err == nil && entry != nil {
+			entry.IsCurrentUser = true
+			userPosition = entry
+		}
+	}
+
+	totalPlayers, err := s.rankingsRepo.CountWindowPlayers(since, schoolID)
+	if err != nil {
+		totalPlayers = 0
+	}
+
+	response := &models.LeaderboardResponse{
+		Type:         leaderboardType,
+		Window:       window,
+		Since:        &since,
+		TopPlayers:   topPlayers,
+		UserPosition: userPosition,
+		TotalPlayers: totalPlayers,
+		LastUpdated:  time.Now(),
+	}
+	s.addCosmetics(response)
 
 	return response, nil
 }
 
+// leaderboardWindowStart inicio de la ventana que contiene a now: el día, la
+// semana (desde el lunes) o el mes
+func leaderboardWindowStart(window string, now time.Time) (time.Time, error) {
+	switch window {
+	case models.LeaderboardWindowDaily:
+		return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location()), nil
+	case models.LeaderboardWindowWeekly:
+		return weekStart(now), nil
+	case models.LeaderboardWindowMonthly:
+		return time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location()), nil
+	default:
+		return time.Time{}, fmt.Errorf("invalid window: %q", window)
+	}
+}
+
 // GetMySchoolLeaderboard obtiene el ranking del colegio del usuario
-func (s *RankingsService) GetMySchoolLeaderboard(userID string, limit, offset int) (*models.LeaderboardResponse, error) {
+func (s *RankingsService) GetMySchoolLeaderboard(userID, window string, limit, offset int) (*models.LeaderboardResponse, error) {
 	// Obtener usuario para saber su colegio
 	user, err := s.userRepo.GetUserByID(userID)
 	if err != nil {
@@ -146,19 +426,354 @@ func (s *RankingsService) GetMySchoolLeaderboard(userID string, limit, offset in
 		return nil, fmt.Errorf("user does not belong to any school")
 	}
 
-	return s.GetSchoolLeaderboard(userID, user.SchoolID.String, limit, offset)
+	return s.GetSchoolLeaderboard(userID, user.SchoolID.String, window, limit, offset)
+}
+
+// === RANKING ENTRE COLEGIOS ===
+
+const (
+	// Días sin actividad tras los que un alumno deja de contar como activo
+	// en la ventana histórica
+	schoolActiveDays = 30
+
+	// Pesos del puntaje de cada colegio (suman 1)
+	schoolWeightPoints        = 0.60
+	schoolWeightParticipation = 0.25
+	schoolWeightPvP           = 0.15
+
+	// Cada cuánto se recalcula el ranking entre colegios; mientras tanto se
+	// sirve desde Redis, igual que leaderboard_cache para el ranking global
+	schoolRankingCacheTTL = 10 * time.Minute
+)
+
+// cachedSchoolRanking ranking entre colegios guardado en Redis
+type cachedSchoolRanking struct {
+	Entries []models.SchoolRankingEntry `json:"entries"`
+	Since   *time.Time                  `json:"since,omitempty"`
 }
 
-// GetUserPosition obtiene la posición del usuario en los rankings
-func (s *RankingsService) GetUserPosition(userID string) (*models.UserPositionResponse, error) {
-	globalPos, schoolPos, err := s.rankingsRepo.GetUserPosition(userID)
+// GetInterSchoolLeaderboard obtiene el ranking entre colegios de la ventana indicada
+func (s *RankingsService) GetInterSchoolLeaderboard(userID, window string, limit, offset int) (*models.SchoolRankingResponse, error) {
+	if limit <= 0 || limit > 100 {
+		limit = 50
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	entries, since, err := s.schoolRanking(window, time.Now())
 	if err != nil {
-		return nil, fmt.Errorf("error getting user position: %w", err)
+		return nil, err
+	}
+
+	// Marcar el colegio del usuario
+	var mySchool *models.SchoolRankingEntry
+	if user, err := s.userRepo.GetUserByID(userID); err == nil && user.SchoolID.Valid {
+		for i := range entries {
+			if entries[i].SchoolID == user.SchoolID.String {
+				entries[i].IsCurrentSchool = true
+				entry := entries[i]
+				mySchool = &entry
+			}
+		}
+	}
+
+	total := len(entries)
+	page := []models.SchoolRankingEntry{}
+	if offset < total {
+		end := offset + limit
+		if end > total {
+			end = total
+		}
+		page = entries[offset:end]
+	}
+
+	return &models.SchoolRankingResponse{
+		Window:       window,
+		Since:        since,
+		TopN:         s.schoolTopN(),
+		MinMembers:   s.schoolMinMembers(),
+		Schools:      page,
+		MySchool:     mySchool,
+		TotalSchools: total,
+		GeneratedAt:  time.Now(),
+	}, nil
+}
+
+// GetSchoolProfile obtiene la página pública de un colegio: su posición
+// histórica y semanal y sus mejores alumnos
+func (s *RankingsService) GetSchoolProfile(schoolID string) (*models.SchoolProfileResponse, error) {
+	school, err := s.schoolRepo.GetSchoolByID(schoolID)
+	if err != nil {
+		return nil, fmt.Errorf("error getting school: %w", err)
+	}
+	if school == nil || !school.IsActive {
+		return nil, errors.New("school not found")
+	}
+
+	now := time.Now()
+	allTime, _, err := s.schoolRanking(models.SchoolRankingWindowAllTime, now)
+	if err != nil {
+		return nil, err
+	}
+	weekly, _, err := s.schoolRanking(models.SchoolRankingWindowWeekly, now)
+	if err != nil {
+		return nil, err
+	}
+
+	topPlayers, err := s.rankingsRepo.GetSchoolLeaderboard(schoolID, 10, 0)
+	if err != nil {
+		return nil, fmt.Errorf("error getting school leaderboard: %w", err)
+	}
+	if topPlayers == nil {
+		topPlayers = []models.LeaderboardEntry{}
 	}
 
-	totalPlayers, _ := s.rankingsRepo.GetTotalPlayers("global", "")
+	ranked := 0
+	for _, entry := range allTime {
+		if entry.Ranked {
+			ranked++
+		}
+	}
+
+	return &models.SchoolProfileResponse{
+		School:             *school,
+		AllTime:            findSchoolEntry(allTime, schoolID),
+		Weekly:             findSchoolEntry(weekly, schoolID),
+		TotalRankedSchools: ranked,
+		TopPlayers:         topPlayers,
+	}, nil
+}
+
+// schoolRanking obtiene el ranking de la ventana desde Redis o lo calcula y
+// lo guarda. Devuelve el inicio de la ventana semanal (nil en la histórica).
+func (s *RankingsService) schoolRanking(window string, now time.Time) ([]models.SchoolRankingEntry, *time.Time, error) {
+	ctx := context.Background()
+	key := schoolRankingCacheKey(window, now)
+
+	if s.redis != nil {
+		if raw, err := s.redis.Get(ctx, key); err == nil && raw != "" {
+			var cached cachedSchoolRanking
+			if err := json.Unmarshal([]byte(raw), &cached); err == nil {
+				return cached.Entries, cached.Since, nil
+			}
+		}
+	}
+
+	entries, since, err := s.computeSchoolRanking(window, now)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if s.redis != nil {
+		data, _ := json.Marshal(cachedSchoolRanking{Entries: entries, Since: since})
+		_ = s.redis.Set(ctx, key, string(data), schoolRankingCacheTTL)
+	}
+
+	return entries, since, nil
+}
+
+// computeSchoolRanking calcula el ranking de la ventana desde la base
+func (s *RankingsService) computeSchoolRanking(window string, now time.Time) ([]models.SchoolRankingEntry, *time.Time, error) {
+	var members []models.SchoolMemberPerformance
+	var since *time.Time
+	var err error
+
+	switch window {
+	case models.SchoolRankingWindowAllTime:
+		activeSince := now.AddDate(0, 0, -schoolActiveDays)
+		members, err = s.rankingsRepo.GetSchoolMemberPerformance(true, time.Time{}, activeSince)
+	case models.SchoolRankingWindowWeekly:
+		start := weekStart(now)
+		since = &start
+		members, err = s.rankingsRepo.GetSchoolMemberPerformance(false, start, start)
+	default:
+		return nil, nil, errors.New("invalid ranking window")
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("error getting school performance: %w", err)
+	}
+
+	return rankSchools(members, s.schoolTopN(), s.schoolMinMembers()), since, nil
+}
+
+// schoolRankingCacheKey la clave semanal incluye el lunes de la semana, así
+// el ranking se recalcula apenas empieza una semana nueva
+func schoolRankingCacheKey(window string, now time.Time) string {
+	if window == models.SchoolRankingWindowWeekly {
+		return "schools:ranking:" + window + ":" + weekStart(now).Format("2006-01-02")
+	}
+	return "schools:ranking:" + window
+}
+
+func (s *RankingsService) schoolTopN() int {
+	if s.schoolCfg == nil || s.schoolCfg.RankingTopN <= 0 {
+		return 10
+	}
+	return s.schoolCfg.RankingTopN
+}
+
+func (s *RankingsService) schoolMinMembers() int {
+	if s.schoolCfg == nil || s.schoolCfg.RankingMinMembers <= 0 {
+		return 1
+	}
+	return s.schoolCfg.RankingMinMembers
+}
+
+// rankSchools agrega el rendimiento de los miembros por colegio y los ordena.
+//
+// El puntaje combina el promedio de los topN mejores alumnos, la tasa de
+// participación y las victorias PvP por miembro. Para no favorecer a los
+// colegios chicos (cuyo promedio sale de pocos alumnos) el promedio se ajusta
+// hacia la media general con un peso de topN/2 alumnos ficticios. Los colegios
+// con menos de minMembers miembros aparecen al final sin posición.
+func rankSchools(members []models.SchoolMemberPerformance, topN, minMembers int) []models.SchoolRankingEntry {
+	type schoolAgg struct {
+		entry  models.SchoolRankingEntry
+		points []int
+	}
+
+	var order []string
+	bySchool := map[string]*schoolAgg{}
+	for _, m := range members {
+		agg, ok := bySchool[m.SchoolID]
+		if !ok {
+			agg = &schoolAgg{entry: models.SchoolRankingEntry{
+				SchoolID:   m.SchoolID,
+				SchoolName: m.SchoolName,
+				Location:   m.Location,
+			}}
+			bySchool[m.SchoolID] = agg
+			order = append(order, m.SchoolID)
+		}
+		agg.points = append(agg.points, m.Points)
+		agg.entry.MemberCount++
+		agg.entry.PvPWins += m.PvPWins
+		if m.Active {
+			agg.entry.ActiveMembers++
+		}
+	}
+
+	// Métricas crudas y media general de los colegios que clasifican
+	var meanSum float64
+	var rankedCount int
+	for _, id := range order {
+		agg := bySchool[id]
+		sort.Sort(sort.Reverse(sort.IntSlice(agg.points)))
+		n := len(agg.points)
+		if n > topN {
+			n = topN
+		}
+		sum := 0
+		for _, p := range agg.points[:n] {
+			sum += p
+		}
+		e := &agg.entry
+		e.TopAverage = float64(sum) / float64(n)
+		e.ParticipationRate = float64(e.ActiveMembers) / float64(e.MemberCount)
+		e.PvPWinsPerMember = float64(e.PvPWins) / float64(e.MemberCount)
+		e.Ranked = e.MemberCount >= minMembers
+		if e.Ranked {
+			meanSum += e.TopAverage
+			rankedCount++
+		}
+	}
+	globalMean := 0.0
+	if rankedCount > 0 {
+		globalMean = meanSum / float64(rankedCount)
+	}
+
+	// Ajuste por tamaño y máximos para normalizar
+	prior := float64(topN) / 2
+	var maxAdjusted, maxWins float64
+	for _, id := range order {
+		e := &bySchool[id].entry
+		n := float64(len(bySchool[id].points))
+		if n > float64(topN) {
+			n = float64(topN)
+		}
+		e.AdjustedTopAverage = (n*e.TopAverage + prior*globalMean) / (n + prior)
+		if !e.Ranked {
+			continue
+		}
+		maxAdjusted = math.Max(maxAdjusted, e.AdjustedTopAverage)
+		maxWins = math.Max(maxWins, e.PvPWinsPerMember)
+	}
+
+	entries := make([]models.SchoolRankingEntry, 0, len(order))
+	for _, id := range order {
+		e := bySchool[id].entry
+		score := schoolWeightParticipation * e.ParticipationRate
+		if maxAdjusted > 0 {
+			score += schoolWeightPoints * math.Min(e.AdjustedTopAverage/maxAdjusted, 1)
+		}
+		if maxWins > 0 {
+			score += schoolWeightPvP * math.Min(e.PvPWinsPerMember/maxWins, 1)
+		}
+		e.Score = roundTo(100*score, 2)
+		e.TopAverage = roundTo(e.TopAverage, 2)
+		e.AdjustedTopAverage = roundTo(e.AdjustedTopAverage, 2)
+		e.ParticipationRate = roundTo(e.ParticipationRate, 4)
+		e.PvPWinsPerMember = roundTo(e.PvPWinsPerMember, 4)
+		entries = append(entries, e)
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		if entries[i].Ranked != entries[j].Ranked {
+			return entries[i].Ranked
+		}
+		if entries[i].Score != entries[j].Score {
+			return entries[i].Score > entries[j].Score
+		}
+		return entries[i].SchoolName < entries[j].SchoolName
+	})
+
+	for i := range entries {
+		if entries[i].Ranked {
+			entries[i].RankPosition = i + 1
+		}
+	}
+
+	return entries
+}
+
+func findSchoolEntry(entries []models.SchoolRankingEntry, schoolID string) *models.SchoolRankingEntry {
+	for i := range entries {
+		if entries[i].SchoolID == schoolID {
+			entry := entries[i]
+			return &entry
+		}
+	}
+	return nil
+}
+
+// weekStart devuelve el lunes 00:00 de la semana de t
+func weekStart(t time.Time) time.Time {
+	offset := (int(t.Weekday()) + 6) % 7
+	day := t.AddDate(0, 0, -offset)
+	return time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, t.Location())
+}
+
+func roundTo(v float64, decimals int) float64 {
+	p := math.Pow(10, float64(decimals))
+	return math.Round(v*p) / p
+}
+
+// GetUserPosition obtiene la posición del usuario en los rankings de la ventana
+func (s *RankingsService) GetUserPosition(userID, window string) (*models.UserPositionResponse, error) {
+	var globalPos, schoolPos, totalPlayers int
+	var err error
+	if window == models.LeaderboardWindowAllTime {
+		globalPos, schoolPos, totalPlayers, err = s.userPositions(userID)
+	} else {
+		globalPos, schoolPos, totalPlayers, err = s.windowPositions(userID, window)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error getting user position: %w", err)
+	}
 
 	response := &models.UserPositionResponse{
+		Window:         window,
 		GlobalPosition: globalPos,
 		SchoolPosition: schoolPos,
 		TotalPlayers:   totalPlayers,
@@ -167,6 +782,198 @@ func (s *RankingsService) GetUserPosition(userID string) (*models.UserPositionRe
 	return response, nil
 }
 
+// userPositions posiciones global y en el colegio y total de jugadores. Desde
+// Redis son O(log n); si no está disponible se calculan en MySQL.
+func (s *RankingsService) userPositions(userID string) (globalPos, schoolPos, total int, err error) {
+	if s.leaderboards != nil {
+		globalPos, schoolPos, total, err = s.leaderboards.Positions(userID)
+		if err == nil {
+			return globalPos, schoolPos, total, nil
+		}
+		if !isLeaderboardUnavailable(err) {
+			fmt.Printf("⚠️ Error reading live positions of %s, using MySQL: %v\n", userID, err)
+		}
+	}
+
+	globalPos, schoolPos, err = s.rankingsRepo.GetUserPosition(userID)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	total, _ = s.rankingsRepo.GetTotalPlayers("global", "")
+	return globalPos, schoolPos, total, nil
+}
+
+// windowPositions posiciones global y en el colegio por los puntos de la
+// ventana (0 si no sumó puntos) y total de jugadores de la ventana
+func (s *RankingsService) windowPositions(userID, window string) (globalPos, schoolPos, total int, err error) {
+	since, err := leaderboardWindowStart(window, time.Now())
+	if err != nil {
+		return 0, 0, 0, err
+	}
+
+	entry, err := s.rankingsRepo.GetWindowPosition(since, "", userID)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	if entry != nil {
+		globalPos = entry.RankPosition
+	}
+
+	user, err := s.userRepo.GetUserByID(userID)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	if user.SchoolID.Valid {
+		entry, err := s.rankingsRepo.GetWindowPosition(since, user.SchoolID.String, userID)
+		if err != nil {
+			return 0, 0, 0, err
+		}
+		if entry != nil {
+			schoolPos = entry.RankPosition
+		}
+	}
+
+	total, err = s.rankingsRepo.CountWindowPlayers(since, "")
+	return globalPos, schoolPos, total, err
+}
+
+// GetDisciplineLeaderboard ranking de una disciplina: precisión en quizzes,
+// precisión en el simulador (opcionalmente de una dificultad), win rate PvP o
+// cursos completados. Solo figuran los jugadores con la actividad mínima.
+func (s *RankingsService) GetDisciplineLeaderboard(userID, discipline, difficulty string, limit, offset int) (*models.DisciplineLeaderboardResponse, error) {
+	metric, err := disciplineMetric(discipline, difficulty)
+	if err != nil {
+		return nil, err
+	}
+
+	if limit <= 0 || limit > 1000 {
+		limit = 100
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	minActivity := s.disciplineMinActivity(discipline)
+
+	topPlayers, err := s.rankingsRepo.GetDisciplineLeaderboard(discipline, difficulty, minActivity, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("error getting discipline leaderboard: %w", err)
+	}
+
+	userInTop := false
+	for i := range topPlayers {
+		if topPlayers[i].UserID == userID {
+			topPlayers[i].IsCurrentUser = true
+			userInTop = true
+		}
+	}
+
+	var userPosition *models.DisciplineLeaderboardEntry
+	if !userInTop {
+		userPosition, err = s.rankingsRepo.GetDisciplinePosition(discipline, difficulty, minActivity, userID)
+		if err != nil {
+			return nil, fmt.Errorf("error getting discipline position: %w", err)
+		}
+		if userPosition != nil {
+			userPosition.IsCurrentUser = true
+		}
+	}
+
+	total, err := s.rankingsRepo.CountDisciplinePlayers(discipline, difficulty, minActivity)
+	if err != nil {
+		return nil, fmt.Errorf("error counting discipline players: %w", err)
+	}
+
+	return &models.DisciplineLeaderboardResponse{
+		Discipline:   discipline,
+		Difficulty:   difficulty,
+		Metric:       metric,
+		MinActivity:  minActivity,
+		TopPlayers:   topPlayers,
+		UserPosition: userPosition,
+		TotalPlayers: total,
+		GeneratedAt:  time.Now(),
+	}, nil
+}
+
+// disciplineMetric valida la disciplina y la dificultad (solo aplica al
+// simulador) y devuelve la métrica con la que se ordena el ranking
+func disciplineMetric(discipline, difficulty string) (string, error) {
+	if difficulty != "" {
+		if discipline != models.DisciplineSimulator {
+			return "", errors.New("difficulty only applies to the simulator leaderboard")
+		}
+		switch models.SimulatorDifficulty(difficulty) {
+		case models.SimulatorDifficultyEasy, models.SimulatorDifficultyMedium, models.SimulatorDifficultyHard:
+		default:
+			return "", errors.New("invalid difficulty")
+		}
+	}
+
+	switch discipline {
+	case models.DisciplineQuiz, models.DisciplineSimulator:
+		return models.DisciplineMetricAccuracy, nil
+	case models.DisciplinePvP:
+		return models.DisciplineMetricWinRate, nil
+	case models.DisciplineCourses:
+		return models.DisciplineMetricCoursesCompleted, nil
+	}
+	return "", errors.New("invalid discipline")
+}
+
+// disciplineMinActivity intentos, partidas o cursos mínimos para figurar en
+// el ranking de la disciplina
+func (s *RankingsService) disciplineMinActivity(discipline string) int {
+	var configured, fallback int
+	switch discipline {
+	case models.DisciplineQuiz:
+		fallback = 5
+		if s.rankingCfg != nil {
+			configured = s.rankingCfg.MinQuizAttempts
+		}
+	case models.DisciplineSimulator:
+		fallback = 10
+		if s.rankingCfg != nil {
+			configured = s.rankingCfg.MinSimulatorAttempts
+		}
+	case models.DisciplinePvP:
+		fallback = 10
+		if s.rankingCfg != nil {
+			configured = s.rankingCfg.MinPvPMatches
+		}
+	case models.DisciplineCourses:
+		fallback = 1
+		if s.rankingCfg != nil {
+			configured = s.rankingCfg.MinCompletedCourses
+		}
+	}
+
+	if configured <= 0 {
+		return fallback
+	}
+	return configured
+}
+
+// GetRankHistory posiciones del usuario en las fotos diarias de los últimos
+// días, para graficar su evolución
+func (s *RankingsService) GetRankHistory(userID string, days int) (*models.RankHistoryResponse, error) {
+	if days <= 0 || days > 730 {
+		days = 30
+	}
+
+	now := time.Now()
+	since := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location()).AddDate(0, 0, -days)
+	snapshots, err := s.rankingsRepo.GetUserSnapshots(userID, since)
+	if err != nil {
+		return nil, fmt.Errorf("error getting rank history: %w", err)
+	}
+
+	return &models.RankHistoryResponse{
+		Days:      days,
+		Snapshots: snapshots,
+	}, nil
+}
+
 // GetPublicProfile obtiene el perfil público de un usuario
 func (s *RankingsService) GetPublicProfile(targetUserID, requestingUserID string) (*models.UserProfilePublic, error) {
 	// Obtener usuario
@@ -188,7 +995,7 @@ func (s *RankingsService) GetPublicProfile(targetUserID, requestingUserID string
 	}
 
 	// Obtener posiciones
-	globalPos, schoolPos, _ := s.rankingsRepo.GetUserPosition(targetUserID)
+	globalPos, schoolPos, _, _ := s.userPositions(targetUserID)
 
 	// Construir perfil público
 	profile := &models.UserProfilePublic{
@@ -219,6 +1026,15 @@ func (s *RankingsService) GetPublicProfile(targetUserID, requestingUserID string
 		profile.SchoolID = &user.SchoolID.String
 	}
 
+	if s.shop != nil {
+		cosmetics, err := s.shop.EquippedCosmetics([]string{targetUserID})
+		if err != nil {
+			fmt.Printf("⚠️ Error getting equipped cosmetics of user %s: %v\n", targetUserID, err)
+		} else {
+			profile.Cosmetics = cosmetics[targetUserID]
+		}
+	}
+
 	return profile, nil
 }
 
@@ -230,15 +1046,12 @@ func (s *RankingsService) GetUserAchievements(userID string) (*models.AllAchieve
 		return nil, fmt.Errorf("error getting achievements: %w", err)
 	}
 
-	// Obtener stats para calcular progreso
-	stats, err := s.userRepo.GetUserStats(userID)
+	// Progreso hacia los logros pendientes según las reglas configuradas
+	locked, err := s.achievements.GetLockedProgress(userID)
 	if err != nil {
-		return nil, fmt.Errorf("error getting user stats: %w", err)
+		return nil, fmt.Errorf("error getting achievement progress: %w", err)
 	}
 
-	// Definir todos los logros posibles y calcular progreso
-	locked := s.calculateAchievementProgress(userID, stats, unlocked)
-
 	response := &models.AllAchievementsResponse{
 		Unlocked:   unlocked,
 		Locked:     locked,
@@ -248,88 +1061,13 @@ func (s *RankingsService) GetUserAchievements(userID string) (*models.AllAchieve
 	return response, nil
 }
 
-// calculateAchievementProgress calcula el progreso de logros bloqueados
-func (s *RankingsService) calculateAchievementProgress(userID string, stats *models.UserStats, unlocked []models.Achievement) []models.AchievementProgress {
-	// Crear mapa de logros desbloqueados
-	unlockedMap := make(map[string]bool)
-	for _, achievement := range unlocked {
-		unlockedMap[achievement.AchievementType] = true
-	}
-
-	var locked []models.AchievementProgress
-
-	// Definir todos los logros posibles
-	allAchievements := []struct {
-		Type        string
-		Name        string
-		Description string
-		Required    int
-		Current     int
-	}{
-		{"first_win", "Primera Victoria", "Gana tu primera partida PvP", 1, stats.TotalWins},
-		{"win_streak_3", "En Racha", "Consigue 3 victorias seguidas", 3, stats.WinStreak},
-		{"win_streak_5", "Imparable", "Consigue 5 victorias seguidas", 5, stats.WinStreak},
-		{"win_streak_10", "Leyenda", "Consigue 10 victorias seguidas", 10, stats.WinStreak},
-		{"rank_bronze", "Rango Bronce", "Alcanza el rango Bronce", 1, boolToInt(stats.RankTier != "")},
-		{"rank_silver", "Ascenso a Plata", "Alcanza el rango Plata", 1, boolToInt(containsRank(stats.RankTier, "Plata", "Oro", "Maestro"))},
-		{"rank_gold", "Ascenso a Oro", "Alcanza el rango Oro", 1, boolToInt(containsRank(stats.RankTier, "Oro", "Maestro"))},
-		{"rank_master", "Maestro de las Finanzas", "Alcanza el rango Maestro", 1, boolToInt(stats.RankTier == "Maestro")},
-		{"quiz_master", "Maestro de Quizzes", "Completa 50 quizzes", 50, stats.TotalQuizzesCompleted},
-		{"simulator_expert", "Experto Simulador", "Completa 100 simulaciones", 100, stats.TotalSimulatorGames},
-		{"pvp_legend", "Leyenda PvP", "Gana 100 partidas PvP", 100, stats.TotalWins},
-		{"points_1000", "Mil Puntos", "Alcanza 1,000 SmartPoints", 1000, stats.Smartpoints},
-		{"points_5000", "Cinco Mil", "Alcanza 5,000 SmartPoints", 5000, stats.Smartpoints},
-		{"points_10000", "Diez Mil", "Alcanza 10,000 SmartPoints", 10000, stats.Smartpoints},
-	}
-
-	for _, ach := range allAchievements {
-		// Si ya está desbloqueado, saltar
-		if unlockedMap[ach.Type] {
-			continue
-		}
-
-		// Calcular progreso
-		progress := float64(0)
-		if ach.Required > 0 {
-			progress = (float64(ach.Current) / float64(ach.Required)) * 100
-			if progress > 100 {
-				progress = 100
-			}
-		}
-
-		locked = append(locked, models.AchievementProgress{
-			AchievementType: ach.Type,
-			Name:            ach.Name,
-			Description:     ach.Description,
-			Current:         ach.Current,
-			Required:        ach.Required,
-			Progress:        progress,
-			IsUnlocked:      false,
-		})
-	}
-
-	return locked
-}
-
 // UpdateLeaderboardCache fuerza actualización del cache
 func (s *RankingsService) UpdateLeaderboardCache() error {
-	return s.rankingsRepo.UpdateLeaderboardCache()
-}
-
-// === HELPERS ===
-
-func boolToInt(b bool) int {
-	if b {
-		return 1
+	if err := s.rankingsRepo.UpdateLeaderboardCache(); err != nil {
+		return err
 	}
-	return 0
-}
-
-func containsRank(current string, ranks ...string) bool {
-	for _, rank := range ranks {
-		if len(current) >= len(rank) && current[:len(rank)] == rank {
-			return true
-		}
+	if s.leaderboards != nil {
+		return s.leaderboards.Rebuild()
 	}
-	return false
+	return nil
 }
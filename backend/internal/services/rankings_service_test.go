@@ -0,0 +1,185 @@
+package services
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/smartstocks/backend/internal/config"
+	"github.com/smartstocks/backend/internal/models"
+)
+
+func schoolMembers(schoolID string, count, points, wins int, active bool) []models.SchoolMemberPerformance {
+	members := make([]models.SchoolMemberPerformance, count)
+	for i := range members {
+		members[i] = models.SchoolMemberPerformance{
+			SchoolID:   schoolID,
+			SchoolName: schoolID,
+			UserID:     fmt.Sprintf("%s-%d", schoolID, i),
+			Points:     points,
+			PvPWins:    wins,
+			Active:     active,
+		}
+	}
+	return members
+}
+
+func TestRankSchools(t *testing.T) {
+	var members []models.SchoolMemberPerformance
+	members = append(members, schoolMembers("grande", 12, 1000, 1, true)...)
+	members = append(members, schoolMembers("chico", 3, 1100, 0, true)...)
+	members = append(members, schoolMembers("mini", 2, 5000, 5, true)...)
+	// Un alumno inactivo y sin puntos no cuenta en el promedio del top 10
+	members = append(members, models.SchoolMemberPerformance{SchoolID: "grande", SchoolName: "grande", UserID: "grande-x"})
+
+	entries := rankSchools(members, 10, 3)
+	if len(entries) != 3 {
+		t.Fatalf("entries = %d", len(entries))
+	}
+
+	grande, chico, mini := findSchoolEntry(entries, "grande"), findSchoolEntry(entries, "chico"), findSchoolEntry(entries, "mini")
+
+	if grande.MemberCount != 13 || grande.ActiveMembers != 12 || grande.TopAverage != 1000 {
+		t.Errorf("grande = %+v", grande)
+	}
+	if grande.ParticipationRate != 0.9231 {
+		t.Errorf("grande participation = %v", grande.ParticipationRate)
+	}
+
+	// El promedio de los colegios chicos se acerca a la media general (1050)
+	if chico.AdjustedTopAverage >= chico.TopAverage || chico.AdjustedTopAverage <= 1050 {
+		t.Errorf("chico adjusted = %v", chico.AdjustedTopAverage)
+	}
+	if grande.AdjustedTopAverage <= grande.TopAverage {
+		t.Errorf("grande adjusted = %v", grande.AdjustedTopAverage)
+	}
+
+	// mini no llega al mínimo de miembros: queda al final y sin posición
+	if mini.Ranked || mini.RankPosition != 0 || entries[2].SchoolID != "mini" {
+		t.Errorf("mini = %+v", mini)
+	}
+	if !grande.Ranked || !chico.Ranked || entries[0].RankPosition != 1 || entries[1].RankPosition != 2 {
+		t.Errorf("ranking = %+v", entries)
+	}
+	for _, e := range entries {
+		if e.Score < 0 || e.Score > 100 {
+			t.Errorf("%s score out of range: %v", e.SchoolID, e.Score)
+		}
+	}
+
+	if got := rankSchools(nil, 10, 3); len(got) != 0 {
+		t.Errorf("empty ranking = %v", got)
+	}
+}
+
+func TestWeekStart(t *testing.T) {
+	loc := time.FixedZone("ART", -3*3600)
+	cases := map[string]time.Time{
+		"2026-10-12": time.Date(2026, 10, 12, 0, 0, 0, 0, loc),   // lunes
+		"2026-10-14": time.Date(2026, 10, 14, 15, 30, 0, 0, loc), // miércoles
+		"2026-10-18": time.Date(2026, 10, 18, 23, 59, 0, 0, loc), // domingo
+	}
+	for name, tm := range cases {
+		got := weekStart(tm)
+		want := time.Date(2026, 10, 12, 0, 0, 0, 0, loc)
+		if !got.Equal(want) {
+			t.Errorf("%s: weekStart = %v", name, got)
+		}
+	}
+}
+
+func TestLeaderboardWindowStart(t *testing.T) {
+	loc := time.FixedZone("ART", -3*3600)
+	now := time.Date(2026, 10, 14, 15, 30, 0, 0, loc) // miércoles
+
+	cases := map[string]time.Time{
+		models.LeaderboardWindowDaily:   time.Date(2026, 10, 14, 0, 0, 0, 0, loc),
+		models.LeaderboardWindowWeekly:  time.Date(2026, 10, 12, 0, 0, 0, 0, loc),
+		models.LeaderboardWindowMonthly: time.Date(2026, 10, 1, 0, 0, 0, 0, loc),
+	}
+	for window, want := range cases {
+		got, err := leaderboardWindowStart(window, now)
+		if err != nil {
+			t.Fatalf("%s: %v", window, err)
+		}
+		if !got.Equal(want) {
+			t.Errorf("%s: leaderboardWindowStart = %v, want %v", window, got, want)
+		}
+	}
+
+	for _, window := range []string{models.LeaderboardWindowAllTime, "yearly", ""} {
+		if _, err := leaderboardWindowStart(window, now); err == nil {
+			t.Errorf("%q: expected error", window)
+		}
+	}
+}
+
+func TestDisciplineMetric(t *testing.T) {
+	valid := []struct {
+		discipline, difficulty, metric string
+	}{
+		{models.DisciplineQuiz, "", models.DisciplineMetricAccuracy},
+		{models.DisciplineSimulator, "", models.DisciplineMetricAccuracy},
+		{models.DisciplineSimulator, string(models.SimulatorDifficultyHard), models.DisciplineMetricAccuracy},
+		{models.DisciplinePvP, "", models.DisciplineMetricWinRate},
+		{models.DisciplineCourses, "", models.DisciplineMetricCoursesCompleted},
+	}
+	for _, tc := range valid {
+		metric, err := disciplineMetric(tc.discipline, tc.difficulty)
+		if err != nil || metric != tc.metric {
+			t.Errorf("%s/%s: got %q, %v; want %q", tc.discipline, tc.difficulty, metric, err, tc.metric)
+		}
+	}
+
+	invalid := [][2]string{
+		{"chess", ""},
+		{"", ""},
+		{models.DisciplineSimulator, "extreme"},
+		{models.DisciplineQuiz, string(models.SimulatorDifficultyEasy)},
+	}
+	for _, tc := range invalid {
+		if _, err := disciplineMetric(tc[0], tc[1]); err == nil {
+			t.Errorf("%s/%s: expected error", tc[0], tc[1])
+		}
+	}
+}
+
+func TestDisciplineMinActivity(t *testing.T) {
+	s := &RankingsService{}
+	if got := s.disciplineMinActivity(models.DisciplineQuiz); got != 5 {
+		t.Errorf("default quiz minimum = %d, want 5", got)
+	}
+
+	s.rankingCfg = &config.LeaderboardConfig{MinPvPMatches: 20}
+	if got := s.disciplineMinActivity(models.DisciplinePvP); got != 20 {
+		t.Errorf("configured pvp minimum = %d, want 20", got)
+	}
+	if got := s.disciplineMinActivity(models.DisciplineSimulator); got != 10 {
+		t.Errorf("unset simulator minimum = %d, want 10", got)
+	}
+}
+
+func TestApplyRankDelta(t *testing.T) {
+	schoolA, schoolB := "school-a", "school-b"
+	schoolPos := 4
+	snapshot := models.LeaderboardSnapshot{GlobalPosition: 10, SchoolID: &schoolA, SchoolPosition: &schoolPos}
+
+	global := models.LeaderboardEntry{RankPosition: 7}
+	applyRankDelta(&global, snapshot, "")
+	if global.PreviousPosition == nil || *global.PreviousPosition != 10 || *global.RankDelta != 3 {
+		t.Errorf("global: previous %v delta %v, want 10 and +3", global.PreviousPosition, global.RankDelta)
+	}
+
+	school := models.LeaderboardEntry{RankPosition: 6}
+	applyRankDelta(&school, snapshot, schoolA)
+	if school.PreviousPosition == nil || *school.PreviousPosition != 4 || *school.RankDelta != -2 {
+		t.Errorf("school: previous %v delta %v, want 4 and -2", school.PreviousPosition, school.RankDelta)
+	}
+
+	// Se unió a otro colegio después de la foto
+	moved := models.LeaderboardEntry{RankPosition: 1}
+	applyRankDelta(&moved, snapshot, schoolB)
+	if moved.PreviousPosition != nil || moved.RankDelta != nil {
+		t.Errorf("other school: expected no delta, got previous %v delta %v", moved.PreviousPosition, moved.RankDelta)
+	}
+}
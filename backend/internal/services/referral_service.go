@@ -0,0 +1,240 @@
+package services
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/smartstocks/backend/internal/config"
+	"github.com/smartstocks/backend/internal/models"
+	"github.com/smartstocks/backend/internal/repository"
+)
+
+// referralCodeAlphabet letras y números sin los que se confunden (0/O, 1/I/L)
+const referralCodeAlphabet = "ABCDEFGHJKMNPQRSTUVWXYZ23456789"
+
+// referralCodeLength largo de los códigos generados
+const referralCodeLength = 8
+
+// referralDeferredBatch cuántos referidos diferidos se reevalúan por pasada
+const referralDeferredBatch = 200
+
+type ReferralService struct {
+	referralRepo       *repository.ReferralRepository
+	notificationRepo   *repository.NotificationRepository
+	accountLinkService *AccountLinkService
+	cfg                *config.ReferralsConfig
+}
+
+func NewReferralService(
+	referralRepo *repository.ReferralRepository,
+	notificationRepo *repository.NotificationRepository,
+	accountLinkService *AccountLinkService,
+	cfg *config.ReferralsConfig,
+) *ReferralService {
+	return &ReferralService{
+		referralRepo:       referralRepo,
+		notificationRepo:   notificationRepo,
+		accountLinkService: accountLinkService,
+		cfg:                cfg,
+	}
+}
+
+// ResolveCode obtiene el usuario dueño del código ingresado al registrarse.
+// Devuelve el código normalizado.
+func (s *ReferralService) ResolveCode(code string) (string, string, error) {
+	code = normalizeReferralCode(code)
+	if code == "" {
+		return "", "", errors.New("invalid referral code")
+	}
+
+	referrerID, err := s.referralRepo.GetUserIDByCode(code)
+	if err != nil {
+		return "", "", fmt.Errorf("error getting referral code: %w", err)
+	}
+	if referrerID == "" {
+		return "", "", errors.New("invalid referral code")
+	}
+	return referrerID, code, nil
+}
+
+// Attach registra al usuario recién creado como invitado del dueño del código
+func (s *ReferralService) Attach(referrerID, code, refereeID string) {
+	err := s.referralRepo.CreateReferral(&models.Referral{
+		ID:         uuid.New().String(),
+		ReferrerID: referrerID,
+		RefereeID:  refereeID,
+		Code:       code,
+	})
+	if err != nil {
+		fmt.Printf("⚠️ Error saving referral of %s by %s: %v\n", refereeID, referrerID, err)
+	}
+}
+
+// EnsureCode obtiene el código del usuario y lo genera si no tiene uno
+func (s *ReferralService) EnsureCode(userID string) (string, error) {
+	for i := 0; i < 5; i++ {
+		code, err := s.referralRepo.GetCode(userID)
+		if err != nil {
+			return "", fmt.Errorf("error getting referral code: %w", err)
+		}
+		if code != "" {
+			return code, nil
+		}
+
+		code, err = generateReferralCode()
+		if err != nil {
+			return "", err
+		}
+		set, err := s.referralRepo.SetCode(userID, code)
+		if err != nil {
+			return "", fmt.Errorf("error saving referral code: %w", err)
+		}
+		if set {
+			return code, nil
+		}
+		// El código ya era de otro usuario (o se asignó uno en paralelo):
+		// se vuelve a leer y, si hace falta, se prueba con otro
+	}
+	return "", errors.New("could not generate a unique referral code")
+}
+
+// Evaluate decide la recompensa del referido cuando el invitado verificó
+// su email y completó su primer quiz. Se llama en los dos momentos; los
+// errores se registran y no afectan a quien la llama.
+func (s *ReferralService) Evaluate(refereeID string) {
+	if err := s.evaluate(refereeID); err != nil {
+		fmt.Printf("⚠️ Error evaluating referral of %s: %v\n", refereeID, err)
+	}
+}
+
+// RunDeferredRewards reevalúa cada interval los referidos diferidos por el
+// límite mensual, que se recompensan cuando el que invitó vuelve a tener
+// cupo. Bloquea; se ejecuta en una goroutine.
+func (s *ReferralService) RunDeferredRewards(interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		refereeIDs, err := s.referralRepo.GetDeferredRefereeIDs(referralDeferredBatch)
+		if err != nil {
+			fmt.Printf("⚠️ Error getting deferred referrals: %v\n", err)
+			continue
+		}
+		for _, refereeID := range refereeIDs {
+			s.Evaluate(refereeID)
+		}
+	}
+}
+
+func (s *ReferralService) evaluate(refereeID string) error {
+	referral, err := s.referralRepo.GetPendingByReferee(refereeID)
+	if err != nil || referral == nil {
+		return err
+	}
+
+	qualified, err := s.referralRepo.IsQualified(refereeID)
+	if err != nil || !qualified {
+		return err
+	}
+
+	// Invitarse a uno mismo con una segunda cuenta no da tokens. El registro
+	// del invitado queda auditado como login, así que se compara también el
+	// dispositivo con el que se creó la cuenta
+	linked, err := s.accountLinkService.AreLinked(referral.ReferrerID, referral.RefereeID)
+	if err != nil {
+		return err
+	}
+	if linked {
+		return s.referralRepo.Reject(referral, models.ReferralRejectLinkedAccounts)
+	}
+
+	if s.cfg.RewardTokens <= 0 {
+		return nil
+	}
+
+	notification := &models.Notification{
+		UserID:      referral.ReferrerID,
+		Type:        models.NotificationReferralReward,
+		Title:       "¡Ganaste tokens por invitar!",
+		Body:        referralNotificationBody(referral.RefereeUsername, s.cfg.RewardTokens),
+		ReferenceID: &referral.ID,
+	}
+	return s.referralRepo.RewardReferral(referral, s.cfg.RewardTokens, s.cfg.MonthlyRewardLimit, s.cfg.TotalRewardLimit, func(tx *sql.Tx) error {
+		return s.notificationRepo.CreateTx(tx, notification)
+	})
+}
+
+// GetReferrals obtiene el código del usuario, sus referidos y lo que ganó
+func (s *ReferralService) GetReferrals(userID string, limit int) (*models.ReferralsResponse, error) {
+	code, err := s.EnsureCode(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	summary, rewardedMonth, err := s.referralRepo.GetSummary(userID)
+	if err != nil {
+		return nil, fmt.Errorf("error getting referral summary: %w", err)
+	}
+
+	referrals, err := s.referralRepo.GetReferrals(userID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("error getting referrals: %w", err)
+	}
+
+	return &models.ReferralsResponse{
+		Code: code,
+		Limits: models.ReferralLimits{
+			RewardTokens:       s.cfg.RewardTokens,
+			MonthlyRewardLimit: s.cfg.MonthlyRewardLimit,
+			TotalRewardLimit:   s.cfg.TotalRewardLimit,
+			RewardedThisMonth:  rewardedMonth,
+		},
+		Summary:   *summary,
+		Referrals: referrals,
+	}, nil
+}
+
+// generateReferralCode genera un código aleatorio de referralCodeLength
+// caracteres
+func generateReferralCode() (string, error) {
+	max := big.NewInt(int64(len(referralCodeAlphabet)))
+	code := make([]byte, referralCodeLength)
+	for i := range code {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", fmt.Errorf("error generating referral code: %w", err)
+		}
+		code[i] = referralCodeAlphabet[n.Int64()]
+	}
+	return string(code), nil
+}
+
+// normalizeReferralCode pasa el código a mayúsculas y quita espacios y
+// guiones. Devuelve "" si tiene caracteres que no puede tener un código.
+func normalizeReferralCode(code string) string {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	code = strings.NewReplacer("-", "", " ", "").Replace(code)
+	if code == "" || len(code) > 12 {
+		return ""
+	}
+	for _, r := range code {
+		if (r < 'A' || r > 'Z') && (r < '0' || r > '9') {
+			return ""
+		}
+	}
+	return code
+}
+
+func referralNotificationBody(refereeUsername string, tokens int) string {
+	return fmt.Sprintf("%s completó su primer quiz con tu código. Ganaste %d tokens.", refereeUsername, tokens)
+}
@@ -0,0 +1,55 @@
+package services
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGenerateReferralCode(t *testing.T) {
+	seen := map[string]bool{}
+	for i := 0; i < 100; i++ {
+		code, err := generateReferralCode()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(code) != referralCodeLength {
+			t.Fatalf("code %q has length %d", code, len(code))
+		}
+		for _, r := range code {
+			if !strings.ContainsRune(referralCodeAlphabet, r) {
+				t.Fatalf("code %q has ambiguous character %q", code, r)
+			}
+		}
+		if normalizeReferralCode(code) != code {
+			t.Errorf("generated code %q is not normalized", code)
+		}
+		seen[code] = true
+	}
+	if len(seen) < 99 {
+		t.Errorf("only %d distinct codes out of 100", len(seen))
+	}
+}
+
+func TestNormalizeReferralCode(t *testing.T) {
+	cases := map[string]string{
+		"abcd2345":         "ABCD2345",
+		"  ABCD-2345 ":     "ABCD2345",
+		"abcd 2345":        "ABCD2345",
+		"":                 "",
+		"   ":              "",
+		"ABCD_2345":        "",
+		"ÁBCD2345":         "",
+		"ABCDEFGH23456789": "",
+	}
+	for in, want := range cases {
+		if got := normalizeReferralCode(in); got != want {
+			t.Errorf("normalizeReferralCode(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestReferralNotificationBody(t *testing.T) {
+	if got := referralNotificationBody("ana", 50); got != "ana completó su primer quiz con tu código. Ganaste 50 tokens." {
+		t.Errorf("body = %q", got)
+	}
+}
@@ -0,0 +1,596 @@
+package services
+
+import (
+	"database/sql"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/smartstocks/backend/internal/models"
+	"github.com/smartstocks/backend/internal/repository"
+)
+
+// maxSchoolImportRows límite de filas por importación CSV
+const maxSchoolImportRows = 1000
+
+// membershipRejectionCooldown tiempo tras un rechazo en el que no se pueden
+// enviar solicitudes para revisión manual
+const membershipRejectionCooldown = 7 * 24 * time.Hour
+
+// ErrSchoolForbidden el usuario no administra el colegio
+var ErrSchoolForbidden = errors.New("unauthorized: you are not an administrator of this school")
+
+// publicEmailDomains proveedores de email gratuitos: no prueban pertenencia a un colegio
+var publicEmailDomains = map[string]bool{
+	"gmail.com":      true,
+	"googlemail.com": true,
+	"hotmail.com":    true,
+	"hotmail.com.ar": true,
+	"outlook.com":    true,
+	"live.com":       true,
+	"yahoo.com":      true,
+	"yahoo.com.ar":   true,
+	"icloud.com":     true,
+	"protonmail.com": true,
+}
+
+type SchoolService struct {
+	schoolRepo   *repository.SchoolRepository
+	userRepo     *repository.UserRepository
+	leaderboards *LeaderboardService
+}
+
+func NewSchoolService(
+	schoolRepo *repository.SchoolRepository,
+	userRepo *repository.UserRepository,
+	leaderboards *LeaderboardService,
+) *SchoolService {
+	return &SchoolService{
+		schoolRepo:   schoolRepo,
+		userRepo:     userRepo,
+		leaderboards: leaderboards,
+	}
+}
+
+// === ADMINISTRADOR GLOBAL ===
+
+// CreateSchool da de alta un colegio con un código de acceso
+func (s *SchoolService) CreateSchool(req *models.CreateSchoolRequest) (*models.SchoolDetail, error) {
+	domains, err := normalizeEmailDomains(req.EmailDomains)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkDomainsAvailable("", domains); err != nil {
+		return nil, err
+	}
+
+	school := &models.SchoolDetail{
+		School: models.School{
+			Name:     strings.TrimSpace(req.Name),
+			Location: strings.TrimSpace(req.Location),
+		},
+		EmailDomains: domains,
+	}
+
+	for i := 0; i < joinCodeRetries; i++ {
+		school.JoinCode, err = generateJoinCode()
+		if err != nil {
+			return nil, err
+		}
+
+		existing, err := s.schoolRepo.GetSchoolByJoinCode(school.JoinCode)
+		if err != nil {
+			return nil, err
+		}
+		if existing == nil {
+			if err := s.schoolRepo.CreateSchool(school); err != nil {
+				return nil, fmt.Errorf("error creating school: %w", err)
+			}
+			return school, nil
+		}
+	}
+
+	return nil, errors.New("could not generate a unique join code")
+}
+
+// ImportSchools da de alta colegios desde un CSV con columnas name, location y
+// email_domains (separados por ";"). Las filas con errores se informan y se omiten.
+func (s *SchoolService) ImportSchools(r io.Reader) (*models.SchoolImportResult, error) {
+	rows, errs, err := parseSchoolsCSV(r)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &models.SchoolImportResult{
+		Created: []models.SchoolDetail{},
+		Errors:  errs,
+	}
+
+	for _, row := range rows {
+		school, err := s.CreateSchool(&row.req)
+		if err != nil {
+			result.Errors = append(result.Errors, models.SchoolImportError{
+				Line:  row.line,
+				Name:  row.req.Name,
+				Error: err.Error(),
+			})
+			continue
+		}
+		result.Created = append(result.Created, *school)
+	}
+
+	return result, nil
+}
+
+// SetSchoolActive activa o desactiva un colegio. Un colegio inactivo no admite
+// nuevas solicitudes y no tiene ranking.
+func (s *SchoolService) SetSchoolActive(schoolID string, active bool) (*models.SchoolDetail, error) {
+	school, err := s.getSchool(schoolID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.schoolRepo.SetSchoolActive(schoolID, active); err != nil {
+		return nil, fmt.Errorf("error updating school: %w", err)
+	}
+
+	school.IsActive = active
+	return school, nil
+}
+
+// AddSchoolAdmin designa a un usuario como administrador del colegio
+func (s *SchoolService) AddSchoolAdmin(schoolID, userID string) error {
+	if _, err := s.getSchool(schoolID); err != nil {
+		return err
+	}
+	if _, err := s.userRepo.GetUserByID(userID); err != nil {
+		return errors.New("user not found")
+	}
+	return s.schoolRepo.AddSchoolAdmin(schoolID, userID)
+}
+
+// RemoveSchoolAdmin quita un administrador del colegio
+func (s *SchoolService) RemoveSchoolAdmin(schoolID, userID string) error {
+	removed, err := s.schoolRepo.RemoveSchoolAdmin(schoolID, userID)
+	if err != nil {
+		return err
+	}
+	if !removed {
+		return errors.New("user is not an administrator of this school")
+	}
+	return nil
+}
+
+// === ADMINISTRADOR DEL COLEGIO ===
+
+// GetSchool obtiene el colegio con su código y dominios
+func (s *SchoolService) GetSchool(schoolID, adminID string) (*models.SchoolDetail, error) {
+	return s.getManagedSchool(schoolID, adminID)
+}
+
+// UpdateSchool edita nombre, ubicación o dominios de email
+func (s *SchoolService) UpdateSchool(schoolID, adminID string, req *models.UpdateSchoolRequest) (*models.SchoolDetail, error) {
+	if _, err := s.getManagedSchool(schoolID, adminID); err != nil {
+		return nil, err
+	}
+
+	if req.EmailDomains != nil {
+		domains, err := normalizeEmailDomains(*req.EmailDomains)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.checkDomainsAvailable(schoolID, domains); err != nil {
+			return nil, err
+		}
+		if err := s.schoolRepo.ReplaceEmailDomains(schoolID, domains); err != nil {
+			return nil, fmt.Errorf("error updating email domains: %w", err)
+		}
+	}
+
+	if err := s.schoolRepo.UpdateSchool(schoolID, req); err != nil {
+		return nil, fmt.Errorf("error updating school: %w", err)
+	}
+
+	return s.schoolRepo.GetSchoolDetail(schoolID)
+}
+
+// RegenerateJoinCode invalida el código actual y genera otro
+func (s *SchoolService) RegenerateJoinCode(schoolID, adminID string) (*models.SchoolDetail, error) {
+	school, err := s.getManagedSchool(schoolID, adminID)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := 0; i < joinCodeRetries; i++ {
+		code, err := generateJoinCode()
+		if err != nil {
+			return nil, err
+		}
+
+		existing, err := s.schoolRepo.GetSchoolByJoinCode(code)
+		if err != nil {
+			return nil, err
+		}
+		if existing == nil {
+			if err := s.schoolRepo.UpdateJoinCode(schoolID, sql.NullString{String: code, Valid: true}); err != nil {
+				return nil, fmt.Errorf("error updating join code: %w", err)
+			}
+			school.JoinCode = code
+			return school, nil
+		}
+	}
+
+	return nil, errors.New("could not generate a unique join code")
+}
+
+// DisableJoinCode deshabilita el ingreso por código (solo dominio o aprobación)
+func (s *SchoolService) DisableJoinCode(schoolID, adminID string) (*models.SchoolDetail, error) {
+	school, err := s.getManagedSchool(schoolID, adminID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.schoolRepo.UpdateJoinCode(schoolID, sql.NullString{}); err != nil {
+		return nil, fmt.Errorf("error updating join code: %w", err)
+	}
+
+	school.JoinCode = ""
+	return school, nil
+}
+
+// GetMembers lista miembros y solicitudes del colegio
+func (s *SchoolService) GetMembers(schoolID, adminID, status string) ([]models.SchoolMembership, error) {
+	if _, err := s.getManagedSchool(schoolID, adminID); err != nil {
+		return nil, err
+	}
+	return s.schoolRepo.GetSchoolMemberships(schoolID, status)
+}
+
+// ReviewMember aprueba o rechaza una solicitud de membresía
+func (s *SchoolService) ReviewMember(schoolID, adminID, userID string, approve bool) (*models.SchoolMembership, error) {
+	if _, err := s.getManagedSchool(schoolID, adminID); err != nil {
+		return nil, err
+	}
+
+	membership, err := s.schoolRepo.GetMembership(userID)
+	if err != nil {
+		return nil, err
+	}
+	if membership == nil || membership.SchoolID != schoolID {
+		return nil, errors.New("membership not found")
+	}
+
+	membership.Status = models.MembershipStatusRejected
+	if approve {
+		membership.Status = models.MembershipStatusApproved
+		if membership.Method == models.MembershipMethodRequest {
+			membership.Method = models.MembershipMethodAdmin
+		}
+	}
+	membership.ReviewedBy = sql.NullString{String: adminID, Valid: true}
+	membership.ReviewedAt = sql.NullTime{Time: time.Now(), Valid: true}
+
+	if err := s.schoolRepo.SaveMembership(membership); err != nil {
+		return nil, fmt.Errorf("error saving membership: %w", err)
+	}
+	s.leaderboards.SyncUser(userID)
+
+	return membership, nil
+}
+
+// RemoveMember quita a un miembro del colegio
+func (s *SchoolService) RemoveMember(schoolID, adminID, userID string) error {
+	if _, err := s.getManagedSchool(schoolID, adminID); err != nil {
+		return err
+	}
+
+	membership, err := s.schoolRepo.GetMembership(userID)
+	if err != nil {
+		return err
+	}
+	if membership == nil || membership.SchoolID != schoolID {
+		return errors.New("membership not found")
+	}
+
+	if _, err := s.schoolRepo.DeleteMembership(userID); err != nil {
+		return err
+	}
+	s.leaderboards.SyncUser(userID)
+	return nil
+}
+
+// === USUARIO ===
+
+// RequestMembership solicita unirse a un colegio. Se aprueba en el acto con el
+// código de acceso del colegio o con un email verificado de un dominio del
+// colegio; si no, queda pendiente hasta que un administrador la revise.
+func (s *SchoolService) RequestMembership(userID string, req *models.JoinSchoolRequest) (*models.SchoolMembership, error) {
+	var school *models.School
+	var err error
+	method := models.MembershipMethodRequest
+
+	switch {
+	case req.JoinCode != nil && strings.TrimSpace(*req.JoinCode) != "":
+		school, err = s.schoolRepo.GetSchoolByJoinCode(strings.ToUpper(strings.TrimSpace(*req.JoinCode)))
+		if err != nil {
+			return nil, err
+		}
+		if school == nil || !school.IsActive {
+			return nil, errors.New("invalid join code")
+		}
+		method = models.MembershipMethodJoinCode
+	case req.SchoolID != nil && *req.SchoolID != "":
+		school, err = s.schoolRepo.GetSchoolByID(*req.SchoolID)
+		if err != nil {
+			return nil, err
+		}
+		if school == nil || !school.IsActive {
+			return nil, errors.New("invalid school_id")
+		}
+	default:
+		return nil, errors.New("school_id or join_code is required")
+	}
+
+	user, err := s.userRepo.GetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	current, err := s.schoolRepo.GetMembership(userID)
+	if err != nil {
+		return nil, err
+	}
+	if current != nil && current.SchoolID == school.ID && current.Status == models.MembershipStatusApproved {
+		return current, nil
+	}
+
+	if method == models.MembershipMethodRequest && user.EmailVerified {
+		domainSchoolID, err := s.schoolRepo.GetSchoolIDByEmailDomain(emailDomain(user.Email))
+		if err != nil {
+			return nil, err
+		}
+		if domainSchoolID == school.ID {
+			method = models.MembershipMethodEmailDomain
+		}
+	}
+
+	now := time.Now()
+
+	// El código de acceso y el dominio prueban la pertenencia; una solicitud
+	// manual tras un rechazo reciente no pisa la decisión del administrador
+	if method == models.MembershipMethodRequest {
+		if wait := rejectionCooldownRemaining(current, now); wait > 0 {
+			return nil, fmt.Errorf("membership request was rejected, try again in %d hours", int(wait.Hours())+1)
+		}
+	}
+
+	membership := &models.SchoolMembership{
+		UserID:      userID,
+		SchoolID:    school.ID,
+		Status:      models.MembershipStatusPending,
+		Method:      method,
+		RequestedAt: now,
+		SchoolName:  school.Name,
+	}
+	if method != models.MembershipMethodRequest {
+		membership.Status = models.MembershipStatusApproved
+		membership.ReviewedAt = sql.NullTime{Time: now, Valid: true}
+	}
+
+	if err := s.schoolRepo.SaveMembership(membership); err != nil {
+		return nil, fmt.Errorf("error saving membership: %w", err)
+	}
+	s.leaderboards.SyncUser(userID)
+
+	return membership, nil
+}
+
+// ValidateSchoolID verifica que el colegio exista y esté activo
+func (s *SchoolService) ValidateSchoolID(schoolID string) error {
+	school, err := s.schoolRepo.GetSchoolByID(schoolID)
+	if err != nil || school == nil || !school.IsActive {
+		return errors.New("invalid school_id")
+	}
+	return nil
+}
+
+// GetMyMembership obtiene la membresía del usuario (nil si no tiene)
+func (s *SchoolService) GetMyMembership(userID string) (*models.SchoolMembership, error) {
+	return s.schoolRepo.GetMembership(userID)
+}
+
+// LeaveSchool deja el colegio o cancela la solicitud. Un rechazo no se puede
+// borrar: vence solo.
+func (s *SchoolService) LeaveSchool(userID string) error {
+	current, err := s.schoolRepo.GetMembership(userID)
+	if err != nil {
+		return err
+	}
+	if current != nil && current.Status == models.MembershipStatusRejected {
+		return errors.New("not a member of any school")
+	}
+
+	removed, err := s.schoolRepo.DeleteMembership(userID)
+	if err != nil {
+		return err
+	}
+	if !removed {
+		return errors.New("not a member of any school")
+	}
+	s.leaderboards.SyncUser(userID)
+	return nil
+}
+
+// === HELPERS ===
+
+// getSchool obtiene el colegio o "school not found"
+func (s *SchoolService) getSchool(schoolID string) (*models.SchoolDetail, error) {
+	school, err := s.schoolRepo.GetSchoolDetail(schoolID)
+	if err != nil {
+		return nil, err
+	}
+	if school == nil {
+		return nil, errors.New("school not found")
+	}
+	return school, nil
+}
+
+// getManagedSchool obtiene el colegio verificando que el usuario lo administre (o sea admin global)
+func (s *SchoolService) getManagedSchool(schoolID, adminID string) (*models.SchoolDetail, error) {
+	school, err := s.getSchool(schoolID)
+	if err != nil {
+		return nil, err
+	}
+
+	isAdmin, err := s.schoolRepo.IsSchoolAdmin(schoolID, adminID)
+	if err != nil {
+		return nil, err
+	}
+	if !isAdmin {
+		role, err := s.userRepo.GetUserRole(adminID)
+		if err != nil {
+			return nil, err
+		}
+		if role != models.RoleAdmin {
+			return nil, ErrSchoolForbidden
+		}
+	}
+
+	return school, nil
+}
+
+// checkDomainsAvailable verifica que ningún dominio pertenezca a otro colegio
+func (s *SchoolService) checkDomainsAvailable(schoolID string, domains []string) error {
+	for _, domain := range domains {
+		owner, err := s.schoolRepo.GetSchoolIDByEmailDomain(domain)
+		if err != nil {
+			return err
+		}
+		if owner != "" && owner != schoolID {
+			return fmt.Errorf("email domain %s already belongs to another school", domain)
+		}
+	}
+	return nil
+}
+
+// rejectionCooldownRemaining cuánto falta para poder volver a solicitar
+// tras un rechazo (0 si no hay rechazo vigente)
+func rejectionCooldownRemaining(current *models.SchoolMembership, now time.Time) time.Duration {
+	if current == nil || current.Status != models.MembershipStatusRejected || !current.ReviewedAt.Valid {
+		return 0
+	}
+	remaining := current.ReviewedAt.Time.Add(membershipRejectionCooldown).Sub(now)
+	if remaining < 0 {
+		return 0
+	}
+	return remaining
+}
+
+// normalizeEmailDomains pasa a minúsculas, quita "@" y duplicados, y rechaza
+// dominios inválidos o de proveedores públicos
+func normalizeEmailDomains(domains []string) ([]string, error) {
+	seen := make(map[string]bool, len(domains))
+	normalized := []string{}
+
+	for _, raw := range domains {
+		domain := strings.ToLower(strings.TrimPrefix(strings.TrimSpace(raw), "@"))
+		if domain == "" || seen[domain] {
+			continue
+		}
+		if !strings.Contains(domain, ".") || strings.ContainsAny(domain, "@ /") ||
+			strings.HasPrefix(domain, ".") || strings.HasSuffix(domain, ".") {
+			return nil, fmt.Errorf("invalid email domain: %s", raw)
+		}
+		if publicEmailDomains[domain] {
+			return nil, fmt.Errorf("public email domain not allowed: %s", domain)
+		}
+		seen[domain] = true
+		normalized = append(normalized, domain)
+	}
+
+	return normalized, nil
+}
+
+// emailDomain devuelve el dominio de un email en minúsculas
+func emailDomain(email string) string {
+	at := strings.LastIndex(email, "@")
+	if at < 0 {
+		return ""
+	}
+	return strings.ToLower(email[at+1:])
+}
+
+// schoolImportRow fila válida del CSV
+type schoolImportRow struct {
+	line int
+	req  models.CreateSchoolRequest
+}
+
+// parseSchoolsCSV lee el CSV de importación. El encabezado es obligatorio y
+// define el orden de las columnas; location y email_domains son opcionales.
+func parseSchoolsCSV(r io.Reader) ([]schoolImportRow, []models.SchoolImportError, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, nil, errors.New("empty CSV file")
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid CSV: %w", err)
+	}
+
+	// Excel agrega un BOM al inicio del archivo
+	columns := map[string]int{}
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))] = i
+	}
+	if _, ok := columns["name"]; !ok {
+		return nil, nil, errors.New("CSV header must include a name column")
+	}
+
+	field := func(record []string, column string) string {
+		i, ok := columns[column]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	rows := []schoolImportRow{}
+	errs := []models.SchoolImportError{}
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			errs = append(errs, models.SchoolImportError{Line: line, Error: err.Error()})
+			continue
+		}
+		if len(rows)+len(errs) >= maxSchoolImportRows {
+			return nil, nil, fmt.Errorf("CSV exceeds %d rows", maxSchoolImportRows)
+		}
+
+		req := models.CreateSchoolRequest{
+			Name:     field(record, "name"),
+			Location: field(record, "location"),
+		}
+		if domains := field(record, "email_domains"); domains != "" {
+			req.EmailDomains = strings.Split(domains, ";")
+		}
+
+		if len(req.Name) < 3 || len(req.Name) > 255 {
+			errs = append(errs, models.SchoolImportError{Line: line, Name: req.Name, Error: "name must be between 3 and 255 characters"})
+			continue
+		}
+
+		rows = append(rows, schoolImportRow{line: line, req: req})
+	}
+
+	return rows, errs, nil
+}
@@ -0,0 +1,89 @@
+package services
+
+import (
+	"database/sql"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/smartstocks/backend/internal/models"
+)
+
+func TestNormalizeEmailDomains(t *testing.T) {
+	domains, err := normalizeEmailDomains([]string{" @Colegio.edu.ar", "colegio.edu.ar", "", "alumnos.colegio.edu.ar"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(domains, ",") != "colegio.edu.ar,alumnos.colegio.edu.ar" {
+		t.Errorf("domains = %v", domains)
+	}
+
+	for _, invalid := range []string{"gmail.com", "localhost", "a b.com", "user@colegio.edu.ar", ".edu.ar"} {
+		if _, err := normalizeEmailDomains([]string{invalid}); err == nil {
+			t.Errorf("%q should be rejected", invalid)
+		}
+	}
+}
+
+func TestEmailDomain(t *testing.T) {
+	if got := emailDomain("Ana@Colegio.EDU.ar"); got != "colegio.edu.ar" {
+		t.Errorf("emailDomain = %q", got)
+	}
+	if got := emailDomain("invalid"); got != "" {
+		t.Errorf("emailDomain without @ = %q", got)
+	}
+}
+
+func TestParseSchoolsCSV(t *testing.T) {
+	input := "\ufeffName,Location,Email_Domains\n" +
+		"Colegio Nacional,CABA,cnba.edu.ar;alumnos.cnba.edu.ar\n" +
+		"X,Rosario,\n" +
+		"Escuela Técnica 1,Córdoba\n"
+
+	rows, errs, err := parseSchoolsCSV(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(rows) != 2 {
+		t.Fatalf("len(rows) = %d, want 2", len(rows))
+	}
+	if rows[0].line != 2 || rows[0].req.Name != "Colegio Nacional" || len(rows[0].req.EmailDomains) != 2 {
+		t.Errorf("unexpected first row: %+v", rows[0])
+	}
+	if rows[1].line != 4 || rows[1].req.Location != "Córdoba" || rows[1].req.EmailDomains != nil {
+		t.Errorf("unexpected second row: %+v", rows[1])
+	}
+
+	if len(errs) != 1 || errs[0].Line != 3 {
+		t.Errorf("errs = %+v, want one error on line 3", errs)
+	}
+
+	if _, _, err := parseSchoolsCSV(strings.NewReader("location\nCABA\n")); err == nil {
+		t.Error("missing name column should fail")
+	}
+}
+
+func TestRejectionCooldownRemaining(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	reviewed := func(ago time.Duration) sql.NullTime {
+		return sql.NullTime{Time: now.Add(-ago), Valid: true}
+	}
+
+	tests := []struct {
+		name       string
+		membership *models.SchoolMembership
+		want       time.Duration
+	}{
+		{"no membership", nil, 0},
+		{"pending", &models.SchoolMembership{Status: models.MembershipStatusPending}, 0},
+		{"approved", &models.SchoolMembership{Status: models.MembershipStatusApproved, ReviewedAt: reviewed(time.Hour)}, 0},
+		{"just rejected", &models.SchoolMembership{Status: models.MembershipStatusRejected, ReviewedAt: reviewed(time.Hour)}, membershipRejectionCooldown - time.Hour},
+		{"rejection expired", &models.SchoolMembership{Status: models.MembershipStatusRejected, ReviewedAt: reviewed(membershipRejectionCooldown + time.Hour)}, 0},
+	}
+	for _, tt := range tests {
+		if got := rejectionCooldownRemaining(tt.membership, now); got != tt.want {
+			t.Errorf("%s: rejectionCooldownRemaining() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
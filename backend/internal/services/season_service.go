@@ -0,0 +1,297 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/smartstocks/backend/internal/models"
+	"github.com/smartstocks/backend/internal/repository"
+)
+
+const (
+	defaultSeasonCarryover = 50
+	minSeasonDuration      = 24 * time.Hour
+)
+
+type SeasonService struct {
+	seasonRepo   *repository.SeasonRepository
+	leaderboards *LeaderboardService
+}
+
+func NewSeasonService(seasonRepo *repository.SeasonRepository, leaderboards *LeaderboardService) *SeasonService {
+	return &SeasonService{
+		seasonRepo:   seasonRepo,
+		leaderboards: leaderboards,
+	}
+}
+
+// === ADMIN ===
+
+// CreateSeason programa una temporada. Si ya empezó y no hay otra activa
+// se activa en el momento.
+func (s *SeasonService) CreateSeason(adminID string, req *models.CreateSeasonRequest) (*models.Season, error) {
+	if err := validateSeason(req, time.Now()); err != nil {
+		return nil, err
+	}
+
+	overlaps, err := s.seasonRepo.HasOverlappingSeason(req.StartsAt, req.EndsAt)
+	if err != nil {
+		return nil, err
+	}
+	if overlaps {
+		return nil, errors.New("season dates overlap with another season")
+	}
+
+	carryover := defaultSeasonCarryover
+	if req.CarryoverPercent != nil {
+		carryover = *req.CarryoverPercent
+	}
+
+	season := &models.Season{
+		Name:             strings.TrimSpace(req.Name),
+		StartsAt:         req.StartsAt,
+		EndsAt:           req.EndsAt,
+		CarryoverPercent: carryover,
+		CreatedBy:        sql.NullString{String: adminID, Valid: true},
+		Rewards:          req.Rewards,
+	}
+	if season.Rewards == nil {
+		season.Rewards = []models.SeasonReward{}
+	}
+
+	if err := s.seasonRepo.CreateSeason(season); err != nil {
+		return nil, fmt.Errorf("error creating season: %w", err)
+	}
+
+	if !season.StartsAt.After(time.Now()) {
+		if err := s.seasonRepo.ProcessRollover(); err != nil {
+			fmt.Printf("⚠️ Error activating season %s: %v\n", season.ID, err)
+		}
+		if updated, err := s.seasonRepo.GetSeasonByID(season.ID); err == nil && updated != nil {
+			season = updated
+		}
+	}
+
+	return season, nil
+}
+
+// DeleteSeason elimina una temporada que todavía no empezó
+func (s *SeasonService) DeleteSeason(seasonID string) error {
+	season, err := s.getSeason(seasonID)
+	if err != nil {
+		return err
+	}
+	if season.Status != models.SeasonStatusScheduled {
+		return errors.New("only scheduled seasons can be deleted")
+	}
+
+	deleted, err := s.seasonRepo.DeleteScheduledSeason(seasonID)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return errors.New("only scheduled seasons can be deleted")
+	}
+	return nil
+}
+
+// ProcessRollover fuerza el cierre de la temporada vencida y la apertura de
+// la siguiente (el EVENT de MySQL lo hace cada 5 minutos)
+func (s *SeasonService) ProcessRollover() error {
+	return s.seasonRepo.ProcessRollover()
+}
+
+// === JUGADORES ===
+
+// GetSeasons lista las temporadas
+func (s *SeasonService) GetSeasons() ([]models.Season, error) {
+	return s.seasonRepo.GetSeasons()
+}
+
+// GetCurrentLeaderboard ranking en vivo de la temporada en curso
+func (s *SeasonService) GetCurrentLeaderboard(userID string, limit, offset int) (*models.SeasonLeaderboardResponse, error) {
+	season, err := s.seasonRepo.GetActiveSeason()
+	if err != nil {
+		return nil, err
+	}
+	if season == nil {
+		return nil, errors.New("no active season")
+	}
+
+	return s.leaderboard(season, userID, limit, offset)
+}
+
+// GetSeasonLeaderboard ranking de una temporada: en vivo si está en curso,
+// las posiciones finales archivadas si ya terminó
+func (s *SeasonService) GetSeasonLeaderboard(seasonID, userID string, limit, offset int) (*models.SeasonLeaderboardResponse, error) {
+	season, err := s.getSeason(seasonID)
+	if err != nil {
+		return nil, err
+	}
+	if season.Status == models.SeasonStatusScheduled {
+		return nil, errors.New("season has not started yet")
+	}
+
+	return s.leaderboard(season, userID, limit, offset)
+}
+
+// GetMySeasonHistory posiciones y tiers finales del usuario en las temporadas pasadas
+func (s *SeasonService) GetMySeasonHistory(userID string) ([]models.SeasonHistoryEntry, error) {
+	return s.seasonRepo.GetUserSeasonHistory(userID)
+}
+
+// === HELPERS ===
+
+func (s *SeasonService) getSeason(seasonID string) (*models.Season, error) {
+	season, err := s.seasonRepo.GetSeasonByID(seasonID)
+	if err != nil {
+		return nil, err
+	}
+	if season == nil {
+		return nil, errors.New("season not found")
+	}
+	return season, nil
+}
+
+func (s *SeasonService) leaderboard(season *models.Season, userID string, limit, offset int) (*models.SeasonLeaderboardResponse, error) {
+	if limit <= 0 || limit > 1000 {
+		limit = 100
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	live := season.Status == models.SeasonStatusActive
+
+	var standings []models.SeasonStanding
+	var userPosition *models.SeasonStanding
+	var total int
+	var err error
+	if live {
+		standings, userPosition, total, err = s.liveStandings(season.ID, userID, limit, offset)
+		if err != nil {
+			if !isLeaderboardUnavailable(err) {
+				fmt.Printf("⚠️ Error reading live season leaderboard, using MySQL: %v\n", err)
+			}
+			standings, err = s.seasonRepo.GetLiveStandings(limit, offset)
+			if err == nil {
+				userPosition, err = s.seasonRepo.GetLiveStanding(userID)
+			}
+			if err == nil {
+				total, err = s.seasonRepo.CountLivePlayers()
+			}
+		}
+	} else {
+		standings, err = s.seasonRepo.GetArchivedStandings(season.ID, limit, offset)
+		if err == nil {
+			userPosition, err = s.seasonRepo.GetArchivedStanding(season.ID, userID)
+		}
+		if err == nil {
+			total, err = s.seasonRepo.CountArchivedPlayers(season.ID)
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error getting season standings: %w", err)
+	}
+
+	for i := range standings {
+		if standings[i].UserID == userID {
+			standings[i].IsCurrentUser = true
+		}
+	}
+	if userPosition != nil {
+		userPosition.IsCurrentUser = true
+	}
+
+	return &models.SeasonLeaderboardResponse{
+		Season:       season,
+		Live:         live,
+		Standings:    standings,
+		UserPosition: userPosition,
+		TotalPlayers: total,
+	}, nil
+}
+
+// liveStandings posiciones de la temporada en curso desde su sorted set en Redis
+func (s *SeasonService) liveStandings(seasonID, userID string, limit, offset int) ([]models.SeasonStanding, *models.SeasonStanding, int, error) {
+	if s.leaderboards == nil {
+		return nil, nil, 0, ErrLeaderboardUnavailable
+	}
+
+	userIDs, total, err := s.leaderboards.SeasonPage(seasonID, limit, offset)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	userPos, err := s.leaderboards.SeasonPosition(seasonID, userID)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+
+	lookup := userIDs
+	if userPos > 0 {
+		lookup = append(append([]string{}, userIDs...), userID)
+	}
+	details, err := s.seasonRepo.GetLiveStandingsByUserIDs(lookup)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+
+	standings := make([]models.SeasonStanding, 0, len(userIDs))
+	for i, id := range userIDs {
+		standing, ok := details[id]
+		if !ok {
+			// Cuenta eliminada después de la última reconstrucción
+			s.leaderboards.SyncUser(id)
+			continue
+		}
+		standing.RankPosition = offset + i + 1
+		standings = append(standings, standing)
+	}
+
+	var userPosition *models.SeasonStanding
+	if standing, ok := details[userID]; ok && userPos > 0 {
+		standing.RankPosition = userPos
+		userPosition = &standing
+	}
+
+	return standings, userPosition, total, nil
+}
+
+// validateSeason verifica fechas y que los rangos de premios no se superpongan
+func validateSeason(req *models.CreateSeasonRequest, now time.Time) error {
+	if strings.TrimSpace(req.Name) == "" {
+		return errors.New("season name is required")
+	}
+	if !req.EndsAt.After(req.StartsAt) {
+		return errors.New("season must end after it starts")
+	}
+	if req.EndsAt.Sub(req.StartsAt) < minSeasonDuration {
+		return errors.New("season must last at least one day")
+	}
+	if !req.EndsAt.After(now) {
+		return errors.New("season end date is in the past")
+	}
+
+	rewards := make([]models.SeasonReward, len(req.Rewards))
+	copy(rewards, req.Rewards)
+	sort.Slice(rewards, func(i, j int) bool { return rewards[i].PositionFrom < rewards[j].PositionFrom })
+
+	for i, reward := range rewards {
+		if reward.PositionFrom < 1 || reward.PositionTo < reward.PositionFrom {
+			return fmt.Errorf("invalid reward positions %d-%d", reward.PositionFrom, reward.PositionTo)
+		}
+		if reward.TokenReward <= 0 {
+			return errors.New("reward tokens must be positive")
+		}
+		if i > 0 && reward.PositionFrom <= rewards[i-1].PositionTo {
+			return fmt.Errorf("reward positions %d-%d overlap with %d-%d",
+				reward.PositionFrom, reward.PositionTo, rewards[i-1].PositionFrom, rewards[i-1].PositionTo)
+		}
+	}
+
+	return nil
+}
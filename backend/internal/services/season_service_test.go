@@ -0,0 +1,66 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/smartstocks/backend/internal/models"
+)
+
+func TestValidateSeason(t *testing.T) {
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	start := now.AddDate(0, 0, 1)
+	end := start.AddDate(0, 3, 0)
+
+	valid := &models.CreateSeasonRequest{
+		Name:     "Temporada 1",
+		StartsAt: start,
+		EndsAt:   end,
+		Rewards: []models.SeasonReward{
+			{PositionFrom: 4, PositionTo: 10, TokenReward: 50},
+			{PositionFrom: 1, PositionTo: 1, TokenReward: 500},
+			{PositionFrom: 2, PositionTo: 3, TokenReward: 200},
+		},
+	}
+	if err := validateSeason(valid, now); err != nil {
+		t.Errorf("valid season: %v", err)
+	}
+
+	// Una temporada que ya empezó se puede crear mientras no haya terminado
+	started := *valid
+	started.StartsAt = now.AddDate(0, 0, -7)
+	if err := validateSeason(&started, now); err != nil {
+		t.Errorf("started season: %v", err)
+	}
+
+	tests := map[string]func(r *models.CreateSeasonRequest){
+		"blank name":         func(r *models.CreateSeasonRequest) { r.Name = "   " },
+		"ends before start":  func(r *models.CreateSeasonRequest) { r.EndsAt = r.StartsAt.Add(-time.Hour) },
+		"shorter than a day": func(r *models.CreateSeasonRequest) { r.EndsAt = r.StartsAt.Add(time.Hour) },
+		"already ended": func(r *models.CreateSeasonRequest) {
+			r.StartsAt, r.EndsAt = now.AddDate(0, -2, 0), now.AddDate(0, -1, 0)
+		},
+		"inverted positions": func(r *models.CreateSeasonRequest) {
+			r.Rewards = []models.SeasonReward{{PositionFrom: 5, PositionTo: 2, TokenReward: 10}}
+		},
+		"overlapping rewards": func(r *models.CreateSeasonRequest) {
+			r.Rewards = append(r.Rewards, models.SeasonReward{PositionFrom: 3, PositionTo: 5, TokenReward: 10})
+		},
+		"zero tokens": func(r *models.CreateSeasonRequest) {
+			r.Rewards = []models.SeasonReward{{PositionFrom: 1, PositionTo: 1}}
+		},
+	}
+	for name, mutate := range tests {
+		req := *valid
+		req.Rewards = append([]models.SeasonReward(nil), valid.Rewards...)
+		mutate(&req)
+		if err := validateSeason(&req, now); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+
+	// La validación no reordena los premios del request
+	if valid.Rewards[0].PositionFrom != 4 {
+		t.Error("validateSeason should not modify the request")
+	}
+}
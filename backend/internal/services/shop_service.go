@@ -0,0 +1,272 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/smartstocks/backend/internal/models"
+	"github.com/smartstocks/backend/internal/repository"
+)
+
+type ShopService struct {
+	shopRepo      *repository.ShopRepository
+	tokensRepo    *repository.TokensRepository
+	tokensService *TokensService
+}
+
+func NewShopService(
+	shopRepo *repository.ShopRepository,
+	tokensRepo *repository.TokensRepository,
+	tokensService *TokensService,
+) *ShopService {
+	return &ShopService{
+		shopRepo:      shopRepo,
+		tokensRepo:    tokensRepo,
+		tokensService: tokensService,
+	}
+}
+
+// GetCatalog obtiene el catálogo marcando qué se puede comprar ahora y qué
+// ya tiene el usuario
+func (s *ShopService) GetCatalog(userID string) (*models.ShopCatalogResponse, error) {
+	items, err := s.shopRepo.GetCatalog()
+	if err != nil {
+		return nil, fmt.Errorf("error getting catalog: %w", err)
+	}
+
+	owned, err := s.shopRepo.GetOwnedItemIDs(userID)
+	if err != nil {
+		return nil, fmt.Errorf("error getting inventory: %w", err)
+	}
+
+	now := time.Now()
+	for i := range items {
+		items[i].Available = shopItemAvailable(&items[i], now)
+		items[i].Owned = owned[items[i].ID]
+	}
+
+	response := &models.ShopCatalogResponse{Items: items}
+	if tokens, err := s.tokensRepo.GetUserTokens(userID); err == nil {
+		response.Balance = tokens.Balance
+	}
+
+	return response, nil
+}
+
+// Purchase compra un cosmético. El cobro y el alta en el inventario van en la
+// misma transacción: si el usuario ya lo tenía no se cobra. idempotencyKey es
+// la clave enviada por el cliente (opcional).
+func (s *ShopService) Purchase(userID, itemID, idempotencyKey string) (*models.ShopPurchaseResponse, error) {
+	item, err := s.shopRepo.GetItemByID(itemID)
+	if err != nil {
+		return nil, fmt.Errorf("error getting item: %w", err)
+	}
+	if item == nil {
+		return nil, errors.New("item not found")
+	}
+	if !shopItemAvailable(item, time.Now()) {
+		return nil, errors.New("item not available")
+	}
+
+	owned, err := s.shopRepo.GetInventoryItem(userID, itemID)
+	if err != nil {
+		return nil, fmt.Errorf("error getting inventory item: %w", err)
+	}
+	if owned != nil {
+		// Un reintento con la misma clave devuelve la compra original
+		if s.purchasedWithKey(userID, item.ID, idempotencyKey) {
+			return &models.ShopPurchaseResponse{Item: *owned, TokensSpent: owned.PricePaid}, nil
+		}
+		return nil, errors.New("item already owned")
+	}
+
+	inventoryItem := &models.InventoryItem{
+		ID:         uuid.New().String(),
+		UserID:     userID,
+		ItemID:     item.ID,
+		ItemType:   item.ItemType,
+		Code:       item.Code,
+		Name:       item.Name,
+		AssetURL:   item.AssetURL,
+		StyleValue: item.StyleValue,
+		PricePaid:  item.Price,
+	}
+
+	// La verificación anterior no cubre dos compras simultáneas: el índice
+	// único del inventario sí, y en ese caso se revierte el cobro
+	alreadyOwned := errors.New("item already owned")
+	description := fmt.Sprintf("Tienda: %s", item.Name)
+	key := tokenIdempotencyKey("shop_purchase", userID, idempotencyKey, inventoryItem.ID)
+	err = s.tokensService.SpendTokensWith(userID, item.Price, "purchase", description, &item.ID, key, func(tx *sql.Tx) error {
+		added, err := s.shopRepo.AddInventoryItemTx(tx, inventoryItem)
+		if err != nil {
+			return err
+		}
+		if !added {
+			return alreadyOwned
+		}
+		return nil
+	})
+	if errors.Is(err, repository.ErrDuplicateTransaction) {
+		// Otro pedido con la misma clave se aplicó mientras tanto
+		owned, lookupErr := s.shopRepo.GetInventoryItem(userID, itemID)
+		if lookupErr == nil && owned != nil {
+			return &models.ShopPurchaseResponse{Item: *owned, TokensSpent: owned.PricePaid}, nil
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	inventoryItem.PurchasedAt = time.Now()
+	return &models.ShopPurchaseResponse{
+		Item:        *inventoryItem,
+		TokensSpent: item.Price,
+	}, nil
+}
+
+// purchasedWithKey verifica si el cobro de la clave enviada por el cliente
+// corresponde a la compra del item
+func (s *ShopService) purchasedWithKey(userID, itemID, idempotencyKey string) bool {
+	if idempotencyKey == "" {
+		return false
+	}
+	transaction, err := s.tokensService.GetTransactionByKey(tokenIdempotencyKey("shop_purchase", userID, idempotencyKey, ""))
+	if err != nil || transaction == nil {
+		return false
+	}
+	return transaction.ReferenceID != nil && *transaction.ReferenceID == itemID
+}
+
+// GetInventory obtiene los cosméticos del usuario
+func (s *ShopService) GetInventory(userID string) ([]models.InventoryItem, error) {
+	return s.shopRepo.GetInventory(userID)
+}
+
+// Equip equipa un cosmético del inventario (reemplaza al del mismo tipo)
+func (s *ShopService) Equip(userID, itemID string) (*models.InventoryItem, error) {
+	return s.setEquipped(userID, itemID, true)
+}
+
+// Unequip desequipa un cosmético del inventario
+func (s *ShopService) Unequip(userID, itemID string) (*models.InventoryItem, error) {
+	return s.setEquipped(userID, itemID, false)
+}
+
+func (s *ShopService) setEquipped(userID, itemID string, equipped bool) (*models.InventoryItem, error) {
+	owned, err := s.shopRepo.SetEquipped(userID, itemID, equipped)
+	if err != nil {
+		return nil, fmt.Errorf("error updating inventory: %w", err)
+	}
+	if !owned {
+		return nil, errors.New("item not owned")
+	}
+
+	item, err := s.shopRepo.GetInventoryItem(userID, itemID)
+	if err != nil || item == nil {
+		return nil, fmt.Errorf("error getting inventory item: %w", err)
+	}
+	return item, nil
+}
+
+// EquippedCosmetics obtiene los cosméticos equipados de varios usuarios
+func (s *ShopService) EquippedCosmetics(userIDs []string) (map[string]*models.EquippedCosmetics, error) {
+	return s.shopRepo.GetEquippedCosmetics(userIDs)
+}
+
+// === ADMIN ===
+
+// CreateItem da de alta un cosmético en el catálogo
+func (s *ShopService) CreateItem(req *models.CreateShopItemRequest) (*models.ShopItem, error) {
+	code := strings.ToLower(strings.TrimSpace(req.Code))
+	if err := validateShopWindow(req.AvailableFrom, req.AvailableUntil); err != nil {
+		return nil, err
+	}
+
+	exists, err := s.shopRepo.CodeExists(code)
+	if err != nil {
+		return nil, fmt.Errorf("error checking item code: %w", err)
+	}
+	if exists {
+		return nil, errors.New("item code already exists")
+	}
+
+	item := &models.ShopItem{
+		ID:             uuid.New().String(),
+		ItemType:       req.ItemType,
+		Code:           code,
+		Name:           strings.TrimSpace(req.Name),
+		Description:    req.Description,
+		Price:          req.Price,
+		AssetURL:       req.AssetURL,
+		StyleValue:     req.StyleValue,
+		AvailableFrom:  req.AvailableFrom,
+		AvailableUntil: req.AvailableUntil,
+		IsActive:       true,
+		SortOrder:      req.SortOrder,
+	}
+	if err := s.shopRepo.CreateItem(item); err != nil {
+		return nil, fmt.Errorf("error creating item: %w", err)
+	}
+
+	return s.shopRepo.GetItemByID(item.ID)
+}
+
+// UpdateItem actualiza un cosmético del catálogo. Desactivarlo no lo quita
+// de los inventarios.
+func (s *ShopService) UpdateItem(itemID string, req *models.UpdateShopItemRequest) (*models.ShopItem, error) {
+	item, err := s.shopRepo.GetItemByID(itemID)
+	if err != nil {
+		return nil, fmt.Errorf("error getting item: %w", err)
+	}
+	if item == nil {
+		return nil, errors.New("item not found")
+	}
+	if err := validateShopWindow(req.AvailableFrom, req.AvailableUntil); err != nil {
+		return nil, err
+	}
+
+	item.Name = strings.TrimSpace(req.Name)
+	item.Description = req.Description
+	item.Price = req.Price
+	item.AssetURL = req.AssetURL
+	item.StyleValue = req.StyleValue
+	item.AvailableFrom = req.AvailableFrom
+	item.AvailableUntil = req.AvailableUntil
+	item.SortOrder = req.SortOrder
+	if req.IsActive != nil {
+		item.IsActive = *req.IsActive
+	}
+
+	if err := s.shopRepo.UpdateItem(item); err != nil {
+		return nil, fmt.Errorf("error updating item: %w", err)
+	}
+
+	return s.shopRepo.GetItemByID(item.ID)
+}
+
+// shopItemAvailable indica si el ítem se puede comprar en now: activo y
+// dentro de su ventana de venta [available_from, available_until)
+func shopItemAvailable(item *models.ShopItem, now time.Time) bool {
+	if !item.IsActive {
+		return false
+	}
+	if item.AvailableFrom != nil && now.Before(*item.AvailableFrom) {
+		return false
+	}
+	if item.AvailableUntil != nil && !now.Before(*item.AvailableUntil) {
+		return false
+	}
+	return true
+}
+
+func validateShopWindow(from, until *time.Time) error {
+	if from != nil && until != nil && !until.After(*from) {
+		return errors.New("available_until must be after available_from")
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/smartstocks/backend/internal/models"
+)
+
+func TestShopItemAvailable(t *testing.T) {
+	now := time.Date(2026, 5, 10, 12, 0, 0, 0, time.UTC)
+	past := now.Add(-24 * time.Hour)
+	future := now.Add(24 * time.Hour)
+
+	tests := []struct {
+		name string
+		item models.ShopItem
+		want bool
+	}{
+		{"no window", models.ShopItem{IsActive: true}, true},
+		{"inactive", models.ShopItem{IsActive: false}, false},
+		{"inside window", models.ShopItem{IsActive: true, AvailableFrom: &past, AvailableUntil: &future}, true},
+		{"not started", models.ShopItem{IsActive: true, AvailableFrom: &future}, false},
+		{"ended", models.ShopItem{IsActive: true, AvailableUntil: &past}, false},
+		{"ends now", models.ShopItem{IsActive: true, AvailableUntil: &now}, false},
+		{"starts now", models.ShopItem{IsActive: true, AvailableFrom: &now}, true},
+	}
+	for _, tt := range tests {
+		if got := shopItemAvailable(&tt.item, now); got != tt.want {
+			t.Errorf("%s: shopItemAvailable() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestValidateShopWindow(t *testing.T) {
+	from := time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC)
+	until := from.AddDate(0, 1, 0)
+
+	if err := validateShopWindow(&from, &until); err != nil {
+		t.Errorf("valid window: unexpected error %v", err)
+	}
+	if err := validateShopWindow(&from, nil); err != nil {
+		t.Errorf("open window: unexpected error %v", err)
+	}
+	if err := validateShopWindow(&until, &from); err == nil {
+		t.Error("inverted window: expected error")
+	}
+	if err := validateShopWindow(&from, &from); err == nil {
+		t.Error("empty window: expected error")
+	}
+}
@@ -11,20 +11,32 @@ import (
 )
 
 type SimulatorService struct {
-	simulatorRepo *repository.SimulatorRepository
-	userRepo      *repository.UserRepository
-	aiService     *SimulatorAIService
+	simulatorRepo     *repository.SimulatorRepository
+	userRepo          *repository.UserRepository
+	aiService         *SimulatorAIService
+	assignmentService *AssignmentService
+	leaderboards      *LeaderboardService
+	achievements      *AchievementService
+	consumables       *ConsumableService
 }
 
 func NewSimulatorService(
 	simulatorRepo *repository.SimulatorRepository,
 	userRepo *repository.UserRepository,
 	aiService *SimulatorAIService,
+	assignmentService *AssignmentService,
+	leaderboards *LeaderboardService,
+	achievements *AchievementService,
+	consumables *ConsumableService,
 ) *SimulatorService {
 	return &SimulatorService{
-		simulatorRepo: simulatorRepo,
-		userRepo:      userRepo,
-		aiService:     aiService,
+		simulatorRepo:     simulatorRepo,
+		userRepo:          userRepo,
+		aiService:         aiService,
+		assignmentService: assignmentService,
+		leaderboards:      leaderboards,
+		achievements:      achievements,
+		consumables:       consumables,
 	}
 }
 
@@ -36,10 +48,88 @@ func (s *SimulatorService) GetScenario(userID string, difficulty models.Simulato
 		return nil, fmt.Errorf("error checking cooldown: %w", err)
 	}
 
-	if !canAttempt {
+	scenario, err := s.activeScenario(difficulty)
+	if err != nil {
+		return nil, err
+	}
+
+	// Con el intento del día usado, solo se muestra si compró un intento extra
+	if !canAttempt && !s.consumables.HasUnused(userID, models.ConsumableSimulatorExtraAttempt, scenario.ID) {
 		return nil, errors.New("you have already attempted this difficulty today. Try again tomorrow")
 	}
 
+	return scenarioResponse(scenario), nil
+}
+
+// BuyHint compra una pista de análisis técnico para el escenario. Se cobra
+// una sola vez: si ya la compró se devuelve la misma pista sin cobrar.
+func (s *SimulatorService) BuyHint(userID, scenarioID, idempotencyKey string) (*models.SimulatorHint, error) {
+	scenario, err := s.simulatorRepo.GetScenarioByID(scenarioID)
+	if err != nil {
+		return nil, errors.New("scenario not found")
+	}
+
+	indicator, clue := technicalHint(scenario.ChartData.Prices, scenario.ID)
+	hint := &models.SimulatorHint{
+		ScenarioID: scenario.ID,
+		Indicator:  indicator,
+		Clue:       clue,
+	}
+
+	previous, err := s.consumables.GetPurchase(userID, models.ConsumableSimulatorHint, scenario.ID)
+	if err != nil {
+		return nil, fmt.Errorf("error getting hint purchase: %w", err)
+	}
+	if previous != nil {
+		return hint, nil
+	}
+
+	assigned := s.assignmentService.HasPendingAssignment(userID, models.AssignmentTypeScenario, scenario.ID)
+	if !assigned && (!scenario.IsActive || scenario.ExpiresAt.Before(time.Now())) {
+		return nil, errors.New("scenario is no longer active or has expired")
+	}
+
+	description := fmt.Sprintf("Pista del simulador: %s", scenario.ChartData.Ticker)
+	purchase, err := s.consumables.Purchase(userID, models.ConsumableSimulatorHint, scenario.ID, description, idempotencyKey, true, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	hint.TokensSpent = purchase.Price
+	return hint, nil
+}
+
+// BuyExtraAttempt compra un intento extra para el escenario activo de la
+// dificultad. Solo se puede comprar con el intento del día ya usado.
+func (s *SimulatorService) BuyExtraAttempt(userID string, difficulty models.SimulatorDifficulty, idempotencyKey string) (*models.SimulatorExtraAttemptResponse, error) {
+	canAttempt, err := s.simulatorRepo.CheckCooldown(userID, difficulty)
+	if err != nil {
+		return nil, fmt.Errorf("error checking cooldown: %w", err)
+	}
+	if canAttempt {
+		return nil, errors.New("daily attempt still available")
+	}
+
+	scenario, err := s.activeScenario(difficulty)
+	if err != nil {
+		return nil, err
+	}
+
+	description := fmt.Sprintf("Intento extra del simulador (%s)", difficulty)
+	purchase, err := s.consumables.Purchase(userID, models.ConsumableSimulatorExtraAttempt, scenario.ID, description, idempotencyKey, false, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.SimulatorExtraAttemptResponse{
+		Purchase:      *purchase,
+		Scenario:      *scenarioResponse(scenario),
+		PointsPercent: s.consumables.Prices().ExtraAttemptPointsPercent,
+	}, nil
+}
+
+// activeScenario busca el escenario activo de la dificultad o genera uno
+func (s *SimulatorService) activeScenario(difficulty models.SimulatorDifficulty) (*models.SimulatorScenario, error) {
 	// Buscar escenario activo existente
 	scenario, err := s.simulatorRepo.GetActiveScenarioByDifficulty(difficulty)
 	if err != nil {
@@ -54,8 +144,13 @@ func (s *SimulatorService) GetScenario(userID string, difficulty models.Simulato
 		}
 	}
 
-	// Preparar respuesta (sin revelar la respuesta correcta)
-	response := &models.SimulatorScenarioResponse{
+	return scenario, nil
+}
+
+// scenarioResponse prepara el escenario para el usuario (sin revelar la
+// respuesta correcta)
+func scenarioResponse(scenario *models.SimulatorScenario) *models.SimulatorScenarioResponse {
+	return &models.SimulatorScenarioResponse{
 		ScenarioID:  scenario.ID,
 		Difficulty:  scenario.Difficulty,
 		NewsContent: scenario.NewsContent,
@@ -68,8 +163,6 @@ func (s *SimulatorService) GetScenario(userID string, difficulty models.Simulato
 		},
 		ExpiresAt: scenario.ExpiresAt,
 	}
-
-	return response, nil
 }
 
 // SubmitDecision procesa la decisión del usuario
@@ -80,8 +173,13 @@ func (s *SimulatorService) SubmitDecision(userID string, req *models.SubmitSimul
 		return nil, fmt.Errorf("error getting scenario: %w", err)
 	}
 
+	// Un escenario asignado y sin entregar se puede resolver aunque haya expirado
+	// o ya se haya usado el intento del día
+	assigned := s.assignmentService.HasPendingAssignment(userID, models.AssignmentTypeScenario, req.ScenarioID)
+
 	// Verificar que el escenario esté activo y no expirado
-	if !scenario.IsActive || scenario.ExpiresAt.Before(time.Now()) {
+	expired := !scenario.IsActive || scenario.ExpiresAt.Before(time.Now())
+	if !assigned && expired {
 		return nil, errors.New("scenario is no longer active or has expired")
 	}
 
@@ -90,26 +188,45 @@ func (s *SimulatorService) SubmitDecision(userID string, req *models.SubmitSimul
 	if err != nil {
 		return nil, fmt.Errorf("error checking cooldown: %w", err)
 	}
-	if !canAttempt {
-		return nil, errors.New("you have already attempted this difficulty today")
+
+	// Si la tarea es lo único que habilita la entrega, cuenta solo para la
+	// tarea y no suma puntos
+	assignedReplay := assigned && (expired || !canAttempt)
+
+	// Con el intento del día usado, se consume un intento extra comprado
+	extraAttemptID := ""
+	if !canAttempt && !assigned {
+		extraAttemptID, err = s.consumables.Consume(userID, models.ConsumableSimulatorExtraAttempt, req.ScenarioID)
+		if err != nil {
+			return nil, fmt.Errorf("error using extra attempt: %w", err)
+		}
+		if extraAttemptID == "" {
+			return nil, errors.New("you have already attempted this difficulty today")
+		}
 	}
 
 	// Evaluar decisión
 	wasCorrect := req.Decision == scenario.CorrectDecision
+	// Un intento extra repite el escenario con el resultado ya revelado: suma
+	// un porcentaje de los puntos y no cuenta para precisión, logros ni tareas
 	pointsEarned := 0
-	if wasCorrect {
+	if wasCorrect && !assignedReplay {
 		pointsEarned = scenario.Difficulty.GetPoints()
+		if extraAttemptID != "" {
+			pointsEarned = s.consumables.ExtraAttemptPoints(pointsEarned)
+		}
 	}
 
 	// Registrar intento
 	attempt := &models.SimulatorAttempt{
-		UserID:       userID,
-		ScenarioID:   req.ScenarioID,
-		Difficulty:   scenario.Difficulty,
-		UserDecision: req.Decision,
-		WasCorrect:   wasCorrect,
-		PointsEarned: pointsEarned,
-		CreatedAt:    time.Now(),
+		UserID:         userID,
+		ScenarioID:     req.ScenarioID,
+		Difficulty:     scenario.Difficulty,
+		UserDecision:   req.Decision,
+		WasCorrect:     wasCorrect,
+		PointsEarned:   pointsEarned,
+		ExtraAttemptID: extraAttemptID,
+		CreatedAt:      time.Now(),
 	}
 
 	if req.TimeTakenSeconds != nil {
@@ -121,8 +238,18 @@ func (s *SimulatorService) SubmitDecision(userID string, req *models.SubmitSimul
 
 	// Guardar intento (esto también actualiza stats del usuario)
 	if err := s.simulatorRepo.RecordAttempt(attempt); err != nil {
+		if extraAttemptID != "" {
+			s.consumables.Release(extraAttemptID)
+		}
 		return nil, fmt.Errorf("error recording attempt: %w", err)
 	}
+	s.leaderboards.SyncUser(userID)
+	s.achievements.Publish(userID, models.AchievementEventSimulatorAttempt)
+
+	// Registrar la entrega si el escenario estaba asignado
+	if extraAttemptID == "" {
+		s.assignmentService.RecordSimulatorDecision(userID, req.ScenarioID, wasCorrect)
+	}
 
 	// Obtener stats actualizados del usuario
 	userStats, err := s.userRepo.GetUserStats(userID)
@@ -140,6 +267,7 @@ func (s *SimulatorService) SubmitDecision(userID string, req *models.SubmitSimul
 		FullChartData:   scenario.ChartData, // Ahora sí mostramos el gráfico completo
 		NewTotalPoints:  userStats.Smartpoints,
 		NewRankTier:     userStats.RankTier,
+		ExtraAttempt:    extraAttemptID != "",
 	}
 
 	return response, nil
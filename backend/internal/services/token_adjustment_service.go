@@ -0,0 +1,341 @@
+package services
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/smartstocks/backend/internal/models"
+	"github.com/smartstocks/backend/internal/repository"
+)
+
+// maxTokenAdjustmentRows límite de filas por CSV de ajustes
+const maxTokenAdjustmentRows = 1000
+
+// maxTokenAdjustmentAmount máximo de tokens por ajuste
+const maxTokenAdjustmentAmount = 100000
+
+// refundableTransactionTypes débitos que se pueden devolver. Las compras y
+// los regalos enviados no: el usuario conserva lo comprado y el destinatario
+// el regalo, y la devolución crearía tokens.
+var refundableTransactionTypes = map[string]bool{
+	"admin_deduct":     true,
+	"tournament_entry": true,
+}
+
+type TokenAdjustmentService struct {
+	adjustmentRepo *repository.TokenAdjustmentRepository
+	tokensRepo     *repository.TokensRepository
+	userRepo       *repository.UserRepository
+}
+
+func NewTokenAdjustmentService(
+	adjustmentRepo *repository.TokenAdjustmentRepository,
+	tokensRepo *repository.TokensRepository,
+	userRepo *repository.UserRepository,
+) *TokenAdjustmentService {
+	return &TokenAdjustmentService{
+		adjustmentRepo: adjustmentRepo,
+		tokensRepo:     tokensRepo,
+		userRepo:       userRepo,
+	}
+}
+
+// Adjust otorga o descuenta tokens a un usuario. idempotencyKey es la clave
+// enviada por el cliente (opcional) para que un reintento no se aplique dos veces.
+func (s *TokenAdjustmentService) Adjust(adminID string, req *models.TokenAdjustmentRequest, idempotencyKey string) (*models.TokenAdjustment, error) {
+	user, err := s.userRepo.GetUserByID(req.UserID)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+
+	adj := &models.TokenAdjustment{
+		ID:       uuid.New().String(),
+		AdminID:  &adminID,
+		UserID:   user.ID,
+		Username: user.Username,
+		Action:   req.Action,
+		Amount:   req.Amount,
+		Reason:   strings.TrimSpace(req.Reason),
+	}
+	key := tokenIdempotencyKey("admin_adjustment", user.ID, idempotencyKey, adj.ID)
+	return s.applyAdjustmentOnce(adj, key)
+}
+
+// Refund devuelve un gasto completo. El reintegro referencia a la
+// transacción original y cada transacción se puede devolver una sola vez.
+// Solo se devuelven los tipos de refundableTransactionTypes.
+func (s *TokenAdjustmentService) Refund(adminID string, req *models.TokenRefundRequest) (*models.TokenAdjustment, error) {
+	return s.refund(adminID, req, nil)
+}
+
+func (s *TokenAdjustmentService) refund(adminID string, req *models.TokenRefundRequest, batchID *string) (*models.TokenAdjustment, error) {
+	original, err := s.tokensRepo.GetTransaction(req.TransactionID)
+	if err != nil {
+		return nil, fmt.Errorf("error getting transaction: %w", err)
+	}
+	if original == nil {
+		return nil, errors.New("transaction not found")
+	}
+	if original.Amount >= 0 {
+		return nil, errors.New("only debits can be refunded")
+	}
+	if !refundableTransactionTypes[original.TransactionType] {
+		return nil, errors.New("transaction type cannot be refunded")
+	}
+
+	refunded, err := s.adjustmentRepo.IsRefunded(original.ID)
+	if err != nil {
+		return nil, fmt.Errorf("error checking refund: %w", err)
+	}
+	if refunded {
+		return nil, errors.New("transaction already refunded")
+	}
+
+	user, err := s.userRepo.GetUserByID(original.UserID)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+
+	adj := &models.TokenAdjustment{
+		ID:                    uuid.New().String(),
+		AdminID:               &adminID,
+		UserID:                user.ID,
+		Username:              user.Username,
+		Action:                models.TokenAdjustmentRefund,
+		Amount:                -original.Amount,
+		Reason:                strings.TrimSpace(req.Reason),
+		RefundedTransactionID: &original.ID,
+		BatchID:               batchID,
+	}
+
+	// La clave por transacción original es la que impide la doble devolución
+	// cuando dos pedidos llegan a la vez
+	err = s.applyAdjustment(adj, "refund:"+original.ID)
+	if errors.Is(err, repository.ErrDuplicateTransaction) {
+		return nil, errors.New("transaction already refunded")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return adj, nil
+}
+
+// ImportAdjustments aplica ajustes desde un CSV con encabezado user,action,
+// amount,reason (user es el id o el email). Las devoluciones usan
+// transaction_id en lugar de user y amount. Las filas con error se informan y
+// se saltean. batchKey (opcional) hace que reenviar el mismo archivo no
+// vuelva a aplicar las filas ya aplicadas.
+func (s *TokenAdjustmentService) ImportAdjustments(adminID string, r io.Reader, batchKey string) (*models.TokenAdjustmentImportResult, error) {
+	rows, errs, err := parseTokenAdjustmentsCSV(r)
+	if err != nil {
+		return nil, err
+	}
+
+	batchID := uuid.New().String()
+	if batchKey == "" {
+		batchKey = batchID
+	}
+
+	result := &models.TokenAdjustmentImportResult{
+		BatchID: batchID,
+		Applied: []models.TokenAdjustment{},
+		Errors:  errs,
+	}
+
+	for _, row := range rows {
+		adj, err := s.importRow(adminID, batchID, batchKey, row)
+		if err != nil {
+			result.Errors = append(result.Errors, models.TokenAdjustmentImportError{
+				Line:  row.line,
+				User:  row.user,
+				Error: err.Error(),
+			})
+			continue
+		}
+		result.Applied = append(result.Applied, *adj)
+	}
+
+	return result, nil
+}
+
+func (s *TokenAdjustmentService) importRow(adminID, batchID, batchKey string, row tokenAdjustmentRow) (*models.TokenAdjustment, error) {
+	if row.action == models.TokenAdjustmentRefund {
+		return s.refund(adminID, &models.TokenRefundRequest{TransactionID: row.transactionID, Reason: row.reason}, &batchID)
+	}
+
+	var user *models.User
+	var err error
+	if strings.Contains(row.user, "@") {
+		user, err = s.userRepo.GetUserByEmail(strings.ToLower(row.user))
+	} else {
+		user, err = s.userRepo.GetUserByID(row.user)
+	}
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+
+	adj := &models.TokenAdjustment{
+		ID:       uuid.New().String(),
+		AdminID:  &adminID,
+		UserID:   user.ID,
+		Username: user.Username,
+		Action:   row.action,
+		Amount:   row.amount,
+		Reason:   row.reason,
+		BatchID:  &batchID,
+	}
+	key := fmt.Sprintf("admin_batch:%s:%d", batchKey, row.line)
+	return s.applyAdjustmentOnce(adj, key)
+}
+
+// applyAdjustmentOnce aplica el ajuste; si la clave ya se usó (un reintento
+// o el mismo CSV reenviado) devuelve el ajuste original
+func (s *TokenAdjustmentService) applyAdjustmentOnce(adj *models.TokenAdjustment, idempotencyKey string) (*models.TokenAdjustment, error) {
+	err := s.applyAdjustment(adj, idempotencyKey)
+	if errors.Is(err, repository.ErrDuplicateTransaction) {
+		if previous, lookupErr := s.adjustmentRepo.GetAdjustmentByKey(idempotencyKey); lookupErr == nil && previous != nil {
+			return previous, nil
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return adj, nil
+}
+
+// applyAdjustment registra la transacción y la auditoría del ajuste
+func (s *TokenAdjustmentService) applyAdjustment(adj *models.TokenAdjustment, idempotencyKey string) error {
+	var transactionType, description string
+	referenceID := &adj.ID
+	switch adj.Action {
+	case models.TokenAdjustmentGrant:
+		transactionType = "admin_grant"
+		description = "Ajuste manual: " + adj.Reason
+	case models.TokenAdjustmentDeduct:
+		transactionType = "admin_deduct"
+		description = "Descuento manual: " + adj.Reason
+	case models.TokenAdjustmentRefund:
+		transactionType = "refund"
+		description = "Devolución: " + adj.Reason
+		referenceID = adj.RefundedTransactionID
+	default:
+		return fmt.Errorf("invalid action: %q", adj.Action)
+	}
+
+	return s.adjustmentRepo.ApplyAdjustment(adj, transactionType, description, referenceID, idempotencyKey)
+}
+
+// GetAdjustments obtiene la auditoría de ajustes (de un usuario o de todos)
+func (s *TokenAdjustmentService) GetAdjustments(userID string, limit int) ([]models.TokenAdjustment, error) {
+	return s.adjustmentRepo.GetAdjustments(userID, limit)
+}
+
+type tokenAdjustmentRow struct {
+	line          int
+	user          string
+	action        string
+	amount        int
+	reason        string
+	transactionID string
+}
+
+// parseTokenAdjustmentsCSV lee el CSV de ajustes. El encabezado es
+// obligatorio y define el orden de las columnas.
+func parseTokenAdjustmentsCSV(r io.Reader) ([]tokenAdjustmentRow, []models.TokenAdjustmentImportError, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, nil, errors.New("empty CSV file")
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid CSV: %w", err)
+	}
+
+	// Excel agrega un BOM al inicio del archivo
+	columns := map[string]int{}
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))] = i
+	}
+	for _, required := range []string{"action", "reason"} {
+		if _, ok := columns[required]; !ok {
+			return nil, nil, fmt.Errorf("CSV header must include a %s column", required)
+		}
+	}
+
+	field := func(record []string, column string) string {
+		i, ok := columns[column]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	rows := []tokenAdjustmentRow{}
+	errs := []models.TokenAdjustmentImportError{}
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			errs = append(errs, models.TokenAdjustmentImportError{Line: line, Error: err.Error()})
+			continue
+		}
+		if len(rows)+len(errs) >= maxTokenAdjustmentRows {
+			return nil, nil, fmt.Errorf("CSV exceeds %d rows", maxTokenAdjustmentRows)
+		}
+
+		row := tokenAdjustmentRow{
+			line:          line,
+			user:          field(record, "user"),
+			action:        strings.ToLower(field(record, "action")),
+			reason:        field(record, "reason"),
+			transactionID: field(record, "transaction_id"),
+		}
+		if msg := validateTokenAdjustmentRow(&row, field(record, "amount")); msg != "" {
+			errs = append(errs, models.TokenAdjustmentImportError{Line: line, User: row.user, Error: msg})
+			continue
+		}
+
+		rows = append(rows, row)
+	}
+
+	return rows, errs, nil
+}
+
+// validateTokenAdjustmentRow valida la fila y completa amount. Devuelve el
+// mensaje de error (vacío si es válida).
+func validateTokenAdjustmentRow(row *tokenAdjustmentRow, amount string) string {
+	if len(row.reason) < 5 || len(row.reason) > 500 {
+		return "reason must be between 5 and 500 characters"
+	}
+
+	switch row.action {
+	case models.TokenAdjustmentRefund:
+		if row.transactionID == "" {
+			return "transaction_id is required for refunds"
+		}
+		return ""
+	case models.TokenAdjustmentGrant, models.TokenAdjustmentDeduct:
+	default:
+		return "action must be grant, deduct or refund"
+	}
+
+	if row.user == "" {
+		return "user is required"
+	}
+	n, err := strconv.Atoi(amount)
+	if err != nil || n < 1 || n > maxTokenAdjustmentAmount {
+		return fmt.Sprintf("amount must be between 1 and %d", maxTokenAdjustmentAmount)
+	}
+	row.amount = n
+	return ""
+}
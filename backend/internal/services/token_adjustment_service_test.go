@@ -0,0 +1,71 @@
+package services
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseTokenAdjustmentsCSV(t *testing.T) {
+	input := "\ufeffUser,Action,Amount,Reason,Transaction_ID\n" +
+		"ana@colegio.edu.ar,Grant,500,Premio del torneo escolar,\n" +
+		"user-2,deduct,0,Corrección de saldo,\n" +
+		",refund,,Compra duplicada,tx-1\n" +
+		"user-3,bonus,10,Motivo válido,\n" +
+		"user-4,deduct,20,no\n"
+
+	rows, errs, err := parseTokenAdjustmentsCSV(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(rows) != 2 {
+		t.Fatalf("len(rows) = %d, want 2", len(rows))
+	}
+	if rows[0].line != 2 || rows[0].action != "grant" || rows[0].amount != 500 || rows[0].user != "ana@colegio.edu.ar" {
+		t.Errorf("unexpected first row: %+v", rows[0])
+	}
+	if rows[1].line != 4 || rows[1].action != "refund" || rows[1].transactionID != "tx-1" {
+		t.Errorf("unexpected refund row: %+v", rows[1])
+	}
+
+	lines := []int{}
+	for _, e := range errs {
+		lines = append(lines, e.Line)
+	}
+	if len(lines) != 3 || lines[0] != 3 || lines[1] != 5 || lines[2] != 6 {
+		t.Errorf("error lines = %v, want [3 5 6]", lines)
+	}
+
+	if _, _, err := parseTokenAdjustmentsCSV(strings.NewReader("user,amount,reason\nu,1,motivo\n")); err == nil {
+		t.Error("missing action column should fail")
+	}
+}
+
+func TestValidateTokenAdjustmentRow(t *testing.T) {
+	row := tokenAdjustmentRow{action: "refund", reason: "Compra duplicada"}
+	if msg := validateTokenAdjustmentRow(&row, ""); msg == "" {
+		t.Error("refund without transaction_id should fail")
+	}
+
+	row = tokenAdjustmentRow{user: "u", action: "grant", reason: "Premio especial"}
+	if msg := validateTokenAdjustmentRow(&row, "100001"); msg == "" {
+		t.Error("amount over the maximum should fail")
+	}
+	if msg := validateTokenAdjustmentRow(&row, "25"); msg != "" || row.amount != 25 {
+		t.Errorf("valid grant: msg = %q, amount = %d", msg, row.amount)
+	}
+}
+
+func TestRefundableTransactionTypes(t *testing.T) {
+	for _, transactionType := range []string{"admin_deduct", "tournament_entry"} {
+		if !refundableTransactionTypes[transactionType] {
+			t.Errorf("%s should be refundable", transactionType)
+		}
+	}
+	// Lo comprado y lo regalado no se devuelve: la devolución crearía tokens
+	for _, transactionType := range []string{"purchase", "gift_sent", "refund"} {
+		if refundableTransactionTypes[transactionType] {
+			t.Errorf("%s should not be refundable", transactionType)
+		}
+	}
+}
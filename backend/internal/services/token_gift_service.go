@@ -0,0 +1,165 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/smartstocks/backend/internal/config"
+	"github.com/smartstocks/backend/internal/models"
+	"github.com/smartstocks/backend/internal/repository"
+)
+
+type TokenGiftService struct {
+	giftRepo           *repository.TokenGiftRepository
+	userRepo           *repository.UserRepository
+	notificationRepo   *repository.NotificationRepository
+	accountLinkService *AccountLinkService
+	cfg                *config.TokenGiftsConfig
+}
+
+func NewTokenGiftService(
+	giftRepo *repository.TokenGiftRepository,
+	userRepo *repository.UserRepository,
+	notificationRepo *repository.NotificationRepository,
+	accountLinkService *AccountLinkService,
+	cfg *config.TokenGiftsConfig,
+) *TokenGiftService {
+	return &TokenGiftService{
+		giftRepo:           giftRepo,
+		userRepo:           userRepo,
+		notificationRepo:   notificationRepo,
+		accountLinkService: accountLinkService,
+		cfg:                cfg,
+	}
+}
+
+// SendGift regala tokens a otro usuario: debita al remitente, acredita al
+// destinatario y le deja una notificación, todo en una misma transacción.
+// idempotencyKey es la clave enviada por el cliente (opcional) para que un
+// reintento no regale dos veces.
+func (s *TokenGiftService) SendGift(senderID string, req *models.SendTokenGiftRequest, idempotencyKey string) (*models.TokenGift, error) {
+	if req.Amount > s.cfg.DailyTokenLimit {
+		return nil, errors.New("daily gift token limit exceeded")
+	}
+
+	sender, err := s.userRepo.GetUserByID(senderID)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+
+	found, err := s.userRepo.GetUserByUsername(strings.TrimSpace(req.RecipientUsername))
+	if err != nil {
+		return nil, fmt.Errorf("error getting recipient: %w", err)
+	}
+	if found == nil {
+		return nil, errors.New("recipient not found")
+	}
+	if found.ID == sender.ID {
+		return nil, errors.New("cannot gift tokens to yourself")
+	}
+	recipient, err := s.userRepo.GetUserByID(found.ID)
+	if err != nil {
+		return nil, errors.New("recipient not found")
+	}
+
+	now := time.Now()
+	if !accountOldEnough(sender.CreatedAt, s.cfg.MinAccountAgeDays, now) {
+		return nil, errors.New("account too new to send gifts")
+	}
+	if !accountOldEnough(recipient.CreatedAt, s.cfg.MinAccountAgeDays, now) {
+		return nil, errors.New("recipient account too new to receive gifts")
+	}
+
+	linked, err := s.accountLinkService.AreLinked(sender.ID, recipient.ID)
+	if err != nil {
+		return nil, err
+	}
+	if linked {
+		return nil, errors.New("transfers between linked accounts are not allowed")
+	}
+
+	gift := &models.TokenGift{
+		ID:                uuid.New().String(),
+		SenderID:          &sender.ID,
+		SenderUsername:    &sender.Username,
+		RecipientID:       &recipient.ID,
+		RecipientUsername: &recipient.Username,
+		Amount:            req.Amount,
+		Message:           optionalNotes(req.Message),
+		CreatedAt:         now,
+	}
+
+	notification := &models.Notification{
+		UserID:      recipient.ID,
+		Type:        models.NotificationTokenGift,
+		Title:       "¡Recibiste un regalo!",
+		Body:        giftNotificationBody(sender.Username, gift.Amount, gift.Message),
+		ReferenceID: &gift.ID,
+	}
+
+	key := tokenIdempotencyKey("gift", sender.ID, idempotencyKey, gift.ID)
+	err = s.giftRepo.CreateGift(gift, key, s.cfg.DailyTokenLimit, s.cfg.DailyGiftLimit, func(tx *sql.Tx) error {
+		return s.notificationRepo.CreateTx(tx, notification)
+	})
+	if errors.Is(err, repository.ErrDuplicateTransaction) {
+		// Un reintento con la misma clave devuelve el regalo original
+		if previous, lookupErr := s.giftRepo.GetGiftByKey(key); lookupErr == nil && previous != nil {
+			return previous, nil
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return gift, nil
+}
+
+// GetGifts lista los regalos enviados o recibidos por el usuario
+func (s *TokenGiftService) GetGifts(userID, direction string, limit int) ([]models.TokenGift, error) {
+	if direction != models.TokenGiftsSent && direction != models.TokenGiftsReceived {
+		return nil, errors.New("direction must be sent or received")
+	}
+	return s.giftRepo.GetGifts(userID, direction, limit)
+}
+
+// GetLimits informa cuánto puede regalar todavía el usuario
+func (s *TokenGiftService) GetLimits(userID string) (*models.TokenGiftLimits, error) {
+	user, err := s.userRepo.GetUserByID(userID)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+
+	giftsSent, tokensSent, err := s.giftRepo.GetDailyUsage(userID)
+	if err != nil {
+		return nil, fmt.Errorf("error getting gift usage: %w", err)
+	}
+
+	return &models.TokenGiftLimits{
+		DailyTokenLimit:   s.cfg.DailyTokenLimit,
+		DailyGiftLimit:    s.cfg.DailyGiftLimit,
+		TokensSent:        tokensSent,
+		GiftsSent:         giftsSent,
+		RemainingTokens:   max(s.cfg.DailyTokenLimit-tokensSent, 0),
+		RemainingGifts:    max(s.cfg.DailyGiftLimit-giftsSent, 0),
+		MinAccountAgeDays: s.cfg.MinAccountAgeDays,
+		Eligible:          accountOldEnough(user.CreatedAt, s.cfg.MinAccountAgeDays, time.Now()),
+	}, nil
+}
+
+// accountOldEnough indica si la cuenta tiene al menos minDays días
+func accountOldEnough(createdAt time.Time, minDays int, now time.Time) bool {
+	return !now.Before(createdAt.AddDate(0, 0, minDays))
+}
+
+// giftNotificationBody texto de la notificación al destinatario
+func giftNotificationBody(senderUsername string, amount int, message *string) string {
+	body := fmt.Sprintf("%s te regaló %d tokens.", senderUsername, amount)
+	if message != nil {
+		body += fmt.Sprintf(" Mensaje: \"%s\"", *message)
+	}
+	return body
+}
@@ -0,0 +1,31 @@
+package services
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAccountOldEnough(t *testing.T) {
+	createdAt := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+
+	if accountOldEnough(createdAt, 7, time.Date(2026, 3, 8, 9, 59, 0, 0, time.UTC)) {
+		t.Error("account a minute short of 7 days should not be old enough")
+	}
+	if !accountOldEnough(createdAt, 7, time.Date(2026, 3, 8, 10, 0, 0, 0, time.UTC)) {
+		t.Error("account of exactly 7 days should be old enough")
+	}
+	if !accountOldEnough(createdAt, 0, createdAt) {
+		t.Error("without a minimum age every account is old enough")
+	}
+}
+
+func TestGiftNotificationBody(t *testing.T) {
+	if got := giftNotificationBody("ana", 50, nil); got != "ana te regaló 50 tokens." {
+		t.Errorf("without message = %q", got)
+	}
+
+	message := "¡Suerte en el torneo!"
+	if got := giftNotificationBody("ana", 50, &message); got != `ana te regaló 50 tokens. Mensaje: "¡Suerte en el torneo!"` {
+		t.Errorf("with message = %q", got)
+	}
+}
@@ -1,12 +1,32 @@
 package services
 
 import (
+	"database/sql"
+	"encoding/base64"
+	"encoding/csv"
+	"errors"
 	"fmt"
+	"io"
+	"strings"
+	"time"
 
+	"github.com/google/uuid"
 	"github.com/smartstocks/backend/internal/models"
 	"github.com/smartstocks/backend/internal/repository"
 )
 
+const (
+	defaultTokenHistoryLimit = 50
+	maxTokenHistoryLimit     = 100
+
+	// defaultTokenSummaryWeeks semanas del resumen cuando no se indica rango
+	defaultTokenSummaryWeeks = 12
+	maxTokenSummaryWeeks     = 104
+
+	// tokenExportPageSize filas leídas por consulta al exportar
+	tokenExportPageSize = 500
+)
+
 type TokensService struct {
 	tokensRepo *repository.TokensRepository
 }
@@ -41,22 +61,30 @@ func (s *TokensService) GetUserTokens(userID string, limit int) (*models.TokensR
 	return response, nil
 }
 
-// GrantTokens otorga tokens a un usuario
-func (s *TokensService) GrantTokens(userID string, amount int, reason, description string) error {
+// GrantTokens otorga tokens a un usuario. Con una idempotencyKey ya usada no
+// se vuelve a acreditar y devuelve false.
+func (s *TokensService) GrantTokens(userID string, amount int, reason, description, idempotencyKey string) (bool, error) {
 	if amount <= 0 {
-		return fmt.Errorf("amount must be positive")
+		return false, fmt.Errorf("amount must be positive")
 	}
 
-	return s.tokensRepo.AddTokens(userID, amount, reason, description, nil)
+	return s.tokensRepo.AddTokens(userID, amount, reason, description, nil, idempotencyKey)
 }
 
 // SpendTokens gasta tokens de un usuario
-func (s *TokensService) SpendTokens(userID string, amount int, reason, description string, referenceID *string) error {
+func (s *TokensService) SpendTokens(userID string, amount int, reason, description string, referenceID *string, idempotencyKey string) error {
+	return s.SpendTokensWith(userID, amount, reason, description, referenceID, idempotencyKey, nil)
+}
+
+// SpendTokensWith gasta tokens y ejecuta apply en la misma transacción (ej.
+// entregar lo comprado). Si apply devuelve error no se cobra nada y se
+// devuelve ese error.
+func (s *TokensService) SpendTokensWith(userID string, amount int, reason, description string, referenceID *string, idempotencyKey string, apply func(tx *sql.Tx) error) error {
 	if amount <= 0 {
 		return fmt.Errorf("amount must be positive")
 	}
 
-	success, err := s.tokensRepo.SubtractTokens(userID, amount, reason, description, referenceID)
+	success, err := s.tokensRepo.SubtractTokensWith(userID, amount, reason, description, referenceID, idempotencyKey, apply)
 	if err != nil {
 		return err
 	}
@@ -68,7 +96,246 @@ func (s *TokensService) SpendTokens(userID string, amount int, reason, descripti
 	return nil
 }
 
-// GetTransactionHistory obtiene el historial de transacciones
-func (s *TokensService) GetTransactionHistory(userID string, limit int) ([]models.TokenTransaction, error) {
-	return s.tokensRepo.GetTransactionHistory(userID, limit)
+// GetTransactionByKey obtiene la transacción aplicada con la clave de
+// idempotencia (nil si no existe)
+func (s *TokensService) GetTransactionByKey(idempotencyKey string) (*models.TokenTransaction, error) {
+	return s.tokensRepo.GetTransactionByKey(idempotencyKey)
+}
+
+// === HISTORIAL ===
+
+// GetTransactionHistory obtiene una página del historial filtrado. La
+// siguiente página se pide con el NextCursor de la respuesta.
+func (s *TokensService) GetTransactionHistory(userID string, filter models.TokenHistoryFilter) (*models.TokenTransactionPage, error) {
+	if err := validateTokenTypes(filter.Types); err != nil {
+		return nil, err
+	}
+
+	var cursor *models.TokenHistoryCursor
+	if filter.Cursor != "" {
+		decoded, err := decodeTokenHistoryCursor(filter.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		cursor = decoded
+	}
+
+	limit := filter.Limit
+	if limit <= 0 || limit > maxTokenHistoryLimit {
+		limit = defaultTokenHistoryLimit
+	}
+
+	// Se pide una fila de más para saber si hay otra página
+	transactions, err := s.tokensRepo.GetTransactions(userID, filter, cursor, limit+1)
+	if err != nil {
+		return nil, fmt.Errorf("error getting transactions: %w", err)
+	}
+
+	page := &models.TokenTransactionPage{Transactions: transactions}
+	if len(transactions) > limit {
+		page.Transactions = transactions[:limit]
+		last := page.Transactions[limit-1]
+		next := encodeTokenHistoryCursor(models.TokenHistoryCursor{CreatedAt: last.CreatedAt, ID: last.ID})
+		page.NextCursor = &next
+	}
+
+	return page, nil
+}
+
+// GetHistorySummary resume lo ganado y gastado por semana. Sin rango toma
+// las últimas semanas hasta hoy; las semanas sin movimientos van en cero.
+func (s *TokensService) GetHistorySummary(userID string, filter models.TokenHistoryFilter) (*models.TokenHistorySummary, error) {
+	if err := validateTokenTypes(filter.Types); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	to := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location()).AddDate(0, 0, 1)
+	if filter.To != nil {
+		to = *filter.To
+	}
+	from := weekStart(to.AddDate(0, 0, -1)).AddDate(0, 0, -7*(defaultTokenSummaryWeeks-1))
+	if filter.From != nil {
+		from = *filter.From
+	}
+	if !from.Before(to) {
+		return nil, errors.New("from must be before to")
+	}
+	if to.Sub(weekStart(from)) > maxTokenSummaryWeeks*7*24*time.Hour {
+		return nil, fmt.Errorf("date range exceeds %d weeks", maxTokenSummaryWeeks)
+	}
+	filter.From, filter.To = &from, &to
+
+	weeks, err := s.tokensRepo.GetWeeklySummary(userID, filter)
+	if err != nil {
+		return nil, fmt.Errorf("error getting summary: %w", err)
+	}
+
+	summary := &models.TokenHistorySummary{
+		From:  from.Format("2006-01-02"),
+		To:    to.AddDate(0, 0, -1).Format("2006-01-02"),
+		Weeks: fillTokenWeeks(weeks, from, to),
+	}
+	for _, week := range summary.Weeks {
+		summary.Earned += week.Earned
+		summary.Spent += week.Spent
+	}
+	summary.Net = summary.Earned - summary.Spent
+
+	return summary, nil
+}
+
+// WriteHistoryCSV escribe todo el historial filtrado como CSV, de la
+// transacción más reciente a la más antigua
+func (s *TokensService) WriteHistoryCSV(w io.Writer, userID string, filter models.TokenHistoryFilter) error {
+	if err := validateTokenTypes(filter.Types); err != nil {
+		return err
+	}
+
+	out := &csvReportWriter{w: csv.NewWriter(w)}
+	if err := out.Begin("transactions", []string{
+		"date", "type", "amount", "balance_after", "description", "reference_id",
+	}); err != nil {
+		return err
+	}
+
+	var cursor *models.TokenHistoryCursor
+	for {
+		transactions, err := s.tokensRepo.GetTransactions(userID, filter, cursor, tokenExportPageSize)
+		if err != nil {
+			return err
+		}
+
+		for _, t := range transactions {
+			var referenceID interface{}
+			if t.ReferenceID != nil {
+				referenceID = *t.ReferenceID
+			}
+			if err := out.Row(t.CreatedAt, t.TransactionType, t.Amount, t.BalanceAfter, t.Description, referenceID); err != nil {
+				return err
+			}
+		}
+
+		if len(transactions) < tokenExportPageSize {
+			break
+		}
+		last := transactions[len(transactions)-1]
+		cursor = &models.TokenHistoryCursor{CreatedAt: last.CreatedAt, ID: last.ID}
+	}
+
+	return out.Close()
+}
+
+// validateTokenTypes verifica que los tipos del filtro existan
+func validateTokenTypes(types []string) error {
+	for _, t := range types {
+		valid := false
+		for _, known := range models.TokenTransactionTypes {
+			if t == known {
+				valid = true
+				break
+			}
+		}
+		if !valid {
+			return fmt.Errorf("invalid transaction type: %s", t)
+		}
+	}
+	return nil
+}
+
+// encodeTokenHistoryCursor serializa la posición en un cursor opaco
+func encodeTokenHistoryCursor(cursor models.TokenHistoryCursor) string {
+	raw := cursor.CreatedAt.UTC().Format(time.RFC3339Nano) + "|" + cursor.ID
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// decodeTokenHistoryCursor lee un cursor generado por encodeTokenHistoryCursor
+func decodeTokenHistoryCursor(value string) (*models.TokenHistoryCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, errors.New("invalid cursor")
+	}
+
+	createdAt, id, found := strings.Cut(string(raw), "|")
+	if !found || id == "" {
+		return nil, errors.New("invalid cursor")
+	}
+	t, err := time.Parse(time.RFC3339Nano, createdAt)
+	if err != nil {
+		return nil, errors.New("invalid cursor")
+	}
+
+	return &models.TokenHistoryCursor{CreatedAt: t.Local(), ID: id}, nil
+}
+
+// fillTokenWeeks completa con ceros las semanas sin movimientos entre from y
+// to (exclusivo)
+func fillTokenWeeks(weeks []models.TokenWeeklySummary, from, to time.Time) []models.TokenWeeklySummary {
+	byStart := make(map[string]models.TokenWeeklySummary, len(weeks))
+	for _, week := range weeks {
+		byStart[week.WeekStart] = week
+	}
+
+	filled := []models.TokenWeeklySummary{}
+	for start := weekStart(from); start.Before(to); start = start.AddDate(0, 0, 7) {
+		key := start.Format("2006-01-02")
+		week, ok := byStart[key]
+		if !ok {
+			week = models.TokenWeeklySummary{WeekStart: key}
+		}
+		filled = append(filled, week)
+	}
+
+	return filled
+}
+
+// === CONCILIACIÓN ===
+
+// Reconcile compara los saldos con el ledger y, con repair, los corrige.
+// Devuelve el reporte de la ejecución.
+func (s *TokensService) Reconcile(adminID string, repair bool) (*models.TokenReconciliationReport, error) {
+	runID := uuid.New().String()
+	var requestedBy *string
+	if adminID != "" {
+		requestedBy = &adminID
+	}
+
+	if err := s.tokensRepo.RunReconciliation(runID, models.ReconciliationTriggeredByAdmin, requestedBy, repair); err != nil {
+		return nil, fmt.Errorf("error reconciling balances: %w", err)
+	}
+
+	return s.GetReconciliation(runID)
+}
+
+// GetReconciliation obtiene el reporte de una ejecución
+func (s *TokensService) GetReconciliation(runID string) (*models.TokenReconciliationReport, error) {
+	run, err := s.tokensRepo.GetReconciliationRun(runID)
+	if err != nil {
+		return nil, fmt.Errorf("error getting reconciliation: %w", err)
+	}
+	if run == nil {
+		return nil, errors.New("reconciliation not found")
+	}
+
+	drifts, err := s.tokensRepo.GetBalanceDrifts(runID)
+	if err != nil {
+		return nil, fmt.Errorf("error getting balance drifts: %w", err)
+	}
+
+	return &models.TokenReconciliationReport{Run: *run, Drifts: drifts}, nil
+}
+
+// GetReconciliations obtiene las últimas ejecuciones (del job diario y manuales)
+func (s *TokensService) GetReconciliations(limit int) ([]models.TokenReconciliationRun, error) {
+	return s.tokensRepo.GetReconciliationRuns(limit)
+}
+
+// tokenIdempotencyKey clave de idempotencia de una operación: scope y usuario
+// más la clave enviada por el cliente (header Idempotency-Key) o, si no la
+// mandó, fallback
+func tokenIdempotencyKey(scope, userID, clientKey, fallback string) string {
+	if clientKey != "" {
+		return scope + ":" + userID + ":" + clientKey
+	}
+	return scope + ":" + userID + ":" + fallback
 }
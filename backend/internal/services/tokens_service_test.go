@@ -0,0 +1,72 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/smartstocks/backend/internal/models"
+)
+
+func TestTokenIdempotencyKey(t *testing.T) {
+	if got := tokenIdempotencyKey("shop_purchase", "user-1", "retry-abc", "inv-1"); got != "shop_purchase:user-1:retry-abc" {
+		t.Errorf("with client key = %q", got)
+	}
+	if got := tokenIdempotencyKey("shop_purchase", "user-1", "", "inv-1"); got != "shop_purchase:user-1:inv-1" {
+		t.Errorf("without client key = %q", got)
+	}
+	// La misma clave del cliente en otro usuario no choca
+	if tokenIdempotencyKey("streak_freeze", "user-1", "k", "") == tokenIdempotencyKey("streak_freeze", "user-2", "k", "") {
+		t.Error("keys of different users must differ")
+	}
+}
+
+func TestTokenHistoryCursor(t *testing.T) {
+	createdAt := time.Date(2026, 3, 10, 14, 30, 5, 0, time.Local)
+	encoded := encodeTokenHistoryCursor(models.TokenHistoryCursor{CreatedAt: createdAt, ID: "tx-1"})
+
+	cursor, err := decodeTokenHistoryCursor(encoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cursor.CreatedAt.Equal(createdAt) || cursor.ID != "tx-1" {
+		t.Errorf("decoded cursor = %+v", cursor)
+	}
+
+	for _, invalid := range []string{"not base64!", "c2luLXNlcGFyYWRvcg", "MjAyNi0wMy0xMHw"} {
+		if _, err := decodeTokenHistoryCursor(invalid); err == nil {
+			t.Errorf("%q should be rejected", invalid)
+		}
+	}
+}
+
+func TestValidateTokenTypes(t *testing.T) {
+	if err := validateTokenTypes([]string{"daily_bonus", "purchase"}); err != nil {
+		t.Errorf("known types: %v", err)
+	}
+	if err := validateTokenTypes([]string{"purchase", "gift"}); err == nil {
+		t.Error("unknown type should be rejected")
+	}
+}
+
+func TestFillTokenWeeks(t *testing.T) {
+	// Miércoles 4 de marzo al domingo 22 de marzo de 2026 (to exclusivo)
+	from := time.Date(2026, 3, 4, 0, 0, 0, 0, time.Local)
+	to := time.Date(2026, 3, 23, 0, 0, 0, 0, time.Local)
+
+	weeks := fillTokenWeeks([]models.TokenWeeklySummary{
+		{WeekStart: "2026-03-09", Earned: 100, Spent: 30, Net: 70, Transactions: 3},
+	}, from, to)
+
+	if len(weeks) != 3 {
+		t.Fatalf("len(weeks) = %d, want 3", len(weeks))
+	}
+	if weeks[0].WeekStart != "2026-03-02" || weeks[0].Transactions != 0 {
+		t.Errorf("first week = %+v, want an empty week starting on Monday 2026-03-02", weeks[0])
+	}
+	if weeks[1].WeekStart != "2026-03-09" || weeks[1].Net != 70 {
+		t.Errorf("second week = %+v", weeks[1])
+	}
+	if weeks[2].WeekStart != "2026-03-16" {
+		t.Errorf("last week = %+v", weeks[2])
+	}
+}
@@ -5,21 +5,25 @@ import (
 	"time"
 
 	"github.com/smartstocks/backend/internal/models"
+	"github.com/smartstocks/backend/internal/ranks"
 	"github.com/smartstocks/backend/internal/repository"
 )
 
 type TournamentsService struct {
 	tournamentsRepo *repository.TournamentsRepository
 	userRepo        *repository.UserRepository
+	tiers           *ranks.Table
 }
 
 func NewTournamentsService(
 	tournamentsRepo *repository.TournamentsRepository,
 	userRepo *repository.UserRepository,
+	tiers *ranks.Table,
 ) *TournamentsService {
 	return &TournamentsService{
 		tournamentsRepo: tournamentsRepo,
 		userRepo:        userRepo,
+		tiers:           tiers,
 	}
 }
 
@@ -247,7 +251,7 @@ func (s *TournamentsService) enrichTournamentDetails(tournament *models.Tourname
 	canRegister := !isRegistered &&
 		registrationOpen &&
 		tournament.CurrentParticipants < tournament.MaxParticipants &&
-		meetsRankRequirement(userRank, tournament.MinRankRequired)
+		s.meetsRankRequirement(userRank, tournament.MinRankRequired)
 
 	// Calcular tiempo hasta inicio
 	var timeUntilStart string
@@ -312,18 +316,16 @@ func (s *TournamentsService) enrichMatchWithUsers(match *models.TournamentMatch)
 	return enriched
 }
 
-func meetsRankRequirement(userRank, requiredRank string) bool {
-	rankValues := map[string]int{
-		"Bronze 1": 1, "Bronze 2": 2, "Bronze 3": 3,
-		"Plata 1": 4, "Plata 2": 5, "Plata 3": 6,
-		"Oro 1": 7, "Oro 2": 8, "Oro 3": 9,
-		"Maestro": 10,
+// meetsRankRequirement compara por posición en la tabla de rangos. Un
+// requisito que no está en la tabla no restringe, igual que
+// meets_rank_requirement en MySQL.
+func (s *TournamentsService) meetsRankRequirement(userRank, requiredRank string) bool {
+	required, ok := s.tiers.Lookup(requiredRank)
+	if !ok {
+		return true
 	}
-
-	userValue := rankValues[userRank]
-	requiredValue := rankValues[requiredRank]
-
-	return userValue >= requiredValue
+	current, _ := s.tiers.Lookup(userRank)
+	return current.AtLeast(required)
 }
 
 func getRoundName(roundNum, maxRound int) string {
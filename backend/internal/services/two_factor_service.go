@@ -0,0 +1,311 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/smartstocks/backend/internal/models"
+	"github.com/smartstocks/backend/internal/repository"
+	"github.com/smartstocks/backend/pkg/database"
+	"github.com/smartstocks/backend/pkg/totp"
+)
+
+const (
+	twoFactorIssuer         = "SmartStocks"
+	twoFactorChallengeTTL   = 5 * time.Minute
+	twoFactorMaxAttempts    = 5
+	twoFactorRecoveryCodes  = 10
+	twoFactorAllowedSkew    = 1
+	recoveryCodeAlphabet    = "abcdefghjkmnpqrstuvwxyz23456789"
+	recoveryCodeGroupLength = 5
+)
+
+// ErrInvalidTwoFactorCode código TOTP o de respaldo incorrecto; en el login
+// cuenta como intento fallido para el bloqueo de la cuenta
+var ErrInvalidTwoFactorCode = errors.New("invalid two-factor code")
+
+type TwoFactorService struct {
+	twoFactorRepo *repository.TwoFactorRepository
+	userRepo      *repository.UserRepository
+	redis         *database.RedisClient
+}
+
+func NewTwoFactorService(
+	twoFactorRepo *repository.TwoFactorRepository,
+	userRepo *repository.UserRepository,
+	redis *database.RedisClient,
+) *TwoFactorService {
+	return &TwoFactorService{
+		twoFactorRepo: twoFactorRepo,
+		userRepo:      userRepo,
+		redis:         redis,
+	}
+}
+
+// Enroll genera un secreto pendiente y la URI para la app autenticadora
+func (s *TwoFactorService) Enroll(userID string) (*models.TwoFactorEnrollResponse, error) {
+	existing, err := s.twoFactorRepo.GetByUserID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("error getting two-factor settings: %w", err)
+	}
+	if existing != nil && existing.Enabled {
+		return nil, errors.New("two-factor authentication is already enabled")
+	}
+
+	user, err := s.userRepo.GetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.twoFactorRepo.SavePendingSecret(userID, secret); err != nil {
+		return nil, fmt.Errorf("error saving secret: %w", err)
+	}
+
+	return &models.TwoFactorEnrollResponse{
+		Secret:          secret,
+		ProvisioningURI: totp.ProvisioningURI(secret, twoFactorIssuer, user.Email),
+	}, nil
+}
+
+// Confirm valida el primer código, habilita el 2FA y genera los códigos de respaldo
+func (s *TwoFactorService) Confirm(userID, code string) (*models.TwoFactorConfirmResponse, error) {
+	tf, err := s.twoFactorRepo.GetByUserID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("error getting two-factor settings: %w", err)
+	}
+	if tf == nil {
+		return nil, errors.New("two-factor enrollment not started")
+	}
+	if tf.Enabled {
+		return nil, errors.New("two-factor authentication is already enabled")
+	}
+
+	step, ok := totp.Validate(tf.Secret, code, time.Now(), twoFactorAllowedSkew)
+	if !ok {
+		return nil, errors.New("invalid code")
+	}
+
+	codes, hashes, err := generateRecoveryCodes(twoFactorRecoveryCodes)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.twoFactorRepo.Enable(userID, step, hashes); err != nil {
+		return nil, fmt.Errorf("error enabling two-factor: %w", err)
+	}
+
+	return &models.TwoFactorConfirmResponse{
+		Enabled:       true,
+		RecoveryCodes: codes,
+	}, nil
+}
+
+// Disable deshabilita el 2FA; requiere un código válido
+func (s *TwoFactorService) Disable(userID, code string) error {
+	if err := s.verifyUserCode(userID, code, ""); err != nil {
+		return err
+	}
+	return s.twoFactorRepo.Disable(userID)
+}
+
+// RegenerateRecoveryCodes invalida los códigos anteriores y genera nuevos
+func (s *TwoFactorService) RegenerateRecoveryCodes(userID, code string) (*models.TwoFactorConfirmResponse, error) {
+	tf, err := s.twoFactorRepo.GetByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if tf == nil || !tf.Enabled {
+		return nil, errors.New("two-factor authentication is not enabled")
+	}
+
+	step, ok := totp.Validate(tf.Secret, code, time.Now(), twoFactorAllowedSkew)
+	if !ok || step <= tf.LastUsedStep {
+		return nil, errors.New("invalid code")
+	}
+
+	codes, hashes, err := generateRecoveryCodes(twoFactorRecoveryCodes)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.twoFactorRepo.Enable(userID, step, hashes); err != nil {
+		return nil, fmt.Errorf("error regenerating recovery codes: %w", err)
+	}
+
+	return &models.TwoFactorConfirmResponse{
+		Enabled:       true,
+		RecoveryCodes: codes,
+	}, nil
+}
+
+// GetStatus obtiene el estado del 2FA del usuario
+func (s *TwoFactorService) GetStatus(userID string) (*models.TwoFactorStatusResponse, error) {
+	tf, err := s.twoFactorRepo.GetByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &models.TwoFactorStatusResponse{}
+	if tf != nil && tf.Enabled {
+		response.Enabled = true
+		response.RemainingRecoveryCodes, _ = s.twoFactorRepo.CountRemainingRecoveryCodes(userID)
+	}
+
+	return response, nil
+}
+
+// IsEnabled indica si el usuario tiene el 2FA activo
+func (s *TwoFactorService) IsEnabled(userID string) (bool, error) {
+	tf, err := s.twoFactorRepo.GetByUserID(userID)
+	if err != nil {
+		return false, err
+	}
+	return tf != nil && tf.Enabled, nil
+}
+
+// CreateChallenge crea un challenge de corta duración para el segundo paso del login
+func (s *TwoFactorService) CreateChallenge(userID string) (*models.TwoFactorChallenge, error) {
+	token := uuid.New().String()
+	if err := s.redis.Set(context.Background(), challengeKey(token), userID, twoFactorChallengeTTL); err != nil {
+		return nil, fmt.Errorf("error creating challenge: %w", err)
+	}
+
+	return &models.TwoFactorChallenge{
+		TwoFactorRequired: true,
+		ChallengeToken:    token,
+		ExpiresIn:         int(twoFactorChallengeTTL.Seconds()),
+	}, nil
+}
+
+// ChallengeUserID devuelve el usuario de un challenge vigente
+func (s *TwoFactorService) ChallengeUserID(token string) (string, error) {
+	userID, err := s.redis.Get(context.Background(), challengeKey(token))
+	if err != nil || userID == "" {
+		return "", errors.New("invalid or expired challenge")
+	}
+	return userID, nil
+}
+
+// VerifyChallenge valida el challenge con un código TOTP o de respaldo y
+// devuelve el usuario. El challenge se invalida tras el éxito o tras
+// demasiados intentos.
+func (s *TwoFactorService) VerifyChallenge(req *models.TwoFactorLoginRequest) (string, error) {
+	ctx := context.Background()
+	key := challengeKey(req.ChallengeToken)
+
+	userID, err := s.ChallengeUserID(req.ChallengeToken)
+	if err != nil {
+		return "", err
+	}
+
+	attempts, err := s.redis.Increment(ctx, challengeAttemptsKey(req.ChallengeToken))
+	if err == nil && attempts == 1 {
+		_ = s.redis.SetExpire(ctx, challengeAttemptsKey(req.ChallengeToken), twoFactorChallengeTTL)
+	}
+	if attempts > twoFactorMaxAttempts {
+		_ = s.redis.Delete(ctx, key, challengeAttemptsKey(req.ChallengeToken))
+		return "", errors.New("too many attempts, please log in again")
+	}
+
+	if err := s.verifyUserCode(userID, req.Code, req.RecoveryCode); err != nil {
+		return "", err
+	}
+
+	_ = s.redis.Delete(ctx, key, challengeAttemptsKey(req.ChallengeToken))
+	return userID, nil
+}
+
+// verifyUserCode valida un código TOTP (sin reuso) o consume un código de respaldo
+func (s *TwoFactorService) verifyUserCode(userID, code, recoveryCode string) error {
+	tf, err := s.twoFactorRepo.GetByUserID(userID)
+	if err != nil {
+		return err
+	}
+	if tf == nil || !tf.Enabled {
+		return errors.New("two-factor authentication is not enabled")
+	}
+
+	if recoveryCode != "" {
+		used, err := s.twoFactorRepo.UseRecoveryCode(userID, hashRecoveryCode(recoveryCode))
+		if err != nil {
+			return err
+		}
+		if !used {
+			return fmt.Errorf("%w: invalid recovery code", ErrInvalidTwoFactorCode)
+		}
+		return nil
+	}
+
+	step, ok := totp.Validate(tf.Secret, code, time.Now(), twoFactorAllowedSkew)
+	if !ok {
+		return ErrInvalidTwoFactorCode
+	}
+
+	fresh, err := s.twoFactorRepo.MarkStepUsed(userID, step)
+	if err != nil {
+		return err
+	}
+	if !fresh {
+		return fmt.Errorf("%w: code already used", ErrInvalidTwoFactorCode)
+	}
+
+	return nil
+}
+
+// === HELPERS ===
+
+func challengeKey(token string) string {
+	return "2fa:challenge:" + token
+}
+
+func challengeAttemptsKey(token string) string {
+	return "2fa:challenge:attempts:" + token
+}
+
+// generateRecoveryCodes genera códigos "xxxxx-xxxxx" y sus hashes SHA-256
+func generateRecoveryCodes(n int) ([]string, []string, error) {
+	codes := make([]string, 0, n)
+	hashes := make([]string, 0, n)
+
+	alphabetSize := big.NewInt(int64(len(recoveryCodeAlphabet)))
+
+	for i := 0; i < n; i++ {
+		var sb strings.Builder
+		for j := 0; j < recoveryCodeGroupLength*2; j++ {
+			if j == recoveryCodeGroupLength {
+				sb.WriteByte('-')
+			}
+			// rand.Int es uniforme; un módulo sobre bytes sesgaría el alfabeto
+			idx, err := rand.Int(rand.Reader, alphabetSize)
+			if err != nil {
+				return nil, nil, fmt.Errorf("error generating recovery codes: %w", err)
+			}
+			sb.WriteByte(recoveryCodeAlphabet[idx.Int64()])
+		}
+
+		code := sb.String()
+		codes = append(codes, code)
+		hashes = append(hashes, hashRecoveryCode(code))
+	}
+
+	return codes, hashes, nil
+}
+
+func hashRecoveryCode(code string) string {
+	normalized := strings.ToLower(strings.ReplaceAll(strings.TrimSpace(code), " ", ""))
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
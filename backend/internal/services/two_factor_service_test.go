@@ -0,0 +1,35 @@
+package services
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGenerateRecoveryCodes(t *testing.T) {
+	codes, hashes, err := generateRecoveryCodes(twoFactorRecoveryCodes)
+	if err != nil {
+		t.Fatalf("generateRecoveryCodes() error = %v", err)
+	}
+	if len(codes) != twoFactorRecoveryCodes || len(hashes) != twoFactorRecoveryCodes {
+		t.Fatalf("got %d codes and %d hashes, want %d", len(codes), len(hashes), twoFactorRecoveryCodes)
+	}
+
+	seen := map[string]bool{}
+	for i, code := range codes {
+		if len(code) != recoveryCodeGroupLength*2+1 || code[recoveryCodeGroupLength] != '-' {
+			t.Errorf("code %q has wrong format", code)
+		}
+		for _, r := range strings.ReplaceAll(code, "-", "") {
+			if !strings.ContainsRune(recoveryCodeAlphabet, r) {
+				t.Errorf("code %q contains %q outside the alphabet", code, r)
+			}
+		}
+		if hashes[i] != hashRecoveryCode(strings.ToUpper(" "+code+" ")) {
+			t.Errorf("hash of %q does not match its normalized form", code)
+		}
+		if seen[code] {
+			t.Errorf("duplicate code %q", code)
+		}
+		seen[code] = true
+	}
+}
@@ -2,6 +2,7 @@ package database
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"time"
@@ -10,6 +11,9 @@ import (
 	"github.com/smartstocks/backend/internal/config"
 )
 
+// replaceBatchSize miembros por comando al reemplazar sets y hashes completos
+const replaceBatchSize = 1000
+
 type RedisClient struct {
 	Client *redis.Client
 }
@@ -70,3 +74,108 @@ func (r *RedisClient) Increment(ctx context.Context, key string) (int64, error)
 func (r *RedisClient) SetExpire(ctx context.Context, key string, expiration time.Duration) error {
 	return r.Client.Expire(ctx, key, expiration).Err()
 }
+
+func (r *RedisClient) TTL(ctx context.Context, key string) (time.Duration, error) {
+	return r.Client.TTL(ctx, key).Result()
+}
+
+// === SORTED SETS ===
+
+// IsNil indica si el error es la respuesta vacía de Redis (clave o miembro inexistente)
+func IsNil(err error) bool {
+	return errors.Is(err, redis.Nil)
+}
+
+func (r *RedisClient) ZAdd(ctx context.Context, key string, score float64, member string) error {
+	return r.Client.ZAdd(ctx, key, redis.Z{Score: score, Member: member}).Err()
+}
+
+func (r *RedisClient) ZRem(ctx context.Context, key string, members ...string) error {
+	args := make([]interface{}, len(members))
+	for i, m := range members {
+		args[i] = m
+	}
+	return r.Client.ZRem(ctx, key, args...).Err()
+}
+
+// ZRevRank posición (base 0) del miembro ordenando de mayor a menor puntaje.
+// Devuelve redis.Nil si el miembro no está en el set.
+func (r *RedisClient) ZRevRank(ctx context.Context, key, member string) (int64, error) {
+	return r.Client.ZRevRank(ctx, key, member).Result()
+}
+
+// ZRevRange miembros entre las posiciones start y stop (base 0, inclusive)
+// ordenados de mayor a menor puntaje
+func (r *RedisClient) ZRevRange(ctx context.Context, key string, start, stop int64) ([]string, error) {
+	return r.Client.ZRevRange(ctx, key, start, stop).Result()
+}
+
+func (r *RedisClient) ZCard(ctx context.Context, key string) (int64, error) {
+	return r.Client.ZCard(ctx, key).Result()
+}
+
+// ReplaceSortedSet reemplaza el contenido del set (miembro -> puntaje) en una
+// transacción MULTI/EXEC: los lectores ven el set anterior o el nuevo, nunca
+// uno a medias
+func (r *RedisClient) ReplaceSortedSet(ctx context.Context, key string, members map[string]float64) error {
+	_, err := r.Client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(ctx, key)
+		batch := make([]redis.Z, 0, replaceBatchSize)
+		for member, score := range members {
+			batch = append(batch, redis.Z{Score: score, Member: member})
+			if len(batch) == replaceBatchSize {
+				pipe.ZAdd(ctx, key, batch...)
+				batch = make([]redis.Z, 0, replaceBatchSize)
+			}
+		}
+		if len(batch) > 0 {
+			pipe.ZAdd(ctx, key, batch...)
+		}
+		return nil
+	})
+	return err
+}
+
+// === HASHES ===
+
+func (r *RedisClient) HSet(ctx context.Context, key, field, value string) error {
+	return r.Client.HSet(ctx, key, field, value).Err()
+}
+
+func (r *RedisClient) HGet(ctx context.Context, key, field string) (string, error) {
+	return r.Client.HGet(ctx, key, field).Result()
+}
+
+func (r *RedisClient) HDel(ctx context.Context, key string, fields ...string) error {
+	return r.Client.HDel(ctx, key, fields...).Err()
+}
+
+// ReplaceHash reemplaza el contenido del hash en una transacción MULTI/EXEC
+func (r *RedisClient) ReplaceHash(ctx context.Context, key string, values map[string]string) error {
+	_, err := r.Client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(ctx, key)
+		batch := make([]interface{}, 0, 2*replaceBatchSize)
+		for field, value := range values {
+			batch = append(batch, field, value)
+			if len(batch) == cap(batch) {
+				pipe.HSet(ctx, key, batch...)
+				batch = make([]interface{}, 0, 2*replaceBatchSize)
+			}
+		}
+		if len(batch) > 0 {
+			pipe.HSet(ctx, key, batch...)
+		}
+		return nil
+	})
+	return err
+}
+
+// ScanKeys lista las claves que coinciden con el patrón usando SCAN (no bloquea Redis como KEYS)
+func (r *RedisClient) ScanKeys(ctx context.Context, pattern string) ([]string, error) {
+	var keys []string
+	iter := r.Client.Scan(ctx, 0, pattern, 500).Iterator()
+	for iter.Next(ctx) {
+		keys = append(keys, iter.Val())
+	}
+	return keys, iter.Err()
+}
@@ -2,12 +2,17 @@ package jwt
 
 import (
 	"errors"
+	"fmt"
+	"sort"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
 )
 
+// DefaultKeyID kid de la clave de NewJWTManager
+const DefaultKeyID = "default"
+
 type Claims struct {
 	UserID   string `json:"user_id"`
 	Email    string `json:"email"`
@@ -16,15 +21,54 @@ type Claims struct {
 }
 
 type JWTManager struct {
-	SecretKey       string
-	ExpirationHours int
+	ExpirationHours  int
+	signingKey       *Key
+	verificationKeys map[string]*Key
+	legacyKeyID      string // clave para tokens sin header "kid"
 }
 
+// NewJWTManager crea un manager HS256 con un único secreto
 func NewJWTManager(secret string, expirationHours int) *JWTManager {
+	key := NewHMACKey(DefaultKeyID, []byte(secret))
 	return &JWTManager{
-		SecretKey:       secret,
-		ExpirationHours: expirationHours,
+		ExpirationHours:  expirationHours,
+		signingKey:       key,
+		verificationKeys: map[string]*Key{key.ID: key},
+		legacyKeyID:      key.ID,
+	}
+}
+
+// NewJWTManagerWithKeys crea un manager que firma con signingKey y acepta
+// además tokens firmados por cualquiera de las claves de verificación.
+// legacyKeyID es la clave que verifica los tokens sin header "kid" (emitidos
+// antes de la rotación); vacío usa la clave de firma.
+func NewJWTManagerWithKeys(signingKey *Key, verificationKeys []*Key, legacyKeyID string, expirationHours int) (*JWTManager, error) {
+	if signingKey == nil || !signingKey.CanSign() {
+		return nil, errors.New("signing key with private material is required")
+	}
+
+	m := &JWTManager{
+		ExpirationHours:  expirationHours,
+		signingKey:       signingKey,
+		verificationKeys: map[string]*Key{signingKey.ID: signingKey},
+	}
+
+	for _, key := range verificationKeys {
+		if _, exists := m.verificationKeys[key.ID]; exists {
+			return nil, fmt.Errorf("duplicate key id: %s", key.ID)
+		}
+		m.verificationKeys[key.ID] = key
 	}
+
+	m.legacyKeyID = signingKey.ID
+	if legacyKeyID != "" {
+		if _, exists := m.verificationKeys[legacyKeyID]; !exists {
+			return nil, fmt.Errorf("unknown legacy key id: %s", legacyKeyID)
+		}
+		m.legacyKeyID = legacyKeyID
+	}
+
+	return m, nil
 }
 
 func (j *JWTManager) GenerateToken(userID, email, username string) (string, error) {
@@ -40,17 +84,13 @@ func (j *JWTManager) GenerateToken(userID, email, username string) (string, erro
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(j.SecretKey))
+	token := jwt.NewWithClaims(j.signingKey.Method, claims)
+	token.Header["kid"] = j.signingKey.ID
+	return token.SignedString(j.signingKey.private)
 }
 
 func (j *JWTManager) ValidateToken(tokenString string) (*Claims, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, errors.New("unexpected signing method")
-		}
-		return []byte(j.SecretKey), nil
-	})
+	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, j.keyFunc)
 
 	if err != nil {
 		return nil, err
@@ -63,6 +103,44 @@ func (j *JWTManager) ValidateToken(tokenString string) (*Claims, error) {
 	return nil, errors.New("invalid token")
 }
 
+// keyFunc selecciona la clave de verificación según el header "kid" y
+// rechaza tokens cuyo algoritmo no coincide con el de la clave
+func (j *JWTManager) keyFunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	if kid == "" {
+		kid = j.legacyKeyID
+	}
+
+	key, ok := j.verificationKeys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown key id: %s", kid)
+	}
+
+	if token.Method.Alg() != key.Method.Alg() {
+		return nil, errors.New("unexpected signing method")
+	}
+
+	return key.public, nil
+}
+
+// SigningKeyID devuelve el kid de la clave activa de firma
+func (j *JWTManager) SigningKeyID() string {
+	return j.signingKey.ID
+}
+
+// JWKS devuelve las claves públicas de verificación en formato JWK Set.
+// Las claves HMAC nunca se publican.
+func (j *JWTManager) JWKS() *JWKSet {
+	set := &JWKSet{Keys: []JWK{}}
+	for _, key := range j.verificationKeys {
+		if jwk, ok := key.JWK(); ok {
+			set.Keys = append(set.Keys, jwk)
+		}
+	}
+	sort.Slice(set.Keys, func(a, b int) bool { return set.Keys[a].Kid < set.Keys[b].Kid })
+	return set
+}
+
 func (j *JWTManager) GenerateRefreshToken() string {
 	return uuid.New().String()
 }
@@ -0,0 +1,136 @@
+package jwt
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func TestKeyRotation(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	oldKey := NewRSAKey("2025-01", rsaKey, nil)
+	oldManager, err := NewJWTManagerWithKeys(oldKey, nil, "", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	oldToken, err := oldManager.GenerateToken("user-1", "a@b.com", "alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// El manager nuevo firma con EdDSA pero sigue aceptando la clave RSA anterior
+	newManager, err := NewJWTManagerWithKeys(
+		NewEd25519Key("2025-06", edKey, nil),
+		[]*Key{NewRSAKey("2025-01", nil, &rsaKey.PublicKey)},
+		"",
+		1,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	claims, err := newManager.ValidateToken(oldToken)
+	if err != nil {
+		t.Fatalf("token signed with previous key rejected: %v", err)
+	}
+	if claims.UserID != "user-1" {
+		t.Errorf("expected user-1, got %s", claims.UserID)
+	}
+
+	newToken, err := newManager.GenerateToken("user-2", "c@d.com", "bob")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := newManager.ValidateToken(newToken); err != nil {
+		t.Fatalf("token signed with active key rejected: %v", err)
+	}
+	if _, err := oldManager.ValidateToken(newToken); err == nil {
+		t.Error("old manager accepted token signed with unknown kid")
+	}
+
+	jwks := newManager.JWKS()
+	if len(jwks.Keys) != 2 {
+		t.Fatalf("expected 2 public keys, got %d", len(jwks.Keys))
+	}
+	if jwks.Keys[0].Kty != "RSA" || jwks.Keys[1].Kty != "OKP" {
+		t.Errorf("unexpected JWKS keys: %+v", jwks.Keys)
+	}
+}
+
+func TestLegacyHMACTokenWithoutKid(t *testing.T) {
+	manager := NewJWTManager("secret", 1)
+
+	claims := &Claims{
+		UserID: "user-1",
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+		},
+	}
+	legacy, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := manager.ValidateToken(legacy); err != nil {
+		t.Fatalf("legacy token without kid rejected: %v", err)
+	}
+
+	if len(manager.JWKS().Keys) != 0 {
+		t.Error("HMAC secrets must not be published in JWKS")
+	}
+
+	// Tras rotar, el secreto anterior queda con otro kid y sigue verificando
+	// los tokens sin kid
+	rotated, err := NewJWTManagerWithKeys(
+		NewHMACKey("2025-06", []byte("new-secret")),
+		[]*Key{NewHMACKey("legacy", []byte("secret"))},
+		"legacy",
+		1,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := rotated.ValidateToken(legacy); err != nil {
+		t.Fatalf("legacy token without kid rejected after rotation: %v", err)
+	}
+
+	if _, err := NewJWTManagerWithKeys(NewHMACKey("2025-06", []byte("new-secret")), nil, "missing", 1); err == nil {
+		t.Error("unknown legacy key id accepted")
+	}
+}
+
+func TestRejectsAlgorithmMismatch(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	manager, err := NewJWTManagerWithKeys(NewRSAKey("rsa", rsaKey, nil), nil, "", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Un token HS256 con el kid de la clave RSA no debe aceptarse
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, &Claims{UserID: "attacker"})
+	token.Header["kid"] = "rsa"
+	forged, err := token.SignedString([]byte("anything"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := manager.ValidateToken(forged); err == nil {
+		t.Error("token with mismatched algorithm accepted")
+	}
+}
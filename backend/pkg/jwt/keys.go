@@ -0,0 +1,149 @@
+package jwt
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"fmt"
+	"math/big"
+	"os"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Algoritmos de firma soportados
+const (
+	AlgHS256 = "HS256"
+	AlgRS256 = "RS256"
+	AlgEdDSA = "EdDSA"
+)
+
+// Key representa una clave de firma/verificación identificada por kid
+type Key struct {
+	ID      string
+	Method  jwt.SigningMethod
+	private interface{}
+	public  interface{}
+}
+
+// JWK representa una clave pública en formato JSON Web Key (RFC 7517)
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+// JWKSet representa un conjunto de claves públicas
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// NewHMACKey crea una clave simétrica HS256
+func NewHMACKey(id string, secret []byte) *Key {
+	return &Key{
+		ID:      id,
+		Method:  jwt.SigningMethodHS256,
+		private: secret,
+		public:  secret,
+	}
+}
+
+// NewRSAKey crea una clave RS256; privateKey puede ser nil para claves solo de verificación
+func NewRSAKey(id string, privateKey *rsa.PrivateKey, publicKey *rsa.PublicKey) *Key {
+	key := &Key{ID: id, Method: jwt.SigningMethodRS256, public: publicKey}
+	if privateKey != nil {
+		key.private = privateKey
+		key.public = &privateKey.PublicKey
+	}
+	return key
+}
+
+// NewEd25519Key crea una clave EdDSA; privateKey puede ser nil para claves solo de verificación
+func NewEd25519Key(id string, privateKey ed25519.PrivateKey, publicKey ed25519.PublicKey) *Key {
+	key := &Key{ID: id, Method: jwt.SigningMethodEdDSA, public: publicKey}
+	if privateKey != nil {
+		key.private = privateKey
+		key.public = privateKey.Public()
+	}
+	return key
+}
+
+// CanSign indica si la clave tiene material privado para firmar
+func (k *Key) CanSign() bool {
+	return k.private != nil
+}
+
+// JWK devuelve la representación pública de la clave. Devuelve false para
+// claves HMAC, que no deben publicarse.
+func (k *Key) JWK() (JWK, bool) {
+	switch pub := k.public.(type) {
+	case *rsa.PublicKey:
+		return JWK{
+			Kty: "RSA",
+			Kid: k.ID,
+			Use: "sig",
+			Alg: AlgRS256,
+			N:   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+		}, true
+	case ed25519.PublicKey:
+		return JWK{
+			Kty: "OKP",
+			Kid: k.ID,
+			Use: "sig",
+			Alg: AlgEdDSA,
+			Crv: "Ed25519",
+			X:   base64.RawURLEncoding.EncodeToString(pub),
+		}, true
+	default:
+		return JWK{}, false
+	}
+}
+
+// LoadPrivateKeyFile carga una clave privada PEM (RSA o Ed25519) para el algoritmo indicado
+func LoadPrivateKeyFile(id, algorithm, path string) (*Key, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading private key %s: %w", path, err)
+	}
+
+	switch algorithm {
+	case AlgRS256:
+		privateKey, err := jwt.ParseRSAPrivateKeyFromPEM(data)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing RSA private key %s: %w", path, err)
+		}
+		return NewRSAKey(id, privateKey, nil), nil
+	case AlgEdDSA:
+		privateKey, err := jwt.ParseEdPrivateKeyFromPEM(data)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing Ed25519 private key %s: %w", path, err)
+		}
+		return NewEd25519Key(id, privateKey.(ed25519.PrivateKey), nil), nil
+	default:
+		return nil, fmt.Errorf("unsupported algorithm for key file: %s", algorithm)
+	}
+}
+
+// LoadPublicKeyFile carga una clave pública PEM detectando si es RSA o Ed25519
+func LoadPublicKeyFile(id, path string) (*Key, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading public key %s: %w", path, err)
+	}
+
+	if publicKey, err := jwt.ParseRSAPublicKeyFromPEM(data); err == nil {
+		return NewRSAKey(id, nil, publicKey), nil
+	}
+
+	if publicKey, err := jwt.ParseEdPublicKeyFromPEM(data); err == nil {
+		return NewEd25519Key(id, nil, publicKey.(ed25519.PublicKey)), nil
+	}
+
+	return nil, fmt.Errorf("unsupported public key format: %s", path)
+}
@@ -0,0 +1,136 @@
+package oidc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	gooidc "github.com/coreos/go-oidc/v3/oidc"
+	"golang.org/x/oauth2"
+)
+
+// Identity datos verificados del usuario extraídos del ID token
+type Identity struct {
+	Provider      string
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+}
+
+// ProviderOptions datos de registro del cliente en un proveedor
+type ProviderOptions struct {
+	Name         string
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+}
+
+// Client implementa authorization code + PKCE contra un proveedor OIDC.
+// El discovery se hace en el primer uso para no bloquear el arranque si el
+// proveedor no está disponible.
+type Client struct {
+	cfg ProviderOptions
+
+	mu       sync.Mutex
+	oauth2   *oauth2.Config
+	verifier *gooidc.IDTokenVerifier
+}
+
+func NewClient(cfg ProviderOptions) *Client {
+	return &Client{cfg: cfg}
+}
+
+// Name nombre del proveedor
+func (c *Client) Name() string {
+	return c.cfg.Name
+}
+
+// AuthCodeURL arma la URL de autorización con state, nonce y challenge PKCE S256
+func (c *Client) AuthCodeURL(ctx context.Context, state, nonce, codeVerifier string) (string, error) {
+	if err := c.discover(ctx); err != nil {
+		return "", err
+	}
+
+	return c.oauth2.AuthCodeURL(state,
+		gooidc.Nonce(nonce),
+		oauth2.S256ChallengeOption(codeVerifier),
+	), nil
+}
+
+// Exchange canjea el código, verifica el ID token (firma, audiencia, nonce)
+// y devuelve la identidad del usuario
+func (c *Client) Exchange(ctx context.Context, code, codeVerifier, nonce string) (*Identity, error) {
+	if err := c.discover(ctx); err != nil {
+		return nil, err
+	}
+
+	token, err := c.oauth2.Exchange(ctx, code, oauth2.VerifierOption(codeVerifier))
+	if err != nil {
+		return nil, fmt.Errorf("error exchanging authorization code: %w", err)
+	}
+
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok || rawIDToken == "" {
+		return nil, errors.New("id_token missing from token response")
+	}
+
+	idToken, err := c.verifier.Verify(ctx, rawIDToken)
+	if err != nil {
+		return nil, fmt.Errorf("invalid id_token: %w", err)
+	}
+
+	if idToken.Nonce != nonce {
+		return nil, errors.New("id_token nonce mismatch")
+	}
+
+	var claims struct {
+		Email         string `json:"email"`
+		EmailVerified *bool  `json:"email_verified"`
+		Name          string `json:"name"`
+	}
+	if err := idToken.Claims(&claims); err != nil {
+		return nil, fmt.Errorf("error parsing id_token claims: %w", err)
+	}
+
+	return &Identity{
+		Provider:      c.cfg.Name,
+		Subject:       idToken.Subject,
+		Email:         claims.Email,
+		EmailVerified: claims.EmailVerified != nil && *claims.EmailVerified,
+		Name:          claims.Name,
+	}, nil
+}
+
+// GenerateVerifier genera un code_verifier PKCE
+func GenerateVerifier() string {
+	return oauth2.GenerateVerifier()
+}
+
+func (c *Client) discover(ctx context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.oauth2 != nil {
+		return nil
+	}
+
+	provider, err := gooidc.NewProvider(ctx, c.cfg.Issuer)
+	if err != nil {
+		return fmt.Errorf("error discovering OIDC provider %s: %w", c.cfg.Name, err)
+	}
+
+	c.oauth2 = &oauth2.Config{
+		ClientID:     c.cfg.ClientID,
+		ClientSecret: c.cfg.ClientSecret,
+		RedirectURL:  c.cfg.RedirectURL,
+		Endpoint:     provider.Endpoint(),
+		Scopes:       c.cfg.Scopes,
+	}
+	c.verifier = provider.Verifier(&gooidc.Config{ClientID: c.cfg.ClientID})
+
+	return nil
+}
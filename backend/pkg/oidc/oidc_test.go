@@ -0,0 +1,138 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	jwtlib "github.com/golang-jwt/jwt/v5"
+	"github.com/smartstocks/backend/pkg/jwt"
+)
+
+// mockProvider es un proveedor OIDC mínimo: discovery, JWKS y token endpoint
+type mockProvider struct {
+	server        *httptest.Server
+	key           *rsa.PrivateKey
+	codeChallenge string
+	nonce         string
+	emailVerified bool
+}
+
+func newMockProvider(t *testing.T) *mockProvider {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m := &mockProvider{key: key, emailVerified: true}
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"issuer":                                m.server.URL,
+			"authorization_endpoint":                m.server.URL + "/authorize",
+			"token_endpoint":                        m.server.URL + "/token",
+			"jwks_uri":                              m.server.URL + "/jwks",
+			"id_token_signing_alg_values_supported": []string{"RS256"},
+		})
+	})
+
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		jwk, _ := jwt.NewRSAKey("mock", nil, &m.key.PublicKey).JWK()
+		json.NewEncoder(w).Encode(jwt.JWKSet{Keys: []jwt.JWK{jwk}})
+	})
+
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+
+		// Validar PKCE: BASE64URL(SHA256(code_verifier)) == code_challenge
+		sum := sha256.Sum256([]byte(r.Form.Get("code_verifier")))
+		if base64.RawURLEncoding.EncodeToString(sum[:]) != m.codeChallenge || r.Form.Get("code") != "valid-code" {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+			return
+		}
+
+		idToken := jwtlib.NewWithClaims(jwtlib.SigningMethodRS256, jwtlib.MapClaims{
+			"iss":            m.server.URL,
+			"aud":            "smartstocks",
+			"sub":            "subject-123",
+			"exp":            time.Now().Add(time.Hour).Unix(),
+			"iat":            time.Now().Unix(),
+			"nonce":          m.nonce,
+			"email":          "ana@colegio.edu.ar",
+			"email_verified": m.emailVerified,
+			"name":           "Ana",
+		})
+		idToken.Header["kid"] = "mock"
+		signed, _ := idToken.SignedString(m.key)
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"access_token": "access",
+			"token_type":   "Bearer",
+			"expires_in":   3600,
+			"id_token":     signed,
+		})
+	})
+
+	m.server = httptest.NewServer(mux)
+	t.Cleanup(m.server.Close)
+	return m
+}
+
+func TestAuthorizationCodeWithPKCE(t *testing.T) {
+	mock := newMockProvider(t)
+	client := NewClient(ProviderOptions{
+		Name:        "mock",
+		Issuer:      mock.server.URL,
+		ClientID:    "smartstocks",
+		RedirectURL: "http://localhost:3000/auth/callback",
+		Scopes:      []string{"openid", "email", "profile"},
+	})
+
+	ctx := context.Background()
+	verifier := GenerateVerifier()
+
+	authURL, err := client.AuthCodeURL(ctx, "state-1", "nonce-1", verifier)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parsed, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	query := parsed.Query()
+	if query.Get("code_challenge_method") != "S256" || query.Get("state") != "state-1" || query.Get("nonce") != "nonce-1" {
+		t.Fatalf("unexpected authorization URL: %s", authURL)
+	}
+	mock.codeChallenge = query.Get("code_challenge")
+	mock.nonce = "nonce-1"
+
+	identity, err := client.Exchange(ctx, "valid-code", verifier, "nonce-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if identity.Subject != "subject-123" || identity.Email != "ana@colegio.edu.ar" || !identity.EmailVerified {
+		t.Errorf("unexpected identity: %+v", identity)
+	}
+
+	// Un verifier distinto debe ser rechazado por el proveedor
+	if _, err := client.Exchange(ctx, "valid-code", GenerateVerifier(), "nonce-1"); err == nil {
+		t.Error("exchange with wrong code_verifier should fail")
+	}
+
+	// Un nonce distinto debe ser rechazado localmente
+	if _, err := client.Exchange(ctx, "valid-code", verifier, "other-nonce"); err == nil {
+		t.Error("exchange with mismatched nonce should fail")
+	}
+}
@@ -0,0 +1,285 @@
+// Package xlsx escribe planillas Office Open XML (.xlsx) en streaming.
+//
+// Las filas se comprimen y se envían al io.Writer a medida que se agregan,
+// sin armar la planilla en memoria, por lo que sirve para exportar reportes
+// grandes directamente en la respuesta HTTP. Las hojas se escriben una a la
+// vez: al abrir una nueva se cierra la anterior.
+package xlsx
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Límites de Excel
+const (
+	MaxRows           = 1048576
+	MaxColumns        = 16384
+	maxSheetNameChars = 31
+)
+
+// TimeLayout formato con el que se escriben los time.Time
+const TimeLayout = "2006-01-02 15:04:05"
+
+var (
+	ErrClosed        = errors.New("xlsx: writer is closed")
+	ErrNoSheet       = errors.New("xlsx: no sheet open")
+	ErrTooManyRows   = errors.New("xlsx: sheet row limit exceeded")
+	ErrTooManyCols   = errors.New("xlsx: row column limit exceeded")
+	ErrDuplicateName = errors.New("xlsx: duplicate sheet name")
+)
+
+// Writer escribe un libro .xlsx en streaming
+type Writer struct {
+	zw     *zip.Writer
+	sheet  *bufio.Writer
+	sheets []string
+	rows   int
+	closed bool
+}
+
+// NewWriter crea un libro que se escribe en w. Close debe llamarse para
+// completar el archivo.
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{zw: zip.NewWriter(w)}
+}
+
+// NewSheet cierra la hoja actual y abre una nueva. Los caracteres no
+// permitidos en el nombre se reemplazan y se trunca a 31 caracteres.
+func (w *Writer) NewSheet(name string) error {
+	if w.closed {
+		return ErrClosed
+	}
+	name = sheetName(name)
+	for _, existing := range w.sheets {
+		if strings.EqualFold(existing, name) {
+			return ErrDuplicateName
+		}
+	}
+	if err := w.endSheet(); err != nil {
+		return err
+	}
+
+	f, err := w.zw.Create(fmt.Sprintf("xl/worksheets/sheet%d.xml", len(w.sheets)+1))
+	if err != nil {
+		return err
+	}
+	w.sheets = append(w.sheets, name)
+	w.sheet = bufio.NewWriter(f)
+	w.rows = 0
+
+	_, err = w.sheet.WriteString(xml.Header +
+		`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+	return err
+}
+
+// WriteRow agrega una fila a la hoja actual. Los enteros y decimales se
+// escriben como números, los bool como booleanos, time.Time con TimeLayout,
+// nil como celda vacía y el resto como texto.
+func (w *Writer) WriteRow(values ...interface{}) error {
+	if w.closed {
+		return ErrClosed
+	}
+	if w.sheet == nil {
+		return ErrNoSheet
+	}
+	if w.rows >= MaxRows {
+		return ErrTooManyRows
+	}
+	if len(values) > MaxColumns {
+		return ErrTooManyCols
+	}
+	w.rows++
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<row r="%d">`, w.rows)
+	for i, v := range values {
+		writeCell(&b, ColumnName(i)+strconv.Itoa(w.rows), v)
+	}
+	b.WriteString(`</row>`)
+
+	_, err := w.sheet.WriteString(b.String())
+	return err
+}
+
+// Flush envía al writer subyacente lo escrito hasta el momento
+func (w *Writer) Flush() error {
+	if w.sheet != nil {
+		if err := w.sheet.Flush(); err != nil {
+			return err
+		}
+	}
+	return w.zw.Flush()
+}
+
+// Close cierra la hoja actual y escribe el índice del libro. Un libro sin
+// hojas recibe una hoja vacía porque Excel no abre libros vacíos.
+func (w *Writer) Close() error {
+	if w.closed {
+		return ErrClosed
+	}
+	if len(w.sheets) == 0 {
+		if err := w.NewSheet("Sheet1"); err != nil {
+			return err
+		}
+	}
+	if err := w.endSheet(); err != nil {
+		return err
+	}
+	w.closed = true
+
+	parts := []struct {
+		name    string
+		content string
+	}{
+		{"[Content_Types].xml", w.contentTypes()},
+		{"_rels/.rels", rootRels},
+		{"xl/workbook.xml", w.workbook()},
+		{"xl/_rels/workbook.xml.rels", w.workbookRels()},
+		{"xl/styles.xml", styles},
+	}
+	for _, part := range parts {
+		f, err := w.zw.Create(part.name)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(f, part.content); err != nil {
+			return err
+		}
+	}
+
+	return w.zw.Close()
+}
+
+func (w *Writer) endSheet() error {
+	if w.sheet == nil {
+		return nil
+	}
+	if _, err := w.sheet.WriteString(`</sheetData></worksheet>`); err != nil {
+		return err
+	}
+	err := w.sheet.Flush()
+	w.sheet = nil
+	return err
+}
+
+// ColumnName convierte un índice (desde 0) en la letra de columna: 0 -> A, 26 -> AA
+func ColumnName(index int) string {
+	name := ""
+	for index >= 0 {
+		name = string(rune('A'+index%26)) + name
+		index = index/26 - 1
+	}
+	return name
+}
+
+func writeCell(b *strings.Builder, ref string, v interface{}) {
+	switch val := v.(type) {
+	case nil:
+		return
+	case int:
+		fmt.Fprintf(b, `<c r="%s"><v>%d</v></c>`, ref, val)
+	case int64:
+		fmt.Fprintf(b, `<c r="%s"><v>%d</v></c>`, ref, val)
+	case float64:
+		fmt.Fprintf(b, `<c r="%s"><v>%s</v></c>`, ref, strconv.FormatFloat(val, 'f', -1, 64))
+	case bool:
+		n := 0
+		if val {
+			n = 1
+		}
+		fmt.Fprintf(b, `<c r="%s" t="b"><v>%d</v></c>`, ref, n)
+	case time.Time:
+		writeString(b, ref, val.Format(TimeLayout))
+	case string:
+		writeString(b, ref, val)
+	default:
+		writeString(b, ref, fmt.Sprint(val))
+	}
+}
+
+func writeString(b *strings.Builder, ref, s string) {
+	fmt.Fprintf(b, `<c r="%s" t="inlineStr"><is><t xml:space="preserve">`, ref)
+	// EscapeText reemplaza los caracteres inválidos en XML por U+FFFD
+	_ = xml.EscapeText(b, []byte(s))
+	b.WriteString(`</t></is></c>`)
+}
+
+// sheetName adapta el nombre a las reglas de Excel
+func sheetName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`[]:*?/\`, r) {
+			return '_'
+		}
+		return r
+	}, strings.TrimSpace(name))
+	name = strings.Trim(name, "'")
+	if runes := []rune(name); len(runes) > maxSheetNameChars {
+		name = string(runes[:maxSheetNameChars])
+	}
+	if name == "" {
+		name = "Sheet"
+	}
+	return name
+}
+
+func (w *Writer) contentTypes() string {
+	var b strings.Builder
+	b.WriteString(xml.Header)
+	b.WriteString(`<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">`)
+	b.WriteString(`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>`)
+	b.WriteString(`<Default Extension="xml" ContentType="application/xml"/>`)
+	b.WriteString(`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>`)
+	b.WriteString(`<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>`)
+	for i := range w.sheets {
+		fmt.Fprintf(&b, `<Override PartName="/xl/worksheets/sheet%d.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>`, i+1)
+	}
+	b.WriteString(`</Types>`)
+	return b.String()
+}
+
+func (w *Writer) workbook() string {
+	var b strings.Builder
+	b.WriteString(xml.Header)
+	b.WriteString(`<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets>`)
+	for i, name := range w.sheets {
+		b.WriteString(`<sheet name="`)
+		_ = xml.EscapeText(&b, []byte(name))
+		fmt.Fprintf(&b, `" sheetId="%d" r:id="rId%d"/>`, i+1, i+1)
+	}
+	b.WriteString(`</sheets></workbook>`)
+	return b.String()
+}
+
+func (w *Writer) workbookRels() string {
+	var b strings.Builder
+	b.WriteString(xml.Header)
+	b.WriteString(`<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">`)
+	for i := range w.sheets {
+		fmt.Fprintf(&b, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet%d.xml"/>`, i+1, i+1)
+	}
+	fmt.Fprintf(&b, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>`, len(w.sheets)+1)
+	b.WriteString(`</Relationships>`)
+	return b.String()
+}
+
+const rootRels = xml.Header +
+	`<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+	`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+	`</Relationships>`
+
+const styles = xml.Header +
+	`<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+	`<fonts count="1"><font><sz val="11"/><name val="Calibri"/></font></fonts>` +
+	`<fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills>` +
+	`<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>` +
+	`<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>` +
+	`<cellXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/></cellXfs>` +
+	`</styleSheet>`
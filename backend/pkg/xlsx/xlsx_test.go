@@ -0,0 +1,136 @@
+package xlsx
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func readPart(t *testing.T, zr *zip.Reader, name string) string {
+	t.Helper()
+	for _, f := range zr.File {
+		if f.Name == name {
+			rc, err := f.Open()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer rc.Close()
+			data, err := io.ReadAll(rc)
+			if err != nil {
+				t.Fatal(err)
+			}
+			return string(data)
+		}
+	}
+	t.Fatalf("part %s not found", name)
+	return ""
+}
+
+func TestWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+
+	if err := w.WriteRow("x"); err != ErrNoSheet {
+		t.Errorf("WriteRow without sheet = %v", err)
+	}
+	if err := w.NewSheet("Quizzes"); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.WriteRow("alumno", "puntaje", "aprobado", "fecha", nil, 0.5); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.WriteRow("Ana <&> \"Pérez\"", 95, true, time.Date(2026, 3, 2, 10, 30, 0, 0, time.UTC), nil, int64(7)); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.NewSheet("quizzes"); err != ErrDuplicateName {
+		t.Errorf("duplicate sheet name = %v", err)
+	}
+	if err := w.NewSheet("PvP: a/b [2026]"); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.WriteRow("x"); err != ErrClosed {
+		t.Errorf("WriteRow after close = %v", err)
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Todas las partes deben ser XML válido
+	for _, f := range zr.File {
+		content := readPart(t, zr, f.Name)
+		dec := xml.NewDecoder(strings.NewReader(content))
+		for {
+			if _, err := dec.Token(); err == io.EOF {
+				break
+			} else if err != nil {
+				t.Fatalf("%s: invalid xml: %v", f.Name, err)
+			}
+		}
+	}
+
+	sheet := readPart(t, zr, "xl/worksheets/sheet1.xml")
+	for _, want := range []string{
+		`<c r="A2" t="inlineStr"><is><t xml:space="preserve">Ana &lt;&amp;&gt; &#34;Pérez&#34;</t></is></c>`,
+		`<c r="B2"><v>95</v></c>`,
+		`<c r="C2" t="b"><v>1</v></c>`,
+		`2026-03-02 10:30:00`,
+		`<c r="F1"><v>0.5</v></c>`,
+		`<c r="F2"><v>7</v></c>`,
+	} {
+		if !strings.Contains(sheet, want) {
+			t.Errorf("sheet1 missing %s", want)
+		}
+	}
+	if strings.Contains(sheet, `r="E1"`) {
+		t.Error("nil cells should be omitted")
+	}
+
+	workbook := readPart(t, zr, "xl/workbook.xml")
+	if !strings.Contains(workbook, `name="Quizzes"`) || !strings.Contains(workbook, `name="PvP_ a_b _2026_"`) {
+		t.Errorf("workbook = %s", workbook)
+	}
+	if !strings.Contains(readPart(t, zr, "[Content_Types].xml"), "/xl/worksheets/sheet2.xml") {
+		t.Error("content types missing sheet2")
+	}
+}
+
+func TestEmptyWorkbook(t *testing.T) {
+	var buf bytes.Buffer
+	if err := NewWriter(&buf).Close(); err != nil {
+		t.Fatal(err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(readPart(t, zr, "xl/workbook.xml"), `name="Sheet1"`) {
+		t.Error("empty workbook should get a default sheet")
+	}
+}
+
+func TestColumnName(t *testing.T) {
+	tests := map[int]string{0: "A", 25: "Z", 26: "AA", 51: "AZ", 52: "BA", 701: "ZZ", 702: "AAA", MaxColumns - 1: "XFD"}
+	for index, want := range tests {
+		if got := ColumnName(index); got != want {
+			t.Errorf("ColumnName(%d) = %q, want %q", index, got, want)
+		}
+	}
+}
+
+func TestSheetName(t *testing.T) {
+	if got := sheetName("  "); got != "Sheet" {
+		t.Errorf("empty name = %q", got)
+	}
+	if got := sheetName(strings.Repeat("á", 40)); len([]rune(got)) != 31 {
+		t.Errorf("long name = %q", got)
+	}
+}
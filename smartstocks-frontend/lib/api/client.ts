@@ -4,6 +4,8 @@ const API_URL = process.env.NEXT_PUBLIC_API_URL || 'http://localhost:8081'
 
 export const apiClient = axios.create({
   baseURL: `${API_URL}/api/v1`,
+  // Send the device cookie the backend uses to detect linked accounts
+  withCredentials: true,
   headers: {
     'Content-Type': 'application/json',
   },